@@ -0,0 +1,260 @@
+package activity
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type ActivityService interface {
+	events.EventSubscriber
+	// ListActivities returns the activities older than the cursor, newest first. A cursor of 0 starts from the newest activity.
+	ListActivities(cursor uint, limit uint64, types []string) (activities []db.Activity, nextCursor *uint, err error)
+	// ListNotifications returns the latest unread notifications and the number of all unread notifications
+	ListNotifications(limit uint64) (notifications []db.Activity, unreadCount int64, err error)
+	// MarkNotificationsRead marks the notifications with the ids as read, or all notifications if no ids are given
+	MarkNotificationsRead(ids []uint) error
+}
+
+type activityService struct {
+	db *gorm.DB
+}
+
+func NewActivityService(db *gorm.DB) *activityService {
+	return &activityService{
+		db: db,
+	}
+}
+
+func (svc *activityService) ConsumeEvent(ctx context.Context, event *events.Event, globalProperties map[string]interface{}) {
+	activity := toActivity(event)
+	if activity == nil {
+		return
+	}
+	activity.Event = event.Event
+	// failures need the attention of the owner of the hub, as well as approval requests
+	activity.Notify = activity.Notify || activity.Type == constants.ACTIVITY_TYPE_FAILURE
+
+	err := svc.db.Create(activity).Error
+	if err != nil {
+		logger.Logger.WithError(err).WithField("event", event.Event).Error("Failed to save activity")
+	}
+}
+
+func (svc *activityService) ListActivities(cursor uint, limit uint64, types []string) ([]db.Activity, *uint, error) {
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	tx := svc.db.Order("id desc")
+	if cursor > 0 {
+		tx = tx.Where("id < ?", cursor)
+	}
+	if len(types) > 0 {
+		tx = tx.Where("type IN ?", types)
+	}
+
+	// load one more activity to know if there is a next page
+	activities := []db.Activity{}
+	err := tx.Limit(int(limit) + 1).Find(&activities).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list activities")
+		return nil, nil, err
+	}
+
+	var nextCursor *uint
+	if len(activities) > int(limit) {
+		activities = activities[:limit]
+		nextCursor = &activities[limit-1].ID
+	}
+	return activities, nextCursor, nil
+}
+
+func (svc *activityService) ListNotifications(limit uint64) ([]db.Activity, int64, error) {
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	limit = min(limit, maxListLimit)
+
+	var unreadCount int64
+	err := svc.unreadNotifications().Count(&unreadCount).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to count notifications")
+		return nil, 0, err
+	}
+
+	notifications := []db.Activity{}
+	err = svc.unreadNotifications().Order("id desc").Limit(int(limit)).Find(&notifications).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list notifications")
+		return nil, 0, err
+	}
+	return notifications, unreadCount, nil
+}
+
+func (svc *activityService) MarkNotificationsRead(ids []uint) error {
+	tx := svc.unreadNotifications()
+	if len(ids) > 0 {
+		tx = tx.Where("id IN ?", ids)
+	}
+	err := tx.Update("read_at", time.Now()).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to mark notifications as read")
+		return err
+	}
+	return nil
+}
+
+func (svc *activityService) unreadNotifications() *gorm.DB {
+	return svc.db.Model(&db.Activity{}).Where("notify = ? AND read_at IS NULL", true)
+}
+
+// toActivity returns nil for events that are not shown in the activity feed
+func toActivity(event *events.Event) *db.Activity {
+	switch event.Event {
+	case "nwc_payment_received", "nwc_payment_sent":
+		transaction, ok := event.Properties.(*lnclient.Transaction)
+		if !ok {
+			logger.Logger.WithField("event", event).Error("Failed to cast event")
+			return nil
+		}
+		title := fmt.Sprintf("Received %d sats", transaction.Amount/1000)
+		if event.Event == "nwc_payment_sent" {
+			title = fmt.Sprintf("Sent %d sats", transaction.Amount/1000)
+		}
+		return &db.Activity{
+			Type:        constants.ACTIVITY_TYPE_PAYMENT,
+			Title:       title,
+			Description: transaction.Description,
+			AmountSat:   transaction.Amount / 1000,
+			PaymentHash: transaction.PaymentHash,
+		}
+	case "nwc_payment_failed_async":
+		properties, ok := event.Properties.(*events.PaymentFailedAsyncProperties)
+		if !ok {
+			logger.Logger.WithField("event", event).Error("Failed to cast event")
+			return nil
+		}
+		return &db.Activity{
+			Type:        constants.ACTIVITY_TYPE_FAILURE,
+			Title:       fmt.Sprintf("Payment of %d sats failed", properties.Transaction.Amount/1000),
+			Description: properties.Reason,
+			AmountSat:   properties.Transaction.Amount / 1000,
+			PaymentHash: properties.Transaction.PaymentHash,
+		}
+	case "nwc_node_start_failed":
+		return &db.Activity{
+			Type:  constants.ACTIVITY_TYPE_FAILURE,
+			Title: "Node failed to start",
+		}
+	}
+
+	// the other events are published with properties of a map
+	properties, ok := event.Properties.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	switch event.Event {
+	case "app_created":
+		return &db.Activity{
+			Type:    constants.ACTIVITY_TYPE_APP,
+			Title:   fmt.Sprintf("Connected %v", properties["name"]),
+			AppName: toString(properties["name"]),
+		}
+	case "app_deleted":
+		return &db.Activity{
+			Type:    constants.ACTIVITY_TYPE_APP,
+			Title:   fmt.Sprintf("Disconnected %v", properties["name"]),
+			AppName: toString(properties["name"]),
+		}
+	case "app_secret_regenerated":
+		return &db.Activity{
+			Type:    constants.ACTIVITY_TYPE_APP,
+			Title:   fmt.Sprintf("Regenerated the connection secret of %v", properties["name"]),
+			AppName: toString(properties["name"]),
+		}
+	case "app_updated":
+		return &db.Activity{
+			Type:    constants.ACTIVITY_TYPE_PERMISSION,
+			Title:   fmt.Sprintf("Changed the permissions of %v", properties["name"]),
+			AppName: toString(properties["name"]),
+		}
+	case "nwc_payment_confirmation_required":
+		return &db.Activity{
+			Type:      constants.ACTIVITY_TYPE_PAYMENT,
+			Title:     fmt.Sprintf("A payment of %v sats needs your approval", properties["amount"]),
+			AmountSat: toInt64(properties["amount"]),
+			Notify:    true,
+		}
+	case "nwc_payment_failed":
+		return &db.Activity{
+			Type:        constants.ACTIVITY_TYPE_FAILURE,
+			Title:       fmt.Sprintf("Payment of %v sats failed", properties["amount"]),
+			Description: fmt.Sprintf("%v", properties["error"]),
+			AppName:     toString(properties["app_name"]),
+			AmountSat:   toInt64(properties["amount"]),
+		}
+	case "nwc_budget_exceeded":
+		return &db.Activity{
+			Type:        constants.ACTIVITY_TYPE_FAILURE,
+			Title:       fmt.Sprintf("%v reached its budget", properties["app_name"]),
+			Description: fmt.Sprintf("A payment of %v sats was rejected because it exceeds the budget of the app.", properties["amount"]),
+			AppName:     toString(properties["app_name"]),
+			AmountSat:   toInt64(properties["amount"]),
+		}
+	case "nwc_budget_warning":
+		return &db.Activity{
+			Type:        constants.ACTIVITY_TYPE_PERMISSION,
+			Title:       fmt.Sprintf("The budget of %v runs out soon", properties["app_name"]),
+			Description: describeBudgetWarning(properties),
+			AppName:     toString(properties["app_name"]),
+			Notify:      true,
+		}
+	case "nwc_permission_denied":
+		return &db.Activity{
+			Type:        constants.ACTIVITY_TYPE_FAILURE,
+			Title:       fmt.Sprintf("%v was denied %v", properties["app_name"], properties["request_method"]),
+			Description: fmt.Sprintf("%v", properties["message"]),
+			AppName:     toString(properties["app_name"]),
+		}
+	}
+	return nil
+}
+
+func describeBudgetWarning(properties map[string]interface{}) string {
+	runsOutAt, _ := properties["runs_out_at"].(time.Time)
+	renewsAt, _ := properties["renews_at"].(time.Time)
+	return fmt.Sprintf("At the current spend rate, the budget of %v sats runs out on %s, before it renews on %s.", properties["budget"], runsOutAt.Format("Jan 2 15:04"), renewsAt.Format("Jan 2 15:04"))
+}
+
+func toInt64(value interface{}) int64 {
+	switch value := value.(type) {
+	case int:
+		return int64(value)
+	case int64:
+		return value
+	case uint64:
+		return int64(value)
+	case float64:
+		return int64(value)
+	}
+	return 0
+}
+
+func toString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
@@ -0,0 +1,139 @@
+package activity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsumeEvent(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	activityService := NewActivityService(svc.DB)
+	activityService.ConsumeEvent(ctx, &events.Event{
+		Event:      "app_created",
+		Properties: map[string]interface{}{"name": "Alby"},
+	}, map[string]interface{}{})
+	activityService.ConsumeEvent(ctx, &events.Event{
+		Event:      "nwc_payment_sent",
+		Properties: &lnclient.Transaction{Amount: 123_000, PaymentHash: tests.MockPaymentHash, Description: "coffee"},
+	}, map[string]interface{}{})
+	// not shown in the feed
+	activityService.ConsumeEvent(ctx, &events.Event{Event: "nwc_started"}, map[string]interface{}{})
+
+	activities, nextCursor, err := activityService.ListActivities(0, 0, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, nextCursor)
+	assert.Equal(t, 2, len(activities))
+
+	assert.Equal(t, constants.ACTIVITY_TYPE_PAYMENT, activities[0].Type)
+	assert.Equal(t, "nwc_payment_sent", activities[0].Event)
+	assert.Equal(t, "Sent 123 sats", activities[0].Title)
+	assert.Equal(t, "coffee", activities[0].Description)
+	assert.Equal(t, int64(123), activities[0].AmountSat)
+	assert.Equal(t, tests.MockPaymentHash, activities[0].PaymentHash)
+
+	assert.Equal(t, constants.ACTIVITY_TYPE_APP, activities[1].Type)
+	assert.Equal(t, "Connected Alby", activities[1].Title)
+	assert.Equal(t, "Alby", activities[1].AppName)
+}
+
+func TestListActivities_Cursor(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	activityService := NewActivityService(svc.DB)
+	for _, event := range []string{"app_created", "app_updated", "app_created", "app_deleted", "app_updated"} {
+		activityService.ConsumeEvent(ctx, &events.Event{
+			Event:      event,
+			Properties: map[string]interface{}{"name": "Alby"},
+		}, map[string]interface{}{})
+	}
+
+	activities, nextCursor, err := activityService.ListActivities(0, 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(activities))
+	assert.Equal(t, "app_updated", activities[0].Event)
+	assert.Equal(t, "app_deleted", activities[1].Event)
+	assert.Equal(t, activities[1].ID, *nextCursor)
+
+	activities, nextCursor, err = activityService.ListActivities(*nextCursor, 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(activities))
+	assert.Equal(t, "app_created", activities[0].Event)
+	assert.Equal(t, "app_updated", activities[1].Event)
+	assert.NotNil(t, nextCursor)
+
+	activities, nextCursor, err = activityService.ListActivities(*nextCursor, 2, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(activities))
+	assert.Nil(t, nextCursor)
+
+	// only permission changes
+	activities, nextCursor, err = activityService.ListActivities(0, 0, []string{constants.ACTIVITY_TYPE_PERMISSION})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(activities))
+	assert.Nil(t, nextCursor)
+	for _, activity := range activities {
+		assert.Equal(t, constants.ACTIVITY_TYPE_PERMISSION, activity.Type)
+	}
+}
+
+func TestNotifications(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	activityService := NewActivityService(svc.DB)
+	activityService.ConsumeEvent(ctx, &events.Event{
+		Event:      "nwc_budget_exceeded",
+		Properties: map[string]interface{}{"app_name": "Alby", "amount": uint64(1000)},
+	}, map[string]interface{}{})
+	activityService.ConsumeEvent(ctx, &events.Event{
+		Event:      "nwc_payment_confirmation_required",
+		Properties: map[string]interface{}{"transaction_id": uint(1), "app_id": uint(1), "amount": uint64(2000)},
+	}, map[string]interface{}{})
+	// not a notification
+	activityService.ConsumeEvent(ctx, &events.Event{
+		Event:      "app_created",
+		Properties: map[string]interface{}{"name": "Alby"},
+	}, map[string]interface{}{})
+
+	notifications, unreadCount, err := activityService.ListNotifications(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), unreadCount)
+	assert.Equal(t, 2, len(notifications))
+	assert.Equal(t, "A payment of 2000 sats needs your approval", notifications[0].Title)
+	assert.Equal(t, "Alby reached its budget", notifications[1].Title)
+
+	err = activityService.MarkNotificationsRead([]uint{notifications[0].ID})
+	assert.NoError(t, err)
+	notifications, unreadCount, err = activityService.ListNotifications(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), unreadCount)
+	assert.Equal(t, "Alby reached its budget", notifications[0].Title)
+
+	err = activityService.MarkNotificationsRead(nil)
+	assert.NoError(t, err)
+	notifications, unreadCount, err = activityService.ListNotifications(0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), unreadCount)
+	assert.Empty(t, notifications)
+
+	// read notifications are still part of the activity feed
+	activities, _, err := activityService.ListActivities(0, 0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(activities))
+	assert.NotNil(t, activities[1].ReadAt)
+}
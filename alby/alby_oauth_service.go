@@ -39,6 +39,7 @@ type albyOAuthService struct {
 	db             *gorm.DB
 	keys           keys.Keys
 	eventPublisher events.EventPublisher
+	oauthClients   *oauthClients
 }
 
 const (
@@ -50,6 +51,8 @@ const (
 
 const ALBY_ACCOUNT_APP_NAME = "getalby.com"
 
+var errSingleUserMode = errors.New("Alby accounts cannot be connected in single-user mode")
+
 func NewAlbyOAuthService(db *gorm.DB, cfg config.Config, keys keys.Keys, eventPublisher events.EventPublisher) *albyOAuthService {
 	conf := &oauth2.Config{
 		ClientID:     cfg.GetEnv().AlbyClientId,
@@ -74,12 +77,16 @@ func NewAlbyOAuthService(db *gorm.DB, cfg config.Config, keys keys.Keys, eventPu
 		db:             db,
 		keys:           keys,
 		eventPublisher: eventPublisher,
+		oauthClients:   newOAuthClients(),
 	}
 	return albyOAuthSvc
 }
 
 func (svc *albyOAuthService) CallbackHandler(ctx context.Context, code string, lnClient lnclient.LNClient) error {
-	token, err := svc.oauthConf.Exchange(ctx, code)
+	if svc.cfg.GetEnv().SingleUser {
+		return errSingleUserMode
+	}
+	token, err := svc.getOAuthConf().Exchange(svc.oauthClients.withHTTPClient(ctx), code)
 	if err != nil {
 		logger.Logger.WithError(err).Error("Failed to exchange token")
 		return err
@@ -192,7 +199,7 @@ func (svc *albyOAuthService) fetchUserToken(ctx context.Context) (*oauth2.Token,
 		return currentToken, nil
 	}
 
-	newToken, err := svc.oauthConf.TokenSource(ctx, currentToken).Token()
+	newToken, err := svc.getOAuthConf().TokenSource(svc.oauthClients.withHTTPClient(ctx), currentToken).Token()
 	if err != nil {
 		logger.Logger.WithError(err).Error("Failed to refresh existing token")
 		return nil, err
@@ -202,6 +209,22 @@ func (svc *albyOAuthService) fetchUserToken(ctx context.Context) (*oauth2.Token,
 	return newToken, nil
 }
 
+func (svc *albyOAuthService) getOAuthClient(token *oauth2.Token) *http.Client {
+	userIdentifier, err := svc.GetUserIdentifier()
+	if err != nil {
+		// the user identifier is only used to look up the client
+		userIdentifier = ""
+	}
+	return svc.oauthClients.get(svc.getOAuthConf(), userIdentifier, token)
+}
+
+// getOAuthConf returns the OAuth config with the current client secret, which changes when it is refreshed from a secret manager
+func (svc *albyOAuthService) getOAuthConf() *oauth2.Config {
+	conf := *svc.oauthConf
+	conf.ClientSecret = svc.cfg.GetEnv().AlbyClientSecret
+	return &conf
+}
+
 func (svc *albyOAuthService) GetMe(ctx context.Context) (*AlbyMe, error) {
 	token, err := svc.fetchUserToken(ctx)
 	if err != nil {
@@ -209,7 +232,7 @@ func (svc *albyOAuthService) GetMe(ctx context.Context) (*AlbyMe, error) {
 		return nil, err
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/internal/users", svc.cfg.GetEnv().AlbyAPIURL), nil)
 	if err != nil {
@@ -224,6 +247,7 @@ func (svc *albyOAuthService) GetMe(ctx context.Context) (*AlbyMe, error) {
 		logger.Logger.WithError(err).Error("Failed to fetch /me")
 		return nil, err
 	}
+	defer res.Body.Close()
 
 	me := &AlbyMe{}
 	err = json.NewDecoder(res.Body).Decode(me)
@@ -244,7 +268,7 @@ func (svc *albyOAuthService) GetBalance(ctx context.Context) (*AlbyBalance, erro
 		return nil, err
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/internal/lndhub/balance", svc.cfg.GetEnv().AlbyAPIURL), nil)
 	if err != nil {
@@ -259,6 +283,7 @@ func (svc *albyOAuthService) GetBalance(ctx context.Context) (*AlbyBalance, erro
 		logger.Logger.WithError(err).Error("Failed to fetch balance endpoint")
 		return nil, err
 	}
+	defer res.Body.Close()
 	balance := &AlbyBalance{}
 	err = json.NewDecoder(res.Body).Decode(balance)
 	if err != nil {
@@ -292,7 +317,7 @@ func (svc *albyOAuthService) DrainSharedWallet(ctx context.Context, lnClient lnc
 
 	logger.Logger.WithField("amount", amount).WithError(err).Error("Draining Alby shared wallet funds")
 
-	transaction, err := transactions.NewTransactionsService(svc.db).MakeInvoice(ctx, amount, "Send shared wallet funds to Alby Hub", "", 120, nil, lnClient, nil, nil)
+	transaction, err := transactions.NewTransactionsService(svc.db, svc.cfg, svc.eventPublisher).MakeInvoice(ctx, amount, "Send shared wallet funds to Alby Hub", "", 120, nil, lnClient, nil, nil)
 	if err != nil {
 		logger.Logger.WithField("amount", amount).WithError(err).Error("Failed to make invoice")
 		return err
@@ -313,7 +338,7 @@ func (svc *albyOAuthService) SendPayment(ctx context.Context, invoice string) er
 		return err
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 
 	type payRequest struct {
 		Invoice string `json:"invoice"`
@@ -345,6 +370,7 @@ func (svc *albyOAuthService) SendPayment(ctx context.Context, invoice string) er
 		}).WithError(err).Error("Failed to pay invoice")
 		return err
 	}
+	defer resp.Body.Close()
 
 	type PayResponse struct {
 		Preimage    string `json:"payment_preimage"`
@@ -391,6 +417,9 @@ func (svc *albyOAuthService) SendPayment(ctx context.Context, invoice string) er
 }
 
 func (svc *albyOAuthService) GetAuthUrl() string {
+	if svc.cfg.GetEnv().SingleUser {
+		return ""
+	}
 	if svc.cfg.GetEnv().AlbyClientId == "" || svc.cfg.GetEnv().AlbyClientSecret == "" {
 		logger.Logger.Fatalf("No ALBY_OAUTH_CLIENT_ID or ALBY_OAUTH_CLIENT_SECRET set")
 	}
@@ -403,6 +432,7 @@ func (svc *albyOAuthService) UnlinkAccount(ctx context.Context) error {
 		logger.Logger.WithError(err).Error("Failed to destroy Alby Account NWC node")
 	}
 	svc.deleteAlbyAccountApps()
+	svc.oauthClients.clear()
 
 	svc.cfg.SetUpdate(userIdentifierKey, "", "")
 	svc.cfg.SetUpdate(accessTokenKey, "", "")
@@ -413,6 +443,9 @@ func (svc *albyOAuthService) UnlinkAccount(ctx context.Context) error {
 }
 
 func (svc *albyOAuthService) LinkAccount(ctx context.Context, lnClient lnclient.LNClient, budget uint64, renewal string) error {
+	if svc.cfg.GetEnv().SingleUser {
+		return errSingleUserMode
+	}
 	svc.deleteAlbyAccountApps()
 
 	connectionPubkey, err := svc.createAlbyAccountNWCNode(ctx)
@@ -470,6 +503,10 @@ func (svc *albyOAuthService) consumeEvent(ctx context.Context, event *events.Eve
 		logger.Logger.WithField("event", event).Debug("Skipped sending to alby events API")
 		return
 	}
+	if svc.cfg.GetEnv().SingleUser {
+		// there is no Alby account to send the events for
+		return
+	}
 
 	if event.Event == "nwc_backup_channels" {
 		if err := svc.backupChannels(ctx, event); err != nil {
@@ -535,7 +572,7 @@ func (svc *albyOAuthService) consumeEvent(ctx context.Context, event *events.Eve
 		return
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 
 	// encode event without global properties
 	originalEventBuffer := bytes.NewBuffer([]byte{})
@@ -594,6 +631,7 @@ func (svc *albyOAuthService) consumeEvent(ctx context.Context, event *events.Eve
 		}).WithError(err).Error("Failed to send request to /events")
 		return
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		logger.Logger.WithFields(logrus.Fields{
@@ -615,7 +653,7 @@ func (svc *albyOAuthService) backupChannels(ctx context.Context, event *events.E
 		return fmt.Errorf("failed to fetch user token: %w", err)
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 
 	type channelsBackup struct {
 		Description string `json:"description"`
@@ -659,6 +697,7 @@ func (svc *albyOAuthService) backupChannels(ctx context.Context, event *events.E
 	if err != nil {
 		return fmt.Errorf("failed to send request to /internal/backups: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		return fmt.Errorf("request to /internal/backups returned non-success status: %d", resp.StatusCode)
@@ -673,7 +712,7 @@ func (svc *albyOAuthService) createAlbyAccountNWCNode(ctx context.Context) (stri
 		logger.Logger.WithError(err).Error("Failed to fetch user token")
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 
 	type createNWCNodeRequest struct {
 		WalletPubkey string `json:"wallet_pubkey"`
@@ -706,6 +745,7 @@ func (svc *albyOAuthService) createAlbyAccountNWCNode(ctx context.Context) (stri
 		}).WithError(err).Error("Failed to send request to /internal/nwcs")
 		return "", err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		logger.Logger.WithFields(logrus.Fields{
@@ -739,7 +779,7 @@ func (svc *albyOAuthService) destroyAlbyAccountNWCNode(ctx context.Context) erro
 		logger.Logger.WithError(err).Error("Failed to fetch user token")
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 
 	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/internal/nwcs", svc.cfg.GetEnv().AlbyAPIURL), nil)
 	if err != nil {
@@ -754,6 +794,7 @@ func (svc *albyOAuthService) destroyAlbyAccountNWCNode(ctx context.Context) erro
 		logger.Logger.WithError(err).Error("Failed to send request to /internal/nwcs")
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		logger.Logger.WithFields(logrus.Fields{
@@ -773,7 +814,7 @@ func (svc *albyOAuthService) activateAlbyAccountNWCNode(ctx context.Context) err
 		logger.Logger.WithError(err).Error("Failed to fetch user token")
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 
 	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/internal/nwcs/activate", svc.cfg.GetEnv().AlbyAPIURL), nil)
 	if err != nil {
@@ -788,6 +829,7 @@ func (svc *albyOAuthService) activateAlbyAccountNWCNode(ctx context.Context) err
 		logger.Logger.WithError(err).Error("Failed to send request to /internal/nwcs/activate")
 		return err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
 		logger.Logger.WithFields(logrus.Fields{
@@ -809,7 +851,7 @@ func (svc *albyOAuthService) GetChannelPeerSuggestions(ctx context.Context) ([]C
 		return nil, err
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/internal/channel_suggestions", svc.cfg.GetEnv().AlbyAPIURL), nil)
 	if err != nil {
@@ -824,6 +866,7 @@ func (svc *albyOAuthService) GetChannelPeerSuggestions(ctx context.Context) ([]C
 		logger.Logger.WithError(err).Error("Failed to fetch channel_suggestions endpoint")
 		return nil, err
 	}
+	defer res.Body.Close()
 	var suggestions []ChannelPeerSuggestion
 	err = json.NewDecoder(res.Body).Decode(&suggestions)
 	if err != nil {
@@ -895,7 +938,7 @@ func (svc *albyOAuthService) requestAutoChannel(ctx context.Context, url string,
 		logger.Logger.WithError(err).Error("Failed to fetch user token")
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 	client.Timeout = 60 * time.Second
 
 	type autoChannelRequest struct {
@@ -1025,7 +1068,7 @@ func (svc *albyOAuthService) getLSPInfo(ctx context.Context, url string) (pubkey
 		logger.Logger.WithError(err).Error("Failed to fetch user token")
 	}
 
-	client := svc.oauthConf.Client(ctx, token)
+	client := svc.getOAuthClient(token)
 	client.Timeout = 60 * time.Second
 
 	type lsps1LSPInfo struct {
@@ -1108,5 +1151,13 @@ func (svc *albyOAuthService) deleteAlbyAccountApps() {
 	err := svc.db.Where("name = ?", ALBY_ACCOUNT_APP_NAME).Delete(&db.App{}).Error
 	if err != nil {
 		logger.Logger.WithError(err).Error("Failed to delete Alby Account apps")
+		return
 	}
+
+	svc.eventPublisher.Publish(&events.Event{
+		Event: "app_deleted",
+		Properties: map[string]interface{}{
+			"name": ALBY_ACCOUNT_APP_NAME,
+		},
+	})
 }
@@ -0,0 +1,74 @@
+package alby
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// oauthClients keeps one OAuth client per Alby user so that the connections to the Alby API
+// are reused between requests instead of doing a TLS handshake for every payment.
+// All clients share the same transport.
+type oauthClients struct {
+	mu        sync.Mutex
+	transport *http.Transport
+	clients   map[string]*oauthClient
+}
+
+type oauthClient struct {
+	accessToken string
+	client      *http.Client
+}
+
+func newOAuthClients() *oauthClients {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 10
+	transport.IdleConnTimeout = 90 * time.Second
+
+	return &oauthClients{
+		transport: transport,
+		clients:   map[string]*oauthClient{},
+	}
+}
+
+// withHTTPClient makes the oauth2 package use the shared transport for token requests
+func (clients *oauthClients) withHTTPClient(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{
+		Transport: clients.transport,
+	})
+}
+
+// get returns the client of the user, a new client is only created once the access token changes
+func (clients *oauthClients) get(oauthConf *oauth2.Config, userIdentifier string, token *oauth2.Token) *http.Client {
+	if token == nil {
+		// no Alby account is linked, requests of this client fail without a token
+		return oauthConf.Client(clients.withHTTPClient(context.Background()), nil)
+	}
+
+	clients.mu.Lock()
+	defer clients.mu.Unlock()
+
+	cached, ok := clients.clients[userIdentifier]
+	if ok && cached.accessToken == token.AccessToken {
+		return cached.client
+	}
+
+	// tokens are refreshed by fetchUserToken, so the client is not bound to the context of a single request
+	client := oauthConf.Client(clients.withHTTPClient(context.Background()), token)
+	clients.clients[userIdentifier] = &oauthClient{
+		accessToken: token.AccessToken,
+		client:      client,
+	}
+	return client
+}
+
+func (clients *oauthClients) clear() {
+	clients.mu.Lock()
+	defer clients.mu.Unlock()
+
+	clients.clients = map[string]*oauthClient{}
+	clients.transport.CloseIdleConnections()
+}
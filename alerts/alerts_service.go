@@ -0,0 +1,375 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const sendTimeout = 30 * time.Second
+
+const (
+	ALERT_STATUS_SETTLED  = "settled"
+	ALERT_STATUS_FAILED   = "failed"
+	ALERT_STATUS_REJECTED = "rejected"
+)
+
+type Alert struct {
+	// one of the config.Notification* event types
+	Type    string
+	Title   string
+	Message string
+	// details for channels that can show them separately, e.g. in a Discord embed
+	AmountSat int64
+	// empty if the payment was not made by an app
+	AppName   string
+	Status    string
+	CreatedAt time.Time
+}
+
+// channel delivers alerts to the owner of the hub, e.g. through a chat app
+type channel interface {
+	name() string
+	send(ctx context.Context, alert *Alert) error
+}
+
+type AlertsService interface {
+	events.EventSubscriber
+	// StartCommands answers commands sent to the channels (e.g. /balance) until the context is cancelled
+	StartCommands(ctx context.Context, lnClient lnclient.LNClient)
+	// StartAlertRules evaluates the alert rules periodically until the context is cancelled,
+	// for rules that can start firing without a new event (e.g. relay_disconnected)
+	StartAlertRules(ctx context.Context)
+	// Reload applies the notification settings of the reloaded config
+	Reload()
+}
+
+type alertsService struct {
+	cfg config.Config
+	// replaced on reload
+	mtx      sync.RWMutex
+	channels []channel
+	telegram *telegramChannel
+	receipts *receiptMailer
+	// nil if no alert rules are set
+	rules      *rulesEngine
+	alertRules string
+	// set once the commands are started, so they can be restarted with a new bot
+	lnClient       lnclient.LNClient
+	commandsCtx    context.Context
+	cancelCommands context.CancelFunc
+}
+
+func NewAlertsService(cfg config.Config) *alertsService {
+	svc := &alertsService{
+		cfg: cfg,
+	}
+	svc.channels, svc.telegram, svc.receipts = newChannels(cfg.GetEnv())
+	svc.alertRules = cfg.GetEnv().AlertRules
+	svc.rules = newAlertRules(svc.alertRules)
+	return svc
+}
+
+func newChannels(env *config.AppConfig) ([]channel, *telegramChannel, *receiptMailer) {
+	channels := []channel{}
+	var telegram *telegramChannel
+	var receipts *receiptMailer
+	if env.TelegramBotToken != "" {
+		telegram = newTelegramChannel(telegramApiUrl, env.TelegramBotToken, env.TelegramChatId)
+		channels = append(channels, telegram)
+	}
+	if env.MatrixHomeserverUrl != "" {
+		channels = append(channels, newMatrixChannel(env.MatrixHomeserverUrl, env.MatrixAccessToken, env.MatrixRoomId))
+	}
+	if env.DiscordWebhookUrl != "" {
+		channels = append(channels, newDiscordChannel(env.DiscordWebhookUrl, env.GetBranding().Name))
+	}
+	if env.SlackWebhookUrl != "" {
+		channels = append(channels, newSlackChannel(env.SlackWebhookUrl))
+	}
+	if env.ReceiptEmail != "" {
+		receipts = newReceiptMailer(env.SmtpHost, env.SmtpPort, env.SmtpUsername, env.SmtpPassword, env.SmtpFrom, env.ReceiptEmail, env.ReceiptMinAmountSat, env.ReceiptCurrency, env.GetBranding())
+	}
+	if env.WebhookUrl != "" {
+		channels = append(channels, newWebhookChannel(env.WebhookUrl, env.WebhookFormat))
+	}
+	return channels, telegram, receipts
+}
+
+func newAlertRules(rules string) *rulesEngine {
+	alertRules, err := config.ParseAlertRules(rules)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to parse alert rules")
+		return nil
+	}
+	if len(alertRules) == 0 {
+		return nil
+	}
+	return newRulesEngine(alertRules)
+}
+
+func (svc *alertsService) Reload() {
+	env := svc.cfg.GetEnv()
+	channels, telegram, receipts := newChannels(env)
+
+	svc.mtx.Lock()
+	defer svc.mtx.Unlock()
+	// the alert rules keep their state unless they changed
+	if env.AlertRules != svc.alertRules {
+		svc.alertRules = env.AlertRules
+		svc.rules = newAlertRules(svc.alertRules)
+	}
+	if svc.commandsCtx != nil && !sameTelegramBot(svc.telegram, telegram) {
+		svc.cancelCommands()
+		svc.startCommands(telegram)
+	}
+	svc.channels = channels
+	svc.telegram = telegram
+	svc.receipts = receipts
+}
+
+func sameTelegramBot(a *telegramChannel, b *telegramChannel) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.botToken == b.botToken && a.chatId == b.chatId
+}
+
+func (svc *alertsService) StartCommands(ctx context.Context, lnClient lnclient.LNClient) {
+	svc.mtx.Lock()
+	defer svc.mtx.Unlock()
+	svc.lnClient = lnClient
+	svc.commandsCtx = ctx
+	svc.startCommands(svc.telegram)
+}
+
+func (svc *alertsService) startCommands(telegram *telegramChannel) {
+	var ctx context.Context
+	ctx, svc.cancelCommands = context.WithCancel(svc.commandsCtx)
+	if telegram != nil {
+		go telegram.listenForCommands(ctx, svc.lnClient)
+	}
+}
+
+func (svc *alertsService) StartAlertRules(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(rulesEvaluationInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				svc.mtx.RLock()
+				rules := svc.rules
+				svc.mtx.RUnlock()
+				if rules == nil {
+					continue
+				}
+				for _, alert := range rules.evaluate() {
+					svc.sendAlert(ctx, alert)
+				}
+			}
+		}
+	}()
+}
+
+func (svc *alertsService) ConsumeEvent(ctx context.Context, event *events.Event, globalProperties map[string]interface{}) {
+	svc.mtx.RLock()
+	receipts := svc.receipts
+	rules := svc.rules
+	channels := svc.channels
+	svc.mtx.RUnlock()
+
+	if receipts != nil {
+		svc.sendReceipt(ctx, receipts, event)
+	}
+	if rules != nil {
+		for _, alert := range rules.consumeEvent(event) {
+			svc.sendAlert(ctx, alert)
+		}
+	}
+	if len(channels) == 0 {
+		return
+	}
+	alert := toAlert(event)
+	if alert == nil || !slices.Contains(svc.cfg.GetEnv().GetNotificationEvents(), alert.Type) {
+		return
+	}
+	alert.CreatedAt = time.Now()
+	svc.sendAlert(ctx, alert)
+}
+
+func (svc *alertsService) sendAlert(ctx context.Context, alert *Alert) {
+	if alert.Type == config.NotificationAlertRule {
+		// also logged for hubs without notification channels
+		logger.Logger.WithFields(logrus.Fields{
+			"title":   alert.Title,
+			"message": alert.Message,
+		}).Warn("Alert rule fired")
+	}
+
+	svc.mtx.RLock()
+	channels := svc.channels
+	svc.mtx.RUnlock()
+
+	// run non-blocking, the channels are external services
+	for _, channel := range channels {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+			defer cancel()
+			err := channel.send(ctx, alert)
+			if err != nil {
+				logger.Logger.WithFields(logrus.Fields{
+					"channel": channel.name(),
+					"type":    alert.Type,
+				}).WithError(err).Error("Failed to send alert")
+			}
+		}()
+	}
+}
+
+// receipts are sent for all settled payments, independent of NOTIFICATION_EVENTS
+func (svc *alertsService) sendReceipt(ctx context.Context, receipts *receiptMailer, event *events.Event) {
+	var receiptType receiptType
+	switch event.Event {
+	case "nwc_payment_received":
+		receiptType = receiptTypeReceived
+	case "nwc_payment_sent":
+		receiptType = receiptTypeSent
+	default:
+		return
+	}
+	transaction, ok := event.Properties.(*lnclient.Transaction)
+	if !ok {
+		logger.Logger.WithField("event", event).Error("Failed to cast event")
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendTimeout)
+		defer cancel()
+		err := receipts.send(ctx, receiptType, transaction)
+		if err != nil {
+			logger.Logger.WithField("payment_hash", transaction.PaymentHash).WithError(err).Error("Failed to send receipt")
+		}
+	}()
+}
+
+// toAlert returns nil for events that are not sent to the channels
+func toAlert(event *events.Event) *Alert {
+	switch event.Event {
+	case "nwc_payment_received":
+		transaction, ok := event.Properties.(*lnclient.Transaction)
+		if !ok {
+			logger.Logger.WithField("event", event).Error("Failed to cast event")
+			return nil
+		}
+		return &Alert{
+			Type:      config.NotificationPaymentReceived,
+			Title:     fmt.Sprintf("Received %s", formatSats(transaction.Amount/1000)),
+			Message:   describeTransaction(transaction),
+			AmountSat: transaction.Amount / 1000,
+			Status:    ALERT_STATUS_SETTLED,
+		}
+	case "nwc_payment_sent":
+		transaction, ok := event.Properties.(*lnclient.Transaction)
+		if !ok {
+			logger.Logger.WithField("event", event).Error("Failed to cast event")
+			return nil
+		}
+		return &Alert{
+			Type:      config.NotificationPaymentSent,
+			Title:     fmt.Sprintf("Sent %s (fee: %s)", formatSats(transaction.Amount/1000), formatSats(transaction.FeesPaid/1000)),
+			Message:   describeTransaction(transaction),
+			AmountSat: transaction.Amount / 1000,
+			Status:    ALERT_STATUS_SETTLED,
+		}
+	case "nwc_payment_failed":
+		// only published for payments of apps, with the amount in sats
+		properties, ok := event.Properties.(map[string]interface{})
+		if !ok {
+			logger.Logger.WithField("event", event).Error("Failed to cast event")
+			return nil
+		}
+		return &Alert{
+			Type:      config.NotificationPaymentFailed,
+			Title:     fmt.Sprintf("Payment of %v sats failed", properties["amount"]),
+			Message:   fmt.Sprintf("%v", properties["error"]),
+			AmountSat: toInt64(properties["amount"]),
+			AppName:   toString(properties["app_name"]),
+			Status:    ALERT_STATUS_FAILED,
+		}
+	case "nwc_budget_exceeded":
+		properties, ok := event.Properties.(map[string]interface{})
+		if !ok {
+			logger.Logger.WithField("event", event).Error("Failed to cast event")
+			return nil
+		}
+		return &Alert{
+			Type:      config.NotificationBudgetExceeded,
+			Title:     fmt.Sprintf("%v reached its budget", properties["app_name"]),
+			Message:   fmt.Sprintf("A payment of %v sats was rejected because it exceeds the budget of the app.", properties["amount"]),
+			AmountSat: toInt64(properties["amount"]),
+			AppName:   toString(properties["app_name"]),
+			Status:    ALERT_STATUS_REJECTED,
+		}
+	case "nwc_budget_warning":
+		properties, ok := event.Properties.(map[string]interface{})
+		if !ok {
+			logger.Logger.WithField("event", event).Error("Failed to cast event")
+			return nil
+		}
+		runsOutAt, _ := properties["runs_out_at"].(time.Time)
+		renewsAt, _ := properties["renews_at"].(time.Time)
+		return &Alert{
+			Type:    config.NotificationBudgetWarning,
+			Title:   fmt.Sprintf("The budget of %v runs out soon", properties["app_name"]),
+			Message: fmt.Sprintf("At the current spend rate, the budget of %v sats runs out on %s, before it renews on %s.", properties["budget"], runsOutAt.Format(time.RFC1123), renewsAt.Format(time.RFC1123)),
+			AppName: toString(properties["app_name"]),
+		}
+	}
+	return nil
+}
+
+func describeTransaction(transaction *lnclient.Transaction) string {
+	if transaction.Description != "" {
+		return transaction.Description
+	}
+	return "Payment hash: " + transaction.PaymentHash
+}
+
+func formatSats(amount int64) string {
+	if amount == 1 {
+		return "1 sat"
+	}
+	return fmt.Sprintf("%d sats", amount)
+}
+
+// event properties are built by different packages, amounts can be of any integer type
+func toInt64(value interface{}) int64 {
+	switch value := value.(type) {
+	case int:
+		return int64(value)
+	case int64:
+		return value
+	case uint64:
+		return int64(value)
+	case float64:
+		return int64(value)
+	}
+	return 0
+}
+
+func toString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
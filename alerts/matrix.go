@@ -0,0 +1,78 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type matrixChannel struct {
+	homeserverUrl string
+	accessToken   string
+	roomId        string
+	httpClient    *http.Client
+	// makes the transaction IDs unique, a retried transaction ID would be ignored by the homeserver
+	txnCounter atomic.Uint64
+}
+
+type matrixError struct {
+	ErrCode string `json:"errcode"`
+	Error   string `json:"error"`
+}
+
+func newMatrixChannel(homeserverUrl string, accessToken string, roomId string) *matrixChannel {
+	return &matrixChannel{
+		homeserverUrl: strings.TrimSuffix(homeserverUrl, "/"),
+		accessToken:   accessToken,
+		roomId:        roomId,
+		httpClient: &http.Client{
+			Timeout: sendTimeout,
+		},
+	}
+}
+
+func (matrix *matrixChannel) name() string {
+	return "matrix"
+}
+
+// send posts a text message to the room, see https://spec.matrix.org/v1.11/client-server-api/#put_matrixclientv3roomsroomidsendeventtypetxnid
+func (matrix *matrixChannel) send(ctx context.Context, alert *Alert) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"msgtype": "m.text",
+		"body":    alert.Title + "\n" + alert.Message,
+	})
+	if err != nil {
+		return err
+	}
+	txnId := fmt.Sprintf("albyhub-%d-%d", time.Now().UnixNano(), matrix.txnCounter.Add(1))
+	requestUrl := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s", matrix.homeserverUrl, url.PathEscape(matrix.roomId), txnId)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, requestUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+matrix.accessToken)
+
+	res, err := matrix.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		matrixError := matrixError{}
+		err = json.NewDecoder(res.Body).Decode(&matrixError)
+		if err != nil || matrixError.ErrCode == "" {
+			return fmt.Errorf("unexpected status %d", res.StatusCode)
+		}
+		return errors.New(matrixError.ErrCode + ": " + matrixError.Error)
+	}
+	return nil
+}
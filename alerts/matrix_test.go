@@ -0,0 +1,51 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getAlby/hub/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatrix_Send(t *testing.T) {
+	var requests []*http.Request
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := map[string]interface{}{}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		assert.NoError(t, err)
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		w.Write([]byte(`{"event_id":"$event"}`))
+	}))
+	defer server.Close()
+
+	matrix := newMatrixChannel(server.URL+"/", "token", "!room:example.com")
+	alert := &Alert{Type: config.NotificationPaymentReceived, Title: "Received 21 sats", Message: "coffee"}
+	assert.NoError(t, matrix.send(context.TODO(), alert))
+	assert.NoError(t, matrix.send(context.TODO(), alert))
+
+	assert.Equal(t, 2, len(requests))
+	assert.Equal(t, http.MethodPut, requests[0].Method)
+	assert.Equal(t, "Bearer token", requests[0].Header.Get("Authorization"))
+	assert.Regexp(t, `^/_matrix/client/v3/rooms/!room:example.com/send/m.room.message/albyhub-\d+-1$`, requests[0].URL.Path)
+	assert.NotEqual(t, requests[0].URL.Path, requests[1].URL.Path)
+	assert.Equal(t, "m.text", bodies[0]["msgtype"])
+	assert.Equal(t, "Received 21 sats\ncoffee", bodies[0]["body"])
+}
+
+func TestMatrix_SendError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"User is not in the room"}`))
+	}))
+	defer server.Close()
+
+	matrix := newMatrixChannel(server.URL, "token", "!room:example.com")
+	err := matrix.send(context.TODO(), &Alert{Title: "Received 21 sats"})
+	assert.EqualError(t, err, "M_FORBIDDEN: User is not in the room")
+}
@@ -0,0 +1,158 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+)
+
+const ratesApiUrl = "https://getalby.com/api/rates"
+
+type receiptType string
+
+const (
+	receiptTypeReceived receiptType = "received"
+	receiptTypeSent     receiptType = "sent"
+)
+
+// receiptMailer emails a receipt for every settled payment above the configured amount
+type receiptMailer struct {
+	smtpAddr     string
+	smtpUsername string
+	smtpPassword string
+	from         string
+	to           string
+	minAmountSat int64
+	// fiat value of the payment is left out if empty
+	currency    string
+	branding    config.Branding
+	ratesApiUrl string
+	httpClient  *http.Client
+	// smtp.SendMail, replaced in tests
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+type fiatRate struct {
+	Code      string  `json:"code"`
+	RateFloat float64 `json:"rate_float"`
+}
+
+func newReceiptMailer(smtpHost string, smtpPort int, smtpUsername string, smtpPassword string, from string, to string, minAmountSat int, currency string, branding config.Branding) *receiptMailer {
+	return &receiptMailer{
+		smtpAddr:     net.JoinHostPort(smtpHost, strconv.Itoa(smtpPort)),
+		smtpUsername: smtpUsername,
+		smtpPassword: smtpPassword,
+		from:         from,
+		to:           to,
+		minAmountSat: int64(minAmountSat),
+		currency:     strings.ToLower(currency),
+		branding:     branding,
+		ratesApiUrl:  ratesApiUrl,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		sendMail: smtp.SendMail,
+	}
+}
+
+func (mailer *receiptMailer) send(ctx context.Context, receiptType receiptType, transaction *lnclient.Transaction) error {
+	amountSat := transaction.Amount / 1000
+	if amountSat < mailer.minAmountSat {
+		return nil
+	}
+
+	fiatValue := ""
+	if mailer.currency != "" {
+		rate, err := mailer.fetchRate(ctx)
+		if err != nil {
+			// a receipt without the fiat value is better than none
+			logger.Logger.WithError(err).WithField("currency", mailer.currency).Error("Failed to fetch fiat rate")
+			fiatValue = " (fiat value unavailable)"
+		} else {
+			fiatValue = fmt.Sprintf(" (%.2f %s)", float64(amountSat)*rate.RateFloat/100_000_000, strings.ToUpper(mailer.currency))
+		}
+	}
+
+	settledAt := time.Now()
+	if transaction.SettledAt != nil {
+		settledAt = time.Unix(*transaction.SettledAt, 0)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Amount: %s%s\r\n", formatSats(amountSat), fiatValue)
+	if receiptType == receiptTypeSent {
+		fmt.Fprintf(&body, "Fee: %s\r\n", formatSats(transaction.FeesPaid/1000))
+	}
+	if transaction.Description != "" {
+		fmt.Fprintf(&body, "Description: %s\r\n", strings.ReplaceAll(transaction.Description, "\n", " "))
+	}
+	fmt.Fprintf(&body, "Payment hash: %s\r\n", transaction.PaymentHash)
+	fmt.Fprintf(&body, "Date: %s\r\n", settledAt.UTC().Format(time.RFC1123))
+	fmt.Fprintf(&body, "\r\n-- \r\nSent by %s\r\n", mailer.branding.Name)
+	if mailer.branding.SupportUrl != "" {
+		fmt.Fprintf(&body, "Support: %s\r\n", mailer.branding.SupportUrl)
+	}
+
+	// the headers only contain values of the config and the amount, the description cannot inject headers
+	headers := []string{
+		"From: " + mailer.from,
+		"To: " + mailer.to,
+		fmt.Sprintf("Subject: Payment %s: %s", receiptType, formatSats(amountSat)),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+	}
+	message := strings.Join(headers, "\r\n") + "\r\n\r\n" + body.String()
+
+	var auth smtp.Auth
+	if mailer.smtpUsername != "" {
+		host, _, _ := net.SplitHostPort(mailer.smtpAddr)
+		auth = smtp.PlainAuth("", mailer.smtpUsername, mailer.smtpPassword, host)
+	}
+	fromAddress, err := mail.ParseAddress(mailer.from)
+	if err != nil {
+		return err
+	}
+	toAddress, err := mail.ParseAddress(mailer.to)
+	if err != nil {
+		return err
+	}
+	return mailer.sendMail(mailer.smtpAddr, auth, fromAddress.Address, []string{toAddress.Address}, []byte(message))
+}
+
+// fetchRate returns the price of one bitcoin in the configured currency
+func (mailer *receiptMailer) fetchRate(ctx context.Context) (*fiatRate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s.json", mailer.ratesApiUrl, mailer.currency), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := mailer.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	rate := &fiatRate{}
+	err = json.NewDecoder(res.Body).Decode(rate)
+	if err != nil {
+		return nil, err
+	}
+	if rate.RateFloat <= 0 {
+		return nil, fmt.Errorf("invalid rate %v", rate.RateFloat)
+	}
+	return rate, nil
+}
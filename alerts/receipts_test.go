@@ -0,0 +1,87 @@
+package alerts
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+type sentMail struct {
+	addr string
+	from string
+	to   []string
+	msg  string
+}
+
+func newTestReceiptMailer(minAmountSat int, currency string) (*receiptMailer, *[]sentMail) {
+	mails := []sentMail{}
+	mailer := newReceiptMailer("smtp.example.com", 587, "", "", "Alby Hub <hub@example.com>", "me@example.com", minAmountSat, currency, config.Branding{Name: "Alby Hub"})
+	mailer.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		mails = append(mails, sentMail{addr, from, to, string(msg)})
+		return nil
+	}
+	return mailer, &mails
+}
+
+func TestReceipt_Sent(t *testing.T) {
+	rates := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/usd.json", r.URL.Path)
+		w.Write([]byte(`{"code":"USD","rate_float":60000}`))
+	}))
+	defer rates.Close()
+
+	mailer, mails := newTestReceiptMailer(0, "USD")
+	mailer.ratesApiUrl = rates.URL
+	err := mailer.send(context.TODO(), receiptTypeSent, &lnclient.Transaction{
+		Amount:      21_000_000,
+		FeesPaid:    3000,
+		Description: "coffee",
+		PaymentHash: tests.MockPaymentHash,
+		SettledAt:   &tests.MockTimeUnix,
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(*mails))
+	mail := (*mails)[0]
+	assert.Equal(t, "smtp.example.com:587", mail.addr)
+	assert.Equal(t, "hub@example.com", mail.from)
+	assert.Equal(t, []string{"me@example.com"}, mail.to)
+	headers, body, _ := strings.Cut(mail.msg, "\r\n\r\n")
+	assert.Contains(t, headers, "Subject: Payment sent: 21000 sats\r\n")
+	assert.Equal(t, "Amount: 21000 sats (12.60 USD)\r\n"+
+		"Fee: 3 sats\r\n"+
+		"Description: coffee\r\n"+
+		"Payment hash: "+tests.MockPaymentHash+"\r\n"+
+		"Date: Tue, 05 Sep 2023 01:22:43 UTC\r\n"+
+		"\r\n-- \r\nSent by Alby Hub\r\n", body)
+}
+
+func TestReceipt_Branding(t *testing.T) {
+	mailer, mails := newTestReceiptMailer(0, "")
+	mailer.branding = config.Branding{Name: "Satoshi Club Wallet", SupportUrl: "https://help.example.com"}
+	err := mailer.send(context.TODO(), receiptTypeReceived, &lnclient.Transaction{Amount: 1_000_000})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(*mails))
+	assert.True(t, strings.HasSuffix((*mails)[0].msg, "\r\n-- \r\nSent by Satoshi Club Wallet\r\nSupport: https://help.example.com\r\n"))
+}
+
+func TestReceipt_BelowMinAmount(t *testing.T) {
+	mailer, mails := newTestReceiptMailer(1000, "")
+	err := mailer.send(context.TODO(), receiptTypeReceived, &lnclient.Transaction{Amount: 999_000})
+	assert.NoError(t, err)
+	assert.Empty(t, *mails)
+
+	err = mailer.send(context.TODO(), receiptTypeReceived, &lnclient.Transaction{Amount: 1_000_000})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(*mails))
+	assert.NotContains(t, (*mails)[0].msg, "Fee:")
+}
@@ -0,0 +1,159 @@
+package alerts
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/events"
+)
+
+// how often the rules are evaluated without new events, e.g. while the relay is disconnected
+const rulesEvaluationInterval = 10 * time.Second
+
+// a failure rate of a few payments says little, e.g. 1 failed out of 2
+const minPaymentsForFailureRate = 5
+
+// successful payments are counted for the failure rate only
+const metricPaymentsSent = "payments_sent"
+
+// rulesEngine evaluates the alert rules over the events. A rule fires once when its
+// condition becomes true, and fires again only after the condition was false in between.
+type rulesEngine struct {
+	mu     sync.Mutex
+	rules  []config.AlertRule
+	firing []bool
+	// the times of the events within the longest window, per metric
+	eventTimes     map[string][]time.Time
+	maxWindow      time.Duration
+	relayDownSince time.Time
+	now            func() time.Time
+}
+
+func newRulesEngine(rules []config.AlertRule) *rulesEngine {
+	engine := &rulesEngine{
+		rules:      rules,
+		firing:     make([]bool, len(rules)),
+		eventTimes: map[string][]time.Time{},
+		now:        time.Now,
+	}
+	for _, rule := range rules {
+		engine.maxWindow = max(engine.maxWindow, rule.Window)
+	}
+	return engine
+}
+
+// consumeEvent records the event and returns the alerts of the rules that start firing
+func (engine *rulesEngine) consumeEvent(event *events.Event) []*Alert {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+
+	now := engine.now()
+	switch event.Event {
+	case "nwc_payment_sent":
+		engine.recordEvent(metricPaymentsSent, now)
+	case "nwc_payment_failed", "nwc_payment_failed_async":
+		engine.recordEvent(config.AlertMetricPaymentFailures, now)
+	case "nwc_budget_exceeded":
+		engine.recordEvent(config.AlertMetricBudgetExceeded, now)
+	case "nwc_permission_denied":
+		engine.recordEvent(config.AlertMetricPermissionDenied, now)
+	case "nwc_relay_disconnected":
+		if engine.relayDownSince.IsZero() {
+			engine.relayDownSince = now
+		}
+	case "nwc_relay_connected":
+		engine.relayDownSince = time.Time{}
+	default:
+		return nil
+	}
+	return engine.evaluateRules(now)
+}
+
+// evaluate returns the alerts of the rules that start firing without a new event
+func (engine *rulesEngine) evaluate() []*Alert {
+	engine.mu.Lock()
+	defer engine.mu.Unlock()
+	return engine.evaluateRules(engine.now())
+}
+
+func (engine *rulesEngine) recordEvent(metric string, now time.Time) {
+	eventTimes := engine.eventTimes[metric]
+	// events are recorded in order, so the expired ones are at the start
+	expired := 0
+	for expired < len(eventTimes) && now.Sub(eventTimes[expired]) > engine.maxWindow {
+		expired++
+	}
+	engine.eventTimes[metric] = append(eventTimes[expired:], now)
+}
+
+func (engine *rulesEngine) countEvents(metric string, window time.Duration, now time.Time) int {
+	count := 0
+	for _, eventTime := range engine.eventTimes[metric] {
+		if now.Sub(eventTime) <= window {
+			count++
+		}
+	}
+	return count
+}
+
+func (engine *rulesEngine) evaluateRules(now time.Time) []*Alert {
+	alerts := []*Alert{}
+	for i, rule := range engine.rules {
+		message, matches := engine.evaluateRule(&rule, now)
+		if matches && !engine.firing[i] {
+			alerts = append(alerts, &Alert{
+				Type:      config.NotificationAlertRule,
+				Title:     "Alert: " + rule.Rule,
+				Message:   message,
+				CreatedAt: now,
+			})
+		}
+		engine.firing[i] = matches
+	}
+	return alerts
+}
+
+// evaluateRule returns whether the condition of the rule is true, with a message that describes the current value
+func (engine *rulesEngine) evaluateRule(rule *config.AlertRule, now time.Time) (string, bool) {
+	window := formatWindow(rule.Window)
+	switch rule.Metric {
+	case config.AlertMetricPaymentFailureRate:
+		failed := engine.countEvents(config.AlertMetricPaymentFailures, rule.Window, now)
+		total := failed + engine.countEvents(metricPaymentsSent, rule.Window, now)
+		if total < minPaymentsForFailureRate {
+			return "", false
+		}
+		failureRate := float64(failed) * 100 / float64(total)
+		return fmt.Sprintf("%.0f%% of the %d payments in the last %s failed.", failureRate, total, window), failureRate > rule.Threshold
+	case config.AlertMetricPaymentFailures:
+		count := engine.countEvents(rule.Metric, rule.Window, now)
+		return fmt.Sprintf("%d payments failed in the last %s.", count, window), float64(count) > rule.Threshold
+	case config.AlertMetricBudgetExceeded:
+		count := engine.countEvents(rule.Metric, rule.Window, now)
+		return fmt.Sprintf("%d payments were rejected in the last %s because they exceed the budget of the app.", count, window), float64(count) > rule.Threshold
+	case config.AlertMetricPermissionDenied:
+		count := engine.countEvents(rule.Metric, rule.Window, now)
+		return fmt.Sprintf("%d requests were rejected in the last %s because the app did not have the permission.", count, window), float64(count) > rule.Threshold
+	case config.AlertMetricRelayDisconnected:
+		if engine.relayDownSince.IsZero() {
+			return "", false
+		}
+		downFor := now.Sub(engine.relayDownSince)
+		return fmt.Sprintf("The hub has not been connected to the relay for %s, apps cannot reach it.", formatWindow(downFor)), downFor > rule.Window
+	}
+	return "", false
+}
+
+// formatWindow formats the duration without trailing zero units, e.g. 10m instead of 10m0s
+func formatWindow(duration time.Duration) string {
+	duration = duration.Round(time.Second)
+	switch {
+	case duration >= time.Hour && duration%time.Hour == 0:
+		return fmt.Sprintf("%dh", duration/time.Hour)
+	case duration >= time.Minute && duration%time.Minute == 0:
+		return fmt.Sprintf("%dm", duration/time.Minute)
+	}
+	return duration.String()
+}
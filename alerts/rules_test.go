@@ -0,0 +1,84 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRulesEngine(t *testing.T, rules string) (*rulesEngine, *time.Time) {
+	alertRules, err := config.ParseAlertRules(rules)
+	assert.NoError(t, err)
+	engine := newRulesEngine(alertRules)
+	now := time.Date(2024, time.July, 1, 12, 0, 0, 0, time.UTC)
+	engine.now = func() time.Time { return now }
+	return engine, &now
+}
+
+func TestRulesEngine_PaymentFailureRate(t *testing.T) {
+	engine, now := newTestRulesEngine(t, "payment_failure_rate > 20% over 10m")
+
+	for i := 0; i < 3; i++ {
+		assert.Empty(t, engine.consumeEvent(&events.Event{Event: "nwc_payment_sent"}))
+	}
+	// not enough payments for a rate yet
+	assert.Empty(t, engine.consumeEvent(&events.Event{Event: "nwc_payment_failed"}))
+
+	alerts := engine.consumeEvent(&events.Event{Event: "nwc_payment_failed"})
+	assert.Equal(t, 1, len(alerts))
+	assert.Equal(t, config.NotificationAlertRule, alerts[0].Type)
+	assert.Equal(t, "Alert: payment_failure_rate > 20% over 10m", alerts[0].Title)
+	assert.Equal(t, "40% of the 5 payments in the last 10m failed.", alerts[0].Message)
+
+	// fires only once while the rate stays high
+	assert.Empty(t, engine.consumeEvent(&events.Event{Event: "nwc_payment_failed"}))
+
+	// the failures are out of the window
+	*now = now.Add(11 * time.Minute)
+	assert.Empty(t, engine.evaluate())
+	for i := 0; i < 4; i++ {
+		assert.Empty(t, engine.consumeEvent(&events.Event{Event: "nwc_payment_sent"}))
+	}
+	// 20% is not above the threshold
+	assert.Empty(t, engine.consumeEvent(&events.Event{Event: "nwc_payment_failed"}))
+	assert.Equal(t, 1, len(engine.consumeEvent(&events.Event{Event: "nwc_payment_failed_async"})))
+}
+
+func TestRulesEngine_Counts(t *testing.T) {
+	engine, now := newTestRulesEngine(t, "budget_exceeded > 1 over 1h, permission_denied > 0 over 1h")
+
+	assert.Empty(t, engine.consumeEvent(&events.Event{Event: "nwc_budget_exceeded"}))
+	*now = now.Add(30 * time.Minute)
+	alerts := engine.consumeEvent(&events.Event{Event: "nwc_budget_exceeded"})
+	assert.Equal(t, 1, len(alerts))
+	assert.Equal(t, "2 payments were rejected in the last 1h because they exceed the budget of the app.", alerts[0].Message)
+
+	alerts = engine.consumeEvent(&events.Event{Event: "nwc_permission_denied"})
+	assert.Equal(t, 1, len(alerts))
+	assert.Equal(t, "Alert: permission_denied > 0 over 1h", alerts[0].Title)
+
+	// unrelated events are not evaluated
+	assert.Nil(t, engine.consumeEvent(&events.Event{Event: "nwc_payment_received"}))
+}
+
+func TestRulesEngine_RelayDisconnected(t *testing.T) {
+	engine, now := newTestRulesEngine(t, "relay_disconnected > 2m")
+
+	assert.Empty(t, engine.consumeEvent(&events.Event{Event: "nwc_relay_disconnected"}))
+	*now = now.Add(time.Minute)
+	assert.Empty(t, engine.evaluate())
+	*now = now.Add(90 * time.Second)
+	alerts := engine.evaluate()
+	assert.Equal(t, 1, len(alerts))
+	assert.Equal(t, "The hub has not been connected to the relay for 2m30s, apps cannot reach it.", alerts[0].Message)
+	assert.Empty(t, engine.evaluate())
+
+	// fires again after the relay reconnected and disconnected again
+	assert.Empty(t, engine.consumeEvent(&events.Event{Event: "nwc_relay_connected"}))
+	assert.Empty(t, engine.consumeEvent(&events.Event{Event: "nwc_relay_disconnected"}))
+	*now = now.Add(3 * time.Minute)
+	assert.Equal(t, 1, len(engine.evaluate()))
+}
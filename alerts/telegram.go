@@ -0,0 +1,180 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+)
+
+const (
+	telegramApiUrl = "https://api.telegram.org"
+	// getUpdates waits up to this long for new messages before returning an empty list
+	telegramPollTimeoutSecs = 30
+	telegramRetryDelay      = 10 * time.Second
+)
+
+type telegramChannel struct {
+	apiUrl     string
+	botToken   string
+	chatId     string
+	httpClient *http.Client
+}
+
+type telegramResponse struct {
+	Ok          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+type telegramUpdate struct {
+	UpdateId int64            `json:"update_id"`
+	Message  *telegramMessage `json:"message"`
+}
+
+type telegramMessage struct {
+	Text string       `json:"text"`
+	Chat telegramChat `json:"chat"`
+}
+
+type telegramChat struct {
+	Id       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+func newTelegramChannel(apiUrl string, botToken string, chatId string) *telegramChannel {
+	return &telegramChannel{
+		apiUrl:   apiUrl,
+		botToken: botToken,
+		chatId:   chatId,
+		httpClient: &http.Client{
+			Timeout: (telegramPollTimeoutSecs + 10) * time.Second,
+		},
+	}
+}
+
+func (telegram *telegramChannel) name() string {
+	return "telegram"
+}
+
+func (telegram *telegramChannel) send(ctx context.Context, alert *Alert) error {
+	return telegram.sendMessage(ctx, alert.Title+"\n"+alert.Message)
+}
+
+func (telegram *telegramChannel) sendMessage(ctx context.Context, text string) error {
+	return telegram.call(ctx, "sendMessage", map[string]interface{}{
+		"chat_id": telegram.chatId,
+		"text":    text,
+	}, nil)
+}
+
+// call sends a request to the Bot API, see https://core.telegram.org/bots/api#making-requests
+func (telegram *telegramChannel) call(ctx context.Context, method string, params map[string]interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/bot%s/%s", telegram.apiUrl, telegram.botToken, method), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := telegram.httpClient.Do(req)
+	if err != nil {
+		// the error contains the url, which must not leak the bot token into the logs
+		return fmt.Errorf("failed to call %s: %w", method, errors.Unwrap(err))
+	}
+	defer res.Body.Close()
+
+	telegramResponse := telegramResponse{}
+	err = json.NewDecoder(res.Body).Decode(&telegramResponse)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s response (status %d): %w", method, res.StatusCode, err)
+	}
+	if !telegramResponse.Ok {
+		return fmt.Errorf("%s failed: %s", method, telegramResponse.Description)
+	}
+	if result != nil {
+		return json.Unmarshal(telegramResponse.Result, result)
+	}
+	return nil
+}
+
+// listenForCommands long-polls the bot for new messages.
+// Only messages of the configured chat are answered, the bot can be found by anyone.
+func (telegram *telegramChannel) listenForCommands(ctx context.Context, lnClient lnclient.LNClient) {
+	var offset int64
+	for {
+		updates := []telegramUpdate{}
+		err := telegram.call(ctx, "getUpdates", map[string]interface{}{
+			"offset":          offset,
+			"timeout":         telegramPollTimeoutSecs,
+			"allowed_updates": []string{"message"},
+		}, &updates)
+		if ctx.Err() != nil {
+			logger.Logger.Info("Stopped Telegram bot")
+			return
+		}
+		if err != nil {
+			logger.Logger.WithError(err).Error("Failed to get Telegram updates")
+			select {
+			case <-ctx.Done():
+				logger.Logger.Info("Stopped Telegram bot")
+				return
+			case <-time.After(telegramRetryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateId + 1
+			if update.Message == nil || !telegram.isConfiguredChat(update.Message.Chat.Id, update.Message.Chat.Username) {
+				continue
+			}
+			reply := telegram.handleCommand(ctx, update.Message.Text, lnClient)
+			if reply == "" {
+				continue
+			}
+			err := telegram.sendMessage(ctx, reply)
+			if err != nil {
+				logger.Logger.WithError(err).Error("Failed to reply to Telegram command")
+			}
+		}
+	}
+}
+
+// the chat ID can also be the username of a channel, e.g. @mychannel
+func (telegram *telegramChannel) isConfiguredChat(chatId int64, username string) bool {
+	return strconv.FormatInt(chatId, 10) == telegram.chatId || (username != "" && "@"+username == telegram.chatId)
+}
+
+// handleCommand returns the reply to the message, or an empty string for messages that are not commands
+func (telegram *telegramChannel) handleCommand(ctx context.Context, text string, lnClient lnclient.LNClient) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+	// in groups commands can be addressed to a bot, e.g. /balance@mybot
+	command, _, _ := strings.Cut(fields[0], "@")
+
+	switch command {
+	case "/balance":
+		balances, err := lnClient.GetBalances(ctx)
+		if err != nil {
+			logger.Logger.WithError(err).Error("Failed to get balances")
+			return "Failed to get the balance of your node"
+		}
+		return fmt.Sprintf("Lightning: %s\nOnchain: %s", formatSats(balances.Lightning.TotalSpendable/1000), formatSats(balances.Onchain.Spendable))
+	default:
+		return "Unknown command, available commands: /balance"
+	}
+}
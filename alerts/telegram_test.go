@@ -0,0 +1,146 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+// newMockTelegramApi returns the requests to sendMessage, getUpdates returns the given updates once
+func newMockTelegramApi(t *testing.T, updates []telegramUpdate) (*httptest.Server, chan map[string]interface{}) {
+	messages := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := map[string]interface{}{}
+		err := json.NewDecoder(r.Body).Decode(&params)
+		assert.NoError(t, err)
+
+		result := []byte("true")
+		switch r.URL.Path {
+		case "/bottoken/sendMessage":
+			messages <- params
+		case "/bottoken/getUpdates":
+			if params["offset"].(float64) == 0 {
+				result, _ = json.Marshal(updates)
+			} else {
+				result = []byte("[]")
+				time.Sleep(10 * time.Millisecond)
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"ok":false,"description":"Not Found"}`))
+			return
+		}
+		json.NewEncoder(w).Encode(telegramResponse{Ok: true, Result: result})
+	}))
+	return server, messages
+}
+
+func TestTelegram_PaymentReceived(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	server, messages := newMockTelegramApi(t, nil)
+	defer server.Close()
+	alertsSvc := &alertsService{
+		cfg:      svc.Cfg,
+		channels: []channel{newTelegramChannel(server.URL, "token", "123")},
+	}
+
+	alertsSvc.ConsumeEvent(context.TODO(), &events.Event{
+		Event: "nwc_payment_received",
+		Properties: &lnclient.Transaction{
+			Amount:      21000,
+			Description: "coffee",
+		},
+	}, nil)
+
+	select {
+	case message := <-messages:
+		assert.Equal(t, "123", message["chat_id"])
+		assert.Equal(t, "Received 21 sats\ncoffee", message["text"])
+	case <-time.After(time.Second):
+		t.Fatal("no message was sent")
+	}
+}
+
+func TestTelegram_DisabledEventType(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().NotificationEvents = config.NotificationPaymentFailed
+
+	server, messages := newMockTelegramApi(t, nil)
+	defer server.Close()
+	alertsSvc := &alertsService{
+		cfg:      svc.Cfg,
+		channels: []channel{newTelegramChannel(server.URL, "token", "123")},
+	}
+
+	alertsSvc.ConsumeEvent(context.TODO(), &events.Event{
+		Event:      "nwc_payment_sent",
+		Properties: &lnclient.Transaction{Amount: 21000},
+	}, nil)
+	alertsSvc.ConsumeEvent(context.TODO(), &events.Event{
+		Event: "nwc_payment_failed",
+		Properties: map[string]interface{}{
+			"error":  "no route",
+			"amount": 21,
+		},
+	}, nil)
+
+	select {
+	case message := <-messages:
+		assert.Equal(t, "Payment of 21 sats failed\nno route", message["text"])
+	case <-time.After(time.Second):
+		t.Fatal("no message was sent")
+	}
+	select {
+	case message := <-messages:
+		t.Fatalf("unexpected message %v", message)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTelegram_BalanceCommand(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	newUpdate := func(updateId int64, chatId int64, text string) telegramUpdate {
+		return telegramUpdate{
+			UpdateId: updateId,
+			Message: &telegramMessage{
+				Text: text,
+				Chat: telegramChat{Id: chatId},
+			},
+		}
+	}
+	server, messages := newMockTelegramApi(t, []telegramUpdate{
+		// other chats are not answered
+		newUpdate(1, 456, "/balance"),
+		newUpdate(2, 123, "/balance"),
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go newTelegramChannel(server.URL, "token", "123").listenForCommands(ctx, svc.LNClient)
+
+	select {
+	case message := <-messages:
+		assert.Equal(t, "123", message["chat_id"])
+		assert.Equal(t, "Lightning: 21 sats\nOnchain: 1000 sats", message["text"])
+	case <-time.After(time.Second):
+		t.Fatal("no message was sent")
+	}
+}
@@ -0,0 +1,278 @@
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/config"
+)
+
+// colors of the status in Discord embeds and Slack attachments
+var statusColors = map[string]int{
+	ALERT_STATUS_SETTLED:  0x2eb67d,
+	ALERT_STATUS_FAILED:   0xe01e5a,
+	ALERT_STATUS_REJECTED: 0xecb22e,
+}
+
+type alertField struct {
+	name  string
+	value string
+}
+
+// alertFields returns the details of the alert that are shown as separate fields
+func alertFields(alert *Alert) []alertField {
+	fields := []alertField{}
+	if alert.AmountSat > 0 {
+		fields = append(fields, alertField{"Amount", formatSats(alert.AmountSat)})
+	}
+	if alert.AppName != "" {
+		fields = append(fields, alertField{"App", alert.AppName})
+	}
+	if alert.Status != "" {
+		fields = append(fields, alertField{"Status", alert.Status})
+	}
+	return fields
+}
+
+func postWebhook(ctx context.Context, httpClient *http.Client, webhookUrl string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookUrl, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		// the webhook url is a secret and must not end up in the logs
+		return fmt.Errorf("failed to post webhook: %w", errors.Unwrap(err))
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		responseBody, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("unexpected status %d: %s", res.StatusCode, responseBody)
+	}
+	return nil
+}
+
+type discordChannel struct {
+	webhookUrl string
+	// shown as the author of the messages
+	username   string
+	httpClient *http.Client
+}
+
+type discordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color,omitempty"`
+	Fields      []discordEmbedField `json:"fields,omitempty"`
+	Timestamp   string              `json:"timestamp"`
+}
+
+type discordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+func newDiscordChannel(webhookUrl string, username string) *discordChannel {
+	return &discordChannel{
+		webhookUrl: webhookUrl,
+		username:   username,
+		httpClient: &http.Client{
+			Timeout: sendTimeout,
+		},
+	}
+}
+
+func (discord *discordChannel) name() string {
+	return "discord"
+}
+
+// send posts the alert as an embed, see https://discord.com/developers/docs/resources/webhook#execute-webhook
+func (discord *discordChannel) send(ctx context.Context, alert *Alert) error {
+	embed := discordEmbed{
+		Title:       alert.Title,
+		Description: alert.Message,
+		Color:       statusColors[alert.Status],
+		Timestamp:   alert.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	for _, field := range alertFields(alert) {
+		embed.Fields = append(embed.Fields, discordEmbedField{
+			Name:   field.name,
+			Value:  field.value,
+			Inline: true,
+		})
+	}
+	return postWebhook(ctx, discord.httpClient, discord.webhookUrl, map[string]interface{}{
+		"username": discord.username,
+		"embeds":   []discordEmbed{embed},
+		// app names must not be able to ping @everyone
+		"allowed_mentions": map[string]interface{}{
+			"parse": []string{},
+		},
+	})
+}
+
+// app names must not be able to mention anyone in Slack either, see https://api.slack.com/reference/surfaces/formatting#escaping
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+type slackChannel struct {
+	webhookUrl string
+	httpClient *http.Client
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type   string      `json:"type"`
+	Text   *slackText  `json:"text,omitempty"`
+	Fields []slackText `json:"fields,omitempty"`
+}
+
+func newSlackChannel(webhookUrl string) *slackChannel {
+	return &slackChannel{
+		webhookUrl: webhookUrl,
+		httpClient: &http.Client{
+			Timeout: sendTimeout,
+		},
+	}
+}
+
+func (slack *slackChannel) name() string {
+	return "slack"
+}
+
+// send posts the alert as blocks in a colored attachment, see https://api.slack.com/messaging/webhooks
+func (slack *slackChannel) send(ctx context.Context, alert *Alert) error {
+	blocks := []slackBlock{{
+		Type: "header",
+		Text: &slackText{Type: "plain_text", Text: alert.Title},
+	}}
+	if alert.Message != "" {
+		blocks = append(blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "plain_text", Text: alert.Message},
+		})
+	}
+	fields := alertFields(alert)
+	if len(fields) > 0 {
+		section := slackBlock{Type: "section"}
+		for _, field := range fields {
+			section.Fields = append(section.Fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", field.name, slackEscaper.Replace(field.value))})
+		}
+		blocks = append(blocks, section)
+	}
+
+	return postWebhook(ctx, slack.httpClient, slack.webhookUrl, map[string]interface{}{
+		// shown in notifications, which do not render blocks
+		"text": alert.Title,
+		"attachments": []map[string]interface{}{{
+			"color":  fmt.Sprintf("#%06x", statusColors[alert.Status]),
+			"blocks": blocks,
+		}},
+	})
+}
+
+// webhookChannel posts the alerts as JSON for custom consumers
+type webhookChannel struct {
+	webhookUrl string
+	format     string
+	httpClient *http.Client
+}
+
+type webhookPayload struct {
+	Type      string          `json:"type"`
+	CreatedAt int64           `json:"created_at"`
+	Alert     webhookAlert    `json:"alert"`
+	Payment   *webhookPayment `json:"payment,omitempty"`
+}
+
+type webhookAlert struct {
+	Title   string `json:"title"`
+	Message string `json:"message"`
+}
+
+type webhookPayment struct {
+	AmountSat int64       `json:"amount_sat"`
+	Status    string      `json:"status"`
+	App       *webhookApp `json:"app,omitempty"`
+}
+
+type webhookApp struct {
+	Name string `json:"name"`
+}
+
+// flatWebhookPayload is meant for automation tools (e.g. Zapier, IFTTT or n8n) that cannot map nested fields.
+// All fields are always present and their names do not change.
+type flatWebhookPayload struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Title     string `json:"title"`
+	Message   string `json:"message"`
+	AmountSat int64  `json:"amount_sat"`
+	AppName   string `json:"app_name"`
+	Status    string `json:"status"`
+}
+
+func newWebhookChannel(webhookUrl string, format string) *webhookChannel {
+	return &webhookChannel{
+		webhookUrl: webhookUrl,
+		format:     format,
+		httpClient: &http.Client{
+			Timeout: sendTimeout,
+		},
+	}
+}
+
+func (webhook *webhookChannel) name() string {
+	return "webhook"
+}
+
+func (webhook *webhookChannel) send(ctx context.Context, alert *Alert) error {
+	if webhook.format == config.FlatWebhookFormat {
+		return postWebhook(ctx, webhook.httpClient, webhook.webhookUrl, &flatWebhookPayload{
+			Type:      alert.Type,
+			Timestamp: alert.CreatedAt.UTC().Format(time.RFC3339),
+			Title:     alert.Title,
+			Message:   alert.Message,
+			AmountSat: alert.AmountSat,
+			AppName:   alert.AppName,
+			Status:    alert.Status,
+		})
+	}
+
+	payload := &webhookPayload{
+		Type:      alert.Type,
+		CreatedAt: alert.CreatedAt.Unix(),
+		Alert: webhookAlert{
+			Title:   alert.Title,
+			Message: alert.Message,
+		},
+	}
+	if alert.Status != "" {
+		payload.Payment = &webhookPayment{
+			AmountSat: alert.AmountSat,
+			Status:    alert.Status,
+		}
+		if alert.AppName != "" {
+			payload.Payment.App = &webhookApp{Name: alert.AppName}
+		}
+	}
+	return postWebhook(ctx, webhook.httpClient, webhook.webhookUrl, payload)
+}
@@ -0,0 +1,148 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+var budgetExceededAlert = &Alert{
+	Type:      config.NotificationBudgetExceeded,
+	Title:     "<!channel> reached its budget",
+	Message:   "A payment of 21 sats was rejected because it exceeds the budget of the app.",
+	AmountSat: 21,
+	AppName:   "<!channel>",
+	Status:    ALERT_STATUS_REJECTED,
+}
+
+func newMockWebhook(t *testing.T, status int) (*httptest.Server, *map[string]interface{}) {
+	payload := map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		assert.NoError(t, err)
+		w.WriteHeader(status)
+	}))
+	return server, &payload
+}
+
+func TestDiscord_Send(t *testing.T) {
+	server, payload := newMockWebhook(t, http.StatusNoContent)
+	defer server.Close()
+
+	err := newDiscordChannel(server.URL, "Alby Hub").send(context.TODO(), budgetExceededAlert)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Alby Hub", (*payload)["username"])
+
+	embed := (*payload)["embeds"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, budgetExceededAlert.Title, embed["title"])
+	assert.Equal(t, float64(0xecb22e), embed["color"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "Amount", "value": "21 sats", "inline": true},
+		map[string]interface{}{"name": "App", "value": "<!channel>", "inline": true},
+		map[string]interface{}{"name": "Status", "value": "rejected", "inline": true},
+	}, embed["fields"])
+	assert.Equal(t, map[string]interface{}{"parse": []interface{}{}}, (*payload)["allowed_mentions"])
+}
+
+func TestSlack_Send(t *testing.T) {
+	server, payload := newMockWebhook(t, http.StatusOK)
+	defer server.Close()
+
+	err := newSlackChannel(server.URL).send(context.TODO(), budgetExceededAlert)
+	assert.NoError(t, err)
+
+	assert.Equal(t, budgetExceededAlert.Title, (*payload)["text"])
+	attachment := (*payload)["attachments"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "#ecb22e", attachment["color"])
+	blocks := attachment["blocks"].([]interface{})
+	assert.Equal(t, 3, len(blocks))
+	fields := blocks[2].(map[string]interface{})["fields"].([]interface{})
+	assert.Equal(t, "*App*\n&lt;!channel&gt;", fields[1].(map[string]interface{})["text"])
+}
+
+func TestWebhook_ErrorStatus(t *testing.T) {
+	server, _ := newMockWebhook(t, http.StatusNotFound)
+	defer server.Close()
+
+	err := newSlackChannel(server.URL).send(context.TODO(), budgetExceededAlert)
+	assert.EqualError(t, err, "unexpected status 404: ")
+}
+
+func TestWebhook_Formats(t *testing.T) {
+	alert := *budgetExceededAlert
+	alert.CreatedAt = time.Unix(1723636800, 0)
+
+	server, payload := newMockWebhook(t, http.StatusOK)
+	defer server.Close()
+
+	err := newWebhookChannel(server.URL, config.NestedWebhookFormat).send(context.TODO(), &alert)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"type":       "budget_exceeded",
+		"created_at": float64(1723636800),
+		"alert": map[string]interface{}{
+			"title":   alert.Title,
+			"message": alert.Message,
+		},
+		"payment": map[string]interface{}{
+			"amount_sat": float64(21),
+			"status":     "rejected",
+			"app":        map[string]interface{}{"name": "<!channel>"},
+		},
+	}, *payload)
+
+	*payload = map[string]interface{}{}
+	err = newWebhookChannel(server.URL, config.FlatWebhookFormat).send(context.TODO(), &alert)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"type":       "budget_exceeded",
+		"timestamp":  "2024-08-14T12:00:00Z",
+		"title":      alert.Title,
+		"message":    alert.Message,
+		"amount_sat": float64(21),
+		"app_name":   "<!channel>",
+		"status":     "rejected",
+	}, *payload)
+}
+
+func TestReload_WebhookUrl(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	received := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- struct{}{}
+	}))
+	defer server.Close()
+
+	alertsSvc := NewAlertsService(svc.Cfg)
+	assert.Empty(t, alertsSvc.channels)
+
+	currentEnv := svc.Cfg.GetEnv()
+	reloadedEnv := *currentEnv
+	reloadedEnv.WebhookUrl = server.URL
+	svc.Cfg.Reload(&reloadedEnv)
+	alertsSvc.Reload()
+
+	// the config is replaced, not changed in place
+	assert.Empty(t, currentEnv.WebhookUrl)
+	assert.Equal(t, server.URL, svc.Cfg.GetEnv().WebhookUrl)
+	assert.Len(t, alertsSvc.channels, 1)
+
+	alertsSvc.sendAlert(context.TODO(), budgetExceededAlert)
+	select {
+	case <-received:
+	case <-time.After(time.Second):
+		t.Fatal("no webhook was sent")
+	}
+}
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+)
+
+var activityTypes = []string{
+	constants.ACTIVITY_TYPE_PAYMENT,
+	constants.ACTIVITY_TYPE_APP,
+	constants.ACTIVITY_TYPE_PERMISSION,
+	constants.ACTIVITY_TYPE_FAILURE,
+}
+
+func (api *api) ListActivities(cursor uint, limit uint64, types []string) (*ListActivitiesResponse, error) {
+	for _, activityType := range types {
+		if !slices.Contains(activityTypes, activityType) {
+			return nil, fmt.Errorf("did not recognize activity type: %s", activityType)
+		}
+	}
+
+	dbActivities, nextCursor, err := api.svc.GetActivityService().ListActivities(cursor, limit, types)
+	if err != nil {
+		return nil, err
+	}
+
+	activities := []Activity{}
+	for _, dbActivity := range dbActivities {
+		activities = append(activities, toApiActivity(&dbActivity))
+	}
+
+	return &ListActivitiesResponse{
+		Activities: activities,
+		NextCursor: nextCursor,
+	}, nil
+}
+
+func (api *api) ListNotifications() (*ListNotificationsResponse, error) {
+	dbNotifications, unreadCount, err := api.svc.GetActivityService().ListNotifications(0)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := []Activity{}
+	for _, dbNotification := range dbNotifications {
+		notifications = append(notifications, toApiActivity(&dbNotification))
+	}
+
+	return &ListNotificationsResponse{
+		Notifications: notifications,
+		UnreadCount:   unreadCount,
+	}, nil
+}
+
+func (api *api) MarkNotificationsRead(markNotificationsReadRequest *MarkNotificationsReadRequest) error {
+	return api.svc.GetActivityService().MarkNotificationsRead(markNotificationsReadRequest.Ids)
+}
+
+func toApiActivity(dbActivity *db.Activity) Activity {
+	return Activity{
+		Id:          dbActivity.ID,
+		Type:        dbActivity.Type,
+		Event:       dbActivity.Event,
+		Title:       dbActivity.Title,
+		Description: dbActivity.Description,
+		AppName:     dbActivity.AppName,
+		AmountSat:   dbActivity.AmountSat,
+		PaymentHash: dbActivity.PaymentHash,
+		CreatedAt:   dbActivity.CreatedAt,
+	}
+}
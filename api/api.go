@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
 	"sync"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/getAlby/hub/db/queries"
 	"github.com/getAlby/hub/events"
 	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/lnurl"
 	"github.com/getAlby/hub/logger"
 	permissions "github.com/getAlby/hub/nip47/permissions"
 	"github.com/getAlby/hub/service"
@@ -38,6 +40,10 @@ type api struct {
 	permissionsSvc permissions.PermissionsService
 	keys           keys.Keys
 	albyOAuthSvc   alby.AlbyOAuthService
+	lnurlSvc       lnurl.LNURLService
+	eventPublisher events.EventPublisher
+
+	walletSummaryCache walletSummaryCache
 }
 
 func NewAPI(svc service.Service, gormDB *gorm.DB, config config.Config, keys keys.Keys, albyOAuthSvc alby.AlbyOAuthService, eventPublisher events.EventPublisher) *api {
@@ -49,6 +55,8 @@ func NewAPI(svc service.Service, gormDB *gorm.DB, config config.Config, keys key
 		permissionsSvc: permissions.NewPermissionsService(gormDB, eventPublisher),
 		keys:           keys,
 		albyOAuthSvc:   albyOAuthSvc,
+		lnurlSvc:       lnurl.NewLNURLService(gormDB, config, svc.GetTransactionsService()),
+		eventPublisher: eventPublisher,
 	}
 }
 
@@ -81,8 +89,6 @@ func (api *api) CreateApp(createAppRequest *CreateAppRequest) (*CreateAppRespons
 		return nil, err
 	}
 
-	relayUrl := api.cfg.GetRelayUrl()
-
 	responseBody := &CreateAppResponse{}
 	responseBody.Name = createAppRequest.Name
 	responseBody.Pubkey = app.NostrPubkey
@@ -92,24 +98,50 @@ func (api *api) CreateApp(createAppRequest *CreateAppRequest) (*CreateAppRespons
 		returnToUrl, err := url.Parse(createAppRequest.ReturnTo)
 		if err == nil {
 			query := returnToUrl.Query()
-			query.Add("relay", relayUrl)
+			query.Add("relay", api.cfg.GetRelayUrl())
 			query.Add("pubkey", api.keys.GetNostrPublicKey())
-			// if user.LightningAddress != "" {
-			// 	query.Add("lud16", user.LightningAddress)
-			// }
+			if lightningAddress := api.appLightningAddress(app); lightningAddress != "" {
+				query.Add("lud16", lightningAddress)
+			}
 			returnToUrl.RawQuery = query.Encode()
 			responseBody.ReturnTo = returnToUrl.String()
 		}
 	}
 
-	var lud16 string
-	// if user.LightningAddress != "" {
-	// 	lud16 = fmt.Sprintf("&lud16=%s", user.LightningAddress)
-	// }
-	responseBody.PairingUri = fmt.Sprintf("nostr+walletconnect://%s?relay=%s&secret=%s%s", api.keys.GetNostrPublicKey(), relayUrl, pairingSecretKey, lud16)
+	responseBody.PairingUri = api.pairingUri(app, pairingSecretKey)
 	return responseBody, nil
 }
 
+func (api *api) RegenerateAppSecret(userApp *db.App) (*CreateAppResponse, error) {
+	pairingSecretKey, err := api.dbSvc.RegenerateAppSecret(userApp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateAppResponse{
+		Name:          userApp.Name,
+		Pubkey:        userApp.NostrPubkey,
+		PairingSecret: pairingSecretKey,
+		PairingUri:    api.pairingUri(userApp, pairingSecretKey),
+	}, nil
+}
+
+// isolated apps are custodial subaccounts and cannot receive to the hub's lightning address
+func (api *api) appLightningAddress(app *db.App) string {
+	if app.Isolated {
+		return ""
+	}
+	return api.lnurlSvc.GetLightningAddress(api.cfg.GetEnv().LightningAddressUsername)
+}
+
+func (api *api) pairingUri(app *db.App, pairingSecretKey string) string {
+	var lud16 string
+	if lightningAddress := api.appLightningAddress(app); lightningAddress != "" {
+		lud16 = fmt.Sprintf("&lud16=%s", lightningAddress)
+	}
+	return fmt.Sprintf("nostr+walletconnect://%s?relay=%s&secret=%s%s", api.keys.GetNostrPublicKey(), api.cfg.GetRelayUrl(), pairingSecretKey, lud16)
+}
+
 func (api *api) UpdateApp(userApp *db.App, updateAppRequest *UpdateAppRequest) error {
 	maxAmount := updateAppRequest.MaxAmountSat
 	budgetRenewal := updateAppRequest.BudgetRenewal
@@ -124,12 +156,28 @@ func (api *api) UpdateApp(userApp *db.App, updateAppRequest *UpdateAppRequest) e
 		return fmt.Errorf("invalid expiresAt: %v", err)
 	}
 
+	if updateAppRequest.LightningAddressUsername != nil && *updateAppRequest.LightningAddressUsername != "" {
+		err = api.lnurlSvc.ValidateAppUsername(*updateAppRequest.LightningAddressUsername)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = api.db.Transaction(func(tx *gorm.DB) error {
+		if updateAppRequest.LightningAddressUsername != nil {
+			err := tx.Model(userApp).Update("lightning_address_username", *updateAppRequest.LightningAddressUsername).Error
+			if err != nil {
+				return err
+			}
+		}
+
 		// Update existing permissions with new budget and expiry
 		err := tx.Model(&db.AppPermission{}).Where("app_id", userApp.ID).Updates(map[string]interface{}{
 			"ExpiresAt":     expiresAt,
 			"MaxAmountSat":  maxAmount,
 			"BudgetRenewal": budgetRenewal,
+			// the forecast changes with the budget
+			"BudgetWarningSentAt": nil,
 		}).Error
 		if err != nil {
 			return err
@@ -172,12 +220,62 @@ func (api *api) UpdateApp(userApp *db.App, updateAppRequest *UpdateAppRequest) e
 		// commit transaction
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	api.eventPublisher.Publish(&events.Event{
+		Event: "app_updated",
+		Properties: map[string]interface{}{
+			"name": userApp.Name,
+		},
+	})
+
+	return nil
 }
 
 func (api *api) DeleteApp(userApp *db.App) error {
-	return api.db.Delete(userApp).Error
+	err := api.db.Delete(userApp).Error
+	if err != nil {
+		return err
+	}
+
+	api.eventPublisher.Publish(&events.Event{
+		Event: "app_deleted",
+		Properties: map[string]interface{}{
+			"name": userApp.Name,
+		},
+	})
+
+	return nil
+}
+
+func (api *api) CreateLNURLWithdraw(userApp *db.App, createLNURLWithdrawRequest *CreateLNURLWithdrawRequest) (*CreateLNURLWithdrawResponse, error) {
+	if !api.cfg.IsFeatureEnabled(config.FeatureLNURL) {
+		return nil, errors.New("LNURL is not enabled on this hub")
+	}
+
+	expiresAt, err := api.parseExpiresAt(createLNURLWithdrawRequest.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	withdraw, err := api.lnurlSvc.CreateWithdraw(userApp.ID, createLNURLWithdrawRequest.AmountSat*1000, createLNURLWithdrawRequest.Description, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	encodedLNURL, err := api.lnurlSvc.EncodeWithdrawLNURL(withdraw.K1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateLNURLWithdrawResponse{
+		Id:        withdraw.ID,
+		LNURL:     encodedLNURL,
+		AmountSat: withdraw.AmountMsat / 1000,
+		ExpiresAt: withdraw.ExpiresAt,
+	}, nil
 }
 
 func (api *api) GetApp(dbApp *db.App) *App {
@@ -206,24 +304,28 @@ func (api *api) GetApp(dbApp *db.App) *App {
 	budgetUsage = queries.GetBudgetUsageSat(api.db, &paySpecificPermission)
 
 	response := App{
-		ID:            dbApp.ID,
-		Name:          dbApp.Name,
-		Description:   dbApp.Description,
-		CreatedAt:     dbApp.CreatedAt,
-		UpdatedAt:     dbApp.UpdatedAt,
-		NostrPubkey:   dbApp.NostrPubkey,
-		ExpiresAt:     expiresAt,
-		MaxAmountSat:  maxAmount,
-		Scopes:        requestMethods,
-		BudgetUsage:   budgetUsage,
-		BudgetRenewal: paySpecificPermission.BudgetRenewal,
-		Isolated:      dbApp.Isolated,
+		ID:               dbApp.ID,
+		Name:             dbApp.Name,
+		Description:      dbApp.Description,
+		CreatedAt:        dbApp.CreatedAt,
+		UpdatedAt:        dbApp.UpdatedAt,
+		NostrPubkey:      dbApp.NostrPubkey,
+		ExpiresAt:        expiresAt,
+		MaxAmountSat:     maxAmount,
+		Scopes:           requestMethods,
+		BudgetUsage:      budgetUsage,
+		BudgetRenewal:    paySpecificPermission.BudgetRenewal,
+		Isolated:         dbApp.Isolated,
+		LightningAddress: api.lnurlSvc.GetLightningAddress(dbApp.LightningAddressUsername),
+		Features:         api.cfg.GetAppFeatures(dbApp.ID),
 	}
 
 	if dbApp.Isolated {
 		response.Balance = queries.GetIsolatedBalance(api.db, dbApp.ID)
 	}
 
+	setBudgetForecast(&response, queries.GetBudgetForecast(api.db, &paySpecificPermission))
+
 	if lastEventResult.RowsAffected > 0 {
 		response.LastEventAt = &lastEvent.CreatedAt
 	}
@@ -248,13 +350,14 @@ func (api *api) ListApps() ([]App, error) {
 	apiApps := []App{}
 	for _, dbApp := range dbApps {
 		apiApp := App{
-			ID:          dbApp.ID,
-			Name:        dbApp.Name,
-			Description: dbApp.Description,
-			CreatedAt:   dbApp.CreatedAt,
-			UpdatedAt:   dbApp.UpdatedAt,
-			NostrPubkey: dbApp.NostrPubkey,
-			Isolated:    dbApp.Isolated,
+			ID:               dbApp.ID,
+			Name:             dbApp.Name,
+			Description:      dbApp.Description,
+			CreatedAt:        dbApp.CreatedAt,
+			UpdatedAt:        dbApp.UpdatedAt,
+			NostrPubkey:      dbApp.NostrPubkey,
+			Isolated:         dbApp.Isolated,
+			LightningAddress: api.lnurlSvc.GetLightningAddress(dbApp.LightningAddressUsername),
 		}
 
 		if dbApp.Isolated {
@@ -268,6 +371,7 @@ func (api *api) ListApps() ([]App, error) {
 				apiApp.BudgetRenewal = appPermission.BudgetRenewal
 				apiApp.MaxAmountSat = uint64(appPermission.MaxAmountSat)
 				apiApp.BudgetUsage = queries.GetBudgetUsageSat(api.db, &appPermission)
+				setBudgetForecast(&apiApp, queries.GetBudgetForecast(api.db, &appPermission))
 			}
 		}
 
@@ -282,6 +386,14 @@ func (api *api) ListApps() ([]App, error) {
 	return apiApps, nil
 }
 
+func setBudgetForecast(app *App, forecast *queries.BudgetForecast) {
+	if forecast == nil || forecast.RunsOutAt == nil {
+		return
+	}
+	app.BudgetRunsOutAt = forecast.RunsOutAt
+	app.BudgetRenewsAt = &forecast.RenewsAt
+}
+
 func (api *api) ListChannels(ctx context.Context) ([]Channel, error) {
 	if api.svc.GetLNClient() == nil {
 		return nil, errors.New("LNClient not started")
@@ -313,6 +425,7 @@ func (api *api) ListChannels(ctx context.Context) ([]Channel, error) {
 			Confirmations:                            channel.Confirmations,
 			ConfirmationsRequired:                    channel.ConfirmationsRequired,
 			ForwardingFeeBaseMsat:                    channel.ForwardingFeeBaseMsat,
+			ForwardingFeeProportionalMillionths:      channel.ForwardingFeeProportionalMillionths,
 			UnspendablePunishmentReserve:             channel.UnspendablePunishmentReserve,
 			CounterpartyUnspendablePunishmentReserve: channel.CounterpartyUnspendablePunishmentReserve,
 			Error:                                    channel.Error,
@@ -508,11 +621,14 @@ func (api *api) SignMessage(ctx context.Context, message string) (*SignMessageRe
 	}, nil
 }
 
-func (api *api) RedeemOnchainFunds(ctx context.Context, toAddress string) (*RedeemOnchainFundsResponse, error) {
+func (api *api) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (*RedeemOnchainFundsResponse, error) {
 	if api.svc.GetLNClient() == nil {
 		return nil, errors.New("LNClient not started")
 	}
-	txId, err := api.svc.GetLNClient().RedeemOnchainFunds(ctx, toAddress)
+	if !sendAll && amount == 0 {
+		return nil, errors.New("no amount provided")
+	}
+	txId, err := api.svc.GetLNClient().RedeemOnchainFunds(ctx, toAddress, amount, feeRate, sendAll)
 	if err != nil {
 		return nil, err
 	}
@@ -594,6 +710,21 @@ func (api *api) GetInfo(ctx context.Context) (*InfoResponse, error) {
 	}
 	info.AlbyUserIdentifier = albyUserIdentifier
 	info.AlbyAccountConnected = api.albyOAuthSvc.IsConnected(ctx)
+	info.SingleUser = api.cfg.GetEnv().SingleUser
+	info.AllInOne = api.cfg.GetEnv().AllInOne
+	info.DisabledBackends = []string{}
+	for _, backendType := range []string{config.BTCPayBackendType, config.NWCBackendType} {
+		if !api.cfg.IsBackendEnabled(backendType) {
+			info.DisabledBackends = append(info.DisabledBackends, backendType)
+		}
+	}
+	branding := api.cfg.GetEnv().GetBranding()
+	info.Branding = BrandingResponse{
+		Name:         branding.Name,
+		LogoUrl:      branding.LogoUrl,
+		PrimaryColor: branding.PrimaryColor,
+		SupportUrl:   branding.SupportUrl,
+	}
 	if api.svc.GetLNClient() != nil {
 		nodeInfo, err := api.svc.GetLNClient().GetInfo(ctx)
 		if err != nil {
@@ -616,6 +747,106 @@ func (api *api) GetEncryptedMnemonic() *EncryptedMnemonicResponse {
 	return &resp
 }
 
+func (api *api) CheckHealth() error {
+	return api.svc.CheckHealth()
+}
+
+func (api *api) ListFeatures() *ListFeaturesResponse {
+	return &ListFeaturesResponse{
+		Features: api.cfg.GetFeatures(),
+	}
+}
+
+func (api *api) UpdateFeature(feature string, updateFeatureRequest *UpdateFeatureRequest) error {
+	if updateFeatureRequest.AppId != nil {
+		var app db.App
+		result := api.db.Limit(1).Find(&app, *updateFeatureRequest.AppId)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("app %d not found", *updateFeatureRequest.AppId)
+		}
+		return api.cfg.SetAppFeatureEnabled(feature, app.ID, updateFeatureRequest.Enabled)
+	}
+	return api.cfg.SetFeatureEnabled(feature, updateFeatureRequest.Enabled)
+}
+
+func (api *api) GetLogSettings() *LogSettingsResponse {
+	return &LogSettingsResponse{
+		LogLevel:        logger.GetLevel(),
+		ComponentLevels: logger.GetComponentLevels(),
+		DebugToggles:    logger.GetDebugToggles(),
+	}
+}
+
+func (api *api) UpdateLogSettings(updateLogSettingsRequest *UpdateLogSettingsRequest) (*LogSettingsResponse, error) {
+	// nothing is changed if any of the settings is invalid
+	if updateLogSettingsRequest.LogLevel != nil {
+		err := validateLogLevel(*updateLogSettingsRequest.LogLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for component, logLevel := range updateLogSettingsRequest.ComponentLevels {
+		if !slices.Contains(logger.Components, component) {
+			return nil, fmt.Errorf("unknown log component %q", component)
+		}
+		err := validateLogLevel(logLevel)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for toggle := range updateLogSettingsRequest.DebugToggles {
+		if !slices.Contains(logger.DebugToggles, toggle) {
+			return nil, fmt.Errorf("unknown debug toggle %q", toggle)
+		}
+	}
+
+	if updateLogSettingsRequest.LogLevel != nil {
+		logger.SetLevel(*updateLogSettingsRequest.LogLevel)
+		logger.SetComponentLevels(api.cfg.GetEnv().ComponentLogLevels())
+	}
+	logger.SetComponentLevels(updateLogSettingsRequest.ComponentLevels)
+	for toggle, enabled := range updateLogSettingsRequest.DebugToggles {
+		logger.SetDebugEnabled(toggle, enabled)
+	}
+
+	logSettings := api.GetLogSettings()
+	logger.Logger.WithFields(logrus.Fields{
+		"log_level":        logSettings.LogLevel,
+		"component_levels": logSettings.ComponentLevels,
+		"debug_toggles":    logSettings.DebugToggles,
+	}).Info("Changed log settings")
+	return logSettings, nil
+}
+
+func validateLogLevel(logLevel string) error {
+	level, err := strconv.Atoi(logLevel)
+	if err != nil || level < int(logrus.PanicLevel) || level > int(logrus.TraceLevel) {
+		return fmt.Errorf("invalid log level %q, must be a number from 0 (panic) to 6 (trace)", logLevel)
+	}
+	return nil
+}
+
+func (api *api) GetNostrKeys() *NostrKeysResponse {
+	return &NostrKeysResponse{
+		Pubkey:         api.keys.GetNostrPublicKey(),
+		PreviousPubkey: api.keys.GetPreviousNostrPublicKey(),
+	}
+}
+
+func (api *api) RotateNostrKeys(ctx context.Context, rotateNostrKeysRequest *RotateNostrKeysRequest) (*NostrKeysResponse, error) {
+	if !api.cfg.CheckUnlockPassword(rotateNostrKeysRequest.UnlockPassword) {
+		return nil, errors.New("incorrect password")
+	}
+	err := api.svc.RotateKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.GetNostrKeys(), nil
+}
+
 func (api *api) SetNextBackupReminder(backupReminderRequest *BackupReminderRequest) error {
 	api.cfg.SetUpdate("NextBackupReminder", backupReminderRequest.NextBackupReminder, "")
 	return nil
@@ -643,6 +874,10 @@ func (api *api) Setup(ctx context.Context, setupRequest *SetupRequest) error {
 		return errors.New("setup already completed")
 	}
 
+	if setupRequest.LNBackendType != "" && !api.cfg.IsBackendEnabled(setupRequest.LNBackendType) {
+		return fmt.Errorf("the %s backend is experimental, enable the %s feature flag to use it", setupRequest.LNBackendType, config.BackendFeature(setupRequest.LNBackendType))
+	}
+
 	api.cfg.Setup(setupRequest.UnlockPassword)
 
 	// TODO: move all below code to cfg.Setup()
@@ -679,6 +914,20 @@ func (api *api) Setup(ctx context.Context, setupRequest *SetupRequest) error {
 		api.cfg.SetUpdate("PhoenixdAuthorization", setupRequest.PhoenixdAuthorization, setupRequest.UnlockPassword)
 	}
 
+	if setupRequest.BTCPayUrl != "" {
+		api.cfg.SetUpdate("BTCPayUrl", setupRequest.BTCPayUrl, setupRequest.UnlockPassword)
+	}
+	if setupRequest.BTCPayApiKey != "" {
+		api.cfg.SetUpdate("BTCPayApiKey", setupRequest.BTCPayApiKey, setupRequest.UnlockPassword)
+	}
+	if setupRequest.BTCPayStoreId != "" {
+		api.cfg.SetUpdate("BTCPayStoreId", setupRequest.BTCPayStoreId, setupRequest.UnlockPassword)
+	}
+
+	if setupRequest.NWCConnectionUri != "" {
+		api.cfg.SetUpdate("NWCConnectionUri", setupRequest.NWCConnectionUri, setupRequest.UnlockPassword)
+	}
+
 	if setupRequest.CashuMintUrl != "" {
 		api.cfg.SetUpdate("CashuMintUrl", setupRequest.CashuMintUrl, setupRequest.UnlockPassword)
 	}
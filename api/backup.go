@@ -29,6 +29,10 @@ func (api *api) CreateBackup(unlockPassword string, w io.Writer) error {
 		return errors.New("invalid unlock password")
 	}
 
+	if db.IsPostgres(api.db) {
+		return errors.New("backups are not supported with a Postgres database, back up the database instead")
+	}
+
 	workDir, err := filepath.Abs(api.cfg.GetEnv().Workdir)
 	if err != nil {
 		return fmt.Errorf("failed to get absolute workdir: %w", err)
@@ -142,6 +146,9 @@ func (api *api) RestoreBackup(unlockPassword string, r io.Reader) error {
 	if strings.HasPrefix(api.cfg.GetEnv().DatabaseUri, "file:") {
 		return errors.New("cannot restore backup when database path is a file URI")
 	}
+	if db.IsPostgres(api.db) {
+		return errors.New("cannot restore backup into a Postgres database")
+	}
 
 	cr, err := decryptingReader(r, unlockPassword)
 	if err != nil {
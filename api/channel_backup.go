@@ -0,0 +1,36 @@
+package api
+
+import (
+	"context"
+	"errors"
+)
+
+func (api *api) GetChannelBackupStatus(ctx context.Context) (*ChannelBackupStatus, error) {
+	if api.svc.GetLNClient() == nil {
+		return nil, errors.New("LNClient not started")
+	}
+	return api.svc.GetChannelBackupService().GetChannelBackupStatus(ctx, api.svc.GetLNClient())
+}
+
+func (api *api) ExportChannelBackup(ctx context.Context) ([]byte, error) {
+	if api.svc.GetLNClient() == nil {
+		return nil, errors.New("LNClient not started")
+	}
+	return api.svc.GetChannelBackupService().ExportChannelBackup(ctx, api.svc.GetLNClient())
+}
+
+func (api *api) VerifyChannelBackup(ctx context.Context, verifyChannelBackupRequest *VerifyChannelBackupRequest) (*VerifyChannelBackupResponse, error) {
+	if api.svc.GetLNClient() == nil {
+		return nil, errors.New("LNClient not started")
+	}
+	channelBackupFile, err := api.svc.GetChannelBackupService().VerifyChannelBackup(ctx, api.svc.GetLNClient(), []byte(verifyChannelBackupRequest.Backup))
+	if err != nil {
+		return nil, err
+	}
+	return &VerifyChannelBackupResponse{
+		Backend:      channelBackupFile.Backend,
+		NodeId:       channelBackupFile.NodeId,
+		ChannelCount: channelBackupFile.ChannelCount,
+		CreatedAt:    channelBackupFile.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"github.com/getAlby/hub/transactions"
+)
+
+const (
+	defaultExportLimit = 100
+	maxExportLimit     = 1000
+)
+
+// ExportTransactions returns the next page of settled transactions for syncing them to
+// other tools. Passing the next cursor of the response to the next export returns only
+// the transactions that were not exported yet.
+func (api *api) ExportTransactions(since, until, limit uint64, cursor *transactions.ExportCursor) (*ExportTransactionsResponse, error) {
+	if limit == 0 {
+		limit = defaultExportLimit
+	}
+	if limit > maxExportLimit {
+		limit = maxExportLimit
+	}
+
+	dbTransactions := []transactions.Transaction{}
+	// one more transaction is loaded to find out if there are more
+	err := api.svc.GetTransactionsService().ExportTransactions(since, until, limit+1, cursor, func(transaction *transactions.Transaction) bool {
+		dbTransactions = append(dbTransactions, *transaction)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &ExportTransactionsResponse{
+		Transactions: []ExportedTransaction{},
+	}
+	if cursor != nil {
+		response.NextCursor = cursor.Encode()
+	}
+	if uint64(len(dbTransactions)) > limit {
+		dbTransactions = dbTransactions[:limit]
+		response.HasMore = true
+	}
+
+	transactionIds := []uint{}
+	for _, transaction := range dbTransactions {
+		transactionIds = append(transactionIds, transaction.ID)
+	}
+	transactionTags, err := api.svc.GetTagsService().GetTags(transactionIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, transaction := range dbTransactions {
+		apiTransaction := toApiTransaction(&transaction)
+		if tags, ok := transactionTags[transaction.ID]; ok {
+			apiTransaction.Tags = tags
+		}
+		response.NextCursor = transactions.NewExportCursor(&transaction).Encode()
+		response.Transactions = append(response.Transactions, ExportedTransaction{
+			Transaction: *apiTransaction,
+			Cursor:      response.NextCursor,
+		})
+	}
+	return response, nil
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/transactions"
+)
+
+// only the hash of the token is stored, the feed url is shown once when it is created
+const transactionsFeedTokenHashKey = "TransactionsFeedTokenHash"
+
+const transactionsFeedLimit = 50
+
+type invalidFeedTokenError struct {
+}
+
+func NewInvalidFeedTokenError() error {
+	return &invalidFeedTokenError{}
+}
+
+func (err *invalidFeedTokenError) Error() string {
+	return "invalid feed token"
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Id         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+// the tags of the transaction
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Text string `xml:",chardata"`
+}
+
+func hashFeedToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func (api *api) GetTransactionsFeed() (*TransactionsFeedResponse, error) {
+	tokenHash, err := api.cfg.Get(transactionsFeedTokenHashKey, "")
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionsFeedResponse{
+		Enabled: tokenHash != "",
+	}, nil
+}
+
+// CreateTransactionsFeed creates a new feed url, the url of an existing feed stops working
+func (api *api) CreateTransactionsFeed() (*TransactionsFeedResponse, error) {
+	tokenBytes := make([]byte, 32)
+	_, err := rand.Read(tokenBytes)
+	if err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(tokenBytes)
+	api.cfg.SetUpdate(transactionsFeedTokenHashKey, hashFeedToken(token), "")
+
+	return &TransactionsFeedResponse{
+		Enabled: true,
+		Url:     strings.TrimSuffix(api.cfg.GetEnv().BaseUrl, "/") + "/api/feeds/transactions?token=" + url.QueryEscape(token),
+	}, nil
+}
+
+func (api *api) DeleteTransactionsFeed() error {
+	api.cfg.SetUpdate(transactionsFeedTokenHashKey, "", "")
+	return nil
+}
+
+// CheckTransactionsFeedToken returns an InvalidFeedTokenError if the token is not the one of the feed url
+func (api *api) CheckTransactionsFeedToken(token string) error {
+	tokenHash, err := api.cfg.Get(transactionsFeedTokenHashKey, "")
+	if err != nil {
+		return err
+	}
+	if tokenHash == "" || subtle.ConstantTimeCompare([]byte(tokenHash), []byte(hashFeedToken(token))) != 1 {
+		return NewInvalidFeedTokenError()
+	}
+	return nil
+}
+
+// WriteTransactionsFeed writes the latest settled transactions as an Atom feed
+func (api *api) WriteTransactionsFeed(ctx context.Context, token string, w io.Writer) error {
+	err := api.CheckTransactionsFeedToken(token)
+	if err != nil {
+		return err
+	}
+	if api.svc.GetLNClient() == nil {
+		return errors.New("LNClient not started")
+	}
+
+	feedTransactions, err := api.svc.GetTransactionsService().ListTransactions(ctx, 0, 0, transactionsFeedLimit, 0, false, nil, api.svc.GetLNClient(), nil)
+	if err != nil {
+		return err
+	}
+
+	var apps []db.App
+	err = api.db.Select("id", "name").Find(&apps).Error
+	if err != nil {
+		return err
+	}
+	appNames := map[uint]string{}
+	for _, app := range apps {
+		appNames[app.ID] = app.Name
+	}
+
+	transactionIds := []uint{}
+	for _, transaction := range feedTransactions {
+		transactionIds = append(transactionIds, transaction.ID)
+	}
+	transactionTags, err := api.svc.GetTagsService().GetTags(transactionIds)
+	if err != nil {
+		return err
+	}
+
+	baseUrl := strings.TrimSuffix(api.cfg.GetEnv().BaseUrl, "/")
+	brandName := api.cfg.GetEnv().GetBranding().Name
+	feed := atomFeed{
+		Id:      "urn:albyhub:transactions",
+		Title:   brandName + " transactions",
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: baseUrl + "/wallet"},
+		Author:  atomAuthor{Name: brandName},
+	}
+	for i, transaction := range feedTransactions {
+		updatedAt := transaction.UpdatedAt
+		if transaction.SettledAt != nil {
+			updatedAt = *transaction.SettledAt
+		}
+		if i == 0 {
+			feed.Updated = updatedAt.UTC().Format(time.RFC3339)
+		}
+
+		title := fmt.Sprintf("Received %d sats", transaction.AmountMsat/1000)
+		if transaction.Type == constants.TRANSACTION_TYPE_OUTGOING {
+			title = fmt.Sprintf("Sent %d sats", transaction.AmountMsat/1000)
+		}
+
+		content := []string{}
+		if memo := transactions.GetMemo(&transaction); memo != "" {
+			content = append(content, memo)
+		}
+		if transaction.Note != "" {
+			content = append(content, "Note: "+transaction.Note)
+		}
+		if transaction.Type == constants.TRANSACTION_TYPE_OUTGOING {
+			content = append(content, fmt.Sprintf("Fee: %d sats", transaction.FeeMsat/1000))
+		}
+		if transaction.AppId != nil {
+			content = append(content, "App: "+appNames[*transaction.AppId])
+		}
+		content = append(content, "Payment hash: "+transaction.PaymentHash)
+
+		categories := []atomCategory{}
+		for _, tag := range transactionTags[transaction.ID] {
+			categories = append(categories, atomCategory{Term: tag})
+		}
+
+		feed.Entries = append(feed.Entries, atomEntry{
+			Id:         fmt.Sprintf("urn:albyhub:transaction:%d", transaction.ID),
+			Title:      title,
+			Updated:    updatedAt.UTC().Format(time.RFC3339),
+			Categories: categories,
+			Content:    atomContent{Type: "text", Text: strings.Join(content, "\n")},
+		})
+	}
+
+	_, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(feed)
+}
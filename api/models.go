@@ -2,18 +2,28 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"io"
 	"time"
 
 	"github.com/getAlby/hub/alby"
+	"github.com/getAlby/hub/backups"
 	"github.com/getAlby/hub/db"
 	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/reports"
+	"github.com/getAlby/hub/swaps"
+	"github.com/getAlby/hub/transactions"
+	"github.com/nbd-wtf/go-nostr"
 )
 
 type API interface {
 	CreateApp(createAppRequest *CreateAppRequest) (*CreateAppResponse, error)
 	UpdateApp(userApp *db.App, updateAppRequest *UpdateAppRequest) error
 	DeleteApp(userApp *db.App) error
+	RegenerateAppSecret(userApp *db.App) (*CreateAppResponse, error)
+	CreateLNURLWithdraw(userApp *db.App, createLNURLWithdrawRequest *CreateLNURLWithdrawRequest) (*CreateLNURLWithdrawResponse, error)
+	GetNip47Usage(days uint64) (*Nip47UsageResponse, error)
+	ListAppRequests(userApp *db.App, limit uint64, offset uint64) ([]AppRequest, error)
 	GetApp(userApp *db.App) *App
 	ListApps() ([]App, error)
 	ListChannels(ctx context.Context) ([]Channel, error)
@@ -24,6 +34,9 @@ type API interface {
 	GetNodeConnectionInfo(ctx context.Context) (*lnclient.NodeConnectionInfo, error)
 	GetNodeStatus(ctx context.Context) (*lnclient.NodeStatus, error)
 	ListPeers(ctx context.Context) ([]lnclient.PeerDetails, error)
+	ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error)
+	AddWatchtower(ctx context.Context, addWatchtowerRequest *AddWatchtowerRequest) error
+	RemoveWatchtower(ctx context.Context, pubkey string) error
 	ConnectPeer(ctx context.Context, connectPeerRequest *ConnectPeerRequest) error
 	DisconnectPeer(ctx context.Context, peerId string) error
 	OpenChannel(ctx context.Context, openChannelRequest *OpenChannelRequest) (*OpenChannelResponse, error)
@@ -32,11 +45,28 @@ type API interface {
 	GetNewOnchainAddress(ctx context.Context) (string, error)
 	GetUnusedOnchainAddress(ctx context.Context) (string, error)
 	SignMessage(ctx context.Context, message string) (*SignMessageResponse, error)
-	RedeemOnchainFunds(ctx context.Context, toAddress string) (*RedeemOnchainFundsResponse, error)
+	RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (*RedeemOnchainFundsResponse, error)
 	GetBalances(ctx context.Context) (*BalancesResponse, error)
-	ListTransactions(ctx context.Context, limit uint64, offset uint64) (*ListTransactionsResponse, error)
-	SendPayment(ctx context.Context, invoice string) (*SendPaymentResponse, error)
+	GetWalletSummary(ctx context.Context) (*WalletSummaryResponse, error)
+	ListActivities(cursor uint, limit uint64, types []string) (*ListActivitiesResponse, error)
+	ListNotifications() (*ListNotificationsResponse, error)
+	MarkNotificationsRead(markNotificationsReadRequest *MarkNotificationsReadRequest) error
+	ListTransactions(ctx context.Context, limit uint64, offset uint64, tag string, query string) (*ListTransactionsResponse, error)
+	ListTransactionTags() ([]string, error)
+	SetTransactionTags(transactionId uint, setTransactionTagsRequest *SetTransactionTagsRequest) error
+	SetTransactionNote(transactionId uint, setTransactionNoteRequest *SetTransactionNoteRequest) error
+	ListTagRules() ([]TagRule, error)
+	CreateTagRule(createTagRuleRequest *CreateTagRuleRequest) (*TagRule, error)
+	DeleteTagRule(id uint) error
+	SendPayment(ctx context.Context, invoice string, sendPaymentRequest *SendPaymentRequest) (*SendPaymentResponse, error)
 	CreateInvoice(ctx context.Context, amount int64, description string) (*MakeInvoiceResponse, error)
+	CreateOffer(ctx context.Context, createOfferRequest *CreateOfferRequest) (*Offer, error)
+	ListOffers(ctx context.Context) ([]Offer, error)
+	ListSwaps(ctx context.Context) ([]Swap, error)
+	SwapIn(ctx context.Context, swapInRequest *SwapInRequest) (*Swap, error)
+	SwapOut(ctx context.Context, swapOutRequest *SwapOutRequest) (*Swap, error)
+	GetAutoSwapConfig() (*AutoSwapConfig, error)
+	UpdateAutoSwapConfig(autoSwapConfig *AutoSwapConfig) error
 	LookupInvoice(ctx context.Context, paymentHash string) (*LookupInvoiceResponse, error)
 	RequestMempoolApi(endpoint string) (interface{}, error)
 	GetInfo(ctx context.Context) (*InfoResponse, error)
@@ -52,7 +82,29 @@ type API interface {
 	RequestLSPOrder(ctx context.Context, request *LSPOrderRequest) (*LSPOrderResponse, error)
 	CreateBackup(unlockPassword string, w io.Writer) error
 	RestoreBackup(unlockPassword string, r io.Reader) error
+	GetChannelBackupStatus(ctx context.Context) (*ChannelBackupStatus, error)
+	ExportChannelBackup(ctx context.Context) ([]byte, error)
+	VerifyChannelBackup(ctx context.Context, verifyChannelBackupRequest *VerifyChannelBackupRequest) (*VerifyChannelBackupResponse, error)
 	GetWalletCapabilities(ctx context.Context) (*WalletCapabilitiesResponse, error)
+	CheckHealth() error
+	ListFeatures() *ListFeaturesResponse
+	UpdateFeature(feature string, updateFeatureRequest *UpdateFeatureRequest) error
+	GetLogSettings() *LogSettingsResponse
+	UpdateLogSettings(updateLogSettingsRequest *UpdateLogSettingsRequest) (*LogSettingsResponse, error)
+	ListPaymentConfirmations() []PaymentConfirmation
+	ConfirmPayment(transactionId uint, confirmPaymentRequest *ConfirmPaymentRequest) error
+	SetupPaymentConfirmationAuthenticator(setupRequest *SetupPaymentConfirmationAuthenticatorRequest) (*SetupPaymentConfirmationAuthenticatorResponse, error)
+	GetNostrKeys() *NostrKeysResponse
+	RotateNostrKeys(ctx context.Context, rotateNostrKeysRequest *RotateNostrKeysRequest) (*NostrKeysResponse, error)
+	GetTransactionsFeed() (*TransactionsFeedResponse, error)
+	CreateTransactionsFeed() (*TransactionsFeedResponse, error)
+	DeleteTransactionsFeed() error
+	WriteTransactionsFeed(ctx context.Context, token string, w io.Writer) error
+	CheckTransactionsFeedToken(token string) error
+	ExportTransactions(since, until, limit uint64, cursor *transactions.ExportCursor) (*ExportTransactionsResponse, error)
+	GetMonthlyReport(ctx context.Context, year int, month int) (*MonthlyReport, error)
+	ExportMonthlyReport(ctx context.Context, year int, month int, format string) ([]byte, error)
+	HandleNip47Request(ctx context.Context, event *nostr.Event) ([]nostr.Event, error)
 }
 
 type App struct {
@@ -68,8 +120,15 @@ type App struct {
 	MaxAmountSat  uint64     `json:"maxAmount"`
 	BudgetUsage   uint64     `json:"budgetUsage"`
 	BudgetRenewal string     `json:"budgetRenewal"`
-	Isolated      bool       `json:"isolated"`
-	Balance       uint64     `json:"balance"`
+	// only set if the budget is projected to run out before it renews, at the current spend rate
+	BudgetRunsOutAt *time.Time `json:"budgetRunsOutAt"`
+	BudgetRenewsAt  *time.Time `json:"budgetRenewsAt"`
+	Isolated        bool       `json:"isolated"`
+	Balance         uint64     `json:"balance"`
+	// LightningAddress is only set if the app has a lightning address username
+	LightningAddress string `json:"lightningAddress,omitempty"`
+	// the features as they apply to the app, only set for a single app
+	Features map[string]bool `json:"features,omitempty"`
 }
 
 type ListAppsResponse struct {
@@ -81,6 +140,8 @@ type UpdateAppRequest struct {
 	BudgetRenewal string   `json:"budgetRenewal"`
 	ExpiresAt     string   `json:"expiresAt"`
 	Scopes        []string `json:"scopes"`
+	// nil leaves the current username unchanged, an empty string removes it
+	LightningAddressUsername *string `json:"lightningAddressUsername"`
 }
 
 type CreateAppRequest struct {
@@ -94,6 +155,65 @@ type CreateAppRequest struct {
 	Isolated      bool     `json:"isolated"`
 }
 
+// a NIP-47 request of an app, for debugging the app
+type AppRequest struct {
+	Id           uint            `json:"id"`
+	NostrId      string          `json:"nostrId"`
+	Method       string          `json:"method"`
+	Params       json.RawMessage `json:"params"`
+	State        string          `json:"state"`
+	ErrorCode    string          `json:"errorCode"`
+	ErrorMessage string          `json:"errorMessage"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	RespondedAt  *time.Time      `json:"respondedAt"`
+	DurationMs   *int64          `json:"durationMs"`
+}
+
+type Nip47UsageResponse struct {
+	Days       []Nip47DayUsage       `json:"days"`
+	Methods    []Nip47MethodUsage    `json:"methods"`
+	Apps       []Nip47AppUsage       `json:"apps"`
+	ErrorCodes []Nip47ErrorCodeUsage `json:"errorCodes"`
+}
+
+type Nip47DayUsage struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`
+}
+
+type Nip47MethodUsage struct {
+	Method   string `json:"method"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`
+}
+
+// the usage of requests from unknown pubkeys has no app id
+type Nip47AppUsage struct {
+	AppId    *uint  `json:"appId"`
+	AppName  string `json:"appName"`
+	Requests int64  `json:"requests"`
+	Errors   int64  `json:"errors"`
+}
+
+type Nip47ErrorCodeUsage struct {
+	Code  string `json:"code"`
+	Count int64  `json:"count"`
+}
+
+type CreateLNURLWithdrawRequest struct {
+	AmountSat   uint64 `json:"amount"`
+	Description string `json:"description"`
+	ExpiresAt   string `json:"expiresAt"`
+}
+
+type CreateLNURLWithdrawResponse struct {
+	Id        uint       `json:"id"`
+	LNURL     string     `json:"lnurl"`
+	AmountSat uint64     `json:"amount"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
 type StartRequest struct {
 	UnlockPassword string `json:"unlockPassword"`
 }
@@ -102,6 +222,29 @@ type UnlockRequest struct {
 	UnlockPassword string `json:"unlockPassword"`
 }
 
+type ListFeaturesResponse struct {
+	Features map[string]bool `json:"features"`
+}
+
+type UpdateFeatureRequest struct {
+	Enabled bool `json:"enabled"`
+	// only changes the feature for this app if set
+	AppId *uint `json:"appId"`
+}
+
+type LogSettingsResponse struct {
+	LogLevel        string            `json:"logLevel"`
+	ComponentLevels map[string]string `json:"componentLevels"`
+	DebugToggles    map[string]bool   `json:"debugToggles"`
+}
+
+// UpdateLogSettingsRequest changes the log settings until the hub is restarted. Omitted settings are kept.
+type UpdateLogSettingsRequest struct {
+	LogLevel        *string           `json:"logLevel"`
+	ComponentLevels map[string]string `json:"componentLevels"`
+	DebugToggles    map[string]bool   `json:"debugToggles"`
+}
+
 type BackupReminderRequest struct {
 	NextBackupReminder string `json:"nextBackupReminder"`
 }
@@ -129,6 +272,14 @@ type SetupRequest struct {
 	PhoenixdAddress       string `json:"phoenixdAddress"`
 	PhoenixdAuthorization string `json:"phoenixdAuthorization"`
 
+	// BTCPay fields
+	BTCPayUrl     string `json:"btcpayUrl"`
+	BTCPayApiKey  string `json:"btcpayApiKey"`
+	BTCPayStoreId string `json:"btcpayStoreId"`
+
+	// NWC fields
+	NWCConnectionUri string `json:"nwcConnectionUri"`
+
 	// Cashu fields
 	CashuMintUrl string `json:"cashuMintUrl"`
 }
@@ -155,8 +306,61 @@ type InfoResponse struct {
 	NextBackupReminder   string `json:"nextBackupReminder"`
 	AlbyUserIdentifier   string `json:"albyUserIdentifier"`
 	AlbyAccountConnected bool   `json:"albyAccountConnected"`
-	Version              string `json:"version"`
-	Network              string `json:"network"`
+	SingleUser           bool   `json:"singleUser"`
+	AllInOne             bool   `json:"allInOne"`
+	// experimental backends that are not offered in the setup
+	DisabledBackends []string         `json:"disabledBackends"`
+	Version          string           `json:"version"`
+	Network          string           `json:"network"`
+	Branding         BrandingResponse `json:"branding"`
+}
+
+type BrandingResponse struct {
+	Name         string `json:"name"`
+	LogoUrl      string `json:"logoUrl"`
+	PrimaryColor string `json:"primaryColor"`
+	SupportUrl   string `json:"supportUrl"`
+}
+
+type WalletSummaryResponse struct {
+	BackendType string `json:"backendType"`
+	Running     bool   `json:"running"`
+	NodeOnline  bool   `json:"nodeOnline"`
+	NodeAlias   string `json:"nodeAlias"`
+	Network     string `json:"network"`
+	// in msat like the lightning balance of BalancesResponse
+	LightningSpendable int64 `json:"lightningSpendable"`
+	// in sats like the on-chain balance of BalancesResponse
+	OnchainSpendable int64  `json:"onchainSpendable"`
+	SpentTodaySat    uint64 `json:"spentTodaySat"`
+}
+
+type Activity struct {
+	Id          uint      `json:"id"`
+	Type        string    `json:"type"`
+	Event       string    `json:"event"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	AppName     string    `json:"appName"`
+	AmountSat   int64     `json:"amountSat"`
+	PaymentHash string    `json:"paymentHash"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type ListActivitiesResponse struct {
+	Activities []Activity `json:"activities"`
+	// nil if there are no older activities
+	NextCursor *uint `json:"nextCursor"`
+}
+
+type ListNotificationsResponse struct {
+	Notifications []Activity `json:"notifications"`
+	UnreadCount   int64      `json:"unreadCount"`
+}
+
+type MarkNotificationsReadRequest struct {
+	// all notifications are marked as read if no ids are given
+	Ids []uint `json:"ids"`
 }
 
 type EncryptedMnemonicResponse struct {
@@ -168,14 +372,39 @@ type ChangeUnlockPasswordRequest struct {
 	NewUnlockPassword     string `json:"newUnlockPassword"`
 }
 
+type NostrKeysResponse struct {
+	Pubkey string `json:"pubkey"`
+	// set while requests to the key before the last rotation are still handled
+	PreviousPubkey string `json:"previousPubkey,omitempty"`
+}
+
+type TransactionsFeedResponse struct {
+	Enabled bool `json:"enabled"`
+	// only returned when the feed is created
+	Url string `json:"url,omitempty"`
+}
+
+type RotateNostrKeysRequest struct {
+	UnlockPassword string `json:"unlockPassword"`
+}
+
 type ConnectPeerRequest = lnclient.ConnectPeerRequest
+
+type AddWatchtowerRequest struct {
+	Pubkey string `json:"pubkey"`
+	// host:port of the watchtower
+	Address string `json:"address"`
+}
 type OpenChannelRequest = lnclient.OpenChannelRequest
 type OpenChannelResponse = lnclient.OpenChannelResponse
 type CloseChannelResponse = lnclient.CloseChannelResponse
 type UpdateChannelRequest = lnclient.UpdateChannelRequest
 
 type RedeemOnchainFundsRequest struct {
-	ToAddress string `json:"toAddress"`
+	ToAddress string  `json:"toAddress"`
+	Amount    uint64  `json:"amount"`
+	FeeRate   *uint64 `json:"feeRate"`
+	SendAll   bool    `json:"sendAll"`
 }
 
 type RedeemOnchainFundsResponse struct {
@@ -185,13 +414,47 @@ type RedeemOnchainFundsResponse struct {
 type OnchainBalanceResponse = lnclient.OnchainBalanceResponse
 type BalancesResponse = lnclient.BalancesResponse
 
+type SendPaymentRequest struct {
+	// optional multi-part payment options, only supported by some nodes
+	MaxParts uint32 `json:"maxParts"`
+	AMP      bool   `json:"amp"`
+}
+
 type SendPaymentResponse = Transaction
+
+// app payments above PAYMENT_CONFIRMATION_THRESHOLD_SAT wait for one of these to be approved
+type PaymentConfirmation struct {
+	TransactionId uint      `json:"transactionId"`
+	AppId         uint      `json:"appId"`
+	Amount        uint64    `json:"amount"`
+	PaymentHash   string    `json:"paymentHash"`
+	Description   string    `json:"description"`
+	CreatedAt     time.Time `json:"createdAt"`
+	ExpiresAt     time.Time `json:"expiresAt"`
+}
+
+type ConfirmPaymentRequest struct {
+	Approved bool `json:"approved"`
+	// a code of the authenticator app, required to approve the payment
+	TotpCode string `json:"totpCode"`
+}
+
+type SetupPaymentConfirmationAuthenticatorRequest struct {
+	UnlockPassword string `json:"unlockPassword"`
+}
+
+type SetupPaymentConfirmationAuthenticatorResponse struct {
+	Secret string `json:"secret"`
+	// otpauth uri to add the secret to an authenticator app as a QR code
+	Uri string `json:"uri"`
+}
 type MakeInvoiceResponse = Transaction
 type LookupInvoiceResponse = Transaction
 type ListTransactionsResponse = []Transaction
 
 // TODO: camelCase
 type Transaction struct {
+	Id              uint        `json:"id"`
 	Type            string      `json:"type"`
 	Invoice         string      `json:"invoice"`
 	Description     string      `json:"description"`
@@ -204,6 +467,52 @@ type Transaction struct {
 	SettledAt       *string     `json:"settled_at"`
 	AppId           *uint       `json:"app_id"`
 	Metadata        interface{} `json:"metadata,omitempty"`
+	Tags            []string    `json:"tags"`
+	// message sent with the payment, read-only
+	Memo string `json:"memo"`
+	// private note of the user
+	Note string `json:"note"`
+}
+
+type ExportedTransaction struct {
+	Transaction
+	// the cursor to continue the export after this transaction
+	Cursor string `json:"cursor"`
+}
+
+type ExportTransactionsResponse struct {
+	Transactions []ExportedTransaction
+	// the cursor of the last transaction, or the cursor of the request if there are no new transactions
+	NextCursor string
+	HasMore    bool
+}
+
+type SetTransactionNoteRequest struct {
+	Note string `json:"note"`
+}
+
+type SetTransactionTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+type TagRule struct {
+	Id    uint   `json:"id"`
+	Tag   string `json:"tag"`
+	AppId *uint  `json:"appId"`
+	// empty if the rule matches the payments of all apps
+	AppName             string    `json:"appName"`
+	Type                string    `json:"type"`
+	Keysend             bool      `json:"keysend"`
+	DescriptionContains string    `json:"descriptionContains"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
+
+type CreateTagRuleRequest struct {
+	Tag                 string `json:"tag"`
+	AppId               *uint  `json:"appId"`
+	Type                string `json:"type"`
+	Keysend             bool   `json:"keysend"`
+	DescriptionContains string `json:"descriptionContains"`
 }
 
 // debug api
@@ -251,6 +560,71 @@ type MakeInvoiceRequest struct {
 	Description string `json:"description"`
 }
 
+type CreateOfferRequest struct {
+	Description string `json:"description"`
+}
+
+type Offer struct {
+	Id               uint      `json:"id"`
+	Offer            string    `json:"offer"`
+	Description      string    `json:"description"`
+	AppId            *uint     `json:"appId"`
+	CreatedAt        time.Time `json:"createdAt"`
+	TotalReceivedSat uint64    `json:"totalReceived"`
+	PaymentsCount    uint64    `json:"paymentsCount"`
+}
+
+type SwapInRequest struct {
+	AmountSat uint64 `json:"amount"`
+}
+
+type SwapOutRequest struct {
+	AmountSat uint64 `json:"amount"`
+	// empty to swap to the on-chain wallet of the node
+	DestinationAddress string `json:"destinationAddress"`
+}
+
+type Swap struct {
+	Id                 string    `json:"id"`
+	Type               string    `json:"type"`
+	State              string    `json:"state"`
+	BoltzStatus        string    `json:"boltzStatus"`
+	AmountSat          uint64    `json:"amount"`
+	OnchainAmountSat   uint64    `json:"onchainAmount"`
+	PaymentHash        string    `json:"paymentHash"`
+	LockupAddress      string    `json:"lockupAddress"`
+	DestinationAddress string    `json:"destinationAddress"`
+	LockupTxId         string    `json:"lockupTxId"`
+	ClaimTxId          string    `json:"claimTxId"`
+	RefundTxId         string    `json:"refundTxId"`
+	AutoSwap           bool      `json:"autoSwap"`
+	CreatedAt          time.Time `json:"createdAt"`
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
+
+type AutoSwapConfig = swaps.AutoSwapConfig
+
+type ChannelBackupStatus = backups.ChannelBackupStatus
+
+type MonthlyReport = reports.MonthlyReport
+
+const (
+	REPORT_FORMAT_HTML = "html"
+	REPORT_FORMAT_PDF  = "pdf"
+)
+
+type VerifyChannelBackupRequest struct {
+	// contents of the exported channel backup file
+	Backup string `json:"backup"`
+}
+
+type VerifyChannelBackupResponse struct {
+	Backend      string    `json:"backend"`
+	NodeId       string    `json:"nodeId"`
+	ChannelCount int       `json:"channelCount"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
 type ResetRouterRequest struct {
 	Key string `json:"key"`
 }
@@ -299,6 +673,7 @@ type Channel struct {
 	Confirmations                            *uint32     `json:"confirmations"`
 	ConfirmationsRequired                    *uint32     `json:"confirmationsRequired"`
 	ForwardingFeeBaseMsat                    uint32      `json:"forwardingFeeBaseMsat"`
+	ForwardingFeeProportionalMillionths      uint32      `json:"forwardingFeeProportionalMillionths"`
 	UnspendablePunishmentReserve             uint64      `json:"unspendablePunishmentReserve"`
 	CounterpartyUnspendablePunishmentReserve uint64      `json:"counterpartyUnspendablePunishmentReserve"`
 	Error                                    *string     `json:"error"`
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+type nip47RequestNotAnsweredError struct {
+}
+
+func NewNip47RequestNotAnsweredError() error {
+	return &nip47RequestNotAnsweredError{}
+}
+
+func (err *nip47RequestNotAnsweredError) Error() string {
+	return "the request was not answered, check the signature, encryption and wallet pubkey of the event"
+}
+
+// HandleNip47Request handles a signed NIP-47 request event that was sent over HTTP and returns the response events.
+// The app is authenticated by the signature of the event, like requests that are received on the relay.
+func (api *api) HandleNip47Request(ctx context.Context, event *nostr.Event) ([]nostr.Event, error) {
+	if api.svc.GetLNClient() == nil {
+		return nil, errors.New("LNClient not started")
+	}
+	if event.Kind != models.REQUEST_KIND {
+		return nil, errors.New("the event is not a NIP-47 request")
+	}
+
+	responses, err := api.svc.HandleNip47Request(ctx, event)
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, NewNip47RequestNotAnsweredError()
+	}
+	return responses, nil
+}
+
+// ListAppRequests returns the NIP-47 requests of the app, newest first
+func (api *api) ListAppRequests(userApp *db.App, limit uint64, offset uint64) ([]AppRequest, error) {
+	requestEvents := []db.RequestEvent{}
+	err := api.db.
+		Where("app_id = ?", userApp.ID).
+		Order("id desc").
+		Limit(int(limit)).
+		Offset(int(offset)).
+		Find(&requestEvents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	appRequests := []AppRequest{}
+	for _, requestEvent := range requestEvents {
+		appRequest := AppRequest{
+			Id:           requestEvent.ID,
+			NostrId:      requestEvent.NostrId,
+			Method:       requestEvent.Method,
+			State:        requestEvent.State,
+			ErrorCode:    requestEvent.ErrorCode,
+			ErrorMessage: requestEvent.ErrorMessage,
+			CreatedAt:    requestEvent.CreatedAt,
+			RespondedAt:  requestEvent.RespondedAt,
+		}
+		// the content of requests that could not be decrypted is not stored
+		nip47Request := &models.Request{}
+		if json.Unmarshal([]byte(requestEvent.ContentData), nip47Request) == nil {
+			appRequest.Params = nip47Request.Params
+		}
+		if requestEvent.RespondedAt != nil {
+			durationMs := requestEvent.RespondedAt.Sub(requestEvent.CreatedAt).Milliseconds()
+			appRequest.DurationMs = &durationMs
+		}
+		appRequests = append(appRequests, appRequest)
+	}
+	return appRequests, nil
+}
+
+const (
+	defaultNip47UsageDays = 30
+	maxNip47UsageDays     = 90
+)
+
+// GetNip47Usage counts the NIP-47 requests of the last days per day, method, app and error code,
+// so that it can be seen which apps and methods cause the load and the failures
+func (api *api) GetNip47Usage(days uint64) (*Nip47UsageResponse, error) {
+	if days == 0 {
+		days = defaultNip47UsageDays
+	}
+	if days > maxNip47UsageDays {
+		days = maxNip47UsageDays
+	}
+	// TODO: Use the location of the user, instead of the server
+	now := time.Now()
+	startDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, -int(days-1))
+
+	// timestamps are stored in local time, their first 10 characters are the local date
+	dayColumn := "substr(created_at, 1, 10)"
+	if db.IsPostgres(api.db) {
+		dayColumn = "to_char(created_at, 'YYYY-MM-DD')"
+	}
+	var rows []struct {
+		Day       string
+		Method    string
+		AppId     *uint
+		ErrorCode string
+		Failed    bool
+		Count     int64
+	}
+	err := api.db.Model(&db.RequestEvent{}).
+		Select(dayColumn+" AS day, method, app_id, error_code, (error_code != '' OR state = ?) AS failed, COUNT(*) AS count", db.REQUEST_EVENT_STATE_HANDLER_ERROR).
+		Where("created_at >= ?", startDate).
+		Group("day, method, app_id, error_code, failed").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var apps []db.App
+	err = api.db.Select("id", "name").Find(&apps).Error
+	if err != nil {
+		return nil, err
+	}
+	appNames := map[uint]string{}
+	for _, app := range apps {
+		appNames[app.ID] = app.Name
+	}
+
+	usage := &Nip47UsageResponse{
+		Days:       []Nip47DayUsage{},
+		Methods:    []Nip47MethodUsage{},
+		Apps:       []Nip47AppUsage{},
+		ErrorCodes: []Nip47ErrorCodeUsage{},
+	}
+	dayIndexes := map[string]int{}
+	for date := startDate; !date.After(now); date = date.AddDate(0, 0, 1) {
+		dayIndexes[date.Format(time.DateOnly)] = len(usage.Days)
+		usage.Days = append(usage.Days, Nip47DayUsage{Date: date.Format(time.DateOnly)})
+	}
+	methodIndexes := map[string]int{}
+	appIndexes := map[uint]int{}
+	// requests of unknown pubkeys have no app
+	unknownAppIndex := -1
+	errorCodeIndexes := map[string]int{}
+
+	for _, row := range rows {
+		var errorCount int64
+		if row.Failed {
+			errorCount = row.Count
+		}
+
+		if i, ok := dayIndexes[row.Day]; ok {
+			usage.Days[i].Requests += row.Count
+			usage.Days[i].Errors += errorCount
+		}
+
+		method := row.Method
+		if method == "" {
+			// the request could not be decrypted
+			method = "unknown"
+		}
+		i, ok := methodIndexes[method]
+		if !ok {
+			i = len(usage.Methods)
+			methodIndexes[method] = i
+			usage.Methods = append(usage.Methods, Nip47MethodUsage{Method: method})
+		}
+		usage.Methods[i].Requests += row.Count
+		usage.Methods[i].Errors += errorCount
+
+		if row.AppId != nil {
+			i, ok = appIndexes[*row.AppId]
+			if !ok {
+				i = len(usage.Apps)
+				appIndexes[*row.AppId] = i
+				usage.Apps = append(usage.Apps, Nip47AppUsage{AppId: row.AppId, AppName: appNames[*row.AppId]})
+			}
+		} else {
+			if unknownAppIndex == -1 {
+				unknownAppIndex = len(usage.Apps)
+				usage.Apps = append(usage.Apps, Nip47AppUsage{})
+			}
+			i = unknownAppIndex
+		}
+		usage.Apps[i].Requests += row.Count
+		usage.Apps[i].Errors += errorCount
+
+		if row.ErrorCode != "" {
+			i, ok = errorCodeIndexes[row.ErrorCode]
+			if !ok {
+				i = len(usage.ErrorCodes)
+				errorCodeIndexes[row.ErrorCode] = i
+				usage.ErrorCodes = append(usage.ErrorCodes, Nip47ErrorCodeUsage{Code: row.ErrorCode})
+			}
+			usage.ErrorCodes[i].Count += row.Count
+		}
+	}
+
+	// the busiest first
+	sort.SliceStable(usage.Methods, func(i, j int) bool { return usage.Methods[i].Requests > usage.Methods[j].Requests })
+	sort.SliceStable(usage.Apps, func(i, j int) bool { return usage.Apps[i].Requests > usage.Apps[j].Requests })
+	sort.SliceStable(usage.ErrorCodes, func(i, j int) bool { return usage.ErrorCodes[i].Count > usage.ErrorCodes[j].Count })
+
+	return usage, nil
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+)
+
+// CreateOffer creates a reusable BOLT12 offer which is paid to the hub, not to an app
+func (api *api) CreateOffer(ctx context.Context, createOfferRequest *CreateOfferRequest) (*Offer, error) {
+	if api.svc.GetLNClient() == nil {
+		return nil, errors.New("LNClient not started")
+	}
+	offer, err := api.svc.GetTransactionsService().MakeOffer(ctx, createOfferRequest.Description, api.svc.GetLNClient(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Offer{
+		Id:          offer.ID,
+		Offer:       offer.Offer,
+		Description: offer.Description,
+		AppId:       offer.AppId,
+		CreatedAt:   offer.CreatedAt,
+	}, nil
+}
+
+// ListOffers returns the offers of the hub and its apps with the totals of the payments received for them
+func (api *api) ListOffers(ctx context.Context) ([]Offer, error) {
+	offers, err := api.svc.GetTransactionsService().ListOffers(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	type offerTotal struct {
+		OfferId       uint
+		AmountMsat    uint64
+		PaymentsCount uint64
+	}
+	var totals []offerTotal
+	err = api.db.Model(&db.Transaction{}).
+		Select("offer_id, SUM(amount_msat) AS amount_msat, COUNT(*) AS payments_count").
+		Where("offer_id IS NOT NULL AND state = ?", constants.TRANSACTION_STATE_SETTLED).
+		Group("offer_id").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	totalsByOfferId := map[uint]offerTotal{}
+	for _, total := range totals {
+		totalsByOfferId[total.OfferId] = total
+	}
+
+	apiOffers := []Offer{}
+	for _, offer := range offers {
+		total := totalsByOfferId[offer.ID]
+		apiOffers = append(apiOffers, Offer{
+			Id:               offer.ID,
+			Offer:            offer.Offer,
+			Description:      offer.Description,
+			AppId:            offer.AppId,
+			CreatedAt:        offer.CreatedAt,
+			TotalReceivedSat: total.AmountMsat / 1000,
+			PaymentsCount:    total.PaymentsCount,
+		})
+	}
+	return apiOffers, nil
+}
@@ -0,0 +1,25 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func (api *api) GetMonthlyReport(ctx context.Context, year int, month int) (*MonthlyReport, error) {
+	return api.svc.GetReportsService().GetMonthlyReport(ctx, year, time.Month(month))
+}
+
+func (api *api) ExportMonthlyReport(ctx context.Context, year int, month int, format string) ([]byte, error) {
+	if format != REPORT_FORMAT_HTML && format != REPORT_FORMAT_PDF {
+		return nil, fmt.Errorf("unknown report format: %s", format)
+	}
+	report, err := api.GetMonthlyReport(ctx, year, month)
+	if err != nil {
+		return nil, err
+	}
+	if format == REPORT_FORMAT_PDF {
+		return api.svc.GetReportsService().RenderPDF(report)
+	}
+	return api.svc.GetReportsService().RenderHTML(report)
+}
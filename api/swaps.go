@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getAlby/hub/db"
+)
+
+func (api *api) ListSwaps(ctx context.Context) ([]Swap, error) {
+	swaps, err := api.svc.GetSwapsService().ListSwaps()
+	if err != nil {
+		return nil, err
+	}
+	apiSwaps := []Swap{}
+	for _, swap := range swaps {
+		apiSwaps = append(apiSwaps, toApiSwap(&swap))
+	}
+	return apiSwaps, nil
+}
+
+// SwapIn moves funds from the on-chain wallet to the lightning balance
+func (api *api) SwapIn(ctx context.Context, swapInRequest *SwapInRequest) (*Swap, error) {
+	if api.svc.GetLNClient() == nil {
+		return nil, errors.New("LNClient not started")
+	}
+	swap, err := api.svc.GetSwapsService().SwapIn(ctx, swapInRequest.AmountSat, api.svc.GetLNClient())
+	if err != nil {
+		return nil, err
+	}
+	apiSwap := toApiSwap(swap)
+	return &apiSwap, nil
+}
+
+// SwapOut moves funds from the lightning balance to the on-chain wallet or another address
+func (api *api) SwapOut(ctx context.Context, swapOutRequest *SwapOutRequest) (*Swap, error) {
+	if api.svc.GetLNClient() == nil {
+		return nil, errors.New("LNClient not started")
+	}
+	swap, err := api.svc.GetSwapsService().SwapOut(ctx, swapOutRequest.AmountSat, swapOutRequest.DestinationAddress, api.svc.GetLNClient())
+	if err != nil {
+		return nil, err
+	}
+	apiSwap := toApiSwap(swap)
+	return &apiSwap, nil
+}
+
+func (api *api) GetAutoSwapConfig() (*AutoSwapConfig, error) {
+	return api.svc.GetSwapsService().GetAutoSwapConfig()
+}
+
+func (api *api) UpdateAutoSwapConfig(autoSwapConfig *AutoSwapConfig) error {
+	return api.svc.GetSwapsService().SetAutoSwapConfig(autoSwapConfig)
+}
+
+func toApiSwap(swap *db.Swap) Swap {
+	return Swap{
+		Id:                 swap.SwapId,
+		Type:               swap.Type,
+		State:              swap.State,
+		BoltzStatus:        swap.BoltzStatus,
+		AmountSat:          swap.AmountSat,
+		OnchainAmountSat:   swap.OnchainAmountSat,
+		PaymentHash:        swap.PaymentHash,
+		LockupAddress:      swap.LockupAddress,
+		DestinationAddress: swap.DestinationAddress,
+		LockupTxId:         swap.LockupTxId,
+		ClaimTxId:          swap.ClaimTxId,
+		RefundTxId:         swap.RefundTxId,
+		AutoSwap:           swap.AutoSwap,
+		CreatedAt:          swap.CreatedAt,
+		UpdatedAt:          swap.UpdatedAt,
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"github.com/getAlby/hub/db"
+)
+
+func (api *api) ListTransactionTags() ([]string, error) {
+	return api.svc.GetTagsService().ListTags()
+}
+
+func (api *api) SetTransactionTags(transactionId uint, setTransactionTagsRequest *SetTransactionTagsRequest) error {
+	return api.svc.GetTagsService().SetTags(transactionId, setTransactionTagsRequest.Tags)
+}
+
+func (api *api) ListTagRules() ([]TagRule, error) {
+	rules, err := api.svc.GetTagsService().ListTagRules()
+	if err != nil {
+		return nil, err
+	}
+	apiRules := []TagRule{}
+	for _, rule := range rules {
+		apiRules = append(apiRules, toApiTagRule(&rule))
+	}
+	return apiRules, nil
+}
+
+func (api *api) CreateTagRule(createTagRuleRequest *CreateTagRuleRequest) (*TagRule, error) {
+	rule := &db.TagRule{
+		Tag:                 createTagRuleRequest.Tag,
+		AppId:               createTagRuleRequest.AppId,
+		Type:                createTagRuleRequest.Type,
+		Keysend:             createTagRuleRequest.Keysend,
+		DescriptionContains: createTagRuleRequest.DescriptionContains,
+	}
+	err := api.svc.GetTagsService().CreateTagRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	if rule.AppId != nil {
+		rule.App = &db.App{}
+		api.db.Select("id", "name").Limit(1).Find(rule.App, *rule.AppId)
+	}
+	apiRule := toApiTagRule(rule)
+	return &apiRule, nil
+}
+
+func (api *api) DeleteTagRule(id uint) error {
+	return api.svc.GetTagsService().DeleteTagRule(id)
+}
+
+func toApiTagRule(rule *db.TagRule) TagRule {
+	apiRule := TagRule{
+		Id:                  rule.ID,
+		Tag:                 rule.Tag,
+		AppId:               rule.AppId,
+		Type:                rule.Type,
+		Keysend:             rule.Keysend,
+		DescriptionContains: rule.DescriptionContains,
+		CreatedAt:           rule.CreatedAt,
+	}
+	if rule.App != nil {
+		apiRule.AppName = rule.App.Name
+	}
+	return apiRule
+}
@@ -6,8 +6,11 @@ import (
 	"errors"
 	"time"
 
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/lnclient"
 	"github.com/getAlby/hub/logger"
 	"github.com/getAlby/hub/transactions"
+	"github.com/getAlby/hub/utils"
 	"github.com/sirupsen/logrus"
 )
 
@@ -33,35 +36,114 @@ func (api *api) LookupInvoice(ctx context.Context, paymentHash string) (*LookupI
 	return toApiTransaction(transaction), nil
 }
 
-// TODO: accept offset, limit params for pagination
-func (api *api) ListTransactions(ctx context.Context, limit uint64, offset uint64) (*ListTransactionsResponse, error) {
+// ListTransactions returns the settled transactions, only the ones with the tag or
+// matching the search query if one of them is not empty
+func (api *api) ListTransactions(ctx context.Context, limit uint64, offset uint64, tag string, query string) (*ListTransactionsResponse, error) {
 	if api.svc.GetLNClient() == nil {
 		return nil, errors.New("LNClient not started")
 	}
-	transactions, err := api.svc.GetTransactionsService().ListTransactions(ctx, 0, 0, limit, offset, false, nil, api.svc.GetLNClient(), nil)
+	var dbTransactions []transactions.Transaction
+	var err error
+	if tag != "" && query != "" {
+		return nil, errors.New("transactions cannot be filtered by tag and searched at the same time")
+	}
+	if query != "" {
+		dbTransactions, err = api.svc.GetTransactionsService().SearchTransactions(query, limit, offset)
+	} else if tag != "" {
+		dbTransactions, err = api.svc.GetTagsService().ListTaggedTransactions(tag, limit, offset)
+	} else {
+		dbTransactions, err = api.svc.GetTransactionsService().ListTransactions(ctx, 0, 0, limit, offset, false, nil, api.svc.GetLNClient(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	transactionIds := []uint{}
+	for _, transaction := range dbTransactions {
+		transactionIds = append(transactionIds, transaction.ID)
+	}
+	transactionTags, err := api.svc.GetTagsService().GetTags(transactionIds)
 	if err != nil {
 		return nil, err
 	}
 
 	apiTransactions := []Transaction{}
-	for _, transaction := range transactions {
-		apiTransactions = append(apiTransactions, *toApiTransaction(&transaction))
+	for _, transaction := range dbTransactions {
+		apiTransaction := toApiTransaction(&transaction)
+		if tags, ok := transactionTags[transaction.ID]; ok {
+			apiTransaction.Tags = tags
+		}
+		apiTransactions = append(apiTransactions, *apiTransaction)
 	}
 
 	return &apiTransactions, nil
 }
 
-func (api *api) SendPayment(ctx context.Context, invoice string) (*SendPaymentResponse, error) {
+func (api *api) SendPayment(ctx context.Context, invoice string, sendPaymentRequest *SendPaymentRequest) (*SendPaymentResponse, error) {
 	if api.svc.GetLNClient() == nil {
 		return nil, errors.New("LNClient not started")
 	}
-	transaction, err := api.svc.GetTransactionsService().SendPaymentSync(ctx, invoice, api.svc.GetLNClient(), nil, nil)
+	var transaction *transactions.Transaction
+	var err error
+	if sendPaymentRequest != nil && (sendPaymentRequest.MaxParts > 0 || sendPaymentRequest.AMP) {
+		if !api.cfg.IsFeatureEnabled(config.FeatureMultiPartPayments) {
+			return nil, errors.New("multi-part payments are not enabled on this hub")
+		}
+		transaction, err = api.svc.GetTransactionsService().SendMultiPartPaymentSync(ctx, invoice, &lnclient.MultiPartPaymentOptions{
+			MaxParts: sendPaymentRequest.MaxParts,
+			AMP:      sendPaymentRequest.AMP,
+		}, api.svc.GetLNClient(), nil, nil)
+	} else {
+		transaction, err = api.svc.GetTransactionsService().SendPaymentSync(ctx, invoice, api.svc.GetLNClient(), nil, nil)
+	}
 	if err != nil {
 		return nil, err
 	}
 	return toApiTransaction(transaction), nil
 }
 
+func (api *api) ListPaymentConfirmations() []PaymentConfirmation {
+	paymentConfirmations := []PaymentConfirmation{}
+	for _, confirmation := range api.svc.GetTransactionsService().ListPendingPaymentConfirmations() {
+		paymentConfirmations = append(paymentConfirmations, PaymentConfirmation{
+			TransactionId: confirmation.TransactionId,
+			AppId:         confirmation.AppId,
+			Amount:        confirmation.AmountMsat,
+			PaymentHash:   confirmation.PaymentHash,
+			Description:   confirmation.Description,
+			CreatedAt:     confirmation.CreatedAt,
+			ExpiresAt:     confirmation.ExpiresAt,
+		})
+	}
+	return paymentConfirmations
+}
+
+func (api *api) SetTransactionNote(transactionId uint, setTransactionNoteRequest *SetTransactionNoteRequest) error {
+	return api.svc.GetTransactionsService().SetTransactionNote(transactionId, setTransactionNoteRequest.Note)
+}
+
+func (api *api) ConfirmPayment(transactionId uint, confirmPaymentRequest *ConfirmPaymentRequest) error {
+	// the authenticator secret is decrypted with the unlock password of the running app
+	return api.svc.GetTransactionsService().ConfirmPayment(transactionId, confirmPaymentRequest.Approved, confirmPaymentRequest.TotpCode, api.svc.HandOverPassword())
+}
+
+// SetupPaymentConfirmationAuthenticator replaces the authenticator app that approves payments.
+// It requires the unlock password, so that a stolen session cannot add its own authenticator.
+func (api *api) SetupPaymentConfirmationAuthenticator(setupRequest *SetupPaymentConfirmationAuthenticatorRequest) (*SetupPaymentConfirmationAuthenticatorResponse, error) {
+	if !api.cfg.CheckUnlockPassword(setupRequest.UnlockPassword) {
+		return nil, errors.New("incorrect password")
+	}
+	secret, err := utils.GenerateTotpSecret()
+	if err != nil {
+		return nil, err
+	}
+	api.cfg.SetUpdate(transactions.PaymentConfirmationTotpSecretKey, secret, setupRequest.UnlockPassword)
+	return &SetupPaymentConfirmationAuthenticatorResponse{
+		Secret: secret,
+		Uri:    utils.TotpUri(secret, "Alby Hub", "Payment confirmations"),
+	}, nil
+}
+
 func toApiTransaction(transaction *transactions.Transaction) *Transaction {
 
 	createdAt := transaction.CreatedAt.Format(time.RFC3339)
@@ -85,6 +167,7 @@ func toApiTransaction(transaction *transactions.Transaction) *Transaction {
 	}
 
 	return &Transaction{
+		Id:              transaction.ID,
 		Type:            transaction.Type,
 		Invoice:         transaction.PaymentRequest,
 		Description:     transaction.Description,
@@ -97,5 +180,8 @@ func toApiTransaction(transaction *transactions.Transaction) *Transaction {
 		CreatedAt:       createdAt,
 		SettledAt:       settledAt,
 		Metadata:        metadata,
+		Tags:            []string{},
+		Memo:            transactions.GetMemo(transaction),
+		Note:            transaction.Note,
 	}
 }
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/db/queries"
+	"github.com/getAlby/hub/logger"
+)
+
+// the home page polls the summary, this keeps it from querying the node on every request
+const walletSummaryCacheTTL = 30 * time.Second
+
+type walletSummaryCache struct {
+	mu        sync.Mutex
+	summary   *WalletSummaryResponse
+	expiresAt time.Time
+}
+
+func (api *api) GetWalletSummary(ctx context.Context) (*WalletSummaryResponse, error) {
+	api.walletSummaryCache.mu.Lock()
+	defer api.walletSummaryCache.mu.Unlock()
+
+	if api.walletSummaryCache.summary != nil && api.walletSummaryCache.expiresAt.After(time.Now()) {
+		return api.walletSummaryCache.summary, nil
+	}
+
+	backendType, _ := api.cfg.Get("LNBackendType", "")
+	summary := &WalletSummaryResponse{
+		BackendType: backendType,
+	}
+
+	lnClient := api.svc.GetLNClient()
+	if lnClient == nil {
+		// not cached so that the summary shows the node as soon as it is started
+		return summary, nil
+	}
+	summary.Running = true
+
+	nodeInfo, err := lnClient.GetInfo(ctx)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to get node info for wallet summary")
+	} else {
+		summary.NodeOnline = true
+		summary.NodeAlias = nodeInfo.Alias
+		summary.Network = nodeInfo.Network
+	}
+
+	balances, err := lnClient.GetBalances(ctx)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to get balances for wallet summary")
+	} else {
+		summary.LightningSpendable = balances.Lightning.TotalSpendable
+		summary.OnchainSpendable = balances.Onchain.Spendable
+	}
+
+	// TODO: Use the location of the user, instead of the server
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	summary.SpentTodaySat = queries.GetSpentSinceSat(api.db, startOfDay)
+
+	api.walletSummaryCache.summary = summary
+	api.walletSummaryCache.expiresAt = time.Now().Add(walletSummaryCacheTTL)
+	return summary, nil
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func (api *api) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	if api.svc.GetLNClient() == nil {
+		return nil, errors.New("LNClient not started")
+	}
+	return api.svc.GetLNClient().ListWatchtowers(ctx)
+}
+
+func (api *api) AddWatchtower(ctx context.Context, addWatchtowerRequest *AddWatchtowerRequest) error {
+	if api.svc.GetLNClient() == nil {
+		return errors.New("LNClient not started")
+	}
+	if addWatchtowerRequest.Pubkey == "" || addWatchtowerRequest.Address == "" {
+		return errors.New("watchtower pubkey and address are required")
+	}
+	logger.Logger.WithFields(logrus.Fields{
+		"pubkey":  addWatchtowerRequest.Pubkey,
+		"address": addWatchtowerRequest.Address,
+	}).Info("Adding watchtower")
+	return api.svc.GetLNClient().AddWatchtower(ctx, addWatchtowerRequest.Pubkey, addWatchtowerRequest.Address)
+}
+
+func (api *api) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	if api.svc.GetLNClient() == nil {
+		return errors.New("LNClient not started")
+	}
+	logger.Logger.WithFields(logrus.Fields{
+		"pubkey": pubkey,
+	}).Info("Removing watchtower")
+	return api.svc.GetLNClient().RemoveWatchtower(ctx, pubkey)
+}
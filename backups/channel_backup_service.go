@@ -0,0 +1,275 @@
+package backups
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	channelBackupFileVersion   = 1
+	channelBackupFileName      = "channel-backup.json"
+	channelBackupMonitorDelay  = 1 * time.Minute
+	channelBackupUploadTimeout = 30 * time.Second
+)
+
+// config keys of the last exported or automatically saved backup
+const (
+	channelBackupFingerprintKey = "ChannelBackupFingerprint"
+	channelBackupExportedAtKey  = "ChannelBackupExportedAt"
+)
+
+// ChannelBackupFile wraps the backup data of the node backend so that it can be
+// checked for corruption and matched to a node before it is needed for recovery
+type ChannelBackupFile struct {
+	Version      int       `json:"version"`
+	Backend      string    `json:"backend"`
+	NodeId       string    `json:"nodeId"`
+	ChannelCount int       `json:"channelCount"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Data         []byte    `json:"data"`
+	Checksum     string    `json:"checksum"`
+}
+
+type ChannelBackupStatus struct {
+	LastExportedAt *time.Time `json:"lastExportedAt"`
+	// channels were opened or closed since the last backup
+	Outdated          bool `json:"outdated"`
+	ChannelCount      int  `json:"channelCount"`
+	AutoBackupEnabled bool `json:"autoBackupEnabled"`
+}
+
+type ChannelBackupService interface {
+	// StartChannelBackupMonitor saves a new backup to the configured location whenever the channels change
+	StartChannelBackupMonitor(ctx context.Context, lnClient lnclient.LNClient)
+	ExportChannelBackup(ctx context.Context, lnClient lnclient.LNClient) ([]byte, error)
+	VerifyChannelBackup(ctx context.Context, lnClient lnclient.LNClient, backupFile []byte) (*ChannelBackupFile, error)
+	GetChannelBackupStatus(ctx context.Context, lnClient lnclient.LNClient) (*ChannelBackupStatus, error)
+}
+
+type channelBackupService struct {
+	cfg        config.Config
+	httpClient *http.Client
+}
+
+func NewChannelBackupService(cfg config.Config) *channelBackupService {
+	return &channelBackupService{
+		cfg: cfg,
+		httpClient: &http.Client{
+			Timeout: channelBackupUploadTimeout,
+		},
+	}
+}
+
+func (svc *channelBackupService) StartChannelBackupMonitor(ctx context.Context, lnClient lnclient.LNClient) {
+	if !svc.autoBackupEnabled() {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(channelBackupMonitorDelay)
+		defer ticker.Stop()
+		for {
+			svc.checkChannelBackup(ctx, lnClient)
+			select {
+			case <-ctx.Done():
+				logger.Logger.Info("Stopping channel backup monitor")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (svc *channelBackupService) checkChannelBackup(ctx context.Context, lnClient lnclient.LNClient) {
+	fingerprint, _, err := svc.channelsFingerprint(ctx, lnClient)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list channels for channel backup")
+		return
+	}
+	lastFingerprint, _ := svc.cfg.Get(channelBackupFingerprintKey, "")
+	if fingerprint == lastFingerprint {
+		return
+	}
+
+	backupFile, err := svc.createChannelBackupFile(ctx, lnClient)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to create channel backup")
+		return
+	}
+
+	env := svc.cfg.GetEnv()
+	if env.ChannelBackupDir != "" {
+		err = writeChannelBackupFile(env.ChannelBackupDir, backupFile)
+		if err != nil {
+			logger.Logger.WithError(err).WithField("dir", env.ChannelBackupDir).Error("Failed to save channel backup")
+			return
+		}
+	}
+	if env.ChannelBackupUrl != "" {
+		err = svc.uploadChannelBackupFile(ctx, env.ChannelBackupUrl, backupFile)
+		if err != nil {
+			logger.Logger.WithError(err).Error("Failed to upload channel backup")
+			return
+		}
+	}
+
+	svc.markExported(fingerprint)
+	logger.Logger.WithFields(logrus.Fields{
+		"fingerprint": fingerprint,
+	}).Info("Saved channel backup")
+}
+
+func (svc *channelBackupService) ExportChannelBackup(ctx context.Context, lnClient lnclient.LNClient) ([]byte, error) {
+	fingerprint, _, err := svc.channelsFingerprint(ctx, lnClient)
+	if err != nil {
+		return nil, err
+	}
+	backupFile, err := svc.createChannelBackupFile(ctx, lnClient)
+	if err != nil {
+		return nil, err
+	}
+	svc.markExported(fingerprint)
+	return backupFile, nil
+}
+
+func (svc *channelBackupService) VerifyChannelBackup(ctx context.Context, lnClient lnclient.LNClient, backupFile []byte) (*ChannelBackupFile, error) {
+	channelBackupFile := &ChannelBackupFile{}
+	err := json.Unmarshal(backupFile, channelBackupFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode channel backup file: %w", err)
+	}
+	if channelBackupFile.Version != channelBackupFileVersion {
+		return nil, fmt.Errorf("unsupported channel backup version: %d", channelBackupFile.Version)
+	}
+	if checksum(channelBackupFile.Data) != channelBackupFile.Checksum {
+		return nil, errors.New("channel backup is corrupted: checksum does not match")
+	}
+	if channelBackupFile.NodeId != lnClient.GetPubkey() {
+		return nil, errors.New("channel backup belongs to another node")
+	}
+
+	err = lnClient.VerifyChannelBackup(ctx, &lnclient.ChannelBackup{
+		Data:         channelBackupFile.Data,
+		ChannelCount: channelBackupFile.ChannelCount,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("channel backup is invalid: %w", err)
+	}
+	return channelBackupFile, nil
+}
+
+func (svc *channelBackupService) GetChannelBackupStatus(ctx context.Context, lnClient lnclient.LNClient) (*ChannelBackupStatus, error) {
+	fingerprint, channelCount, err := svc.channelsFingerprint(ctx, lnClient)
+	if err != nil {
+		return nil, err
+	}
+	lastFingerprint, _ := svc.cfg.Get(channelBackupFingerprintKey, "")
+
+	status := &ChannelBackupStatus{
+		Outdated:          channelCount > 0 && fingerprint != lastFingerprint,
+		ChannelCount:      channelCount,
+		AutoBackupEnabled: svc.autoBackupEnabled(),
+	}
+	exportedAt, _ := svc.cfg.Get(channelBackupExportedAtKey, "")
+	if exportedAt != "" {
+		lastExportedAt, err := time.Parse(time.RFC3339, exportedAt)
+		if err == nil {
+			status.LastExportedAt = &lastExportedAt
+		}
+	}
+	return status, nil
+}
+
+func (svc *channelBackupService) autoBackupEnabled() bool {
+	env := svc.cfg.GetEnv()
+	return env.ChannelBackupDir != "" || env.ChannelBackupUrl != ""
+}
+
+func (svc *channelBackupService) markExported(fingerprint string) {
+	svc.cfg.SetUpdate(channelBackupFingerprintKey, fingerprint, "")
+	svc.cfg.SetUpdate(channelBackupExportedAtKey, time.Now().UTC().Format(time.RFC3339), "")
+}
+
+func (svc *channelBackupService) createChannelBackupFile(ctx context.Context, lnClient lnclient.LNClient) ([]byte, error) {
+	channelBackup, err := lnClient.ExportChannelBackup(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export channel backup: %w", err)
+	}
+	backend, _ := svc.cfg.Get("LNBackendType", "")
+
+	return json.Marshal(&ChannelBackupFile{
+		Version:      channelBackupFileVersion,
+		Backend:      backend,
+		NodeId:       lnClient.GetPubkey(),
+		ChannelCount: channelBackup.ChannelCount,
+		CreatedAt:    time.Now().UTC(),
+		Data:         channelBackup.Data,
+		Checksum:     checksum(channelBackup.Data),
+	})
+}
+
+func (svc *channelBackupService) uploadChannelBackupFile(ctx context.Context, backupUrl string, backupFile []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, backupUrl, bytes.NewReader(backupFile))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := svc.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("channel backup upload failed with status %d", res.StatusCode)
+	}
+	return nil
+}
+
+// channelsFingerprint changes whenever a channel is opened or closed
+func (svc *channelBackupService) channelsFingerprint(ctx context.Context, lnClient lnclient.LNClient) (string, int, error) {
+	channels, err := lnClient.ListChannels(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	channelIds := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		channelIds = append(channelIds, channel.Id)
+	}
+	slices.Sort(channelIds)
+	return checksum([]byte(strings.Join(channelIds, ","))), len(channels), nil
+}
+
+func writeChannelBackupFile(dir string, backupFile []byte) error {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first so that the previous backup is never left half-written
+	tmpFile := filepath.Join(dir, channelBackupFileName+".tmp")
+	err = os.WriteFile(tmpFile, backupFile, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, filepath.Join(dir, channelBackupFileName))
+}
+
+func checksum(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
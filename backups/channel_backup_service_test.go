@@ -0,0 +1,135 @@
+package backups
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportChannelBackup(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.LNClient.(*tests.MockLn).Channels = []lnclient.Channel{{Id: "channel-1"}}
+
+	channelBackupService := NewChannelBackupService(svc.Cfg)
+	status, err := channelBackupService.GetChannelBackupStatus(ctx, svc.LNClient)
+	assert.NoError(t, err)
+	assert.True(t, status.Outdated)
+	assert.Nil(t, status.LastExportedAt)
+
+	backupFile, err := channelBackupService.ExportChannelBackup(ctx, svc.LNClient)
+	assert.NoError(t, err)
+
+	channelBackupFile, err := channelBackupService.VerifyChannelBackup(ctx, svc.LNClient, backupFile)
+	assert.NoError(t, err)
+	assert.Equal(t, tests.MockChannelBackup, string(channelBackupFile.Data))
+	assert.Equal(t, 1, channelBackupFile.ChannelCount)
+	assert.Equal(t, svc.LNClient.GetPubkey(), channelBackupFile.NodeId)
+
+	status, err = channelBackupService.GetChannelBackupStatus(ctx, svc.LNClient)
+	assert.NoError(t, err)
+	assert.False(t, status.Outdated)
+	assert.NotNil(t, status.LastExportedAt)
+
+	// a new channel needs a new backup
+	svc.LNClient.(*tests.MockLn).Channels = append(svc.LNClient.(*tests.MockLn).Channels, lnclient.Channel{Id: "channel-2"})
+	status, err = channelBackupService.GetChannelBackupStatus(ctx, svc.LNClient)
+	assert.NoError(t, err)
+	assert.True(t, status.Outdated)
+	assert.Equal(t, 2, status.ChannelCount)
+}
+
+func TestVerifyChannelBackup_Corrupted(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	channelBackupService := NewChannelBackupService(svc.Cfg)
+	backupFile, err := channelBackupService.ExportChannelBackup(ctx, svc.LNClient)
+	assert.NoError(t, err)
+
+	channelBackupFile := &ChannelBackupFile{}
+	assert.NoError(t, json.Unmarshal(backupFile, channelBackupFile))
+	channelBackupFile.Data[0] ^= 0xff
+	corruptedFile, err := json.Marshal(channelBackupFile)
+	assert.NoError(t, err)
+	_, err = channelBackupService.VerifyChannelBackup(ctx, svc.LNClient, corruptedFile)
+	assert.EqualError(t, err, "channel backup is corrupted: checksum does not match")
+
+	// the node rejects backups that have a valid checksum but invalid data
+	channelBackupFile.Checksum = checksum(channelBackupFile.Data)
+	invalidFile, err := json.Marshal(channelBackupFile)
+	assert.NoError(t, err)
+	_, err = channelBackupService.VerifyChannelBackup(ctx, svc.LNClient, invalidFile)
+	assert.EqualError(t, err, "channel backup is invalid: invalid channel backup")
+
+	_, err = channelBackupService.VerifyChannelBackup(ctx, svc.LNClient, []byte("not a backup"))
+	assert.Error(t, err)
+}
+
+func TestVerifyChannelBackup_OtherNode(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	channelBackupService := NewChannelBackupService(svc.Cfg)
+	backupFile, err := channelBackupService.ExportChannelBackup(ctx, svc.LNClient)
+	assert.NoError(t, err)
+
+	svc.LNClient.(*tests.MockLn).Pubkey = "02" + "11111111111111111111111111111111111111111111111111111111111111"
+	_, err = channelBackupService.VerifyChannelBackup(ctx, svc.LNClient, backupFile)
+	assert.EqualError(t, err, "channel backup belongs to another node")
+}
+
+func TestCheckChannelBackup_AutoBackup(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.LNClient.(*tests.MockLn).Channels = []lnclient.Channel{{Id: "channel-1"}}
+
+	uploads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), `"checksum"`)
+		uploads++
+	}))
+	defer server.Close()
+
+	backupDir := t.TempDir()
+	svc.Cfg.GetEnv().ChannelBackupDir = backupDir
+	svc.Cfg.GetEnv().ChannelBackupUrl = server.URL
+
+	channelBackupService := NewChannelBackupService(svc.Cfg)
+	channelBackupService.checkChannelBackup(ctx, svc.LNClient)
+	assert.Equal(t, 1, uploads)
+
+	backupFile, err := os.ReadFile(filepath.Join(backupDir, channelBackupFileName))
+	assert.NoError(t, err)
+	_, err = channelBackupService.VerifyChannelBackup(ctx, svc.LNClient, backupFile)
+	assert.NoError(t, err)
+
+	// nothing changed, so no new backup is uploaded
+	channelBackupService.checkChannelBackup(ctx, svc.LNClient)
+	assert.Equal(t, 1, uploads)
+
+	status, err := channelBackupService.GetChannelBackupStatus(ctx, svc.LNClient)
+	assert.NoError(t, err)
+	assert.True(t, status.AutoBackupEnabled)
+	assert.False(t, status.Outdated)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+)
+
+const (
+	exportFormatCSV          = "csv"
+	exportFormatLedger       = "ledger"
+	exportFormatBeancount    = "beancount"
+	exportFormatKoinly       = "koinly"
+	exportFormatCoinTracking = "cointracking"
+)
+
+var exportFormats = []string{exportFormatCSV, exportFormatLedger, exportFormatBeancount, exportFormatKoinly, exportFormatCoinTracking}
+
+// accounts of the ledger and beancount exports
+const (
+	lightningAccount     = "Assets:Lightning"
+	incomeAccount        = "Income:Lightning"
+	expensesAccount      = "Expenses:Lightning"
+	feesExpensesAccount  = "Expenses:Lightning:Fees"
+	accountingCommodity  = "BTC"
+	satsPerBitcoin       = 100_000_000
+	accountingDateFormat = "2006-01-02"
+)
+
+// formatBTC rounds down to whole sats, the accounting tools do not support millisats
+func formatBTC(amountMsat uint64) string {
+	sats := amountMsat / 1000
+	return fmt.Sprintf("%d.%08d", sats/satsPerBitcoin, sats%satsPerBitcoin)
+}
+
+func settledAt(transaction *db.Transaction) time.Time {
+	if transaction.SettledAt != nil {
+		return transaction.SettledAt.UTC()
+	}
+	return transaction.CreatedAt.UTC()
+}
+
+func transactionPayee(transaction *db.Transaction) string {
+	payee := "Received"
+	if transaction.Type == constants.TRANSACTION_TYPE_OUTGOING {
+		payee = "Sent"
+	}
+	description := strings.Join(strings.Fields(transaction.Description), " ")
+	if description != "" {
+		payee += ": " + description
+	}
+	return payee
+}
+
+// writeTransactionsLedger writes the transactions in the plain text format of ledger-cli
+func writeTransactionsLedger(w io.Writer, transactions []db.Transaction) error {
+	for _, transaction := range transactions {
+		fmt.Fprintf(w, "%s * %s\n", settledAt(&transaction).Format("2006/01/02"), transactionPayee(&transaction))
+		fmt.Fprintf(w, "    ; payment_hash: %s\n", transaction.PaymentHash)
+		if transaction.Type == constants.TRANSACTION_TYPE_OUTGOING {
+			fmt.Fprintf(w, "    %-28s %s %s\n", expensesAccount, formatBTC(transaction.AmountMsat), accountingCommodity)
+			if transaction.FeeMsat >= 1000 {
+				fmt.Fprintf(w, "    %-28s %s %s\n", feesExpensesAccount, formatBTC(transaction.FeeMsat), accountingCommodity)
+			}
+			fmt.Fprintf(w, "    %s\n\n", lightningAccount)
+		} else {
+			fmt.Fprintf(w, "    %-28s %s %s\n", lightningAccount, formatBTC(transaction.AmountMsat), accountingCommodity)
+			fmt.Fprintf(w, "    %s\n\n", incomeAccount)
+		}
+	}
+	return nil
+}
+
+// writeTransactionsBeancount writes the transactions as a beancount file, including the open directives of the accounts
+func writeTransactionsBeancount(w io.Writer, transactions []db.Transaction) error {
+	if len(transactions) == 0 {
+		return nil
+	}
+	openedAt := settledAt(&transactions[0]).Format(accountingDateFormat)
+	for _, account := range []string{lightningAccount, incomeAccount, expensesAccount, feesExpensesAccount} {
+		fmt.Fprintf(w, "%s open %s %s\n", openedAt, account, accountingCommodity)
+	}
+	fmt.Fprintln(w)
+
+	for _, transaction := range transactions {
+		narration := strings.Join(strings.Fields(transaction.Description), " ")
+		narration = strings.ReplaceAll(strings.ReplaceAll(narration, `\`, `\\`), `"`, `\"`)
+		payee := "Received"
+		if transaction.Type == constants.TRANSACTION_TYPE_OUTGOING {
+			payee = "Sent"
+		}
+		fmt.Fprintf(w, "%s * \"%s\" \"%s\"\n", settledAt(&transaction).Format(accountingDateFormat), payee, narration)
+		fmt.Fprintf(w, "  payment_hash: \"%s\"\n", transaction.PaymentHash)
+		if transaction.Type == constants.TRANSACTION_TYPE_OUTGOING {
+			fmt.Fprintf(w, "  %-28s %s %s\n", expensesAccount, formatBTC(transaction.AmountMsat), accountingCommodity)
+			if transaction.FeeMsat >= 1000 {
+				fmt.Fprintf(w, "  %-28s %s %s\n", feesExpensesAccount, formatBTC(transaction.FeeMsat), accountingCommodity)
+			}
+			fmt.Fprintf(w, "  %s\n\n", lightningAccount)
+		} else {
+			fmt.Fprintf(w, "  %-28s %s %s\n", lightningAccount, formatBTC(transaction.AmountMsat), accountingCommodity)
+			fmt.Fprintf(w, "  %s\n\n", incomeAccount)
+		}
+	}
+	return nil
+}
+
+// writeTransactionsKoinly writes the Koinly universal CSV format.
+// The net worth columns are left empty, Koinly fills them in with its own historical prices.
+func writeTransactionsKoinly(w io.Writer, transactions []db.Transaction) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"Date", "Sent Amount", "Sent Currency", "Received Amount", "Received Currency", "Fee Amount", "Fee Currency", "Net Worth Amount", "Net Worth Currency", "Label", "Description", "TxHash"})
+	for _, transaction := range transactions {
+		row := make([]string, 12)
+		row[0] = settledAt(&transaction).Format("2006-01-02 15:04:05 UTC")
+		if transaction.Type == constants.TRANSACTION_TYPE_OUTGOING {
+			row[1] = formatBTC(transaction.AmountMsat)
+			row[2] = accountingCommodity
+			if transaction.FeeMsat >= 1000 {
+				row[5] = formatBTC(transaction.FeeMsat)
+				row[6] = accountingCommodity
+			}
+		} else {
+			row[3] = formatBTC(transaction.AmountMsat)
+			row[4] = accountingCommodity
+		}
+		row[10] = transaction.Description
+		row[11] = transaction.PaymentHash
+		csvWriter.Write(row)
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// writeTransactionsCoinTracking writes the CSV import format of CoinTracking
+func writeTransactionsCoinTracking(w io.Writer, transactions []db.Transaction) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"Type", "Buy Amount", "Buy Currency", "Sell Amount", "Sell Currency", "Fee", "Fee Currency", "Exchange", "Trade-Group", "Comment", "Date"})
+	for _, transaction := range transactions {
+		row := make([]string, 11)
+		if transaction.Type == constants.TRANSACTION_TYPE_OUTGOING {
+			row[0] = "Withdrawal"
+			row[3] = formatBTC(transaction.AmountMsat)
+			row[4] = accountingCommodity
+			if transaction.FeeMsat >= 1000 {
+				row[5] = formatBTC(transaction.FeeMsat)
+				row[6] = accountingCommodity
+			}
+		} else {
+			row[0] = "Deposit"
+			row[1] = formatBTC(transaction.AmountMsat)
+			row[2] = accountingCommodity
+		}
+		row[7] = "Alby Hub"
+		row[9] = strings.TrimSpace(transaction.Description + " " + transaction.PaymentHash)
+		row[10] = settledAt(&transaction).Format("2006-01-02 15:04:05")
+		csvWriter.Write(row)
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"gopkg.in/macaroon.v2"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/lnclient/btcpay"
+	"github.com/getAlby/hub/lnclient/lnd"
+	"github.com/getAlby/hub/lnclient/nwc"
+	"github.com/getAlby/hub/lnclient/phoenixd"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/service"
+)
+
+const (
+	checkPassed  = "PASS"
+	checkFailed  = "FAIL"
+	checkSkipped = "SKIP"
+)
+
+type checkResult struct {
+	name   string
+	status string
+	detail string
+}
+
+func passed(name string, detail string) checkResult {
+	return checkResult{name: name, status: checkPassed, detail: detail}
+}
+
+func failed(name string, err error) checkResult {
+	return checkResult{name: name, status: checkFailed, detail: err.Error()}
+}
+
+func skipped(name string, detail string) checkResult {
+	return checkResult{name: name, status: checkSkipped, detail: detail}
+}
+
+// runCheckConfig checks the config without starting the hub: the values, keys, database, relay and backend.
+// Nothing is written, the database is not migrated and the node is only asked for its info.
+func runCheckConfig(args []string) error {
+	flags := newFlagSet("check-config")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of every network check")
+	flags.Parse(args)
+
+	results := []checkResult{}
+	appConfig, err := service.LoadAppConfig()
+	if err == nil {
+		err = appConfig.Validate()
+	}
+	if err != nil {
+		results = append(results, failed("config", err))
+	} else {
+		logger.Init(appConfig.LogLevel)
+		results = append(results, passed("config", "valid"))
+		results = append(results, checkWorkdir(appConfig))
+		results = append(results, checkKeys(appConfig))
+		results = append(results, checkDatabase(appConfig))
+		results = append(results, checkRelay(appConfig, *timeout))
+		results = append(results, checkBackend(appConfig, *timeout))
+	}
+
+	failedChecks := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, result := range results {
+		if result.status == checkFailed {
+			failedChecks++
+		}
+		// multiple validation errors are on separate lines
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.status, result.name, strings.ReplaceAll(result.detail, "\n", "; "))
+	}
+	w.Flush()
+
+	if failedChecks > 0 {
+		return fmt.Errorf("%d of %d checks failed", failedChecks, len(results))
+	}
+	return nil
+}
+
+func checkWorkdir(appConfig *config.AppConfig) checkResult {
+	workdirStat, err := os.Stat(appConfig.Workdir)
+	if os.IsNotExist(err) {
+		return passed("workdir", fmt.Sprintf("%s is created on the first start", appConfig.Workdir))
+	}
+	if err != nil {
+		return failed("workdir", fmt.Errorf("cannot access %s: %w", appConfig.Workdir, err))
+	}
+	if !workdirStat.IsDir() {
+		return failed("workdir", fmt.Errorf("%s is not a directory", appConfig.Workdir))
+	}
+	return passed("workdir", appConfig.Workdir)
+}
+
+// checkKeys parses the LND certificate and macaroon, the keys of the other backends are checked by connecting to them
+func checkKeys(appConfig *config.AppConfig) checkResult {
+	if appConfig.LNBackendType != config.LNDBackendType {
+		return skipped("keys", "no keys to parse for the backend")
+	}
+	if appConfig.LNDCertFile != "" {
+		certBytes, err := os.ReadFile(appConfig.LNDCertFile)
+		if err != nil {
+			return failed("keys", fmt.Errorf("LND_CERT_FILE: %w", err))
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(certBytes) {
+			return failed("keys", errors.New("LND_CERT_FILE: no PEM encoded certificate found"))
+		}
+	}
+	macaroonHex, err := lndMacaroonHex(appConfig)
+	if err != nil {
+		return failed("keys", err)
+	}
+	macaroonBytes, err := hex.DecodeString(macaroonHex)
+	if err != nil {
+		return failed("keys", fmt.Errorf("LND_MACAROON_HEX: %w", err))
+	}
+	err = (&macaroon.Macaroon{}).UnmarshalBinary(macaroonBytes)
+	if err != nil {
+		return failed("keys", fmt.Errorf("invalid LND macaroon: %w", err))
+	}
+	return passed("keys", "LND certificate and macaroon")
+}
+
+func lndMacaroonHex(appConfig *config.AppConfig) (string, error) {
+	if appConfig.LNDMacaroonHex != "" {
+		return appConfig.LNDMacaroonHex, nil
+	}
+	macaroonBytes, err := os.ReadFile(appConfig.LNDMacaroonFile)
+	if err != nil {
+		return "", fmt.Errorf("LND_MACAROON_FILE: %w", err)
+	}
+	return hex.EncodeToString(macaroonBytes), nil
+}
+
+func checkDatabase(appConfig *config.AppConfig) checkResult {
+	if !db.IsPostgresUri(appConfig.DatabaseUri) {
+		path := strings.TrimPrefix(strings.SplitN(appConfig.DatabaseUri, "?", 2)[0], "file:")
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			// opening the database would create it
+			dirStat, err := os.Stat(filepath.Dir(path))
+			switch {
+			case os.IsNotExist(err), err == nil && dirStat.IsDir():
+				return passed("database", fmt.Sprintf("%s is created on the first start", path))
+			case err != nil:
+				return failed("database", err)
+			default:
+				return failed("database", fmt.Errorf("%s is not a directory", filepath.Dir(path)))
+			}
+		}
+	}
+	err := db.Ping(appConfig.DatabaseUri)
+	if err != nil {
+		return failed("database", fmt.Errorf("failed to connect: %w", err))
+	}
+	return passed("database", "connected")
+}
+
+func checkRelay(appConfig *config.AppConfig, timeout time.Duration) checkResult {
+	if appConfig.AllInOne {
+		return skipped("relay", "the hub serves its own relay in all-in-one mode")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	relay, err := nostr.RelayConnect(ctx, appConfig.Relay)
+	if err != nil {
+		return failed("relay", fmt.Errorf("failed to connect to %s: %w", appConfig.Relay, err))
+	}
+	relay.Close()
+	return passed("relay", fmt.Sprintf("connected to %s", appConfig.Relay))
+}
+
+// checkBackend connects to the node of the backend, the embedded nodes are started by the hub itself
+func checkBackend(appConfig *config.AppConfig, timeout time.Duration) checkResult {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var lnClient lnclient.LNClient
+	var err error
+	switch appConfig.LNBackendType {
+	case "":
+		return skipped("backend", "no LN_BACKEND_TYPE set, the node is configured in the setup")
+	case config.LNDBackendType:
+		var certHex, macaroonHex string
+		if appConfig.LNDCertFile != "" {
+			certBytes, err := os.ReadFile(appConfig.LNDCertFile)
+			if err != nil {
+				return failed("backend", fmt.Errorf("LND_CERT_FILE: %w", err))
+			}
+			certHex = hex.EncodeToString(certBytes)
+		}
+		macaroonHex, err = lndMacaroonHex(appConfig)
+		if err != nil {
+			return failed("backend", err)
+		}
+		lnClient, err = lnd.NewLNDService(ctx, events.NewEventPublisher(), appConfig.LNDAddress, certHex, macaroonHex)
+	case config.PhoenixBackendType:
+		lnClient, err = phoenixd.NewPhoenixService(appConfig.PhoenixdAddress, appConfig.PhoenixdAuthorization)
+	case config.BTCPayBackendType:
+		lnClient, err = btcpay.NewBTCPayService(appConfig.BTCPayUrl, appConfig.BTCPayApiKey, appConfig.BTCPayStoreId)
+	case config.NWCBackendType:
+		lnClient, err = nwc.NewNWCService(ctx, events.NewEventPublisher(), appConfig.NWCConnectionUri, "")
+	case config.LDKBackendType:
+		return checkEsplora(ctx, appConfig.LDKEsploraServer)
+	default:
+		return skipped("backend", fmt.Sprintf("the %s node is started by the hub", appConfig.LNBackendType))
+	}
+	if err != nil {
+		return failed("backend", fmt.Errorf("failed to connect to the %s node: %w", appConfig.LNBackendType, err))
+	}
+	defer lnClient.Shutdown()
+
+	info, err := lnClient.GetInfo(ctx)
+	if err != nil {
+		return failed("backend", fmt.Errorf("failed to get the info of the %s node: %w", appConfig.LNBackendType, err))
+	}
+	return passed("backend", fmt.Sprintf("%s node %s on %s", appConfig.LNBackendType, info.Pubkey, info.Network))
+}
+
+// the embedded LDK node syncs with the Esplora server
+func checkEsplora(ctx context.Context, esploraServer string) checkResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(esploraServer, "/")+"/blocks/tip/height", nil)
+	if err != nil {
+		return failed("backend", fmt.Errorf("LDK_ESPLORA_SERVER: %w", err))
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return failed("backend", fmt.Errorf("failed to reach the Esplora server %s: %w", esploraServer, err))
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return failed("backend", fmt.Errorf("the Esplora server %s returned status %d", esploraServer, res.StatusCode))
+	}
+	return passed("backend", fmt.Sprintf("LDK node syncs with %s", esploraServer))
+}
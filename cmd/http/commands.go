@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/getAlby/hub/api"
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nip47/permissions"
+	"github.com/getAlby/hub/service"
+	"gorm.io/gorm"
+)
+
+func runMigrate(args []string) error {
+	flags := newFlagSet("migrate")
+	flags.Parse(args)
+
+	gormDB, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Stop(gormDB)
+
+	fmt.Println("Database is up to date")
+	return nil
+}
+
+func runCreateApp(args []string) error {
+	flags := newFlagSet("create-app")
+	name := flags.String("name", "", "name of the app (required)")
+	pubkey := flags.String("pubkey", "", "nostr pubkey of the app, generated if empty")
+	scopes := flags.String("scopes", strings.Join(permissions.AllScopes(), ","), "comma-separated list of scopes")
+	maxAmount := flags.Uint64("max-amount", 0, "budget in sats, 0 for no budget")
+	budgetRenewal := flags.String("budget-renewal", constants.BUDGET_RENEWAL_MONTHLY, "budget renewal: daily, weekly, monthly, yearly or never")
+	expiresAt := flags.String("expires-at", "", "expiry of the connection in RFC3339 format")
+	isolated := flags.Bool("isolated", false, "create an isolated app with its own balance")
+	passwordFile := flags.String("password-file", "", "file to read the unlock password from, otherwise it is prompted for or read from stdin")
+	flags.Parse(args)
+
+	if *name == "" {
+		return errors.New("-name is required")
+	}
+
+	svc, err := newCommandService()
+	if err != nil {
+		return err
+	}
+	defer db.Stop(svc.GetDB())
+
+	unlockPassword, err := readUnlockPassword(*passwordFile)
+	if err != nil {
+		return err
+	}
+	err = unlock(svc, unlockPassword)
+	if err != nil {
+		return err
+	}
+	// keys are needed to build the pairing URI
+	err = svc.GetKeys().Init(svc.GetConfig(), unlockPassword)
+	if err != nil {
+		return err
+	}
+
+	response, err := newCommandAPI(svc).CreateApp(&api.CreateAppRequest{
+		Name:          *name,
+		Pubkey:        *pubkey,
+		MaxAmountSat:  *maxAmount,
+		BudgetRenewal: *budgetRenewal,
+		ExpiresAt:     *expiresAt,
+		Scopes:        strings.Split(*scopes, ","),
+		Isolated:      *isolated,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(response.PairingUri)
+	return nil
+}
+
+func runListApps(args []string) error {
+	flags := newFlagSet("list-apps")
+	flags.Parse(args)
+
+	svc, err := newCommandService()
+	if err != nil {
+		return err
+	}
+	defer db.Stop(svc.GetDB())
+
+	apps, err := newCommandAPI(svc).ListApps()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPUBKEY\tBUDGET (SATS)\tLAST USED\tISOLATED")
+	for _, app := range apps {
+		budget := "-"
+		if app.MaxAmountSat > 0 {
+			budget = fmt.Sprintf("%d/%d %s", app.BudgetUsage, app.MaxAmountSat, app.BudgetRenewal)
+		}
+		lastUsed := "never"
+		if app.LastEventAt != nil {
+			lastUsed = app.LastEventAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%t\n", app.ID, app.Name, app.NostrPubkey, budget, lastUsed, app.Isolated)
+	}
+	return w.Flush()
+}
+
+func runExport(args []string) error {
+	flags := newFlagSet("export")
+	output := flags.String("output", "", "file to write to, stdout if empty")
+	appId := flags.Uint("app-id", 0, "only export transactions of this app")
+	format := flags.String("format", exportFormatCSV, "output format: "+strings.Join(exportFormats, ", "))
+	flags.Parse(args)
+
+	if !slices.Contains(exportFormats, *format) {
+		return fmt.Errorf("unsupported format %q", *format)
+	}
+
+	gormDB, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Stop(gormDB)
+
+	query := gormDB.Order("created_at ASC")
+	if *appId != 0 {
+		query = query.Where("app_id = ?", *appId)
+	}
+	if *format != exportFormatCSV {
+		// the accounting formats only contain payments that moved funds
+		query = query.Where("state = ?", constants.TRANSACTION_STATE_SETTLED)
+	}
+	var transactions []db.Transaction
+	err = query.Find(&transactions).Error
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case exportFormatLedger:
+		return writeTransactionsLedger(w, transactions)
+	case exportFormatBeancount:
+		return writeTransactionsBeancount(w, transactions)
+	case exportFormatKoinly:
+		return writeTransactionsKoinly(w, transactions)
+	case exportFormatCoinTracking:
+		return writeTransactionsCoinTracking(w, transactions)
+	default:
+		return writeTransactionsCSV(w, transactions)
+	}
+}
+
+func writeTransactionsCSV(w io.Writer, transactions []db.Transaction) error {
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Write([]string{"created_at", "settled_at", "type", "state", "amount_msat", "fee_msat", "payment_hash", "description", "app_id"})
+	for _, transaction := range transactions {
+		settledAt := ""
+		if transaction.SettledAt != nil {
+			settledAt = transaction.SettledAt.Format(time.RFC3339)
+		}
+		appId := ""
+		if transaction.AppId != nil {
+			appId = strconv.FormatUint(uint64(*transaction.AppId), 10)
+		}
+		csvWriter.Write([]string{
+			transaction.CreatedAt.Format(time.RFC3339),
+			settledAt,
+			transaction.Type,
+			transaction.State,
+			strconv.FormatUint(transaction.AmountMsat, 10),
+			strconv.FormatUint(transaction.FeeMsat, 10),
+			transaction.PaymentHash,
+			transaction.Description,
+			appId,
+		})
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func runBackup(args []string) error {
+	flags := newFlagSet("backup")
+	output := flags.String("output", "", "file to write the backup to (required)")
+	passwordFile := flags.String("password-file", "", "file to read the unlock password from, otherwise it is prompted for or read from stdin")
+	flags.Parse(args)
+
+	if *output == "" {
+		return errors.New("-output is required")
+	}
+
+	svc, err := newCommandService()
+	if err != nil {
+		return err
+	}
+
+	unlockPassword, err := readUnlockPassword(*passwordFile)
+	if err != nil {
+		return err
+	}
+	err = unlock(svc, unlockPassword)
+	if err != nil {
+		return err
+	}
+
+	// the node has to be running to find its storage directory.
+	// The hub must not be running in another process, as the node data is locked.
+	err = svc.StartApp(unlockPassword)
+	if err != nil {
+		return fmt.Errorf("failed to start node: %w", err)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		svc.StopApp()
+		return err
+	}
+	defer file.Close()
+
+	// CreateBackup stops the node and closes the database
+	err = newCommandAPI(svc).CreateBackup(unlockPassword, file)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Backup written to %s\n", *output)
+	return nil
+}
+
+// openDB opens the database (running any pending migrations) without starting the hub
+func openDB() (*gorm.DB, error) {
+	appConfig, err := service.LoadAppConfig()
+	if err != nil {
+		return nil, err
+	}
+	logger.Init(appConfig.LogLevel)
+
+	err = os.MkdirAll(appConfig.Workdir, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	return db.NewDB(appConfig.DatabaseUri)
+}
+
+func newCommandService() (service.Service, error) {
+	return service.NewService(context.Background())
+}
+
+func newCommandAPI(svc service.Service) api.API {
+	return api.NewAPI(svc, svc.GetDB(), svc.GetConfig(), svc.GetKeys(), svc.GetAlbyOAuthSvc(), svc.GetEventPublisher())
+}
+
+// unlock checks the password of a hub that has already been set up through the web UI
+func unlock(svc service.Service, unlockPassword string) error {
+	unlockPasswordCheck, _ := svc.GetConfig().Get("UnlockPasswordCheck", "")
+	if unlockPasswordCheck == "" {
+		return errors.New("hub is not set up yet, complete the setup in the web UI first")
+	}
+
+	if !svc.GetConfig().CheckUnlockPassword(unlockPassword) {
+		return errors.New("invalid unlock password")
+	}
+	return nil
+}
+
+// the password is never taken as an argument, where other users could see it in the process list
+func readUnlockPassword(passwordFile string) (string, error) {
+	if passwordFile != "" {
+		passwordBytes, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(passwordBytes), "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Unlock password: ")
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		passwordBytes, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return string(passwordBytes), err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+
+	"github.com/getAlby/hub/api"
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/nip47/permissions"
+	"github.com/getAlby/hub/service"
+	"github.com/getAlby/hub/tests"
+)
+
+const loadTestPassword = "loadtest"
+
+// payloads of the NIP-47 requests sent by the synthetic clients
+var loadTestRequests = map[string]map[string]interface{}{
+	models.GET_INFO_METHOD:    {},
+	models.GET_BALANCE_METHOD: {},
+	models.MAKE_INVOICE_METHOD: {
+		"amount":      1000,
+		"description": "loadtest",
+	},
+	models.LOOKUP_INVOICE_METHOD: {
+		"payment_hash": tests.MockPaymentHash,
+	},
+	models.LIST_TRANSACTIONS_METHOD: {
+		"limit": 10,
+	},
+	models.PAY_KEYSEND_METHOD: {
+		"amount": 1000,
+		"pubkey": tests.MockNodeInfo.Pubkey,
+	},
+}
+
+type loadTestResult struct {
+	method  string
+	latency time.Duration
+	// the NIP-47 error code, or an error of the client (e.g. a timeout)
+	err string
+}
+
+func runLoadTest(args []string) error {
+	flags := newFlagSet("loadtest")
+	clients := flags.Int("clients", 10, "number of NWC clients sending requests at the same time")
+	requests := flags.Int("requests", 50, "number of requests sent by each client")
+	methods := flags.String("methods", "get_info,get_balance,make_invoice,list_transactions,pay_keysend", "comma-separated list of methods, the clients take turns through them")
+	timeout := flags.Duration("timeout", 30*time.Second, "how long a client waits for a response")
+	allowRemoteRelay := flags.Bool("allow-remote-relay", false, "allow a relay that is not running on this machine")
+	flags.Parse(args)
+
+	methodList := strings.Split(*methods, ",")
+	for i, method := range methodList {
+		method = strings.TrimSpace(method)
+		methodList[i] = method
+		if _, ok := loadTestRequests[method]; !ok {
+			return fmt.Errorf("unsupported method %q", method)
+		}
+	}
+	if *clients <= 0 || *requests <= 0 {
+		return errors.New("-clients and -requests must be positive")
+	}
+
+	// the hub runs with its own data, so the data of an existing hub is not touched
+	workdir, err := os.MkdirTemp("", "albyhub-loadtest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workdir)
+	os.Setenv("WORK_DIR", workdir)
+	os.Setenv("DATABASE_URI", filepath.Join(workdir, "nwc.db"))
+	os.Setenv("KEY_STORE", "db")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc, err := service.NewService(ctx)
+	if err != nil {
+		return err
+	}
+	defer svc.Shutdown()
+
+	relayUrl := svc.GetConfig().GetRelayUrl()
+	if !*allowRemoteRelay && !isLocalRelay(relayUrl) {
+		return fmt.Errorf("relay %s is not running on this machine, pass -allow-remote-relay to use it anyway", relayUrl)
+	}
+
+	svc.GetConfig().Setup(loadTestPassword)
+	lnClient, err := tests.NewMockLn()
+	if err != nil {
+		return err
+	}
+	err = svc.StartAppWithLNClient(loadTestPassword, lnClient)
+	if err != nil {
+		return err
+	}
+	walletPubkey := svc.GetKeys().GetNostrPublicKey()
+
+	loadTestClients := []*loadTestClient{}
+	for i := 0; i < *clients; i++ {
+		client, err := newLoadTestClient(ctx, svc, relayUrl, walletPubkey, i)
+		if err != nil {
+			return err
+		}
+		defer client.relay.Close()
+		loadTestClients = append(loadTestClients, client)
+	}
+
+	// the hub might not be subscribed to the relay yet
+	for i, client := range loadTestClients {
+		_, err := client.sendRequest(ctx, models.GET_INFO_METHOD, 2**timeout)
+		if err != nil {
+			return fmt.Errorf("client %d did not get a response: %w", i, err)
+		}
+	}
+
+	var resultsMtx sync.Mutex
+	results := []loadTestResult{}
+	var wg sync.WaitGroup
+	fmt.Fprintf(os.Stderr, "Running %d clients x %d requests against %s\n", *clients, *requests, relayUrl)
+	startedAt := time.Now()
+	for i, client := range loadTestClients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < *requests; j++ {
+				method := methodList[(i+j)%len(methodList)]
+				result := loadTestResult{method: method}
+				requestStartedAt := time.Now()
+				code, err := client.sendRequest(ctx, method, *timeout)
+				result.latency = time.Since(requestStartedAt)
+				if err != nil {
+					result.err = err.Error()
+				} else {
+					result.err = code
+				}
+
+				resultsMtx.Lock()
+				results = append(results, result)
+				resultsMtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	printLoadTestResults(results, time.Since(startedAt))
+	return nil
+}
+
+func isLocalRelay(relayUrl string) bool {
+	parsedUrl, err := url.Parse(relayUrl)
+	if err != nil {
+		return false
+	}
+	host := parsedUrl.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+type loadTestClient struct {
+	relay        *nostr.Relay
+	secretKey    string
+	pubkey       string
+	walletPubkey string
+	sharedSecret []byte
+	mu           sync.Mutex
+	responses    map[string]chan *nostr.Event
+}
+
+// newLoadTestClient creates an app connection with all scopes and no budget, and subscribes to its responses
+func newLoadTestClient(ctx context.Context, svc service.Service, relayUrl string, walletPubkey string, index int) (*loadTestClient, error) {
+	secretKey := nostr.GeneratePrivateKey()
+	pubkey, err := nostr.GetPublicKey(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := nip04.ComputeSharedSecret(walletPubkey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = newCommandAPI(svc).CreateApp(&api.CreateAppRequest{
+		Name:          fmt.Sprintf("loadtest %d", index),
+		Pubkey:        pubkey,
+		BudgetRenewal: constants.BUDGET_RENEWAL_NEVER,
+		Scopes:        permissions.AllScopes(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	relay, err := nostr.RelayConnect(ctx, relayUrl)
+	if err != nil {
+		return nil, err
+	}
+	since := nostr.Now()
+	sub, err := relay.Subscribe(ctx, nostr.Filters{{
+		Kinds:   []int{models.RESPONSE_KIND},
+		Authors: []string{walletPubkey},
+		Tags:    nostr.TagMap{"p": []string{pubkey}},
+		Since:   &since,
+	}})
+	if err != nil {
+		relay.Close()
+		return nil, err
+	}
+
+	client := &loadTestClient{
+		relay:        relay,
+		secretKey:    secretKey,
+		pubkey:       pubkey,
+		walletPubkey: walletPubkey,
+		sharedSecret: sharedSecret,
+		responses:    map[string]chan *nostr.Event{},
+	}
+	go func() {
+		for event := range sub.Events {
+			eTag := event.Tags.GetFirst([]string{"e"})
+			if eTag == nil {
+				continue
+			}
+			client.mu.Lock()
+			response, ok := client.responses[eTag.Value()]
+			client.mu.Unlock()
+			if ok {
+				// relays can send the same event more than once
+				select {
+				case response <- event:
+				default:
+				}
+			}
+		}
+	}()
+	return client, nil
+}
+
+// sendRequest returns the error code of the response, which is empty if the request succeeded
+func (client *loadTestClient) sendRequest(ctx context.Context, method string, timeout time.Duration) (string, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"method": method,
+		"params": loadTestRequests[method],
+	})
+	if err != nil {
+		return "", err
+	}
+	content, err := nip04.Encrypt(string(payload), client.sharedSecret)
+	if err != nil {
+		return "", err
+	}
+	event := nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{[]string{"p", client.walletPubkey}},
+		Content:   content,
+	}
+	err = event.Sign(client.secretKey)
+	if err != nil {
+		return "", err
+	}
+
+	// multi_pay methods are not supported, so every request gets a single response
+	response := make(chan *nostr.Event, 1)
+	client.mu.Lock()
+	client.responses[event.ID] = response
+	client.mu.Unlock()
+	defer func() {
+		client.mu.Lock()
+		delete(client.responses, event.ID)
+		client.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err = client.relay.Publish(ctx, event)
+	if err != nil {
+		return "", err
+	}
+
+	select {
+	case <-ctx.Done():
+		return "", errors.New("timed out")
+	case responseEvent := <-response:
+		decrypted, err := nip04.Decrypt(responseEvent.Content, client.sharedSecret)
+		if err != nil {
+			return "", err
+		}
+		nip47Response := models.Response{}
+		err = json.Unmarshal([]byte(decrypted), &nip47Response)
+		if err != nil {
+			return "", err
+		}
+		if nip47Response.Error != nil {
+			return nip47Response.Error.Code, nil
+		}
+		return "", nil
+	}
+}
+
+func printLoadTestResults(results []loadTestResult, duration time.Duration) {
+	resultsByMethod := map[string][]loadTestResult{}
+	for _, result := range results {
+		resultsByMethod[result.method] = append(resultsByMethod[result.method], result)
+	}
+	methods := []string{}
+	for method := range resultsByMethod {
+		methods = append(methods, method)
+	}
+	slices.Sort(methods)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tREQUESTS\tERRORS\tREQ/S\tP50\tP99")
+	for _, method := range methods {
+		printLoadTestRow(w, method, resultsByMethod[method], duration)
+	}
+	printLoadTestRow(w, "total", results, duration)
+	w.Flush()
+
+	// the error codes help to tell an overloaded hub (e.g. RATE_LIMITED) from a broken one
+	errorCounts := map[string]int{}
+	for _, result := range results {
+		if result.err != "" {
+			errorCounts[result.method+": "+result.err]++
+		}
+	}
+	for message, count := range errorCounts {
+		fmt.Fprintf(os.Stdout, "%dx %s\n", count, message)
+	}
+}
+
+func printLoadTestRow(w *tabwriter.Writer, method string, results []loadTestResult, duration time.Duration) {
+	latencies := make([]time.Duration, len(results))
+	errorCount := 0
+	for i, result := range results {
+		latencies[i] = result.latency
+		if result.err != "" {
+			errorCount++
+		}
+	}
+	slices.Sort(latencies)
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.1f\t%s\t%s\n",
+		method,
+		len(results),
+		errorCount,
+		float64(len(results))/duration.Seconds(),
+		percentile(latencies, 50).Round(time.Millisecond),
+		percentile(latencies, 99).Round(time.Millisecond))
+}
+
+// percentile expects the latencies to be sorted
+func percentile(latencies []time.Duration, p int) time.Duration {
+	if len(latencies) == 0 {
+		return 0
+	}
+	return latencies[(len(latencies)-1)*p/100]
+}
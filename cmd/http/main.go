@@ -1,62 +1,76 @@
 package main
 
 import (
-	"context"
+	"flag"
 	"fmt"
-	nethttp "net/http"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	"strings"
 
-	echologrus "github.com/davrux/echo-logrus/v4"
-	"github.com/getAlby/hub/http"
+	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/logger"
-	"github.com/getAlby/hub/service"
-	"github.com/labstack/echo/v4"
-	log "github.com/sirupsen/logrus"
 )
 
+type command struct {
+	name        string
+	description string
+	run         func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "Start the hub in HTTP mode (default)", runServe},
+	{"migrate", "Run database migrations and exit", runMigrate},
+	{"create-app", "Create an app connection and print its pairing URI", runCreateApp},
+	{"list-apps", "List app connections", runListApps},
+	{"export", "Export transactions as CSV or for accounting tools", runExport},
+	{"backup", "Create an encrypted backup of the hub data", runBackup},
+	{"check-config", "Validate the environment config", runCheckConfig},
+	{"loadtest", "Measure NWC request throughput against a local relay with a mock node", runLoadTest},
+}
+
 func main() {
-	log.Info("NWC Starting in HTTP mode")
-
-	// Create a channel to receive OS signals.
-	osSignalChannel := make(chan os.Signal, 1)
-	// Notify the channel on os.Interrupt, syscall.SIGTERM, and os.Kill.
-	signal.Notify(osSignalChannel, os.Interrupt, syscall.SIGTERM, os.Kill)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	svc, _ := service.NewService(ctx)
-
-	echologrus.Logger = logger.Logger
-	e := echo.New()
-
-	//register shared routes
-	httpSvc := http.NewHttpService(svc, svc.GetEventPublisher())
-	httpSvc.RegisterSharedRoutes(e)
-	//start Echo server
-	go func() {
-		if err := e.Start(fmt.Sprintf(":%v", svc.GetConfig().GetEnv().Port)); err != nil && err != nethttp.ErrServerClosed {
-			logger.Logger.Fatalf("shutting down the server: %v", err)
+	// no subcommand (or only flags) keeps the previous behaviour of starting the server
+	name := "serve"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name = args[0]
+		args = args[1:]
+	}
+
+	if name == "help" {
+		printUsage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if name != "serve" {
+				// keep stdout for the command output
+				logger.SetOutput(os.Stderr)
+			}
+			if err := cmd.run(args); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
 		}
-	}()
-
-	var signal os.Signal
-	go func() {
-		// wait for exit signal
-		signal = <-osSignalChannel
-		logger.Logger.WithField("signal", signal).Info("Received OS signal")
-		cancel()
-	}()
-
-	//handle graceful shutdown
-	<-ctx.Done()
-	logger.Logger.WithField("signal", signal).Info("Context Done")
-	logger.Logger.Info("Shutting down echo server...")
-	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	e.Shutdown(ctx)
-	logger.Logger.Info("Echo server exited")
-	svc.Shutdown()
-	logger.Logger.Info("Service exited")
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	printUsage()
+	os.Exit(2)
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", os.Args[0])
+}
+
+// newFlagSet creates the flags of a command, including a flag for every config option
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	config.RegisterFlags(flags)
+	return flags
 }
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/daemon"
+	"github.com/getAlby/hub/http"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/service"
+	"github.com/labstack/echo/v4"
+)
+
+func runServe(args []string) error {
+	flags := newFlagSet("serve")
+	flags.Parse(args)
+
+	// Create a channel to receive OS signals.
+	osSignalChannel := make(chan os.Signal, 1)
+	// Notify the channel on os.Interrupt, syscall.SIGTERM, and os.Kill.
+	signal.Notify(osSignalChannel, os.Interrupt, syscall.SIGTERM, os.Kill)
+
+	var child *upgradeChild
+	if isUpgradeChild() {
+		child = openUpgradeChild()
+		// checked before the service is created, as it runs the migrations
+		if err := checkUpgradeMigrations(); err != nil {
+			child.refuse(err)
+			return fmt.Errorf("refused to take over from the upgraded process: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	svc, err := service.NewService(ctx)
+	if err != nil {
+		cancel()
+		if child != nil {
+			child.refuse(err)
+		}
+		return err
+	}
+	logger.Logger.Info("NWC Starting in HTTP mode")
+
+	listener, err := listen(svc.GetConfig().GetEnv().Port)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	e := echo.New()
+	e.Listener = listener
+
+	//register shared routes
+	httpSvc := http.NewHttpService(svc, svc.GetEventPublisher())
+	httpSvc.RegisterSharedRoutes(e)
+
+	//start Echo server
+	go func() {
+		if err := e.Start(""); err != nil && err != nethttp.ErrServerClosed {
+			logger.Logger.Fatalf("shutting down the server: %v", err)
+		}
+	}()
+
+	// headless deployments can provide the unlock password instead of unlocking from the UI
+	unlockPassword := svc.GetConfig().GetEnv().AutoUnlockPassword
+	if child != nil {
+		logger.Logger.Info("Taking over from the upgraded process")
+		go takeOver(svc, child, unlockPassword)
+	} else if unlockPassword != "" {
+		if err := unlock(svc, unlockPassword); err != nil {
+			logger.Logger.WithError(err).Error("Failed to unlock on startup")
+		} else {
+			go func() {
+				if err := svc.StartApp(unlockPassword); err != nil {
+					logger.Logger.WithError(err).Error("Failed to start app")
+				}
+			}()
+		}
+	}
+
+	startSystemdNotifier(ctx, svc.GetConfig().GetEnv().Port)
+
+	// reload the config on SIGHUP without restarting the hub
+	reloadSignalChannel := make(chan os.Signal, 1)
+	signal.Notify(reloadSignalChannel, syscall.SIGHUP)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reloadSignalChannel:
+				logger.Logger.Info("Received SIGHUP, reloading config")
+				sdNotify(daemon.SdNotifyReloading)
+				if err := svc.ReloadConfig(); err != nil {
+					logger.Logger.WithError(err).Error("Failed to reload config")
+				}
+				sdNotify(daemon.SdNotifyReady)
+			}
+		}
+	}()
+
+	// upgrade to a new binary on SIGUSR2
+	var upg *upgrade
+	var upgradeMtx sync.Mutex
+	upgradeSignalChannel := make(chan os.Signal, 1)
+	signal.Notify(upgradeSignalChannel, syscall.SIGUSR2)
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-upgradeSignalChannel:
+				logger.Logger.Info("Received SIGUSR2, starting upgrade")
+				newUpgrade, err := startUpgrade(listener)
+				if err != nil {
+					logger.Logger.WithError(err).Error("Failed to upgrade")
+					continue
+				}
+				upgradeMtx.Lock()
+				if ctx.Err() != nil {
+					// the hub was shut down while waiting for the new process
+					newUpgrade.abort()
+					upgradeMtx.Unlock()
+					return
+				}
+				logger.Logger.WithField("pid", newUpgrade.cmd.Process.Pid).Info("New process is ready, handing over")
+				err = newUpgrade.handOver(svc.HandOverPassword())
+				if err != nil {
+					logger.Logger.WithError(err).Error("Failed to upgrade")
+					upgradeMtx.Unlock()
+					continue
+				}
+				upg = newUpgrade
+				cancel()
+				upgradeMtx.Unlock()
+				return
+			}
+		}
+	}()
+
+	var signal os.Signal
+	go func() {
+		// wait for exit signal
+		signal = <-osSignalChannel
+		logger.Logger.WithField("signal", signal).Info("Received OS signal")
+		cancel()
+	}()
+
+	//handle graceful shutdown
+	<-ctx.Done()
+	logger.Logger.WithField("signal", signal).Info("Context Done")
+	upgradeMtx.Lock()
+	defer upgradeMtx.Unlock()
+	if upg != nil {
+		// systemd keeps tracking the service through the new process
+		sdNotify(fmt.Sprintf("MAINPID=%d", upg.cmd.Process.Pid))
+	} else {
+		sdNotify(daemon.SdNotifyStopping)
+	}
+	logger.Logger.Info("Shutting down echo server...")
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	e.Shutdown(ctx)
+	logger.Logger.Info("Echo server exited")
+	if upg != nil {
+		// the new process keeps the requests it receives until the app was stopped here
+		svc.StopApp()
+		upg.complete()
+		logger.Logger.Info("Handed over to the new process")
+	}
+	svc.Shutdown()
+	logger.Logger.Info("Service exited")
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"os"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/daemon"
+
+	"github.com/getAlby/hub/logger"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// startSystemdNotifier signals readiness to systemd (Type=notify) once the
+// HTTP server answers health checks and, if WatchdogSec is set, keeps pinging
+// the watchdog for as long as the health checks pass.
+func startSystemdNotifier(ctx context.Context, port string) {
+	if os.Getenv("NOTIFY_SOCKET") == "" {
+		return
+	}
+
+	healthUrl := fmt.Sprintf("http://127.0.0.1:%s/api/health", port)
+	client := &nethttp.Client{Timeout: healthCheckTimeout}
+
+	go func() {
+		for checkHealth(ctx, client, healthUrl) != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(500 * time.Millisecond):
+			}
+		}
+		sdNotify(daemon.SdNotifyReady)
+
+		watchdogInterval, err := daemon.SdWatchdogEnabled(false)
+		if err != nil {
+			logger.Logger.WithError(err).Error("Invalid systemd watchdog configuration")
+			return
+		}
+		if watchdogInterval == 0 {
+			return
+		}
+		logger.Logger.WithField("interval", watchdogInterval).Info("Starting systemd watchdog")
+
+		ticker := time.NewTicker(watchdogInterval / 2)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// withholding the ping makes systemd restart the hub
+				if err := checkHealth(ctx, client, healthUrl); err != nil {
+					logger.Logger.WithError(err).Warn("Health check failed, skipping systemd watchdog ping")
+					continue
+				}
+				sdNotify(daemon.SdNotifyWatchdog)
+			}
+		}
+	}()
+}
+
+func checkHealth(ctx context.Context, client *nethttp.Client, healthUrl string) error {
+	req, err := nethttp.NewRequestWithContext(ctx, nethttp.MethodGet, healthUrl, nil)
+	if err != nil {
+		return err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected health check status: %d", res.StatusCode)
+	}
+	return nil
+}
+
+func sdNotify(state string) {
+	if _, err := daemon.SdNotify(false, state); err != nil {
+		logger.Logger.WithError(err).WithField("state", state).Error("Failed to notify systemd")
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/service"
+)
+
+// A running hub is upgraded by replacing its binary and sending it SIGUSR2.
+// The new process inherits the HTTP listener, so no connections are refused,
+// and serves HTTP requests once it has started. The old process then passes it
+// the unlock password, and the new process subscribes to the relay next to the
+// old one, keeping the requests it receives. NIP-47 requests are ephemeral and
+// not stored by relays, so they would be lost otherwise. The old process then
+// finishes its in-flight HTTP and NIP-47 requests and stops the node, and the
+// new process starts the node and handles the requests it kept.
+// Versions with new migrations are not started while the old process uses the
+// database, they refuse the upgrade and need a restart.
+const (
+	upgradeEnv = "ALBYHUB_UPGRADE"
+	// file descriptors passed to the new process
+	upgradeListenerFd = 3
+	upgradeReadyFd    = 4
+	upgradePasswordFd = 5
+
+	upgradeReadyTimeout = 2 * time.Minute
+)
+
+type upgrade struct {
+	cmd          *exec.Cmd
+	readyPipe    *bufio.Reader
+	readyFile    *os.File
+	passwordPipe *os.File
+}
+
+func isUpgradeChild() bool {
+	return os.Getenv(upgradeEnv) == "1"
+}
+
+// listen creates the HTTP listener, or takes over the one of the process being upgraded
+func listen(port string) (net.Listener, error) {
+	if isUpgradeChild() {
+		return net.FileListener(os.NewFile(upgradeListenerFd, "listener"))
+	}
+	return net.Listen("tcp", fmt.Sprintf(":%v", port))
+}
+
+// startUpgrade starts the new binary and waits until it is ready to take over
+func startUpgrade(listener net.Listener) (*upgrade, error) {
+	tcpListener, ok := listener.(*net.TCPListener)
+	if !ok {
+		return nil, errors.New("listener cannot be passed to another process")
+	}
+	listenerFile, err := tcpListener.File()
+	if err != nil {
+		return nil, err
+	}
+	defer listenerFile.Close()
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	passwordReader, passwordWriter, err := os.Pipe()
+	if err != nil {
+		readyReader.Close()
+		readyWriter.Close()
+		return nil, err
+	}
+
+	// the binary at the original path, not the replaced one of this process
+	cmd := exec.Command(os.Args[0], os.Args[1:]...)
+	cmd.Env = append(os.Environ(), upgradeEnv+"=1")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{listenerFile, readyWriter, passwordReader}
+	err = cmd.Start()
+	readyWriter.Close()
+	passwordReader.Close()
+	if err != nil {
+		readyReader.Close()
+		passwordWriter.Close()
+		return nil, err
+	}
+
+	upg := &upgrade{
+		cmd:          cmd,
+		readyPipe:    bufio.NewReader(readyReader),
+		readyFile:    readyReader,
+		passwordPipe: passwordWriter,
+	}
+	err = upg.waitFor("ready")
+	if err != nil {
+		upg.abort()
+		return nil, fmt.Errorf("new process did not become ready: %w", err)
+	}
+	return upg, nil
+}
+
+// waitFor waits until the new process sends the message
+func (upg *upgrade) waitFor(message string) error {
+	received := make(chan error, 1)
+	go func() {
+		line, err := upg.readyPipe.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if err == nil && strings.HasPrefix(line, "refused: ") {
+			err = errors.New(strings.TrimPrefix(line, "refused: "))
+		} else if err == nil && line != message {
+			err = fmt.Errorf("unexpected message: %q", line)
+		}
+		received <- err
+	}()
+
+	select {
+	case err := <-received:
+		return err
+	case <-time.After(upgradeReadyTimeout):
+		return errors.New("timed out")
+	}
+}
+
+// handOver passes the unlock password to the new process and waits until it has subscribed to the relay,
+// after which this process can stop the app
+func (upg *upgrade) handOver(unlockPassword string) error {
+	_, err := io.WriteString(upg.passwordPipe, unlockPassword+"\n")
+	if err == nil {
+		err = upg.waitFor("subscribed")
+	}
+	if err != nil {
+		upg.abort()
+		return fmt.Errorf("new process did not subscribe to the relay: %w", err)
+	}
+	return nil
+}
+
+// complete tells the new process that the app was stopped and it can start the node
+func (upg *upgrade) complete() {
+	upg.passwordPipe.Close()
+	upg.readyFile.Close()
+	// the new process is not reaped by this process, it keeps running after exit
+	upg.cmd.Process.Release()
+}
+
+func (upg *upgrade) abort() {
+	upg.passwordPipe.Close()
+	upg.readyFile.Close()
+	upg.cmd.Process.Kill()
+	upg.cmd.Wait()
+}
+
+// upgradeChild is the side of the new process
+type upgradeChild struct {
+	readyPipe    *os.File
+	passwordPipe *bufio.Reader
+}
+
+func openUpgradeChild() *upgradeChild {
+	os.Unsetenv(upgradeEnv)
+	return &upgradeChild{
+		readyPipe:    os.NewFile(upgradeReadyFd, "ready"),
+		passwordPipe: bufio.NewReader(os.NewFile(upgradePasswordFd, "password")),
+	}
+}
+
+func (child *upgradeChild) send(message string) {
+	_, err := io.WriteString(child.readyPipe, message+"\n")
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to send message to the upgraded process")
+	}
+}
+
+// refuse tells the process being upgraded that this process cannot take over, so it keeps running
+func (child *upgradeChild) refuse(reason error) {
+	child.send("refused: " + reason.Error())
+	child.readyPipe.Close()
+}
+
+// ready tells the process being upgraded that this process has started and returns the unlock password
+func (child *upgradeChild) ready() (string, error) {
+	child.send("ready")
+	unlockPassword, err := child.passwordPipe.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read unlock password from the upgraded process: %w", err)
+	}
+	return strings.TrimSuffix(unlockPassword, "\n"), nil
+}
+
+// subscribed tells the process being upgraded that it can stop the app, and blocks until it has done so
+func (child *upgradeChild) subscribed() {
+	child.send("subscribed")
+	child.readyPipe.Close()
+	// the pipe is closed once the app was stopped, or when the process exited
+	io.Copy(io.Discard, child.passwordPipe)
+}
+
+// checkUpgradeMigrations refuses to take over if the migrations of this version did not run yet,
+// as the process that is upgraded would keep using the database while they run
+func checkUpgradeMigrations() error {
+	appConfig, err := service.LoadAppConfig()
+	if err != nil {
+		return err
+	}
+	pending, err := db.HasPendingMigrations(appConfig.DatabaseUri)
+	if err != nil {
+		return err
+	}
+	if pending {
+		return errors.New("this version changes the database schema, restart the hub instead of upgrading it")
+	}
+	return nil
+}
+
+// takeOver subscribes to the relay while the process being upgraded stops the app,
+// then starts the app in this process
+func takeOver(svc service.Service, child *upgradeChild, autoUnlockPassword string) {
+	unlockPassword, err := child.ready()
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to take over from the upgraded process")
+	}
+	if unlockPassword != "" {
+		err = svc.StandBy(unlockPassword)
+		if err != nil {
+			// requests sent until the app is started here are lost
+			logger.Logger.WithError(err).Error("Failed to subscribe to the relay before the handover")
+		}
+	}
+	child.subscribed()
+	logger.Logger.Info("The upgraded process stopped the app, starting it")
+
+	if unlockPassword == "" {
+		// the app was not started by the upgraded process
+		unlockPassword = autoUnlockPassword
+	}
+	if unlockPassword == "" {
+		return
+	}
+	if err := unlock(svc, unlockPassword); err != nil {
+		logger.Logger.WithError(err).Error("Failed to unlock on startup")
+		return
+	}
+	if err := svc.TakeOver(unlockPassword); err != nil {
+		logger.Logger.WithError(err).Error("Failed to start app")
+	}
+}
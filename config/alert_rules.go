@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the metrics that alert rules can be set for
+const (
+	// percentage of the outgoing payments in the window that failed
+	AlertMetricPaymentFailureRate = "payment_failure_rate"
+	// number of failed outgoing payments in the window
+	AlertMetricPaymentFailures = "payment_failures"
+	// number of payments in the window that were rejected because they exceed the budget of the app
+	AlertMetricBudgetExceeded = "budget_exceeded"
+	// number of requests in the window that the app did not have the permission for
+	AlertMetricPermissionDenied = "permission_denied"
+	// time the hub has not been connected to the relay
+	AlertMetricRelayDisconnected = "relay_disconnected"
+)
+
+// AlertRule fires when the value of the metric is above the threshold
+type AlertRule struct {
+	// the rule as it was configured, e.g. "payment_failure_rate > 20% over 10m"
+	Rule   string
+	Metric string
+	// a percentage for payment_failure_rate, a number of events for the other counted metrics
+	Threshold float64
+	// the time the events are counted in, or the time the relay has to be disconnected for relay_disconnected
+	Window time.Duration
+}
+
+// ParseAlertRules parses a comma-separated list of rules in the form
+// "<metric> > <threshold> over <window>", e.g. "payment_failures > 5 over 1h",
+// or "relay_disconnected > <duration>", e.g. "relay_disconnected > 2m".
+func ParseAlertRules(alertRules string) ([]AlertRule, error) {
+	var parsed []AlertRule
+	for _, rule := range strings.Split(alertRules, ",") {
+		rule = strings.Join(strings.Fields(rule), " ")
+		if rule == "" {
+			continue
+		}
+		alertRule, err := parseAlertRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %w", rule, err)
+		}
+		parsed = append(parsed, *alertRule)
+	}
+	return parsed, nil
+}
+
+func parseAlertRule(rule string) (*AlertRule, error) {
+	fields := strings.Fields(rule)
+	if len(fields) < 3 || fields[1] != ">" {
+		return nil, fmt.Errorf("expected a rule like \"payment_failures > 5 over 1h\"")
+	}
+	alertRule := &AlertRule{
+		Rule:   rule,
+		Metric: fields[0],
+	}
+
+	if alertRule.Metric == AlertMetricRelayDisconnected {
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("expected a rule like \"relay_disconnected > 2m\"")
+		}
+		duration, err := time.ParseDuration(fields[2])
+		if err != nil || duration <= 0 {
+			return nil, fmt.Errorf("invalid duration %q", fields[2])
+		}
+		alertRule.Window = duration
+		return alertRule, nil
+	}
+
+	if len(fields) != 5 || fields[3] != "over" {
+		return nil, fmt.Errorf("expected a window like \"over 10m\"")
+	}
+	window, err := time.ParseDuration(fields[4])
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("invalid window %q", fields[4])
+	}
+	alertRule.Window = window
+
+	threshold := fields[2]
+	switch alertRule.Metric {
+	case AlertMetricPaymentFailureRate:
+		percentage, ok := strings.CutSuffix(threshold, "%")
+		if !ok {
+			return nil, fmt.Errorf("the threshold of %s is a percentage, e.g. 20%%", alertRule.Metric)
+		}
+		threshold = percentage
+	case AlertMetricPaymentFailures, AlertMetricBudgetExceeded, AlertMetricPermissionDenied:
+	default:
+		return nil, fmt.Errorf("unknown metric %q", alertRule.Metric)
+	}
+	alertRule.Threshold, err = strconv.ParseFloat(threshold, 64)
+	if err != nil || alertRule.Threshold < 0 {
+		return nil, fmt.Errorf("invalid threshold %q", fields[2])
+	}
+	return alertRule, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAlertRules(t *testing.T) {
+	alertRules, err := ParseAlertRules("payment_failure_rate > 20% over 10m, relay_disconnected > 2m,  budget_exceeded  > 3 over 1h,")
+	assert.NoError(t, err)
+	assert.Equal(t, []AlertRule{
+		{Rule: "payment_failure_rate > 20% over 10m", Metric: AlertMetricPaymentFailureRate, Threshold: 20, Window: 10 * time.Minute},
+		{Rule: "relay_disconnected > 2m", Metric: AlertMetricRelayDisconnected, Window: 2 * time.Minute},
+		{Rule: "budget_exceeded > 3 over 1h", Metric: AlertMetricBudgetExceeded, Threshold: 3, Window: time.Hour},
+	}, alertRules)
+
+	alertRules, err = ParseAlertRules("")
+	assert.NoError(t, err)
+	assert.Empty(t, alertRules)
+
+	_, err = ParseAlertRules("payment_failures>5")
+	assert.ErrorContains(t, err, `invalid rule "payment_failures>5"`)
+	_, err = ParseAlertRules("payment_failure_rate > 20 over 10m")
+	assert.ErrorContains(t, err, "percentage")
+	_, err = ParseAlertRules("payment_failures > 5 over soon")
+	assert.ErrorContains(t, err, `invalid window "soon"`)
+	_, err = ParseAlertRules("channel_closed > 1 over 1h")
+	assert.ErrorContains(t, err, `unknown metric "channel_closed"`)
+	_, err = ParseAlertRules("relay_disconnected > 2m over 1h")
+	assert.Error(t, err)
+}
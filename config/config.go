@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"sync/atomic"
 
 	"github.com/getAlby/hub/db"
 	"github.com/getAlby/hub/logger"
@@ -14,7 +16,8 @@ import (
 )
 
 type config struct {
-	Env          *AppConfig
+	// swapped as a whole on reload, so readers never see a partially reloaded config
+	env          atomic.Pointer[AppConfig]
 	CookieSecret string
 	db           *gorm.DB
 }
@@ -32,46 +35,62 @@ func NewConfig(env *AppConfig, db *gorm.DB) *config {
 }
 
 func (cfg *config) init(env *AppConfig) {
-	cfg.Env = env
+	cfg.env.Store(env)
 
-	if cfg.Env.Relay != "" {
-		cfg.SetUpdate("Relay", cfg.Env.Relay, "")
+	if env.Relay != "" {
+		cfg.SetUpdate("Relay", env.Relay, "")
 	}
-	if cfg.Env.LNBackendType != "" {
-		cfg.SetUpdate("LNBackendType", cfg.Env.LNBackendType, "")
+	if env.LNBackendType != "" {
+		cfg.SetUpdate("LNBackendType", env.LNBackendType, "")
 	}
 
 	// LND specific to support env variables
-	if cfg.Env.LNDAddress != "" {
-		cfg.SetUpdate("LNDAddress", cfg.Env.LNDAddress, "")
+	if env.LNDAddress != "" {
+		cfg.SetUpdate("LNDAddress", env.LNDAddress, "")
 	}
-	if cfg.Env.LNDCertFile != "" {
-		certBytes, err := os.ReadFile(cfg.Env.LNDCertFile)
+	if env.LNDCertFile != "" {
+		certBytes, err := os.ReadFile(env.LNDCertFile)
 		if err != nil {
 			logger.Logger.Fatalf("Failed to read LND cert file: %v", err)
 		}
 		certHex := hex.EncodeToString(certBytes)
 		cfg.SetUpdate("LNDCertHex", certHex, "")
 	}
-	if cfg.Env.LNDMacaroonFile != "" {
-		macBytes, err := os.ReadFile(cfg.Env.LNDMacaroonFile)
+	if env.LNDMacaroonFile != "" {
+		macBytes, err := os.ReadFile(env.LNDMacaroonFile)
 		if err != nil {
 			logger.Logger.Fatalf("Failed to read LND macaroon file: %v", err)
 		}
 		macHex := hex.EncodeToString(macBytes)
 		cfg.SetUpdate("LNDMacaroonHex", macHex, "")
 	}
+	if env.LNDMacaroonHex != "" {
+		cfg.SetUpdate("LNDMacaroonHex", env.LNDMacaroonHex, "")
+	}
 	// Phoenix specific to support env variables
-	if cfg.Env.PhoenixdAddress != "" {
-		cfg.SetUpdate("PhoenixdAddress", cfg.Env.PhoenixdAddress, "")
+	if env.PhoenixdAddress != "" {
+		cfg.SetUpdate("PhoenixdAddress", env.PhoenixdAddress, "")
+	}
+	if env.PhoenixdAuthorization != "" {
+		cfg.SetUpdate("PhoenixdAuthorization", env.PhoenixdAuthorization, "")
+	}
+	// BTCPay specific to support env variables
+	if env.BTCPayUrl != "" {
+		cfg.SetUpdate("BTCPayUrl", env.BTCPayUrl, "")
+	}
+	if env.BTCPayApiKey != "" {
+		cfg.SetUpdate("BTCPayApiKey", env.BTCPayApiKey, "")
+	}
+	if env.BTCPayStoreId != "" {
+		cfg.SetUpdate("BTCPayStoreId", env.BTCPayStoreId, "")
 	}
-	if cfg.Env.PhoenixdAuthorization != "" {
-		cfg.SetUpdate("PhoenixdAuthorization", cfg.Env.PhoenixdAuthorization, "")
+	if env.NWCConnectionUri != "" {
+		cfg.SetUpdate("NWCConnectionUri", env.NWCConnectionUri, "")
 	}
 
 	// set the cookie secret to the one from the env
 	// if no cookie secret is configured we create a random one and store it in the DB
-	cfg.CookieSecret = cfg.Env.CookieSecret
+	cfg.CookieSecret = env.CookieSecret
 	if cfg.CookieSecret == "" {
 		hex, err := randomHex(20)
 		if err == nil {
@@ -81,6 +100,54 @@ func (cfg *config) init(env *AppConfig) {
 	}
 }
 
+// Reload applies the settings that can be changed at runtime
+// and warns about changed settings that need a restart
+func (cfg *config) Reload(env *AppConfig) {
+	currentEnv := cfg.GetEnv()
+	reloadedEnv := *currentEnv
+	changed := false
+	logLevelChanged := false
+	for _, key := range configKeys() {
+		currentValue, _ := configField(currentEnv, key)
+		newValue, _ := configField(env, key)
+		if currentValue.Interface() == newValue.Interface() {
+			continue
+		}
+		if !slices.Contains(reloadableKeys, key) {
+			logger.Logger.WithField("key", key).Warn("Config option changed, restart the hub to apply it")
+			continue
+		}
+		reloadedValue, _ := configField(&reloadedEnv, key)
+		reloadedValue.Set(newValue)
+		changed = true
+		logger.Logger.WithField("key", key).Info("Reloaded config option")
+
+		switch key {
+		case "LOG_LEVEL", "LOG_LEVEL_HTTP", "LOG_LEVEL_NOSTR", "LOG_LEVEL_LNCLIENT", "LOG_LEVEL_DB":
+			logLevelChanged = true
+		case "RELAY":
+			// the current relay connection is kept, the new relay is used when reconnecting
+			if env.Relay != "" {
+				cfg.SetUpdate("Relay", env.Relay, "")
+			}
+		case "LND_MACAROON_HEX":
+			// the running node keeps its connection, the new macaroon is used when the node is started again
+			if env.LNDMacaroonHex != "" {
+				cfg.SetUpdate("LNDMacaroonHex", env.LNDMacaroonHex, "")
+			}
+		}
+	}
+	if !changed {
+		return
+	}
+	cfg.env.Store(&reloadedEnv)
+
+	if logLevelChanged {
+		logger.SetLevel(reloadedEnv.LogLevel)
+		logger.SetComponentLevels(reloadedEnv.ComponentLogLevels())
+	}
+}
+
 func (cfg *config) GetCookieSecret() string {
 	return cfg.CookieSecret
 }
@@ -143,7 +210,7 @@ func (cfg *config) SetIgnore(key string, value string, encryptionKey string) {
 func (cfg *config) SetUpdate(key string, value string, encryptionKey string) {
 	clauses := clause.OnConflict{
 		Columns:   []clause.Column{{Name: "key"}},
-		DoUpdates: clause.AssignmentColumns([]string{"value"}),
+		DoUpdates: clause.AssignmentColumns([]string{"value", "encrypted"}),
 	}
 	err := cfg.set(key, value, clauses, encryptionKey, cfg.db)
 	if err != nil {
@@ -206,8 +273,9 @@ func (cfg *config) Setup(encryptionKey string) {
 	cfg.SetUpdate("UnlockPasswordCheck", unlockPasswordCheck, encryptionKey)
 }
 
+// GetEnv returns the current config. It must not be modified, reloads replace it with a new one
 func (cfg *config) GetEnv() *AppConfig {
-	return cfg.Env
+	return cfg.env.Load()
 }
 
 func randomHex(n int) (string, error) {
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getAlby/hub/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// feature flags gate experimental subsystems so they can ship disabled
+// and be turned on per instance or per app
+const (
+	FeatureNotifications     = "notifications"
+	FeatureLNURL             = "lnurl"
+	FeatureMultiPartPayments = "multi_part_payments"
+	FeatureBTCPayBackend     = "btcpay_backend"
+	FeatureNWCBackend        = "nwc_backend"
+)
+
+var featureDefaults = map[string]bool{
+	FeatureNotifications:     true,
+	FeatureLNURL:             true,
+	FeatureMultiPartPayments: false,
+	FeatureBTCPayBackend:     false,
+	FeatureNWCBackend:        false,
+}
+
+// backends that are still experimental, keyed by backend type
+var backendFeatures = map[string]string{
+	BTCPayBackendType: FeatureBTCPayBackend,
+	NWCBackendType:    FeatureNWCBackend,
+}
+
+func featureConfigKey(feature string) string {
+	return "FeatureFlag." + feature
+}
+
+func appFeatureConfigKey(feature string, appId uint) string {
+	return fmt.Sprintf("FeatureFlag.%s.app.%d", feature, appId)
+}
+
+// IsFeatureEnabled checks, in order of precedence, the DB override, FEATURE_FLAGS and the default
+func (cfg *config) IsFeatureEnabled(feature string) bool {
+	enabled, ok := featureDefaults[feature]
+	if !ok {
+		logger.Logger.WithField("feature", feature).Error("Unknown feature flag")
+		return false
+	}
+
+	if envEnabled, ok := parseFeatureFlags(cfg.GetEnv().FeatureFlags)[feature]; ok {
+		enabled = envEnabled
+	}
+
+	override, err := cfg.Get(featureConfigKey(feature), "")
+	if err != nil {
+		logger.Logger.WithField("feature", feature).WithError(err).Error("Failed to read feature flag override")
+		return enabled
+	}
+	if override != "" {
+		enabled = override == "true"
+	}
+	return enabled
+}
+
+// SetFeatureEnabled stores an override that takes precedence over the environment
+func (cfg *config) SetFeatureEnabled(feature string, enabled bool) error {
+	if _, ok := featureDefaults[feature]; !ok {
+		return fmt.Errorf("unknown feature flag: %s", feature)
+	}
+	cfg.SetUpdate(featureConfigKey(feature), strconv.FormatBool(enabled), "")
+	return nil
+}
+
+// IsFeatureEnabledForApp checks the override of the app before the ones of the hub
+func (cfg *config) IsFeatureEnabledForApp(feature string, appId uint) bool {
+	if _, ok := featureDefaults[feature]; !ok {
+		logger.Logger.WithField("feature", feature).Error("Unknown feature flag")
+		return false
+	}
+	override, err := cfg.Get(appFeatureConfigKey(feature, appId), "")
+	if err != nil {
+		logger.Logger.WithFields(logrus.Fields{
+			"feature": feature,
+			"app_id":  appId,
+		}).WithError(err).Error("Failed to read feature flag override of app")
+	}
+	if override != "" {
+		return override == "true"
+	}
+	return cfg.IsFeatureEnabled(feature)
+}
+
+// SetAppFeatureEnabled stores an override for one app that takes precedence over the ones of the hub
+func (cfg *config) SetAppFeatureEnabled(feature string, appId uint, enabled bool) error {
+	if _, ok := featureDefaults[feature]; !ok {
+		return fmt.Errorf("unknown feature flag: %s", feature)
+	}
+	cfg.SetUpdate(appFeatureConfigKey(feature, appId), strconv.FormatBool(enabled), "")
+	return nil
+}
+
+// IsBackendEnabled returns false for experimental backends whose feature flag is not enabled
+func (cfg *config) IsBackendEnabled(backendType string) bool {
+	feature, ok := backendFeatures[backendType]
+	return !ok || cfg.IsFeatureEnabled(feature)
+}
+
+// BackendFeature returns the feature flag of an experimental backend, or an empty string
+func BackendFeature(backendType string) string {
+	return backendFeatures[backendType]
+}
+
+func (cfg *config) GetAppFeatures(appId uint) map[string]bool {
+	features := map[string]bool{}
+	for feature := range featureDefaults {
+		features[feature] = cfg.IsFeatureEnabledForApp(feature, appId)
+	}
+	return features
+}
+
+func (cfg *config) GetFeatures() map[string]bool {
+	features := map[string]bool{}
+	for feature := range featureDefaults {
+		features[feature] = cfg.IsFeatureEnabled(feature)
+	}
+	return features
+}
+
+// parseFeatureFlags parses a comma-separated list like "lnurl=false,multi_part_payments".
+// Unknown flags are ignored.
+func parseFeatureFlags(featureFlags string) map[string]bool {
+	flags := map[string]bool{}
+	for _, flag := range strings.Split(featureFlags, ",") {
+		name, value, hasValue := strings.Cut(strings.TrimSpace(flag), "=")
+		if name == "" {
+			continue
+		}
+		enabled := true
+		if hasValue {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				continue
+			}
+			enabled = parsed
+		}
+		flags[name] = enabled
+	}
+	return flags
+}
+
+func validateFeatureFlags(featureFlags string) error {
+	for name := range parseFeatureFlags(featureFlags) {
+		if _, ok := featureDefaults[name]; !ok {
+			return fmt.Errorf("FEATURE_FLAGS: unknown feature flag %q, known flags: %s", name, strings.Join(knownFeatures(), ", "))
+		}
+	}
+	return nil
+}
+
+func knownFeatures() []string {
+	features := make([]string, 0, len(featureDefaults))
+	for feature := range featureDefaults {
+		features = append(features, feature)
+	}
+	slices.Sort(features)
+	return features
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFeatureFlags(t *testing.T) {
+	flags := parseFeatureFlags(" multi_part_payments, lnurl=false,notifications=invalid,,")
+	assert.Equal(t, map[string]bool{
+		FeatureMultiPartPayments: true,
+		FeatureLNURL:             false,
+	}, flags)
+}
+
+func TestValidateFeatureFlags(t *testing.T) {
+	assert.NoError(t, validateFeatureFlags(""))
+	assert.NoError(t, validateFeatureFlags("lnurl=false,multi_part_payments"))
+
+	err := validateFeatureFlags("webhooks")
+	assert.EqualError(t, err, `FEATURE_FLAGS: unknown feature flag "webhooks", known flags: btcpay_backend, lnurl, multi_part_payments, notifications, nwc_backend`)
+}
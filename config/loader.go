@@ -0,0 +1,217 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"github.com/kelseyhightower/envconfig"
+	"gopkg.in/yaml.v3"
+)
+
+// settings that can be changed at runtime by reloading the config (SIGHUP).
+// All other settings are only read on startup.
+var reloadableKeys = []string{
+	"LOG_LEVEL",
+	"LOG_LEVEL_HTTP",
+	"LOG_LEVEL_NOSTR",
+	"LOG_LEVEL_LNCLIENT",
+	"LOG_LEVEL_DB",
+	"RELAY",
+	"LOG_EVENTS",
+	"AUTO_LINK_ALBY_ACCOUNT",
+	"BLOCK_DUPLICATE_PAYMENTS",
+	"LIGHTNING_ADDRESS_USERNAME",
+	"MEMPOOL_API",
+	"FEATURE_FLAGS",
+	"ALBY_OAUTH_CLIENT_SECRET",
+	"LND_MACAROON_HEX",
+	"NOTIFICATION_EVENTS",
+	"ALERT_RULES",
+	"TELEGRAM_BOT_TOKEN",
+	"TELEGRAM_CHAT_ID",
+	"MATRIX_HOMESERVER_URL",
+	"MATRIX_ACCESS_TOKEN",
+	"MATRIX_ROOM_ID",
+	"DISCORD_WEBHOOK_URL",
+	"SLACK_WEBHOOK_URL",
+	"WEBHOOK_URL",
+	"WEBHOOK_FORMAT",
+	"SMTP_HOST",
+	"SMTP_PORT",
+	"SMTP_USERNAME",
+	"SMTP_PASSWORD",
+	"SMTP_FROM",
+	"RECEIPT_EMAIL",
+	"RECEIPT_MIN_AMOUNT_SAT",
+	"RECEIPT_CURRENCY",
+}
+
+// values passed as command line flags, keyed by environment variable name
+var flagValues = map[string]string{}
+
+// RegisterFlags adds a flag for every config option, e.g. -log-level for LOG_LEVEL
+func RegisterFlags(flags *flag.FlagSet) {
+	for _, key := range configKeys() {
+		key := key
+		flags.Func(strings.ToLower(strings.ReplaceAll(key, "_", "-")), "overrides "+key, func(value string) error {
+			flagValues[key] = value
+			return nil
+		})
+	}
+}
+
+// LoadAppConfig reads the config from, in order of precedence:
+// command line flags, environment variables (including the .env file and <NAME>_FILE of secrets), the CONFIG_FILE, the APP_ENV profile and defaults.
+// References to secrets in Vault or AWS Secrets Manager are resolved last.
+func LoadAppConfig() (*AppConfig, error) {
+	godotenv.Load(".env")
+	appConfig := &AppConfig{}
+	err := envconfig.Process("", appConfig)
+	if err != nil {
+		return nil, err
+	}
+	err = readSecretFiles(appConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	configFile := appConfig.ConfigFile
+	if flagConfigFile, ok := flagValues["CONFIG_FILE"]; ok {
+		configFile = flagConfigFile
+	}
+	fileValues := map[string]string{}
+	if configFile != "" {
+		fileValues, err = readConfigFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	appEnv := appConfig.AppEnv
+	if _, ok := os.LookupEnv("APP_ENV"); !ok {
+		appEnv = fileValues["APP_ENV"]
+	}
+	if flagAppEnv, ok := flagValues["APP_ENV"]; ok {
+		appEnv = flagAppEnv
+	}
+	for key, value := range profiles[appEnv] {
+		if envIsSet(key) {
+			continue
+		}
+		if _, ok := fileValues[key]; ok {
+			continue
+		}
+		err = setConfigValue(appConfig, key, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for key, value := range fileValues {
+		if envIsSet(key) {
+			continue
+		}
+		err = setConfigValue(appConfig, key, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", configFile, err)
+		}
+	}
+
+	for key, value := range flagValues {
+		err = setConfigValue(appConfig, key, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = resolveSecrets(appConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	appConfig.applyAllInOne()
+	return appConfig, nil
+}
+
+// readConfigFile reads a flat YAML or TOML file. Keys are the environment variable names,
+// in either case and with dashes or underscores (e.g. LOG_LEVEL or log-level)
+func readConfigFile(path string) (map[string]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &raw)
+	case ".toml":
+		err = toml.Unmarshal(content, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	values := map[string]string{}
+	for key, value := range raw {
+		values[strings.ToUpper(strings.ReplaceAll(key, "-", "_"))] = fmt.Sprint(value)
+	}
+	return values, nil
+}
+
+func configKeys() []string {
+	configType := reflect.TypeOf(AppConfig{})
+	keys := make([]string, 0, configType.NumField())
+	for i := 0; i < configType.NumField(); i++ {
+		if key := configType.Field(i).Tag.Get("envconfig"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func configField(appConfig *AppConfig, key string) (reflect.Value, bool) {
+	configValue := reflect.ValueOf(appConfig).Elem()
+	for i := 0; i < configValue.NumField(); i++ {
+		if configValue.Type().Field(i).Tag.Get("envconfig") == key {
+			return configValue.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setConfigValue(appConfig *AppConfig, key string, value string) error {
+	field, ok := configField(appConfig, key)
+	if !ok {
+		return fmt.Errorf("unknown config option %s", key)
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		intValue, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid number %q", key, value)
+		}
+		field.SetInt(int64(intValue))
+	case reflect.Bool:
+		boolValue, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%s: invalid boolean %q", key, value)
+		}
+		field.SetBool(boolValue)
+	default:
+		return fmt.Errorf("%s: unsupported config type %s", key, field.Kind())
+	}
+	return nil
+}
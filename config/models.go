@@ -1,5 +1,20 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+)
+
 const (
 	LNDBackendType        = "LND"
 	GreenlightBackendType = "GREENLIGHT"
@@ -7,46 +22,530 @@ const (
 	BreezBackendType      = "BREEZ"
 	PhoenixBackendType    = "PHOENIX"
 	CashuBackendType      = "CASHU"
+	BTCPayBackendType     = "BTCPAY"
+	NWCBackendType        = "NWC"
 )
 
+// backends that keep the state of the node in the workdir, so they cannot be shared by replicas of the hub
+var workdirBackendTypes = []string{LDKBackendType, GreenlightBackendType, BreezBackendType, CashuBackendType}
+
+// SupportsReplicas returns true if multiple replicas of the hub can connect to the same node of the backend
+func SupportsReplicas(backendType string) bool {
+	return !slices.Contains(workdirBackendTypes, backendType)
+}
+
 const (
 	OnchainAddressKey = "OnchainAddress"
 )
 
+// event types that can be sent to the notification channels (e.g. Telegram)
+const (
+	NotificationPaymentReceived = "payment_received"
+	NotificationPaymentSent     = "payment_sent"
+	NotificationPaymentFailed   = "payment_failed"
+	NotificationBudgetExceeded  = "budget_exceeded"
+	NotificationBudgetWarning   = "budget_warning"
+	// sent for the alert rules, independent of NOTIFICATION_EVENTS
+	NotificationAlertRule = "alert_rule"
+)
+
+var currencyCodeRegex = regexp.MustCompile(`^[a-zA-Z]{3}$`)
+
+var hexColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+var NotificationEventTypes = []string{NotificationPaymentReceived, NotificationPaymentSent, NotificationPaymentFailed, NotificationBudgetExceeded, NotificationBudgetWarning}
+
+const (
+	NestedWebhookFormat = "nested"
+	FlatWebhookFormat   = "flat"
+)
+
+const (
+	DBKeyStoreType       = "db"
+	KeychainKeyStoreType = "keychain"
+)
+
 type AppConfig struct {
-	Relay                 string `envconfig:"RELAY" default:"wss://relay.getalby.com/v1"`
-	LNBackendType         string `envconfig:"LN_BACKEND_TYPE"`
-	LNDAddress            string `envconfig:"LND_ADDRESS"`
-	LNDCertFile           string `envconfig:"LND_CERT_FILE"`
-	LNDMacaroonFile       string `envconfig:"LND_MACAROON_FILE"`
-	Workdir               string `envconfig:"WORK_DIR"`
-	Port                  string `envconfig:"PORT" default:"8080"`
-	DatabaseUri           string `envconfig:"DATABASE_URI" default:"nwc.db"`
-	CookieSecret          string `envconfig:"COOKIE_SECRET"`
-	LogLevel              string `envconfig:"LOG_LEVEL"`
-	LDKNetwork            string `envconfig:"LDK_NETWORK" default:"bitcoin"`
-	LDKEsploraServer      string `envconfig:"LDK_ESPLORA_SERVER" default:"https://electrs.getalbypro.com"` // TODO: remove LDK prefix
-	LDKGossipSource       string `envconfig:"LDK_GOSSIP_SOURCE"`
-	LDKLogLevel           string `envconfig:"LDK_LOG_LEVEL"`
-	MempoolApi            string `envconfig:"MEMPOOL_API" default:"https://mempool.space/api"`
-	AlbyAPIURL            string `envconfig:"ALBY_API_URL" default:"https://api.getalby.com"`
-	AlbyClientId          string `envconfig:"ALBY_OAUTH_CLIENT_ID" default:"J2PbXS1yOf"`
-	AlbyClientSecret      string `envconfig:"ALBY_OAUTH_CLIENT_SECRET" default:"rABK2n16IWjLTZ9M1uKU"`
-	AlbyOAuthAuthUrl      string `envconfig:"ALBY_OAUTH_AUTH_URL" default:"https://getalby.com/oauth"`
-	BaseUrl               string `envconfig:"BASE_URL" default:"http://localhost:8080"`
-	FrontendUrl           string `envconfig:"FRONTEND_URL"`
-	LogEvents             bool   `envconfig:"LOG_EVENTS" default:"true"`
-	AutoLinkAlbyAccount   bool   `envconfig:"AUTO_LINK_ALBY_ACCOUNT" default:"true"`
-	PhoenixdAddress       string `envconfig:"PHOENIXD_ADDRESS"`
-	PhoenixdAuthorization string `envconfig:"PHOENIXD_AUTHORIZATION"`
-	GoProfilerAddr        string `envconfig:"GO_PROFILER_ADDR"`
-	DdProfilerEnabled     bool   `envconfig:"DD_PROFILER_ENABLED" default:"false"`
+	Relay                    string `envconfig:"RELAY" default:"wss://relay.getalby.com/v1"`
+	LNBackendType            string `envconfig:"LN_BACKEND_TYPE"`
+	LNDAddress               string `envconfig:"LND_ADDRESS"`
+	LNDCertFile              string `envconfig:"LND_CERT_FILE"`
+	LNDMacaroonFile          string `envconfig:"LND_MACAROON_FILE"`
+	LNDMacaroonHex           string `envconfig:"LND_MACAROON_HEX"`
+	Workdir                  string `envconfig:"WORK_DIR"`
+	Port                     string `envconfig:"PORT" default:"8080"`
+	DatabaseUri              string `envconfig:"DATABASE_URI" default:"nwc.db"`
+	CookieSecret             string `envconfig:"COOKIE_SECRET"`
+	CookieSecure             bool   `envconfig:"COOKIE_SECURE" default:"false"`
+	LogLevel                 string `envconfig:"LOG_LEVEL"`
+	LogLevelHTTP             string `envconfig:"LOG_LEVEL_HTTP"`
+	LogLevelNostr            string `envconfig:"LOG_LEVEL_NOSTR"`
+	LogLevelLNClient         string `envconfig:"LOG_LEVEL_LNCLIENT"`
+	LogLevelDB               string `envconfig:"LOG_LEVEL_DB"`
+	LogFilePath              string `envconfig:"LOG_FILE_PATH"`
+	LogFileMaxSizeMB         int    `envconfig:"LOG_FILE_MAX_SIZE_MB" default:"100"`
+	LogFileMaxAgeDays        int    `envconfig:"LOG_FILE_MAX_AGE_DAYS" default:"3"`
+	LogFileMaxBackups        int    `envconfig:"LOG_FILE_MAX_BACKUPS" default:"3"`
+	LogFileCompress          bool   `envconfig:"LOG_FILE_COMPRESS" default:"false"`
+	LDKNetwork               string `envconfig:"LDK_NETWORK" default:"bitcoin"`
+	LDKEsploraServer         string `envconfig:"LDK_ESPLORA_SERVER" default:"https://electrs.getalbypro.com"` // TODO: remove LDK prefix
+	LDKGossipSource          string `envconfig:"LDK_GOSSIP_SOURCE"`
+	LDKLogLevel              string `envconfig:"LDK_LOG_LEVEL"`
+	MempoolApi               string `envconfig:"MEMPOOL_API" default:"https://mempool.space/api"`
+	BoltzApi                 string `envconfig:"BOLTZ_API" default:"https://api.boltz.exchange/v2"`
+	AlbyAPIURL               string `envconfig:"ALBY_API_URL" default:"https://api.getalby.com"`
+	AlbyClientId             string `envconfig:"ALBY_OAUTH_CLIENT_ID" default:"J2PbXS1yOf"`
+	AlbyClientSecret         string `envconfig:"ALBY_OAUTH_CLIENT_SECRET" default:"rABK2n16IWjLTZ9M1uKU"`
+	AlbyOAuthAuthUrl         string `envconfig:"ALBY_OAUTH_AUTH_URL" default:"https://getalby.com/oauth"`
+	BaseUrl                  string `envconfig:"BASE_URL" default:"http://localhost:8080"`
+	FrontendUrl              string `envconfig:"FRONTEND_URL"`
+	LogEvents                bool   `envconfig:"LOG_EVENTS" default:"true"`
+	AutoLinkAlbyAccount      bool   `envconfig:"AUTO_LINK_ALBY_ACCOUNT" default:"true"`
+	PhoenixdAddress          string `envconfig:"PHOENIXD_ADDRESS"`
+	PhoenixdAuthorization    string `envconfig:"PHOENIXD_AUTHORIZATION"`
+	BTCPayUrl                string `envconfig:"BTCPAY_URL"`
+	BTCPayApiKey             string `envconfig:"BTCPAY_API_KEY"`
+	BTCPayStoreId            string `envconfig:"BTCPAY_STORE_ID"`
+	NWCConnectionUri         string `envconfig:"NWC_CONNECTION_URI"`
+	GoProfilerAddr           string `envconfig:"GO_PROFILER_ADDR"`
+	DdProfilerEnabled        bool   `envconfig:"DD_PROFILER_ENABLED" default:"false"`
+	LightningAddressUsername string `envconfig:"LIGHTNING_ADDRESS_USERNAME"`
+	BlockDuplicatePayments   bool   `envconfig:"BLOCK_DUPLICATE_PAYMENTS" default:"false"`
+	ConfigFile               string `envconfig:"CONFIG_FILE"`
+	AppEnv                   string `envconfig:"APP_ENV"`
+	VaultAddr                string `envconfig:"VAULT_ADDR"`
+	VaultToken               string `envconfig:"VAULT_TOKEN"`
+	AWSRegion                string `envconfig:"AWS_REGION"`
+	AWSAccessKeyId           string `envconfig:"AWS_ACCESS_KEY_ID"`
+	AWSSecretAccessKey       string `envconfig:"AWS_SECRET_ACCESS_KEY"`
+	AWSSessionToken          string `envconfig:"AWS_SESSION_TOKEN"`
+	AWSEndpointUrl           string `envconfig:"AWS_ENDPOINT_URL"`
+	SecretsRefreshMins       int    `envconfig:"SECRETS_REFRESH_MINS" default:"60"`
+	SentryDSN                string `envconfig:"SENTRY_DSN"`
+	FeatureFlags             string `envconfig:"FEATURE_FLAGS"`
+	AutoUnlockPassword       string `envconfig:"AUTO_UNLOCK_PASSWORD"`
+	KeyStore                 string `envconfig:"KEY_STORE" default:"db"`
+	AdminIPAllowlist         string `envconfig:"ADMIN_IP_ALLOWLIST"`
+	TrustedProxies           string `envconfig:"TRUSTED_PROXIES"`
+	SessionIdleTimeoutMins   int    `envconfig:"SESSION_IDLE_TIMEOUT_MINS" default:"60"`
+	SessionMaxAgeHours       int    `envconfig:"SESSION_MAX_AGE_HOURS" default:"168"`
+	Nip47RequestMaxAgeSecs   int    `envconfig:"NIP47_REQUEST_MAX_AGE_SECS" default:"600"`
+	Nip47ClockSkewSecs       int    `envconfig:"NIP47_CLOCK_SKEW_SECS" default:"120"`
+	ConfirmPaymentsAboveSat  int    `envconfig:"PAYMENT_CONFIRMATION_THRESHOLD_SAT" default:"0"`
+	ConfirmationTimeoutSecs  int    `envconfig:"PAYMENT_CONFIRMATION_TIMEOUT_SECS" default:"120"`
+	KeyRotationGraceDays     int    `envconfig:"KEY_ROTATION_GRACE_DAYS" default:"30"`
+	Nip47Workers             int    `envconfig:"NIP47_WORKERS" default:"10"`
+	Nip47QueueSize           int    `envconfig:"NIP47_QUEUE_SIZE" default:"1000"`
+	Nip47QueueSizePerApp     int    `envconfig:"NIP47_QUEUE_SIZE_PER_APP" default:"100"`
+	Nip47ResponseQueueSize   int    `envconfig:"NIP47_RESPONSE_QUEUE_SIZE" default:"100"`
+	Nip47SlowRequestMs       int    `envconfig:"NIP47_SLOW_REQUEST_MS" default:"5000"`
+	Nip47MultiPayMaxPayments int    `envconfig:"NIP47_MULTI_PAY_MAX_PAYMENTS" default:"100"`
+	Nip47MultiPayMaxTotalSat int    `envconfig:"NIP47_MULTI_PAY_MAX_TOTAL_SAT" default:"0"`
+	Nip47RateLimitPerMin     int    `envconfig:"NIP47_RATE_LIMIT_PER_MIN" default:"0"`
+	MetricsAddr              string `envconfig:"METRICS_ADDR"`
+	SingleUser               bool   `envconfig:"SINGLE_USER" default:"false"`
+	AllInOne                 bool   `envconfig:"ALL_IN_ONE" default:"false"`
+	LeaderElection           bool   `envconfig:"LEADER_ELECTION" default:"false"`
+	ReplicaId                string `envconfig:"REPLICA_ID"`
+	LeaderLeaseSecs          int    `envconfig:"LEADER_LEASE_SECS" default:"15"`
+	NotificationEvents       string `envconfig:"NOTIFICATION_EVENTS" default:"payment_received,payment_sent,payment_failed,budget_exceeded,budget_warning"`
+	AlertRules               string `envconfig:"ALERT_RULES"`
+	TelegramBotToken         string `envconfig:"TELEGRAM_BOT_TOKEN"`
+	TelegramChatId           string `envconfig:"TELEGRAM_CHAT_ID"`
+	MatrixHomeserverUrl      string `envconfig:"MATRIX_HOMESERVER_URL"`
+	MatrixAccessToken        string `envconfig:"MATRIX_ACCESS_TOKEN"`
+	MatrixRoomId             string `envconfig:"MATRIX_ROOM_ID"`
+	DiscordWebhookUrl        string `envconfig:"DISCORD_WEBHOOK_URL"`
+	SlackWebhookUrl          string `envconfig:"SLACK_WEBHOOK_URL"`
+	WebhookUrl               string `envconfig:"WEBHOOK_URL"`
+	WebhookFormat            string `envconfig:"WEBHOOK_FORMAT" default:"nested"`
+	SmtpHost                 string `envconfig:"SMTP_HOST"`
+	SmtpPort                 int    `envconfig:"SMTP_PORT" default:"587"`
+	SmtpUsername             string `envconfig:"SMTP_USERNAME"`
+	SmtpPassword             string `envconfig:"SMTP_PASSWORD"`
+	SmtpFrom                 string `envconfig:"SMTP_FROM"`
+	ReceiptEmail             string `envconfig:"RECEIPT_EMAIL"`
+	ReceiptMinAmountSat      int    `envconfig:"RECEIPT_MIN_AMOUNT_SAT" default:"0"`
+	ReceiptCurrency          string `envconfig:"RECEIPT_CURRENCY"`
+	ReportEmail              string `envconfig:"REPORT_EMAIL"`
+	ReportCurrency           string `envconfig:"REPORT_CURRENCY" default:"usd"`
+	ChannelBackupDir         string `envconfig:"CHANNEL_BACKUP_DIR"`
+	ChannelBackupUrl         string `envconfig:"CHANNEL_BACKUP_URL"`
+	DumpDir                  string `envconfig:"DUMP_DIR"`
+	DumpS3Url                string `envconfig:"DUMP_S3_URL"`
+	DumpS3Region             string `envconfig:"DUMP_S3_REGION" default:"us-east-1"`
+	DumpS3AccessKeyId        string `envconfig:"DUMP_S3_ACCESS_KEY_ID"`
+	DumpS3SecretAccessKey    string `envconfig:"DUMP_S3_SECRET_ACCESS_KEY"`
+	DumpIntervalMins         int    `envconfig:"DUMP_INTERVAL_MINS" default:"60"`
+	BrandName                string `envconfig:"BRAND_NAME" default:"Alby Hub"`
+	BrandLogoUrl             string `envconfig:"BRAND_LOGO_URL"`
+	BrandPrimaryColor        string `envconfig:"BRAND_PRIMARY_COLOR"`
+	BrandSupportUrl          string `envconfig:"BRAND_SUPPORT_URL"`
+
+	// the secrets that were read from files or secret managers
+	externalSecrets []string
+}
+
+// Branding is shown in the frontend, error pages, emails and notifications of
+// hubs that companies or communities run for their members
+type Branding struct {
+	Name string
+	// the default logo is used if empty
+	LogoUrl string
+	// a hex color like #ff9900, the color of the theme is used if empty
+	PrimaryColor string
+	// the Alby support is used if empty
+	SupportUrl string
+}
+
+func (c *AppConfig) GetBranding() Branding {
+	name := strings.TrimSpace(c.BrandName)
+	if name == "" {
+		name = "Alby Hub"
+	}
+	return Branding{
+		Name:         name,
+		LogoUrl:      c.BrandLogoUrl,
+		PrimaryColor: c.BrandPrimaryColor,
+		SupportUrl:   c.BrandSupportUrl,
+	}
+}
+
+// GetNotificationEvents returns the event types that are sent to the notification channels, all of them if none are set
+func (c *AppConfig) GetNotificationEvents() []string {
+	if strings.TrimSpace(c.NotificationEvents) == "" {
+		return NotificationEventTypes
+	}
+	notificationEvents := []string{}
+	for _, notificationEvent := range strings.Split(c.NotificationEvents, ",") {
+		notificationEvent = strings.TrimSpace(notificationEvent)
+		if notificationEvent != "" {
+			notificationEvents = append(notificationEvents, notificationEvent)
+		}
+	}
+	return notificationEvents
+}
+
+// the path of the relay that the hub serves in all-in-one mode
+const EmbeddedRelayPath = "/relay"
+
+// EmbeddedRelayUrl is the url of the relay of the all-in-one mode for apps, on the BASE_URL of the hub
+func (c *AppConfig) EmbeddedRelayUrl() string {
+	baseUrl, err := url.Parse(c.BaseUrl)
+	if err != nil {
+		return ""
+	}
+	switch baseUrl.Scheme {
+	case "http":
+		baseUrl.Scheme = "ws"
+	case "https":
+		baseUrl.Scheme = "wss"
+	default:
+		return ""
+	}
+	return baseUrl.JoinPath(EmbeddedRelayPath).String()
+}
+
+// LocalEmbeddedRelayUrl is the url the hub connects to its own relay with, without going through a proxy
+func (c *AppConfig) LocalEmbeddedRelayUrl() string {
+	return "ws://127.0.0.1:" + c.Port + EmbeddedRelayPath
+}
+
+// applyAllInOne makes the hub independent of other services in all-in-one mode: it serves its own relay,
+// runs the embedded LDK node by default and does not use an Alby account
+func (c *AppConfig) applyAllInOne() {
+	if !c.AllInOne {
+		return
+	}
+	c.Relay = c.EmbeddedRelayUrl()
+	c.SingleUser = true
+	if c.LNBackendType == "" {
+		c.LNBackendType = LDKBackendType
+	}
 }
 
 func (c *AppConfig) IsDefaultClientId() bool {
 	return c.AlbyClientId == "J2PbXS1yOf"
 }
 
+// ComponentLogLevels returns the log level overrides per logger component
+func (c *AppConfig) ComponentLogLevels() map[string]string {
+	return map[string]string{
+		logger.ComponentHTTP:     c.LogLevelHTTP,
+		logger.ComponentNostr:    c.LogLevelNostr,
+		logger.ComponentLNClient: c.LogLevelLNClient,
+		logger.ComponentDB:       c.LogLevelDB,
+	}
+}
+
+// Validate checks the environment config for values the hub would fail on at runtime
+func (c *AppConfig) Validate() error {
+	var errs []error
+
+	backendTypes := []string{LNDBackendType, GreenlightBackendType, LDKBackendType, BreezBackendType, PhoenixBackendType, CashuBackendType, BTCPayBackendType, NWCBackendType}
+	if c.LNBackendType != "" && !slices.Contains(backendTypes, c.LNBackendType) {
+		errs = append(errs, fmt.Errorf("LN_BACKEND_TYPE: unknown backend type %q", c.LNBackendType))
+	}
+	if c.LNBackendType == LNDBackendType && (c.LNDAddress == "" || (c.LNDMacaroonFile == "" && c.LNDMacaroonHex == "")) {
+		errs = append(errs, errors.New("LND_ADDRESS and LND_MACAROON_FILE or LND_MACAROON_HEX are required for the LND backend"))
+	}
+	if c.LNBackendType == PhoenixBackendType && c.PhoenixdAddress == "" {
+		errs = append(errs, errors.New("PHOENIXD_ADDRESS is required for the PHOENIX backend"))
+	}
+	if c.LNBackendType == BTCPayBackendType && (c.BTCPayUrl == "" || c.BTCPayApiKey == "" || c.BTCPayStoreId == "") {
+		errs = append(errs, errors.New("BTCPAY_URL, BTCPAY_API_KEY and BTCPAY_STORE_ID are required for the BTCPAY backend"))
+	}
+	if c.LNBackendType == NWCBackendType && c.NWCConnectionUri == "" {
+		errs = append(errs, errors.New("NWC_CONNECTION_URI is required for the NWC backend"))
+	}
+
+	if c.SecretsRefreshMins < 0 {
+		errs = append(errs, fmt.Errorf("SECRETS_REFRESH_MINS: cannot be negative, got %d", c.SecretsRefreshMins))
+	}
+
+	if _, ok := profiles[c.AppEnv]; c.AppEnv != "" && !ok {
+		errs = append(errs, fmt.Errorf("APP_ENV: unknown profile %q, must be one of dev, staging or production", c.AppEnv))
+	}
+
+	if !slices.Contains([]string{"bitcoin", "testnet", "signet", "regtest"}, c.LDKNetwork) {
+		errs = append(errs, fmt.Errorf("LDK_NETWORK: unknown network %q", c.LDKNetwork))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port <= 0 || port > 65535 {
+		errs = append(errs, fmt.Errorf("PORT: invalid port %q", c.Port))
+	}
+
+	logLevels := []struct {
+		name  string
+		value string
+	}{
+		{"LOG_LEVEL", c.LogLevel},
+		{"LOG_LEVEL_HTTP", c.LogLevelHTTP},
+		{"LOG_LEVEL_NOSTR", c.LogLevelNostr},
+		{"LOG_LEVEL_LNCLIENT", c.LogLevelLNClient},
+		{"LOG_LEVEL_DB", c.LogLevelDB},
+	}
+	for _, logLevel := range logLevels {
+		if logLevel.value == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(logLevel.value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: must be a number, got %q", logLevel.name, logLevel.value))
+		}
+	}
+
+	if c.LogFileMaxSizeMB <= 0 {
+		errs = append(errs, fmt.Errorf("LOG_FILE_MAX_SIZE_MB: must be positive, got %d", c.LogFileMaxSizeMB))
+	}
+	if c.LogFileMaxAgeDays < 0 || c.LogFileMaxBackups < 0 {
+		errs = append(errs, errors.New("LOG_FILE_MAX_AGE_DAYS and LOG_FILE_MAX_BACKUPS cannot be negative"))
+	}
+
+	if c.AllInOne && c.EmbeddedRelayUrl() == "" {
+		errs = append(errs, fmt.Errorf("ALL_IN_ONE: BASE_URL must be a http:// or https:// url, got %q", c.BaseUrl))
+	}
+	if c.LeaderElection {
+		if !db.IsPostgresUri(c.DatabaseUri) {
+			errs = append(errs, errors.New("LEADER_ELECTION: DATABASE_URI must be a postgres:// connection string shared by all replicas"))
+		}
+		if c.AllInOne {
+			errs = append(errs, errors.New("LEADER_ELECTION and ALL_IN_ONE cannot be used together"))
+		}
+		if c.LNBackendType != "" && !SupportsReplicas(c.LNBackendType) {
+			errs = append(errs, fmt.Errorf("LEADER_ELECTION: the %s backend keeps its state in the workdir and cannot be shared by replicas", c.LNBackendType))
+		}
+		if c.LeaderLeaseSecs < 3 {
+			errs = append(errs, fmt.Errorf("LEADER_LEASE_SECS: must be at least 3, got %d", c.LeaderLeaseSecs))
+		}
+	}
+	if relayUrl, err := url.Parse(c.Relay); err != nil || (relayUrl.Scheme != "ws" && relayUrl.Scheme != "wss") {
+		errs = append(errs, fmt.Errorf("RELAY: must be a ws:// or wss:// url, got %q", c.Relay))
+	}
+
+	urls := []struct {
+		name     string
+		value    string
+		optional bool
+	}{
+		{"BASE_URL", c.BaseUrl, false},
+		{"ALBY_API_URL", c.AlbyAPIURL, false},
+		{"MEMPOOL_API", c.MempoolApi, false},
+		{"BOLTZ_API", c.BoltzApi, false},
+		{"DISCORD_WEBHOOK_URL", c.DiscordWebhookUrl, true},
+		{"SLACK_WEBHOOK_URL", c.SlackWebhookUrl, true},
+		{"WEBHOOK_URL", c.WebhookUrl, true},
+		{"BTCPAY_URL", c.BTCPayUrl, true},
+		{"CHANNEL_BACKUP_URL", c.ChannelBackupUrl, true},
+		{"DUMP_S3_URL", c.DumpS3Url, true},
+	}
+	for _, u := range urls {
+		if u.optional && u.value == "" {
+			continue
+		}
+		if parsedUrl, err := url.Parse(u.value); err != nil || parsedUrl.Scheme == "" || parsedUrl.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: invalid url %q", u.name, u.value))
+		}
+	}
+
+	if !slices.Contains([]string{DBKeyStoreType, KeychainKeyStoreType}, c.KeyStore) {
+		errs = append(errs, fmt.Errorf("KEY_STORE: unknown key store %q", c.KeyStore))
+	}
+
+	if c.SessionIdleTimeoutMins <= 0 || c.SessionMaxAgeHours <= 0 {
+		errs = append(errs, errors.New("SESSION_IDLE_TIMEOUT_MINS and SESSION_MAX_AGE_HOURS must be positive"))
+	}
+
+	if c.Nip47RequestMaxAgeSecs < 0 {
+		errs = append(errs, fmt.Errorf("NIP47_REQUEST_MAX_AGE_SECS: cannot be negative, got %d", c.Nip47RequestMaxAgeSecs))
+	}
+
+	if c.Nip47ClockSkewSecs < 0 {
+		errs = append(errs, fmt.Errorf("NIP47_CLOCK_SKEW_SECS: cannot be negative, got %d", c.Nip47ClockSkewSecs))
+	}
+
+	if c.ConfirmPaymentsAboveSat < 0 {
+		errs = append(errs, fmt.Errorf("PAYMENT_CONFIRMATION_THRESHOLD_SAT: cannot be negative, got %d", c.ConfirmPaymentsAboveSat))
+	}
+	if c.ConfirmationTimeoutSecs <= 0 {
+		errs = append(errs, fmt.Errorf("PAYMENT_CONFIRMATION_TIMEOUT_SECS: must be positive, got %d", c.ConfirmationTimeoutSecs))
+	}
+	if c.KeyRotationGraceDays <= 0 {
+		errs = append(errs, fmt.Errorf("KEY_ROTATION_GRACE_DAYS: must be positive, got %d", c.KeyRotationGraceDays))
+	}
+
+	if c.Nip47Workers <= 0 || c.Nip47QueueSize <= 0 || c.Nip47QueueSizePerApp <= 0 || c.Nip47ResponseQueueSize <= 0 {
+		errs = append(errs, errors.New("NIP47_WORKERS, NIP47_QUEUE_SIZE, NIP47_QUEUE_SIZE_PER_APP and NIP47_RESPONSE_QUEUE_SIZE must be positive"))
+	}
+	if c.Nip47MultiPayMaxPayments < 0 || c.Nip47MultiPayMaxTotalSat < 0 {
+		errs = append(errs, errors.New("NIP47_MULTI_PAY_MAX_PAYMENTS and NIP47_MULTI_PAY_MAX_TOTAL_SAT cannot be negative"))
+	}
+	if c.Nip47RateLimitPerMin < 0 {
+		errs = append(errs, fmt.Errorf("NIP47_RATE_LIMIT_PER_MIN: cannot be negative, got %d", c.Nip47RateLimitPerMin))
+	}
+
+	for _, notificationEvent := range c.GetNotificationEvents() {
+		if !slices.Contains(NotificationEventTypes, notificationEvent) {
+			errs = append(errs, fmt.Errorf("NOTIFICATION_EVENTS: unknown event type %q", notificationEvent))
+		}
+	}
+	if _, err := ParseAlertRules(c.AlertRules); err != nil {
+		errs = append(errs, fmt.Errorf("ALERT_RULES: %w", err))
+	}
+	if c.ReceiptEmail != "" {
+		if c.SmtpHost == "" || c.SmtpFrom == "" {
+			errs = append(errs, errors.New("SMTP_HOST and SMTP_FROM are required for RECEIPT_EMAIL"))
+		}
+		if c.SmtpPort <= 0 || c.SmtpPort > 65535 {
+			errs = append(errs, fmt.Errorf("SMTP_PORT: invalid port %d", c.SmtpPort))
+		}
+		if _, err := mail.ParseAddress(c.ReceiptEmail); err != nil {
+			errs = append(errs, fmt.Errorf("RECEIPT_EMAIL: invalid address %q", c.ReceiptEmail))
+		}
+		if _, err := mail.ParseAddress(c.SmtpFrom); c.SmtpFrom != "" && err != nil {
+			errs = append(errs, fmt.Errorf("SMTP_FROM: invalid address %q", c.SmtpFrom))
+		}
+	}
+	if c.ReportEmail != "" {
+		if c.SmtpHost == "" || c.SmtpFrom == "" {
+			errs = append(errs, errors.New("SMTP_HOST and SMTP_FROM are required for REPORT_EMAIL"))
+		}
+		if _, err := mail.ParseAddress(c.ReportEmail); err != nil {
+			errs = append(errs, fmt.Errorf("REPORT_EMAIL: invalid address %q", c.ReportEmail))
+		}
+	}
+	if c.ReportCurrency != "" && !currencyCodeRegex.MatchString(c.ReportCurrency) {
+		errs = append(errs, fmt.Errorf("REPORT_CURRENCY: invalid currency code %q", c.ReportCurrency))
+	}
+	if c.ReceiptCurrency != "" && !currencyCodeRegex.MatchString(c.ReceiptCurrency) {
+		errs = append(errs, fmt.Errorf("RECEIPT_CURRENCY: invalid currency code %q", c.ReceiptCurrency))
+	}
+	if c.ReceiptMinAmountSat < 0 {
+		errs = append(errs, fmt.Errorf("RECEIPT_MIN_AMOUNT_SAT: cannot be negative, got %d", c.ReceiptMinAmountSat))
+	}
+	if c.WebhookFormat != "" && !slices.Contains([]string{NestedWebhookFormat, FlatWebhookFormat}, c.WebhookFormat) {
+		errs = append(errs, fmt.Errorf("WEBHOOK_FORMAT: unknown format %q", c.WebhookFormat))
+	}
+	if (c.TelegramBotToken == "") != (c.TelegramChatId == "") {
+		errs = append(errs, errors.New("TELEGRAM_BOT_TOKEN and TELEGRAM_CHAT_ID must be set together"))
+	}
+	if c.MatrixHomeserverUrl != "" || c.MatrixAccessToken != "" || c.MatrixRoomId != "" {
+		if c.MatrixHomeserverUrl == "" || c.MatrixAccessToken == "" || c.MatrixRoomId == "" {
+			errs = append(errs, errors.New("MATRIX_HOMESERVER_URL, MATRIX_ACCESS_TOKEN and MATRIX_ROOM_ID must be set together"))
+		} else if parsedUrl, err := url.Parse(c.MatrixHomeserverUrl); err != nil || parsedUrl.Scheme == "" || parsedUrl.Host == "" {
+			errs = append(errs, fmt.Errorf("MATRIX_HOMESERVER_URL: invalid url %q", c.MatrixHomeserverUrl))
+		}
+	}
+
+	if c.DumpS3Url != "" && (c.DumpS3AccessKeyId == "" || c.DumpS3SecretAccessKey == "" || c.DumpS3Region == "") {
+		errs = append(errs, errors.New("DUMP_S3_ACCESS_KEY_ID, DUMP_S3_SECRET_ACCESS_KEY and DUMP_S3_REGION are required for DUMP_S3_URL"))
+	}
+	if c.DumpIntervalMins <= 0 {
+		errs = append(errs, fmt.Errorf("DUMP_INTERVAL_MINS: must be positive, got %d", c.DumpIntervalMins))
+	}
+
+	if c.BrandPrimaryColor != "" && !hexColorRegex.MatchString(c.BrandPrimaryColor) {
+		errs = append(errs, fmt.Errorf("BRAND_PRIMARY_COLOR: invalid hex color %q", c.BrandPrimaryColor))
+	}
+	for _, brandUrl := range []struct {
+		name  string
+		value string
+	}{
+		{"BRAND_LOGO_URL", c.BrandLogoUrl},
+		{"BRAND_SUPPORT_URL", c.BrandSupportUrl},
+	} {
+		if brandUrl.value == "" {
+			continue
+		}
+		// only web urls, as they are used in links and images of the frontend
+		if parsedUrl, err := url.Parse(brandUrl.value); err != nil || (parsedUrl.Scheme != "https" && parsedUrl.Scheme != "http") || parsedUrl.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: invalid url %q", brandUrl.name, brandUrl.value))
+		}
+	}
+
+	if _, err := ParseIPRanges(c.AdminIPAllowlist); err != nil {
+		errs = append(errs, fmt.Errorf("ADMIN_IP_ALLOWLIST: %w", err))
+	}
+	if _, err := ParseIPRanges(c.TrustedProxies); err != nil {
+		errs = append(errs, fmt.Errorf("TRUSTED_PROXIES: %w", err))
+	}
+
+	if err := validateFeatureFlags(c.FeatureFlags); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// ParseIPRanges parses a comma-separated list of CIDRs. Single IPs are also accepted.
+func ParseIPRanges(ipRanges string) ([]*net.IPNet, error) {
+	var parsed []*net.IPNet
+	for _, ipRange := range strings.Split(ipRanges, ",") {
+		ipRange = strings.TrimSpace(ipRange)
+		if ipRange == "" {
+			continue
+		}
+		if !strings.Contains(ipRange, "/") {
+			ip := net.ParseIP(ipRange)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", ipRange)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			parsed = append(parsed, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(ipRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", ipRange)
+		}
+		parsed = append(parsed, ipNet)
+	}
+	return parsed, nil
+}
+
 type Config interface {
 	Get(key string, encryptionKey string) (string, error)
 	SetIgnore(key string, value string, encryptionKey string)
@@ -57,4 +556,12 @@ type Config interface {
 	CheckUnlockPassword(password string) bool
 	ChangeUnlockPassword(currentUnlockPassword string, newUnlockPassword string) error
 	Setup(encryptionKey string)
+	Reload(env *AppConfig)
+	IsFeatureEnabled(feature string) bool
+	SetFeatureEnabled(feature string, enabled bool) error
+	GetFeatures() map[string]bool
+	IsFeatureEnabledForApp(feature string, appId uint) bool
+	SetAppFeatureEnabled(feature string, appId uint, enabled bool) error
+	GetAppFeatures(appId uint) map[string]bool
+	IsBackendEnabled(backendType string) bool
 }
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIPRanges(t *testing.T) {
+	ipRanges, err := ParseIPRanges(" 10.0.0.0/8, 192.168.1.5 ,::1,")
+	assert.NoError(t, err)
+	assert.Len(t, ipRanges, 3)
+	assert.Equal(t, "10.0.0.0/8", ipRanges[0].String())
+	assert.Equal(t, "192.168.1.5/32", ipRanges[1].String())
+	assert.Equal(t, "::1/128", ipRanges[2].String())
+
+	ipRanges, err = ParseIPRanges("")
+	assert.NoError(t, err)
+	assert.Empty(t, ipRanges)
+
+	_, err = ParseIPRanges("10.0.0.0/33")
+	assert.EqualError(t, err, `invalid CIDR "10.0.0.0/33"`)
+	_, err = ParseIPRanges("localhost")
+	assert.EqualError(t, err, `invalid IP "localhost"`)
+}
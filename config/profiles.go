@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+const (
+	DevProfile        = "dev"
+	StagingProfile    = "staging"
+	ProductionProfile = "production"
+)
+
+// defaults of the APP_ENV profiles, keyed by environment variable name.
+// They are overridden by the CONFIG_FILE, environment variables and flags.
+var profiles = map[string]map[string]string{
+	// a local hub that needs no external services and cannot lose real funds
+	DevProfile: {
+		"LOG_LEVEL":          "5",
+		"COOKIE_SECURE":      "false",
+		"ALL_IN_ONE":         "true",
+		"LDK_NETWORK":        "signet",
+		"LDK_ESPLORA_SERVER": "https://mutinynet.com/api",
+		"MEMPOOL_API":        "https://mutinynet.com/api",
+	},
+	StagingProfile: {
+		"LOG_LEVEL":          "4",
+		"COOKIE_SECURE":      "true",
+		"RELAY":              "wss://relay.getalby.com/v1",
+		"LDK_NETWORK":        "signet",
+		"LDK_ESPLORA_SERVER": "https://mutinynet.com/api",
+		"MEMPOOL_API":        "https://mutinynet.com/api",
+	},
+	ProductionProfile: {
+		"LOG_LEVEL":     "4",
+		"COOKIE_SECURE": "true",
+		"RELAY":         "wss://relay.getalby.com/v1",
+		"LDK_NETWORK":   "bitcoin",
+	},
+}
+
+// parts of config keys whose values are not shown in the config summary
+var secretKeyParts = []string{"SECRET", "PASSWORD", "TOKEN", "API_KEY", "AUTHORIZATION", "MACAROON", "DSN", "ACCESS_KEY", "CONNECTION_URI", "WEBHOOK_URL", "CHANNEL_BACKUP_URL"}
+
+// Summary returns the effective value of every config option that is set, with secrets masked
+func (c *AppConfig) Summary() map[string]string {
+	summary := map[string]string{}
+	configValue := reflect.ValueOf(c).Elem()
+	for i := 0; i < configValue.NumField(); i++ {
+		key := configValue.Type().Field(i).Tag.Get("envconfig")
+		if key == "" {
+			continue
+		}
+		value := fmt.Sprint(configValue.Field(i).Interface())
+		if value == "" {
+			continue
+		}
+		summary[key] = summaryValue(key, value)
+	}
+	return summary
+}
+
+func summaryValue(key string, value string) string {
+	for _, secretKeyPart := range secretKeyParts {
+		if strings.Contains(key, secretKeyPart) {
+			return "***"
+		}
+	}
+	// connection strings can contain a password
+	if parsedUrl, err := url.Parse(value); err == nil && parsedUrl.User != nil {
+		return parsedUrl.Redacted()
+	}
+	return value
+}
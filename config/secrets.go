@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/getAlby/hub/secrets"
+)
+
+// options that can be read from the file in <NAME>_FILE (e.g. Docker or Kubernetes secrets)
+// or refer to a secret in Vault or AWS Secrets Manager, see the secrets package
+var secretKeys = []string{
+	"ALBY_OAUTH_CLIENT_SECRET",
+	"AUTO_UNLOCK_PASSWORD",
+	"COOKIE_SECRET",
+	"DATABASE_URI",
+	"LND_MACAROON_HEX",
+	"PHOENIXD_AUTHORIZATION",
+	"BTCPAY_API_KEY",
+	"NWC_CONNECTION_URI",
+	"SMTP_PASSWORD",
+	"TELEGRAM_BOT_TOKEN",
+	"MATRIX_ACCESS_TOKEN",
+	"DUMP_S3_SECRET_ACCESS_KEY",
+	"SENTRY_DSN",
+}
+
+// envIsSet returns true if the option is set by an environment variable, for secrets also by <NAME>_FILE
+func envIsSet(key string) bool {
+	if _, ok := os.LookupEnv(key); ok {
+		return true
+	}
+	if !slices.Contains(secretKeys, key) {
+		return false
+	}
+	_, ok := os.LookupEnv(key + "_FILE")
+	return ok
+}
+
+// readSecretFiles sets the secrets from their <NAME>_FILE, unless the secret itself is set in the environment
+func readSecretFiles(appConfig *AppConfig) error {
+	for _, key := range secretKeys {
+		path, ok := os.LookupEnv(key + "_FILE")
+		if !ok {
+			continue
+		}
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s_FILE: %w", key, err)
+		}
+		err = setConfigValue(appConfig, key, strings.TrimRight(string(content), "\r\n"))
+		if err != nil {
+			return err
+		}
+		appConfig.externalSecrets = append(appConfig.externalSecrets, key)
+	}
+	return nil
+}
+
+// resolveSecrets replaces the references to secrets in Vault or AWS Secrets Manager with the secrets
+func resolveSecrets(appConfig *AppConfig) error {
+	resolver := secrets.NewResolver(secrets.Options{
+		VaultAddr:          appConfig.VaultAddr,
+		VaultToken:         appConfig.VaultToken,
+		AWSRegion:          appConfig.AWSRegion,
+		AWSAccessKeyId:     appConfig.AWSAccessKeyId,
+		AWSSecretAccessKey: appConfig.AWSSecretAccessKey,
+		AWSSessionToken:    appConfig.AWSSessionToken,
+		AWSEndpointUrl:     appConfig.AWSEndpointUrl,
+	})
+	for _, key := range secretKeys {
+		field, _ := configField(appConfig, key)
+		if !secrets.IsReference(field.String()) {
+			continue
+		}
+		value, err := resolver.Resolve(context.Background(), field.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		field.SetString(value)
+		if !slices.Contains(appConfig.externalSecrets, key) {
+			appConfig.externalSecrets = append(appConfig.externalSecrets, key)
+		}
+	}
+	return nil
+}
+
+// HasExternalSecrets returns true if any secret is read from a file or a secret manager,
+// so it can change while the hub is running
+func (c *AppConfig) HasExternalSecrets() bool {
+	return len(c.externalSecrets) > 0
+}
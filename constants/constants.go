@@ -6,9 +6,41 @@ const (
 	TRANSACTION_TYPE_INCOMING = "incoming"
 	TRANSACTION_TYPE_OUTGOING = "outgoing"
 
+	// incoming invoices are pending until paid
 	TRANSACTION_STATE_PENDING = "PENDING"
 	TRANSACTION_STATE_SETTLED = "SETTLED"
 	TRANSACTION_STATE_FAILED  = "FAILED"
+
+	// outgoing payment states, see transactions/state.go
+	TRANSACTION_STATE_CREATED   = "CREATED"
+	TRANSACTION_STATE_RESERVED  = "RESERVED"
+	TRANSACTION_STATE_IN_FLIGHT = "IN_FLIGHT"
+	TRANSACTION_STATE_TIMED_OUT = "TIMED_OUT"
+)
+
+// outgoing payments in these states count towards budgets and isolated app balances
+var OUTGOING_TRANSACTION_RESERVED_STATES = []string{
+	TRANSACTION_STATE_RESERVED,
+	TRANSACTION_STATE_IN_FLIGHT,
+	TRANSACTION_STATE_TIMED_OUT,
+	TRANSACTION_STATE_SETTLED,
+}
+
+const (
+	SWAP_TYPE_IN  = "in"  // on-chain to lightning
+	SWAP_TYPE_OUT = "out" // lightning to on-chain
+
+	SWAP_STATE_PENDING  = "PENDING"
+	SWAP_STATE_SUCCESS  = "SUCCESS"
+	SWAP_STATE_FAILED   = "FAILED"
+	SWAP_STATE_REFUNDED = "REFUNDED"
+)
+
+const (
+	ACTIVITY_TYPE_PAYMENT    = "payment"
+	ACTIVITY_TYPE_APP        = "app"        // app connections
+	ACTIVITY_TYPE_PERMISSION = "permission" // permission changes of apps
+	ACTIVITY_TYPE_FAILURE    = "failure"
 )
 
 const (
@@ -2,16 +2,34 @@ package db
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/getAlby/hub/db/migrations"
 	"github.com/getAlby/hub/logger"
 	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// IsPostgresUri returns true if the DATABASE_URI is the connection string of a Postgres database, rather than a sqlite file
+func IsPostgresUri(uri string) bool {
+	return strings.HasPrefix(uri, "postgres://") || strings.HasPrefix(uri, "postgresql://")
+}
+
+// IsPostgres returns true if the database is a Postgres database, for the few queries that differ from sqlite
+func IsPostgres(gormDB *gorm.DB) bool {
+	return gormDB.Dialector.Name() == "postgres"
+}
+
 func NewDB(uri string) (*gorm.DB, error) {
+	if IsPostgresUri(uri) {
+		return newPostgresDB(uri)
+	}
+
 	// avoid SQLITE_BUSY errors with _txlock=IMMEDIATE
-	gormDB, err := gorm.Open(sqlite.Open(uri+"?_txlock=IMMEDIATE"), &gorm.Config{})
+	gormDB, err := gorm.Open(sqlite.Open(uri+"?_txlock=IMMEDIATE"), &gorm.Config{
+		Logger: &gormLogger{},
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -58,13 +76,74 @@ func NewDB(uri string) (*gorm.DB, error) {
 
 	err = migrations.Migrate(gormDB)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to migrate")
+		logger.DB.WithError(err).Error("Failed to migrate")
+		return nil, err
+	}
+
+	return gormDB, nil
+}
+
+// an arbitrary key of the advisory lock that is held while the migrations run
+const postgresMigrationsLockId = 4242012
+
+// newPostgresDB connects to a Postgres database, which can be shared by multiple replicas of the hub
+func newPostgresDB(uri string) (*gorm.DB, error) {
+	gormDB, err := gorm.Open(postgres.Open(uri), &gorm.Config{
+		Logger: &gormLogger{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// replicas that start at the same time wait for each other, instead of running the same migrations
+	err = gormDB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("SELECT pg_advisory_xact_lock(?)", postgresMigrationsLockId).Error
+		if err != nil {
+			return err
+		}
+		return migrations.Migrate(tx)
+	})
+	if err != nil {
+		logger.DB.WithError(err).Error("Failed to migrate")
 		return nil, err
 	}
 
 	return gormDB, nil
 }
 
+// Ping connects to the database and closes the connection again, without running the migrations
+func Ping(uri string) error {
+	dialector := sqlite.Open(uri)
+	if IsPostgresUri(uri) {
+		dialector = postgres.Open(uri)
+	}
+	gormDB, err := gorm.Open(dialector, &gorm.Config{
+		Logger: &gormLogger{},
+	})
+	if err != nil {
+		return err
+	}
+	defer Stop(gormDB)
+	return gormDB.Exec("SELECT 1").Error
+}
+
+// HasPendingMigrations connects to the database and returns true if this version has migrations that did not run on it yet,
+// without running them
+func HasPendingMigrations(uri string) (bool, error) {
+	dialector := sqlite.Open(uri)
+	if IsPostgresUri(uri) {
+		dialector = postgres.Open(uri)
+	}
+	gormDB, err := gorm.Open(dialector, &gorm.Config{
+		Logger: &gormLogger{},
+	})
+	if err != nil {
+		return false, err
+	}
+	defer Stop(gormDB)
+	return migrations.HasPending(gormDB)
+}
+
 func Stop(db *gorm.DB) error {
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -46,7 +46,7 @@ func (svc *dbService) CreateApp(name string, pubkey string, maxAmountSat uint64,
 		//validate public key
 		decoded, err := hex.DecodeString(pairingPublicKey)
 		if err != nil || len(decoded) != 32 {
-			logger.Logger.WithField("pairingPublicKey", pairingPublicKey).Error("Invalid public key format")
+			logger.DB.WithField("pairingPublicKey", pairingPublicKey).Error("Invalid public key format")
 			return nil, "", fmt.Errorf("invalid public key format: %s", pairingPublicKey)
 		}
 	}
@@ -79,7 +79,7 @@ func (svc *dbService) CreateApp(name string, pubkey string, maxAmountSat uint64,
 	})
 
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to save app")
+		logger.DB.WithError(err).Error("Failed to save app")
 		return nil, "", err
 	}
 
@@ -92,3 +92,28 @@ func (svc *dbService) CreateApp(name string, pubkey string, maxAmountSat uint64,
 
 	return &app, pairingSecretKey, nil
 }
+
+// RegenerateAppSecret replaces the connection secret of the app. Only the public key
+// of the secret is stored, so a lost secret cannot be shown again and has to be replaced.
+func (svc *dbService) RegenerateAppSecret(app *App) (string, error) {
+	pairingSecretKey := nostr.GeneratePrivateKey()
+	pairingPublicKey, err := nostr.GetPublicKey(pairingSecretKey)
+	if err != nil {
+		return "", err
+	}
+
+	err = svc.db.Model(app).Update("nostr_pubkey", pairingPublicKey).Error
+	if err != nil {
+		logger.DB.WithError(err).Error("Failed to regenerate app secret")
+		return "", err
+	}
+
+	svc.eventPublisher.Publish(&events.Event{
+		Event: "app_secret_regenerated",
+		Properties: map[string]interface{}{
+			"name": app.Name,
+		},
+	})
+
+	return pairingSecretKey, nil
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/getAlby/hub/logger"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger writes gorm logs to the db component logger instead of plain stdout.
+// Query parameters are never logged as they can contain preimages and secrets.
+type gormLogger struct{}
+
+func (l *gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	// the level is controlled by the db component log level
+	return l
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if logger.DB != nil {
+		logger.DB.Infof(msg, data...)
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if logger.DB != nil {
+		logger.DB.Warnf(msg, data...)
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if logger.DB != nil {
+		logger.DB.Errorf(msg, data...)
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	// the DB is opened in tests before the logger is initialized
+	if logger.DB == nil {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := elapsed > slowQueryThreshold
+	if !failed && !slow && !logger.DB.IsLevelEnabled(logrus.TraceLevel) {
+		return
+	}
+
+	sql, rows := fc()
+	entry := logger.DB.WithFields(logrus.Fields{
+		"sql":        sql,
+		"rows":       rows,
+		"elapsed_ms": elapsed.Milliseconds(),
+	})
+	switch {
+	case failed:
+		// callers log errors they do not expect (e.g. unique constraints are used for deduplication)
+		entry.WithError(err).Warn("Database query failed")
+	case slow:
+		entry.Warn("Slow database query")
+	default:
+		entry.Trace("Database query")
+	}
+}
+
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -17,7 +17,7 @@ var _202403171120_delete_ldk_payments = &gormigrate.Migration{
 
 		/*ldkDbPath := filepath.Join(appConfig.Workdir, "ldk", "storage", "ldk_node_data.sqlite")
 		if _, err := os.Stat(ldkDbPath); errors.Is(err, os.ErrNotExist) {
-			logger.Logger.Info("No LDK database, skipping migration")
+			logger.DB.Info("No LDK database, skipping migration")
 			return nil
 		}
 		ldkDb, err := sql.Open("sqlite", ldkDbPath)
@@ -32,7 +32,7 @@ var _202403171120_delete_ldk_payments = &gormigrate.Migration{
 		if err != nil {
 			return err
 		}
-		logger.Logger.WithFields(logrus.Fields{
+		logger.DB.WithFields(logrus.Fields{
 			"rowsAffected": rowsAffected,
 		}).Info("Removed incompatible payments from LDK database")
 
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds a lightning address username to apps so that
+// payments to <username>@<hub domain> can be credited to the app
+var _202408061737_lightning_addresses = &gormigrate.Migration{
+	ID: "202408061737_lightning_addresses",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+ALTER TABLE apps ADD COLUMN lightning_address_username TEXT;
+CREATE UNIQUE INDEX idx_apps_lightning_address_username ON apps(lightning_address_username) WHERE lightning_address_username IS NOT NULL AND lightning_address_username != '';
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds a table for single-use LNURL-withdraw links
+// which are paid from the budget of the app that created them
+var _202408071021_lnurl_withdraws = &gormigrate.Migration{
+	ID: "202408071021_lnurl_withdraws",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE lnurl_withdraws(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	app_id integer,
+	k1 text UNIQUE,
+	amount_msat integer,
+	description text,
+	transaction_id integer,
+	expires_at datetime,
+	used_at datetime,
+	created_at datetime,
+	updated_at datetime,
+	CONSTRAINT fk_lnurl_withdraws_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
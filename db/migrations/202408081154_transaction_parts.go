@@ -0,0 +1,34 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds a table to track the individual parts of multi-part payments
+var _202408081154_transaction_parts = &gormigrate.Migration{
+	ID: "202408081154_transaction_parts",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE transaction_parts(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	transaction_id integer,
+	amount_msat integer,
+	fee_msat integer,
+	created_at datetime,
+	CONSTRAINT fk_transaction_parts_transaction FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_transaction_parts_transaction_id ON transaction_parts(transaction_id);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration moves pending outgoing payments to the new explicit payment states.
+// Their outcome is unknown, so they are marked as timed out and keep their fee reserve
+// until the node reports them as settled or failed.
+var _202408091530_payment_states = &gormigrate.Migration{
+	ID: "202408091530_payment_states",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+UPDATE transactions SET state = 'TIMED_OUT' WHERE type = 'outgoing' AND state = 'PENDING';
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
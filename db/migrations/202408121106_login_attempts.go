@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds a table to track failed unlock attempts, so that lockouts survive restarts
+var _202408121106_login_attempts = &gormigrate.Migration{
+	ID: "202408121106_login_attempts",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE login_attempts(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	source text UNIQUE,
+	failed_attempts integer,
+	locked_until datetime,
+	created_at datetime,
+	updated_at datetime
+);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
@@ -0,0 +1,32 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds a table to track web UI sessions on the server
+var _202408121530_sessions = &gormigrate.Migration{
+	ID: "202408121530_sessions",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE sessions(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	token_hash text UNIQUE,
+	last_seen_at datetime,
+	expires_at datetime,
+	created_at datetime
+);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds an index for listing the transactions of an app, newest first.
+//
+// The other hot lookups are already covered:
+// - apps.nostr_pubkey and request_events.nostr_id have UNIQUE constraints, which SQLite backs with an index
+// - transactions.payment_hash has idx_transactions_payment_hash (202407201604_transactions_indexes)
+// (the old payments table was replaced by transactions in 202407012100_transactions)
+var _202408141200_transactions_app_id_created_at_index = &gormigrate.Migration{
+	ID: "202408141200_transactions_app_id_created_at_index",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE INDEX IF NOT EXISTS idx_transactions_app_id_created_at ON transactions(app_id, created_at);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds a table for reusable BOLT12 offers and links
+// the incoming transactions received for an offer to it
+var _202408151200_offers = &gormigrate.Migration{
+	ID: "202408151200_offers",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE offers(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	app_id integer,
+	offer text,
+	bolt12_offer_id text UNIQUE,
+	description text,
+	created_at datetime,
+	updated_at datetime,
+	CONSTRAINT fk_offers_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE SET NULL
+);
+ALTER TABLE transactions ADD COLUMN offer_id integer REFERENCES offers(id) ON DELETE SET NULL;
+CREATE INDEX idx_transactions_offer_id ON transactions(offer_id);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
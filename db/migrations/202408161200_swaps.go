@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds a table for swaps between the lightning and on-chain balance
+var _202408161200_swaps = &gormigrate.Migration{
+	ID: "202408161200_swaps",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE swaps(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	swap_id text UNIQUE,
+	type text,
+	state text,
+	boltz_status text,
+	amount_sat integer,
+	onchain_amount_sat integer,
+	invoice text,
+	payment_hash text,
+	preimage text,
+	private_key text,
+	boltz_public_key text,
+	swap_tree text,
+	lockup_address text,
+	destination_address text,
+	timeout_block_height integer,
+	lockup_tx_id text,
+	claim_tx_id text,
+	refund_tx_id text,
+	auto_swap boolean,
+	created_at datetime,
+	updated_at datetime
+);
+CREATE INDEX idx_swaps_state ON swaps(state);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
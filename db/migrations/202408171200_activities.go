@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds a table for the entries of the activity feed
+var _202408171200_activities = &gormigrate.Migration{
+	ID: "202408171200_activities",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE activities(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	type text,
+	event text,
+	title text,
+	description text,
+	app_name text,
+	amount_sat integer,
+	payment_hash text,
+	created_at datetime
+);
+CREATE INDEX idx_activities_type ON activities(type);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
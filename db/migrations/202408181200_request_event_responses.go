@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration stores the error and the response time of NIP-47 requests,
+// so that they can be inspected on the page of the app
+var _202408181200_request_event_responses = &gormigrate.Migration{
+	ID: "202408181200_request_event_responses",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+ALTER TABLE request_events ADD COLUMN error_code text;
+ALTER TABLE request_events ADD COLUMN error_message text;
+ALTER TABLE request_events ADD COLUMN responded_at datetime;
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
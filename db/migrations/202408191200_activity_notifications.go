@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds the read state of activities that are shown in the notification center
+var _202408191200_activity_notifications = &gormigrate.Migration{
+	ID: "202408191200_activity_notifications",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+ALTER TABLE activities ADD COLUMN notify boolean;
+ALTER TABLE activities ADD COLUMN read_at datetime;
+CREATE INDEX idx_activities_notify_read_at ON activities(notify, read_at);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
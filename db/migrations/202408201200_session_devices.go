@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration stores the browser and IP of web UI sessions, so that they
+// can be recognized when they are listed in the settings
+var _202408201200_session_devices = &gormigrate.Migration{
+	ID: "202408201200_session_devices",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+ALTER TABLE sessions ADD COLUMN user_agent text;
+ALTER TABLE sessions ADD COLUMN ip_address text;
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
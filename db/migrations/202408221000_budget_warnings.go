@@ -0,0 +1,27 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration remembers when a warning was sent that the budget of an app
+// is projected to run out, so that it is sent once per budget period
+var _202408221000_budget_warnings = &gormigrate.Migration{
+	ID: "202408221000_budget_warnings",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+ALTER TABLE app_permissions ADD COLUMN budget_warning_sent_at datetime;
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
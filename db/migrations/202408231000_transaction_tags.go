@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds tags to transactions and the rules that tag new transactions.
+// Tags added by a rule are removed with the rule.
+var _202408231000_transaction_tags = &gormigrate.Migration{
+	ID: "202408231000_transaction_tags",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE tag_rules(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	tag text,
+	app_id integer,
+	type text,
+	keysend boolean,
+	description_contains text,
+	created_at datetime,
+	CONSTRAINT fk_tag_rules_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+CREATE TABLE transaction_tags(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	transaction_id integer,
+	tag text,
+	tag_rule_id integer,
+	created_at datetime,
+	CONSTRAINT fk_transaction_tags_transaction FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+	CONSTRAINT fk_transaction_tags_tag_rule FOREIGN KEY (tag_rule_id) REFERENCES tag_rules(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_transaction_tags_transaction_id_tag ON transaction_tags(transaction_id, tag);
+CREATE INDEX idx_transaction_tags_tag ON transaction_tags(tag);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds a full-text index of the transactions, kept up to date by triggers.
+// The destination is only known for keysend payments, which store it in the metadata.
+var _202408251000_transactions_search = &gormigrate.Migration{
+	ID: "202408251000_transactions_search",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE VIRTUAL TABLE transactions_search USING fts5(
+	description,
+	note,
+	destination,
+	app_name,
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+CREATE TRIGGER transactions_search_insert AFTER INSERT ON transactions BEGIN
+	INSERT INTO transactions_search(rowid, description, note, destination, app_name)
+	VALUES (
+		new.id,
+		new.description,
+		new.note,
+		CASE WHEN json_valid(new.metadata) THEN json_extract(new.metadata, '$.destination') END,
+		(SELECT name FROM apps WHERE id = new.app_id)
+	);
+END;
+CREATE TRIGGER transactions_search_update AFTER UPDATE OF description, note, metadata, app_id ON transactions BEGIN
+	DELETE FROM transactions_search WHERE rowid = old.id;
+	INSERT INTO transactions_search(rowid, description, note, destination, app_name)
+	VALUES (
+		new.id,
+		new.description,
+		new.note,
+		CASE WHEN json_valid(new.metadata) THEN json_extract(new.metadata, '$.destination') END,
+		(SELECT name FROM apps WHERE id = new.app_id)
+	);
+END;
+CREATE TRIGGER transactions_search_delete AFTER DELETE ON transactions BEGIN
+	DELETE FROM transactions_search WHERE rowid = old.id;
+END;
+CREATE TRIGGER transactions_search_app_name AFTER UPDATE OF name ON apps BEGIN
+	UPDATE transactions_search SET app_name = new.name
+	WHERE rowid IN (SELECT id FROM transactions WHERE app_id = new.id);
+END;
+INSERT INTO transactions_search(rowid, description, note, destination, app_name)
+SELECT
+	transactions.id,
+	transactions.description,
+	transactions.note,
+	CASE WHEN json_valid(transactions.metadata) THEN json_extract(transactions.metadata, '$.destination') END,
+	apps.name
+FROM transactions LEFT JOIN apps ON apps.id = transactions.app_id;
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
@@ -0,0 +1,35 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds the outbox of the NIP-47 responses. A response is stored before it is
+// published and removed once it was published, so that it is not lost when the hub stops in between.
+var _202408261000_outbox_responses = &gormigrate.Migration{
+	ID: "202408261000_outbox_responses",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE outbox_responses(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	request_event_id integer,
+	request text,
+	response text,
+	created_at datetime,
+	updated_at datetime,
+	CONSTRAINT fk_outbox_responses_request_event FOREIGN KEY (request_event_id) REFERENCES request_events(id) ON DELETE CASCADE
+);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
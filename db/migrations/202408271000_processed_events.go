@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds the processed request events with their responses, to answer
+// requests that are delivered more than once with the stored responses.
+var _202408271000_processed_events = &gormigrate.Migration{
+	ID: "202408271000_processed_events",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+CREATE TABLE processed_events(
+	id integer PRIMARY KEY AUTOINCREMENT,
+	nostr_id text UNIQUE,
+	request_event_id integer,
+	state text,
+	responses text,
+	created_at datetime,
+	updated_at datetime,
+	CONSTRAINT fk_processed_events_request_event FOREIGN KEY (request_event_id) REFERENCES request_events(id) ON DELETE CASCADE
+);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
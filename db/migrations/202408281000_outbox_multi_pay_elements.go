@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration stores the elements of multi_pay requests in the outbox. Every element gets
+// an outbox response before the payments are sent, with the payment hash to find its payment
+// and the d tag of its response.
+var _202408281000_outbox_multi_pay_elements = &gormigrate.Migration{
+	ID: "202408281000_outbox_multi_pay_elements",
+	Migrate: func(tx *gorm.DB) error {
+
+		if err := tx.Exec(`
+ALTER TABLE outbox_responses ADD COLUMN d_tag text;
+ALTER TABLE outbox_responses ADD COLUMN payment_hash text;
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
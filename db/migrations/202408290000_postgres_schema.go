@@ -0,0 +1,25 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+//go:embed 202408290000_postgres_schema.sql
+var postgresSchema string
+
+// This migration creates the schema of all sqlite migrations up to 202408281000_outbox_multi_pay_elements
+// on a new Postgres database. The sqlite migrations are not run on Postgres.
+//
+// Postgres has no full-text index of the transactions, see SearchTransactions.
+var _202408290000_postgres_schema = &gormigrate.Migration{
+	ID: "202408290000_postgres_schema",
+	Migrate: func(tx *gorm.DB) error {
+		return tx.Exec(postgresSchema).Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
@@ -0,0 +1,112 @@
+CREATE TABLE user_configs (id bigserial PRIMARY KEY, key text NOT NULL UNIQUE, value text, encrypted boolean, created_at timestamptz, updated_at timestamptz);
+
+CREATE TABLE apps (id bigserial PRIMARY KEY, name text, description text, nostr_pubkey text UNIQUE, created_at timestamptz, updated_at timestamptz, isolated boolean, lightning_address_username text);
+CREATE UNIQUE INDEX idx_apps_lightning_address_username ON apps(lightning_address_username) WHERE lightning_address_username IS NOT NULL AND lightning_address_username != '';
+
+CREATE TABLE app_permissions (id bigserial PRIMARY KEY, app_id bigint, scope text, max_amount_sat bigint, budget_renewal text, expires_at timestamptz, created_at timestamptz, updated_at timestamptz, budget_warning_sent_at timestamptz, CONSTRAINT fk_app_permissions_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE);
+CREATE INDEX idx_app_permissions_scope ON app_permissions(scope);
+CREATE INDEX idx_app_permissions_app_id ON app_permissions(app_id);
+
+CREATE TABLE request_events (id bigserial PRIMARY KEY, app_id bigint, nostr_id text UNIQUE, state text, created_at timestamptz, updated_at timestamptz, method text, content_data text, error_code text, error_message text, responded_at timestamptz, CONSTRAINT fk_request_events_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE);
+CREATE INDEX idx_request_events_app_id ON request_events(app_id);
+CREATE INDEX idx_request_events_app_id_and_id ON request_events(app_id, id);
+CREATE INDEX idx_request_events_method ON request_events(method);
+
+CREATE TABLE response_events (id bigserial PRIMARY KEY, nostr_id text UNIQUE, request_id bigint, state text, replied_at timestamptz, created_at timestamptz, updated_at timestamptz, CONSTRAINT fk_response_events_request_event FOREIGN KEY (request_id) REFERENCES request_events(id) ON DELETE CASCADE);
+
+CREATE TABLE offers (id bigserial PRIMARY KEY, app_id bigint, offer text, bolt12_offer_id text UNIQUE, description text, created_at timestamptz, updated_at timestamptz, CONSTRAINT fk_offers_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE SET NULL);
+
+CREATE TABLE transactions (
+	id bigserial PRIMARY KEY,
+	app_id bigint,
+	request_event_id bigint,
+	type text,
+	state text,
+	payment_request text,
+	preimage text,
+	payment_hash text,
+	description text,
+	description_hash text,
+	amount_msat bigint,
+	fee_msat bigint,
+	fee_reserve_msat bigint,
+	created_at timestamptz,
+	updated_at timestamptz,
+	expires_at timestamptz,
+	settled_at timestamptz,
+	metadata text,
+	self_payment boolean,
+	offer_id bigint REFERENCES offers(id) ON DELETE SET NULL,
+	note text
+);
+CREATE INDEX idx_transactions_app_id ON transactions(app_id);
+CREATE INDEX idx_transactions_request_event_id ON transactions(request_event_id);
+CREATE INDEX idx_transactions_state ON transactions(state);
+CREATE INDEX idx_transactions_type ON transactions(type);
+CREATE INDEX idx_transactions_payment_hash ON transactions(payment_hash);
+CREATE INDEX idx_transactions_created_at ON transactions(created_at);
+CREATE INDEX idx_transactions_settled_at ON transactions(settled_at);
+CREATE INDEX idx_transactions_app_id_type_state_created_at ON transactions(app_id, type, state, created_at);
+CREATE INDEX idx_transactions_app_id_type_state ON transactions(app_id, type, state);
+CREATE INDEX idx_transactions_app_id_type_state_created_at_settled_at_payment_hash ON transactions(app_id, type, state, created_at, settled_at, payment_hash);
+CREATE INDEX idx_transactions_app_id_created_at ON transactions(app_id, created_at);
+CREATE INDEX idx_transactions_offer_id ON transactions(offer_id);
+-- every replica receives the payments of the node, only one of them records an incoming payment
+CREATE UNIQUE INDEX idx_transactions_incoming_payment_hash ON transactions(payment_hash) WHERE type = 'incoming';
+
+CREATE TABLE transaction_parts (id bigserial PRIMARY KEY, transaction_id bigint, amount_msat bigint, fee_msat bigint, created_at timestamptz, CONSTRAINT fk_transaction_parts_transaction FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE);
+CREATE INDEX idx_transaction_parts_transaction_id ON transaction_parts(transaction_id);
+
+CREATE TABLE lnurl_withdraws (id bigserial PRIMARY KEY, app_id bigint, k1 text UNIQUE, amount_msat bigint, description text, transaction_id bigint, expires_at timestamptz, used_at timestamptz, created_at timestamptz, updated_at timestamptz, CONSTRAINT fk_lnurl_withdraws_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE);
+
+CREATE TABLE login_attempts (id bigserial PRIMARY KEY, source text UNIQUE, failed_attempts bigint, locked_until timestamptz, created_at timestamptz, updated_at timestamptz);
+
+CREATE TABLE sessions (id bigserial PRIMARY KEY, token_hash text UNIQUE, last_seen_at timestamptz, expires_at timestamptz, created_at timestamptz, user_agent text, ip_address text);
+
+CREATE TABLE swaps (
+	id bigserial PRIMARY KEY,
+	swap_id text UNIQUE,
+	type text,
+	state text,
+	boltz_status text,
+	amount_sat bigint,
+	onchain_amount_sat bigint,
+	invoice text,
+	payment_hash text,
+	preimage text,
+	private_key text,
+	boltz_public_key text,
+	swap_tree text,
+	lockup_address text,
+	destination_address text,
+	timeout_block_height bigint,
+	lockup_tx_id text,
+	claim_tx_id text,
+	refund_tx_id text,
+	auto_swap boolean,
+	created_at timestamptz,
+	updated_at timestamptz
+);
+CREATE INDEX idx_swaps_state ON swaps(state);
+
+CREATE TABLE activities (id bigserial PRIMARY KEY, type text, event text, title text, description text, app_name text, amount_sat bigint, payment_hash text, created_at timestamptz, notify boolean, read_at timestamptz);
+CREATE INDEX idx_activities_type ON activities(type);
+CREATE INDEX idx_activities_notify_read_at ON activities(notify, read_at);
+
+CREATE TABLE tag_rules (id bigserial PRIMARY KEY, tag text, app_id bigint, type text, keysend boolean, description_contains text, created_at timestamptz, CONSTRAINT fk_tag_rules_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE);
+
+CREATE TABLE transaction_tags (
+	id bigserial PRIMARY KEY,
+	transaction_id bigint,
+	tag text,
+	tag_rule_id bigint,
+	created_at timestamptz,
+	CONSTRAINT fk_transaction_tags_transaction FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+	CONSTRAINT fk_transaction_tags_tag_rule FOREIGN KEY (tag_rule_id) REFERENCES tag_rules(id) ON DELETE CASCADE
+);
+CREATE UNIQUE INDEX idx_transaction_tags_transaction_id_tag ON transaction_tags(transaction_id, tag);
+CREATE INDEX idx_transaction_tags_tag ON transaction_tags(tag);
+
+CREATE TABLE outbox_responses (id bigserial PRIMARY KEY, request_event_id bigint, request text, response text, created_at timestamptz, updated_at timestamptz, d_tag text, payment_hash text, CONSTRAINT fk_outbox_responses_request_event FOREIGN KEY (request_event_id) REFERENCES request_events(id) ON DELETE CASCADE);
+
+CREATE TABLE processed_events (id bigserial PRIMARY KEY, nostr_id text UNIQUE, request_event_id bigint, state text, responses text, created_at timestamptz, updated_at timestamptz, CONSTRAINT fk_processed_events_request_event FOREIGN KEY (request_event_id) REFERENCES request_events(id) ON DELETE CASCADE);
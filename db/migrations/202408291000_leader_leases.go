@@ -0,0 +1,37 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds the leases of the leader election, held by one replica of the hub
+// at a time when multiple replicas share a Postgres database.
+var _202408291000_leader_leases = &gormigrate.Migration{
+	ID: "202408291000_leader_leases",
+	Migrate: func(tx *gorm.DB) error {
+		timeType := "datetime"
+		if tx.Dialector.Name() == "postgres" {
+			timeType = "timestamptz"
+		}
+
+		if err := tx.Exec(`
+CREATE TABLE leader_leases(
+	name text PRIMARY KEY,
+	holder text,
+	expires_at ` + timeType + `,
+	created_at ` + timeType + `,
+	updated_at ` + timeType + `
+);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	_ "embed"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration adds the counters of the NIP-47 rate limit, shared by all replicas of the hub.
+var _202408301000_request_counters = &gormigrate.Migration{
+	ID: "202408301000_request_counters",
+	Migrate: func(tx *gorm.DB) error {
+		timeType := "datetime"
+		if tx.Dialector.Name() == "postgres" {
+			timeType = "timestamptz"
+		}
+
+		if err := tx.Exec(`
+CREATE TABLE request_counters(
+	app_id bigint,
+	window_start ` + timeType + `,
+	requests integer,
+	PRIMARY KEY (app_id, window_start),
+	CONSTRAINT fk_request_counters_app FOREIGN KEY (app_id) REFERENCES apps(id) ON DELETE CASCADE
+);
+`).Error; err != nil {
+			return err
+		}
+
+		return nil
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
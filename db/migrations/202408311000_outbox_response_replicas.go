@@ -0,0 +1,18 @@
+package migrations
+
+import (
+	"github.com/go-gormigrate/gormigrate/v2"
+	"gorm.io/gorm"
+)
+
+// This migration records the replica that handles the request of an outbox response,
+// so that requests still handled by another replica are not answered as interrupted.
+var _202408311000_outbox_response_replicas = &gormigrate.Migration{
+	ID: "202408311000_outbox_response_replicas",
+	Migrate: func(tx *gorm.DB) error {
+		return tx.Exec("ALTER TABLE outbox_responses ADD COLUMN replica text").Error
+	},
+	Rollback: func(tx *gorm.DB) error {
+		return nil
+	},
+}
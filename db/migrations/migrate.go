@@ -6,20 +6,81 @@ import (
 )
 
 func Migrate(gormDB *gorm.DB) error {
-
-	m := gormigrate.New(gormDB, gormigrate.DefaultOptions, []*gormigrate.Migration{
-		_202401191539_initial_migration,
-		_202403171120_delete_ldk_payments,
-		_202404021909_nullable_expires_at,
-		_202405302121_store_decrypted_request,
-		_202406061259_delete_content,
-		_202406071726_vacuum,
-		_202406301207_rename_request_methods,
-		_202407012100_transactions,
-		_202407151352_autoincrement,
-		_202407201604_transactions_indexes,
-		_202407262257_remove_invalid_scopes,
-	})
+	m := gormigrate.New(gormDB, gormigrate.DefaultOptions, migrationsFor(gormDB))
 
 	return m.Migrate()
 }
+
+// HasPending returns true if some of the migrations have not run on the database yet
+func HasPending(gormDB *gorm.DB) (bool, error) {
+	if !gormDB.Migrator().HasTable(gormigrate.DefaultOptions.TableName) {
+		return true, nil
+	}
+	var ids []string
+	err := gormDB.Table(gormigrate.DefaultOptions.TableName).Pluck(gormigrate.DefaultOptions.IDColumnName, &ids).Error
+	if err != nil {
+		return false, err
+	}
+	migrated := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		migrated[id] = true
+	}
+	for _, migration := range migrationsFor(gormDB) {
+		if !migrated[migration.ID] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func migrationsFor(gormDB *gorm.DB) []*gormigrate.Migration {
+	migrations := sqliteMigrations
+	if gormDB.Dialector.Name() == "postgres" {
+		// Postgres databases start with the schema that the sqlite migrations ended with
+		migrations = []*gormigrate.Migration{
+			_202408290000_postgres_schema,
+		}
+	}
+	// these migrations run on sqlite and Postgres
+	migrations = append(migrations,
+		_202408291000_leader_leases,
+		_202408301000_request_counters,
+		_202408311000_outbox_response_replicas,
+	)
+	return migrations
+}
+
+// migrations from before Postgres was supported, only run on sqlite
+var sqliteMigrations = []*gormigrate.Migration{
+	_202401191539_initial_migration,
+	_202403171120_delete_ldk_payments,
+	_202404021909_nullable_expires_at,
+	_202405302121_store_decrypted_request,
+	_202406061259_delete_content,
+	_202406071726_vacuum,
+	_202406301207_rename_request_methods,
+	_202407012100_transactions,
+	_202407151352_autoincrement,
+	_202407201604_transactions_indexes,
+	_202407262257_remove_invalid_scopes,
+	_202408061737_lightning_addresses,
+	_202408071021_lnurl_withdraws,
+	_202408081154_transaction_parts,
+	_202408091530_payment_states,
+	_202408121106_login_attempts,
+	_202408121530_sessions,
+	_202408141200_transactions_app_id_created_at_index,
+	_202408151200_offers,
+	_202408161200_swaps,
+	_202408171200_activities,
+	_202408181200_request_event_responses,
+	_202408191200_activity_notifications,
+	_202408201200_session_devices,
+	_202408221000_budget_warnings,
+	_202408231000_transaction_tags,
+	_202408241000_transaction_notes,
+	_202408251000_transactions_search,
+	_202408261000_outbox_responses,
+	_202408271000_processed_events,
+	_202408281000_outbox_multi_pay_elements,
+}
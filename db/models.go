@@ -19,6 +19,8 @@ type App struct {
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	Isolated    bool
+	// optional username to receive payments as <username>@<hub domain>
+	LightningAddressUsername string
 }
 
 type AppPermission struct {
@@ -29,8 +31,10 @@ type AppPermission struct {
 	MaxAmountSat  int
 	BudgetRenewal string
 	ExpiresAt     *time.Time
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	// the last warning that the budget runs out before it renews
+	BudgetWarningSentAt *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
 }
 
 type RequestEvent struct {
@@ -41,8 +45,12 @@ type RequestEvent struct {
 	ContentData string
 	Method      string
 	State       string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// set if the request was answered with an error
+	ErrorCode    string
+	ErrorMessage string
+	RespondedAt  *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 type ResponseEvent struct {
@@ -55,6 +63,56 @@ type ResponseEvent struct {
 	UpdatedAt time.Time
 }
 
+// ProcessedEvent records the outcome of a request nostr event, so that a request that is
+// delivered again by a relay is answered with the same responses instead of being handled again
+type ProcessedEvent struct {
+	ID             uint
+	NostrId        string `validate:"required"`
+	RequestEventId uint   `validate:"required"`
+	// the state of the request event once it was handled
+	State string
+	// the signed response nostr events as a JSON array, in the order they were published
+	Responses string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LeaderLease is held by the replica that is the leader, until it expires without being renewed
+type LeaderLease struct {
+	Name      string `gorm:"primaryKey"`
+	Holder    string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// RequestCounter counts the NIP-47 requests of an app in a window of the rate limit
+type RequestCounter struct {
+	AppId       uint      `gorm:"primaryKey;autoIncrement:false"`
+	WindowStart time.Time `gorm:"primaryKey"`
+	Requests    int
+}
+
+// OutboxResponse is a response that has to be published for a request event. Payment requests
+// get one before the payment is made (one per payment for multi_pay requests), so that they can
+// be answered after a restart of the hub.
+type OutboxResponse struct {
+	ID             uint
+	RequestEventId uint `validate:"required"`
+	RequestEvent   RequestEvent
+	// the signed request nostr event, to create the response after a restart
+	Request string
+	// the signed response nostr event, empty while the request is handled
+	Response string
+	// set for the elements of multi_pay requests, which have one outbox response each
+	DTag        string
+	PaymentHash string
+	// the replica that handles the request, empty without leader election
+	Replica   string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 type Transaction struct {
 	ID              uint
 	AppId           *uint
@@ -77,10 +135,147 @@ type Transaction struct {
 	SettledAt       *time.Time
 	Metadata        string
 	SelfPayment     bool
+	OfferId         *uint
+	Offer           *Offer
+	// private note of the user, never shared with the other side of the payment
+	Note string
+}
+
+// a tag of a settled transaction, added by the user or by a tag rule
+type TransactionTag struct {
+	ID            uint
+	TransactionId uint   `validate:"required"`
+	Tag           string `validate:"required"`
+	// nil if the tag was added by the user
+	TagRuleId *uint
+	CreatedAt time.Time
+}
+
+// a rule that tags the settled transactions matching all of its conditions
+type TagRule struct {
+	ID    uint
+	Tag   string `validate:"required"`
+	AppId *uint
+	App   *App
+	// empty to match incoming and outgoing transactions
+	Type string
+	// only match payments without an invoice
+	Keysend             bool
+	DescriptionContains string
+	CreatedAt           time.Time
+}
+
+// reusable BOLT12 offer, the transactions received for it reference the offer
+type Offer struct {
+	ID            uint
+	AppId         *uint
+	App           *App
+	Offer         string `validate:"required"`
+	Bolt12OfferId string `validate:"required"`
+	Description   string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// a swap between the lightning and on-chain balance of the node through Boltz.
+// The private key and preimage are needed to claim or refund the on-chain funds.
+type Swap struct {
+	ID                 uint
+	SwapId             string `validate:"required"`
+	Type               string `validate:"required"`
+	State              string `validate:"required"`
+	BoltzStatus        string
+	AmountSat          uint64
+	OnchainAmountSat   uint64
+	Invoice            string
+	PaymentHash        string
+	Preimage           string
+	PrivateKey         string
+	BoltzPublicKey     string
+	SwapTree           string
+	LockupAddress      string
+	DestinationAddress string
+	TimeoutBlockHeight uint32
+	LockupTxId         string
+	ClaimTxId          string
+	RefundTxId         string
+	// set for swaps started by the auto swap out
+	AutoSwap  bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// an entry of the activity feed, recorded from the events published by the hub
+type Activity struct {
+	ID          uint
+	Type        string `validate:"required"`
+	Event       string `validate:"required"`
+	Title       string
+	Description string
+	// empty if the activity is not related to an app
+	AppName     string
+	AmountSat   int64
+	PaymentHash string
+	// shown in the notification center until it is read
+	Notify    bool
+	ReadAt    *time.Time
+	CreatedAt time.Time
+}
+
+// a single part of a multi-part payment. The amounts and fees of all parts
+// are also rolled up into the parent transaction
+type TransactionPart struct {
+	ID            uint
+	TransactionId uint `validate:"required"`
+	AmountMsat    uint64
+	FeeMsat       uint64
+	CreatedAt     time.Time
+}
+
+// single-use LNURL-withdraw link paid from the app's budget
+type LNURLWithdraw struct {
+	ID            uint
+	AppId         uint `validate:"required"`
+	App           App
+	K1            string `validate:"required"`
+	AmountMsat    uint64
+	Description   string
+	TransactionId *uint
+	ExpiresAt     *time.Time
+	UsedAt        *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// gorm would otherwise split the initialism into ln_url_withdraws
+func (LNURLWithdraw) TableName() string {
+	return "lnurl_withdraws"
+}
+
+// failed unlock attempts from a single source (e.g. an IP address)
+type LoginAttempt struct {
+	ID             uint
+	Source         string `validate:"required"`
+	FailedAttempts int
+	LockedUntil    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// an unlocked web UI session. Only a hash of the session id in the cookie is stored
+type Session struct {
+	ID         uint
+	TokenHash  string `validate:"required"`
+	UserAgent  string
+	IPAddress  string
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
 }
 
 type DBService interface {
 	CreateApp(name string, pubkey string, maxAmountSat uint64, budgetRenewal string, expiresAt *time.Time, scopes []string, isolated bool) (*App, string, error)
+	RegenerateAppSecret(app *App) (string, error)
 }
 
 const (
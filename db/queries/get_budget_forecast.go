@@ -0,0 +1,73 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"gorm.io/gorm"
+)
+
+// the spend rate is not projected until this share of the budget period has passed,
+// a single payment right after the budget renewed would be extrapolated too far
+const minBudgetForecastProgress = 0.1
+
+type BudgetForecast struct {
+	RenewedAt time.Time
+	RenewsAt  time.Time
+	// average spending since the budget renewed
+	SpendRateSatPerDay float64
+	// nil if the budget is projected to last until it renews
+	RunsOutAt *time.Time
+}
+
+// GetBudgetForecast projects when the budget runs out at the current spend rate.
+// It returns nil if the permission has no budget or the budget never renews.
+func GetBudgetForecast(tx *gorm.DB, appPermission *db.AppPermission) *BudgetForecast {
+	if appPermission.MaxAmountSat <= 0 || appPermission.BudgetRenewal == "" || appPermission.BudgetRenewal == constants.BUDGET_RENEWAL_NEVER {
+		return nil
+	}
+	renewedAt := getStartOfBudget(appPermission.BudgetRenewal)
+	renewsAt := getEndOfBudget(appPermission.BudgetRenewal, renewedAt)
+	usageSat := GetBudgetUsageSat(tx, appPermission)
+	return projectBudget(time.Now(), renewedAt, renewsAt, usageSat, uint64(appPermission.MaxAmountSat))
+}
+
+func projectBudget(now time.Time, renewedAt time.Time, renewsAt time.Time, usageSat uint64, maxAmountSat uint64) *BudgetForecast {
+	forecast := &BudgetForecast{
+		RenewedAt: renewedAt,
+		RenewsAt:  renewsAt,
+	}
+	if usageSat >= maxAmountSat {
+		forecast.RunsOutAt = &now
+		return forecast
+	}
+
+	elapsed := now.Sub(renewedAt)
+	if elapsed <= 0 || usageSat == 0 || elapsed.Seconds() < renewsAt.Sub(renewedAt).Seconds()*minBudgetForecastProgress {
+		return forecast
+	}
+	spendRateSatPerSecond := float64(usageSat) / elapsed.Seconds()
+	forecast.SpendRateSatPerDay = spendRateSatPerSecond * (24 * time.Hour).Seconds()
+
+	runsOutAt := now.Add(time.Duration(float64(maxAmountSat-usageSat) / spendRateSatPerSecond * float64(time.Second)))
+	if runsOutAt.Before(renewsAt) {
+		forecast.RunsOutAt = &runsOutAt
+	}
+	return forecast
+}
+
+func getEndOfBudget(budgetType string, startOfBudget time.Time) time.Time {
+	switch budgetType {
+	case constants.BUDGET_RENEWAL_DAILY:
+		return startOfBudget.AddDate(0, 0, 1)
+	case constants.BUDGET_RENEWAL_WEEKLY:
+		return startOfBudget.AddDate(0, 0, 7)
+	case constants.BUDGET_RENEWAL_MONTHLY:
+		return startOfBudget.AddDate(0, 1, 0)
+	case constants.BUDGET_RENEWAL_YEARLY:
+		return startOfBudget.AddDate(1, 0, 0)
+	default: //"never"
+		return time.Time{}
+	}
+}
@@ -0,0 +1,34 @@
+package queries
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectBudget(t *testing.T) {
+	renewedAt := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	renewsAt := getEndOfBudget(constants.BUDGET_RENEWAL_MONTHLY, renewedAt)
+	assert.Equal(t, time.Date(2024, time.July, 1, 0, 0, 0, 0, time.UTC), renewsAt)
+
+	// 10 days in, 500 of 1000 sats were spent: runs out after 20 days
+	now := renewedAt.AddDate(0, 0, 10)
+	forecast := projectBudget(now, renewedAt, renewsAt, 500, 1000)
+	assert.Equal(t, 50.0, forecast.SpendRateSatPerDay)
+	assert.Equal(t, renewedAt.AddDate(0, 0, 20), *forecast.RunsOutAt)
+
+	// lasts until it renews
+	forecast = projectBudget(now, renewedAt, renewsAt, 100, 1000)
+	assert.Equal(t, 10.0, forecast.SpendRateSatPerDay)
+	assert.Nil(t, forecast.RunsOutAt)
+
+	// already used up
+	forecast = projectBudget(now, renewedAt, renewsAt, 1000, 1000)
+	assert.Equal(t, now, *forecast.RunsOutAt)
+
+	// too early in the budget period to project
+	forecast = projectBudget(renewedAt.Add(time.Hour), renewedAt, renewsAt, 500, 1000)
+	assert.Nil(t, forecast.RunsOutAt)
+}
@@ -15,10 +15,19 @@ func GetBudgetUsageSat(tx *gorm.DB, appPermission *db.AppPermission) uint64 {
 	tx.
 		Table("transactions").
 		Select("SUM(amount_msat + fee_msat + fee_reserve_msat) as sum").
-		Where("app_id = ? AND type = ? AND (state = ? OR state = ?) AND created_at > ?", appPermission.AppId, constants.TRANSACTION_TYPE_OUTGOING, constants.TRANSACTION_STATE_SETTLED, constants.TRANSACTION_STATE_PENDING, getStartOfBudget(appPermission.BudgetRenewal)).Scan(&result)
+		Where("app_id = ? AND type = ? AND state IN ? AND created_at > ?", appPermission.AppId, constants.TRANSACTION_TYPE_OUTGOING, constants.OUTGOING_TRANSACTION_RESERVED_STATES, getStartOfBudget(appPermission.BudgetRenewal)).Scan(&result)
 	return result.Sum / 1000
 }
 
+// BudgetUsageMsatQuery selects the budget usage of the app in msat, to be used as a subquery
+// in statements that must see the usage at the time they are executed
+func BudgetUsageMsatQuery(tx *gorm.DB, appPermission *db.AppPermission) *gorm.DB {
+	return tx.
+		Table("transactions").
+		Select("COALESCE(SUM(amount_msat + fee_msat + fee_reserve_msat), 0)").
+		Where("app_id = ? AND type = ? AND state IN ? AND created_at > ?", appPermission.AppId, constants.TRANSACTION_TYPE_OUTGOING, constants.OUTGOING_TRANSACTION_RESERVED_STATES, getStartOfBudget(appPermission.BudgetRenewal))
+}
+
 func getStartOfBudget(budget_type string) time.Time {
 	now := time.Now()
 	switch budget_type {
@@ -21,7 +21,16 @@ func GetIsolatedBalance(tx *gorm.DB, appId uint) uint64 {
 	tx.
 		Table("transactions").
 		Select("SUM(amount_msat + fee_msat + fee_reserve_msat) as sum").
-		Where("app_id = ? AND type = ? AND (state = ? OR state = ?)", appId, constants.TRANSACTION_TYPE_OUTGOING, constants.TRANSACTION_STATE_SETTLED, constants.TRANSACTION_STATE_PENDING).Scan(&spent)
+		Where("app_id = ? AND type = ? AND state IN ?", appId, constants.TRANSACTION_TYPE_OUTGOING, constants.OUTGOING_TRANSACTION_RESERVED_STATES).Scan(&spent)
 
 	return received.Sum - spent.Sum
 }
+
+// IsolatedBalanceMsatQuery selects the balance of the isolated app in msat as a subquery
+func IsolatedBalanceMsatQuery(tx *gorm.DB, appId uint) *gorm.DB {
+	return tx.
+		Table("transactions").
+		Select("COALESCE(SUM(CASE WHEN type = ? AND state = ? THEN amount_msat WHEN type = ? AND state IN ? THEN -(amount_msat + fee_msat + fee_reserve_msat) ELSE 0 END), 0)",
+			constants.TRANSACTION_TYPE_INCOMING, constants.TRANSACTION_STATE_SETTLED, constants.TRANSACTION_TYPE_OUTGOING, constants.OUTGOING_TRANSACTION_RESERVED_STATES).
+		Where("app_id = ?", appId)
+}
@@ -0,0 +1,21 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"gorm.io/gorm"
+)
+
+// GetSpentSinceSat sums the settled payments of the node since the given time, across all apps.
+// Self payments only move funds between apps of the hub and are not counted.
+func GetSpentSinceSat(tx *gorm.DB, since time.Time) uint64 {
+	var result struct {
+		Sum uint64
+	}
+	tx.
+		Table("transactions").
+		Select("SUM(amount_msat + fee_msat) as sum").
+		Where("type = ? AND state = ? AND self_payment = ? AND settled_at > ?", constants.TRANSACTION_TYPE_OUTGOING, constants.TRANSACTION_STATE_SETTLED, false, since).Scan(&result)
+	return result.Sum / 1000
+}
@@ -0,0 +1,63 @@
+package dumps
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/utils"
+)
+
+type dirDestination struct {
+	dir string
+}
+
+func (destination *dirDestination) write(ctx context.Context, name string, data []byte) error {
+	path := filepath.Join(destination.dir, filepath.FromSlash(name))
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first so that a loader never picks up a half-written file
+	tmpFile := path + ".tmp"
+	err = os.WriteFile(tmpFile, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpFile, path)
+}
+
+// s3Destination uploads the files to an S3-compatible bucket, e.g. AWS S3, Cloudflare R2
+// or MinIO. The url contains the bucket in the path and optionally a prefix for the
+// files, e.g. https://s3.eu-central-1.amazonaws.com/my-bucket/hub
+type s3Destination struct {
+	url             string
+	region          string
+	accessKeyId     string
+	secretAccessKey string
+	httpClient      *http.Client
+}
+
+func (destination *s3Destination) write(ctx context.Context, name string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, strings.TrimSuffix(destination.url, "/")+"/"+name, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	utils.SignAWSRequest(req, "s3", utils.SHA256Hex(data), destination.region, destination.accessKeyId, destination.secretAccessKey, time.Now())
+
+	res, err := destination.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("upload to S3 failed with status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package dumps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/transactions"
+)
+
+const (
+	dumpBatchSize     = 10000
+	dumpUploadTimeout = 1 * time.Minute
+	// request events are updated until they are answered, recent ones are left for the next dump
+	requestEventsDumpDelay = 10 * time.Minute
+	dumpFileTimeFormat     = "20060102T150405Z"
+)
+
+// config keys of the watermarks, everything up to them was dumped
+const (
+	dumpTransactionsCursorKey = "DumpTransactionsCursor"
+	dumpRequestEventsIdKey    = "DumpRequestEventsId"
+)
+
+type DumpsService interface {
+	// StartDumps writes the transactions and request events added since the last dump to
+	// DUMP_DIR and DUMP_S3_URL every DUMP_INTERVAL_MINS, if one of them is set
+	StartDumps(ctx context.Context)
+}
+
+type dumpsService struct {
+	db                  *gorm.DB
+	cfg                 config.Config
+	transactionsService transactions.TransactionsService
+	destinations        []dumpDestination
+}
+
+// dumpDestination stores a dump file, the name includes the table, e.g. transactions/20240701T120000Z-1.jsonl
+type dumpDestination interface {
+	write(ctx context.Context, name string, data []byte) error
+}
+
+// transactionRecord is a settled transaction as it is dumped. The preimage and the
+// private note are not included.
+type transactionRecord struct {
+	ID              uint       `json:"id"`
+	AppId           *uint      `json:"app_id"`
+	RequestEventId  *uint      `json:"request_event_id"`
+	Type            string     `json:"type"`
+	State           string     `json:"state"`
+	AmountMsat      uint64     `json:"amount_msat"`
+	FeeMsat         uint64     `json:"fee_msat"`
+	PaymentRequest  string     `json:"payment_request"`
+	PaymentHash     string     `json:"payment_hash"`
+	Description     string     `json:"description"`
+	DescriptionHash string     `json:"description_hash"`
+	SelfPayment     bool       `json:"self_payment"`
+	CreatedAt       time.Time  `json:"created_at"`
+	SettledAt       *time.Time `json:"settled_at"`
+}
+
+// requestEventRecord is a NIP-47 request as it is dumped, without its content
+type requestEventRecord struct {
+	ID           uint       `json:"id"`
+	AppId        *uint      `json:"app_id"`
+	NostrId      string     `json:"nostr_id"`
+	Method       string     `json:"method"`
+	State        string     `json:"state"`
+	ErrorCode    string     `json:"error_code"`
+	ErrorMessage string     `json:"error_message"`
+	CreatedAt    time.Time  `json:"created_at"`
+	RespondedAt  *time.Time `json:"responded_at"`
+}
+
+func NewDumpsService(db *gorm.DB, cfg config.Config, transactionsService transactions.TransactionsService) *dumpsService {
+	svc := &dumpsService{
+		db:                  db,
+		cfg:                 cfg,
+		transactionsService: transactionsService,
+	}
+	env := cfg.GetEnv()
+	if env.DumpDir != "" {
+		svc.destinations = append(svc.destinations, &dirDestination{dir: env.DumpDir})
+	}
+	if env.DumpS3Url != "" {
+		svc.destinations = append(svc.destinations, &s3Destination{
+			url:             env.DumpS3Url,
+			region:          env.DumpS3Region,
+			accessKeyId:     env.DumpS3AccessKeyId,
+			secretAccessKey: env.DumpS3SecretAccessKey,
+			httpClient: &http.Client{
+				Timeout: dumpUploadTimeout,
+			},
+		})
+	}
+	return svc
+}
+
+func (svc *dumpsService) StartDumps(ctx context.Context) {
+	if len(svc.destinations) == 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Duration(svc.cfg.GetEnv().DumpIntervalMins) * time.Minute)
+		defer ticker.Stop()
+		for {
+			svc.dump(ctx)
+			select {
+			case <-ctx.Done():
+				logger.Logger.Info("Stopping data dumps")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (svc *dumpsService) dump(ctx context.Context) {
+	// the files of a dump share the time, so that they can be loaded together
+	dumpTime := time.Now().UTC().Format(dumpFileTimeFormat)
+
+	count, err := svc.dumpTransactions(ctx, dumpTime)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to dump transactions")
+	} else if count > 0 {
+		logger.Logger.WithField("count", count).Info("Dumped transactions")
+	}
+
+	count, err = svc.dumpRequestEvents(ctx, dumpTime)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to dump request events")
+	} else if count > 0 {
+		logger.Logger.WithField("count", count).Info("Dumped request events")
+	}
+}
+
+// dumpTransactions dumps the transactions settled after the watermark in files of up to dumpBatchSize transactions
+func (svc *dumpsService) dumpTransactions(ctx context.Context, dumpTime string) (int, error) {
+	total := 0
+	for batch := 1; ; batch++ {
+		var cursor *transactions.ExportCursor
+		watermark, _ := svc.cfg.Get(dumpTransactionsCursorKey, "")
+		if watermark != "" {
+			var err error
+			cursor, err = transactions.DecodeExportCursor(watermark)
+			if err != nil {
+				return total, fmt.Errorf("invalid transactions watermark %q: %w", watermark, err)
+			}
+		}
+
+		var data bytes.Buffer
+		var last *transactions.Transaction
+		count := 0
+		encoder := json.NewEncoder(&data)
+		var encodeErr error
+		err := svc.transactionsService.ExportTransactions(0, 0, dumpBatchSize, cursor, func(transaction *transactions.Transaction) bool {
+			encodeErr = encoder.Encode(newTransactionRecord(transaction))
+			last = transaction
+			count++
+			return encodeErr == nil
+		})
+		if err == nil {
+			err = encodeErr
+		}
+		if err != nil {
+			return total, err
+		}
+		if count == 0 {
+			return total, nil
+		}
+
+		err = svc.write(ctx, fmt.Sprintf("transactions/%s-%d.jsonl", dumpTime, batch), data.Bytes())
+		if err != nil {
+			return total, err
+		}
+		svc.cfg.SetUpdate(dumpTransactionsCursorKey, transactions.NewExportCursor(last).Encode(), "")
+		total += count
+		if count < dumpBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// dumpRequestEvents dumps the request events with an id above the watermark in files of up to dumpBatchSize events
+func (svc *dumpsService) dumpRequestEvents(ctx context.Context, dumpTime string) (int, error) {
+	total := 0
+	for batch := 1; ; batch++ {
+		watermark, _ := svc.cfg.Get(dumpRequestEventsIdKey, "")
+		lastId := uint64(0)
+		if watermark != "" {
+			var err error
+			lastId, err = strconv.ParseUint(watermark, 10, 64)
+			if err != nil {
+				return total, fmt.Errorf("invalid request events watermark %q: %w", watermark, err)
+			}
+		}
+
+		requestEvents := []db.RequestEvent{}
+		err := svc.db.
+			Where("id > ? AND created_at <= ?", lastId, time.Now().Add(-requestEventsDumpDelay)).
+			Order("id").
+			Limit(dumpBatchSize).
+			Find(&requestEvents).Error
+		if err != nil {
+			return total, err
+		}
+		if len(requestEvents) == 0 {
+			return total, nil
+		}
+
+		var data bytes.Buffer
+		encoder := json.NewEncoder(&data)
+		for i := range requestEvents {
+			err = encoder.Encode(newRequestEventRecord(&requestEvents[i]))
+			if err != nil {
+				return total, err
+			}
+		}
+
+		err = svc.write(ctx, fmt.Sprintf("request_events/%s-%d.jsonl", dumpTime, batch), data.Bytes())
+		if err != nil {
+			return total, err
+		}
+		svc.cfg.SetUpdate(dumpRequestEventsIdKey, strconv.FormatUint(uint64(requestEvents[len(requestEvents)-1].ID), 10), "")
+		total += len(requestEvents)
+		if len(requestEvents) < dumpBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// write stores the file in every destination. The watermark is only moved once all of
+// them succeeded, so a failed dump is repeated in full and may duplicate records in the
+// destinations that succeeded.
+func (svc *dumpsService) write(ctx context.Context, name string, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, dumpUploadTimeout)
+	defer cancel()
+	for _, destination := range svc.destinations {
+		err := destination.write(ctx, name, data)
+		if err != nil {
+			logger.Logger.WithError(err).WithFields(logrus.Fields{
+				"name":        name,
+				"destination": fmt.Sprintf("%T", destination),
+			}).Error("Failed to write dump file")
+			return err
+		}
+	}
+	return nil
+}
+
+func newTransactionRecord(transaction *transactions.Transaction) *transactionRecord {
+	record := &transactionRecord{
+		ID:              transaction.ID,
+		AppId:           transaction.AppId,
+		RequestEventId:  transaction.RequestEventId,
+		Type:            transaction.Type,
+		State:           transaction.State,
+		AmountMsat:      transaction.AmountMsat,
+		FeeMsat:         transaction.FeeMsat,
+		PaymentRequest:  transaction.PaymentRequest,
+		PaymentHash:     transaction.PaymentHash,
+		Description:     transaction.Description,
+		DescriptionHash: transaction.DescriptionHash,
+		SelfPayment:     transaction.SelfPayment,
+		CreatedAt:       transaction.CreatedAt.UTC(),
+	}
+	if transaction.SettledAt != nil {
+		settledAt := transaction.SettledAt.UTC()
+		record.SettledAt = &settledAt
+	}
+	return record
+}
+
+func newRequestEventRecord(requestEvent *db.RequestEvent) *requestEventRecord {
+	record := &requestEventRecord{
+		ID:           requestEvent.ID,
+		AppId:        requestEvent.AppId,
+		NostrId:      requestEvent.NostrId,
+		Method:       requestEvent.Method,
+		State:        requestEvent.State,
+		ErrorCode:    requestEvent.ErrorCode,
+		ErrorMessage: requestEvent.ErrorMessage,
+		CreatedAt:    requestEvent.CreatedAt.UTC(),
+	}
+	if requestEvent.RespondedAt != nil {
+		respondedAt := requestEvent.RespondedAt.UTC()
+		record.RespondedAt = &respondedAt
+	}
+	return record
+}
@@ -0,0 +1,128 @@
+package dumps
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+	"github.com/getAlby/hub/transactions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDump(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	settledAt := time.Now().Add(-time.Minute)
+	preimage := "secret"
+	for _, transaction := range []*db.Transaction{
+		{Type: constants.TRANSACTION_TYPE_INCOMING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "settled", AmountMsat: 21000, SettledAt: &settledAt, Preimage: &preimage, Note: "private"},
+		{Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_PENDING, PaymentHash: "pending"},
+	} {
+		assert.NoError(t, svc.DB.Create(transaction).Error)
+	}
+	for _, requestEvent := range []*db.RequestEvent{
+		{NostrId: "answered", Method: "pay_invoice", State: "executed", CreatedAt: time.Now().Add(-time.Hour)},
+		{NostrId: "recent", Method: "get_info", State: "received"},
+	} {
+		assert.NoError(t, svc.DB.Create(requestEvent).Error)
+	}
+
+	uploads := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access-key/"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		uploads[r.URL.Path] = string(body)
+	}))
+	defer server.Close()
+
+	dumpDir := t.TempDir()
+	env := svc.Cfg.GetEnv()
+	env.DumpDir = dumpDir
+	env.DumpS3Url = server.URL + "/bucket/hub/"
+	env.DumpS3AccessKeyId = "access-key"
+	env.DumpS3SecretAccessKey = "secret-key"
+
+	dumpsService := NewDumpsService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	dumpsService.dump(ctx)
+
+	files, err := filepath.Glob(filepath.Join(dumpDir, "*", "*.jsonl"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(files))
+	assert.Equal(t, 2, len(uploads))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		name, err := filepath.Rel(dumpDir, file)
+		assert.NoError(t, err)
+		assert.Equal(t, string(data), uploads["/bucket/hub/"+filepath.ToSlash(name)])
+
+		assert.Equal(t, 1, strings.Count(string(data), "\n"))
+		switch filepath.Base(filepath.Dir(file)) {
+		case "transactions":
+			assert.Contains(t, string(data), `"payment_hash":"settled"`)
+			assert.Contains(t, string(data), `"amount_msat":21000`)
+			assert.NotContains(t, string(data), "secret")
+			assert.NotContains(t, string(data), "private")
+		case "request_events":
+			assert.Contains(t, string(data), `"nostr_id":"answered"`)
+		default:
+			t.Errorf("unexpected dump file %s", file)
+		}
+	}
+
+	// nothing new since the watermarks
+	dumpsService.dump(ctx)
+	files, err = filepath.Glob(filepath.Join(dumpDir, "*", "*.jsonl"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(files))
+	assert.Equal(t, 2, len(uploads))
+}
+
+func TestDump_FailedUploadIsRepeated(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	settledAt := time.Now().Add(-time.Minute)
+	assert.NoError(t, svc.DB.Create(&db.Transaction{Type: constants.TRANSACTION_TYPE_INCOMING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "settled", SettledAt: &settledAt}).Error)
+
+	status := http.StatusInternalServerError
+	uploads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	env := svc.Cfg.GetEnv()
+	env.DumpS3Url = server.URL + "/bucket"
+	env.DumpS3AccessKeyId = "access-key"
+	env.DumpS3SecretAccessKey = "secret-key"
+
+	dumpsService := NewDumpsService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	dumpsService.dump(ctx)
+	assert.Equal(t, 1, uploads)
+	watermark, _ := svc.Cfg.Get(dumpTransactionsCursorKey, "")
+	assert.Empty(t, watermark)
+
+	status = http.StatusOK
+	dumpsService.dump(ctx)
+	assert.Equal(t, 2, uploads)
+	watermark, _ = svc.Cfg.Get(dumpTransactionsCursorKey, "")
+	assert.NotEmpty(t, watermark)
+}
@@ -0,0 +1,151 @@
+package frontend
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// files in dist/assets are built by vite with a content hash in their name
+	fingerprintedAssetsPath = "/assets/"
+	immutableCacheControl   = "public, max-age=31536000, immutable"
+	// other files can change between versions and are revalidated through their ETag
+	revalidateCacheControl = "no-cache"
+)
+
+var compressibleExtensions = []string{".html", ".js", ".css", ".json", ".svg", ".txt", ".webmanifest", ".map"}
+
+// content types that are missing from the mime package on some systems
+var contentTypes = map[string]string{
+	// the manifest of the PWA (progressive web app) generated by vite-plugin-pwa
+	".webmanifest": "application/manifest+json",
+	".woff2":       "font/woff2",
+}
+
+// root-relative URLs in index.html, e.g. href="/favicon.svg"
+var assetUrlRegex = regexp.MustCompile(`(href|src)="(/[^"?#]+)"`)
+
+type asset struct {
+	content     []byte
+	gzipped     []byte
+	contentType string
+	// the first characters of the sha256 hash of the content
+	hash string
+}
+
+// loadAssets reads all embedded files into memory and compresses them once, rather than on every request
+func loadAssets(assetsFS fs.FS) (map[string]*asset, error) {
+	assets := map[string]*asset{}
+	err := fs.WalkDir(assetsFS, ".", func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(assetsFS, filePath)
+		if err != nil {
+			return err
+		}
+
+		asset, err := newAsset(filePath, content)
+		if err != nil {
+			return err
+		}
+		assets["/"+filePath] = asset
+		return nil
+	})
+	return assets, err
+}
+
+// newAsset detects the content type of the file and compresses it if that makes it smaller
+func newAsset(filePath string, content []byte) (*asset, error) {
+	hash := sha256.Sum256(content)
+	asset := &asset{
+		content:     content,
+		contentType: mime.TypeByExtension(path.Ext(filePath)),
+		hash:        hex.EncodeToString(hash[:])[:16],
+	}
+	if contentType, ok := contentTypes[path.Ext(filePath)]; ok {
+		asset.contentType = contentType
+	}
+	if asset.contentType == "" {
+		asset.contentType = http.DetectContentType(content)
+	}
+
+	isCompressible := false
+	for _, extension := range compressibleExtensions {
+		if strings.HasSuffix(filePath, extension) {
+			isCompressible = true
+		}
+	}
+	if isCompressible {
+		var buffer bytes.Buffer
+		writer, err := gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		_, err = writer.Write(content)
+		if err != nil {
+			return nil, err
+		}
+		err = writer.Close()
+		if err != nil {
+			return nil, err
+		}
+		// tiny files can grow when compressed
+		if buffer.Len() < len(content) {
+			asset.gzipped = buffer.Bytes()
+		}
+	}
+
+	return asset, nil
+}
+
+// fingerprintUrls adds the content hash to the URLs of files that are not fingerprinted by vite (e.g. from /public),
+// so that they can be cached as long as the files in /assets
+func fingerprintUrls(html string, assets map[string]*asset) string {
+	return assetUrlRegex.ReplaceAllStringFunc(html, func(match string) string {
+		parts := assetUrlRegex.FindStringSubmatch(match)
+		assetPath := parts[2]
+		asset, ok := assets[assetPath]
+		if !ok || strings.HasPrefix(assetPath, fingerprintedAssetsPath) {
+			return match
+		}
+		return parts[1] + `="` + assetPath + "?v=" + asset.hash + `"`
+	})
+}
+
+func serveAsset(c echo.Context, assetPath string, asset *asset) error {
+	header := c.Response().Header()
+	if strings.HasPrefix(assetPath, fingerprintedAssetsPath) || c.QueryParam("v") == asset.hash {
+		header.Set(echo.HeaderCacheControl, immutableCacheControl)
+	} else {
+		header.Set(echo.HeaderCacheControl, revalidateCacheControl)
+	}
+	header.Set(echo.HeaderContentType, asset.contentType)
+
+	content := asset.content
+	etag := asset.hash
+	if asset.gzipped != nil {
+		header.Add(echo.HeaderVary, echo.HeaderAcceptEncoding)
+		if strings.Contains(c.Request().Header.Get(echo.HeaderAcceptEncoding), "gzip") {
+			header.Set(echo.HeaderContentEncoding, "gzip")
+			content = asset.gzipped
+			etag += "-gzip"
+		}
+	}
+	header.Set("ETag", `"`+etag+`"`)
+
+	// handles HEAD requests and If-None-Match
+	http.ServeContent(c.Response(), c.Request(), assetPath, time.Time{}, bytes.NewReader(content))
+	return nil
+}
@@ -0,0 +1,61 @@
+package frontend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssets(t *testing.T) {
+	assets, err := loadAssets(fstest.MapFS{
+		"index.html":           {Data: []byte(`<link rel="icon" href="/favicon.svg" /><script src="/assets/index-abc.js"></script>`)},
+		"favicon.svg":          {Data: []byte(strings.Repeat("<svg></svg>", 100))},
+		"manifest.webmanifest": {Data: []byte(`{"name":"Alby Hub"}`)},
+		"assets/index-abc.js":  {Data: []byte(strings.Repeat("console.log(1);", 100))},
+	})
+	assert.NoError(t, err)
+
+	html := fingerprintUrls(string(assets["/index.html"].content), assets)
+	assert.Equal(t, `<link rel="icon" href="/favicon.svg?v=`+assets["/favicon.svg"].hash+`" /><script src="/assets/index-abc.js"></script>`, html)
+
+	e := echo.New()
+	serve := func(target string, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(echo.HeaderAcceptEncoding, acceptEncoding)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assetPath := c.Request().URL.Path
+		assert.NoError(t, serveAsset(c, assetPath, assets[assetPath]))
+		return rec
+	}
+
+	rec := serve("/assets/index-abc.js", "gzip, deflate, br")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, immutableCacheControl, rec.Header().Get(echo.HeaderCacheControl))
+	assert.Equal(t, "gzip", rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, assets["/assets/index-abc.js"].gzipped, rec.Body.Bytes())
+
+	rec = serve("/favicon.svg?v="+assets["/favicon.svg"].hash, "")
+	assert.Equal(t, immutableCacheControl, rec.Header().Get(echo.HeaderCacheControl))
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.Equal(t, assets["/favicon.svg"].content, rec.Body.Bytes())
+
+	rec = serve("/favicon.svg", "")
+	assert.Equal(t, revalidateCacheControl, rec.Header().Get(echo.HeaderCacheControl))
+
+	req := httptest.NewRequest(http.MethodGet, "/favicon.svg", nil)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+	rec = httptest.NewRecorder()
+	assert.NoError(t, serveAsset(e.NewContext(req, rec), "/favicon.svg", assets["/favicon.svg"]))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	// the manifest of the PWA is revalidated so that changes are picked up by installed apps
+	rec = serve("/manifest.webmanifest", "")
+	assert.Equal(t, revalidateCacheControl, rec.Header().Get(echo.HeaderCacheControl))
+	assert.Equal(t, "application/manifest+json", rec.Header().Get(echo.HeaderContentType))
+}
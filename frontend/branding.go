@@ -0,0 +1,53 @@
+package frontend
+
+import (
+	"encoding/json"
+	"html"
+	"regexp"
+
+	"github.com/getAlby/hub/config"
+)
+
+// generated by vite-plugin-pwa from the manifest in vite.config.ts
+const manifestPath = "/manifest.webmanifest"
+
+var (
+	titleRegex      = regexp.MustCompile(`<title>[^<]*</title>`)
+	themeColorRegex = regexp.MustCompile(`<meta name="theme-color" content="[^"]*"\s*/?>`)
+	iconRegex       = regexp.MustCompile(`<link rel="icon" href="[^"]*"\s*/?>`)
+)
+
+// brandIndexHtml sets the title, theme color and icon of index.html, so that
+// they are correct before the frontend loaded the branding of the info endpoint
+func brandIndexHtml(indexHtml string, branding config.Branding) string {
+	indexHtml = titleRegex.ReplaceAllLiteralString(indexHtml, "<title>"+html.EscapeString(branding.Name)+"</title>")
+	if branding.PrimaryColor != "" {
+		indexHtml = themeColorRegex.ReplaceAllLiteralString(indexHtml, `<meta name="theme-color" content="`+html.EscapeString(branding.PrimaryColor)+`" />`)
+	}
+	if branding.LogoUrl != "" {
+		indexHtml = iconRegex.ReplaceAllLiteralString(indexHtml, `<link rel="icon" href="`+html.EscapeString(branding.LogoUrl)+`" />`)
+	}
+	return indexHtml
+}
+
+// brandManifest sets the name of the app when it is installed as a PWA
+func brandManifest(manifest *asset, branding config.Branding) (*asset, error) {
+	values := map[string]interface{}{}
+	err := json.Unmarshal(manifest.content, &values)
+	if err != nil {
+		return nil, err
+	}
+	values["name"] = branding.Name
+	values["short_name"] = branding.Name
+	if branding.PrimaryColor != "" {
+		values["theme_color"] = branding.PrimaryColor
+	}
+	if branding.LogoUrl != "" {
+		values["icons"] = []map[string]string{{"src": branding.LogoUrl, "sizes": "any"}}
+	}
+	content, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return newAsset(manifestPath, content)
+}
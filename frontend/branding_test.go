@@ -0,0 +1,39 @@
+package frontend
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/getAlby/hub/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testIndexHtml = `<head><meta name="theme-color" content="#000000" /><title>Alby Hub</title><link rel="icon" href="/favicon.svg?v=abc" /></head>`
+
+func TestBrandIndexHtml(t *testing.T) {
+	assert.Equal(t, `<head><meta name="theme-color" content="#000000" /><title>Alby Hub</title><link rel="icon" href="/favicon.svg?v=abc" /></head>`,
+		brandIndexHtml(testIndexHtml, config.Branding{Name: "Alby Hub"}))
+
+	assert.Equal(t, `<head><meta name="theme-color" content="#ff9900" /><title>Satoshi &lt;Club&gt;</title><link rel="icon" href="https://example.com/logo.png?a=1&amp;b=2" /></head>`,
+		brandIndexHtml(testIndexHtml, config.Branding{
+			Name:         "Satoshi <Club>",
+			LogoUrl:      "https://example.com/logo.png?a=1&b=2",
+			PrimaryColor: "#ff9900",
+		}))
+}
+
+func TestBrandManifest(t *testing.T) {
+	manifest, err := newAsset("manifest.webmanifest", []byte(`{"name":"Alby Hub","short_name":"Alby Hub","display":"standalone"}`))
+	assert.NoError(t, err)
+
+	brandedManifest, err := brandManifest(manifest, config.Branding{Name: "Satoshi Club", PrimaryColor: "#ff9900"})
+	assert.NoError(t, err)
+	assert.Equal(t, "application/manifest+json", brandedManifest.contentType)
+
+	values := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(brandedManifest.content, &values))
+	assert.Equal(t, "Satoshi Club", values["name"])
+	assert.Equal(t, "Satoshi Club", values["short_name"])
+	assert.Equal(t, "#ff9900", values["theme_color"])
+	assert.Equal(t, "standalone", values["display"])
+}
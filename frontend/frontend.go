@@ -1,26 +1,99 @@
 package frontend
 
 import (
+	"crypto/rand"
 	"embed"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/logger"
 )
 
 //go:embed dist
 var embeddedReactAssets embed.FS
 
-func RegisterHandlers(e *echo.Echo) {
-	e.Use(middleware.StaticWithConfig(middleware.StaticConfig{
-		Skipper: nil,
-		// Root directory from where the static content is served.
-		Root: "dist",
-		// Enable HTML5 mode by forwarding all not-found requests to root so that
-		// SPA (single-page application) can handle the routing.
-		HTML5:      true,
-		Browse:     false,
-		IgnoreBase: false,
-		Filesystem: http.FS(embeddedReactAssets),
-	}))
+// the inline scripts in index.html are allowed through a nonce that is generated per request
+const contentSecurityPolicy = "default-src 'self'; " +
+	"script-src 'self' 'nonce-%s' https://app.chatwoot.com; " +
+	"style-src 'self' 'unsafe-inline' https://app.chatwoot.com; " +
+	"img-src 'self' data: blob: https:; " +
+	"font-src 'self' data: https://app.chatwoot.com; " +
+	"connect-src 'self' https: wss:; " +
+	"frame-src https://app.chatwoot.com; " +
+	"frame-ancestors 'none'; " +
+	"object-src 'none'; " +
+	"base-uri 'self'; " +
+	"form-action 'self'"
+
+func RegisterHandlers(e *echo.Echo, branding config.Branding) {
+	distFS, err := fs.Sub(embeddedReactAssets, "dist")
+	if err != nil {
+		logger.HTTP.WithError(err).Fatal("Failed to open embedded frontend")
+	}
+	assets, err := loadAssets(distFS)
+	if err != nil {
+		logger.HTTP.WithError(err).Fatal("Failed to load embedded frontend")
+	}
+	index, ok := assets["/index.html"]
+	if !ok {
+		// the frontend is built before the backend
+		logger.HTTP.Fatal("Failed to read index.html")
+	}
+	if manifest, ok := assets[manifestPath]; ok {
+		assets[manifestPath], err = brandManifest(manifest, branding)
+		if err != nil {
+			logger.HTTP.WithError(err).Fatal("Failed to brand web app manifest")
+		}
+	}
+	// index.html is served below so that a nonce can be added to its scripts
+	indexHtml := []byte(brandIndexHtml(fingerprintUrls(string(index.content), assets), branding))
+
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			method := c.Request().Method
+			if method != http.MethodGet && method != http.MethodHead {
+				return next(c)
+			}
+			if isIndexPath(c) {
+				return serveIndex(c, indexHtml)
+			}
+			assetPath := c.Request().URL.Path
+			if asset, ok := assets[assetPath]; ok {
+				return serveAsset(c, assetPath, asset)
+			}
+			// forward all not-found requests to index.html so that the
+			// SPA (single-page application) can handle the routing.
+			err := next(c)
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) && httpErr.Code == http.StatusNotFound {
+				return serveIndex(c, indexHtml)
+			}
+			return err
+		}
+	})
+}
+
+func isIndexPath(c echo.Context) bool {
+	path := c.Request().URL.Path
+	return path == "/" || path == "/index.html"
+}
+
+func serveIndex(c echo.Context, indexHtml []byte) error {
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return err
+	}
+	nonce := base64.StdEncoding.EncodeToString(nonceBytes)
+
+	html := strings.ReplaceAll(string(indexHtml), "<script", fmt.Sprintf(`<script nonce="%s"`, nonce))
+	c.Response().Header().Set("Content-Security-Policy", fmt.Sprintf(contentSecurityPolicy, nonce))
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+	return c.HTML(http.StatusOK, html)
 }
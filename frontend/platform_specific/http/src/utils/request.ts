@@ -16,10 +16,15 @@ export const request = async <T>(
     }
 
     if (!fetchResponse.ok) {
+      // quoted by the user when asking for help, to find the error in the logs
+      const requestId =
+        (body as ErrorResponse)?.requestId ||
+        fetchResponse.headers.get("X-Request-Id");
       throw new Error(
         fetchResponse.status +
           " " +
-          ((body as ErrorResponse)?.message || "Unknown error")
+          ((body as ErrorResponse)?.message || "Unknown error") +
+          (requestId ? ` (request ID: ${requestId})` : "")
       );
     }
     return body;
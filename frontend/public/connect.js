@@ -0,0 +1,128 @@
+// Embeddable "Connect with NWC" widget of Alby Hub.
+//
+// Include it from your hub and request a connection:
+//
+//   <script src="https://my-hub.example.com/connect.js"></script>
+//   <button data-nwc-connect data-name="My App" data-request-methods="pay_invoice get_balance">
+//     Connect Wallet
+//   </button>
+//
+// The hub opens in a popup where the user approves the permissions. The
+// connection URL is then passed back to this page only, as the `detail` of a
+// `nwc:connected` event on the button. It can also be requested in JavaScript:
+//
+//   const nwcUrl = await AlbyHub.connect({ name: "My App", requestMethods: ["pay_invoice"] });
+(function () {
+  "use strict";
+
+  var script = document.currentScript;
+  var hubUrl = script ? new URL(script.src).origin : window.location.origin;
+  var popupWidth = 600;
+  var popupHeight = 800;
+
+  function connectUrl(options) {
+    var params = new URLSearchParams();
+    params.set("name", options.name || document.title);
+    // the hub only sends the connection to this origin
+    params.set("origin", window.location.origin);
+    if (options.requestMethods) {
+      params.set("request_methods", [].concat(options.requestMethods).join(" "));
+    }
+    if (options.notificationTypes) {
+      params.set("notification_types", [].concat(options.notificationTypes).join(" "));
+    }
+    if (options.maxAmount) {
+      params.set("max_amount", String(options.maxAmount));
+    }
+    if (options.budgetRenewal) {
+      params.set("budget_renewal", options.budgetRenewal);
+    }
+    if (options.expiresAt) {
+      params.set("expires_at", String(options.expiresAt));
+    }
+    if (options.isolated) {
+      params.set("isolated", "true");
+    }
+    return hubUrl + "/#/apps/new?" + params.toString();
+  }
+
+  function connect(options) {
+    options = options || {};
+    return new Promise(function (resolve, reject) {
+      var left = window.screenX + (window.outerWidth - popupWidth) / 2;
+      var top = window.screenY + (window.outerHeight - popupHeight) / 2;
+      var popup = window.open(
+        connectUrl(options),
+        "alby-hub-connect",
+        "popup,width=" + popupWidth + ",height=" + popupHeight + ",left=" + left + ",top=" + top
+      );
+      if (!popup) {
+        reject(new Error("The popup to connect your wallet was blocked"));
+        return;
+      }
+
+      var closedInterval;
+      function cleanup() {
+        window.removeEventListener("message", onMessage);
+        window.clearInterval(closedInterval);
+      }
+      function onMessage(event) {
+        if (event.origin !== hubUrl || event.source !== popup) {
+          return;
+        }
+        var data = event.data || {};
+        if (data.type !== "nwc:success" || !data.payload || !data.payload.nostrWalletConnectUrl) {
+          return;
+        }
+        cleanup();
+        resolve(data.payload.nostrWalletConnectUrl);
+      }
+      window.addEventListener("message", onMessage);
+      closedInterval = window.setInterval(function () {
+        if (popup.closed) {
+          cleanup();
+          reject(new Error("The connection was cancelled"));
+        }
+      }, 500);
+    });
+  }
+
+  function optionsFromElement(element) {
+    var data = element.dataset;
+    return {
+      name: data.name,
+      requestMethods: data.requestMethods && data.requestMethods.split(" "),
+      notificationTypes: data.notificationTypes && data.notificationTypes.split(" "),
+      maxAmount: data.maxAmount,
+      budgetRenewal: data.budgetRenewal,
+      expiresAt: data.expiresAt,
+      isolated: data.isolated === "true",
+    };
+  }
+
+  document.addEventListener("click", function (event) {
+    var element = event.target.closest && event.target.closest("[data-nwc-connect]");
+    if (!element) {
+      return;
+    }
+    event.preventDefault();
+    connect(optionsFromElement(element)).then(
+      function (nostrWalletConnectUrl) {
+        element.dispatchEvent(
+          new CustomEvent("nwc:connected", {
+            bubbles: true,
+            detail: { nostrWalletConnectUrl: nostrWalletConnectUrl },
+          })
+        );
+      },
+      function (error) {
+        element.dispatchEvent(
+          new CustomEvent("nwc:error", { bubbles: true, detail: { error: error } })
+        );
+      }
+    );
+  });
+
+  window.AlbyHub = window.AlbyHub || {};
+  window.AlbyHub.connect = connect;
+})();
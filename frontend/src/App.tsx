@@ -1,8 +1,10 @@
 import { RouterProvider, createHashRouter } from "react-router-dom";
 
 import { ThemeProvider } from "src/components/ui/theme-provider";
+import { useBranding } from "src/hooks/useBranding";
 import { usePosthog } from "./hooks/usePosthog";
 
+import FlashMessage from "src/components/FlashMessage";
 import { Toaster } from "src/components/ui/toaster";
 import routes from "src/routes.tsx";
 
@@ -10,6 +12,7 @@ const router = createHashRouter(routes);
 
 function App() {
   usePosthog();
+  useBranding();
 
   return (
     <>
@@ -19,6 +22,7 @@ function App() {
         storageKey="vite-ui-theme"
       >
         <Toaster />
+        <FlashMessage />
         <RouterProvider router={router} />
       </ThemeProvider>
     </>
@@ -0,0 +1,122 @@
+import {
+  AppWindowIcon,
+  ArrowDownUpIcon,
+  LucideIcon,
+  ShieldIcon,
+  AlertTriangleIcon,
+} from "lucide-react";
+import React from "react";
+
+import Loading from "src/components/Loading";
+import { Button } from "src/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardHeader,
+  CardTitle,
+} from "src/components/ui/card";
+import { useActivities } from "src/hooks/useActivities";
+import { ActivityType } from "src/types";
+
+const activityTypes: {
+  type: ActivityType;
+  label: string;
+  icon: LucideIcon;
+}[] = [
+  { type: "payment", label: "Payments", icon: ArrowDownUpIcon },
+  { type: "app", label: "Connections", icon: AppWindowIcon },
+  { type: "permission", label: "Permissions", icon: ShieldIcon },
+  { type: "failure", label: "Failures", icon: AlertTriangleIcon },
+];
+
+function ActivityFeed() {
+  const [types, setTypes] = React.useState<ActivityType[]>([]);
+  const { data, size, setSize, isValidating } = useActivities(types);
+  const loadMoreRef = React.useRef<HTMLDivElement>(null);
+
+  const activities = data?.flatMap((page) => page.activities) ?? [];
+  const hasMore = !!data?.[data.length - 1]?.nextCursor;
+
+  React.useEffect(() => {
+    const element = loadMoreRef.current;
+    if (!element || !hasMore) {
+      return;
+    }
+    // load the next page once the end of the feed is scrolled into view
+    const observer = new IntersectionObserver((entries) => {
+      if (entries[0].isIntersecting && !isValidating) {
+        setSize(size + 1);
+      }
+    });
+    observer.observe(element);
+    return () => observer.disconnect();
+  }, [hasMore, isValidating, setSize, size]);
+
+  const toggleType = (type: ActivityType) => {
+    setTypes(
+      types.includes(type) ? types.filter((t) => t !== type) : [...types, type]
+    );
+  };
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>Activity</CardTitle>
+      </CardHeader>
+      <CardContent className="flex flex-col gap-5">
+        <div className="flex flex-row flex-wrap gap-2">
+          {activityTypes.map(({ type, label }) => (
+            <Button
+              key={type}
+              size="sm"
+              variant={types.includes(type) ? "default" : "outline"}
+              onClick={() => toggleType(type)}
+            >
+              {label}
+            </Button>
+          ))}
+        </div>
+        {!data && <Loading />}
+        {data && !activities.length && (
+          <p className="text-sm text-muted-foreground">No activity yet.</p>
+        )}
+        <div className="flex flex-col gap-4">
+          {activities.map((activity) => {
+            const Icon =
+              activityTypes.find(({ type }) => type === activity.type)
+                ?.icon ?? ArrowDownUpIcon;
+            return (
+              <div key={activity.id} className="flex flex-row gap-3">
+                <Icon
+                  className={`w-5 h-5 mt-0.5 shrink-0 ${
+                    activity.type === "failure"
+                      ? "text-destructive"
+                      : "text-muted-foreground"
+                  }`}
+                />
+                <div className="flex-1 overflow-hidden">
+                  <p className="font-medium">{activity.title}</p>
+                  {activity.description && (
+                    <p className="text-sm text-muted-foreground truncate">
+                      {activity.description}
+                    </p>
+                  )}
+                </div>
+                <p className="text-sm text-muted-foreground whitespace-nowrap">
+                  {new Date(activity.createdAt).toLocaleString()}
+                </p>
+              </div>
+            );
+          })}
+        </div>
+        {hasMore && (
+          <div ref={loadMoreRef}>
+            <Loading />
+          </div>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
+
+export default ActivityFeed;
@@ -0,0 +1,24 @@
+import dayjs from "dayjs";
+import { AlertTriangleIcon } from "lucide-react";
+
+import { Alert, AlertDescription, AlertTitle } from "src/components/ui/alert";
+import { App } from "src/types";
+
+export function BudgetForecastAlert({ app }: { app: App }) {
+  if (!app.budgetRunsOutAt || !app.budgetRenewsAt) {
+    return null;
+  }
+
+  return (
+    <Alert className="mb-4">
+      <AlertTriangleIcon className="h-4 w-4" />
+      <AlertTitle>The budget runs out soon</AlertTitle>
+      <AlertDescription>
+        At the current spend rate, the budget runs out{" "}
+        {dayjs(app.budgetRunsOutAt).fromNow()}, before it renews on{" "}
+        {dayjs(app.budgetRenewsAt).format("MMM D")}. Payments of this app fail
+        until then unless you increase the budget.
+      </AlertDescription>
+    </Alert>
+  );
+}
@@ -0,0 +1,41 @@
+import React from "react";
+
+import { useToast } from "src/components/ui/use-toast";
+
+// FlashMessage shows an error once that the hub passed in the url, e.g. after
+// a failed redirect back from an OAuth provider
+function FlashMessage() {
+  const { toast } = useToast();
+
+  React.useEffect(() => {
+    // the router is a hash router, so the query is part of the hash
+    const [path, query] = window.location.hash.split("?");
+    const queryParams = new URLSearchParams(query);
+    const flash = queryParams.get("flash");
+    if (!flash) {
+      return;
+    }
+    const requestId = queryParams.get("request_id");
+    toast({
+      title: flash,
+      description: requestId
+        ? `Please quote the request ID ${requestId} if you ask for help.`
+        : undefined,
+      variant: "destructive",
+    });
+
+    queryParams.delete("flash");
+    queryParams.delete("request_id");
+    const remainingQuery = queryParams.toString();
+    // keep the state of the router in the history entry
+    window.history.replaceState(
+      window.history.state,
+      "",
+      remainingQuery ? `${path}?${remainingQuery}` : path
+    );
+  }, [toast]);
+
+  return null;
+}
+
+export default FlashMessage;
@@ -0,0 +1,127 @@
+import { Label } from "src/components/ui/label";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "src/components/ui/select";
+import { Switch } from "src/components/ui/switch";
+import { useToast } from "src/components/ui/use-toast";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useLogSettings } from "src/hooks/useLogSettings";
+import {
+  LogSettings as LogSettingsResponse,
+  UpdateLogSettingsRequest,
+} from "src/types";
+import { handleRequestError } from "src/utils/handleRequestError";
+import { request } from "src/utils/request";
+
+const logLevels = [
+  { value: "0", label: "Panic" },
+  { value: "1", label: "Fatal" },
+  { value: "2", label: "Error" },
+  { value: "3", label: "Warning" },
+  { value: "4", label: "Info" },
+  { value: "5", label: "Debug" },
+  { value: "6", label: "Trace" },
+];
+
+const debugToggleLabels: Record<string, string> = {
+  nostr_frames: "Log nostr events sent to and received from the relay",
+  backend_bodies: "Log requests to the node and its responses",
+};
+
+export function LogSettings() {
+  const { data: csrf } = useCSRF();
+  const { data: logSettings, mutate: reloadLogSettings } = useLogSettings();
+  const { toast } = useToast();
+
+  if (!logSettings) {
+    return null;
+  }
+
+  const updateLogSettings = async (
+    updateLogSettingsRequest: UpdateLogSettingsRequest
+  ) => {
+    try {
+      if (!csrf) {
+        throw new Error("No CSRF token");
+      }
+      const updated = await request<LogSettingsResponse>("/api/log-settings", {
+        method: "PATCH",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify(updateLogSettingsRequest),
+      });
+      await reloadLogSettings(updated, { revalidate: false });
+    } catch (error) {
+      handleRequestError(toast, "Failed to update log settings", error);
+    }
+  };
+
+  const levelSelect = (
+    id: string,
+    value: string,
+    onChange: (value: string) => void
+  ) => (
+    <Select value={value} onValueChange={onChange}>
+      <SelectTrigger id={id} className="w-32">
+        <SelectValue />
+      </SelectTrigger>
+      <SelectContent>
+        {logLevels.map((logLevel) => (
+          <SelectItem key={logLevel.value} value={logLevel.value}>
+            {logLevel.label}
+          </SelectItem>
+        ))}
+      </SelectContent>
+    </Select>
+  );
+
+  return (
+    <div className="grid gap-4 m-8 max-w-lg">
+      <div>
+        <h3 className="text-lg font-medium">Logging</h3>
+        <p className="text-sm text-muted-foreground">
+          Changes apply right away and are reset when the hub restarts.
+          Secrets are redacted from all logs.
+        </p>
+      </div>
+      <div className="flex items-center justify-between">
+        <Label htmlFor="log-level">Log level</Label>
+        {levelSelect("log-level", logSettings.logLevel, (logLevel) =>
+          updateLogSettings({ logLevel })
+        )}
+      </div>
+      {Object.entries(logSettings.componentLevels).map(
+        ([component, logLevel]) => (
+          <div key={component} className="flex items-center justify-between">
+            <Label htmlFor={`log-level-${component}`}>
+              Log level of <span className="font-mono">{component}</span>
+            </Label>
+            {levelSelect(`log-level-${component}`, logLevel, (value) =>
+              updateLogSettings({ componentLevels: { [component]: value } })
+            )}
+          </div>
+        )
+      )}
+      {Object.entries(logSettings.debugToggles).map(([toggle, enabled]) => (
+        <div key={toggle} className="flex items-center justify-between">
+          <Label htmlFor={`debug-${toggle}`}>
+            {debugToggleLabels[toggle] || toggle}
+          </Label>
+          <Switch
+            id={`debug-${toggle}`}
+            checked={enabled}
+            onCheckedChange={(checked) =>
+              updateLogSettings({ debugToggles: { [toggle]: checked } })
+            }
+          />
+        </div>
+      ))}
+    </div>
+  );
+}
@@ -0,0 +1,98 @@
+import { BellIcon } from "lucide-react";
+
+import { Button } from "src/components/ui/button";
+import {
+  Popover,
+  PopoverContent,
+  PopoverTrigger,
+} from "src/components/ui/popover";
+import { useToast } from "src/components/ui/use-toast";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useNotifications } from "src/hooks/useNotifications";
+import { MarkNotificationsReadRequest } from "src/types";
+import { handleRequestError } from "src/utils/handleRequestError";
+import { request } from "src/utils/request";
+
+function NotificationCenter() {
+  const { data: csrf } = useCSRF();
+  const { data, mutate: reloadNotifications } = useNotifications();
+  const { toast } = useToast();
+
+  const unreadCount = data?.unreadCount ?? 0;
+
+  const markRead = async (ids?: number[]) => {
+    if (!csrf) {
+      throw new Error("No CSRF token");
+    }
+    try {
+      await request("/api/notifications/read", {
+        method: "POST",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify({ ids } as MarkNotificationsReadRequest),
+      });
+      await reloadNotifications();
+    } catch (error) {
+      handleRequestError(toast, "Failed to mark notifications as read", error);
+    }
+  };
+
+  return (
+    <Popover>
+      <PopoverTrigger asChild>
+        <Button variant="ghost" size="icon" className="relative shrink-0">
+          <BellIcon className="w-4 h-4" />
+          {unreadCount > 0 && (
+            <span className="absolute top-0 right-0 min-w-4 h-4 px-1 rounded-full bg-destructive text-destructive-foreground text-[10px] leading-4">
+              {unreadCount > 99 ? "99+" : unreadCount}
+            </span>
+          )}
+          <span className="sr-only">Notifications</span>
+        </Button>
+      </PopoverTrigger>
+      <PopoverContent className="w-80 p-0" align="end">
+        <div className="flex flex-row items-center justify-between px-4 py-3 border-b">
+          <p className="font-semibold text-sm">Notifications</p>
+          {unreadCount > 0 && (
+            <Button
+              variant="link"
+              size="sm"
+              className="h-auto p-0"
+              onClick={() => markRead()}
+            >
+              Mark all as read
+            </Button>
+          )}
+        </div>
+        <div className="flex flex-col max-h-96 overflow-y-auto">
+          {!data?.notifications.length && (
+            <p className="px-4 py-6 text-sm text-center text-muted-foreground">
+              You&apos;re all caught up.
+            </p>
+          )}
+          {data?.notifications.map((notification) => (
+            <button
+              key={notification.id}
+              className="flex flex-col gap-1 px-4 py-3 text-left border-b last:border-b-0 hover:bg-muted"
+              onClick={() => markRead([notification.id])}
+            >
+              <p className="text-sm font-medium">{notification.title}</p>
+              {notification.description && (
+                <p className="text-xs text-muted-foreground break-words">
+                  {notification.description}
+                </p>
+              )}
+              <p className="text-xs text-muted-foreground">
+                {new Date(notification.createdAt).toLocaleString()}
+              </p>
+            </button>
+          ))}
+        </div>
+      </PopoverContent>
+    </Popover>
+  );
+}
+
+export default NotificationCenter;
@@ -0,0 +1,35 @@
+import { WifiOffIcon } from "lucide-react";
+import React from "react";
+
+import { Alert, AlertDescription, AlertTitle } from "src/components/ui/alert";
+
+function OfflineAlert() {
+  const [online, setOnline] = React.useState(navigator.onLine);
+
+  React.useEffect(() => {
+    const updateOnline = () => setOnline(navigator.onLine);
+    window.addEventListener("online", updateOnline);
+    window.addEventListener("offline", updateOnline);
+    return () => {
+      window.removeEventListener("online", updateOnline);
+      window.removeEventListener("offline", updateOnline);
+    };
+  }, []);
+
+  if (online) {
+    return null;
+  }
+
+  return (
+    <Alert>
+      <WifiOffIcon className="h-4 w-4" />
+      <AlertTitle>You are offline</AlertTitle>
+      <AlertDescription>
+        Your apps and transactions are shown as they were on your last visit.
+        Payments and changes are possible again once you are back online.
+      </AlertDescription>
+    </Alert>
+  );
+}
+
+export default OfflineAlert;
@@ -0,0 +1,119 @@
+import { ShieldAlertIcon } from "lucide-react";
+import React from "react";
+import { Alert, AlertDescription, AlertTitle } from "src/components/ui/alert";
+import { Button } from "src/components/ui/button";
+import { Input } from "src/components/ui/input";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { useToast } from "src/components/ui/use-toast";
+import { useApps } from "src/hooks/useApps";
+import { useCSRF } from "src/hooks/useCSRF";
+import { usePaymentConfirmations } from "src/hooks/usePaymentConfirmations";
+import { PaymentConfirmation } from "src/types";
+import { handleRequestError } from "src/utils/handleRequestError";
+import { request } from "src/utils/request";
+
+export default function PaymentConfirmations() {
+  const { data: paymentConfirmations } = usePaymentConfirmations();
+
+  if (!paymentConfirmations?.length) {
+    return null;
+  }
+
+  return (
+    <div className="flex flex-col gap-4">
+      {paymentConfirmations.map((paymentConfirmation) => (
+        <PaymentConfirmationAlert
+          key={paymentConfirmation.transactionId}
+          paymentConfirmation={paymentConfirmation}
+        />
+      ))}
+    </div>
+  );
+}
+
+function PaymentConfirmationAlert({
+  paymentConfirmation,
+}: {
+  paymentConfirmation: PaymentConfirmation;
+}) {
+  const { data: csrf } = useCSRF();
+  const { data: apps } = useApps();
+  const { mutate: reloadPaymentConfirmations } = usePaymentConfirmations();
+  const { toast } = useToast();
+  const [isLoading, setLoading] = React.useState(false);
+  const [totpCode, setTotpCode] = React.useState("");
+
+  const app = apps?.find((app) => app.id === paymentConfirmation.appId);
+
+  async function confirmPayment(approved: boolean) {
+    if (!csrf) {
+      throw new Error("csrf not loaded");
+    }
+    setLoading(true);
+    try {
+      await request(
+        `/api/payment-confirmations/${paymentConfirmation.transactionId}`,
+        {
+          method: "POST",
+          headers: {
+            "X-CSRF-Token": csrf,
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({ approved, totpCode }),
+        }
+      );
+      toast({
+        title: approved ? "Payment approved" : "Payment rejected",
+      });
+    } catch (error) {
+      handleRequestError(toast, "Failed to confirm payment", error);
+    }
+    await reloadPaymentConfirmations();
+    setLoading(false);
+  }
+
+  return (
+    <Alert>
+      <ShieldAlertIcon className="h-4 w-4" />
+      <AlertTitle>
+        {app?.name || "An app"} wants to pay{" "}
+        {new Intl.NumberFormat().format(
+          Math.floor(paymentConfirmation.amount / 1000)
+        )}{" "}
+        sats
+      </AlertTitle>
+      <AlertDescription>
+        {paymentConfirmation.description && (
+          <div className="mb-2">{paymentConfirmation.description}</div>
+        )}
+        <div className="flex gap-2">
+          <Input
+            className="w-32 h-9"
+            inputMode="numeric"
+            autoComplete="one-time-code"
+            maxLength={6}
+            placeholder="Code"
+            value={totpCode}
+            onChange={(e) => setTotpCode(e.target.value)}
+          />
+          <LoadingButton
+            size={"sm"}
+            loading={isLoading}
+            disabled={totpCode.length !== 6}
+            onClick={() => confirmPayment(true)}
+          >
+            Approve
+          </LoadingButton>
+          <Button
+            size={"sm"}
+            variant="outline"
+            disabled={isLoading}
+            onClick={() => confirmPayment(false)}
+          >
+            Reject
+          </Button>
+        </div>
+      </AlertDescription>
+    </Alert>
+  );
+}
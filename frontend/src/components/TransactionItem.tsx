@@ -10,6 +10,7 @@ import {
 } from "lucide-react";
 import React from "react";
 import AppAvatar from "src/components/AppAvatar";
+import { Badge } from "src/components/ui/badge";
 import {
   Credenza,
   CredenzaBody,
@@ -20,22 +21,34 @@ import {
   CredenzaTitle,
   CredenzaTrigger,
 } from "src/components/ui/credenza";
+import { Input } from "src/components/ui/input";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { Textarea } from "src/components/ui/textarea";
 import { toast } from "src/components/ui/use-toast";
 import { useApps } from "src/hooks/useApps";
+import { useCSRF } from "src/hooks/useCSRF";
 import { copyToClipboard } from "src/lib/clipboard";
 import { cn } from "src/lib/utils";
 import { Transaction } from "src/types";
+import { handleRequestError } from "src/utils/handleRequestError";
+import { request } from "src/utils/request";
 
 dayjs.extend(utc);
 dayjs.extend(timezone);
 
 type Props = {
   tx: Transaction;
+  // called after the tags or the note of the transaction were saved
+  onChange?: () => void;
 };
 
-function TransactionItem({ tx }: Props) {
+function TransactionItem({ tx, onChange }: Props) {
   const { data: apps } = useApps();
+  const { data: csrf } = useCSRF();
   const [showDetails, setShowDetails] = React.useState(false);
+  const [tags, setTags] = React.useState(tx.tags.join(" "));
+  const [note, setNote] = React.useState(tx.note);
+  const [saving, setSaving] = React.useState<"tags" | "note">();
   const type = tx.type;
   const Icon = tx.type == "outgoing" ? ArrowUpIcon : ArrowDownIcon;
   const app = tx.app_id && apps?.find((app) => app.id === tx.app_id);
@@ -45,6 +58,29 @@ function TransactionItem({ tx }: Props) {
     toast({ title: "Copied to clipboard." });
   };
 
+  const save = async (field: "tags" | "note", body: object) => {
+    if (!csrf) {
+      throw new Error("No CSRF token");
+    }
+    try {
+      setSaving(field);
+      await request(`/api/transactions/${tx.id}/${field}`, {
+        method: "PUT",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify(body),
+      });
+      toast({ title: field === "tags" ? "Tags saved" : "Note saved" });
+      onChange?.();
+    } catch (error) {
+      handleRequestError(toast, `Failed to save ${field}`, error);
+    } finally {
+      setSaving(undefined);
+    }
+  };
+
   return (
     <CredenzaProvider>
       <Credenza
@@ -96,8 +132,17 @@ function TransactionItem({ tx }: Props) {
                 </p>
               </div>
               <p className="text-sm md:text-base text-muted-foreground break-all">
-                {tx.description}
+                {tx.memo}
               </p>
+              {tx.tags.length > 0 && (
+                <div className="flex flex-wrap gap-1 mt-1">
+                  {tx.tags.map((tag) => (
+                    <Badge key={tag} variant="secondary">
+                      {tag}
+                    </Badge>
+                  ))}
+                </div>
+              )}
             </div>
             <div className="flex ml-auto text-right space-x-3 shrink-0">
               <div className="flex items-center gap-2 text-xl">
@@ -182,14 +227,54 @@ function TransactionItem({ tx }: Props) {
                 </p>
               </div>
             )}
-            {tx.description && (
+            {tx.memo && (
               <div className="mt-6">
-                <p>Description</p>
-                <p className="text-muted-foreground break-all">
-                  {tx.description}
-                </p>
+                <p>Memo</p>
+                <p className="text-muted-foreground break-all">{tx.memo}</p>
               </div>
             )}
+            <div className="mt-6">
+              <p>Note</p>
+              <p className="text-sm text-muted-foreground">
+                Only visible to you.
+              </p>
+              <div className="flex items-end gap-2 mt-1">
+                <Textarea
+                  value={note}
+                  onChange={(e) => setNote(e.target.value)}
+                  placeholder="Add a note"
+                  maxLength={1000}
+                />
+                <LoadingButton
+                  loading={saving === "note"}
+                  variant="secondary"
+                  onClick={() => save("note", { note })}
+                >
+                  Save
+                </LoadingButton>
+              </div>
+            </div>
+            <div className="mt-6">
+              <p>Tags</p>
+              <div className="flex items-center gap-2 mt-1">
+                <Input
+                  value={tags}
+                  onChange={(e) => setTags(e.target.value)}
+                  placeholder="e.g. podcasting zaps"
+                />
+                <LoadingButton
+                  loading={saving === "tags"}
+                  variant="secondary"
+                  onClick={() =>
+                    save("tags", {
+                      tags: tags.split(/[\s,]+/).filter(Boolean),
+                    })
+                  }
+                >
+                  Save
+                </LoadingButton>
+              </div>
+            </div>
           </CredenzaBody>
           <CredenzaFooter className="!justify-start mt-4 !flex-col">
             <div
@@ -1,30 +1,115 @@
-import { Drum } from "lucide-react";
+import { Drum, Search } from "lucide-react";
+import React from "react";
 import EmptyState from "src/components/EmptyState";
 import Loading from "src/components/Loading";
 import TransactionItem from "src/components/TransactionItem";
+import { Input } from "src/components/ui/input";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "src/components/ui/select";
+import { useTransactionTags } from "src/hooks/useTransactionTags";
 import { useTransactions } from "src/hooks/useTransactions";
 
+// the value of the tag filter that shows all transactions
+const ALL_TAGS = "all";
+
+// wait for the user to stop typing before searching
+const SEARCH_DELAY_MS = 300;
+
 function TransactionsList() {
-  const { data: transactions, isLoading } = useTransactions();
+  const [tag, setTag] = React.useState("");
+  const [search, setSearch] = React.useState("");
+  const [query, setQuery] = React.useState("");
+  const {
+    data: transactions,
+    isLoading,
+    mutate: reloadTransactions,
+  } = useTransactions(false, 100, 1, query ? "" : tag, query);
+  const { data: tags, mutate: reloadTags } = useTransactionTags();
+
+  React.useEffect(() => {
+    const timeout = setTimeout(() => setQuery(search.trim()), SEARCH_DELAY_MS);
+    return () => clearTimeout(timeout);
+  }, [search]);
 
-  if (isLoading) {
-    return <Loading />;
-  }
+  const onTransactionChange = () => {
+    reloadTransactions();
+    reloadTags();
+  };
 
   return (
     <div className="transaction-list">
-      {!transactions?.length ? (
-        <EmptyState
-          icon={Drum}
-          title="No transactions yet"
-          description="Your most recent incoming and outgoing payments will show up here."
-          buttonText="Receive Your First Payment"
-          buttonLink="/wallet/receive"
-        />
+      <div className="flex gap-2 mb-4">
+        <div className="relative flex-1">
+          <Search
+            className="absolute left-3 top-2.5 w-4 h-4 text-muted-foreground"
+          />
+          <Input
+            type="search"
+            value={search}
+            onChange={(e) => setSearch(e.target.value)}
+            placeholder="Search descriptions, notes, destinations and apps"
+            className="pl-9"
+          />
+        </div>
+        {!!tags?.length && !query && (
+          <Select
+            value={tag || ALL_TAGS}
+            onValueChange={(value) => setTag(value === ALL_TAGS ? "" : value)}
+          >
+            <SelectTrigger className="w-[200px]">
+              <SelectValue />
+            </SelectTrigger>
+            <SelectContent>
+              <SelectItem value={ALL_TAGS}>All tags</SelectItem>
+              {tags.map((tagOption) => (
+                <SelectItem key={tagOption} value={tagOption}>
+                  {tagOption}
+                </SelectItem>
+              ))}
+            </SelectContent>
+          </Select>
+        )}
+      </div>
+      {isLoading ? (
+        <Loading />
+      ) : !transactions?.length ? (
+        query ? (
+          <p className="text-center text-muted-foreground py-8">
+            No transactions match "{query}". Search finds words that start
+            with what you typed, so try fewer or shorter words.
+          </p>
+        ) : tag ? (
+          <EmptyState
+            icon={Drum}
+            title="No transactions with this tag"
+            description="Tag a transaction in its details, or add a tag rule in the settings."
+            buttonText="Manage Tag Rules"
+            buttonLink="/settings/tags"
+          />
+        ) : (
+          <EmptyState
+            icon={Drum}
+            title="No transactions yet"
+            description="Your most recent incoming and outgoing payments will show up here."
+            buttonText="Receive Your First Payment"
+            buttonLink="/wallet/receive"
+          />
+        )
       ) : (
         <>
           {transactions?.map((tx) => {
-            return <TransactionItem key={tx.payment_hash + tx.type} tx={tx} />;
+            return (
+              <TransactionItem
+                key={tx.payment_hash + tx.type}
+                tx={tx}
+                onChange={onTransactionChange}
+              />
+            );
           })}
         </>
       )}
@@ -0,0 +1,61 @@
+import { Badge } from "src/components/ui/badge";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "src/components/ui/card";
+import { useWalletSummary } from "src/hooks/useWalletSummary";
+import { formatAmount } from "src/lib/utils";
+
+function WalletSummaryCard() {
+  const { data: summary } = useWalletSummary();
+
+  if (!summary) {
+    return null;
+  }
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle className="flex flex-row items-center gap-2">
+          {summary.nodeAlias || "Your Node"}
+          {!summary.running ? (
+            <Badge variant="warning">Not running</Badge>
+          ) : summary.nodeOnline ? (
+            <Badge variant="positive">Online</Badge>
+          ) : (
+            <Badge variant="destructive">Offline</Badge>
+          )}
+        </CardTitle>
+        <CardDescription>
+          {summary.backendType}
+          {summary.network && ` on ${summary.network}`}
+        </CardDescription>
+      </CardHeader>
+      <CardContent className="grid grid-cols-1 sm:grid-cols-3 gap-5">
+        <div>
+          <p className="text-sm text-muted-foreground">Spending Balance</p>
+          <p className="text-2xl font-semibold">
+            {formatAmount(summary.lightningSpendable)} sats
+          </p>
+        </div>
+        <div>
+          <p className="text-sm text-muted-foreground">Savings Balance</p>
+          <p className="text-2xl font-semibold">
+            {formatAmount(summary.onchainSpendable * 1000)} sats
+          </p>
+        </div>
+        <div>
+          <p className="text-sm text-muted-foreground">Spent Today</p>
+          <p className="text-2xl font-semibold">
+            {formatAmount(summary.spentTodaySat * 1000)} sats
+          </p>
+        </div>
+      </CardContent>
+    </Card>
+  );
+}
+
+export default WalletSummaryCard;
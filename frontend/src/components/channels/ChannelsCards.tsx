@@ -200,6 +200,17 @@ export function ChannelsCards({
                           sats
                         </p>
                       </div>
+                      {channel.public && (
+                        <div className="flex justify-between items-center">
+                          <p className="text-muted-foreground font-medium">
+                            Routing Fee
+                          </p>
+                          <p className="text-foreground">
+                            {formatAmount(channel.forwardingFeeBaseMsat)} sats +{" "}
+                            {channel.forwardingFeeProportionalMillionths} ppm
+                          </p>
+                        </div>
+                      )}
                     </CardDescription>
                   </div>
                 </CardHeader>
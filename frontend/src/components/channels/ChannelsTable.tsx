@@ -81,6 +81,7 @@ export function ChannelsTable({
                 </Tooltip>
               </TooltipProvider>
             </TableHead>
+            <TableHead className="w-[150px]">Routing Fee</TableHead>
             <TableHead className="w-[300px]">
               <div className="flex flex-row justify-between items-center gap-2">
                 <div>Spending</div>
@@ -148,6 +149,16 @@ export function ChannelsTable({
                         )}{" "}
                         sats
                       </TableCell>
+                      <TableCell>
+                        {channel.public ? (
+                          <>
+                            {formatAmount(channel.forwardingFeeBaseMsat)} sats
+                            + {channel.forwardingFeeProportionalMillionths} ppm
+                          </>
+                        ) : (
+                          "-"
+                        )}
+                      </TableCell>
                       <TableCell>
                         <div className="relative">
                           <Progress
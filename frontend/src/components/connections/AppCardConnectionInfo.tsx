@@ -74,6 +74,12 @@ export function AppCardConnectionInfo({
             className="h-4"
             value={(connection.budgetUsage * 100) / connection.maxAmount}
           />
+          {connection.budgetRunsOutAt && (
+            <p className="text-xs text-orange-500 mt-2">
+              Runs out {dayjs(connection.budgetRunsOutAt).fromNow()} at the
+              current spend rate
+            </p>
+          )}
           <div className="flex flex-row justify-between text-xs items-center text-muted-foreground mt-2">
             <div>
               Last used:{" "}
@@ -0,0 +1,126 @@
+import { Badge } from "src/components/ui/badge";
+import { Button } from "src/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "src/components/ui/card";
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "src/components/ui/table";
+import { appRequestsPageSize, useAppRequests } from "src/hooks/useAppRequests";
+import { AppRequest } from "src/types";
+
+// shows the most relevant params of a request in a single line
+function summarizeParams(params: AppRequest["params"]) {
+  if (!params) {
+    return "";
+  }
+  return Object.entries(params)
+    .map(([key, value]) => {
+      const text = typeof value === "string" ? value : JSON.stringify(value);
+      return `${key}: ${text.length > 24 ? text.slice(0, 24) + "…" : text}`;
+    })
+    .join(", ");
+}
+
+function AppRequests({ pubkey }: { pubkey: string }) {
+  const { data, size, setSize, isValidating } = useAppRequests(pubkey);
+
+  const requests = data?.flat() ?? [];
+  const hasMore = data?.[data.length - 1]?.length === appRequestsPageSize;
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>Requests</CardTitle>
+        <CardDescription>
+          The NIP-47 requests made by this app and how they were answered.
+        </CardDescription>
+      </CardHeader>
+      <CardContent>
+        {data && !requests.length && (
+          <p className="text-sm text-muted-foreground">No requests yet.</p>
+        )}
+        {!!requests.length && (
+          <Table>
+            <TableHeader>
+              <TableRow>
+                <TableHead>Method</TableHead>
+                <TableHead>Params</TableHead>
+                <TableHead>Result</TableHead>
+                <TableHead className="text-right">Time</TableHead>
+              </TableRow>
+            </TableHeader>
+            <TableBody>
+              {requests.map((request) => (
+                <TableRow key={request.id}>
+                  <TableCell className="font-medium whitespace-nowrap">
+                    {request.method || "unknown"}
+                  </TableCell>
+                  <TableCell className="text-muted-foreground">
+                    {request.params && (
+                      <details>
+                        <summary className="cursor-pointer break-all">
+                          {summarizeParams(request.params)}
+                        </summary>
+                        <pre className="text-xs whitespace-pre-wrap break-all mt-2">
+                          {JSON.stringify(request.params, null, 2)}
+                        </pre>
+                      </details>
+                    )}
+                  </TableCell>
+                  <TableCell>
+                    {request.errorCode ? (
+                      <div className="flex flex-col gap-1">
+                        <Badge variant="destructive" className="w-fit">
+                          {request.errorCode}
+                        </Badge>
+                        <span className="text-xs text-muted-foreground">
+                          {request.errorMessage}
+                        </span>
+                      </div>
+                    ) : (
+                      <Badge
+                        variant={
+                          request.state === "executed" ? "positive" : "outline"
+                        }
+                      >
+                        {request.state}
+                      </Badge>
+                    )}
+                  </TableCell>
+                  <TableCell className="text-right text-muted-foreground whitespace-nowrap">
+                    <p>{new Date(request.createdAt).toLocaleString()}</p>
+                    {request.durationMs !== undefined && (
+                      <p className="text-xs">{request.durationMs} ms</p>
+                    )}
+                  </TableCell>
+                </TableRow>
+              ))}
+            </TableBody>
+          </Table>
+        )}
+        {hasMore && (
+          <Button
+            variant="outline"
+            className="mt-4"
+            disabled={isValidating}
+            onClick={() => setSize(size + 1)}
+          >
+            Load more
+          </Button>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
+
+export default AppRequests;
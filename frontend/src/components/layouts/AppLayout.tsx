@@ -24,6 +24,9 @@ import {
   useLocation,
   useNavigate,
 } from "react-router-dom";
+import NotificationCenter from "src/components/NotificationCenter";
+import OfflineAlert from "src/components/OfflineAlert";
+import PaymentConfirmations from "src/components/PaymentConfirmations";
 import SidebarHint from "src/components/SidebarHint";
 import UserAvatar from "src/components/UserAvatar";
 import { AlbyHubLogo } from "src/components/icons/AlbyHubLogo";
@@ -50,6 +53,7 @@ import { useInfo } from "src/hooks/useInfo";
 import { useRemoveSuccessfulChannelOrder } from "src/hooks/useRemoveSuccessfulChannelOrder";
 import { cn } from "src/lib/utils";
 import { openLink } from "src/utils/openLink";
+import { clearOfflineCache } from "src/utils/pwa";
 import { request } from "src/utils/request";
 import ExternalLink from "../ExternalLink";
 
@@ -67,23 +71,31 @@ export default function AppLayout() {
     setMobileMenuOpen(false);
   }, [location]);
 
-  const logout = React.useCallback(async () => {
-    if (!csrf) {
-      throw new Error("csrf not loaded");
-    }
+  const logout = React.useCallback(
+    async (allDevices = false) => {
+      if (!csrf) {
+        throw new Error("csrf not loaded");
+      }
 
-    await request("/api/logout", {
-      method: "POST",
-      headers: {
-        "X-CSRF-Token": csrf,
-        "Content-Type": "application/json",
-      },
-    });
+      await request(allDevices ? "/api/logout-all" : "/api/logout", {
+        method: "POST",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+      });
 
-    await refetchInfo();
-    navigate("/", { replace: true });
-    toast({ title: "You are now logged out." });
-  }, [csrf, navigate, refetchInfo, toast]);
+      await clearOfflineCache();
+      await refetchInfo();
+      navigate("/", { replace: true });
+      toast({
+        title: allDevices
+          ? "You are now logged out on all devices."
+          : "You are now logged out.",
+      });
+    },
+    [csrf, navigate, refetchInfo, toast]
+  );
 
   const isHttpMode = window.location.protocol.startsWith("http");
 
@@ -107,13 +119,22 @@ export default function AppLayout() {
         </DropdownMenuGroup>
         <DropdownMenuSeparator />
         {isHttpMode && (
-          <DropdownMenuItem
-            onClick={logout}
-            className="w-full flex flex-row items-center gap-2"
-          >
-            <Lock className="w-4 h-4" />
-            <p>Lock Alby Hub</p>
-          </DropdownMenuItem>
+          <>
+            <DropdownMenuItem
+              onClick={() => logout()}
+              className="w-full flex flex-row items-center gap-2"
+            >
+              <Lock className="w-4 h-4" />
+              <p>Lock Alby Hub</p>
+            </DropdownMenuItem>
+            <DropdownMenuItem
+              onClick={() => logout(true)}
+              className="w-full flex flex-row items-center gap-2"
+            >
+              <Lock className="w-4 h-4" />
+              <p>Lock on all devices</p>
+            </DropdownMenuItem>
+          </>
         )}
       </DropdownMenuContent>
     );
@@ -183,6 +204,13 @@ export default function AppLayout() {
         <MenuItem
           to="/"
           onClick={(e) => {
+            e.preventDefault();
+            // hubs with their own branding have their own support
+            const supportUrl = info?.branding?.supportUrl;
+            if (supportUrl) {
+              openLink(supportUrl);
+              return;
+            }
             // eslint-disable-next-line @typescript-eslint/no-explicit-any
             const chatwoot = (window as any).$chatwoot;
             if (chatwoot) {
@@ -190,8 +218,6 @@ export default function AppLayout() {
             } else {
               openLink("https://getalby.com/help");
             }
-
-            e.preventDefault();
           }}
         >
           <MessageCircleQuestion className="h-4 w-4" />
@@ -217,7 +243,15 @@ export default function AppLayout() {
                 <nav className="grid items-start px-2 py-2 text-sm font-medium lg:px-4">
                   <div className="p-3 flex justify-between items-center mt-2 mb-6">
                     <Link to="/">
-                      <AlbyHubLogo className="text-foreground" />
+                      {info.branding?.logoUrl ? (
+                        <img
+                          src={info.branding.logoUrl}
+                          alt={info.branding.name}
+                          className="h-8 max-w-[160px] object-contain"
+                        />
+                      ) : (
+                        <AlbyHubLogo className="text-foreground" />
+                      )}
                     </Link>
                     <TooltipProvider>
                       <Tooltip>
@@ -264,14 +298,17 @@ export default function AppLayout() {
                       {albyMe?.name || albyMe?.email}
                     </Link>
                   </div>
-                  <DropdownMenu>
-                    <DropdownMenuTrigger asChild>
-                      <Button variant="ghost" size="icon">
-                        <EllipsisVertical className="w-4 h-4" />
-                      </Button>
-                    </DropdownMenuTrigger>
-                    <UserMenuContent />
-                  </DropdownMenu>
+                  <div className="flex flex-row items-center">
+                    <NotificationCenter />
+                    <DropdownMenu>
+                      <DropdownMenuTrigger asChild>
+                        <Button variant="ghost" size="icon">
+                          <EllipsisVertical className="w-4 h-4" />
+                        </Button>
+                      </DropdownMenuTrigger>
+                      <UserMenuContent />
+                    </DropdownMenu>
+                  </div>
                 </div>
               </div>
             </div>
@@ -296,7 +333,9 @@ export default function AppLayout() {
                   <nav className="grid gap-2 text-lg font-medium">
                     <div className="p-3 ">
                       <Link to="/" className="font-semibold text-xl">
-                        <span className="">Alby Hub</span>
+                        <span className="">
+                          {info.branding?.name || "Alby Hub"}
+                        </span>
                       </Link>
                     </div>
                     <MainMenuContent />
@@ -306,20 +345,25 @@ export default function AppLayout() {
                     <MainNavSecondary />
                   </div>
                 </SheetContent>
-                <DropdownMenu>
-                  <DropdownMenuTrigger asChild>
-                    <Link
-                      to="#"
-                      className="grid grid-flow-col gap-2 font-semibold text-lg whitespace-nowrap overflow-hidden text-ellipsis"
-                    >
-                      <UserAvatar className="h-8 w-8" />
-                    </Link>
-                  </DropdownMenuTrigger>
-                  <UserMenuContent />
-                </DropdownMenu>
+                <div className="flex flex-row items-center gap-2">
+                  <NotificationCenter />
+                  <DropdownMenu>
+                    <DropdownMenuTrigger asChild>
+                      <Link
+                        to="#"
+                        className="grid grid-flow-col gap-2 font-semibold text-lg whitespace-nowrap overflow-hidden text-ellipsis"
+                      >
+                        <UserAvatar className="h-8 w-8" />
+                      </Link>
+                    </DropdownMenuTrigger>
+                    <UserMenuContent />
+                  </DropdownMenu>
+                </div>
               </Sheet>
             </header>
             <div className="flex flex-1 flex-col gap-4 p-4 lg:gap-6 lg:p-8">
+              <OfflineAlert />
+              <PaymentConfirmations />
               <Outlet />
             </div>
           </main>
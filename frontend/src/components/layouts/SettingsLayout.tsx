@@ -24,10 +24,18 @@ import { request } from "src/utils/request";
 
 export default function SettingsLayout() {
   const { data: csrf } = useCSRF();
-  const { mutate: refetchInfo, hasMnemonic, hasNodeBackup } = useInfo();
+  const {
+    mutate: refetchInfo,
+    hasMnemonic,
+    hasNodeBackup,
+    hasChannelBackup,
+    hasWatchtowers,
+  } = useInfo();
   const navigate = useNavigate();
   const { toast } = useToast();
   const [shuttingDown, setShuttingDown] = useState(false);
+  // the feed is fetched over HTTP, which the desktop app does not serve
+  const isHttpMode = window.location.protocol.startsWith("http");
 
   const shutdown = React.useCallback(async () => {
     if (!csrf) {
@@ -102,12 +110,33 @@ export default function SettingsLayout() {
             <MenuItem to="/settings/change-unlock-password">
               Unlock Password
             </MenuItem>
+            <MenuItem to="/settings/identity-key">Identity Key</MenuItem>
+            <MenuItem to="/settings/payment-confirmations">
+              Payment Confirmations
+            </MenuItem>
+            <MenuItem to="/settings/reports">Reports</MenuItem>
+            <MenuItem to="/settings/app-usage">App Usage</MenuItem>
+            <MenuItem to="/settings/tags">Tags</MenuItem>
+            {isHttpMode && (
+              <MenuItem to="/settings/sessions">Sessions</MenuItem>
+            )}
+            {isHttpMode && (
+              <MenuItem to="/settings/transactions-feed">
+                Transactions Feed
+              </MenuItem>
+            )}
             {hasMnemonic && (
               <MenuItem to="/settings/key-backup">Key Backup</MenuItem>
             )}
             {hasNodeBackup && (
               <MenuItem to="/settings/node-backup">Migrate Node</MenuItem>
             )}
+            {hasChannelBackup && (
+              <MenuItem to="/settings/channel-backup">Channel Backup</MenuItem>
+            )}
+            {hasWatchtowers && (
+              <MenuItem to="/settings/watchtowers">Watchtowers</MenuItem>
+            )}
             <MenuItem to="/settings/alby-account">Alby Account</MenuItem>
             <MenuItem to="/debug-tools">
               Debug Tools
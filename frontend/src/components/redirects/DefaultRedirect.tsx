@@ -10,7 +10,12 @@ export function DefaultRedirect() {
   const navigate = useNavigate();
 
   React.useEffect(() => {
-    if (!info || (info.running && info.unlocked && info.albyAccountConnected)) {
+    if (
+      !info ||
+      (info.running &&
+        info.unlocked &&
+        (info.albyAccountConnected || info.singleUser))
+    ) {
       return;
     }
     const returnTo = location.pathname + location.search;
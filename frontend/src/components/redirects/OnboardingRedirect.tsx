@@ -9,7 +9,12 @@ export function OnboardingRedirect() {
   const navigate = useNavigate();
 
   React.useEffect(() => {
-    if (!info || (info.running && info.unlocked && info.albyAccountConnected)) {
+    if (
+      !info ||
+      (info.running &&
+        info.unlocked &&
+        (info.albyAccountConnected || info.singleUser))
+    ) {
       return;
     }
     navigate("/");
@@ -0,0 +1,20 @@
+import useSWRInfinite from "swr/infinite";
+
+import { ActivityType, ListActivitiesResponse } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+const limit = 20;
+
+export function useActivities(types: ActivityType[] = []) {
+  return useSWRInfinite<ListActivitiesResponse>(
+    (pageIndex, previousPage: ListActivitiesResponse | null) => {
+      if (previousPage && !previousPage.nextCursor) {
+        // reached the oldest activity
+        return null;
+      }
+      const cursor = previousPage?.nextCursor ?? 0;
+      return `/api/activities?cursor=${cursor}&limit=${limit}&types=${types.join(",")}`;
+    },
+    swrFetcher
+  );
+}
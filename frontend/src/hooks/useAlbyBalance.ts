@@ -1,10 +1,17 @@
 import useSWR from "swr";
 
+import { useInfo } from "src/hooks/useInfo";
 import { AlbyBalance } from "src/types";
 import { swrFetcher } from "src/utils/swr";
 
 export function useAlbyBalance() {
-  return useSWR<AlbyBalance>("/api/alby/balance", swrFetcher, {
-    dedupingInterval: 5 * 60 * 1000, // 5 minutes
-  });
+  const { data: info } = useInfo();
+  // there is no Alby account in single-user mode
+  return useSWR<AlbyBalance>(
+    info && !info.singleUser ? "/api/alby/balance" : null,
+    swrFetcher,
+    {
+      dedupingInterval: 5 * 60 * 1000, // 5 minutes
+    }
+  );
 }
@@ -1,10 +1,17 @@
 import useSWR from "swr";
 
+import { useInfo } from "src/hooks/useInfo";
 import { AlbyMe } from "src/types";
 import { swrFetcher } from "src/utils/swr";
 
 export function useAlbyMe() {
-  return useSWR<AlbyMe>("/api/alby/me", swrFetcher, {
-    dedupingInterval: 5 * 60 * 1000, // 5 minutes
-  });
+  const { data: info } = useInfo();
+  // there is no Alby account in single-user mode
+  return useSWR<AlbyMe>(
+    info && !info.singleUser ? "/api/alby/me" : null,
+    swrFetcher,
+    {
+      dedupingInterval: 5 * 60 * 1000, // 5 minutes
+    }
+  );
 }
@@ -0,0 +1,19 @@
+import useSWRInfinite from "swr/infinite";
+
+import { AppRequest } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export const appRequestsPageSize = 20;
+
+export function useAppRequests(pubkey: string) {
+  return useSWRInfinite<AppRequest[]>(
+    (pageIndex, previousPage: AppRequest[] | null) => {
+      if (previousPage && previousPage.length < appRequestsPageSize) {
+        return null;
+      }
+      const offset = pageIndex * appRequestsPageSize;
+      return `/api/apps/${pubkey}/requests?limit=${appRequestsPageSize}&offset=${offset}`;
+    },
+    swrFetcher
+  );
+}
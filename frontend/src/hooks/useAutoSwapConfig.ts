@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { AutoSwapConfig } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useAutoSwapConfig() {
+  return useSWR<AutoSwapConfig>("/api/swaps/auto", swrFetcher);
+}
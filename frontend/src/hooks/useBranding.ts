@@ -0,0 +1,59 @@
+import React from "react";
+
+import { useInfo } from "src/hooks/useInfo";
+
+// useBranding applies the branding of the hub (BRAND_* options) to the page
+export function useBranding() {
+  const { data: info } = useInfo();
+  const name = info?.branding.name;
+  const primaryColor = info?.branding.primaryColor;
+
+  React.useEffect(() => {
+    if (name) {
+      document.title = name;
+    }
+  }, [name]);
+
+  React.useEffect(() => {
+    if (!primaryColor) {
+      return;
+    }
+    const style = document.documentElement.style;
+    const [h, s, l] = hexToHsl(primaryColor);
+    // the colors of the themes are HSL values without hsl()
+    style.setProperty("--primary", `${h} ${s}% ${l}%`);
+    style.setProperty(
+      "--primary-foreground",
+      l > 60 ? "0 0% 3.9%" : "0 0% 98%"
+    );
+    return () => {
+      style.removeProperty("--primary");
+      style.removeProperty("--primary-foreground");
+    };
+  }, [primaryColor]);
+}
+
+function hexToHsl(hex: string): [number, number, number] {
+  const r = parseInt(hex.slice(1, 3), 16) / 255;
+  const g = parseInt(hex.slice(3, 5), 16) / 255;
+  const b = parseInt(hex.slice(5, 7), 16) / 255;
+
+  const max = Math.max(r, g, b);
+  const min = Math.min(r, g, b);
+  const l = (max + min) / 2;
+  let h = 0;
+  let s = 0;
+  if (max !== min) {
+    const d = max - min;
+    s = l > 0.5 ? d / (2 - max - min) : d / (max + min);
+    if (max === r) {
+      h = (g - b) / d + (g < b ? 6 : 0);
+    } else if (max === g) {
+      h = (b - r) / d + 2;
+    } else {
+      h = (r - g) / d + 4;
+    }
+    h *= 60;
+  }
+  return [Math.round(h), Math.round(s * 100), Math.round(l * 100)];
+}
@@ -0,0 +1,13 @@
+import useSWR from "swr";
+
+import { useInfo } from "src/hooks/useInfo";
+import { ChannelBackupStatus } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useChannelBackupStatus() {
+  const { hasChannelBackup } = useInfo();
+  return useSWR<ChannelBackupStatus>(
+    hasChannelBackup ? "/api/channel-backup" : null,
+    swrFetcher
+  );
+}
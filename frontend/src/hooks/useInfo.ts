@@ -28,6 +28,12 @@ export function useInfo(poll = false) {
       hasNodeBackup:
         info.data?.backendType &&
         backendTypeConfigs[info.data.backendType].hasNodeBackup,
+      hasChannelBackup:
+        info.data?.backendType &&
+        backendTypeConfigs[info.data.backendType].hasChannelBackup,
+      hasWatchtowers:
+        info.data?.backendType &&
+        backendTypeConfigs[info.data.backendType].hasWatchtowers,
     }),
     [info]
   );
@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { LogSettings } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useLogSettings() {
+  return useSWR<LogSettings>("/api/log-settings", swrFetcher);
+}
@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { MonthlyReport } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useMonthlyReport(year: number, month: number) {
+  return useSWR<MonthlyReport>(`/api/reports/${year}/${month}`, swrFetcher);
+}
@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { Nip47Usage } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useNip47Usage(days: number) {
+  return useSWR<Nip47Usage>(`/api/nip47/usage?days=${days}`, swrFetcher);
+}
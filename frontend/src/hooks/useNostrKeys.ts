@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { NostrKeys } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useNostrKeys() {
+  return useSWR<NostrKeys>("/api/keys", swrFetcher);
+}
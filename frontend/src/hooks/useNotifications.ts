@@ -0,0 +1,11 @@
+import useSWR from "swr";
+
+import { ListNotificationsResponse } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useNotifications() {
+  return useSWR<ListNotificationsResponse>("/api/notifications", swrFetcher, {
+    // approval requests should show up without reloading the page
+    refreshInterval: 10000,
+  });
+}
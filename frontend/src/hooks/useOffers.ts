@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { Offer } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useOffers() {
+  return useSWR<Offer[]>("/api/offers", swrFetcher);
+}
@@ -0,0 +1,16 @@
+import useSWR, { SWRConfiguration } from "swr";
+
+import { PaymentConfirmation } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+const pollConfiguration: SWRConfiguration = {
+  refreshInterval: 3000,
+};
+
+export function usePaymentConfirmations() {
+  return useSWR<PaymentConfirmation[]>(
+    "/api/payment-confirmations",
+    swrFetcher,
+    pollConfiguration
+  );
+}
@@ -1,7 +1,10 @@
 import React from "react";
 import { useBalances } from "src/hooks/useBalances";
 import { useCSRF } from "src/hooks/useCSRF";
-import { RedeemOnchainFundsResponse } from "src/types";
+import {
+  RedeemOnchainFundsRequest,
+  RedeemOnchainFundsResponse,
+} from "src/types";
 import { request } from "src/utils/request";
 
 export function useRedeemOnchainFunds() {
@@ -41,7 +44,10 @@ export function useRedeemOnchainFunds() {
             "X-CSRF-Token": csrf,
             "Content-Type": "application/json",
           },
-          body: JSON.stringify({ toAddress }),
+          body: JSON.stringify({
+            toAddress,
+            sendAll: true,
+          } as RedeemOnchainFundsRequest),
         }
       );
       console.info("Redeemed onchain funds", response);
@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { Session } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useSessions() {
+  return useSWR<Session[]>("/api/sessions", swrFetcher);
+}
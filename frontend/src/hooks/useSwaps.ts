@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { Swap } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useSwaps() {
+  return useSWR<Swap[]>("/api/swaps", swrFetcher);
+}
@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { TagRule } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useTagRules() {
+  return useSWR<TagRule[]>("/api/tag-rules", swrFetcher);
+}
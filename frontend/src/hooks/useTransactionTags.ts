@@ -0,0 +1,7 @@
+import useSWR from "swr";
+
+import { swrFetcher } from "src/utils/swr";
+
+export function useTransactionTags() {
+  return useSWR<string[]>("/api/transaction-tags", swrFetcher);
+}
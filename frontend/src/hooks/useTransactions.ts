@@ -7,10 +7,19 @@ const pollConfiguration: SWRConfiguration = {
   refreshInterval: 3000,
 };
 
-export function useTransactions(poll = false, limit = 100, page = 1) {
+// tag and query filter the transactions, only one of them can be set
+export function useTransactions(
+  poll = false,
+  limit = 100,
+  page = 1,
+  tag = "",
+  query = ""
+) {
   const offset = (page - 1) * limit;
+  const tagParam = tag ? `&tag=${encodeURIComponent(tag)}` : "";
+  const queryParam = query ? `&q=${encodeURIComponent(query)}` : "";
   return useSWR<Transaction[]>(
-    `/api/transactions?limit=${limit}&offset=${offset}`,
+    `/api/transactions?limit=${limit}&offset=${offset}${tagParam}${queryParam}`,
     swrFetcher,
     poll ? pollConfiguration : undefined
   );
@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { TransactionsFeed } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useTransactionsFeed() {
+  return useSWR<TransactionsFeed>("/api/transactions-feed", swrFetcher);
+}
@@ -0,0 +1,11 @@
+import useSWR from "swr";
+
+import { WalletSummary } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useWalletSummary() {
+  return useSWR<WalletSummary>("/api/wallet/summary", swrFetcher, {
+    // the summary is cached by the hub for the same time
+    refreshInterval: 30000,
+  });
+}
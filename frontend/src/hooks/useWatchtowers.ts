@@ -0,0 +1,8 @@
+import useSWR from "swr";
+
+import { Watchtower } from "src/types";
+import { swrFetcher } from "src/utils/swr";
+
+export function useWatchtowers() {
+  return useSWR<Watchtower[]>("/api/watchtowers", swrFetcher);
+}
@@ -4,6 +4,8 @@ type BackendTypeConfig = {
   hasMnemonic: boolean;
   hasChannelManagement: boolean;
   hasNodeBackup: boolean;
+  hasChannelBackup: boolean;
+  hasWatchtowers: boolean;
 };
 
 export const backendTypeConfigs: Record<BackendType, BackendTypeConfig> = {
@@ -11,30 +13,56 @@ export const backendTypeConfigs: Record<BackendType, BackendTypeConfig> = {
     hasMnemonic: false,
     hasChannelManagement: true,
     hasNodeBackup: false,
+    hasChannelBackup: true,
+    hasWatchtowers: true,
   },
   BREEZ: {
     hasMnemonic: true,
     hasChannelManagement: false,
     hasNodeBackup: false,
+    hasChannelBackup: false,
+    hasWatchtowers: false,
   },
   GREENLIGHT: {
     hasMnemonic: true,
     hasChannelManagement: true,
     hasNodeBackup: false,
+    hasChannelBackup: false,
+    hasWatchtowers: false,
   },
   LDK: {
     hasMnemonic: true,
     hasChannelManagement: true,
     hasNodeBackup: true,
+    hasChannelBackup: true,
+    hasWatchtowers: false,
   },
   PHOENIX: {
     hasMnemonic: false,
     hasChannelManagement: false,
     hasNodeBackup: false,
+    hasChannelBackup: false,
+    hasWatchtowers: false,
   },
   CASHU: {
     hasMnemonic: false,
     hasChannelManagement: false,
     hasNodeBackup: false,
+    hasChannelBackup: false,
+    hasWatchtowers: false,
+  },
+  BTCPAY: {
+    hasMnemonic: false,
+    hasChannelManagement: false,
+    hasNodeBackup: false,
+    hasChannelBackup: false,
+    hasWatchtowers: false,
+  },
+  NWC: {
+    hasMnemonic: false,
+    hasChannelManagement: false,
+    hasNodeBackup: false,
+    hasChannelBackup: false,
+    hasWatchtowers: false,
   },
 };
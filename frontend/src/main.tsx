@@ -3,6 +3,9 @@ import ReactDOM from "react-dom/client";
 import App from "src/App.tsx";
 import "src/index.css";
 import "src/fonts.css";
+import { registerServiceWorker } from "src/utils/pwa";
+
+registerServiceWorker();
 
 ReactDOM.createRoot(document.getElementById("root")!).render(
   <React.StrictMode>
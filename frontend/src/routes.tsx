@@ -10,6 +10,7 @@ import { StartRedirect } from "src/components/redirects/StartRedirect";
 import { BackupMnemonic } from "src/screens/BackupMnemonic";
 import { BackupNode } from "src/screens/BackupNode";
 import { BackupNodeSuccess } from "src/screens/BackupNodeSuccess";
+import ErrorPage from "src/screens/ErrorPage";
 import Home from "src/screens/Home";
 import { Intro } from "src/screens/Intro";
 import NotFound from "src/screens/NotFound";
@@ -32,11 +33,21 @@ import { OpeningFirstChannel } from "src/screens/channels/first/OpeningFirstChan
 import { Success } from "src/screens/onboarding/Success";
 import BuyBitcoin from "src/screens/onchain/BuyBitcoin";
 import DepositBitcoin from "src/screens/onchain/DepositBitcoin";
+import SendBitcoin from "src/screens/onchain/SendBitcoin";
 import ConnectPeer from "src/screens/peers/ConnectPeer";
 import Peers from "src/screens/peers/Peers";
 import { AlbyAccount } from "src/screens/settings/AlbyAccount";
+import { AppUsage } from "src/screens/settings/AppUsage";
 import { ChangeUnlockPassword } from "src/screens/settings/ChangeUnlockPassword";
+import { ChannelBackup } from "src/screens/settings/ChannelBackup";
 import DebugTools from "src/screens/settings/DebugTools";
+import { IdentityKey } from "src/screens/settings/IdentityKey";
+import { PaymentConfirmationAuthenticator } from "src/screens/settings/PaymentConfirmationAuthenticator";
+import { Reports } from "src/screens/settings/Reports";
+import { Sessions } from "src/screens/settings/Sessions";
+import { TagRules } from "src/screens/settings/TagRules";
+import { TransactionsFeed } from "src/screens/settings/TransactionsFeed";
+import { Watchtowers } from "src/screens/settings/Watchtowers";
 import Settings from "src/screens/settings/Settings";
 import { ImportMnemonic } from "src/screens/setup/ImportMnemonic";
 import { RestoreNode } from "src/screens/setup/RestoreNode";
@@ -44,14 +55,18 @@ import { SetupAdvanced } from "src/screens/setup/SetupAdvanced";
 import { SetupFinish } from "src/screens/setup/SetupFinish";
 import { SetupNode } from "src/screens/setup/SetupNode";
 import { SetupPassword } from "src/screens/setup/SetupPassword";
+import { BTCPayForm } from "src/screens/setup/node/BTCPayForm";
 import { BreezForm } from "src/screens/setup/node/BreezForm";
 import { CashuForm } from "src/screens/setup/node/CashuForm";
 import { GreenlightForm } from "src/screens/setup/node/GreenlightForm";
 import { LDKForm } from "src/screens/setup/node/LDKForm";
 import { LNDForm } from "src/screens/setup/node/LNDForm";
+import { NWCForm } from "src/screens/setup/node/NWCForm";
 import { PhoenixdForm } from "src/screens/setup/node/PhoenixdForm";
 import { PresetNodeForm } from "src/screens/setup/node/PresetNodeForm";
+import Swaps from "src/screens/swaps/Swaps";
 import Wallet from "src/screens/wallet";
+import Offers from "src/screens/wallet/Offers";
 import Receive from "src/screens/wallet/Receive";
 import Send from "src/screens/wallet/Send";
 import SignMessage from "src/screens/wallet/SignMessage";
@@ -60,6 +75,7 @@ const routes = [
   {
     path: "/",
     element: <AppLayout />,
+    errorElement: <ErrorPage />,
     handle: { crumb: () => "Home" },
     children: [
       {
@@ -91,6 +107,11 @@ const routes = [
             element: <Receive />,
             handle: { crumb: () => "Receive" },
           },
+          {
+            path: "offers",
+            element: <Offers />,
+            handle: { crumb: () => "Offers" },
+          },
           {
             path: "send",
             element: <Send />,
@@ -121,6 +142,41 @@ const routes = [
                 element: <ChangeUnlockPassword />,
                 handle: { crumb: () => "Unlock Password" },
               },
+              {
+                path: "identity-key",
+                element: <IdentityKey />,
+                handle: { crumb: () => "Identity Key" },
+              },
+              {
+                path: "payment-confirmations",
+                element: <PaymentConfirmationAuthenticator />,
+                handle: { crumb: () => "Payment Confirmations" },
+              },
+              {
+                path: "app-usage",
+                element: <AppUsage />,
+                handle: { crumb: () => "App Usage" },
+              },
+              {
+                path: "reports",
+                element: <Reports />,
+                handle: { crumb: () => "Reports" },
+              },
+              {
+                path: "sessions",
+                element: <Sessions />,
+                handle: { crumb: () => "Sessions" },
+              },
+              {
+                path: "tags",
+                element: <TagRules />,
+                handle: { crumb: () => "Tags" },
+              },
+              {
+                path: "transactions-feed",
+                element: <TransactionsFeed />,
+                handle: { crumb: () => "Transactions Feed" },
+              },
               {
                 path: "key-backup",
                 element: <BackupMnemonic />,
@@ -130,6 +186,16 @@ const routes = [
                 path: "node-backup",
                 element: <BackupNode />,
               },
+              {
+                path: "channel-backup",
+                element: <ChannelBackup />,
+                handle: { crumb: () => "Channel Backup" },
+              },
+              {
+                path: "watchtowers",
+                element: <Watchtowers />,
+                handle: { crumb: () => "Watchtowers" },
+              },
               {
                 path: "alby-account",
                 element: <AlbyAccount />,
@@ -223,6 +289,16 @@ const routes = [
             element: <DepositBitcoin />,
             handle: { crumb: () => "Deposit Bitcoin" },
           },
+          {
+            path: "onchain/send-bitcoin",
+            element: <SendBitcoin />,
+            handle: { crumb: () => "Send Bitcoin" },
+          },
+          {
+            path: "swaps",
+            element: <Swaps />,
+            handle: { crumb: () => "Swaps" },
+          },
         ],
       },
       {
@@ -256,6 +332,7 @@ const routes = [
   },
   {
     element: <TwoColumnFullScreenLayout />,
+    errorElement: <ErrorPage />,
     children: [
       {
         path: "start",
@@ -311,6 +388,14 @@ const routes = [
                 path: "phoenix",
                 element: <PhoenixdForm />,
               },
+              {
+                path: "btcpay",
+                element: <BTCPayForm />,
+              },
+              {
+                path: "nwc",
+                element: <NWCForm />,
+              },
               {
                 path: "lnd",
                 element: <LNDForm />,
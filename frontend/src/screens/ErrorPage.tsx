@@ -0,0 +1,46 @@
+import { AlertTriangleIcon } from "lucide-react";
+import { useRouteError } from "react-router-dom";
+import { Button } from "src/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "src/components/ui/card";
+
+function ErrorPage() {
+  const error = useRouteError();
+  console.error("Failed to render page", error);
+
+  return (
+    <div className="flex min-h-screen w-full items-center justify-center p-4">
+      <Card className="max-w-lg">
+        <CardHeader>
+          <CardTitle>
+            <div className="flex flex-row items-center gap-2">
+              <AlertTriangleIcon className="w-10 h-10" />
+              Something went wrong
+            </div>
+          </CardTitle>
+          <CardDescription>
+            This page could not be shown. Please try again, or ask for help in
+            our community if the problem persists.
+          </CardDescription>
+        </CardHeader>
+        <CardContent className="flex flex-col gap-4">
+          {error instanceof Error && (
+            <pre className="text-xs whitespace-pre-wrap break-all text-muted-foreground">
+              {error.message}
+            </pre>
+          )}
+          <a href="/">
+            <Button variant="outline">Return Home</Button>
+          </a>
+        </CardContent>
+      </Card>
+    </div>
+  );
+}
+
+export default ErrorPage;
@@ -1,8 +1,10 @@
 import { ExternalLinkIcon } from "lucide-react";
 import AlbyHead from "src/assets/images/alby-head.svg";
+import ActivityFeed from "src/components/ActivityFeed";
 import AppHeader from "src/components/AppHeader";
 import ExternalLink from "src/components/ExternalLink";
 import Loading from "src/components/Loading";
+import WalletSummaryCard from "src/components/WalletSummaryCard";
 import { Button } from "src/components/ui/button";
 import {
   Card,
@@ -47,6 +49,7 @@ function Home() {
     <>
       <AppHeader title={getGreeting(albyMe?.name)} description="" />
       <OnboardingChecklist />
+      <WalletSummaryCard />
       <div className="grid grid-cols-1 lg:grid-cols-2 gap-5">
         <ExternalLink to="https://www.getalby.com/dashboard">
           <Card>
@@ -108,6 +111,7 @@ function Home() {
           </ExternalLink>
         )}
       </div>
+      <ActivityFeed />
     </>
   );
 }
@@ -20,29 +20,42 @@ import { copyToClipboard } from "src/lib/clipboard";
 import { CreateAppResponse } from "src/types";
 
 export default function AppCreated() {
-  const { state } = useLocation();
+  const { pathname, search, state } = useLocation();
   const navigate = useNavigate();
-  const createAppResponse = state as CreateAppResponse | undefined;
+  // the secret is only kept in memory so that it is shown exactly once and
+  // cannot be revealed again by going back or reloading the page
+  const [createAppResponse] = useState(state as CreateAppResponse | undefined);
+
+  useEffect(() => {
+    if (createAppResponse?.pairingUri) {
+      navigate(pathname + search, { replace: true, state: null });
+    }
+  }, [createAppResponse, navigate, pathname, search]);
+
   if (!createAppResponse?.pairingUri) {
-    navigate("/");
-    return null;
+    return <Navigate to="/" />;
   }
 
-  return <AppCreatedInternal />;
+  return <AppCreatedInternal createAppResponse={createAppResponse} />;
 }
-function AppCreatedInternal() {
-  const { search, state } = useLocation();
+
+type AppCreatedInternalProps = {
+  createAppResponse: CreateAppResponse;
+};
+
+function AppCreatedInternal({ createAppResponse }: AppCreatedInternalProps) {
+  const { search } = useLocation();
   const navigate = useNavigate();
   const { toast } = useToast();
 
   const queryParams = new URLSearchParams(search);
   const appId = queryParams.get("app") ?? "";
   const appstoreApp = suggestedApps.find((app) => app.id === appId);
+  const origin = queryParams.get("origin") ?? "";
 
   const [timeout, setTimeout] = useState(false);
   const [isQRCodeVisible, setIsQRCodeVisible] = useState(false);
 
-  const createAppResponse = state as CreateAppResponse;
   const pairingUri = createAppResponse.pairingUri;
   const { data: app } = useApp(createAppResponse.pairingPublicKey, true);
 
@@ -88,11 +101,19 @@ function AppCreatedInternal() {
         "*"
       );
     }
-  }, [appstoreApp]);
-
-  if (!createAppResponse) {
-    return <Navigate to="/apps/new" />;
-  }
+    // the connect widget receives the connection itself. The browser only
+    // delivers the message if the opener is still on the approved origin.
+    if (window.opener && origin) {
+      window.opener.postMessage(
+        {
+          type: "nwc:success",
+          payload: { success: true, nostrWalletConnectUrl: pairingUri },
+        },
+        origin
+      );
+      window.close();
+    }
+  }, [appstoreApp, origin, pairingUri]);
 
   return (
     <>
@@ -118,6 +139,10 @@ function AppCreatedInternal() {
             in settings)
           </p>
           <p>2. Scan or paste the connection secret</p>
+          <p className="text-sm text-muted-foreground mt-2">
+            The connection secret is only shown once. If you lose it, you can
+            regenerate it on the connection page.
+          </p>
         </div>
         <Card className="max-w-sm">
           <CardHeader>
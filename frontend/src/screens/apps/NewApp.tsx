@@ -55,6 +55,8 @@ const NewAppInternal = ({ capabilities }: NewAppInternalProps) => {
 
   const pubkey = queryParams.get("pubkey") ?? "";
   const returnTo = queryParams.get("return_to") ?? "";
+  // set by the connect widget (public/connect.js) of the site that opened the hub
+  const origin = parseOrigin(queryParams.get("origin") ?? "");
 
   const nameParam = (queryParams.get("name") || queryParams.get("c")) ?? "";
   const [appName, setAppName] = useState(app ? app.title : nameParam);
@@ -125,7 +127,10 @@ const NewAppInternal = ({ capabilities }: NewAppInternalProps) => {
     if (requestMethodsSet.has("get_balance")) {
       scopes.push("get_balance");
     }
-    if (requestMethodsSet.has("make_invoice")) {
+    if (
+      requestMethodsSet.has("make_invoice") ||
+      requestMethodsSet.has("make_offer")
+    ) {
       scopes.push("make_invoice");
     }
     if (requestMethodsSet.has("lookup_invoice")) {
@@ -213,7 +218,15 @@ const NewAppInternal = ({ capabilities }: NewAppInternalProps) => {
         window.location.href = createAppResponse.returnTo;
         return;
       }
-      navigate(`/apps/created${app ? `?app=${app.id}` : ""}`, {
+      const createdParams = new URLSearchParams();
+      if (app) {
+        createdParams.set("app", app.id);
+      }
+      if (origin) {
+        createdParams.set("origin", origin);
+      }
+      const createdQuery = createdParams.toString();
+      navigate(`/apps/created${createdQuery ? `?${createdQuery}` : ""}`, {
         state: createAppResponse,
       });
       toast({ title: "App created" });
@@ -281,6 +294,11 @@ const NewAppInternal = ({ capabilities }: NewAppInternalProps) => {
         </div>
 
         <Separator />
+        {origin && (
+          <p className="text-xs text-muted-foreground">
+            The connection will be sent to {origin}
+          </p>
+        )}
         {returnTo && (
           <p className="text-xs text-muted-foreground">
             You will automatically return to {returnTo}
@@ -293,4 +311,16 @@ const NewAppInternal = ({ capabilities }: NewAppInternalProps) => {
   );
 };
 
+function parseOrigin(origin: string): string | undefined {
+  try {
+    const url = new URL(origin);
+    if (url.protocol !== "https:" && url.protocol !== "http:") {
+      return undefined;
+    }
+    return url.origin;
+  } catch {
+    return undefined;
+  }
+}
+
 export default NewApp;
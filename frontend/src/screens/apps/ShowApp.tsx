@@ -8,6 +8,7 @@ import {
   App,
   AppPermissions,
   BudgetRenewalType,
+  CreateAppResponse,
   UpdateAppRequest,
   WalletCapabilities,
 } from "src/types";
@@ -17,6 +18,8 @@ import { request } from "src/utils/request"; // build the project for this to ap
 
 import AppAvatar from "src/components/AppAvatar";
 import AppHeader from "src/components/AppHeader";
+import { BudgetForecastAlert } from "src/components/BudgetForecastAlert";
+import AppRequests from "src/components/connections/AppRequests";
 import Loading from "src/components/Loading";
 import Permissions from "src/components/Permissions";
 import {
@@ -41,6 +44,7 @@ import { Table, TableBody, TableCell, TableRow } from "src/components/ui/table";
 import { useToast } from "src/components/ui/use-toast";
 import { useCapabilities } from "src/hooks/useCapabilities";
 import { formatAmount } from "src/lib/utils";
+import NotFound from "src/screens/NotFound";
 
 function ShowApp() {
   const { pubkey } = useParams() as { pubkey: string };
@@ -48,6 +52,9 @@ function ShowApp() {
   const { data: capabilities } = useCapabilities();
 
   if (error) {
+    if (error.message.startsWith("404")) {
+      return <NotFound />;
+    }
     return <p className="text-red-500">{error.message}</p>;
   }
 
@@ -124,6 +131,36 @@ function AppInternal({ app, refetchApp, capabilities }: AppInternalProps) {
     }
   };
 
+  const regenerateSecret = async () => {
+    try {
+      if (!csrf) {
+        throw new Error("No CSRF token");
+      }
+
+      const createAppResponse = await request<CreateAppResponse>(
+        `/api/apps/${app.nostrPubkey}/regenerate-secret`,
+        {
+          method: "POST",
+          headers: {
+            "X-CSRF-Token": csrf,
+          },
+        }
+      );
+      if (!createAppResponse) {
+        throw new Error("no regenerate secret response received");
+      }
+
+      navigate("/apps/created", { state: createAppResponse });
+      toast({ title: "Connection secret regenerated" });
+    } catch (error) {
+      handleRequestError(
+        toast,
+        "Failed to regenerate connection secret",
+        error
+      );
+    }
+  };
+
   return (
     <>
       <div className="w-full">
@@ -141,29 +178,52 @@ function AppInternal({ app, refetchApp, capabilities }: AppInternalProps) {
               </div>
             }
             contentRight={
-              <AlertDialog>
-                <AlertDialogTrigger asChild>
-                  <Button variant="destructive">Delete</Button>
-                </AlertDialogTrigger>
-                <AlertDialogContent>
-                  <AlertDialogHeader>
-                    <AlertDialogTitle>Are you sure?</AlertDialogTitle>
-                    <AlertDialogDescription>
-                      This will revoke the permission and will no longer allow
-                      calls from this public key.
-                    </AlertDialogDescription>
-                  </AlertDialogHeader>
-                  <AlertDialogFooter>
-                    <AlertDialogCancel>Cancel</AlertDialogCancel>
-                    <AlertDialogAction
-                      onClick={() => deleteApp(app.nostrPubkey)}
-                      disabled={isDeleting}
-                    >
-                      Continue
-                    </AlertDialogAction>
-                  </AlertDialogFooter>
-                </AlertDialogContent>
-              </AlertDialog>
+              <div className="flex flex-row gap-2">
+                <AlertDialog>
+                  <AlertDialogTrigger asChild>
+                    <Button variant="outline">Regenerate Secret</Button>
+                  </AlertDialogTrigger>
+                  <AlertDialogContent>
+                    <AlertDialogHeader>
+                      <AlertDialogTitle>Regenerate secret?</AlertDialogTitle>
+                      <AlertDialogDescription>
+                        A new connection secret will be created and the current
+                        one will stop working. You will need to connect the app
+                        again with the new secret.
+                      </AlertDialogDescription>
+                    </AlertDialogHeader>
+                    <AlertDialogFooter>
+                      <AlertDialogCancel>Cancel</AlertDialogCancel>
+                      <AlertDialogAction onClick={regenerateSecret}>
+                        Continue
+                      </AlertDialogAction>
+                    </AlertDialogFooter>
+                  </AlertDialogContent>
+                </AlertDialog>
+                <AlertDialog>
+                  <AlertDialogTrigger asChild>
+                    <Button variant="destructive">Delete</Button>
+                  </AlertDialogTrigger>
+                  <AlertDialogContent>
+                    <AlertDialogHeader>
+                      <AlertDialogTitle>Are you sure?</AlertDialogTitle>
+                      <AlertDialogDescription>
+                        This will revoke the permission and will no longer allow
+                        calls from this public key.
+                      </AlertDialogDescription>
+                    </AlertDialogHeader>
+                    <AlertDialogFooter>
+                      <AlertDialogCancel>Cancel</AlertDialogCancel>
+                      <AlertDialogAction
+                        onClick={() => deleteApp(app.nostrPubkey)}
+                        disabled={isDeleting}
+                      >
+                        Continue
+                      </AlertDialogAction>
+                    </AlertDialogFooter>
+                  </AlertDialogContent>
+                </AlertDialog>
+              </div>
             }
             description={""}
           />
@@ -249,6 +309,7 @@ function AppInternal({ app, refetchApp, capabilities }: AppInternalProps) {
                 </CardTitle>
               </CardHeader>
               <CardContent>
+                <BudgetForecastAlert app={app} />
                 <Permissions
                   capabilities={capabilities}
                   permissions={permissions}
@@ -260,6 +321,8 @@ function AppInternal({ app, refetchApp, capabilities }: AppInternalProps) {
               </CardContent>
             </Card>
           )}
+
+          <AppRequests pubkey={app.nostrPubkey} />
         </div>
       </div>
     </>
@@ -55,6 +55,7 @@ import {
 } from "src/constants.ts";
 import { useAlbyBalance } from "src/hooks/useAlbyBalance.ts";
 import { useBalances } from "src/hooks/useBalances.ts";
+import { useChannelBackupStatus } from "src/hooks/useChannelBackupStatus";
 import { useChannels } from "src/hooks/useChannels";
 import { useInfo } from "src/hooks/useInfo";
 import { useIsDesktop } from "src/hooks/useMediaQuery.ts";
@@ -77,6 +78,7 @@ export default function Channels() {
   const { data: channels, mutate: reloadChannels } = useChannels();
   const { data: nodeConnectionInfo } = useNodeConnectionInfo();
   const { data: balances } = useBalances();
+  const { data: channelBackupStatus } = useChannelBackupStatus();
   const { data: albyBalance, mutate: reloadAlbyBalance } = useAlbyBalance();
   const [nodes, setNodes] = React.useState<Node[]>([]);
   const { mutate: reloadInfo } = useInfo();
@@ -210,6 +212,15 @@ export default function Channels() {
 
       const forwardingFeeBaseMsat = +forwardingFeeBaseSats * 1000;
 
+      const forwardingFeeProportionalMillionths = prompt(
+        "Enter proportional forwarding fee in ppm (parts per million)",
+        channel.forwardingFeeProportionalMillionths.toString()
+      );
+
+      if (!forwardingFeeProportionalMillionths) {
+        return;
+      }
+
       console.info(
         `🎬 Updating channel ${channel.id} with ${channel.remotePubkey}`
       );
@@ -224,6 +235,8 @@ export default function Channels() {
           },
           body: JSON.stringify({
             forwardingFeeBaseMsat: forwardingFeeBaseMsat,
+            forwardingFeeProportionalMillionths:
+              +forwardingFeeProportionalMillionths,
           } as UpdateChannelRequest),
         }
       );
@@ -321,6 +334,21 @@ export default function Channels() {
                       Deposit Bitcoin
                     </Link>
                   </DropdownMenuItem>
+                  {(balances?.onchain.spendable || 0) > ONCHAIN_DUST_SATS && (
+                    <DropdownMenuItem>
+                      <Link
+                        to="/channels/onchain/send-bitcoin"
+                        className="w-full"
+                      >
+                        Send Bitcoin
+                      </Link>
+                    </DropdownMenuItem>
+                  )}
+                  <DropdownMenuItem>
+                    <Link to="/channels/swaps" className="w-full">
+                      Swap
+                    </Link>
+                  </DropdownMenuItem>
                   {(balances?.onchain.spendable || 0) > ONCHAIN_DUST_SATS && (
                     <DropdownMenuItem
                       onClick={redeemOnchainFunds.redeemFunds}
@@ -427,6 +455,19 @@ export default function Channels() {
               </AlertDescription>
             </Alert>
           )}
+
+          {channelBackupStatus?.outdated && (
+            <Alert>
+              <AlertTriangle className="h-4 w-4" />
+              <AlertTitle>Channel backup outdated</AlertTitle>
+              <AlertDescription>
+                Your channels changed since your last channel backup.{" "}
+                <Link className="underline" to="/settings/channel-backup">
+                  Download a new channel backup.
+                </Link>
+              </AlertDescription>
+            </Alert>
+          )}
         </>
       )}
 
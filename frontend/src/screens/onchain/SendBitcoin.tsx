@@ -0,0 +1,174 @@
+import React from "react";
+import AppHeader from "src/components/AppHeader";
+import ExternalLink from "src/components/ExternalLink";
+import Loading from "src/components/Loading";
+import { Checkbox } from "src/components/ui/checkbox";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { useToast } from "src/components/ui/use-toast";
+import { useBalances } from "src/hooks/useBalances";
+import { useCSRF } from "src/hooks/useCSRF";
+import {
+  RedeemOnchainFundsRequest,
+  RedeemOnchainFundsResponse,
+} from "src/types";
+import { request } from "src/utils/request";
+
+export default function SendBitcoin() {
+  const { data: csrf } = useCSRF();
+  const { data: balances, mutate: reloadBalances } = useBalances();
+  const { toast } = useToast();
+  const [isLoading, setLoading] = React.useState(false);
+  const [toAddress, setToAddress] = React.useState("");
+  const [amount, setAmount] = React.useState("");
+  const [feeRate, setFeeRate] = React.useState("");
+  const [sendAll, setSendAll] = React.useState(false);
+  const [txId, setTxId] = React.useState("");
+
+  if (!balances) {
+    return <Loading />;
+  }
+
+  const handleSubmit = async (event: React.FormEvent<HTMLFormElement>) => {
+    event.preventDefault();
+    if (!csrf) {
+      throw new Error("csrf not loaded");
+    }
+    if (
+      !confirm(
+        "Are you sure you want to send your onchain funds to this address? If you send to an address you do not own, your funds will be lost."
+      )
+    ) {
+      return;
+    }
+    try {
+      setLoading(true);
+      const response = await request<RedeemOnchainFundsResponse>(
+        "/api/wallet/redeem-onchain-funds",
+        {
+          method: "POST",
+          headers: {
+            "X-CSRF-Token": csrf,
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({
+            toAddress: toAddress.trim(),
+            amount: sendAll ? undefined : +amount,
+            feeRate: feeRate ? +feeRate : undefined,
+            sendAll,
+          } as RedeemOnchainFundsRequest),
+        }
+      );
+      if (!response?.txId) {
+        throw new Error("No transaction id in response");
+      }
+      setTxId(response.txId);
+      setToAddress("");
+      setAmount("");
+      toast({ title: "Successfully sent bitcoin" });
+      await reloadBalances();
+    } catch (e) {
+      toast({
+        variant: "destructive",
+        title: "Failed to send bitcoin: " + e,
+      });
+      console.error(e);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="grid gap-5">
+      <AppHeader
+        title="Send Bitcoin"
+        description="Send bitcoin from your savings balance to an on-chain address"
+      />
+      <div className="max-w-lg">
+        <form onSubmit={handleSubmit} className="grid gap-5">
+          <div>
+            <Label htmlFor="to-address">Address</Label>
+            <Input
+              id="to-address"
+              type="text"
+              value={toAddress}
+              placeholder="bc1..."
+              required
+              onChange={(e) => {
+                setToAddress(e.target.value);
+                setTxId("");
+              }}
+            />
+          </div>
+          <div>
+            <Label htmlFor="amount">Amount (sats)</Label>
+            <Input
+              id="amount"
+              type="number"
+              value={sendAll ? balances.onchain.spendable : amount}
+              min={1}
+              max={balances.onchain.spendable}
+              required={!sendAll}
+              disabled={sendAll}
+              onChange={(e) => setAmount(e.target.value)}
+            />
+            <p className="text-xs text-muted-foreground mt-2">
+              Spendable:{" "}
+              {new Intl.NumberFormat().format(balances.onchain.spendable)} sats
+            </p>
+          </div>
+          <div className="flex items-center">
+            <Checkbox
+              id="send-all"
+              checked={sendAll}
+              onCheckedChange={() => setSendAll(!sendAll)}
+            />
+            <Label htmlFor="send-all" className="ml-2">
+              Send entire savings balance
+            </Label>
+          </div>
+          <div>
+            <Label htmlFor="fee-rate">Fee rate (sat/vB)</Label>
+            <Input
+              id="fee-rate"
+              type="number"
+              value={feeRate}
+              min={1}
+              placeholder="Use the node's fee estimate"
+              onChange={(e) => setFeeRate(e.target.value)}
+            />
+            <p className="text-xs text-muted-foreground mt-2">
+              Check{" "}
+              <ExternalLink to="https://mempool.space" className="underline">
+                mempool.space
+              </ExternalLink>{" "}
+              for current fee rates. Not all node backends support custom fee
+              rates.
+            </p>
+          </div>
+          <div>
+            <LoadingButton
+              loading={isLoading}
+              type="submit"
+              disabled={!toAddress || (!sendAll && !amount)}
+            >
+              Send
+            </LoadingButton>
+          </div>
+          {txId && (
+            <p className="text-sm">
+              Transaction sent:{" "}
+              <ExternalLink
+                to={`https://mempool.space/tx/${txId}`}
+                className="underline break-all"
+              >
+                {txId}
+              </ExternalLink>
+            </p>
+          )}
+        </form>
+      </div>
+    </div>
+  );
+}
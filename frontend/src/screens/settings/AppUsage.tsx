@@ -0,0 +1,190 @@
+import React from "react";
+
+import Container from "src/components/Container";
+import Loading from "src/components/Loading";
+import SettingsHeader from "src/components/SettingsHeader";
+import { Label } from "src/components/ui/label";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "src/components/ui/select";
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "src/components/ui/table";
+import { useNip47Usage } from "src/hooks/useNip47Usage";
+import { Nip47Usage } from "src/types";
+
+const PERIODS = [7, 30, 90];
+
+function formatErrorRate(requests: number, errors: number) {
+  if (!requests) {
+    return "-";
+  }
+  return `${Math.round((errors / requests) * 100)}%`;
+}
+
+function UsageChart({ days }: { days: Nip47Usage["days"] }) {
+  const maxRequests = Math.max(1, ...days.map((day) => day.requests));
+  return (
+    <div className="grid gap-1.5">
+      <div className="flex flex-row items-end gap-px h-32">
+        {days.map((day) => (
+          <div
+            key={day.date}
+            className="flex-1 flex flex-col justify-end h-full"
+            title={`${day.date}: ${day.requests} requests, ${day.errors} errors`}
+          >
+            <div
+              className="bg-destructive"
+              style={{ height: `${(day.errors / maxRequests) * 100}%` }}
+            />
+            <div
+              className="bg-primary"
+              style={{
+                height: `${((day.requests - day.errors) / maxRequests) * 100}%`,
+              }}
+            />
+          </div>
+        ))}
+      </div>
+      <div className="flex flex-row justify-between text-xs text-muted-foreground">
+        <span>{days[0]?.date}</span>
+        <span>{days[days.length - 1]?.date}</span>
+      </div>
+    </div>
+  );
+}
+
+export function AppUsage() {
+  const [days, setDays] = React.useState(30);
+  const { data: usage } = useNip47Usage(days);
+
+  return (
+    <>
+      <SettingsHeader
+        title="App Usage"
+        description="See which connected apps and methods send the most requests
+          to your hub and which of them fail."
+      />
+      <Container>
+        <div className="w-full flex flex-col gap-5">
+          <div className="grid gap-1.5">
+            <Label htmlFor="usage-period">Period</Label>
+            <Select
+              value={days.toString()}
+              onValueChange={(value) => setDays(parseInt(value))}
+            >
+              <SelectTrigger id="usage-period" className="w-[200px]">
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                {PERIODS.map((period) => (
+                  <SelectItem key={period} value={period.toString()}>
+                    Last {period} days
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+          </div>
+          {!usage ? (
+            <Loading />
+          ) : !usage.methods.length ? (
+            <p className="text-sm text-muted-foreground">
+              No requests in this period.
+            </p>
+          ) : (
+            <>
+              <UsageChart days={usage.days} />
+              <Table>
+                <TableHeader>
+                  <TableRow>
+                    <TableHead>Method</TableHead>
+                    <TableHead className="text-right">Requests</TableHead>
+                    <TableHead className="text-right">Errors</TableHead>
+                    <TableHead className="text-right">Error rate</TableHead>
+                  </TableRow>
+                </TableHeader>
+                <TableBody>
+                  {usage.methods.map((method) => (
+                    <TableRow key={method.method}>
+                      <TableCell className="font-mono">
+                        {method.method}
+                      </TableCell>
+                      <TableCell className="text-right">
+                        {method.requests}
+                      </TableCell>
+                      <TableCell className="text-right">
+                        {method.errors}
+                      </TableCell>
+                      <TableCell className="text-right">
+                        {formatErrorRate(method.requests, method.errors)}
+                      </TableCell>
+                    </TableRow>
+                  ))}
+                </TableBody>
+              </Table>
+              <Table>
+                <TableHeader>
+                  <TableRow>
+                    <TableHead>App</TableHead>
+                    <TableHead className="text-right">Requests</TableHead>
+                    <TableHead className="text-right">Errors</TableHead>
+                    <TableHead className="text-right">Error rate</TableHead>
+                  </TableRow>
+                </TableHeader>
+                <TableBody>
+                  {usage.apps.map((app) => (
+                    <TableRow key={app.appId ?? "unknown"}>
+                      <TableCell>
+                        {app.appId === null
+                          ? "Unknown connections"
+                          : app.appName || "Deleted app"}
+                      </TableCell>
+                      <TableCell className="text-right">
+                        {app.requests}
+                      </TableCell>
+                      <TableCell className="text-right">{app.errors}</TableCell>
+                      <TableCell className="text-right">
+                        {formatErrorRate(app.requests, app.errors)}
+                      </TableCell>
+                    </TableRow>
+                  ))}
+                </TableBody>
+              </Table>
+              {usage.errorCodes.length > 0 && (
+                <Table>
+                  <TableHeader>
+                    <TableRow>
+                      <TableHead>Error code</TableHead>
+                      <TableHead className="text-right">Requests</TableHead>
+                    </TableRow>
+                  </TableHeader>
+                  <TableBody>
+                    {usage.errorCodes.map((errorCode) => (
+                      <TableRow key={errorCode.code}>
+                        <TableCell className="font-mono">
+                          {errorCode.code}
+                        </TableCell>
+                        <TableCell className="text-right">
+                          {errorCode.count}
+                        </TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              )}
+            </>
+          )}
+        </div>
+      </Container>
+    </>
+  );
+}
@@ -0,0 +1,192 @@
+import { AlertTriangleIcon } from "lucide-react";
+import React from "react";
+
+import Container from "src/components/Container";
+import SettingsHeader from "src/components/SettingsHeader";
+import { Alert, AlertDescription, AlertTitle } from "src/components/ui/alert";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { useToast } from "src/components/ui/use-toast";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useChannelBackupStatus } from "src/hooks/useChannelBackupStatus";
+import { VerifyChannelBackupResponse } from "src/types";
+import { handleRequestError } from "src/utils/handleRequestError";
+import { request } from "src/utils/request";
+
+export function ChannelBackup() {
+  const { data: csrf } = useCSRF();
+  const { data: status, mutate: reloadStatus } = useChannelBackupStatus();
+  const { toast } = useToast();
+
+  const [exporting, setExporting] = React.useState(false);
+  const [verifying, setVerifying] = React.useState(false);
+  const [backupFile, setBackupFile] = React.useState<File>();
+  const [verifiedBackup, setVerifiedBackup] =
+    React.useState<VerifyChannelBackupResponse>();
+
+  const exportBackup = async () => {
+    if (!csrf) {
+      throw new Error("No CSRF token");
+    }
+
+    const isHttpMode = window.location.protocol.startsWith("http");
+
+    try {
+      setExporting(true);
+
+      if (isHttpMode) {
+        const response = await fetch("/api/channel-backup/export", {
+          method: "POST",
+          headers: {
+            "X-CSRF-Token": csrf,
+          },
+        });
+
+        if (!response?.ok) {
+          throw new Error(`Error:${response?.statusText}`);
+        }
+        const blob = await response.blob();
+        const url = window.URL.createObjectURL(blob);
+        const a = document.createElement("a");
+        a.href = url;
+        a.download = "channel-backup.json";
+        document.body.appendChild(a);
+        a.click();
+        window.URL.revokeObjectURL(url);
+        a.remove();
+      } else {
+        await request("/api/channel-backup/export", {
+          method: "POST",
+          headers: {
+            "X-CSRF-Token": csrf,
+          },
+        });
+      }
+
+      await reloadStatus();
+      toast({ title: "Channel backup saved" });
+    } catch (error) {
+      handleRequestError(toast, "Failed to export channel backup", error);
+    } finally {
+      setExporting(false);
+    }
+  };
+
+  const verifyBackup = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!csrf) {
+      throw new Error("No CSRF token");
+    }
+    if (!backupFile) {
+      return;
+    }
+
+    try {
+      setVerifying(true);
+      setVerifiedBackup(undefined);
+      const response = await request<VerifyChannelBackupResponse>(
+        "/api/channel-backup/verify",
+        {
+          method: "POST",
+          headers: {
+            "X-CSRF-Token": csrf,
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({ backup: await backupFile.text() }),
+        }
+      );
+      setVerifiedBackup(response);
+    } catch (error) {
+      handleRequestError(toast, "Channel backup is not valid", error);
+    } finally {
+      setVerifying(false);
+    }
+  };
+
+  return (
+    <>
+      <SettingsHeader
+        title="Channel Backup"
+        description="Keep a backup of your channels so that the funds in them can be
+          recovered if the data of your node is lost. Export a new backup
+          whenever a channel is opened or closed."
+      />
+      <Container>
+        <div className="w-full flex flex-col gap-5">
+          {status?.outdated && (
+            <Alert>
+              <AlertTriangleIcon className="h-4 w-4" />
+              <AlertTitle>Your channel backup is outdated</AlertTitle>
+              <AlertDescription>
+                Your channels changed since the last backup. Channels which are
+                not part of a backup cannot be recovered.
+              </AlertDescription>
+            </Alert>
+          )}
+          <div className="grid gap-1.5">
+            <Label>Last backup</Label>
+            <p className="text-sm text-muted-foreground">
+              {status?.lastExportedAt
+                ? new Date(status.lastExportedAt).toLocaleString()
+                : "Never"}
+              {status &&
+                ` (${status.channelCount} ${
+                  status.channelCount === 1 ? "channel" : "channels"
+                } open)`}
+            </p>
+            {status?.autoBackupEnabled && (
+              <p className="text-sm text-muted-foreground">
+                A new backup is saved to the configured location automatically
+                when your channels change.
+              </p>
+            )}
+          </div>
+          <LoadingButton
+            loading={exporting}
+            onClick={exportBackup}
+            className="w-fit"
+          >
+            Download Channel Backup
+          </LoadingButton>
+          <form onSubmit={verifyBackup} className="w-full flex flex-col gap-3">
+            <div className="grid gap-1.5">
+              <Label htmlFor="backup-file">Verify a backup file</Label>
+              <Input
+                id="backup-file"
+                type="file"
+                accept=".json,application/json"
+                onChange={(e) => {
+                  setBackupFile(e.target.files?.[0]);
+                  setVerifiedBackup(undefined);
+                }}
+              />
+            </div>
+            <LoadingButton
+              loading={verifying}
+              disabled={!backupFile}
+              variant="secondary"
+              className="w-fit"
+            >
+              Verify Backup
+            </LoadingButton>
+          </form>
+          {verifiedBackup && (
+            <Alert>
+              <AlertTitle>Channel backup is valid</AlertTitle>
+              <AlertDescription>
+                The backup of {verifiedBackup.channelCount}{" "}
+                {verifiedBackup.channelCount === 1
+                  ? "channel"
+                  : "channels"}{" "}
+                was created on{" "}
+                {new Date(verifiedBackup.createdAt).toLocaleString()} and
+                belongs to this node.
+              </AlertDescription>
+            </Alert>
+          )}
+        </div>
+      </Container>
+    </>
+  );
+}
@@ -1,4 +1,5 @@
 import { useState } from "react";
+import { LogSettings } from "src/components/LogSettings";
 import { Button } from "src/components/ui/button";
 import { Textarea } from "src/components/ui/textarea";
 import { useCSRF } from "src/hooks/useCSRF";
@@ -42,6 +43,7 @@ export default function DebugTools() {
 
   return (
     <div>
+      <LogSettings />
       <div className="grid gap-6 m-8 md:grid-cols-3 xl:grid-cols-4">
         <Button
           onClick={() => {
@@ -0,0 +1,109 @@
+import React from "react";
+
+import Container from "src/components/Container";
+import SettingsHeader from "src/components/SettingsHeader";
+import { Alert, AlertDescription, AlertTitle } from "src/components/ui/alert";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { useToast } from "src/components/ui/use-toast";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useNostrKeys } from "src/hooks/useNostrKeys";
+import { NostrKeys } from "src/types";
+import { request } from "src/utils/request";
+
+export function IdentityKey() {
+  const { data: csrf } = useCSRF();
+  const { data: nostrKeys, mutate: reloadNostrKeys } = useNostrKeys();
+  const { toast } = useToast();
+
+  const [unlockPassword, setUnlockPassword] = React.useState("");
+  const [loading, setLoading] = React.useState(false);
+
+  const onSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+
+    if (
+      !confirm(
+        "Are you sure you want to rotate your identity key? New connections will use the new key. Existing connections keep working through the previous key until the grace period is over."
+      )
+    ) {
+      return;
+    }
+
+    try {
+      if (!csrf) {
+        throw new Error("No CSRF token");
+      }
+      setLoading(true);
+      const rotatedKeys = await request<NostrKeys>("/api/keys/rotate", {
+        method: "POST",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify({ unlockPassword }),
+      });
+      await reloadNostrKeys(rotatedKeys);
+      setUnlockPassword("");
+      toast({
+        title: "Successfully rotated identity key",
+        description:
+          "Reconnect your apps before the grace period of the previous key is over.",
+      });
+    } catch (error) {
+      toast({
+        title: "Key rotation failed",
+        description: (error as Error).message,
+        variant: "destructive",
+      });
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <>
+      <SettingsHeader
+        title="Identity Key"
+        description="Your apps connect to your hub through its identity key. Rotate
+          it if you think the key was compromised."
+      />
+      <Container>
+        <div className="w-full flex flex-col gap-3">
+          <div className="grid gap-1.5">
+            <Label>Current key</Label>
+            <p className="text-sm text-muted-foreground break-all">
+              {nostrKeys?.pubkey}
+            </p>
+          </div>
+          {nostrKeys?.previousPubkey && (
+            <Alert>
+              <AlertTitle>Migration in progress</AlertTitle>
+              <AlertDescription>
+                Requests to your previous key{" "}
+                <span className="break-all">{nostrKeys.previousPubkey}</span>{" "}
+                are still answered. Apps that support it move to the new key
+                automatically, all others need to be connected again.
+              </AlertDescription>
+            </Alert>
+          )}
+          <form onSubmit={onSubmit} className="w-full flex flex-col gap-3">
+            <div className="grid gap-1.5">
+              <Label htmlFor="unlock-password">Unlock Password</Label>
+              <Input
+                id="unlock-password"
+                type="password"
+                name="password"
+                onChange={(e) => setUnlockPassword(e.target.value)}
+                value={unlockPassword}
+                placeholder="Password"
+              />
+            </div>
+            <LoadingButton loading={loading}>Rotate Identity Key</LoadingButton>
+          </form>
+        </div>
+      </Container>
+    </>
+  );
+}
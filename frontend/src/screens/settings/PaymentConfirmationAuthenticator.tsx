@@ -0,0 +1,107 @@
+import React from "react";
+
+import Container from "src/components/Container";
+import QRCode from "src/components/QRCode";
+import SettingsHeader from "src/components/SettingsHeader";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { useToast } from "src/components/ui/use-toast";
+import { useCSRF } from "src/hooks/useCSRF";
+import { PaymentConfirmationAuthenticator as Authenticator } from "src/types";
+import { request } from "src/utils/request";
+
+export function PaymentConfirmationAuthenticator() {
+  const { data: csrf } = useCSRF();
+  const { toast } = useToast();
+
+  const [unlockPassword, setUnlockPassword] = React.useState("");
+  const [authenticator, setAuthenticator] = React.useState<Authenticator>();
+  const [loading, setLoading] = React.useState(false);
+
+  const onSubmit = async (e: React.FormEvent) => {
+    e.preventDefault();
+
+    if (
+      !confirm(
+        "Are you sure you want to set up a new authenticator? Codes of a previously set up authenticator will no longer approve payments."
+      )
+    ) {
+      return;
+    }
+
+    try {
+      if (!csrf) {
+        throw new Error("No CSRF token");
+      }
+      setLoading(true);
+      const newAuthenticator = await request<Authenticator>(
+        "/api/payment-confirmations/authenticator",
+        {
+          method: "POST",
+          headers: {
+            "X-CSRF-Token": csrf,
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify({ unlockPassword }),
+        }
+      );
+      setAuthenticator(newAuthenticator);
+      setUnlockPassword("");
+    } catch (error) {
+      toast({
+        title: "Failed to set up authenticator",
+        description: (error as Error).message,
+        variant: "destructive",
+      });
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <>
+      <SettingsHeader
+        title="Payment Confirmations"
+        description="App payments above the confirmation threshold are approved
+          with a code of your authenticator app."
+      />
+      <Container>
+        <div className="w-full flex flex-col gap-3">
+          {authenticator ? (
+            <div className="flex flex-col gap-3">
+              <p className="text-sm text-muted-foreground">
+                Scan the QR code with your authenticator app. It is only shown
+                once.
+              </p>
+              <QRCode value={authenticator.uri} />
+              <div className="grid gap-1.5">
+                <Label>Secret</Label>
+                <p className="text-sm text-muted-foreground break-all">
+                  {authenticator.secret}
+                </p>
+              </div>
+            </div>
+          ) : (
+            <form onSubmit={onSubmit} className="w-full flex flex-col gap-3">
+              <div className="grid gap-1.5">
+                <Label htmlFor="unlock-password">Unlock Password</Label>
+                <Input
+                  id="unlock-password"
+                  type="password"
+                  name="password"
+                  onChange={(e) => setUnlockPassword(e.target.value)}
+                  value={unlockPassword}
+                  placeholder="Password"
+                />
+              </div>
+              <LoadingButton loading={loading}>
+                Set Up Authenticator
+              </LoadingButton>
+            </form>
+          )}
+        </div>
+      </Container>
+    </>
+  );
+}
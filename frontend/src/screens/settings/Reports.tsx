@@ -0,0 +1,272 @@
+import React from "react";
+
+import Container from "src/components/Container";
+import Loading from "src/components/Loading";
+import SettingsHeader from "src/components/SettingsHeader";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "src/components/ui/select";
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "src/components/ui/table";
+import { useToast } from "src/components/ui/use-toast";
+import { useMonthlyReport } from "src/hooks/useMonthlyReport";
+import { MonthlyReport } from "src/types";
+import { handleRequestError } from "src/utils/handleRequestError";
+import { request } from "src/utils/request";
+
+// the current month and the eleven before it, newest first
+function getRecentMonths() {
+  const now = new Date();
+  return Array.from({ length: 12 }, (_, i) => {
+    const date = new Date(now.getFullYear(), now.getMonth() - i, 1);
+    return {
+      year: date.getFullYear(),
+      month: date.getMonth() + 1,
+      label: date.toLocaleDateString(undefined, {
+        month: "long",
+        year: "numeric",
+      }),
+    };
+  });
+}
+
+function formatAmount(report: MonthlyReport, amountSat: number) {
+  const sats = `${new Intl.NumberFormat().format(amountSat)} sats`;
+  if (!report.currency) {
+    return sats;
+  }
+  const fiat = new Intl.NumberFormat(undefined, {
+    style: "currency",
+    currency: report.currency,
+  }).format((amountSat * report.btcRate) / 100_000_000);
+  return `${sats} (${fiat})`;
+}
+
+export function Reports() {
+  const months = React.useMemo(getRecentMonths, []);
+  const [selectedMonth, setSelectedMonth] = React.useState(months[1]);
+  const { data: report } = useMonthlyReport(
+    selectedMonth.year,
+    selectedMonth.month
+  );
+  const { toast } = useToast();
+  const [exporting, setExporting] = React.useState(false);
+
+  const exportReport = async (format: "html" | "pdf") => {
+    const url = `/api/reports/${selectedMonth.year}/${selectedMonth.month}/export?format=${format}`;
+    const isHttpMode = window.location.protocol.startsWith("http");
+
+    if (isHttpMode) {
+      // the report is served with the session cookie, like a page of the hub
+      window.open(url, "_blank");
+      return;
+    }
+
+    try {
+      setExporting(true);
+      await request(url);
+      toast({ title: "Report saved" });
+    } catch (error) {
+      handleRequestError(toast, "Failed to save report", error);
+    } finally {
+      setExporting(false);
+    }
+  };
+
+  return (
+    <>
+      <SettingsHeader
+        title="Reports"
+        description="See what you spent and received in a month, per app and
+          with the nodes you paid the most. Self payments between your apps
+          are not included."
+      />
+      <Container>
+        <div className="w-full flex flex-col gap-5">
+          <div className="grid gap-1.5">
+            <Label htmlFor="report-month">Month</Label>
+            <Select
+              value={`${selectedMonth.year}-${selectedMonth.month}`}
+              onValueChange={(value) =>
+                setSelectedMonth(
+                  months.find(
+                    (month) => `${month.year}-${month.month}` === value
+                  ) || months[0]
+                )
+              }
+            >
+              <SelectTrigger id="report-month" className="w-[200px]">
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                {months.map((month) => (
+                  <SelectItem
+                    key={`${month.year}-${month.month}`}
+                    value={`${month.year}-${month.month}`}
+                  >
+                    {month.label}
+                  </SelectItem>
+                ))}
+              </SelectContent>
+            </Select>
+          </div>
+          {!report ? (
+            <Loading />
+          ) : (
+            <>
+              <Table>
+                <TableBody>
+                  <TableRow>
+                    <TableCell>Sent</TableCell>
+                    <TableCell className="text-right">
+                      {formatAmount(report, report.totalSentSat)}
+                    </TableCell>
+                    <TableCell className="text-right">
+                      {report.sentCount} payments
+                    </TableCell>
+                  </TableRow>
+                  <TableRow>
+                    <TableCell>Received</TableCell>
+                    <TableCell className="text-right">
+                      {formatAmount(report, report.totalReceivedSat)}
+                    </TableCell>
+                    <TableCell className="text-right">
+                      {report.receivedCount} payments
+                    </TableCell>
+                  </TableRow>
+                  <TableRow>
+                    <TableCell>Fees</TableCell>
+                    <TableCell className="text-right">
+                      {formatAmount(report, report.totalFeesSat)}
+                    </TableCell>
+                    <TableCell />
+                  </TableRow>
+                </TableBody>
+              </Table>
+              {report.apps.length > 0 && (
+                <Table>
+                  <TableHeader>
+                    <TableRow>
+                      <TableHead>App</TableHead>
+                      <TableHead className="text-right">Sent</TableHead>
+                      <TableHead className="text-right">Received</TableHead>
+                      <TableHead className="text-right">Fees</TableHead>
+                    </TableRow>
+                  </TableHeader>
+                  <TableBody>
+                    {report.apps.map((app) => (
+                      <TableRow key={app.appId ?? "other"}>
+                        <TableCell>
+                          {app.appId === null
+                            ? "Other payments"
+                            : app.appName || "Deleted app"}
+                        </TableCell>
+                        <TableCell className="text-right">
+                          {app.sentSat} sats
+                        </TableCell>
+                        <TableCell className="text-right">
+                          {app.receivedSat} sats
+                        </TableCell>
+                        <TableCell className="text-right">
+                          {app.feesSat} sats
+                        </TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              )}
+              {report.tags.length > 0 && (
+                <Table>
+                  <TableHeader>
+                    <TableRow>
+                      <TableHead>Tag</TableHead>
+                      <TableHead className="text-right">Sent</TableHead>
+                      <TableHead className="text-right">Received</TableHead>
+                      <TableHead className="text-right">Payments</TableHead>
+                    </TableRow>
+                  </TableHeader>
+                  <TableBody>
+                    {report.tags.map((tag) => (
+                      <TableRow key={tag.tag}>
+                        <TableCell>{tag.tag}</TableCell>
+                        <TableCell className="text-right">
+                          {tag.sentSat} sats
+                        </TableCell>
+                        <TableCell className="text-right">
+                          {tag.receivedSat} sats
+                        </TableCell>
+                        <TableCell className="text-right">
+                          {tag.count}
+                        </TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              )}
+              {report.topDestinations.length > 0 && (
+                <Table>
+                  <TableHeader>
+                    <TableRow>
+                      <TableHead>Top destinations</TableHead>
+                      <TableHead className="text-right">Sent</TableHead>
+                      <TableHead className="text-right">Payments</TableHead>
+                    </TableRow>
+                  </TableHeader>
+                  <TableBody>
+                    {report.topDestinations.map((destination) => (
+                      <TableRow key={destination.destination}>
+                        <TableCell className="font-mono text-xs break-all">
+                          {destination.destination}
+                        </TableCell>
+                        <TableCell className="text-right">
+                          {destination.amountSat} sats
+                        </TableCell>
+                        <TableCell className="text-right">
+                          {destination.count}
+                        </TableCell>
+                      </TableRow>
+                    ))}
+                  </TableBody>
+                </Table>
+              )}
+              {report.currency && (
+                <p className="text-sm text-muted-foreground">
+                  Fiat values use the bitcoin price of{" "}
+                  {new Date(report.generatedAt).toLocaleString()}.
+                </p>
+              )}
+              <div className="flex flex-row gap-2">
+                <LoadingButton
+                  loading={exporting}
+                  variant="secondary"
+                  onClick={() => exportReport("html")}
+                >
+                  Open HTML
+                </LoadingButton>
+                <LoadingButton
+                  loading={exporting}
+                  variant="secondary"
+                  onClick={() => exportReport("pdf")}
+                >
+                  Download PDF
+                </LoadingButton>
+              </div>
+            </>
+          )}
+        </div>
+      </Container>
+    </>
+  );
+}
@@ -0,0 +1,134 @@
+import { useNavigate } from "react-router-dom";
+
+import Container from "src/components/Container";
+import Loading from "src/components/Loading";
+import SettingsHeader from "src/components/SettingsHeader";
+import { Badge } from "src/components/ui/badge";
+import { Button } from "src/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "src/components/ui/card";
+import { useToast } from "src/components/ui/use-toast";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useInfo } from "src/hooks/useInfo";
+import { useSessions } from "src/hooks/useSessions";
+import { Session } from "src/types";
+import { handleRequestError } from "src/utils/handleRequestError";
+import { clearOfflineCache } from "src/utils/pwa";
+import { request } from "src/utils/request";
+
+const browsers: [RegExp, string][] = [
+  [/Edg\//, "Edge"],
+  [/OPR\//, "Opera"],
+  [/Firefox\//, "Firefox"],
+  [/Chrome\//, "Chrome"],
+  [/Safari\//, "Safari"],
+];
+
+const operatingSystems: [RegExp, string][] = [
+  [/Android/, "Android"],
+  [/iPhone|iPad/, "iOS"],
+  [/Windows/, "Windows"],
+  [/Mac OS X/, "macOS"],
+  [/Linux/, "Linux"],
+];
+
+// describeDevice turns the user agent into a short summary like "Firefox on Linux"
+function describeDevice(userAgent: string) {
+  const browser = browsers.find(([regex]) => regex.test(userAgent))?.[1];
+  const os = operatingSystems.find(([regex]) => regex.test(userAgent))?.[1];
+  if (!browser && !os) {
+    return userAgent || "Unknown device";
+  }
+  return [browser || "Unknown browser", os].filter(Boolean).join(" on ");
+}
+
+export function Sessions() {
+  const { data: csrf } = useCSRF();
+  const { data: sessions, mutate: reloadSessions } = useSessions();
+  const { mutate: refetchInfo } = useInfo();
+  const navigate = useNavigate();
+  const { toast } = useToast();
+
+  if (!sessions) {
+    return <Loading />;
+  }
+
+  const revokeSession = async (session: Session) => {
+    if (!csrf) {
+      throw new Error("No CSRF token");
+    }
+    if (
+      session.current &&
+      !confirm("This will log you out of this browser. Continue?")
+    ) {
+      return;
+    }
+
+    try {
+      await request(`/api/sessions/${session.id}`, {
+        method: "DELETE",
+        headers: {
+          "X-CSRF-Token": csrf,
+        },
+      });
+      if (session.current) {
+        await clearOfflineCache();
+        await refetchInfo();
+        navigate("/", { replace: true });
+        toast({ title: "You are now logged out." });
+        return;
+      }
+      await reloadSessions();
+      toast({ title: "Session revoked" });
+    } catch (error) {
+      handleRequestError(toast, "Failed to revoke session", error);
+    }
+  };
+
+  return (
+    <>
+      <SettingsHeader
+        title="Sessions"
+        description="Browsers in which your hub is unlocked. Revoke a session you
+          do not recognize or no longer use to log out that browser."
+      />
+      <Container>
+        <div className="w-full flex flex-col gap-5">
+          {sessions.map((session) => (
+            <Card key={session.id}>
+              <CardHeader>
+                <CardTitle className="flex flex-row items-center gap-2">
+                  {describeDevice(session.userAgent)}
+                  {session.current && (
+                    <Badge variant="outline">this device</Badge>
+                  )}
+                </CardTitle>
+                <CardDescription className="break-all">
+                  {session.ipAddress || "Unknown IP address"}
+                </CardDescription>
+              </CardHeader>
+              <CardContent className="flex flex-row items-center justify-between gap-2 text-sm">
+                <p>
+                  Last seen {new Date(session.lastSeenAt).toLocaleString()},
+                  logged in {new Date(session.createdAt).toLocaleString()}
+                </p>
+                <Button
+                  variant="destructive"
+                  size="sm"
+                  onClick={() => revokeSession(session)}
+                >
+                  Revoke
+                </Button>
+              </CardContent>
+            </Card>
+          ))}
+        </div>
+      </Container>
+    </>
+  );
+}
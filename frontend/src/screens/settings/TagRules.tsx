@@ -0,0 +1,254 @@
+import { Trash2 } from "lucide-react";
+import React from "react";
+
+import Container from "src/components/Container";
+import Loading from "src/components/Loading";
+import SettingsHeader from "src/components/SettingsHeader";
+import { Button } from "src/components/ui/button";
+import { Checkbox } from "src/components/ui/checkbox";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "src/components/ui/select";
+import {
+  Table,
+  TableBody,
+  TableCell,
+  TableHead,
+  TableHeader,
+  TableRow,
+} from "src/components/ui/table";
+import { useToast } from "src/components/ui/use-toast";
+import { useApps } from "src/hooks/useApps";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useTagRules } from "src/hooks/useTagRules";
+import { CreateTagRuleRequest, TagRule } from "src/types";
+import { handleRequestError } from "src/utils/handleRequestError";
+import { request } from "src/utils/request";
+
+// the select value for rules that match all apps or both payment directions
+const ANY = "any";
+
+// describeRule summarizes the conditions, e.g. "Keysend payments via Fountain"
+function describeRule(rule: TagRule) {
+  const conditions = [
+    rule.keysend
+      ? "Keysend payments"
+      : rule.type === "incoming"
+        ? "Incoming payments"
+        : rule.type === "outgoing"
+          ? "Outgoing payments"
+          : "Payments",
+  ];
+  if (rule.appId !== null) {
+    conditions.push(`via ${rule.appName || "a deleted app"}`);
+  }
+  if (rule.descriptionContains) {
+    conditions.push(`with "${rule.descriptionContains}" in the description`);
+  }
+  return conditions.join(" ");
+}
+
+export function TagRules() {
+  const { data: csrf } = useCSRF();
+  const { data: rules, mutate: reloadRules } = useTagRules();
+  const { data: apps } = useApps();
+  const { toast } = useToast();
+  const [tag, setTag] = React.useState("");
+  const [appId, setAppId] = React.useState(ANY);
+  const [type, setType] = React.useState(ANY);
+  const [keysend, setKeysend] = React.useState(false);
+  const [descriptionContains, setDescriptionContains] = React.useState("");
+  const [creating, setCreating] = React.useState(false);
+
+  if (!rules) {
+    return <Loading />;
+  }
+
+  const createRule = async (event: React.FormEvent) => {
+    event.preventDefault();
+    if (!csrf) {
+      throw new Error("No CSRF token");
+    }
+
+    const createTagRuleRequest: CreateTagRuleRequest = {
+      tag,
+      appId: appId === ANY ? null : parseInt(appId),
+      type: keysend
+        ? "outgoing"
+        : type === ANY
+          ? ""
+          : (type as TagRule["type"]),
+      keysend,
+      descriptionContains,
+    };
+    try {
+      setCreating(true);
+      await request("/api/tag-rules", {
+        method: "POST",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify(createTagRuleRequest),
+      });
+      toast({
+        title: "Tag rule added",
+        description: "Matching payments were tagged.",
+      });
+      setTag("");
+      setDescriptionContains("");
+      reloadRules();
+    } catch (error) {
+      handleRequestError(toast, "Failed to add tag rule", error);
+    } finally {
+      setCreating(false);
+    }
+  };
+
+  const deleteRule = async (rule: TagRule) => {
+    if (!csrf) {
+      throw new Error("No CSRF token");
+    }
+    if (
+      !confirm(
+        `The "${rule.tag}" tag will be removed from the payments tagged by this rule. Continue?`
+      )
+    ) {
+      return;
+    }
+
+    try {
+      await request(`/api/tag-rules/${rule.id}`, {
+        method: "DELETE",
+        headers: {
+          "X-CSRF-Token": csrf,
+        },
+      });
+      toast({ title: "Tag rule deleted" });
+      reloadRules();
+    } catch (error) {
+      handleRequestError(toast, "Failed to delete tag rule", error);
+    }
+  };
+
+  return (
+    <>
+      <SettingsHeader
+        title="Tags"
+        description="Tag payments automatically. Rules tag your existing payments
+          when they are added and every new payment that matches them. Tags are
+          shown in the wallet, the transactions feed and the monthly reports."
+      />
+      <Container>
+        <div className="w-full flex flex-col gap-8">
+          {rules.length > 0 && (
+            <Table>
+              <TableHeader>
+                <TableRow>
+                  <TableHead>Tag</TableHead>
+                  <TableHead>Applies to</TableHead>
+                  <TableHead />
+                </TableRow>
+              </TableHeader>
+              <TableBody>
+                {rules.map((rule) => (
+                  <TableRow key={rule.id}>
+                    <TableCell className="font-medium">{rule.tag}</TableCell>
+                    <TableCell>{describeRule(rule)}</TableCell>
+                    <TableCell className="text-right">
+                      <Button
+                        variant="ghost"
+                        size="icon"
+                        onClick={() => deleteRule(rule)}
+                      >
+                        <Trash2 className="w-4 h-4" />
+                      </Button>
+                    </TableCell>
+                  </TableRow>
+                ))}
+              </TableBody>
+            </Table>
+          )}
+          <form onSubmit={createRule} className="flex flex-col gap-5">
+            <div className="grid gap-1.5">
+              <Label htmlFor="tag-rule-tag">Tag</Label>
+              <Input
+                id="tag-rule-tag"
+                value={tag}
+                onChange={(e) => setTag(e.target.value)}
+                placeholder="e.g. podcasting"
+                required
+              />
+            </div>
+            <div className="grid gap-1.5">
+              <Label htmlFor="tag-rule-app">App</Label>
+              <Select value={appId} onValueChange={setAppId}>
+                <SelectTrigger id="tag-rule-app">
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value={ANY}>Any app</SelectItem>
+                  {apps?.map((app) => (
+                    <SelectItem key={app.id} value={app.id.toString()}>
+                      {app.name}
+                    </SelectItem>
+                  ))}
+                </SelectContent>
+              </Select>
+            </div>
+            <div className="grid gap-1.5">
+              <Label htmlFor="tag-rule-type">Direction</Label>
+              <Select
+                value={keysend ? "outgoing" : type}
+                onValueChange={setType}
+                disabled={keysend}
+              >
+                <SelectTrigger id="tag-rule-type">
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value={ANY}>Incoming and outgoing</SelectItem>
+                  <SelectItem value="incoming">Incoming</SelectItem>
+                  <SelectItem value="outgoing">Outgoing</SelectItem>
+                </SelectContent>
+              </Select>
+            </div>
+            <div className="flex items-center">
+              <Checkbox
+                id="tag-rule-keysend"
+                checked={keysend}
+                onCheckedChange={() => setKeysend(!keysend)}
+              />
+              <Label htmlFor="tag-rule-keysend" className="ml-2">
+                Only keysend payments, e.g. podcast streaming and boosts
+              </Label>
+            </div>
+            <div className="grid gap-1.5">
+              <Label htmlFor="tag-rule-description">
+                Description contains
+              </Label>
+              <Input
+                id="tag-rule-description"
+                value={descriptionContains}
+                onChange={(e) => setDescriptionContains(e.target.value)}
+                placeholder="Optional"
+              />
+            </div>
+            <div>
+              <LoadingButton type="submit" loading={creating}>
+                Add Rule
+              </LoadingButton>
+            </div>
+          </form>
+        </div>
+      </Container>
+    </>
+  );
+}
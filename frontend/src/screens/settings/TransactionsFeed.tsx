@@ -0,0 +1,149 @@
+import { Copy } from "lucide-react";
+import React from "react";
+
+import Container from "src/components/Container";
+import SettingsHeader from "src/components/SettingsHeader";
+import { Alert, AlertDescription, AlertTitle } from "src/components/ui/alert";
+import { Button } from "src/components/ui/button";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { useToast } from "src/components/ui/use-toast";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useTransactionsFeed } from "src/hooks/useTransactionsFeed";
+import { copyToClipboard } from "src/lib/clipboard";
+import { TransactionsFeed as TransactionsFeedResponse } from "src/types";
+import { request } from "src/utils/request";
+
+export function TransactionsFeed() {
+  const { data: csrf } = useCSRF();
+  const { data: transactionsFeed, mutate: reloadTransactionsFeed } =
+    useTransactionsFeed();
+  const { toast } = useToast();
+
+  // the url is only returned once, when the feed is created
+  const [feedUrl, setFeedUrl] = React.useState("");
+  const [loading, setLoading] = React.useState(false);
+
+  const createFeed = async () => {
+    if (
+      transactionsFeed?.enabled &&
+      !confirm(
+        "Are you sure you want to create a new feed URL? Feed readers that use the current URL stop receiving updates."
+      )
+    ) {
+      return;
+    }
+
+    try {
+      if (!csrf) {
+        throw new Error("No CSRF token");
+      }
+      setLoading(true);
+      const createdFeed = await request<TransactionsFeedResponse>(
+        "/api/transactions-feed",
+        {
+          method: "POST",
+          headers: {
+            "X-CSRF-Token": csrf,
+            "Content-Type": "application/json",
+          },
+        }
+      );
+      setFeedUrl(createdFeed?.url || "");
+      await reloadTransactionsFeed();
+    } catch (error) {
+      toast({
+        title: "Failed to create feed",
+        description: (error as Error).message,
+        variant: "destructive",
+      });
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const deleteFeed = async () => {
+    try {
+      if (!csrf) {
+        throw new Error("No CSRF token");
+      }
+      setLoading(true);
+      await request("/api/transactions-feed", {
+        method: "DELETE",
+        headers: {
+          "X-CSRF-Token": csrf,
+        },
+      });
+      setFeedUrl("");
+      await reloadTransactionsFeed();
+      toast({ title: "Transactions feed disabled" });
+    } catch (error) {
+      toast({
+        title: "Failed to disable feed",
+        description: (error as Error).message,
+        variant: "destructive",
+      });
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <>
+      <SettingsHeader
+        title="Transactions Feed"
+        description="Follow the payments of your wallet in a feed reader. Anyone
+          with the private URL of the feed can see your transactions, but cannot
+          access your wallet."
+      />
+      <Container>
+        <div className="w-full flex flex-col gap-3">
+          {feedUrl && (
+            <div className="grid gap-1.5">
+              <Label htmlFor="feed-url">Feed URL</Label>
+              <div className="flex flex-row gap-2">
+                <Input id="feed-url" readOnly value={feedUrl} />
+                <Button
+                  variant="secondary"
+                  size="icon"
+                  onClick={() => copyToClipboard(feedUrl)}
+                >
+                  <Copy className="w-4 h-4" />
+                </Button>
+              </div>
+              <p className="text-sm text-muted-foreground">
+                Add this URL to your feed reader. It is only shown once. The
+                token of the URL also gives accounting tools access to the
+                transactions export API.
+              </p>
+            </div>
+          )}
+          {transactionsFeed?.enabled && !feedUrl && (
+            <Alert>
+              <AlertTitle>Feed enabled</AlertTitle>
+              <AlertDescription>
+                Create a new URL if you lost the current one. The current URL
+                stops working.
+              </AlertDescription>
+            </Alert>
+          )}
+          <div className="flex flex-row gap-2">
+            <LoadingButton loading={loading} onClick={createFeed}>
+              {transactionsFeed?.enabled ? "Create New URL" : "Enable Feed"}
+            </LoadingButton>
+            {transactionsFeed?.enabled && (
+              <Button
+                variant="destructive"
+                disabled={loading}
+                onClick={deleteFeed}
+              >
+                Disable Feed
+              </Button>
+            )}
+          </div>
+        </div>
+      </Container>
+    </>
+  );
+}
@@ -0,0 +1,159 @@
+import React from "react";
+
+import Container from "src/components/Container";
+import Loading from "src/components/Loading";
+import SettingsHeader from "src/components/SettingsHeader";
+import { Badge } from "src/components/ui/badge";
+import { Button } from "src/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "src/components/ui/card";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { useToast } from "src/components/ui/use-toast";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useWatchtowers } from "src/hooks/useWatchtowers";
+import { AddWatchtowerRequest } from "src/types";
+import { handleRequestError } from "src/utils/handleRequestError";
+import { request } from "src/utils/request";
+
+export function Watchtowers() {
+  const { data: csrf } = useCSRF();
+  const { data: watchtowers, mutate: reloadWatchtowers } = useWatchtowers();
+  const { toast } = useToast();
+
+  const [uri, setUri] = React.useState("");
+  const [adding, setAdding] = React.useState(false);
+
+  if (!watchtowers) {
+    return <Loading />;
+  }
+
+  const addWatchtower = async (e: React.FormEvent) => {
+    e.preventDefault();
+    if (!csrf) {
+      throw new Error("No CSRF token");
+    }
+    const [pubkey, address] = uri.trim().split("@");
+    if (!pubkey || !address) {
+      toast({
+        variant: "destructive",
+        title: "Invalid watchtower URI",
+        description: "The URI should be in the format pubkey@host:port",
+      });
+      return;
+    }
+
+    try {
+      setAdding(true);
+      await request("/api/watchtowers", {
+        method: "POST",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify({ pubkey, address } as AddWatchtowerRequest),
+      });
+      setUri("");
+      await reloadWatchtowers();
+      toast({ title: "Watchtower added" });
+    } catch (error) {
+      handleRequestError(toast, "Failed to add watchtower", error);
+    } finally {
+      setAdding(false);
+    }
+  };
+
+  const removeWatchtower = async (pubkey: string) => {
+    if (!csrf) {
+      throw new Error("No CSRF token");
+    }
+    if (!confirm("Are you sure you want to remove this watchtower?")) {
+      return;
+    }
+
+    try {
+      await request(`/api/watchtowers/${pubkey}`, {
+        method: "DELETE",
+        headers: {
+          "X-CSRF-Token": csrf,
+        },
+      });
+      await reloadWatchtowers();
+      toast({ title: "Watchtower removed" });
+    } catch (error) {
+      handleRequestError(toast, "Failed to remove watchtower", error);
+    }
+  };
+
+  return (
+    <>
+      <SettingsHeader
+        title="Watchtowers"
+        description="Watchtowers monitor your channels while your node is offline
+          and punish a channel partner that tries to close a channel with an
+          old state."
+      />
+      <Container>
+        <div className="w-full flex flex-col gap-5">
+          <form onSubmit={addWatchtower} className="w-full flex flex-col gap-3">
+            <div className="grid gap-1.5">
+              <Label htmlFor="watchtower-uri">Add a watchtower</Label>
+              <Input
+                id="watchtower-uri"
+                type="text"
+                value={uri}
+                placeholder="pubkey@host:port"
+                required
+                onChange={(e) => setUri(e.target.value)}
+              />
+            </div>
+            <LoadingButton loading={adding} className="w-fit">
+              Add Watchtower
+            </LoadingButton>
+          </form>
+          {!watchtowers.length && (
+            <p className="text-sm text-muted-foreground">
+              No watchtowers added yet.
+            </p>
+          )}
+          {watchtowers.map((watchtower) => (
+            <Card key={watchtower.pubkey}>
+              <CardHeader>
+                <CardTitle className="flex flex-row items-center gap-2 break-all">
+                  {watchtower.pubkey}
+                  {watchtower.activeSessionCandidate && (
+                    <Badge variant="outline">active</Badge>
+                  )}
+                </CardTitle>
+                <CardDescription className="break-all">
+                  {watchtower.addresses.join(", ")}
+                </CardDescription>
+              </CardHeader>
+              <CardContent className="flex flex-row items-center justify-between gap-2 text-sm">
+                <p>
+                  {watchtower.numSessions}{" "}
+                  {watchtower.numSessions === 1 ? "session" : "sessions"},{" "}
+                  {watchtower.numBackups} backed up,{" "}
+                  {watchtower.numPendingBackups} pending
+                </p>
+                <Button
+                  variant="destructive"
+                  size="sm"
+                  onClick={() => removeWatchtower(watchtower.pubkey)}
+                >
+                  Remove
+                </Button>
+              </CardContent>
+            </Card>
+          ))}
+        </div>
+      </Container>
+    </>
+  );
+}
@@ -1,3 +1,4 @@
+import { StoreIcon } from "lucide-react";
 import React, { ReactElement } from "react";
 import { useNavigate } from "react-router-dom";
 import Container from "src/components/Container";
@@ -5,8 +6,10 @@ import TwoColumnLayoutHeader from "src/components/TwoColumnLayoutHeader";
 import { BreezIcon } from "src/components/icons/Breez";
 import { GreenlightIcon } from "src/components/icons/Greenlight";
 import { LDKIcon } from "src/components/icons/LDK";
+import { NostrWalletConnectIcon } from "src/components/icons/NostrWalletConnectIcon";
 import { PhoenixdIcon } from "src/components/icons/Phoenixd";
 import { Button } from "src/components/ui/button";
+import { useInfo } from "src/hooks/useInfo";
 import { cn } from "src/lib/utils";
 import { BackendType } from "src/types";
 
@@ -46,6 +49,14 @@ const backendTypeDisplayConfigs: Record<BackendType, BackendTypeDisplayConfig> =
       title: "Cashu Mint",
       icon: <img src={cashu} />,
     },
+    BTCPAY: {
+      title: "BTCPay Server",
+      icon: <StoreIcon />,
+    },
+    NWC: {
+      title: "NWC Wallet",
+      icon: <NostrWalletConnectIcon />,
+    },
   };
 
 const backendTypeDisplayConfigList = Object.entries(
@@ -58,6 +69,7 @@ const backendTypeDisplayConfigList = Object.entries(
 export function SetupNode() {
   const navigate = useNavigate();
   const setupStore = useSetupStore();
+  const { data: info } = useInfo();
   const [selectedBackendType, setSelectedBackupType] =
     React.useState<BackendType>();
 
@@ -80,6 +92,9 @@ export function SetupNode() {
         <div className="flex flex-col gap-5 w-full mt-6">
           <div className="w-full grid grid-cols-2 gap-4">
             {backendTypeDisplayConfigList
+              .filter(
+                (item) => !info?.disabledBackends.includes(item.backendType)
+              )
               .filter((item) =>
                 hasImportedMnemonic
                   ? backendTypeConfigs[item.backendType].hasMnemonic
@@ -39,6 +39,9 @@ export function SetupPassword() {
       navigate(`/setup/import-mnemonic`);
     } else if (node) {
       navigate(`/setup/node/${node}`);
+    } else if (info.allInOne) {
+      // the all-in-one mode runs the embedded node, there is nothing to choose
+      navigate(`/setup/node/ldk`);
     } else {
       navigate(`/setup/node`);
     }
@@ -0,0 +1,89 @@
+import React from "react";
+import { useNavigate } from "react-router-dom";
+import Container from "src/components/Container";
+import TwoColumnLayoutHeader from "src/components/TwoColumnLayoutHeader";
+import { Button } from "src/components/ui/button";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { useToast } from "src/components/ui/use-toast";
+import useSetupStore from "src/state/SetupStore";
+
+export function BTCPayForm() {
+  const { toast } = useToast();
+  const navigate = useNavigate();
+  const setupStore = useSetupStore();
+  const [btcpayUrl, setBTCPayUrl] = React.useState<string>(
+    setupStore.nodeInfo.btcpayUrl || ""
+  );
+  const [btcpayApiKey, setBTCPayApiKey] = React.useState<string>(
+    setupStore.nodeInfo.btcpayApiKey || ""
+  );
+  const [btcpayStoreId, setBTCPayStoreId] = React.useState<string>(
+    setupStore.nodeInfo.btcpayStoreId || ""
+  );
+
+  function onSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    if (!btcpayUrl || !btcpayApiKey || !btcpayStoreId) {
+      toast({
+        title: "Please fill out all fields",
+        variant: "destructive",
+      });
+      return;
+    }
+    handleSubmit({
+      btcpayUrl,
+      btcpayApiKey,
+      btcpayStoreId,
+    });
+  }
+
+  async function handleSubmit(data: object) {
+    setupStore.updateNodeInfo({
+      backendType: "BTCPAY",
+      ...data,
+    });
+    navigate("/setup/finish");
+  }
+
+  return (
+    <Container>
+      <TwoColumnLayoutHeader
+        title="Configure BTCPay Server"
+        description="Use the lightning node of your BTCPay store. The API key needs the permission to use the lightning node of the store."
+      />
+      <form className="w-full grid gap-5 mt-6" onSubmit={onSubmit}>
+        <div className="grid gap-1.5">
+          <Label htmlFor="btcpay-url">BTCPay Server URL</Label>
+          <Input
+            name="btcpay-url"
+            onChange={(e) => setBTCPayUrl(e.target.value)}
+            placeholder="https://btcpay.example.com"
+            value={btcpayUrl}
+            id="btcpay-url"
+          />
+        </div>
+        <div className="grid gap-1.5">
+          <Label htmlFor="btcpay-store-id">Store ID</Label>
+          <Input
+            name="btcpay-store-id"
+            onChange={(e) => setBTCPayStoreId(e.target.value)}
+            value={btcpayStoreId}
+            id="btcpay-store-id"
+          />
+        </div>
+        <div className="grid gap-1.5">
+          <Label htmlFor="btcpay-api-key">API Key</Label>
+          <Input
+            name="btcpay-api-key"
+            onChange={(e) => setBTCPayApiKey(e.target.value)}
+            value={btcpayApiKey}
+            type="password"
+            id="btcpay-api-key"
+          />
+        </div>
+        <Button>Next</Button>
+      </form>
+    </Container>
+  );
+}
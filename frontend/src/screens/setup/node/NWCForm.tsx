@@ -0,0 +1,63 @@
+import React from "react";
+import { useNavigate } from "react-router-dom";
+import Container from "src/components/Container";
+import TwoColumnLayoutHeader from "src/components/TwoColumnLayoutHeader";
+import { Button } from "src/components/ui/button";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { useToast } from "src/components/ui/use-toast";
+import useSetupStore from "src/state/SetupStore";
+
+export function NWCForm() {
+  const { toast } = useToast();
+  const navigate = useNavigate();
+  const setupStore = useSetupStore();
+  const [nwcConnectionUri, setNWCConnectionUri] = React.useState<string>(
+    setupStore.nodeInfo.nwcConnectionUri || ""
+  );
+
+  function onSubmit(e: React.FormEvent) {
+    e.preventDefault();
+    if (!nwcConnectionUri.startsWith("nostr+walletconnect:")) {
+      toast({
+        title: "Please enter a nostr+walletconnect:// connection secret",
+        variant: "destructive",
+      });
+      return;
+    }
+    handleSubmit({
+      nwcConnectionUri,
+    });
+  }
+
+  async function handleSubmit(data: object) {
+    setupStore.updateNodeInfo({
+      backendType: "NWC",
+      ...data,
+    });
+    navigate("/setup/finish");
+  }
+
+  return (
+    <Container>
+      <TwoColumnLayoutHeader
+        title="Connect NWC Wallet"
+        description="Use an existing wallet through its NWC connection. The connections of this hub get their own permissions and budgets, within the limits of this connection."
+      />
+      <form className="w-full grid gap-5 mt-6" onSubmit={onSubmit}>
+        <div className="grid gap-1.5">
+          <Label htmlFor="nwc-connection-uri">Connection Secret</Label>
+          <Input
+            name="nwc-connection-uri"
+            onChange={(e) => setNWCConnectionUri(e.target.value.trim())}
+            placeholder="nostr+walletconnect://..."
+            value={nwcConnectionUri}
+            type="password"
+            id="nwc-connection-uri"
+          />
+        </div>
+        <Button>Next</Button>
+      </form>
+    </Container>
+  );
+}
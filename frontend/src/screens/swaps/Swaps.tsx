@@ -0,0 +1,299 @@
+import React from "react";
+import AppHeader from "src/components/AppHeader";
+import ExternalLink from "src/components/ExternalLink";
+import Loading from "src/components/Loading";
+import { Badge } from "src/components/ui/badge";
+import { Button } from "src/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "src/components/ui/card";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { useToast } from "src/components/ui/use-toast";
+import { useAutoSwapConfig } from "src/hooks/useAutoSwapConfig";
+import { useBalances } from "src/hooks/useBalances";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useSwaps } from "src/hooks/useSwaps";
+import {
+  AutoSwapConfig,
+  Swap,
+  SwapInRequest,
+  SwapOutRequest,
+  SwapType,
+} from "src/types";
+import { request } from "src/utils/request";
+
+export default function Swaps() {
+  const { data: csrf } = useCSRF();
+  const { data: balances, mutate: reloadBalances } = useBalances();
+  const { data: swaps, mutate: reloadSwaps } = useSwaps();
+  const { data: autoSwapConfig, mutate: reloadAutoSwapConfig } =
+    useAutoSwapConfig();
+  const { toast } = useToast();
+  const [isLoading, setLoading] = React.useState(false);
+  const [isSavingAutoSwap, setSavingAutoSwap] = React.useState(false);
+  const [swapType, setSwapType] = React.useState<SwapType>("out");
+  const [amount, setAmount] = React.useState("");
+  const [destinationAddress, setDestinationAddress] = React.useState("");
+  const [autoSwapThreshold, setAutoSwapThreshold] = React.useState("");
+  const [autoSwapAmount, setAutoSwapAmount] = React.useState("");
+  const [autoSwapDestination, setAutoSwapDestination] = React.useState("");
+
+  React.useEffect(() => {
+    if (autoSwapConfig) {
+      setAutoSwapThreshold(
+        autoSwapConfig.balanceThreshold
+          ? autoSwapConfig.balanceThreshold.toString()
+          : ""
+      );
+      setAutoSwapAmount(
+        autoSwapConfig.amount ? autoSwapConfig.amount.toString() : ""
+      );
+      setAutoSwapDestination(autoSwapConfig.destinationAddress);
+    }
+  }, [autoSwapConfig]);
+
+  if (!balances || !swaps || !autoSwapConfig) {
+    return <Loading />;
+  }
+
+  const handleSubmit = async (event: React.FormEvent<HTMLFormElement>) => {
+    event.preventDefault();
+    if (!csrf) {
+      throw new Error("csrf not loaded");
+    }
+    try {
+      setLoading(true);
+      await request<Swap>(`/api/swaps/${swapType}`, {
+        method: "POST",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify(
+          swapType === "in"
+            ? ({ amount: +amount } as SwapInRequest)
+            : ({
+                amount: +amount,
+                destinationAddress: destinationAddress.trim(),
+              } as SwapOutRequest)
+        ),
+      });
+      setAmount("");
+      setDestinationAddress("");
+      await Promise.all([reloadSwaps(), reloadBalances()]);
+      toast({
+        title: "Swap started",
+        description:
+          "The swap completes once the on-chain transaction is confirmed",
+      });
+    } catch (e) {
+      toast({
+        variant: "destructive",
+        title: "Failed to start swap: " + e,
+      });
+      console.error(e);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const saveAutoSwapConfig = async (
+    event: React.FormEvent<HTMLFormElement>
+  ) => {
+    event.preventDefault();
+    if (!csrf) {
+      throw new Error("csrf not loaded");
+    }
+    try {
+      setSavingAutoSwap(true);
+      await request("/api/swaps/auto", {
+        method: "PATCH",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify({
+          balanceThreshold: +autoSwapThreshold,
+          amount: +autoSwapAmount,
+          destinationAddress: autoSwapDestination.trim(),
+        } as AutoSwapConfig),
+      });
+      await reloadAutoSwapConfig();
+      toast({ title: "Successfully saved auto swap settings" });
+    } catch (e) {
+      toast({
+        variant: "destructive",
+        title: "Failed to save auto swap settings: " + e,
+      });
+      console.error(e);
+    } finally {
+      setSavingAutoSwap(false);
+    }
+  };
+
+  const spendable =
+    swapType === "in"
+      ? balances.onchain.spendable
+      : balances.lightning.totalSpendable;
+
+  return (
+    <div className="grid gap-5">
+      <AppHeader
+        title="Swaps"
+        description="Move funds between your savings balance and your spending balance with Boltz"
+      />
+      <div className="max-w-lg grid gap-5">
+        <form onSubmit={handleSubmit} className="grid gap-5">
+          <div className="flex flex-row gap-2">
+            <Button
+              type="button"
+              variant={swapType === "out" ? "default" : "outline"}
+              onClick={() => setSwapType("out")}
+            >
+              Lightning to on-chain
+            </Button>
+            <Button
+              type="button"
+              variant={swapType === "in" ? "default" : "outline"}
+              onClick={() => setSwapType("in")}
+            >
+              On-chain to lightning
+            </Button>
+          </div>
+          <div>
+            <Label htmlFor="amount">Amount (sats)</Label>
+            <Input
+              id="amount"
+              type="number"
+              value={amount}
+              min={1}
+              max={spendable}
+              required
+              onChange={(e) => setAmount(e.target.value)}
+            />
+            <p className="text-xs text-muted-foreground mt-2">
+              Spendable: {new Intl.NumberFormat().format(spendable)} sats. Boltz
+              charges a service fee and the on-chain fees of the swap.
+            </p>
+          </div>
+          {swapType === "out" && (
+            <div>
+              <Label htmlFor="destination-address">
+                Destination address (optional)
+              </Label>
+              <Input
+                id="destination-address"
+                type="text"
+                value={destinationAddress}
+                placeholder="Defaults to your savings balance"
+                onChange={(e) => setDestinationAddress(e.target.value)}
+              />
+            </div>
+          )}
+          <div>
+            <LoadingButton loading={isLoading} type="submit" disabled={!amount}>
+              Swap
+            </LoadingButton>
+          </div>
+        </form>
+        <Card>
+          <CardHeader>
+            <CardTitle>Auto Swap</CardTitle>
+            <CardDescription>
+              Automatically swap lightning funds to on-chain when your spending
+              balance exceeds a threshold. Leave the threshold empty to disable
+              auto swaps.
+            </CardDescription>
+          </CardHeader>
+          <CardContent>
+            <form onSubmit={saveAutoSwapConfig} className="grid gap-5">
+              <div>
+                <Label htmlFor="auto-swap-threshold">
+                  Spending balance threshold (sats)
+                </Label>
+                <Input
+                  id="auto-swap-threshold"
+                  type="number"
+                  value={autoSwapThreshold}
+                  min={1}
+                  onChange={(e) => setAutoSwapThreshold(e.target.value)}
+                />
+              </div>
+              <div>
+                <Label htmlFor="auto-swap-amount">Swap amount (sats)</Label>
+                <Input
+                  id="auto-swap-amount"
+                  type="number"
+                  value={autoSwapAmount}
+                  min={1}
+                  required={!!autoSwapThreshold}
+                  onChange={(e) => setAutoSwapAmount(e.target.value)}
+                />
+              </div>
+              <div>
+                <Label htmlFor="auto-swap-destination">
+                  Destination address (optional)
+                </Label>
+                <Input
+                  id="auto-swap-destination"
+                  type="text"
+                  value={autoSwapDestination}
+                  placeholder="Defaults to your savings balance"
+                  onChange={(e) => setAutoSwapDestination(e.target.value)}
+                />
+              </div>
+              <div>
+                <LoadingButton loading={isSavingAutoSwap} type="submit">
+                  Save
+                </LoadingButton>
+              </div>
+            </form>
+          </CardContent>
+        </Card>
+        {swaps.map((swap) => (
+          <Card key={swap.id}>
+            <CardHeader>
+              <CardTitle className="flex flex-row items-center gap-2">
+                {swap.type === "in"
+                  ? "On-chain to lightning"
+                  : "Lightning to on-chain"}
+                <Badge variant="outline">{swap.state}</Badge>
+                {swap.autoSwap && <Badge variant="outline">auto</Badge>}
+              </CardTitle>
+              <CardDescription>
+                {new Intl.NumberFormat().format(swap.amount)} sats on{" "}
+                {new Date(swap.createdAt).toLocaleString()}
+              </CardDescription>
+            </CardHeader>
+            <CardContent className="grid gap-2 text-sm">
+              {swap.boltzStatus && <p>Boltz status: {swap.boltzStatus}</p>}
+              {[
+                { label: "Lockup transaction", txId: swap.lockupTxId },
+                { label: "Claim transaction", txId: swap.claimTxId },
+                { label: "Refund transaction", txId: swap.refundTxId },
+              ]
+                .filter((tx) => tx.txId)
+                .map((tx) => (
+                  <p key={tx.label}>
+                    {tx.label}:{" "}
+                    <ExternalLink
+                      to={`https://mempool.space/tx/${tx.txId}`}
+                      className="underline break-all"
+                    >
+                      {tx.txId}
+                    </ExternalLink>
+                  </p>
+                ))}
+            </CardContent>
+          </Card>
+        ))}
+      </div>
+    </div>
+  );
+}
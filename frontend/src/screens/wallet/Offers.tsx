@@ -0,0 +1,142 @@
+import { Copy } from "lucide-react";
+import React from "react";
+import AppHeader from "src/components/AppHeader";
+import Loading from "src/components/Loading";
+import QRCode from "src/components/QRCode";
+import { Button } from "src/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardHeader,
+  CardTitle,
+} from "src/components/ui/card";
+import { Input } from "src/components/ui/input";
+import { Label } from "src/components/ui/label";
+import { LoadingButton } from "src/components/ui/loading-button";
+import { useToast } from "src/components/ui/use-toast";
+import { useCSRF } from "src/hooks/useCSRF";
+import { useOffers } from "src/hooks/useOffers";
+import { copyToClipboard } from "src/lib/clipboard";
+import { CreateOfferRequest, Offer } from "src/types";
+import { request } from "src/utils/request";
+
+export default function Offers() {
+  const { data: csrf } = useCSRF();
+  const { data: offers, mutate: reloadOffers } = useOffers();
+  const { toast } = useToast();
+  const [isLoading, setLoading] = React.useState(false);
+  const [description, setDescription] = React.useState("");
+  const [createdOffer, setCreatedOffer] = React.useState<Offer>();
+
+  if (!offers) {
+    return <Loading />;
+  }
+
+  const handleSubmit = async (event: React.FormEvent<HTMLFormElement>) => {
+    event.preventDefault();
+    if (!csrf) {
+      throw new Error("csrf not loaded");
+    }
+    try {
+      setLoading(true);
+      const offer = await request<Offer>("/api/offers", {
+        method: "POST",
+        headers: {
+          "X-CSRF-Token": csrf,
+          "Content-Type": "application/json",
+        },
+        body: JSON.stringify({
+          description: description.trim(),
+        } as CreateOfferRequest),
+      });
+      setDescription("");
+      setCreatedOffer(offer);
+      await reloadOffers();
+      toast({
+        title: "Successfully created offer",
+      });
+    } catch (e) {
+      toast({
+        variant: "destructive",
+        title: "Failed to create offer: " + e,
+      });
+      console.error(e);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  return (
+    <div className="grid gap-5">
+      <AppHeader
+        title="Offers"
+        description="Create reusable BOLT12 offers which can be paid many times, every payment shows up as a transaction"
+      />
+      <div className="max-w-lg grid gap-5">
+        <form onSubmit={handleSubmit} className="grid gap-5">
+          <div>
+            <Label htmlFor="description">Description</Label>
+            <Input
+              id="description"
+              type="text"
+              value={description}
+              placeholder="For e.g. donations"
+              onChange={(e) => {
+                setDescription(e.target.value);
+              }}
+            />
+          </div>
+          <div>
+            <LoadingButton loading={isLoading} type="submit">
+              Create Offer
+            </LoadingButton>
+          </div>
+        </form>
+        {createdOffer && (
+          <Card>
+            <CardHeader>
+              <CardTitle>New Offer</CardTitle>
+              <CardDescription>{createdOffer.description}</CardDescription>
+            </CardHeader>
+            <CardContent className="flex flex-col items-center gap-4">
+              <QRCode value={createdOffer.offer} className="w-full" />
+            </CardContent>
+          </Card>
+        )}
+        {offers.map((offer) => (
+          <Card key={offer.id}>
+            <CardHeader>
+              <CardTitle>{offer.description || "Offer"}</CardTitle>
+              <CardDescription>
+                {new Intl.NumberFormat().format(offer.totalReceived)} sats
+                received in {offer.paymentsCount}{" "}
+                {offer.paymentsCount === 1 ? "payment" : "payments"}
+              </CardDescription>
+            </CardHeader>
+            <CardContent>
+              <div className="flex flex-row items-center gap-2">
+                <Input
+                  type="text"
+                  value={offer.offer}
+                  className="flex-1"
+                  readOnly
+                />
+                <Button
+                  type="button"
+                  variant="secondary"
+                  size="icon"
+                  onClick={() => {
+                    copyToClipboard(offer.offer);
+                  }}
+                >
+                  <Copy className="w-4 h-4" />
+                </Button>
+              </div>
+            </CardContent>
+          </Card>
+        ))}
+      </div>
+    </div>
+  );
+}
@@ -24,6 +24,7 @@ import { LoadingButton } from "src/components/ui/loading-button";
 import { useToast } from "src/components/ui/use-toast";
 import { useBalances } from "src/hooks/useBalances";
 import { useCSRF } from "src/hooks/useCSRF";
+import { useCapabilities } from "src/hooks/useCapabilities";
 import { useInfo } from "src/hooks/useInfo";
 import { useTransaction } from "src/hooks/useTransaction";
 import { copyToClipboard } from "src/lib/clipboard";
@@ -33,6 +34,7 @@ import { request } from "src/utils/request";
 export default function Receive() {
   const { hasChannelManagement } = useInfo();
   const { data: balances } = useBalances();
+  const { data: capabilities } = useCapabilities();
   const { data: csrf } = useCSRF();
   const { toast } = useToast();
   const [isLoading, setLoading] = React.useState(false);
@@ -126,6 +128,13 @@ export default function Receive() {
       <AppHeader
         title="Receive"
         description="Create a lightning invoice that can be paid by any bitcoin lightning wallet"
+        contentRight={
+          capabilities?.methods.includes("make_offer") && (
+            <Link to="/wallet/offers">
+              <Button variant="outline">Reusable Offers</Button>
+            </Link>
+          )
+        }
       />
       {hasChannelManagement &&
         parseInt(amount || "0") * 1000 >=
@@ -16,7 +16,9 @@ export type BackendType =
   | "GREENLIGHT"
   | "LDK"
   | "PHOENIX"
-  | "CASHU";
+  | "CASHU"
+  | "BTCPAY"
+  | "NWC";
 
 export type Nip47RequestMethod =
   | "get_info"
@@ -28,7 +30,8 @@ export type Nip47RequestMethod =
   | "list_transactions"
   | "sign_message"
   | "multi_pay_invoice"
-  | "multi_pay_keysend";
+  | "multi_pay_keysend"
+  | "make_offer";
 
 export type BudgetRenewalType =
   | "daily"
@@ -42,7 +45,7 @@ export type Scope =
   | "pay_invoice" // also used for pay_keysend, multi_pay_invoice, multi_pay_keysend
   | "get_balance"
   | "get_info"
-  | "make_invoice"
+  | "make_invoice" // also used for make_offer
   | "lookup_invoice"
   | "list_transactions"
   | "sign_message"
@@ -106,6 +109,7 @@ export const budgetOptions: Record<string, number> = {
 
 export interface ErrorResponse {
   message: string;
+  requestId?: string;
 }
 
 export interface App {
@@ -124,6 +128,31 @@ export interface App {
   maxAmount: number;
   budgetUsage: number;
   budgetRenewal: BudgetRenewalType;
+  // only set if the budget is projected to run out before it renews
+  budgetRunsOutAt?: string;
+  budgetRenewsAt?: string;
+  // the features as they apply to the app, only set for a single app
+  features?: Record<string, boolean>;
+}
+
+export interface NostrKeys {
+  pubkey: string;
+  previousPubkey?: string;
+}
+
+export interface PaymentConfirmation {
+  transactionId: number;
+  appId: number;
+  amount: number;
+  paymentHash: string;
+  description: string;
+  createdAt: string;
+  expiresAt: string;
+}
+
+export interface PaymentConfirmationAuthenticator {
+  secret: string;
+  uri: string;
 }
 
 export interface AppPermissions {
@@ -139,6 +168,10 @@ export interface InfoResponse {
   setupCompleted: boolean;
   oauthRedirect: boolean;
   albyAccountConnected: boolean;
+  singleUser: boolean;
+  allInOne: boolean;
+  // experimental backends that are not offered in the setup
+  disabledBackends: BackendType[];
   running: boolean;
   unlocked: boolean;
   albyAuthUrl: string;
@@ -146,8 +179,16 @@ export interface InfoResponse {
   albyUserIdentifier: string;
   network?: Network;
   version: string;
+  branding: Branding;
 }
 
+export type Branding = {
+  name: string;
+  logoUrl: string;
+  primaryColor: string;
+  supportUrl: string;
+};
+
 export type Network = "bitcoin" | "testnet" | "signet";
 
 export interface EncryptedMnemonicResponse {
@@ -192,6 +233,7 @@ export type Channel = {
   confirmations?: number;
   confirmationsRequired?: number;
   forwardingFeeBaseMsat: number;
+  forwardingFeeProportionalMillionths: number;
   unspendablePunishmentReserve: number;
   counterpartyUnspendablePunishmentReserve: number;
   error?: string;
@@ -200,6 +242,7 @@ export type Channel = {
 
 export type UpdateChannelRequest = {
   forwardingFeeBaseMsat: number;
+  forwardingFeeProportionalMillionths?: number;
 };
 
 export type Peer = {
@@ -281,6 +324,12 @@ export type SetupNodeInfo = Partial<{
 
   phoenixdAddress?: string;
   phoenixdAuthorization?: string;
+
+  btcpayUrl?: string;
+  btcpayApiKey?: string;
+  btcpayStoreId?: string;
+
+  nwcConnectionUri?: string;
 }>;
 
 export type LSPType = "LSPS1";
@@ -348,6 +397,13 @@ export type AutoChannelResponse = {
   channelSize: number;
 };
 
+export type RedeemOnchainFundsRequest = {
+  toAddress: string;
+  amount?: number;
+  feeRate?: number;
+  sendAll: boolean;
+};
+
 export type RedeemOnchainFundsResponse = {
   txId: string;
 };
@@ -367,6 +423,7 @@ export type BalancesResponse = {
 };
 
 export type Transaction = {
+  id: number;
   type: "incoming" | "outgoing";
   app_id: number | undefined;
   invoice: string;
@@ -379,8 +436,27 @@ export type Transaction = {
   created_at: string;
   settled_at: string | undefined;
   metadata: unknown;
+  tags: string[];
+  memo: string;
+  note: string;
+};
+
+export type TagRule = {
+  id: number;
+  tag: string;
+  appId: number | null;
+  appName: string;
+  type: "" | "incoming" | "outgoing";
+  keysend: boolean;
+  descriptionContains: string;
+  createdAt: string;
 };
 
+export type CreateTagRuleRequest = Omit<
+  TagRule,
+  "id" | "appName" | "createdAt"
+>;
+
 export type NewChannelOrderStatus = "pay" | "paid" | "success" | "opening";
 
 export type NewChannelOrder = {
@@ -401,3 +477,211 @@ export type NewChannelOrder = {
       lspUrl: string;
     }
 );
+
+export type CreateOfferRequest = {
+  description: string;
+};
+
+export type Offer = {
+  id: number;
+  offer: string;
+  description: string;
+  appId?: number;
+  createdAt: string;
+  totalReceived: number;
+  paymentsCount: number;
+};
+
+export interface TransactionsFeed {
+  enabled: boolean;
+  url?: string;
+}
+
+export type SwapType = "in" | "out";
+
+export type SwapState = "pending" | "success" | "failed" | "refunded";
+
+export type Swap = {
+  id: string;
+  type: SwapType;
+  state: SwapState;
+  boltzStatus: string;
+  amount: number;
+  onchainAmount: number;
+  paymentHash: string;
+  lockupAddress: string;
+  destinationAddress: string;
+  lockupTxId: string;
+  claimTxId: string;
+  refundTxId: string;
+  autoSwap: boolean;
+  createdAt: string;
+  updatedAt: string;
+};
+
+export type SwapInRequest = {
+  amount: number;
+};
+
+export type SwapOutRequest = {
+  amount: number;
+  destinationAddress?: string;
+};
+
+export type AutoSwapConfig = {
+  balanceThreshold: number;
+  amount: number;
+  destinationAddress: string;
+};
+
+export type ChannelBackupStatus = {
+  lastExportedAt?: string;
+  outdated: boolean;
+  channelCount: number;
+  autoBackupEnabled: boolean;
+};
+
+export type VerifyChannelBackupResponse = {
+  backend: string;
+  nodeId: string;
+  channelCount: number;
+  createdAt: string;
+};
+
+export type Watchtower = {
+  pubkey: string;
+  addresses: string[];
+  activeSessionCandidate: boolean;
+  numSessions: number;
+  numBackups: number;
+  numPendingBackups: number;
+};
+
+export type AddWatchtowerRequest = {
+  pubkey: string;
+  address: string;
+};
+
+export type WalletSummary = {
+  backendType: BackendType;
+  running: boolean;
+  nodeOnline: boolean;
+  nodeAlias: string;
+  network: Network;
+  lightningSpendable: number;
+  onchainSpendable: number;
+  spentTodaySat: number;
+};
+
+export type ActivityType = "payment" | "app" | "permission" | "failure";
+
+export type Activity = {
+  id: number;
+  type: ActivityType;
+  event: string;
+  title: string;
+  description: string;
+  appName: string;
+  amountSat: number;
+  paymentHash: string;
+  createdAt: string;
+};
+
+export type ListActivitiesResponse = {
+  activities: Activity[];
+  nextCursor?: number;
+};
+
+export type ListNotificationsResponse = {
+  notifications: Activity[];
+  unreadCount: number;
+};
+
+export type MarkNotificationsReadRequest = {
+  ids?: number[];
+};
+
+export type AppRequest = {
+  id: number;
+  nostrId: string;
+  method: string;
+  params?: Record<string, unknown>;
+  state: string;
+  errorCode: string;
+  errorMessage: string;
+  createdAt: string;
+  respondedAt?: string;
+  durationMs?: number;
+};
+
+export type Session = {
+  id: number;
+  userAgent: string;
+  ipAddress: string;
+  createdAt: string;
+  lastSeenAt: string;
+  current: boolean;
+};
+
+export type Nip47Usage = {
+  days: {
+    date: string;
+    requests: number;
+    errors: number;
+  }[];
+  methods: {
+    method: string;
+    requests: number;
+    errors: number;
+  }[];
+  apps: {
+    appId: number | null;
+    appName: string;
+    requests: number;
+    errors: number;
+  }[];
+  errorCodes: {
+    code: string;
+    count: number;
+  }[];
+};
+
+export type MonthlyReport = {
+  year: number;
+  month: number;
+  totalSentSat: number;
+  totalReceivedSat: number;
+  totalFeesSat: number;
+  sentCount: number;
+  receivedCount: number;
+  apps: {
+    appId: number | null;
+    appName: string;
+    sentSat: number;
+    receivedSat: number;
+    feesSat: number;
+    count: number;
+  }[];
+  topDestinations: {
+    destination: string;
+    amountSat: number;
+    count: number;
+  }[];
+  tags: {
+    tag: string;
+    sentSat: number;
+    receivedSat: number;
+    count: number;
+  }[];
+  currency: string;
+  btcRate: number;
+  generatedAt: string;
+};
+
+export type LogSettings = {
+  logLevel: string;
+  componentLevels: Record<string, string>;
+  debugToggles: Record<string, boolean>;
+};
+
+export type UpdateLogSettingsRequest = Partial<LogSettings>;
@@ -0,0 +1,30 @@
+import { registerSW } from "virtual:pwa-register";
+
+// cache of the service worker, see runtimeCaching in vite.config.ts
+const apiCacheName = "api";
+
+export function registerServiceWorker() {
+  const isHttpMode = window.location.protocol.startsWith("http");
+  if (!isHttpMode || !("serviceWorker" in navigator)) {
+    return;
+  }
+  registerSW({
+    immediate: true,
+    onRegisterError(error) {
+      console.error("Failed to register service worker", error);
+    },
+  });
+}
+
+// clearOfflineCache removes the responses that are shown while offline, so
+// that they cannot be read from the device after locking the hub
+export async function clearOfflineCache() {
+  if (!("caches" in window)) {
+    return;
+  }
+  try {
+    await caches.delete(apiCacheName);
+  } catch (error) {
+    console.error("Failed to clear offline cache", error);
+  }
+}
@@ -10,24 +10,52 @@ export default defineConfig({
     tsconfigPaths(),
     VitePWA({
       registerType: 'autoUpdate',
-      includeAssets: ['favicon.ico', 'robots.txt', 'apple-touch-icon.png'],
+      // the service worker is registered in src/utils/pwa.ts, only in HTTP mode
+      injectRegister: null,
+      includeAssets: ['favicon.svg', 'robots.txt'],
       manifest: {
+        id: '/',
         short_name: 'Alby Hub',
         name: 'Alby Hub',
+        description: 'Self-custodial Lightning wallet with integrated node',
         icons: [
           {
             src: 'favicon.svg',
             sizes: 'any',
-            type: 'image/svg+xml'
+            type: 'image/svg+xml',
+            purpose: 'any'
           }
         ],
-        start_url: '.',
+        start_url: '/',
+        scope: '/',
         display: 'standalone',
         theme_color: '#000000',
         background_color: '#ffffff'
       },
       workbox: {
-        globPatterns: ['**/*.{js,css,html,png,svg,ico}']
+        globPatterns: ['**/*.{js,css,html,png,svg,ico,woff2}'],
+        navigateFallback: 'index.html',
+        navigateFallbackDenylist: [/^\/api\//],
+        runtimeCaching: [
+          {
+            // the last responses are shown while the hub cannot be reached
+            urlPattern: ({ url, request }) =>
+              request.method === 'GET' &&
+              /^\/api\/(info|apps|transactions)$/.test(url.pathname),
+            handler: 'NetworkFirst',
+            options: {
+              cacheName: 'api',
+              networkTimeoutSeconds: 5,
+              expiration: {
+                maxEntries: 50,
+                maxAgeSeconds: 7 * 24 * 60 * 60
+              },
+              cacheableResponse: {
+                statuses: [200]
+              }
+            }
+          }
+        ]
       }
     })
   ],
@@ -74,12 +74,22 @@ func (albyHttpSvc *AlbyHttpService) unlinkHandler(c echo.Context) error {
 func (albyHttpSvc *AlbyHttpService) albyCallbackHandler(c echo.Context) error {
 	code := c.QueryParam("code")
 
+	redirectUrl := albyHttpSvc.appConfig.FrontendUrl
+	if redirectUrl == "" {
+		redirectUrl = albyHttpSvc.appConfig.BaseUrl
+	}
+
 	err := albyHttpSvc.albyOAuthSvc.CallbackHandler(c.Request().Context(), code, albyHttpSvc.svc.GetLNClient())
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to handle Alby OAuth callback")
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: fmt.Sprintf("Failed to handle Alby OAuth callback: %s", err.Error()),
-		})
+		logger.HTTP.WithError(err).WithField("request_id", getRequestId(c)).Error("Failed to handle Alby OAuth callback")
+		if albyHttpSvc.appConfig.IsDefaultClientId() {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Message:   fmt.Sprintf("Failed to handle Alby OAuth callback: %s", err.Error()),
+				RequestId: getRequestId(c),
+			})
+		}
+		// the browser was redirected here by Alby, so send it back to the frontend to show the error
+		return redirectWithFlash(c, redirectUrl, "Failed to connect your Alby Account. Please try again.")
 	}
 
 	if albyHttpSvc.appConfig.IsDefaultClientId() {
@@ -88,18 +98,13 @@ func (albyHttpSvc *AlbyHttpService) albyCallbackHandler(c echo.Context) error {
 		return c.NoContent(http.StatusNoContent)
 	}
 
-	redirectUrl := albyHttpSvc.appConfig.FrontendUrl
-	if redirectUrl == "" {
-		redirectUrl = albyHttpSvc.appConfig.BaseUrl
-	}
-
 	return c.Redirect(http.StatusFound, redirectUrl)
 }
 
 func (albyHttpSvc *AlbyHttpService) albyMeHandler(c echo.Context) error {
 	me, err := albyHttpSvc.albyOAuthSvc.GetMe(c.Request().Context())
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to request alby me endpoint")
+		logger.HTTP.WithError(err).Error("Failed to request alby me endpoint")
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Message: fmt.Sprintf("Failed to request alby me endpoint: %s", err.Error()),
 		})
@@ -111,7 +116,7 @@ func (albyHttpSvc *AlbyHttpService) albyMeHandler(c echo.Context) error {
 func (albyHttpSvc *AlbyHttpService) albyBalanceHandler(c echo.Context) error {
 	balance, err := albyHttpSvc.albyOAuthSvc.GetBalance(c.Request().Context())
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to request alby balance endpoint")
+		logger.HTTP.WithError(err).Error("Failed to request alby balance endpoint")
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Message: fmt.Sprintf("Failed to request alby balance endpoint: %s", err.Error()),
 		})
@@ -132,7 +137,7 @@ func (albyHttpSvc *AlbyHttpService) albyPayHandler(c echo.Context) error {
 
 	err := albyHttpSvc.albyOAuthSvc.SendPayment(c.Request().Context(), payRequest.Invoice)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to request alby pay endpoint")
+		logger.HTTP.WithError(err).Error("Failed to request alby pay endpoint")
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Message: fmt.Sprintf("Failed to request alby pay endpoint: %s", err.Error()),
 		})
@@ -146,7 +151,7 @@ func (albyHttpSvc *AlbyHttpService) albyDrainHandler(c echo.Context) error {
 	err := albyHttpSvc.albyOAuthSvc.DrainSharedWallet(c.Request().Context(), albyHttpSvc.svc.GetLNClient())
 
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to drain shared wallet")
+		logger.HTTP.WithError(err).Error("Failed to drain shared wallet")
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Message: fmt.Sprintf("Failed to drain shared wallet: %s", err.Error()),
 		})
@@ -165,8 +170,10 @@ func (albyHttpSvc *AlbyHttpService) albyLinkAccountHandler(c echo.Context) error
 
 	err := albyHttpSvc.albyOAuthSvc.LinkAccount(c.Request().Context(), albyHttpSvc.svc.GetLNClient(), linkAccountRequest.Budget, linkAccountRequest.Renewal)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to connect alby account")
-		return err
+		logger.HTTP.WithError(err).Error("Failed to connect alby account")
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to connect alby account: %s", err.Error()),
+		})
 	}
 
 	return c.NoContent(http.StatusNoContent)
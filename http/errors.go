@@ -0,0 +1,119 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/logger"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+var errorPageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - {{.Branding.Name}}</title>
+<style>
+body { font-family: sans-serif; max-width: 32rem; margin: 4rem auto; padding: 0 1rem; color: #171717; }
+code { background: #f5f5f5; padding: 0.125rem 0.25rem; border-radius: 0.25rem; }
+a { color: inherit; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .RequestId}}<p>If you ask for help{{if .Branding.SupportUrl}} from <a href="{{.Branding.SupportUrl}}">our support</a>{{end}}, please quote the request ID <code>{{.RequestId}}</code>.</p>{{end}}
+<p><a href="/">Return Home</a></p>
+</body>
+</html>
+`))
+
+type errorPage struct {
+	Title     string
+	Message   string
+	RequestId string
+	Branding  config.Branding
+}
+
+// errorHandler replaces the default error handler of echo for errors that handlers return
+// instead of writing a response themselves. Browsers get a rendered error page, other
+// clients an ErrorResponse. Both include the request ID so that the error can be found in the logs.
+func (httpSvc *HttpService) errorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	code := http.StatusInternalServerError
+	// the message of unexpected errors is only logged as it can contain internal details
+	message := "Something went wrong. Please try again."
+	var httpError *echo.HTTPError
+	if errors.As(err, &httpError) {
+		code = httpError.Code
+		message = fmt.Sprintf("%v", httpError.Message)
+	}
+	requestId := getRequestId(c)
+
+	if code >= http.StatusInternalServerError {
+		logger.HTTP.WithFields(logrus.Fields{
+			"request_id": requestId,
+			"method":     c.Request().Method,
+			"path":       c.Path(),
+		}).WithError(err).Error("Failed to handle request")
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(code)
+	} else if acceptsHtml(c) {
+		err = c.HTMLBlob(code, renderErrorPage(&errorPage{
+			Title:     http.StatusText(code),
+			Message:   message,
+			RequestId: requestId,
+			Branding:  httpSvc.cfg.GetEnv().GetBranding(),
+		}))
+	} else {
+		err = c.JSON(code, ErrorResponse{
+			Message:   message,
+			RequestId: requestId,
+		})
+	}
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to send error response")
+	}
+}
+
+// redirectWithFlash redirects a browser back to the frontend, which shows the message once
+func redirectWithFlash(c echo.Context, redirectUrl string, message string) error {
+	query := url.Values{
+		"flash":      []string{message},
+		"request_id": []string{getRequestId(c)},
+	}
+	// the frontend uses a hash router
+	return c.Redirect(http.StatusFound, fmt.Sprintf("%s/#/?%s", strings.TrimSuffix(redirectUrl, "/"), query.Encode()))
+}
+
+func renderErrorPage(page *errorPage) []byte {
+	var html strings.Builder
+	err := errorPageTemplate.Execute(&html, page)
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to render error page")
+		return []byte(page.Message)
+	}
+	return []byte(html.String())
+}
+
+func getRequestId(c echo.Context) string {
+	return c.Response().Header().Get(echo.HeaderXRequestID)
+}
+
+// acceptsHtml is true for page loads of a browser, but not for requests of the frontend
+func acceptsHtml(c echo.Context) bool {
+	return !strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) &&
+		strings.Contains(c.Request().Header.Get(echo.HeaderAccept), echo.MIMETextHTML)
+}
@@ -2,24 +2,30 @@ package http
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 
-	echologrus "github.com/davrux/echo-logrus/v4"
 	"github.com/gorilla/sessions"
 	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
 	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/db"
 	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lockout"
 	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nostr/relay"
 	"github.com/getAlby/hub/service"
+	"github.com/getAlby/hub/transactions"
 
 	"github.com/getAlby/hub/api"
 	"github.com/getAlby/hub/frontend"
@@ -28,23 +34,29 @@ import (
 type HttpService struct {
 	api            api.API
 	albyHttpSvc    *AlbyHttpService
+	lnurlHttpSvc   *LNURLHttpService
+	lockoutSvc     lockout.LockoutService
 	cfg            config.Config
 	eventPublisher events.EventPublisher
 	db             *gorm.DB
+	embeddedRelay  *relay.Relay
 }
 
 const (
-	sessionCookieName    = "session"
-	sessionCookieAuthKey = "authenticated"
+	sessionCookieName  = "session"
+	sessionCookieIdKey = "id"
 )
 
 func NewHttpService(svc service.Service, eventPublisher events.EventPublisher) *HttpService {
 	return &HttpService{
 		api:            api.NewAPI(svc, svc.GetDB(), svc.GetConfig(), svc.GetKeys(), svc.GetAlbyOAuthSvc(), svc.GetEventPublisher()),
 		albyHttpSvc:    NewAlbyHttpService(svc, svc.GetAlbyOAuthSvc(), svc.GetConfig().GetEnv()),
+		lnurlHttpSvc:   NewLNURLHttpService(svc),
+		lockoutSvc:     lockout.NewLockoutService(svc.GetDB(), eventPublisher),
 		cfg:            svc.GetConfig(),
 		eventPublisher: eventPublisher,
 		db:             svc.GetDB(),
+		embeddedRelay:  svc.GetEmbeddedRelay(),
 	}
 }
 
@@ -57,13 +69,79 @@ func (httpSvc *HttpService) validateUserMiddleware(next echo.HandlerFunc) echo.H
 	}
 }
 
+// exportAuthMiddleware also accepts the token of the transactions feed as a bearer token,
+// so that accounting tools can sync the transactions without unlocking the hub
+func (httpSvc *HttpService) exportAuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if httpSvc.isUnlocked(c) {
+			return next(c)
+		}
+		token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+		if !ok || httpSvc.api.CheckTransactionsFeedToken(token) != nil {
+			return c.NoContent(http.StatusUnauthorized)
+		}
+		return next(c)
+	}
+}
+
 func (httpSvc *HttpService) RegisterSharedRoutes(e *echo.Echo) {
 	e.HideBanner = true
-	e.Use(echologrus.Middleware())
-	e.Use(middleware.Recover())
+	e.HTTPErrorHandler = httpSvc.errorHandler
 	e.Use(middleware.RequestID())
+	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
+		LogMethod:    true,
+		LogURIPath:   true,
+		LogStatus:    true,
+		LogLatency:   true,
+		LogRemoteIP:  true,
+		LogRequestID: true,
+		LogError:     true,
+		// the query is not logged as it can contain secrets
+		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
+			entry := logger.HTTP.WithFields(logrus.Fields{
+				"request_id": v.RequestID,
+				"method":     v.Method,
+				"path":       v.URIPath,
+				"status":     v.Status,
+				"latency_ms": v.Latency.Milliseconds(),
+				"remote_ip":  v.RemoteIP,
+			})
+			if v.Error != nil {
+				entry = entry.WithError(v.Error)
+			}
+			entry.Info("Handled request")
+			return nil
+		},
+	}))
+	e.Use(middleware.RecoverWithConfig(middleware.RecoverConfig{
+		LogErrorFunc: func(c echo.Context, err error, stack []byte) error {
+			logger.HTTP.WithFields(logrus.Fields{
+				"method":     c.Request().Method,
+				"path":       c.Path(),
+				"request_id": c.Response().Header().Get(echo.HeaderXRequestID),
+				"stack":      string(stack),
+			}).WithError(err).Error("Recovered from panic in HTTP handler")
+			return err
+		},
+	}))
+	// HSTS is only sent for requests over TLS (or X-Forwarded-Proto: https)
+	e.Use(middleware.SecureWithConfig(middleware.SecureConfig{
+		ContentTypeNosniff: "nosniff",
+		XFrameOptions:      "DENY",
+		HSTSMaxAge:         31536000,
+		ReferrerPolicy:     "strict-origin-when-cross-origin",
+	}))
+	// the config is validated on startup
+	err := httpSvc.configureIPAllowlist(e)
+	if err != nil {
+		logger.HTTP.WithError(err).Fatal("Invalid IP allowlist")
+	}
 	e.Use(middleware.CSRFWithConfig(middleware.CSRFConfig{
 		TokenLookup: "header:X-CSRF-Token",
+		// NIP-47 requests are authenticated by the signature of the event, not by the session
+		Skipper: func(c echo.Context) bool {
+			return c.Path() == "/api/nip47"
+		},
 	}))
 	e.Use(session.Middleware(sessions.NewCookieStore([]byte(httpSvc.cfg.GetCookieSecret()))))
 
@@ -73,12 +151,23 @@ func (httpSvc *HttpService) RegisterSharedRoutes(e *echo.Echo) {
 	e.PATCH("/api/apps/:pubkey", httpSvc.appsUpdateHandler, authMiddleware)
 	e.DELETE("/api/apps/:pubkey", httpSvc.appsDeleteHandler, authMiddleware)
 	e.POST("/api/apps", httpSvc.appsCreateHandler, authMiddleware)
+	e.POST("/api/apps/:pubkey/lnurl-withdraws", httpSvc.appsCreateLNURLWithdrawHandler, authMiddleware)
+	e.POST("/api/apps/:pubkey/regenerate-secret", httpSvc.appsRegenerateSecretHandler, authMiddleware)
+	e.GET("/api/apps/:pubkey/requests", httpSvc.appsListRequestsHandler, authMiddleware)
+	e.GET("/api/nip47/usage", httpSvc.nip47UsageHandler, authMiddleware)
 	e.GET("/api/encrypted-mnemonic", httpSvc.encryptedMnemonicHandler, authMiddleware)
 	e.PATCH("/api/backup-reminder", httpSvc.backupReminderHandler, authMiddleware)
+	e.GET("/api/features", httpSvc.featuresListHandler, authMiddleware)
+	e.PATCH("/api/features/:name", httpSvc.featuresUpdateHandler, authMiddleware)
+	e.GET("/api/keys", httpSvc.nostrKeysHandler, authMiddleware)
 
 	e.GET("/api/csrf", httpSvc.csrfHandler)
 	e.GET("/api/info", httpSvc.infoHandler)
+	e.GET("/api/health", httpSvc.healthHandler)
 	e.POST("/api/logout", httpSvc.logoutHandler)
+	e.POST("/api/logout-all", httpSvc.logoutAllHandler, authMiddleware)
+	e.GET("/api/sessions", httpSvc.listSessionsHandler, authMiddleware)
+	e.DELETE("/api/sessions/:id", httpSvc.revokeSessionHandler, authMiddleware)
 	e.POST("/api/setup", httpSvc.setupHandler)
 
 	// allow one unlock request per second
@@ -86,6 +175,7 @@ func (httpSvc *HttpService) RegisterSharedRoutes(e *echo.Echo) {
 	e.POST("/api/start", httpSvc.startHandler, unlockRateLimiter)
 	e.POST("/api/unlock", httpSvc.unlockHandler, unlockRateLimiter)
 	e.PATCH("/api/unlock-password", httpSvc.changeUnlockPasswordHandler, unlockRateLimiter)
+	e.POST("/api/keys/rotate", httpSvc.rotateNostrKeysHandler, authMiddleware, unlockRateLimiter)
 
 	// TODO: below could be supported by NIP-47
 	e.GET("/api/channels", httpSvc.channelsListHandler, authMiddleware)
@@ -100,32 +190,73 @@ func (httpSvc *HttpService) RegisterSharedRoutes(e *echo.Echo) {
 	e.DELETE("/api/peers/:peerId", httpSvc.disconnectPeerHandler, authMiddleware)
 	e.DELETE("/api/peers/:peerId/channels/:channelId", httpSvc.closeChannelHandler, authMiddleware)
 	e.PATCH("/api/peers/:peerId/channels/:channelId", httpSvc.updateChannelHandler, authMiddleware)
+	e.GET("/api/watchtowers", httpSvc.listWatchtowersHandler, authMiddleware)
+	e.POST("/api/watchtowers", httpSvc.addWatchtowerHandler, authMiddleware)
+	e.DELETE("/api/watchtowers/:pubkey", httpSvc.removeWatchtowerHandler, authMiddleware)
 	e.GET("/api/wallet/address", httpSvc.onchainAddressHandler, authMiddleware)
 	e.POST("/api/wallet/new-address", httpSvc.newOnchainAddressHandler, authMiddleware)
 	e.POST("/api/wallet/redeem-onchain-funds", httpSvc.redeemOnchainFundsHandler, authMiddleware)
 	e.POST("/api/wallet/sign-message", httpSvc.signMessageHandler, authMiddleware)
 	e.POST("/api/wallet/sync", httpSvc.walletSyncHandler, authMiddleware)
 	e.GET("/api/wallet/capabilities", httpSvc.capabilitiesHandler, authMiddleware)
+	e.GET("/api/wallet/summary", httpSvc.walletSummaryHandler, authMiddleware)
 	e.POST("/api/payments/:invoice", httpSvc.sendPaymentHandler, authMiddleware)
 	e.POST("/api/invoices", httpSvc.makeInvoiceHandler, authMiddleware)
+	e.GET("/api/offers", httpSvc.listOffersHandler, authMiddleware)
+	e.POST("/api/offers", httpSvc.createOfferHandler, authMiddleware)
+	e.GET("/api/swaps", httpSvc.listSwapsHandler, authMiddleware)
+	e.POST("/api/swaps/in", httpSvc.swapInHandler, authMiddleware)
+	e.POST("/api/swaps/out", httpSvc.swapOutHandler, authMiddleware)
+	e.GET("/api/swaps/auto", httpSvc.getAutoSwapConfigHandler, authMiddleware)
+	e.PATCH("/api/swaps/auto", httpSvc.updateAutoSwapConfigHandler, authMiddleware)
 	e.GET("/api/transactions", httpSvc.listTransactionsHandler, authMiddleware)
 	e.GET("/api/transactions/:paymentHash", httpSvc.lookupTransactionHandler, authMiddleware)
+	e.PUT("/api/transactions/:id/tags", httpSvc.setTransactionTagsHandler, authMiddleware)
+	e.PUT("/api/transactions/:id/note", httpSvc.setTransactionNoteHandler, authMiddleware)
+	e.GET("/api/transaction-tags", httpSvc.listTransactionTagsHandler, authMiddleware)
+	e.GET("/api/tag-rules", httpSvc.listTagRulesHandler, authMiddleware)
+	e.POST("/api/tag-rules", httpSvc.createTagRuleHandler, authMiddleware)
+	e.DELETE("/api/tag-rules/:id", httpSvc.deleteTagRuleHandler, authMiddleware)
+	e.GET("/api/transactions-feed", httpSvc.transactionsFeedHandler, authMiddleware)
+	e.GET("/api/activities", httpSvc.listActivitiesHandler, authMiddleware)
+	e.GET("/api/notifications", httpSvc.listNotificationsHandler, authMiddleware)
+	e.POST("/api/notifications/read", httpSvc.markNotificationsReadHandler, authMiddleware)
+	e.POST("/api/transactions-feed", httpSvc.createTransactionsFeedHandler, authMiddleware)
+	e.DELETE("/api/transactions-feed", httpSvc.deleteTransactionsFeedHandler, authMiddleware)
+	e.GET("/api/reports/:year/:month", httpSvc.monthlyReportHandler, authMiddleware)
+	e.GET("/api/reports/:year/:month/export", httpSvc.exportMonthlyReportHandler, authMiddleware)
+	// authenticated by the token in the url, so that feed readers can subscribe
+	e.GET("/api/feeds/transactions", httpSvc.transactionsFeedAtomHandler)
+	e.GET("/api/v1/transactions/export", httpSvc.exportTransactionsHandler, httpSvc.exportAuthMiddleware)
+	e.POST("/api/nip47", httpSvc.nip47Handler, middleware.BodyLimit("64K"))
+	if httpSvc.embeddedRelay != nil {
+		e.GET(config.EmbeddedRelayPath, echo.WrapHandler(httpSvc.embeddedRelay))
+	}
+	e.GET("/api/payment-confirmations", httpSvc.listPaymentConfirmationsHandler, authMiddleware)
+	e.POST("/api/payment-confirmations/:id", httpSvc.confirmPaymentHandler, authMiddleware)
+	e.POST("/api/payment-confirmations/authenticator", httpSvc.setupPaymentConfirmationAuthenticatorHandler, authMiddleware, unlockRateLimiter)
 	e.GET("/api/balances", httpSvc.balancesHandler, authMiddleware)
 	e.POST("/api/reset-router", httpSvc.resetRouterHandler, authMiddleware)
 	e.POST("/api/stop", httpSvc.stopHandler, authMiddleware)
 
 	httpSvc.albyHttpSvc.RegisterSharedRoutes(e, authMiddleware)
+	httpSvc.lnurlHttpSvc.RegisterSharedRoutes(e)
 
 	e.GET("/api/mempool", httpSvc.mempoolApiHandler, authMiddleware)
 
 	e.POST("/api/send-payment-probes", httpSvc.sendPaymentProbesHandler, authMiddleware)
 	e.POST("/api/send-spontaneous-payment-probes", httpSvc.sendSpontaneousPaymentProbesHandler, authMiddleware)
 	e.GET("/api/log/:type", httpSvc.getLogOutputHandler, authMiddleware)
+	e.GET("/api/log-settings", httpSvc.logSettingsHandler, authMiddleware)
+	e.PATCH("/api/log-settings", httpSvc.updateLogSettingsHandler, authMiddleware)
 
 	e.POST("/api/backup", httpSvc.createBackupHandler, authMiddleware)
+	e.GET("/api/channel-backup", httpSvc.channelBackupStatusHandler, authMiddleware)
+	e.POST("/api/channel-backup/export", httpSvc.exportChannelBackupHandler, authMiddleware)
+	e.POST("/api/channel-backup/verify", httpSvc.verifyChannelBackupHandler, authMiddleware)
 	e.POST("/api/restore", httpSvc.restoreBackupHandler)
 
-	frontend.RegisterHandlers(e)
+	frontend.RegisterHandlers(e, httpSvc.cfg.GetEnv().GetBranding())
 }
 
 func (httpSvc *HttpService) csrfHandler(c echo.Context) error {
@@ -149,6 +280,16 @@ func (httpSvc *HttpService) infoHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, responseBody)
 }
 
+func (httpSvc *HttpService) healthHandler(c echo.Context) error {
+	err := httpSvc.api.CheckHealth()
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (httpSvc *HttpService) encryptedMnemonicHandler(c echo.Context) error {
 	responseBody := httpSvc.api.GetEncryptedMnemonic()
 	return c.JSON(http.StatusOK, responseBody)
@@ -172,6 +313,28 @@ func (httpSvc *HttpService) backupReminderHandler(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (httpSvc *HttpService) featuresListHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, httpSvc.api.ListFeatures())
+}
+
+func (httpSvc *HttpService) featuresUpdateHandler(c echo.Context) error {
+	var updateFeatureRequest api.UpdateFeatureRequest
+	if err := c.Bind(&updateFeatureRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	err := httpSvc.api.UpdateFeature(c.Param("name"), &updateFeatureRequest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Failed to update feature: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (httpSvc *HttpService) startHandler(c echo.Context) error {
 	var startRequest api.StartRequest
 	if err := c.Bind(&startRequest); err != nil {
@@ -180,10 +343,8 @@ func (httpSvc *HttpService) startHandler(c echo.Context) error {
 		})
 	}
 
-	if !httpSvc.cfg.CheckUnlockPassword(startRequest.UnlockPassword) {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Message: "Invalid password",
-		})
+	if ok, err := httpSvc.checkUnlockPassword(c, startRequest.UnlockPassword); !ok {
+		return err
 	}
 
 	err := httpSvc.saveSessionCookie(c)
@@ -197,7 +358,7 @@ func (httpSvc *HttpService) startHandler(c echo.Context) error {
 	go func() {
 		err := httpSvc.api.Start(&startRequest)
 		if err != nil {
-			logger.Logger.WithError(err).Error("Failed to start node")
+			logger.HTTP.WithError(err).Error("Failed to start node")
 		}
 	}()
 
@@ -212,10 +373,8 @@ func (httpSvc *HttpService) unlockHandler(c echo.Context) error {
 		})
 	}
 
-	if !httpSvc.cfg.CheckUnlockPassword(unlockRequest.UnlockPassword) {
-		return c.JSON(http.StatusUnauthorized, ErrorResponse{
-			Message: "Invalid password",
-		})
+	if ok, err := httpSvc.checkUnlockPassword(c, unlockRequest.UnlockPassword); !ok {
+		return err
 	}
 
 	err := httpSvc.saveSessionCookie(c)
@@ -241,6 +400,10 @@ func (httpSvc *HttpService) changeUnlockPasswordHandler(c echo.Context) error {
 		})
 	}
 
+	if ok, err := httpSvc.checkUnlockPassword(c, changeUnlockPasswordRequest.CurrentUnlockPassword); !ok {
+		return err
+	}
+
 	err := httpSvc.api.ChangeUnlockPassword(&changeUnlockPasswordRequest)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -248,43 +411,65 @@ func (httpSvc *HttpService) changeUnlockPasswordHandler(c echo.Context) error {
 		})
 	}
 
+	err = httpSvc.revokeAllSessions()
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to revoke sessions after changing the unlock password")
+	}
+
 	return c.NoContent(http.StatusNoContent)
 }
 
-func (httpSvc *HttpService) isUnlocked(c echo.Context) bool {
-	sess, _ := session.Get(sessionCookieName, c)
-	return sess.Values[sessionCookieAuthKey] == true
+func (httpSvc *HttpService) nostrKeysHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, httpSvc.api.GetNostrKeys())
 }
 
-func (httpSvc *HttpService) saveSessionCookie(c echo.Context) error {
-	sess, _ := session.Get("session", c)
-	sess.Options = &sessions.Options{
-		Path:     "/",
-		MaxAge:   86400 * 7,
-		HttpOnly: true,
+func (httpSvc *HttpService) rotateNostrKeysHandler(c echo.Context) error {
+	var rotateNostrKeysRequest api.RotateNostrKeysRequest
+	if err := c.Bind(&rotateNostrKeysRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
 	}
-	sess.Values[sessionCookieAuthKey] = true
-	err := sess.Save(c.Request(), c.Response())
+
+	if ok, err := httpSvc.checkUnlockPassword(c, rotateNostrKeysRequest.UnlockPassword); !ok {
+		return err
+	}
+
+	nostrKeys, err := httpSvc.api.RotateNostrKeys(c.Request().Context(), &rotateNostrKeysRequest)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to save session")
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to rotate keys: %s", err.Error()),
+		})
 	}
-	return err
+
+	return c.JSON(http.StatusOK, nostrKeys)
 }
 
-func (httpSvc *HttpService) logoutHandler(c echo.Context) error {
-	sess, err := session.Get(sessionCookieName, c)
+// checkUnlockPassword verifies the unlock password and locks out clients after repeated failures.
+// If the password is not accepted the response has already been written.
+func (httpSvc *HttpService) checkUnlockPassword(c echo.Context, unlockPassword string) (bool, error) {
+	source := c.RealIP()
+	err := httpSvc.lockoutSvc.Check(source)
+	if retryAfter, lockedOut := lockout.RetryAfter(err); lockedOut {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		return false, c.JSON(http.StatusTooManyRequests, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: "Failed to get session",
+		return false, c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to check login attempts: %s", err.Error()),
 		})
 	}
-	sess.Options.MaxAge = -1
-	if err := sess.Save(c.Request(), c.Response()); err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: "Failed to save session",
+
+	if !httpSvc.cfg.CheckUnlockPassword(unlockPassword) {
+		httpSvc.lockoutSvc.RecordFailure(source)
+		return false, c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Message: "Invalid password",
 		})
 	}
-	return c.NoContent(http.StatusNoContent)
+	httpSvc.lockoutSvc.RecordSuccess(source)
+	return true, nil
 }
 
 func (httpSvc *HttpService) channelsListHandler(c echo.Context) error {
@@ -382,101 +567,654 @@ func (httpSvc *HttpService) nodeNetworkGraphHandler(c echo.Context) error {
 
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: err.Error(),
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, info)
+}
+
+func (httpSvc *HttpService) balancesHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	balances, err := httpSvc.api.GetBalances(ctx)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, balances)
+}
+
+func (httpSvc *HttpService) walletSummaryHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	summary, err := httpSvc.api.GetWalletSummary(ctx)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, summary)
+}
+
+func (httpSvc *HttpService) sendPaymentHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var sendPaymentRequest api.SendPaymentRequest
+	if err := c.Bind(&sendPaymentRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	paymentResponse, err := httpSvc.api.SendPayment(ctx, c.Param("invoice"), &sendPaymentRequest)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, paymentResponse)
+}
+
+func (httpSvc *HttpService) makeInvoiceHandler(c echo.Context) error {
+	var makeInvoiceRequest api.MakeInvoiceRequest
+	if err := c.Bind(&makeInvoiceRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	invoice, err := httpSvc.api.CreateInvoice(c.Request().Context(), makeInvoiceRequest.Amount, makeInvoiceRequest.Description)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, invoice)
+}
+
+func (httpSvc *HttpService) listOffersHandler(c echo.Context) error {
+	offers, err := httpSvc.api.ListOffers(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, offers)
+}
+
+func (httpSvc *HttpService) createOfferHandler(c echo.Context) error {
+	var createOfferRequest api.CreateOfferRequest
+	if err := c.Bind(&createOfferRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	offer, err := httpSvc.api.CreateOffer(c.Request().Context(), &createOfferRequest)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to create offer: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, offer)
+}
+
+func (httpSvc *HttpService) listSwapsHandler(c echo.Context) error {
+	swaps, err := httpSvc.api.ListSwaps(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, swaps)
+}
+
+func (httpSvc *HttpService) swapInHandler(c echo.Context) error {
+	var swapInRequest api.SwapInRequest
+	if err := c.Bind(&swapInRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	swap, err := httpSvc.api.SwapIn(c.Request().Context(), &swapInRequest)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to swap in: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, swap)
+}
+
+func (httpSvc *HttpService) swapOutHandler(c echo.Context) error {
+	var swapOutRequest api.SwapOutRequest
+	if err := c.Bind(&swapOutRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	swap, err := httpSvc.api.SwapOut(c.Request().Context(), &swapOutRequest)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to swap out: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, swap)
+}
+
+func (httpSvc *HttpService) getAutoSwapConfigHandler(c echo.Context) error {
+	autoSwapConfig, err := httpSvc.api.GetAutoSwapConfig()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, autoSwapConfig)
+}
+
+func (httpSvc *HttpService) updateAutoSwapConfigHandler(c echo.Context) error {
+	var autoSwapConfig api.AutoSwapConfig
+	if err := c.Bind(&autoSwapConfig); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	err := httpSvc.api.UpdateAutoSwapConfig(&autoSwapConfig)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Failed to update auto swap settings: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (httpSvc *HttpService) lookupTransactionHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	transaction, err := httpSvc.api.LookupInvoice(ctx, c.Param("paymentHash"))
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, transaction)
+}
+
+func (httpSvc *HttpService) listTransactionsHandler(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	limit := uint64(20)
+	offset := uint64(0)
+
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if parsedLimit, err := strconv.ParseUint(limitParam, 10, 64); err == nil {
+			limit = parsedLimit
+		}
+	}
+
+	if offsetParam := c.QueryParam("offset"); offsetParam != "" {
+		if parsedOffset, err := strconv.ParseUint(offsetParam, 10, 64); err == nil {
+			offset = parsedOffset
+		}
+	}
+
+	transactions, err := httpSvc.api.ListTransactions(ctx, limit, offset, c.QueryParam("tag"), c.QueryParam("q"))
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, transactions)
+}
+
+func (httpSvc *HttpService) setTransactionTagsHandler(c echo.Context) error {
+	transactionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Invalid transaction id: %s", err.Error()),
+		})
+	}
+
+	var setTransactionTagsRequest api.SetTransactionTagsRequest
+	if err := c.Bind(&setTransactionTagsRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	err = httpSvc.api.SetTransactionTags(uint(transactionId), &setTransactionTagsRequest)
+	if errors.Is(err, transactions.NewNotFoundError()) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Message: "No settled transaction with this id",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Failed to set transaction tags: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (httpSvc *HttpService) setTransactionNoteHandler(c echo.Context) error {
+	transactionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Invalid transaction id: %s", err.Error()),
+		})
+	}
+
+	var setTransactionNoteRequest api.SetTransactionNoteRequest
+	if err := c.Bind(&setTransactionNoteRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	err = httpSvc.api.SetTransactionNote(uint(transactionId), &setTransactionNoteRequest)
+	if errors.Is(err, transactions.NewNotFoundError()) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Message: "No transaction with this id",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Failed to save note: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (httpSvc *HttpService) listTransactionTagsHandler(c echo.Context) error {
+	tags, err := httpSvc.api.ListTransactionTags()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to list tags: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+func (httpSvc *HttpService) listTagRulesHandler(c echo.Context) error {
+	rules, err := httpSvc.api.ListTagRules()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to list tag rules: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, rules)
+}
+
+func (httpSvc *HttpService) createTagRuleHandler(c echo.Context) error {
+	var createTagRuleRequest api.CreateTagRuleRequest
+	if err := c.Bind(&createTagRuleRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	rule, err := httpSvc.api.CreateTagRule(&createTagRuleRequest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Failed to create tag rule: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, rule)
+}
+
+func (httpSvc *HttpService) deleteTagRuleHandler(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Invalid tag rule id: %s", err.Error()),
+		})
+	}
+
+	err = httpSvc.api.DeleteTagRule(uint(id))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to delete tag rule: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (httpSvc *HttpService) listActivitiesHandler(c echo.Context) error {
+	cursor := uint64(0)
+	limit := uint64(0)
+	types := []string{}
+
+	if cursorParam := c.QueryParam("cursor"); cursorParam != "" {
+		if parsedCursor, err := strconv.ParseUint(cursorParam, 10, 64); err == nil {
+			cursor = parsedCursor
+		}
+	}
+
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if parsedLimit, err := strconv.ParseUint(limitParam, 10, 64); err == nil {
+			limit = parsedLimit
+		}
+	}
+
+	if typesParam := c.QueryParam("types"); typesParam != "" {
+		types = strings.Split(typesParam, ",")
+	}
+
+	activities, err := httpSvc.api.ListActivities(uint(cursor), limit, types)
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, activities)
+}
+
+func (httpSvc *HttpService) listNotificationsHandler(c echo.Context) error {
+	notifications, err := httpSvc.api.ListNotifications()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, notifications)
+}
+
+func (httpSvc *HttpService) markNotificationsReadHandler(c echo.Context) error {
+	var markNotificationsReadRequest api.MarkNotificationsReadRequest
+	if err := c.Bind(&markNotificationsReadRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	err := httpSvc.api.MarkNotificationsRead(&markNotificationsReadRequest)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to mark notifications as read: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (httpSvc *HttpService) transactionsFeedHandler(c echo.Context) error {
+	transactionsFeed, err := httpSvc.api.GetTransactionsFeed()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+	return c.JSON(http.StatusOK, transactionsFeed)
+}
+
+func (httpSvc *HttpService) createTransactionsFeedHandler(c echo.Context) error {
+	transactionsFeed, err := httpSvc.api.CreateTransactionsFeed()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to create transactions feed: %s", err.Error()),
+		})
+	}
+	return c.JSON(http.StatusOK, transactionsFeed)
+}
+
+func (httpSvc *HttpService) deleteTransactionsFeedHandler(c echo.Context) error {
+	err := httpSvc.api.DeleteTransactionsFeed()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to delete transactions feed: %s", err.Error()),
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (httpSvc *HttpService) transactionsFeedAtomHandler(c echo.Context) error {
+	var feed bytes.Buffer
+	err := httpSvc.api.WriteTransactionsFeed(c.Request().Context(), c.QueryParam("token"), &feed)
+	if errors.Is(err, api.NewInvalidFeedTokenError()) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to write transactions feed")
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Message: fmt.Sprintf("Failed to get transactions feed: %s", err.Error()),
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "private, no-cache")
+	return c.Blob(http.StatusOK, "application/atom+xml; charset=utf-8", feed.Bytes())
+}
+
+// exportTransactionsHandler writes one transaction per line (JSON Lines). The cursor to
+// continue with is in the X-Next-Cursor header and in the cursor field of each transaction.
+func (httpSvc *HttpService) exportTransactionsHandler(c echo.Context) error {
+	var since, until, limit uint64
+	params := []struct {
+		name  string
+		value *uint64
+	}{{"since", &since}, {"until", &until}, {"limit", &limit}}
+	for _, param := range params {
+		value := c.QueryParam(param.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Message: fmt.Sprintf("Invalid %s: %s", param.name, err.Error()),
+			})
+		}
+		*param.value = parsed
+	}
+
+	var cursor *transactions.ExportCursor
+	if cursorParam := c.QueryParam("cursor"); cursorParam != "" {
+		var err error
+		cursor, err = transactions.DecodeExportCursor(cursorParam)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Message: fmt.Sprintf("Bad request: %s", err.Error()),
+			})
+		}
+	}
+
+	exportResponse, err := httpSvc.api.ExportTransactions(since, until, limit, cursor)
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to export transactions")
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to export transactions: %s", err.Error()),
 		})
 	}
 
-	return c.JSON(http.StatusOK, info)
-}
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, transaction := range exportResponse.Transactions {
+		err := encoder.Encode(transaction)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Message: fmt.Sprintf("Failed to export transactions: %s", err.Error()),
+			})
+		}
+	}
 
-func (httpSvc *HttpService) balancesHandler(c echo.Context) error {
-	ctx := c.Request().Context()
+	c.Response().Header().Set(echo.HeaderCacheControl, "private, no-cache")
+	c.Response().Header().Set("X-Next-Cursor", exportResponse.NextCursor)
+	c.Response().Header().Set("X-Has-More", strconv.FormatBool(exportResponse.HasMore))
+	return c.Blob(http.StatusOK, "application/x-ndjson", body.Bytes())
+}
 
-	balances, err := httpSvc.api.GetBalances(ctx)
+func (httpSvc *HttpService) monthlyReportHandler(c echo.Context) error {
+	year, month, err := parseReportMonth(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
 
+	report, err := httpSvc.api.GetMonthlyReport(c.Request().Context(), year, month)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: err.Error(),
+			Message: fmt.Sprintf("Failed to get monthly report: %s", err.Error()),
 		})
 	}
-
-	return c.JSON(http.StatusOK, balances)
+	return c.JSON(http.StatusOK, report)
 }
 
-func (httpSvc *HttpService) sendPaymentHandler(c echo.Context) error {
-	ctx := c.Request().Context()
-
-	paymentResponse, err := httpSvc.api.SendPayment(ctx, c.Param("invoice"))
+func (httpSvc *HttpService) exportMonthlyReportHandler(c echo.Context) error {
+	year, month, err := parseReportMonth(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+	format := c.QueryParam("format")
+	if format == "" {
+		format = api.REPORT_FORMAT_HTML
+	}
 
+	report, err := httpSvc.api.ExportMonthlyReport(c.Request().Context(), year, month, format)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: err.Error(),
+			Message: fmt.Sprintf("Failed to export monthly report: %s", err.Error()),
 		})
 	}
 
-	return c.JSON(http.StatusOK, paymentResponse)
+	if format == api.REPORT_FORMAT_PDF {
+		c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=report-%d-%02d.pdf", year, month))
+		return c.Blob(http.StatusOK, "application/pdf", report)
+	}
+	// opened in a new tab, so that it can be printed or saved from the browser
+	return c.HTMLBlob(http.StatusOK, report)
 }
 
-func (httpSvc *HttpService) makeInvoiceHandler(c echo.Context) error {
-	var makeInvoiceRequest api.MakeInvoiceRequest
-	if err := c.Bind(&makeInvoiceRequest); err != nil {
+func parseReportMonth(c echo.Context) (int, int, error) {
+	year, err := strconv.Atoi(c.Param("year"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid year: %s", c.Param("year"))
+	}
+	month, err := strconv.Atoi(c.Param("month"))
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, fmt.Errorf("invalid month: %s", c.Param("month"))
+	}
+	return year, month, nil
+}
+
+// nip47Handler answers a NIP-47 request event synchronously, for clients that cannot reach the relay
+func (httpSvc *HttpService) nip47Handler(c echo.Context) error {
+	var event nostr.Event
+	if err := c.Bind(&event); err != nil {
 		return c.JSON(http.StatusBadRequest, ErrorResponse{
 			Message: fmt.Sprintf("Bad request: %s", err.Error()),
 		})
 	}
 
-	invoice, err := httpSvc.api.CreateInvoice(c.Request().Context(), makeInvoiceRequest.Amount, makeInvoiceRequest.Description)
-
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+	responses, err := httpSvc.api.HandleNip47Request(c.Request().Context(), &event)
+	if errors.Is(err, api.NewNip47RequestNotAnsweredError()) {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
 			Message: err.Error(),
 		})
 	}
+	if err != nil {
+		return c.JSON(http.StatusServiceUnavailable, ErrorResponse{
+			Message: fmt.Sprintf("Failed to handle request: %s", err.Error()),
+		})
+	}
 
-	return c.JSON(http.StatusOK, invoice)
+	return c.JSON(http.StatusOK, responses)
 }
 
-func (httpSvc *HttpService) lookupTransactionHandler(c echo.Context) error {
-	ctx := c.Request().Context()
+func (httpSvc *HttpService) listPaymentConfirmationsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, httpSvc.api.ListPaymentConfirmations())
+}
 
-	transaction, err := httpSvc.api.LookupInvoice(ctx, c.Param("paymentHash"))
+func (httpSvc *HttpService) confirmPaymentHandler(c echo.Context) error {
+	transactionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Invalid transaction id: %s", err.Error()),
+		})
+	}
+
+	var confirmPaymentRequest api.ConfirmPaymentRequest
+	if err := c.Bind(&confirmPaymentRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
 
+	err = httpSvc.api.ConfirmPayment(uint(transactionId), &confirmPaymentRequest)
+	if errors.Is(err, transactions.NewNotFoundError()) {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Message: "No payment is waiting for confirmation with this id",
+		})
+	}
+	if errors.Is(err, transactions.NewInvalidConfirmationCodeError()) {
+		return c.JSON(http.StatusUnauthorized, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: err.Error(),
+			Message: fmt.Sprintf("Failed to confirm payment: %s", err.Error()),
 		})
 	}
 
-	return c.JSON(http.StatusOK, transaction)
+	return c.NoContent(http.StatusNoContent)
 }
 
-func (httpSvc *HttpService) listTransactionsHandler(c echo.Context) error {
-	ctx := c.Request().Context()
-
-	limit := uint64(20)
-	offset := uint64(0)
-
-	if limitParam := c.QueryParam("limit"); limitParam != "" {
-		if parsedLimit, err := strconv.ParseUint(limitParam, 10, 64); err == nil {
-			limit = parsedLimit
-		}
+func (httpSvc *HttpService) setupPaymentConfirmationAuthenticatorHandler(c echo.Context) error {
+	var setupRequest api.SetupPaymentConfirmationAuthenticatorRequest
+	if err := c.Bind(&setupRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
 	}
 
-	if offsetParam := c.QueryParam("offset"); offsetParam != "" {
-		if parsedOffset, err := strconv.ParseUint(offsetParam, 10, 64); err == nil {
-			offset = parsedOffset
-		}
+	if ok, err := httpSvc.checkUnlockPassword(c, setupRequest.UnlockPassword); !ok {
+		return err
 	}
 
-	transactions, err := httpSvc.api.ListTransactions(ctx, limit, offset)
-
+	setupResponse, err := httpSvc.api.SetupPaymentConfirmationAuthenticator(&setupRequest)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Message: err.Error(),
+			Message: fmt.Sprintf("Failed to set up authenticator: %s", err.Error()),
 		})
 	}
 
-	return c.JSON(http.StatusOK, transactions)
+	return c.JSON(http.StatusOK, setupResponse)
 }
 
 func (httpSvc *HttpService) walletSyncHandler(c echo.Context) error {
@@ -495,7 +1233,7 @@ func (httpSvc *HttpService) mempoolApiHandler(c echo.Context) error {
 
 	response, err := httpSvc.api.RequestMempoolApi(endpoint)
 	if err != nil {
-		logger.Logger.WithField("endpoint", endpoint).WithError(err).Error("Failed to request mempool API")
+		logger.HTTP.WithField("endpoint", endpoint).WithError(err).Error("Failed to request mempool API")
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Message: fmt.Sprintf("Failed to request mempool API: %s", err.Error()),
 		})
@@ -507,7 +1245,7 @@ func (httpSvc *HttpService) mempoolApiHandler(c echo.Context) error {
 func (httpSvc *HttpService) capabilitiesHandler(c echo.Context) error {
 	response, err := httpSvc.api.GetWalletCapabilities(c.Request().Context())
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to request wallet capabilities")
+		logger.HTTP.WithError(err).Error("Failed to request wallet capabilities")
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Message: fmt.Sprintf("Failed to request wallet capabilities: %s", err.Error()),
 		})
@@ -583,6 +1321,46 @@ func (httpSvc *HttpService) disconnectPeerHandler(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (httpSvc *HttpService) listWatchtowersHandler(c echo.Context) error {
+	watchtowers, err := httpSvc.api.ListWatchtowers(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to list watchtowers: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, watchtowers)
+}
+
+func (httpSvc *HttpService) addWatchtowerHandler(c echo.Context) error {
+	var addWatchtowerRequest api.AddWatchtowerRequest
+	if err := c.Bind(&addWatchtowerRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	err := httpSvc.api.AddWatchtower(c.Request().Context(), &addWatchtowerRequest)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to add watchtower: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (httpSvc *HttpService) removeWatchtowerHandler(c echo.Context) error {
+	err := httpSvc.api.RemoveWatchtower(c.Request().Context(), c.Param("pubkey"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to remove watchtower: %s", err.Error()),
+		})
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (httpSvc *HttpService) closeChannelHandler(c echo.Context) error {
 	ctx := c.Request().Context()
 
@@ -680,7 +1458,7 @@ func (httpSvc *HttpService) redeemOnchainFundsHandler(c echo.Context) error {
 		})
 	}
 
-	redeemOnchainFundsResponse, err := httpSvc.api.RedeemOnchainFunds(ctx, redeemOnchainFundsRequest.ToAddress)
+	redeemOnchainFundsResponse, err := httpSvc.api.RedeemOnchainFunds(ctx, redeemOnchainFundsRequest.ToAddress, redeemOnchainFundsRequest.Amount, redeemOnchainFundsRequest.FeeRate, redeemOnchainFundsRequest.SendAll)
 
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
@@ -761,7 +1539,7 @@ func (httpSvc *HttpService) appsUpdateHandler(c echo.Context) error {
 	err := httpSvc.api.UpdateApp(&dbApp, &requestData)
 
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to update app")
+		logger.HTTP.WithError(err).Error("Failed to update app")
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Message: fmt.Sprintf("Failed to update app: %v", err),
 		})
@@ -799,6 +1577,119 @@ func (httpSvc *HttpService) appsDeleteHandler(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+func (httpSvc *HttpService) appsCreateLNURLWithdrawHandler(c echo.Context) error {
+	var requestData api.CreateLNURLWithdrawRequest
+	if err := c.Bind(&requestData); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	// TODO: move this to DB service
+	dbApp := db.App{}
+	findResult := httpSvc.db.Where("nostr_pubkey = ?", c.Param("pubkey")).First(&dbApp)
+
+	if findResult.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Message: "App does not exist",
+		})
+	}
+
+	responseBody, err := httpSvc.api.CreateLNURLWithdraw(&dbApp, &requestData)
+
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to create lnurl withdraw")
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to create lnurl withdraw: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, responseBody)
+}
+
+func (httpSvc *HttpService) nip47UsageHandler(c echo.Context) error {
+	days := uint64(0)
+	if daysParam := c.QueryParam("days"); daysParam != "" {
+		parsedDays, err := strconv.ParseUint(daysParam, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, ErrorResponse{
+				Message: fmt.Sprintf("Invalid days: %s", err.Error()),
+			})
+		}
+		days = parsedDays
+	}
+
+	usage, err := httpSvc.api.GetNip47Usage(days)
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to get NIP-47 usage")
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to get NIP-47 usage: %s", err.Error()),
+		})
+	}
+	return c.JSON(http.StatusOK, usage)
+}
+
+func (httpSvc *HttpService) appsListRequestsHandler(c echo.Context) error {
+	limit := uint64(20)
+	offset := uint64(0)
+
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if parsedLimit, err := strconv.ParseUint(limitParam, 10, 64); err == nil {
+			limit = parsedLimit
+		}
+	}
+
+	if offsetParam := c.QueryParam("offset"); offsetParam != "" {
+		if parsedOffset, err := strconv.ParseUint(offsetParam, 10, 64); err == nil {
+			offset = parsedOffset
+		}
+	}
+
+	// TODO: move this to DB service
+	dbApp := db.App{}
+	findResult := httpSvc.db.Where("nostr_pubkey = ?", c.Param("pubkey")).First(&dbApp)
+
+	if findResult.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Message: "App does not exist",
+		})
+	}
+
+	appRequests, err := httpSvc.api.ListAppRequests(&dbApp, limit, offset)
+
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to list app requests")
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to list app requests: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, appRequests)
+}
+
+func (httpSvc *HttpService) appsRegenerateSecretHandler(c echo.Context) error {
+	// TODO: move this to DB service
+	dbApp := db.App{}
+	findResult := httpSvc.db.Where("nostr_pubkey = ?", c.Param("pubkey")).First(&dbApp)
+
+	if findResult.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Message: "App does not exist",
+		})
+	}
+
+	responseBody, err := httpSvc.api.RegenerateAppSecret(&dbApp)
+
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to regenerate app secret")
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to regenerate app secret: %v", err),
+		})
+	}
+
+	return c.JSON(http.StatusOK, responseBody)
+}
+
 func (httpSvc *HttpService) appsCreateHandler(c echo.Context) error {
 	var requestData api.CreateAppRequest
 	if err := c.Bind(&requestData); err != nil {
@@ -810,7 +1701,7 @@ func (httpSvc *HttpService) appsCreateHandler(c echo.Context) error {
 	responseBody, err := httpSvc.api.CreateApp(&requestData)
 
 	if err != nil {
-		logger.Logger.WithField("requestData", requestData).WithError(err).Error("Failed to save app")
+		logger.HTTP.WithField("requestData", requestData).WithError(err).Error("Failed to save app")
 		return c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Message: fmt.Sprintf("Failed to save app: %v", err),
 		})
@@ -898,6 +1789,28 @@ func (httpSvc *HttpService) getLogOutputHandler(c echo.Context) error {
 	return c.JSON(http.StatusOK, getLogResponse)
 }
 
+func (httpSvc *HttpService) logSettingsHandler(c echo.Context) error {
+	return c.JSON(http.StatusOK, httpSvc.api.GetLogSettings())
+}
+
+func (httpSvc *HttpService) updateLogSettingsHandler(c echo.Context) error {
+	var updateLogSettingsRequest api.UpdateLogSettingsRequest
+	if err := c.Bind(&updateLogSettingsRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	logSettings, err := httpSvc.api.UpdateLogSettings(&updateLogSettingsRequest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Failed to update log settings: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, logSettings)
+}
+
 func (httpSvc *HttpService) createBackupHandler(c echo.Context) error {
 	var backupRequest api.BasicBackupRequest
 	if err := c.Bind(&backupRequest); err != nil {
@@ -925,13 +1838,58 @@ func (httpSvc *HttpService) createBackupHandler(c echo.Context) error {
 	return nil
 }
 
+func (httpSvc *HttpService) channelBackupStatusHandler(c echo.Context) error {
+	status, err := httpSvc.api.GetChannelBackupStatus(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+func (httpSvc *HttpService) exportChannelBackupHandler(c echo.Context) error {
+	backupFile, err := httpSvc.api.ExportChannelBackup(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to export channel backup: %s", err.Error()),
+		})
+	}
+
+	c.Response().Header().Set("Content-Disposition", "attachment; filename=channel-backup.json")
+	return c.Blob(http.StatusOK, "application/json", backupFile)
+}
+
+func (httpSvc *HttpService) verifyChannelBackupHandler(c echo.Context) error {
+	var verifyChannelBackupRequest api.VerifyChannelBackupRequest
+	if err := c.Bind(&verifyChannelBackupRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Bad request: %s", err.Error()),
+		})
+	}
+
+	verifyChannelBackupResponse, err := httpSvc.api.VerifyChannelBackup(c.Request().Context(), &verifyChannelBackupRequest)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Failed to verify channel backup: %s", err.Error()),
+		})
+	}
+
+	return c.JSON(http.StatusOK, verifyChannelBackupResponse)
+}
+
 func (httpSvc *HttpService) restoreBackupHandler(c echo.Context) error {
 	info, err := httpSvc.api.GetInfo(c.Request().Context())
 	if err != nil {
-		return err
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to get info: %s", err.Error()),
+		})
 	}
 	if info.SetupCompleted {
-		return errors.New("Setup already completed")
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: "Setup already completed",
+		})
 	}
 
 	password := c.FormValue("unlockPassword")
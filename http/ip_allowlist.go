@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"slices"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/getAlby/hub/config"
+)
+
+// routes that stay reachable from anywhere when ADMIN_IP_ALLOWLIST is set
+var publicRoutes = []string{
+	"/api/health",
+	"/.well-known/lnurlp/:username",
+	"/api/lnurlp/:username/callback",
+	"/api/lnurlw/callback",
+	"/api/lnurlw/:k1",
+	// protected by the feed token, feed readers usually fetch from their own servers
+	"/api/feeds/transactions",
+	"/api/v1/transactions/export",
+	// apps authenticate with the signature of the request event
+	"/api/nip47",
+	config.EmbeddedRelayPath,
+}
+
+// configureIPAllowlist restricts the web UI and API to ADMIN_IP_ALLOWLIST.
+// Client IPs are only taken from X-Forwarded-For if the request comes from one of TRUSTED_PROXIES,
+// otherwise the address of the connection is used.
+func (httpSvc *HttpService) configureIPAllowlist(e *echo.Echo) error {
+	env := httpSvc.cfg.GetEnv()
+	allowlist, err := config.ParseIPRanges(env.AdminIPAllowlist)
+	if err != nil {
+		return err
+	}
+	trustedProxies, err := config.ParseIPRanges(env.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	// without an extractor echo trusts the X-Forwarded-For and X-Real-IP headers of any client,
+	// which would let clients pick their IP for the allowlist and the unlock lockout
+	e.IPExtractor = echo.ExtractIPDirect()
+	if len(trustedProxies) > 0 {
+		trustOptions := []echo.TrustOption{
+			echo.TrustLoopback(false),
+			echo.TrustLinkLocal(false),
+			echo.TrustPrivateNet(false),
+		}
+		for _, trustedProxy := range trustedProxies {
+			trustOptions = append(trustOptions, echo.TrustIPRange(trustedProxy))
+		}
+		e.IPExtractor = echo.ExtractIPFromXFFHeader(trustOptions...)
+	}
+
+	if len(allowlist) == 0 {
+		return nil
+	}
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if slices.Contains(publicRoutes, c.Path()) || isAllowedIP(c.RealIP(), allowlist) {
+				return next(c)
+			}
+			return c.NoContent(http.StatusForbidden)
+		}
+	})
+	return nil
+}
+
+func isAllowedIP(remoteIP string, allowlist []*net.IPNet) bool {
+	ip := net.ParseIP(remoteIP)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range allowlist {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/lockout"
+	"github.com/getAlby/hub/tests"
+)
+
+func newUnlockTestServer(t *testing.T, trustedProxies string) *echo.Echo {
+	svc, err := tests.CreateTestService()
+	require.NoError(t, err)
+	cfg := config.NewConfig(&config.AppConfig{
+		Workdir:        ".test",
+		TrustedProxies: trustedProxies,
+	}, svc.DB)
+	cfg.Setup(tests.UnlockPassword)
+
+	httpSvc := &HttpService{
+		cfg:        cfg,
+		lockoutSvc: lockout.NewLockoutService(svc.DB, svc.EventPublisher),
+	}
+	e := echo.New()
+	require.NoError(t, httpSvc.configureIPAllowlist(e))
+	e.POST("/unlock", func(c echo.Context) error {
+		var request struct {
+			UnlockPassword string `json:"unlockPassword"`
+		}
+		if err := c.Bind(&request); err != nil {
+			return err
+		}
+		if ok, err := httpSvc.checkUnlockPassword(c, request.UnlockPassword); !ok {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	})
+	return e
+}
+
+func unlockWithForwardedFor(e *echo.Echo, remoteAddr string, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/unlock", strings.NewReader(`{"unlockPassword":"wrong"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+	req.Header.Set(echo.HeaderXRealIP, forwardedFor)
+	req.RemoteAddr = remoteAddr
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestCheckUnlockPassword_IgnoresSpoofedForwardedFor(t *testing.T) {
+	defer tests.RemoveTestService()
+	e := newUnlockTestServer(t, "")
+
+	// every attempt claims to come from a different client
+	spoofedIPs := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}
+	for _, spoofedIP := range spoofedIPs {
+		assert.Equal(t, http.StatusUnauthorized, unlockWithForwardedFor(e, "203.0.113.7:1234", spoofedIP))
+	}
+	assert.Equal(t, http.StatusUnauthorized, unlockWithForwardedFor(e, "203.0.113.7:1234", "10.0.0.6"))
+	assert.Equal(t, http.StatusTooManyRequests, unlockWithForwardedFor(e, "203.0.113.7:1234", "10.0.0.7"))
+}
+
+func TestCheckUnlockPassword_TrustedProxyForwardedFor(t *testing.T) {
+	defer tests.RemoveTestService()
+	e := newUnlockTestServer(t, "192.0.2.1/32")
+
+	for i := 0; i < 6; i++ {
+		assert.Equal(t, http.StatusUnauthorized, unlockWithForwardedFor(e, "192.0.2.1:1234", "198.51.100.1"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, unlockWithForwardedFor(e, "192.0.2.1:1234", "198.51.100.1"))
+	// other clients behind the proxy are not locked out
+	assert.Equal(t, http.StatusUnauthorized, unlockWithForwardedFor(e, "192.0.2.1:1234", "198.51.100.2"))
+}
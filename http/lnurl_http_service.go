@@ -0,0 +1,122 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/lnurl"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/service"
+	"github.com/labstack/echo/v4"
+)
+
+type LNURLHttpService struct {
+	lnurlSvc lnurl.LNURLService
+	svc      service.Service
+}
+
+func NewLNURLHttpService(svc service.Service) *LNURLHttpService {
+	return &LNURLHttpService{
+		lnurlSvc: lnurl.NewLNURLService(svc.GetDB(), svc.GetConfig(), svc.GetTransactionsService()),
+		svc:      svc,
+	}
+}
+
+func (lnurlHttpSvc *LNURLHttpService) RegisterSharedRoutes(e *echo.Echo) {
+	// public LUD-16 endpoints - these must not require a session.
+	// The LNURL feature can be enabled for single apps, so the recipient is checked by the LNURL service.
+	e.GET("/.well-known/lnurlp/:username", lnurlHttpSvc.payRequestHandler)
+	e.GET("/api/lnurlp/:username/callback", lnurlHttpSvc.payRequestCallbackHandler)
+	// public LUD-03 endpoints
+	e.GET("/api/lnurlw/callback", lnurlHttpSvc.withdrawRequestCallbackHandler, lnurlHttpSvc.featureEnabledMiddleware)
+	e.GET("/api/lnurlw/:k1", lnurlHttpSvc.withdrawRequestHandler, lnurlHttpSvc.featureEnabledMiddleware)
+}
+
+func (lnurlHttpSvc *LNURLHttpService) featureEnabledMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !lnurlHttpSvc.svc.GetConfig().IsFeatureEnabled(config.FeatureLNURL) {
+			return c.JSON(http.StatusNotFound, lnurl.ErrorResponse{
+				Status: lnurl.STATUS_ERROR,
+				Reason: "LNURL is not enabled on this hub",
+			})
+		}
+		return next(c)
+	}
+}
+
+func (lnurlHttpSvc *LNURLHttpService) payRequestHandler(c echo.Context) error {
+	payRequest, err := lnurlHttpSvc.lnurlSvc.GetPayRequest(c.Param("username"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, lnurl.ErrorResponse{
+			Status: lnurl.STATUS_ERROR,
+			Reason: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, payRequest)
+}
+
+func (lnurlHttpSvc *LNURLHttpService) payRequestCallbackHandler(c echo.Context) error {
+	lnClient := lnurlHttpSvc.svc.GetLNClient()
+	if lnClient == nil {
+		return c.JSON(http.StatusServiceUnavailable, lnurl.ErrorResponse{
+			Status: lnurl.STATUS_ERROR,
+			Reason: "Wallet is not running",
+		})
+	}
+
+	amount, err := strconv.ParseUint(c.QueryParam("amount"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, lnurl.ErrorResponse{
+			Status: lnurl.STATUS_ERROR,
+			Reason: "Invalid amount",
+		})
+	}
+
+	callbackResponse, err := lnurlHttpSvc.lnurlSvc.HandlePayRequestCallback(c.Request().Context(), c.Param("username"), amount, c.QueryParam("comment"), lnClient)
+	if err != nil {
+		logger.HTTP.WithField("username", c.Param("username")).WithError(err).Error("Failed to handle lnurl pay callback")
+		return c.JSON(http.StatusBadRequest, lnurl.ErrorResponse{
+			Status: lnurl.STATUS_ERROR,
+			Reason: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, callbackResponse)
+}
+
+func (lnurlHttpSvc *LNURLHttpService) withdrawRequestHandler(c echo.Context) error {
+	withdrawRequest, err := lnurlHttpSvc.lnurlSvc.GetWithdrawRequest(c.Param("k1"))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, lnurl.ErrorResponse{
+			Status: lnurl.STATUS_ERROR,
+			Reason: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, withdrawRequest)
+}
+
+func (lnurlHttpSvc *LNURLHttpService) withdrawRequestCallbackHandler(c echo.Context) error {
+	lnClient := lnurlHttpSvc.svc.GetLNClient()
+	if lnClient == nil {
+		return c.JSON(http.StatusServiceUnavailable, lnurl.ErrorResponse{
+			Status: lnurl.STATUS_ERROR,
+			Reason: "Wallet is not running",
+		})
+	}
+
+	err := lnurlHttpSvc.lnurlSvc.HandleWithdrawCallback(c.Request().Context(), c.QueryParam("k1"), c.QueryParam("pr"), lnClient)
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to handle lnurl withdraw callback")
+		return c.JSON(http.StatusBadRequest, lnurl.ErrorResponse{
+			Status: lnurl.STATUS_ERROR,
+			Reason: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, lnurl.StatusResponse{
+		Status: lnurl.STATUS_OK,
+	})
+}
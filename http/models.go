@@ -1,5 +1,19 @@
 package http
 
+import "time"
+
 type ErrorResponse struct {
 	Message string `json:"message"`
+	// quoted by the user when asking for help, see the X-Request-Id header
+	RequestId string `json:"requestId,omitempty"`
+}
+
+type SessionResponse struct {
+	Id         uint      `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	// the session of the browser that made the request
+	Current bool `json:"current"`
 }
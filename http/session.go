@@ -0,0 +1,223 @@
+package http
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+)
+
+// the last seen time is only updated this often to avoid a write on every request
+const sessionTouchInterval = time.Minute
+
+func hashSessionToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// currentSession returns the unexpired server-side session of the request, if any
+func (httpSvc *HttpService) currentSession(c echo.Context) *db.Session {
+	sess, _ := session.Get(sessionCookieName, c)
+	token, ok := sess.Values[sessionCookieIdKey].(string)
+	if !ok || token == "" {
+		return nil
+	}
+
+	var dbSession db.Session
+	result := httpSvc.db.Limit(1).Find(&dbSession, &db.Session{TokenHash: hashSessionToken(token)})
+	if result.Error != nil {
+		logger.HTTP.WithError(result.Error).Error("Failed to load session")
+		return nil
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	idleTimeout := time.Duration(httpSvc.cfg.GetEnv().SessionIdleTimeoutMins) * time.Minute
+	if now.After(dbSession.ExpiresAt) || now.Sub(dbSession.LastSeenAt) > idleTimeout {
+		return nil
+	}
+	if now.Sub(dbSession.LastSeenAt) > sessionTouchInterval {
+		err := httpSvc.db.Model(&dbSession).Update("last_seen_at", now).Error
+		if err != nil {
+			logger.HTTP.WithError(err).Error("Failed to update session")
+		}
+	}
+	return &dbSession
+}
+
+func (httpSvc *HttpService) isUnlocked(c echo.Context) bool {
+	return httpSvc.currentSession(c) != nil
+}
+
+// saveSessionCookie starts a new session. A new session id is issued on every unlock
+// and the previous session of the client is ended.
+func (httpSvc *HttpService) saveSessionCookie(c echo.Context) error {
+	httpSvc.deleteCurrentSession(c)
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		logger.HTTP.WithError(err).Error("Failed to generate session id")
+		return err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	now := time.Now()
+	maxAge := time.Duration(httpSvc.cfg.GetEnv().SessionMaxAgeHours) * time.Hour
+	// the IP is the address of the connection, or of the client if it came through one of TRUSTED_PROXIES
+	err := httpSvc.db.Create(&db.Session{
+		TokenHash:  hashSessionToken(token),
+		UserAgent:  c.Request().UserAgent(),
+		IPAddress:  c.RealIP(),
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(maxAge),
+	}).Error
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to create session")
+		return err
+	}
+	httpSvc.deleteExpiredSessions()
+
+	sess, _ := session.Get(sessionCookieName, c)
+	sess.Options = &sessions.Options{
+		Path:     "/",
+		MaxAge:   int(maxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   httpSvc.cfg.GetEnv().CookieSecure,
+		SameSite: http.SameSiteLaxMode,
+	}
+	sess.Values[sessionCookieIdKey] = token
+	err = sess.Save(c.Request(), c.Response())
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to save session")
+	}
+	return err
+}
+
+func (httpSvc *HttpService) deleteCurrentSession(c echo.Context) {
+	sess, _ := session.Get(sessionCookieName, c)
+	token, ok := sess.Values[sessionCookieIdKey].(string)
+	if !ok || token == "" {
+		return
+	}
+	err := httpSvc.db.Where(&db.Session{TokenHash: hashSessionToken(token)}).Delete(&db.Session{}).Error
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to delete session")
+	}
+}
+
+func (httpSvc *HttpService) deleteExpiredSessions() {
+	idleTimeout := time.Duration(httpSvc.cfg.GetEnv().SessionIdleTimeoutMins) * time.Minute
+	now := time.Now()
+	err := httpSvc.db.Where("expires_at < ? OR last_seen_at < ?", now, now.Add(-idleTimeout)).Delete(&db.Session{}).Error
+	if err != nil {
+		logger.HTTP.WithError(err).Error("Failed to delete expired sessions")
+	}
+}
+
+// revokeAllSessions logs out every browser, e.g. after the unlock password was changed
+func (httpSvc *HttpService) revokeAllSessions() error {
+	return httpSvc.db.Where("1 = 1").Delete(&db.Session{}).Error
+}
+
+func (httpSvc *HttpService) clearSessionCookie(c echo.Context) error {
+	sess, err := session.Get(sessionCookieName, c)
+	if err != nil {
+		return err
+	}
+	sess.Options.MaxAge = -1
+	delete(sess.Values, sessionCookieIdKey)
+	return sess.Save(c.Request(), c.Response())
+}
+
+func (httpSvc *HttpService) logoutHandler(c echo.Context) error {
+	httpSvc.deleteCurrentSession(c)
+	if err := httpSvc.clearSessionCookie(c); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: "Failed to save session",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (httpSvc *HttpService) listSessionsHandler(c echo.Context) error {
+	currentSession := httpSvc.currentSession(c)
+	httpSvc.deleteExpiredSessions()
+
+	dbSessions := []db.Session{}
+	err := httpSvc.db.Order("last_seen_at desc").Find(&dbSessions).Error
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to list sessions: %s", err.Error()),
+		})
+	}
+
+	sessions := []SessionResponse{}
+	for _, dbSession := range dbSessions {
+		sessions = append(sessions, SessionResponse{
+			Id:         dbSession.ID,
+			UserAgent:  dbSession.UserAgent,
+			IPAddress:  dbSession.IPAddress,
+			CreatedAt:  dbSession.CreatedAt,
+			LastSeenAt: dbSession.LastSeenAt,
+			Current:    currentSession != nil && currentSession.ID == dbSession.ID,
+		})
+	}
+	return c.JSON(http.StatusOK, sessions)
+}
+
+func (httpSvc *HttpService) revokeSessionHandler(c echo.Context) error {
+	sessionId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, ErrorResponse{
+			Message: fmt.Sprintf("Invalid session id: %s", err.Error()),
+		})
+	}
+
+	result := httpSvc.db.Delete(&db.Session{}, sessionId)
+	if result.Error != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: fmt.Sprintf("Failed to revoke session: %s", result.Error.Error()),
+		})
+	}
+	if result.RowsAffected == 0 {
+		return c.JSON(http.StatusNotFound, ErrorResponse{
+			Message: "Session does not exist",
+		})
+	}
+
+	// revoking the own session is the same as locking the hub
+	if currentSession := httpSvc.currentSession(c); currentSession == nil {
+		if err := httpSvc.clearSessionCookie(c); err != nil {
+			return c.JSON(http.StatusInternalServerError, ErrorResponse{
+				Message: "Failed to save session",
+			})
+		}
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (httpSvc *HttpService) logoutAllHandler(c echo.Context) error {
+	if err := httpSvc.revokeAllSessions(); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: "Failed to delete sessions",
+		})
+	}
+	if err := httpSvc.clearSessionCookie(c); err != nil {
+		return c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Message: "Failed to save session",
+		})
+	}
+	return c.NoContent(http.StatusNoContent)
+}
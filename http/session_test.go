@@ -0,0 +1,50 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+)
+
+func TestSaveSessionCookie_RecordsConnectionIP(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	require.NoError(t, err)
+
+	httpSvc := &HttpService{
+		cfg: config.NewConfig(&config.AppConfig{
+			Workdir:                ".test",
+			SessionMaxAgeHours:     1,
+			SessionIdleTimeoutMins: 60,
+		}, svc.DB),
+		db: svc.DB,
+	}
+	e := echo.New()
+	require.NoError(t, httpSvc.configureIPAllowlist(e))
+	e.Use(session.Middleware(sessions.NewCookieStore([]byte("secret"))))
+	e.POST("/unlock", func(c echo.Context) error {
+		return httpSvc.saveSessionCookie(c)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/unlock", nil)
+	req.Header.Set(echo.HeaderXForwardedFor, "10.0.0.1")
+	req.Header.Set(echo.HeaderXRealIP, "10.0.0.1")
+	req.RemoteAddr = "203.0.113.7:1234"
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var dbSession db.Session
+	require.NoError(t, svc.DB.First(&dbSession).Error)
+	assert.Equal(t, "203.0.113.7", dbSession.IPAddress)
+}
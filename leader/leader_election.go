@@ -0,0 +1,165 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+)
+
+// the lease of the replica that subscribes to the relay and runs the background tasks
+const HubLeaseName = "hub"
+
+type leaderElection struct {
+	db            *gorm.DB
+	name          string
+	holder        string
+	leaseDuration time.Duration
+	now           func() time.Time
+}
+
+// LeaderElection elects one of the replicas that share a database as the leader,
+// by holding a lease that is renewed while the replica is running
+type LeaderElection interface {
+	// Run tries to acquire the lease until ctx is done. onElected runs while this replica holds the lease,
+	// its context is cancelled once the lease is lost and Run waits for it to return before trying again.
+	Run(ctx context.Context, onElected func(ctx context.Context))
+}
+
+func NewLeaderElection(db *gorm.DB, name string, holder string, leaseDuration time.Duration) *leaderElection {
+	return &leaderElection{
+		db:            db,
+		name:          name,
+		holder:        holder,
+		leaseDuration: leaseDuration,
+		now:           time.Now,
+	}
+}
+
+func (election *leaderElection) Run(ctx context.Context, onElected func(ctx context.Context)) {
+	// renewed a few times per lease, so that a slow renewal does not lose it
+	renewInterval := election.leaseDuration / 3
+	ticker := time.NewTicker(renewInterval)
+	defer ticker.Stop()
+
+	var stepDown func()
+	// the lease is given up before it expires, so that there is never more than one leader
+	var leadUntil time.Time
+
+	for {
+		acquired, expiresAt, err := election.acquire()
+		switch {
+		case acquired:
+			leadUntil = expiresAt.Add(-renewInterval)
+			if stepDown == nil {
+				logger.Logger.WithField("holder", election.holder).Info("Elected as the leader")
+				stepDown = election.lead(ctx, onElected)
+			}
+		case err != nil && stepDown != nil && election.now().Before(leadUntil):
+			// keep leading until the lease is about to expire, the database may only be unavailable for a moment
+			logger.Logger.WithError(err).Warn("Failed to renew the leader lease")
+		case stepDown != nil:
+			logger.Logger.WithField("holder", election.holder).WithError(err).Warn("Lost the leader lease")
+			stepDown()
+			stepDown = nil
+		case err != nil:
+			logger.Logger.WithError(err).Error("Failed to acquire the leader lease")
+		}
+
+		select {
+		case <-ctx.Done():
+			if stepDown != nil {
+				stepDown()
+				election.release()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead runs onElected until the returned function is called, which waits for onElected to return
+func (election *leaderElection) lead(ctx context.Context, onElected func(ctx context.Context)) func() {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		onElected(leaderCtx)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// acquire takes over the lease if it is free or expired, or renews it if this replica already holds it
+func (election *leaderElection) acquire() (bool, time.Time, error) {
+	now := election.now()
+	expiresAt := now.Add(election.leaseDuration)
+	result := election.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"holder", "expires_at", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			gorm.Expr("leader_leases.holder = ? OR leader_leases.expires_at < ?", election.holder, now),
+		}},
+	}).Create(&db.LeaderLease{
+		Name:      election.name,
+		Holder:    election.holder,
+		ExpiresAt: expiresAt,
+	})
+	if result.Error != nil {
+		return false, time.Time{}, result.Error
+	}
+	return result.RowsAffected > 0, expiresAt, nil
+}
+
+// release gives up the lease, so that another replica takes over without waiting for it to expire
+func (election *leaderElection) release() {
+	err := election.db.Where("name = ? AND holder = ?", election.name, election.holder).Delete(&db.LeaderLease{}).Error
+	if err != nil {
+		logger.Logger.WithFields(logrus.Fields{
+			"holder": election.holder,
+		}).WithError(err).Error("Failed to release the leader lease")
+	}
+}
+
+// ReplicaLeaseName is the name of the lease that a replica holds while it is running
+func ReplicaLeaseName(replicaId string) string {
+	return "replica:" + replicaId
+}
+
+// RunReplicaLease holds the lease of the replica until ctx is done,
+// so that the other replicas know which requests it is still handling
+func RunReplicaLease(ctx context.Context, gormDB *gorm.DB, replicaId string, leaseDuration time.Duration) {
+	lease := NewLeaderElection(gormDB, ReplicaLeaseName(replicaId), replicaId, leaseDuration)
+	ticker := time.NewTicker(leaseDuration / 3)
+	defer ticker.Stop()
+	for {
+		_, _, err := lease.acquire()
+		if err != nil {
+			logger.Logger.WithError(err).Warn("Failed to renew the replica lease")
+		}
+
+		select {
+		case <-ctx.Done():
+			lease.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsReplicaAlive returns whether the replica holds its replica lease
+func IsReplicaAlive(gormDB *gorm.DB, replicaId string) (bool, error) {
+	var count int64
+	err := gormDB.Model(&db.LeaderLease{}).Where("name = ? AND expires_at > ?", ReplicaLeaseName(replicaId), time.Now()).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
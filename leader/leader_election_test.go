@@ -0,0 +1,114 @@
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+)
+
+func TestAcquire_OneReplicaHoldsTheLease(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	first := NewLeaderElection(svc.DB, HubLeaseName, "replica-1", 15*time.Second)
+	first.now = func() time.Time { return now }
+	second := NewLeaderElection(svc.DB, HubLeaseName, "replica-2", 15*time.Second)
+	second.now = func() time.Time { return now }
+
+	acquired, _, err := first.acquire()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, _, err = second.acquire()
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	// the holder renews its lease
+	now = now.Add(10 * time.Second)
+	acquired, _, err = first.acquire()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// another replica takes over once the lease expired
+	now = now.Add(16 * time.Second)
+	acquired, _, err = second.acquire()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, _, err = first.acquire()
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	var lease db.LeaderLease
+	assert.NoError(t, svc.DB.First(&lease, "name = ?", HubLeaseName).Error)
+	assert.Equal(t, "replica-2", lease.Holder)
+}
+
+func TestRun_LeaseIsReleasedOnShutdown(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	election := NewLeaderElection(svc.DB, HubLeaseName, "replica-1", 3*time.Second)
+	ctx, cancel := context.WithCancel(context.Background())
+	var leading atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		election.Run(ctx, func(leaderCtx context.Context) {
+			leading.Store(true)
+			<-leaderCtx.Done()
+			leading.Store(false)
+		})
+	}()
+
+	assert.Eventually(t, leading.Load, time.Second, 10*time.Millisecond)
+	cancel()
+	<-done
+	// Run waits for the leader to stop
+	assert.False(t, leading.Load())
+
+	var count int64
+	assert.NoError(t, svc.DB.Model(&db.LeaderLease{}).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+
+	// another replica does not have to wait for the lease to expire
+	other := NewLeaderElection(svc.DB, HubLeaseName, "replica-2", 3*time.Second)
+	acquired, _, err := other.acquire()
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestRunReplicaLease(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		RunReplicaLease(ctx, svc.DB, "replica-1", 3*time.Second)
+	}()
+
+	assert.Eventually(t, func() bool {
+		alive, err := IsReplicaAlive(svc.DB, "replica-1")
+		return err == nil && alive
+	}, time.Second, 10*time.Millisecond)
+	alive, err := IsReplicaAlive(svc.DB, "replica-2")
+	assert.NoError(t, err)
+	assert.False(t, alive)
+
+	// a stopped replica is no longer alive
+	cancel()
+	<-done
+	alive, err = IsReplicaAlive(svc.DB, "replica-1")
+	assert.NoError(t, err)
+	assert.False(t, alive)
+}
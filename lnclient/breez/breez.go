@@ -6,7 +6,6 @@ import (
 	"context"
 	"encoding/hex"
 	"errors"
-	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,12 +29,12 @@ type BreezListener struct {
 
 func (listener BreezListener) Log(l breez_sdk.LogEntry) {
 	if l.Level != "TRACE" && l.Level != "DEBUG" {
-		logger.Logger.WithField("level", l.Level).Print(l.Line)
+		logger.LNClient.WithField("level", l.Level).Print(l.Line)
 	}
 }
 
 func (BreezListener) OnEvent(e breez_sdk.BreezEvent) {
-	log.Printf("received event %#v", e)
+	logger.LNClient.Debugf("received event %#v", e)
 }
 
 func NewBreezService(mnemonic, apiKey, inviteCode, workDir string) (result lnclient.LNClient, err error) {
@@ -71,15 +70,15 @@ func NewBreezService(mnemonic, apiKey, inviteCode, workDir string) (result lncli
 		return nil, err
 	}
 	if err == nil {
-		logger.Logger.WithField("status", healthCheck.Status).Info("Current service status")
+		logger.LNClient.WithField("status", healthCheck.Status).Info("Current service status")
 	}
 
 	nodeInfo, err := svc.NodeInfo()
 	if err != nil {
 		return nil, err
 	}
-	logger.Logger.WithField("info", nodeInfo).Info("Node info")
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithField("info", nodeInfo).Info("Node info")
+	logger.LNClient.WithFields(logrus.Fields{
 		"ln balance":                     nodeInfo.ChannelsBalanceMsat,
 		"balance":                        nodeInfo.OnchainBalanceMsat,
 		"max_payable_msat":               nodeInfo.MaxPayableMsat,
@@ -118,6 +117,10 @@ func (bs *BreezService) SendPaymentSync(ctx context.Context, payReq string) (*ln
 
 }
 
+func (bs *BreezService) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	return nil, lnclient.NewMultiPartPaymentNotSupportedError()
+}
+
 func (bs *BreezService) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
 	// TODO: re-enable when passing custom preimage is possible
 	/*extraTlvs := []breez_sdk.TlvEntry{}
@@ -200,13 +203,13 @@ func (bs *BreezService) MakeInvoice(ctx context.Context, amount int64, descripti
 }
 
 func (bs *BreezService) LookupInvoice(ctx context.Context, paymentHash string) (transaction *lnclient.Transaction, err error) {
-	log.Printf("p: %v", paymentHash)
+	logger.LNClient.WithField("paymentHash", paymentHash).Debug("Looking up invoice")
 	payment, err := bs.svc.PaymentByHash(paymentHash)
 	if err != nil {
 		return nil, err
 	}
 	if payment != nil {
-		log.Printf("p: %v", payment)
+		logger.LNClient.Debugf("Found payment: %v", payment)
 		transaction, err = breezPaymentToTransaction(payment)
 		if err != nil {
 			return nil, err
@@ -309,7 +312,7 @@ func breezPaymentToTransaction(payment *breez_sdk.Payment) (*lnclient.Transactio
 		// TODO: Breez should provide these details so we don't need to manually decode the invoice
 		paymentRequest, err := decodepay.Decodepay(strings.ToLower(lnDetails.Data.Bolt11))
 		if err != nil {
-			log.Printf("Failed to decode bolt11 invoice: %v", payment)
+			logger.LNClient.Errorf("Failed to decode bolt11 invoice: %v", payment)
 			return nil, err
 		}
 
@@ -351,10 +354,10 @@ func (bs *BreezService) GetNewOnchainAddress(ctx context.Context) (string, error
 	/*swapInfo, err := bs.svc.ReceiveOnchain(breez_sdk.ReceiveOnchainRequest{})
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to get onchain address: %v", err)
+		logger.LNClient.Errorf("Failed to get onchain address: %v", err)
 		return "", err
 	}
-	logger.Logger.Infof("This address has deposit limits! Min: %d Max: %d", swapInfo.MinAllowedDeposit, swapInfo.MaxAllowedDeposit)
+	logger.LNClient.Infof("This address has deposit limits! Min: %d Max: %d", swapInfo.MinAllowedDeposit, swapInfo.MaxAllowedDeposit)
 
 	return swapInfo.BitcoinAddress, nil*/
 	return "", nil
@@ -364,7 +367,7 @@ func (bs *BreezService) GetOnchainBalance(ctx context.Context) (*lnclient.Onchai
 	response, err := bs.svc.NodeInfo()
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to get node info: %v", err)
+		logger.LNClient.Errorf("Failed to get node info: %v", err)
 		return nil, err
 	}
 
@@ -374,36 +377,43 @@ func (bs *BreezService) GetOnchainBalance(ctx context.Context) (*lnclient.Onchai
 	}, nil
 }
 
-func (bs *BreezService) RedeemOnchainFunds(ctx context.Context, toAddress string) (txId string, err error) {
+func (bs *BreezService) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (txId string, err error) {
 	if toAddress == "" {
 		return "", errors.New("No address provided")
 	}
-
-	recommendedFees, err := bs.svc.RecommendedFees()
-	if err != nil {
-		logger.Logger.Errorf("Failed to get recommended fees info: %v", err)
-		return "", err
+	if !sendAll {
+		return "", errors.New("only the whole onchain balance can be redeemed")
 	}
 
-	satPerVbyte := uint32(recommendedFees.FastestFee)
+	var satPerVbyte uint32
+	if feeRate != nil {
+		satPerVbyte = uint32(*feeRate)
+	} else {
+		recommendedFees, err := bs.svc.RecommendedFees()
+		if err != nil {
+			logger.LNClient.Errorf("Failed to get recommended fees info: %v", err)
+			return "", err
+		}
+		satPerVbyte = uint32(recommendedFees.FastestFee)
+	}
 	prepareReq := breez_sdk.PrepareRedeemOnchainFundsRequest{SatPerVbyte: satPerVbyte, ToAddress: toAddress}
 	prepareRedeemOnchainFundsResponse, err := bs.svc.PrepareRedeemOnchainFunds(prepareReq)
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to prepare onchain address: %v", err)
+		logger.LNClient.Errorf("Failed to prepare onchain address: %v", err)
 		return "", err
 	}
-	logger.Logger.Infof("PrepareRedeemOnchainFunds response: %#v", prepareRedeemOnchainFundsResponse)
+	logger.LNClient.Infof("PrepareRedeemOnchainFunds response: %#v", prepareRedeemOnchainFundsResponse)
 
 	redeemReq := breez_sdk.RedeemOnchainFundsRequest{SatPerVbyte: satPerVbyte, ToAddress: toAddress}
 	redeemOnchainFundsResponse, err := bs.svc.RedeemOnchainFunds(redeemReq)
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to redeem onchain funds: %v", err)
+		logger.LNClient.Errorf("Failed to redeem onchain funds: %v", err)
 		return "", err
 	}
 
-	logger.Logger.Infof("RedeemOnchainFunds response: %#v", redeemOnchainFundsResponse)
+	logger.LNClient.Infof("RedeemOnchainFunds response: %#v", redeemOnchainFundsResponse)
 	return hex.EncodeToString(redeemOnchainFundsResponse.Txid), nil
 }
 
@@ -442,6 +452,30 @@ func (bs *BreezService) SignMessage(ctx context.Context, message string) (string
 	return resp.Signature, nil
 }
 
+func (bs *BreezService) MakeOffer(ctx context.Context, description string) (*lnclient.Offer, error) {
+	return nil, errors.New("not supported")
+}
+
+func (bs *BreezService) ExportChannelBackup(ctx context.Context) (*lnclient.ChannelBackup, error) {
+	return nil, errors.New("not supported")
+}
+
+func (bs *BreezService) VerifyChannelBackup(ctx context.Context, channelBackup *lnclient.ChannelBackup) error {
+	return errors.New("not supported")
+}
+
+func (bs *BreezService) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	return nil, errors.New("not supported")
+}
+
+func (bs *BreezService) AddWatchtower(ctx context.Context, pubkey string, address string) error {
+	return errors.New("not supported")
+}
+
+func (bs *BreezService) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	return errors.New("not supported")
+}
+
 func (bs *BreezService) GetBalances(ctx context.Context) (*lnclient.BalancesResponse, error) {
 	info, err := bs.svc.NodeInfo()
 	if err != nil {
@@ -0,0 +1,600 @@
+package btcpay
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	decodepay "github.com/nbd-wtf/ln-decodepay"
+	"github.com/sirupsen/logrus"
+)
+
+// logs the requests to the node while the backend bodies debug toggle is enabled
+var bodyLoggingTransport = logger.NewBodyLoggingTransport(http.DefaultTransport)
+
+// Greenfield returns amounts as strings (millisats for lightning, sats for onchain)
+type greenfieldAmount int64
+
+func (a *greenfieldAmount) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		*a = 0
+		return nil
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return err
+	}
+	*a = greenfieldAmount(parsed)
+	return nil
+}
+
+type InfoResponse struct {
+	NodeURIs    []string `json:"nodeURIs"`
+	BlockHeight uint32   `json:"blockHeight"`
+	Alias       string   `json:"alias"`
+	Color       string   `json:"color"`
+}
+
+type BalanceResponse struct {
+	Onchain *struct {
+		Confirmed   greenfieldAmount `json:"confirmed"`
+		Unconfirmed greenfieldAmount `json:"unconfirmed"`
+		Reserved    greenfieldAmount `json:"reserved"`
+	} `json:"onchain"`
+	Offchain *struct {
+		Local  greenfieldAmount `json:"local"`
+		Remote greenfieldAmount `json:"remote"`
+	} `json:"offchain"`
+}
+
+type ChannelResponse struct {
+	RemoteNode   string           `json:"remoteNode"`
+	IsPublic     bool             `json:"isPublic"`
+	IsActive     bool             `json:"isActive"`
+	Capacity     greenfieldAmount `json:"capacity"`
+	LocalBalance greenfieldAmount `json:"localBalance"`
+	ChannelPoint string           `json:"channelPoint"`
+}
+
+type InvoiceResponse struct {
+	Id             string           `json:"id"`
+	Status         string           `json:"status"`
+	BOLT11         string           `json:"BOLT11"`
+	PaidAt         *int64           `json:"paidAt"`
+	ExpiresAt      int64            `json:"expiresAt"`
+	Amount         greenfieldAmount `json:"amount"`
+	AmountReceived greenfieldAmount `json:"amountReceived"`
+	PaymentHash    string           `json:"paymentHash"`
+	Preimage       string           `json:"preimage"`
+}
+
+type PaymentResponse struct {
+	Id          string           `json:"id"`
+	Status      string           `json:"status"`
+	BOLT11      string           `json:"BOLT11"`
+	PaymentHash string           `json:"paymentHash"`
+	Preimage    string           `json:"preimage"`
+	CreatedAt   int64            `json:"createdAt"`
+	TotalAmount greenfieldAmount `json:"totalAmount"`
+	FeeAmount   greenfieldAmount `json:"feeAmount"`
+}
+
+type errorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// BTCPayService uses the lightning node of a BTCPay Server store through the Greenfield API
+type BTCPayService struct {
+	// base url of the lightning endpoints of the store
+	lightningUrl string
+	apiKey       string
+	pubkey       string
+}
+
+// NewBTCPayService connects to a store, the API key needs the
+// btcpay.store.canuselightningnode permission for that store
+func NewBTCPayService(serverUrl string, apiKey string, storeId string) (result lnclient.LNClient, err error) {
+	if serverUrl == "" || apiKey == "" || storeId == "" {
+		return nil, errors.New("BTCPay url, API key and store ID are required")
+	}
+	btcpayService := &BTCPayService{
+		lightningUrl: strings.TrimSuffix(serverUrl, "/") + "/api/v1/stores/" + url.PathEscape(storeId) + "/lightning/BTC",
+		apiKey:       apiKey,
+	}
+
+	info, err := btcpayService.GetInfo(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	btcpayService.pubkey = info.Pubkey
+
+	return btcpayService, nil
+}
+
+func (svc *BTCPayService) request(ctx context.Context, method string, path string, body interface{}, timeout time.Duration, result interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(bodyBytes)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, svc.lightningUrl+path, reqBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", "token "+svc.apiKey)
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	client := &http.Client{Timeout: timeout, Transport: bodyLoggingTransport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		errorRes := errorResponse{}
+		responseBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(responseBytes, &errorRes) == nil && errorRes.Message != "" {
+			return fmt.Errorf("BTCPay request failed with status %d: %s (%s)", resp.StatusCode, errorRes.Message, errorRes.Code)
+		}
+		return fmt.Errorf("BTCPay request failed with status %d: %s", resp.StatusCode, responseBytes)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (svc *BTCPayService) getBalance(ctx context.Context) (*BalanceResponse, error) {
+	var balanceRes BalanceResponse
+	err := svc.request(ctx, http.MethodGet, "/balance", nil, 10*time.Second, &balanceRes)
+	if err != nil {
+		return nil, err
+	}
+	return &balanceRes, nil
+}
+
+func (svc *BTCPayService) GetBalance(ctx context.Context) (balance int64, err error) {
+	balanceRes, err := svc.getBalance(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if balanceRes.Offchain == nil {
+		return 0, nil
+	}
+	return int64(balanceRes.Offchain.Local), nil
+}
+
+func (svc *BTCPayService) GetBalances(ctx context.Context) (*lnclient.BalancesResponse, error) {
+	balanceRes, err := svc.getBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := &lnclient.BalancesResponse{}
+	if balanceRes.Onchain != nil {
+		balances.Onchain = lnclient.OnchainBalanceResponse{
+			Spendable: int64(balanceRes.Onchain.Confirmed),
+			Total:     int64(balanceRes.Onchain.Confirmed + balanceRes.Onchain.Unconfirmed),
+			Reserved:  int64(balanceRes.Onchain.Reserved),
+		}
+	}
+	if balanceRes.Offchain != nil {
+		local := int64(balanceRes.Offchain.Local)
+		remote := int64(balanceRes.Offchain.Remote)
+		balances.Lightning = lnclient.LightningBalanceResponse{
+			TotalSpendable:       local,
+			TotalReceivable:      remote,
+			NextMaxSpendable:     local,
+			NextMaxReceivable:    remote,
+			NextMaxSpendableMPP:  local,
+			NextMaxReceivableMPP: remote,
+		}
+	}
+	return balances, nil
+}
+
+func (svc *BTCPayService) ListTransactions(ctx context.Context, from, until, limit, offset uint64, unpaid bool, invoiceType string) (transactions []lnclient.Transaction, err error) {
+	transactions = []lnclient.Transaction{}
+
+	if invoiceType == "" || invoiceType == "incoming" {
+		var invoices []InvoiceResponse
+		err = svc.request(ctx, http.MethodGet, "/invoices?pendingOnly=false", nil, 10*time.Second, &invoices)
+		if err != nil {
+			return nil, err
+		}
+		for _, invoice := range invoices {
+			if !unpaid && invoice.Status != "Paid" {
+				continue
+			}
+			transaction, err := btcpayInvoiceToTransaction(&invoice)
+			if err != nil {
+				continue
+			}
+			transactions = append(transactions, *transaction)
+		}
+	}
+
+	if invoiceType == "" || invoiceType == "outgoing" {
+		var payments []PaymentResponse
+		err = svc.request(ctx, http.MethodGet, "/payments?includePending="+strconv.FormatBool(unpaid), nil, 10*time.Second, &payments)
+		if err != nil {
+			return nil, err
+		}
+		for _, payment := range payments {
+			if !unpaid && payment.Status != "Complete" {
+				continue
+			}
+			transactions = append(transactions, *btcpayPaymentToTransaction(&payment))
+		}
+	}
+
+	// Greenfield has no date filters, so filter and paginate the combined list
+	filtered := []lnclient.Transaction{}
+	for _, transaction := range transactions {
+		if from != 0 && transaction.CreatedAt < int64(from) {
+			continue
+		}
+		if until != 0 && transaction.CreatedAt > int64(until) {
+			continue
+		}
+		filtered = append(filtered, transaction)
+	}
+
+	// sort by created date descending
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return filtered[i].CreatedAt > filtered[j].CreatedAt
+	})
+
+	if offset >= uint64(len(filtered)) {
+		return []lnclient.Transaction{}, nil
+	}
+	filtered = filtered[offset:]
+	if limit != 0 && limit < uint64(len(filtered)) {
+		filtered = filtered[:limit]
+	}
+	return filtered, nil
+}
+
+func (svc *BTCPayService) GetInfo(ctx context.Context) (info *lnclient.NodeInfo, err error) {
+	var infoRes InfoResponse
+	err = svc.request(ctx, http.MethodGet, "/info", nil, 10*time.Second, &infoRes)
+	if err != nil {
+		return nil, err
+	}
+
+	pubkey := ""
+	if len(infoRes.NodeURIs) > 0 {
+		pubkey, _, _ = strings.Cut(infoRes.NodeURIs[0], "@")
+	}
+	alias := infoRes.Alias
+	if alias == "" {
+		alias = "BTCPay Server"
+	}
+	return &lnclient.NodeInfo{
+		Alias:       alias,
+		Color:       infoRes.Color,
+		Pubkey:      pubkey,
+		Network:     "bitcoin",
+		BlockHeight: infoRes.BlockHeight,
+		BlockHash:   "",
+	}, nil
+}
+
+func (svc *BTCPayService) ListChannels(ctx context.Context) ([]lnclient.Channel, error) {
+	var channelsRes []ChannelResponse
+	err := svc.request(ctx, http.MethodGet, "/channels", nil, 10*time.Second, &channelsRes)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := []lnclient.Channel{}
+	for _, channel := range channelsRes {
+		fundingTxId, _, _ := strings.Cut(channel.ChannelPoint, ":")
+		channels = append(channels, lnclient.Channel{
+			LocalBalance:          int64(channel.LocalBalance),
+			LocalSpendableBalance: int64(channel.LocalBalance),
+			RemoteBalance:         int64(channel.Capacity - channel.LocalBalance),
+			Id:                    channel.ChannelPoint,
+			RemotePubkey:          channel.RemoteNode,
+			FundingTxId:           fundingTxId,
+			Active:                channel.IsActive,
+			Public:                channel.IsPublic,
+		})
+	}
+	return channels, nil
+}
+
+func (svc *BTCPayService) MakeInvoice(ctx context.Context, amount int64, description string, descriptionHash string, expiry int64) (transaction *lnclient.Transaction, err error) {
+	if expiry == 0 {
+		expiry = lnclient.DEFAULT_INVOICE_EXPIRY
+	}
+
+	requestBody := map[string]interface{}{
+		"amount":      strconv.FormatInt(amount, 10),
+		"description": description,
+		"expiry":      expiry,
+	}
+	if descriptionHash != "" {
+		// Greenfield hashes the description itself and cannot take a hash of an unknown description
+		hash := sha256.Sum256([]byte(description))
+		if description == "" || hex.EncodeToString(hash[:]) != descriptionHash {
+			return nil, errors.New("BTCPay requires the description of the description hash")
+		}
+		requestBody["descriptionHashOnly"] = true
+	}
+
+	var invoiceRes InvoiceResponse
+	err = svc.request(ctx, http.MethodPost, "/invoices", requestBody, 30*time.Second, &invoiceRes)
+	if err != nil {
+		return nil, err
+	}
+	return btcpayInvoiceToTransaction(&invoiceRes)
+}
+
+func (svc *BTCPayService) LookupInvoice(ctx context.Context, paymentHash string) (transaction *lnclient.Transaction, err error) {
+	// the invoice id is the payment hash for the lightning implementations BTCPay supports
+	var invoiceRes InvoiceResponse
+	err = svc.request(ctx, http.MethodGet, "/invoices/"+url.PathEscape(paymentHash), nil, 10*time.Second, &invoiceRes)
+	if err != nil {
+		return nil, err
+	}
+	return btcpayInvoiceToTransaction(&invoiceRes)
+}
+
+func (svc *BTCPayService) SendPaymentSync(ctx context.Context, payReq string) (*lnclient.PayInvoiceResponse, error) {
+	var payRes PaymentResponse
+	err := svc.request(ctx, http.MethodPost, "/invoices/pay", map[string]interface{}{
+		"BOLT11": payReq,
+	}, 90*time.Second, &payRes)
+	if err != nil {
+		return nil, err
+	}
+
+	if payRes.Status != "Complete" {
+		logger.LNClient.WithFields(logrus.Fields{
+			"bolt11": payReq,
+			"status": payRes.Status,
+		}).Error("BTCPay payment did not complete")
+		return nil, fmt.Errorf("payment status %s", payRes.Status)
+	}
+
+	return &lnclient.PayInvoiceResponse{
+		Preimage: payRes.Preimage,
+		Fee:      uint64(payRes.FeeAmount),
+	}, nil
+}
+
+func (svc *BTCPayService) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	return nil, lnclient.NewMultiPartPaymentNotSupportedError()
+}
+
+func (svc *BTCPayService) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *BTCPayService) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (txId string, err error) {
+	return "", errors.New("not implemented")
+}
+
+func (svc *BTCPayService) ResetRouter(key string) error {
+	return nil
+}
+
+func (svc *BTCPayService) Shutdown() error {
+	return nil
+}
+
+func (svc *BTCPayService) GetNodeConnectionInfo(ctx context.Context) (nodeConnectionInfo *lnclient.NodeConnectionInfo, err error) {
+	var infoRes InfoResponse
+	err = svc.request(ctx, http.MethodGet, "/info", nil, 10*time.Second, &infoRes)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeConnectionInfo = &lnclient.NodeConnectionInfo{
+		Pubkey: svc.pubkey,
+	}
+	if len(infoRes.NodeURIs) > 0 {
+		_, address, _ := strings.Cut(infoRes.NodeURIs[0], "@")
+		host, port, found := strings.Cut(address, ":")
+		nodeConnectionInfo.Address = host
+		if found {
+			nodeConnectionInfo.Port, _ = strconv.Atoi(port)
+		}
+	}
+	return nodeConnectionInfo, nil
+}
+
+func (svc *BTCPayService) ConnectPeer(ctx context.Context, connectPeerRequest *lnclient.ConnectPeerRequest) error {
+	return errors.New("not implemented")
+}
+
+func (svc *BTCPayService) OpenChannel(ctx context.Context, openChannelRequest *lnclient.OpenChannelRequest) (*lnclient.OpenChannelResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *BTCPayService) CloseChannel(ctx context.Context, closeChannelRequest *lnclient.CloseChannelRequest) (*lnclient.CloseChannelResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *BTCPayService) GetNewOnchainAddress(ctx context.Context) (string, error) {
+	var address string
+	err := svc.request(ctx, http.MethodPost, "/address", nil, 10*time.Second, &address)
+	if err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+func (svc *BTCPayService) GetOnchainBalance(ctx context.Context) (*lnclient.OnchainBalanceResponse, error) {
+	balances, err := svc.GetBalances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &balances.Onchain, nil
+}
+
+func (svc *BTCPayService) SignMessage(ctx context.Context, message string) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (svc *BTCPayService) MakeOffer(ctx context.Context, description string) (*lnclient.Offer, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *BTCPayService) ExportChannelBackup(ctx context.Context) (*lnclient.ChannelBackup, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *BTCPayService) VerifyChannelBackup(ctx context.Context, channelBackup *lnclient.ChannelBackup) error {
+	return errors.New("not implemented")
+}
+
+func (svc *BTCPayService) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *BTCPayService) AddWatchtower(ctx context.Context, pubkey string, address string) error {
+	return errors.New("not implemented")
+}
+
+func (svc *BTCPayService) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	return errors.New("not implemented")
+}
+
+func (svc *BTCPayService) SendPaymentProbes(ctx context.Context, invoice string) error {
+	return nil
+}
+
+func (svc *BTCPayService) SendSpontaneousPaymentProbes(ctx context.Context, amountMsat uint64, nodeId string) error {
+	return nil
+}
+
+func (svc *BTCPayService) ListPeers(ctx context.Context) ([]lnclient.PeerDetails, error) {
+	return nil, nil
+}
+
+func (svc *BTCPayService) GetLogOutput(ctx context.Context, maxLen int) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (svc *BTCPayService) GetNodeStatus(ctx context.Context) (nodeStatus *lnclient.NodeStatus, err error) {
+	return nil, nil
+}
+
+func (svc *BTCPayService) GetStorageDir() (string, error) {
+	return "", nil
+}
+
+func (svc *BTCPayService) GetNetworkGraph(nodeIds []string) (lnclient.NetworkGraphResponse, error) {
+	return nil, nil
+}
+
+func (svc *BTCPayService) UpdateLastWalletSyncRequest() {}
+
+func (svc *BTCPayService) DisconnectPeer(ctx context.Context, peerId string) error {
+	return nil
+}
+
+func (svc *BTCPayService) UpdateChannel(ctx context.Context, updateChannelRequest *lnclient.UpdateChannelRequest) error {
+	return nil
+}
+
+func (svc *BTCPayService) GetSupportedNIP47Methods() []string {
+	return []string{"pay_invoice", "get_balance", "get_info", "make_invoice", "lookup_invoice", "list_transactions", "multi_pay_invoice"}
+}
+
+func (svc *BTCPayService) GetSupportedNIP47NotificationTypes() []string {
+	return []string{}
+}
+
+func (svc *BTCPayService) GetPubkey() string {
+	return svc.pubkey
+}
+
+func btcpayInvoiceToTransaction(invoice *InvoiceResponse) (*lnclient.Transaction, error) {
+	// the creation date and description are only available in the invoice itself
+	paymentRequest, err := decodepay.Decodepay(invoice.BOLT11)
+	if err != nil {
+		logger.LNClient.WithFields(logrus.Fields{
+			"bolt11": invoice.BOLT11,
+		}).Errorf("Failed to decode bolt11 invoice: %v", err)
+
+		return nil, err
+	}
+
+	var settledAt *int64
+	amountMsat := int64(invoice.Amount)
+	if invoice.Status == "Paid" {
+		settledAt = invoice.PaidAt
+		amountMsat = int64(invoice.AmountReceived)
+	}
+	expiresAt := invoice.ExpiresAt
+	paymentHash := invoice.PaymentHash
+	if paymentHash == "" {
+		paymentHash = paymentRequest.PaymentHash
+	}
+
+	return &lnclient.Transaction{
+		Type:            "incoming",
+		Invoice:         invoice.BOLT11,
+		Preimage:        invoice.Preimage,
+		PaymentHash:     paymentHash,
+		Amount:          amountMsat,
+		FeesPaid:        0,
+		CreatedAt:       int64(paymentRequest.CreatedAt),
+		ExpiresAt:       &expiresAt,
+		SettledAt:       settledAt,
+		Description:     paymentRequest.Description,
+		DescriptionHash: paymentRequest.DescriptionHash,
+	}, nil
+}
+
+func btcpayPaymentToTransaction(payment *PaymentResponse) *lnclient.Transaction {
+	var settledAt *int64
+	if payment.Status == "Complete" {
+		// Greenfield does not return the completion date of payments
+		settledAt = &payment.CreatedAt
+	}
+	description := ""
+	descriptionHash := ""
+	paymentRequest, err := decodepay.Decodepay(payment.BOLT11)
+	if err == nil {
+		description = paymentRequest.Description
+		descriptionHash = paymentRequest.DescriptionHash
+	}
+
+	return &lnclient.Transaction{
+		Type:            "outgoing",
+		Invoice:         payment.BOLT11,
+		Preimage:        payment.Preimage,
+		PaymentHash:     payment.PaymentHash,
+		Amount:          int64(payment.TotalAmount - payment.FeeAmount),
+		FeesPaid:        int64(payment.FeeAmount),
+		CreatedAt:       payment.CreatedAt,
+		SettledAt:       settledAt,
+		Description:     description,
+		DescriptionHash: descriptionHash,
+	}
+}
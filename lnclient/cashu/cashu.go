@@ -3,7 +3,6 @@ package cashu
 import (
 	"context"
 	"errors"
-	"log"
 	"os"
 	"path/filepath"
 	"sort"
@@ -33,16 +32,16 @@ func NewCashuService(workDir string, mintUrl string) (result lnclient.LNClient,
 	newpath := filepath.Join(workDir)
 	err = os.MkdirAll(newpath, os.ModePerm)
 	if err != nil {
-		log.Printf("Failed to create cashu working dir: %v", err)
+		logger.LNClient.Errorf("Failed to create cashu working dir: %v", err)
 		return nil, err
 	}
 
-	logger.Logger.WithField("mintUrl", mintUrl).Info("Setting up cashu wallet")
+	logger.LNClient.WithField("mintUrl", mintUrl).Info("Setting up cashu wallet")
 	config := wallet.Config{WalletPath: newpath, CurrentMintURL: mintUrl}
 
 	wallet, err := wallet.LoadWallet(config)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to load cashu wallet")
+		logger.LNClient.WithError(err).Error("Failed to load cashu wallet")
 		return nil, err
 	}
 
@@ -60,7 +59,7 @@ func (cs *CashuService) Shutdown() error {
 func (cs *CashuService) SendPaymentSync(ctx context.Context, invoice string) (response *lnclient.PayInvoiceResponse, err error) {
 	meltResponse, err := cs.wallet.Melt(invoice, cs.wallet.CurrentMint())
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to melt invoice")
+		logger.LNClient.WithError(err).Error("Failed to melt invoice")
 		return nil, err
 	}
 
@@ -71,13 +70,13 @@ func (cs *CashuService) SendPaymentSync(ctx context.Context, invoice string) (re
 	// TODO: get fee from melt response
 	cashuInvoice, err := cs.wallet.GetInvoiceByPaymentRequest(invoice)
 	if err != nil {
-		logger.Logger.WithField("invoice", invoice).WithError(err).Error("Failed to get invoice after melting")
+		logger.LNClient.WithField("invoice", invoice).WithError(err).Error("Failed to get invoice after melting")
 		return nil, err
 	}
 
 	transaction, err := cs.cashuInvoiceToTransaction(cashuInvoice)
 	if err != nil {
-		logger.Logger.WithField("invoice", invoice).WithError(err).Error("Failed to convert invoice to transaction")
+		logger.LNClient.WithField("invoice", invoice).WithError(err).Error("Failed to convert invoice to transaction")
 		return nil, err
 	}
 
@@ -89,6 +88,10 @@ func (cs *CashuService) SendPaymentSync(ctx context.Context, invoice string) (re
 	}, nil
 }
 
+func (cs *CashuService) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	return nil, lnclient.NewMultiPartPaymentNotSupportedError()
+}
+
 func (cs *CashuService) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
 	return nil, errors.New("keysend not supported")
 }
@@ -111,13 +114,13 @@ func (cs *CashuService) MakeInvoice(ctx context.Context, amount int64, descripti
 	}
 	mintResponse, err := cs.wallet.RequestMint(uint64(amount / 1000))
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to mint")
+		logger.LNClient.WithError(err).Error("Failed to mint")
 		return nil, err
 	}
 
 	paymentRequest, err := decodepay.Decodepay(mintResponse.Request)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"invoice": mintResponse.Request,
 		}).WithError(err).Error("Failed to decode bolt11 invoice")
 		return nil, err
@@ -130,7 +133,7 @@ func (cs *CashuService) LookupInvoice(ctx context.Context, paymentHash string) (
 	cashuInvoice := cs.wallet.GetInvoiceByPaymentHash(paymentHash)
 
 	if cashuInvoice == nil {
-		logger.Logger.WithField("paymentHash", paymentHash).Error("Failed to lookup payment request by payment hash")
+		logger.LNClient.WithField("paymentHash", paymentHash).Error("Failed to lookup payment request by payment hash")
 		return nil, errors.New("failed to lookup payment request by payment hash")
 	}
 
@@ -213,7 +216,7 @@ func (cs *CashuService) GetOnchainBalance(ctx context.Context) (*lnclient.Onchai
 	}, nil
 }
 
-func (cs *CashuService) RedeemOnchainFunds(ctx context.Context, toAddress string) (string, error) {
+func (cs *CashuService) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (string, error) {
 	return "", nil
 }
 
@@ -225,6 +228,30 @@ func (cs *CashuService) SignMessage(ctx context.Context, message string) (string
 	return "", nil
 }
 
+func (cs *CashuService) MakeOffer(ctx context.Context, description string) (*lnclient.Offer, error) {
+	return nil, errors.New("offers not supported")
+}
+
+func (cs *CashuService) ExportChannelBackup(ctx context.Context) (*lnclient.ChannelBackup, error) {
+	return nil, errors.New("channel backups not supported")
+}
+
+func (cs *CashuService) VerifyChannelBackup(ctx context.Context, channelBackup *lnclient.ChannelBackup) error {
+	return errors.New("channel backups not supported")
+}
+
+func (cs *CashuService) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	return nil, errors.New("watchtowers not supported")
+}
+
+func (cs *CashuService) AddWatchtower(ctx context.Context, pubkey string, address string) error {
+	return errors.New("watchtowers not supported")
+}
+
+func (cs *CashuService) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	return errors.New("watchtowers not supported")
+}
+
 func (cs *CashuService) DisconnectPeer(ctx context.Context, peerId string) error {
 	return nil
 }
@@ -263,7 +290,7 @@ func (cs *CashuService) UpdateChannel(ctx context.Context, updateChannelRequest
 func (cs *CashuService) GetBalances(ctx context.Context) (*lnclient.BalancesResponse, error) {
 	balance, err := cs.GetBalance(ctx)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to get balance")
+		logger.LNClient.WithError(err).Error("Failed to get balance")
 		return nil, err
 	}
 	return &lnclient.BalancesResponse{
@@ -285,7 +312,7 @@ func (cs *CashuService) GetBalances(ctx context.Context) (*lnclient.BalancesResp
 func (cs *CashuService) cashuInvoiceToTransaction(cashuInvoice *storage.Invoice) (*lnclient.Transaction, error) {
 	paymentRequest, err := decodepay.Decodepay(cashuInvoice.PaymentRequest)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"invoice": cashuInvoice.PaymentRequest,
 		}).WithError(err).Error("Failed to decode bolt11 invoice")
 		return nil, err
@@ -325,19 +352,19 @@ func (cs *CashuService) cashuInvoiceToTransaction(cashuInvoice *storage.Invoice)
 
 func (cs *CashuService) checkInvoice(cashuInvoice *storage.Invoice) {
 	if cashuInvoice.TransactionType == storage.Mint && !cashuInvoice.Paid {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"paymentHash": cashuInvoice.PaymentHash,
 		}).Info("Checking unpaid invoice")
 
 		proofs, err := cs.wallet.MintTokens(cashuInvoice.Id)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"paymentHash": cashuInvoice.PaymentHash,
 			}).WithError(err).Warn("failed to mint")
 		}
 
 		if proofs != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"paymentHash": cashuInvoice.PaymentHash,
 				"amount":      proofs.Amount(),
 			}).Info("sats successfully minted")
@@ -3,7 +3,6 @@ package greenlight
 import (
 	"context"
 	"errors"
-	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -40,7 +39,7 @@ func NewGreenlightService(cfg config.Config, mnemonic, inviteCode, workDir, encr
 	newpath := filepath.Join(workDir)
 	err = os.MkdirAll(newpath, os.ModePerm)
 	if err != nil {
-		log.Printf("Failed to create greenlight working dir: %v", err)
+		logger.LNClient.Errorf("Failed to create greenlight working dir: %v", err)
 		return nil, err
 	}
 
@@ -51,22 +50,22 @@ func NewGreenlightService(cfg config.Config, mnemonic, inviteCode, workDir, encr
 		credentials = &glalby.GreenlightCredentials{
 			GlCreds: existingDeviceCreds,
 		}
-		logger.Logger.Info("Using saved greenlight credentials")
+		logger.LNClient.Info("Using saved greenlight credentials")
 	}
 
 	if credentials == nil {
-		logger.Logger.Info("No greenlight credentials found, attempting to recover existing node")
+		logger.LNClient.Info("No greenlight credentials found, attempting to recover existing node")
 		recoveredCredentials, err := glalby.Recover(mnemonic)
 		credentials = &recoveredCredentials
 
 		if err != nil {
-			logger.Logger.Errorf("Failed to recover node: %v", err)
-			logger.Logger.Infof("Trying to register instead...")
+			logger.LNClient.Errorf("Failed to recover node: %v", err)
+			logger.LNClient.Infof("Trying to register instead...")
 			recoveredCredentials, err := glalby.Register(mnemonic, inviteCode)
 			credentials = &recoveredCredentials
 
 			if err != nil {
-				logger.Logger.Fatalf("Failed to register new node")
+				logger.LNClient.Fatalf("Failed to register new node")
 			}
 		}
 
@@ -79,11 +78,11 @@ func NewGreenlightService(cfg config.Config, mnemonic, inviteCode, workDir, encr
 	client, err := glalby.NewBlockingGreenlightAlbyClient(mnemonic, *credentials)
 
 	if err != nil {
-		log.Printf("Failed to create greenlight alby client: %v", err)
+		logger.LNClient.Errorf("Failed to create greenlight alby client: %v", err)
 		return nil, err
 	}
 	if client == nil {
-		log.Fatalf("unexpected response from NewBlockingGreenlightAlbyClient")
+		logger.LNClient.Fatal("unexpected response from NewBlockingGreenlightAlbyClient")
 	}
 
 	nodeInfo, err := client.GetInfo()
@@ -98,7 +97,7 @@ func NewGreenlightService(cfg config.Config, mnemonic, inviteCode, workDir, encr
 		return nil, err
 	}
 
-	log.Printf("Node info: %v", nodeInfo)
+	logger.LNClient.Infof("Node info: %v", nodeInfo)
 
 	return &gs, nil
 }
@@ -106,7 +105,7 @@ func NewGreenlightService(cfg config.Config, mnemonic, inviteCode, workDir, encr
 func (gs *GreenlightService) Shutdown() error {
 	_, err := gs.client.Shutdown()
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to shutdown greenlight node")
+		logger.LNClient.WithError(err).Error("Failed to shutdown greenlight node")
 		return err
 	}
 	return nil
@@ -118,15 +117,19 @@ func (gs *GreenlightService) SendPaymentSync(ctx context.Context, payReq string)
 	})
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to send payment: %v", err)
+		logger.LNClient.Errorf("Failed to send payment: %v", err)
 		return nil, err
 	}
-	log.Printf("SendPaymentSync succeeded: %v", response.Preimage)
+	logger.LNClient.Info("SendPaymentSync succeeded")
 	return &lnclient.PayInvoiceResponse{
 		Preimage: response.Preimage,
 	}, nil
 }
 
+func (gs *GreenlightService) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	return nil, lnclient.NewMultiPartPaymentNotSupportedError()
+}
+
 func (gs *GreenlightService) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
 
 	// TODO: re-enable when passing custom preimage is possible
@@ -148,7 +151,7 @@ func (gs *GreenlightService) SendKeysend(ctx context.Context, amount uint64, des
 	})
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to send keysend payment: %v", err)
+		logger.LNClient.Errorf("Failed to send keysend payment: %v", err)
 		return "", "", 0, err
 	}
 
@@ -162,7 +165,7 @@ func (gs *GreenlightService) GetBalance(ctx context.Context) (balance int64, err
 	response, err := gs.client.ListFunds(glalby.ListFundsRequest{})
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to list funds: %v", err)
+		logger.LNClient.Errorf("Failed to list funds: %v", err)
 		return 0, err
 	}
 
@@ -190,13 +193,13 @@ func (gs *GreenlightService) MakeInvoice(ctx context.Context, amount int64, desc
 	})
 
 	if err != nil {
-		logger.Logger.Errorf("MakeInvoice failed: %v", err)
+		logger.LNClient.Errorf("MakeInvoice failed: %v", err)
 		return nil, err
 	}
 
 	paymentRequest, err := decodepay.Decodepay(strings.ToLower(invoice.Bolt11))
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"invoice": invoice.Bolt11,
 		}).Errorf("Failed to decode bolt11 invoice: %v", invoice.Bolt11)
 		return nil, err
@@ -226,7 +229,7 @@ func (gs *GreenlightService) LookupInvoice(ctx context.Context, paymentHash stri
 	})
 
 	if err != nil {
-		logger.Logger.Errorf("ListInvoices failed: %v", err)
+		logger.LNClient.Errorf("ListInvoices failed: %v", err)
 		return nil, err
 	}
 
@@ -242,7 +245,7 @@ func (gs *GreenlightService) LookupInvoice(ctx context.Context, paymentHash stri
 	transaction, err = gs.greenlightInvoiceToTransaction(&invoice)
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to map invoice: %v", err)
+		logger.LNClient.Errorf("Failed to map invoice: %v", err)
 		return nil, err
 	}
 
@@ -253,14 +256,14 @@ func (gs *GreenlightService) ListTransactions(ctx context.Context, from, until,
 	listInvoicesResponse, err := gs.client.ListInvoices(glalby.ListInvoicesRequest{})
 
 	if err != nil {
-		logger.Logger.Errorf("ListInvoices failed: %v", err)
+		logger.LNClient.Errorf("ListInvoices failed: %v", err)
 		return nil, err
 	}
 
 	transactions = []lnclient.Transaction{}
 
 	if err != nil {
-		log.Printf("ListInvoices failed: %v", err)
+		logger.LNClient.Errorf("ListInvoices failed: %v", err)
 		return nil, err
 	}
 
@@ -281,7 +284,7 @@ func (gs *GreenlightService) ListTransactions(ctx context.Context, from, until,
 	listPaymentsResponse, err := gs.client.ListPayments(glalby.ListPaymentsRequest{})
 
 	if err != nil {
-		logger.Logger.Errorf("ListPayments failed: %v", err)
+		logger.LNClient.Errorf("ListPayments failed: %v", err)
 		return nil, err
 	}
 
@@ -295,7 +298,7 @@ func (gs *GreenlightService) ListTransactions(ctx context.Context, from, until,
 			bolt11 := *payment.Bolt11
 			paymentRequest, err := decodepay.Decodepay(strings.ToLower(bolt11))
 			if err != nil {
-				log.Printf("Failed to decode bolt11 invoice: %v", bolt11)
+				logger.LNClient.Errorf("Failed to decode bolt11 invoice: %v", bolt11)
 				return nil, err
 			}
 
@@ -350,7 +353,7 @@ func (gs *GreenlightService) GetInfo(ctx context.Context) (info *lnclient.NodeIn
 	nodeInfo, err := gs.client.GetInfo()
 
 	if err != nil {
-		logger.Logger.Errorf("GetInfo failed: %v", err)
+		logger.LNClient.Errorf("GetInfo failed: %v", err)
 		return nil, err
 	}
 
@@ -368,7 +371,7 @@ func (gs *GreenlightService) ListChannels(ctx context.Context) ([]lnclient.Chann
 	response, err := gs.client.ListFunds(glalby.ListFundsRequest{})
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to list funds: %v", err)
+		logger.LNClient.Errorf("Failed to list funds: %v", err)
 		return nil, err
 	}
 
@@ -406,7 +409,7 @@ func (gs *GreenlightService) ListChannels(ctx context.Context) ([]lnclient.Chann
 func (gs *GreenlightService) GetNodeConnectionInfo(ctx context.Context) (nodeConnectionInfo *lnclient.NodeConnectionInfo, err error) {
 	info, err := gs.GetInfo(ctx)
 	if err != nil {
-		logger.Logger.Errorf("GetInfo failed: %v", err)
+		logger.LNClient.Errorf("GetInfo failed: %v", err)
 		return nil, err
 	}
 	return &lnclient.NodeConnectionInfo{
@@ -429,7 +432,7 @@ func (gs *GreenlightService) ConnectPeer(ctx context.Context, connectPeerRequest
 		Port: port,
 	})
 	if err != nil {
-		logger.Logger.Errorf("ConnectPeer failed: %v", err)
+		logger.LNClient.Errorf("ConnectPeer failed: %v", err)
 		return err
 	}
 	return nil
@@ -446,7 +449,7 @@ func (gs *GreenlightService) OpenChannel(ctx context.Context, openChannelRequest
 		// Minconf:    &minConf,
 	})
 	if err != nil {
-		logger.Logger.Errorf("OpenChannel failed: %v", err)
+		logger.LNClient.Errorf("OpenChannel failed: %v", err)
 		return nil, err
 	}
 
@@ -460,7 +463,7 @@ func (gs *GreenlightService) CloseChannel(ctx context.Context, closeChannelReque
 		Id: closeChannelRequest.ChannelId,
 	})
 	if err != nil {
-		logger.Logger.WithError(err).Error("CloseChannel failed")
+		logger.LNClient.WithError(err).Error("CloseChannel failed")
 		return nil, err
 	}
 
@@ -471,7 +474,7 @@ func (gs *GreenlightService) GetNewOnchainAddress(ctx context.Context) (string,
 
 	newAddressResponse, err := gs.client.NewAddress(glalby.NewAddressRequest{})
 	if err != nil {
-		logger.Logger.Errorf("NewAddress failed: %v", err)
+		logger.LNClient.Errorf("NewAddress failed: %v", err)
 		return "", err
 	}
 	if newAddressResponse.Bech32 == nil {
@@ -483,10 +486,10 @@ func (gs *GreenlightService) GetNewOnchainAddress(ctx context.Context) (string,
 
 func (gs *GreenlightService) GetOnchainBalance(ctx context.Context) (*lnclient.OnchainBalanceResponse, error) {
 	response, err := gs.client.ListFunds(glalby.ListFundsRequest{})
-	logger.Logger.WithField("response", response).Info("Listed funds")
+	logger.LNClient.WithField("response", response).Info("Listed funds")
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to list funds: %v", err)
+		logger.LNClient.Errorf("Failed to list funds: %v", err)
 		return nil, err
 	}
 
@@ -508,17 +511,23 @@ func (gs *GreenlightService) GetOnchainBalance(ctx context.Context) (*lnclient.O
 	}, nil
 }
 
-func (gs *GreenlightService) RedeemOnchainFunds(ctx context.Context, toAddress string) (string, error) {
-	amountAll := glalby.AmountOrAll(glalby.AmountOrAllAll{})
+func (gs *GreenlightService) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (string, error) {
+	if feeRate != nil {
+		return "", errors.New("custom fee rates are not supported")
+	}
+	withdrawAmount := glalby.AmountOrAll(glalby.AmountOrAllAmount{Msat: amount * 1000})
+	if sendAll {
+		withdrawAmount = glalby.AmountOrAll(glalby.AmountOrAllAll{})
+	}
 	txId, err := gs.client.Withdraw(glalby.WithdrawRequest{
 		Destination: toAddress,
-		Amount:      &amountAll,
+		Amount:      &withdrawAmount,
 	})
 	if err != nil {
-		logger.Logger.WithError(err).Error("Withdraw failed")
+		logger.LNClient.WithError(err).Error("Withdraw failed")
 		return "", err
 	}
-	logger.Logger.WithField("txId", txId).Info("Redeeming On-Chain funds")
+	logger.LNClient.WithField("txId", txId).Info("Redeeming On-Chain funds")
 
 	return txId.Txid, nil
 }
@@ -545,13 +554,37 @@ func (gs *GreenlightService) SignMessage(ctx context.Context, message string) (s
 	})
 
 	if err != nil {
-		logger.Logger.Errorf("SignMessage failed: %v", err)
+		logger.LNClient.Errorf("SignMessage failed: %v", err)
 		return "", err
 	}
 
 	return response.Zbase, nil
 }
 
+func (gs *GreenlightService) MakeOffer(ctx context.Context, description string) (*lnclient.Offer, error) {
+	return nil, errors.New("not supported")
+}
+
+func (gs *GreenlightService) ExportChannelBackup(ctx context.Context) (*lnclient.ChannelBackup, error) {
+	return nil, errors.New("not supported")
+}
+
+func (gs *GreenlightService) VerifyChannelBackup(ctx context.Context, channelBackup *lnclient.ChannelBackup) error {
+	return errors.New("not supported")
+}
+
+func (gs *GreenlightService) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	return nil, errors.New("not supported")
+}
+
+func (gs *GreenlightService) AddWatchtower(ctx context.Context, pubkey string, address string) error {
+	return errors.New("not supported")
+}
+
+func (gs *GreenlightService) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	return errors.New("not supported")
+}
+
 func (gs *GreenlightService) greenlightInvoiceToTransaction(invoice *glalby.ListInvoicesInvoice) (*lnclient.Transaction, error) {
 	description := ""
 	descriptionHash := ""
@@ -561,7 +594,7 @@ func (gs *GreenlightService) greenlightInvoiceToTransaction(invoice *glalby.List
 	bolt11 := *invoice.Bolt11
 	paymentRequest, err := decodepay.Decodepay(strings.ToLower(bolt11))
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"invoice": bolt11,
 		}).Errorf("Failed to decode bolt11 invoice: %v", bolt11)
 		return nil, err
@@ -613,14 +646,14 @@ func (gs *GreenlightService) ResetRouter(key string) error {
 func (gs *GreenlightService) GetBalances(ctx context.Context) (*lnclient.BalancesResponse, error) {
 	onchainBalance, err := gs.GetOnchainBalance(ctx)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to retrieve onchain balance")
+		logger.LNClient.WithError(err).Error("Failed to retrieve onchain balance")
 		return nil, err
 	}
 
 	response, err := gs.client.ListFunds(glalby.ListFundsRequest{})
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to list funds: %v", err)
+		logger.LNClient.Errorf("Failed to list funds: %v", err)
 		return nil, err
 	}
 
@@ -3,9 +3,9 @@ package ldk
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"math"
 	"os"
 	"path/filepath"
@@ -56,7 +56,7 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 	newpath := filepath.Join(workDir)
 	err = os.MkdirAll(newpath, os.ModePerm)
 	if err != nil {
-		log.Printf("Failed to create LDK working dir: %v", err)
+		logger.LNClient.Errorf("Failed to create LDK working dir: %v", err)
 		return nil, err
 	}
 
@@ -105,10 +105,10 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 	builder.SetNetwork(network)
 	builder.SetEsploraServer(cfg.GetEnv().LDKEsploraServer)
 	if cfg.GetEnv().LDKGossipSource != "" {
-		logger.Logger.WithField("gossipSource", cfg.GetEnv().LDKGossipSource).Warn("LDK RGS instance set")
+		logger.LNClient.WithField("gossipSource", cfg.GetEnv().LDKGossipSource).Warn("LDK RGS instance set")
 		builder.SetGossipSourceRgs(cfg.GetEnv().LDKGossipSource)
 	} else {
-		logger.Logger.Warn("No LDK RGS instance set")
+		logger.LNClient.Warn("No LDK RGS instance set")
 	}
 	builder.SetStorageDirPath(filepath.Join(newpath, "./storage"))
 
@@ -121,7 +121,7 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 	node, err := builder.Build()
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to create LDK node: %v", err)
+		logger.LNClient.Errorf("Failed to create LDK node: %v", err)
 		return nil, err
 	}
 
@@ -182,11 +182,11 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 
 	err = node.Start()
 	if err != nil {
-		logger.Logger.Errorf("Failed to start LDK node: %v", err)
+		logger.LNClient.Errorf("Failed to start LDK node: %v", err)
 		return nil, err
 	}
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"nodeId": nodeId,
 		"status": node.Status(),
 	}).Info("Started LDK node. Syncing wallet...")
@@ -194,7 +194,7 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 	syncStartTime := time.Now()
 	err = node.SyncWallets()
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to sync LDK wallets")
+		logger.LNClient.WithError(err).Error("Failed to sync LDK wallets")
 		ls.eventPublisher.Publish(&events.Event{
 			Event: "nwc_node_sync_failed",
 			Properties: map[string]interface{}{
@@ -208,14 +208,14 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 
 		shutdownErr := ls.Shutdown()
 		if shutdownErr != nil {
-			logger.Logger.WithError(shutdownErr).Error("Failed to shutdown LDK node")
+			logger.LNClient.WithError(shutdownErr).Error("Failed to shutdown LDK node")
 		}
 
 		return nil, err
 	}
 	ls.lastSync = time.Now()
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"nodeId":   nodeId,
 		"status":   node.Status(),
 		"duration": math.Ceil(time.Since(syncStartTime).Seconds()),
@@ -232,12 +232,12 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 			"027100442c3b79f606f80f322d98d499eefcb060599efc5d4ecb00209c2cb54190@3.230.33.224:9735",    // c=
 			"038a9e56512ec98da2b5789761f7af8f280baf98a09282360cd6ff1381b5e889bf@64.23.162.51:9735",    // Megalith LSP
 		}
-		logger.Logger.Info("Connecting to some peers to retrieve P2P gossip data")
+		logger.LNClient.Info("Connecting to some peers to retrieve P2P gossip data")
 		for _, peer := range peers {
 			parts := strings.FieldsFunc(peer, func(r rune) bool { return r == '@' || r == ':' })
 			port, err := strconv.ParseUint(parts[2], 10, 16)
 			if err != nil {
-				logger.Logger.WithError(err).Error("Failed to parse port number")
+				logger.LNClient.WithError(err).Error("Failed to parse port number")
 				continue
 			}
 			err = ls.ConnectPeer(ctx, &lnclient.ConnectPeerRequest{
@@ -246,7 +246,7 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 				Port:    uint16(port),
 			})
 			if err != nil {
-				logger.Logger.WithField("peer", peer).WithError(err).Error("Failed to connect to peer")
+				logger.LNClient.WithField("peer", peer).WithError(err).Error("Failed to connect to peer")
 			}
 		}
 	}
@@ -263,10 +263,10 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 			case <-time.After(MIN_SYNC_INTERVAL):
 				ls.syncing = true
 				// always update fee rates to avoid differences in fee rates with channel partners
-				logger.Logger.Info("Updating fee estimates")
+				logger.LNClient.Info("Updating fee estimates")
 				err = node.UpdateFeeEstimates()
 				if err != nil {
-					logger.Logger.WithError(err).Error("Failed to update fee estimates")
+					logger.LNClient.WithError(err).Error("Failed to update fee estimates")
 					ls.eventPublisher.Publish(&events.Event{
 						Event: "nwc_node_sync_failed",
 						Properties: map[string]interface{}{
@@ -283,12 +283,12 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 					continue
 				}
 
-				logger.Logger.Info("Starting background wallet sync")
+				logger.LNClient.Info("Starting background wallet sync")
 				syncStartTime := time.Now()
 				err = node.SyncWallets()
 
 				if err != nil {
-					logger.Logger.WithError(err).Error("Failed to sync LDK wallets")
+					logger.LNClient.WithError(err).Error("Failed to sync LDK wallets")
 					ls.eventPublisher.Publish(&events.Event{
 						Event: "nwc_node_sync_failed",
 						Properties: map[string]interface{}{
@@ -305,7 +305,7 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 
 				ls.lastSync = time.Now()
 
-				logger.Logger.WithFields(logrus.Fields{
+				logger.LNClient.WithFields(logrus.Fields{
 					"nodeId":   nodeId,
 					"status":   node.Status(),
 					"duration": math.Ceil(time.Since(syncStartTime).Seconds()),
@@ -319,23 +319,23 @@ func NewLDKService(ctx context.Context, cfg config.Config, eventPublisher events
 
 func (ls *LDKService) Shutdown() error {
 	if ls.node == nil {
-		logger.Logger.Info("LDK client already shut down")
+		logger.LNClient.Info("LDK client already shut down")
 		return nil
 	}
 	// make sure nothing else can use it
 	node := ls.node
 	ls.node = nil
 
-	logger.Logger.Info("shutting down LDK client")
-	logger.Logger.Info("cancelling LDK context")
+	logger.LNClient.Info("shutting down LDK client")
+	logger.LNClient.Info("cancelling LDK context")
 	ls.cancel()
 
 	for ls.syncing {
-		logger.Logger.Info("Waiting for background sync to finish before stopping LDK node...")
+		logger.LNClient.Info("Waiting for background sync to finish before stopping LDK node...")
 		time.Sleep(1 * time.Second)
 	}
 
-	logger.Logger.Info("stopping LDK node")
+	logger.LNClient.Info("stopping LDK node")
 	shutdownChannel := make(chan error)
 	go func() {
 		shutdownChannel <- node.Stop()
@@ -344,21 +344,21 @@ func (ls *LDKService) Shutdown() error {
 	select {
 	case err := <-shutdownChannel:
 		if err != nil {
-			logger.Logger.WithError(err).Error("Failed to stop LDK node")
+			logger.LNClient.WithError(err).Error("Failed to stop LDK node")
 			// do not return error - we still need to destroy the node
 		} else {
-			logger.Logger.Info("LDK stop node succeeded")
+			logger.LNClient.Info("LDK stop node succeeded")
 		}
 	case <-time.After(120 * time.Second):
-		logger.Logger.Error("Timeout shutting down LDK node after 120 seconds")
+		logger.LNClient.Error("Timeout shutting down LDK node after 120 seconds")
 	}
 
-	logger.Logger.Info("Destroying node object")
+	logger.LNClient.Info("Destroying node object")
 	node.Destroy()
 
 	ls.resetRouterInternal()
 
-	logger.Logger.Info("LDK shutdown complete")
+	logger.LNClient.Info("LDK shutdown complete")
 
 	return nil
 }
@@ -367,21 +367,21 @@ func (ls *LDKService) resetRouterInternal() {
 	key, err := ls.cfg.Get(resetRouterKey, "")
 
 	if err != nil {
-		logger.Logger.Error("Failed to retrieve ResetRouter key")
+		logger.LNClient.Error("Failed to retrieve ResetRouter key")
 	}
 
 	if key != "" {
 		ls.cfg.SetUpdate(resetRouterKey, "", "")
-		logger.Logger.WithField("key", key).Info("Resetting router")
+		logger.LNClient.WithField("key", key).Info("Resetting router")
 
 		ldkDbPath := filepath.Join(ls.workdir, "storage", "ldk_node_data.sqlite")
 		if _, err := os.Stat(ldkDbPath); errors.Is(err, os.ErrNotExist) {
-			logger.Logger.Error("Could not find LDK database")
+			logger.LNClient.Error("Could not find LDK database")
 			return
 		}
 		ldkDb, err := sql.Open("sqlite", ldkDbPath)
 		if err != nil {
-			logger.Logger.Error("Could not open LDK DB file")
+			logger.LNClient.Error("Could not open LDK DB file")
 			return
 		}
 
@@ -397,21 +397,21 @@ func (ls *LDKService) resetRouterInternal() {
 		case "NetworkGraph":
 			command = "delete from ldk_node_data where key = 'network_graph';VACUUM;"
 		default:
-			logger.Logger.WithField("key", key).Error("Unknown reset router key")
+			logger.LNClient.WithField("key", key).Error("Unknown reset router key")
 			return
 		}
 
 		result, err := ldkDb.Exec(command)
 		if err != nil {
-			logger.Logger.WithError(err).Error("Failed execute reset command")
+			logger.LNClient.WithError(err).Error("Failed execute reset command")
 			return
 		}
 		rowsAffected, err := result.RowsAffected()
 		if err != nil {
-			logger.Logger.WithError(err).Error("Failed to get rows affected")
+			logger.LNClient.WithError(err).Error("Failed to get rows affected")
 			return
 		}
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"rowsAffected": rowsAffected,
 		}).Info("Reset router")
 	}
@@ -420,7 +420,7 @@ func (ls *LDKService) resetRouterInternal() {
 func (ls *LDKService) SendPaymentSync(ctx context.Context, invoice string) (*lnclient.PayInvoiceResponse, error) {
 	paymentRequest, err := decodepay.Decodepay(invoice)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"bolt11": invoice,
 		}).Errorf("Failed to decode bolt11 invoice: %v", err)
 
@@ -446,7 +446,7 @@ func (ls *LDKService) SendPaymentSync(ctx context.Context, invoice string) (*lnc
 
 	paymentHash, err := ls.node.Bolt11Payment().Send(invoice)
 	if err != nil {
-		logger.Logger.WithError(err).Error("SendPayment failed")
+		logger.LNClient.WithError(err).Error("SendPayment failed")
 		return nil, err
 	}
 	fee := uint64(0)
@@ -459,23 +459,23 @@ func (ls *LDKService) SendPaymentSync(ctx context.Context, invoice string) (*lnc
 		eventPaymentFailed, isEventPaymentFailedEvent := (*event).(ldk_node.EventPaymentFailed)
 
 		if isEventPaymentSuccessfulEvent && eventPaymentSuccessful.PaymentHash == paymentHash {
-			logger.Logger.Info("Got payment success event")
+			logger.LNClient.Info("Got payment success event")
 			payment := ls.node.Payment(paymentHash)
 			if payment == nil {
-				logger.Logger.Errorf("Couldn't find payment by payment hash: %v", paymentHash)
+				logger.LNClient.Errorf("Couldn't find payment by payment hash: %v", paymentHash)
 				return nil, errors.New("payment not found")
 			}
 
 			bolt11PaymentKind, ok := payment.Kind.(ldk_node.PaymentKindBolt11)
 
 			if !ok {
-				logger.Logger.WithFields(logrus.Fields{
+				logger.LNClient.WithFields(logrus.Fields{
 					"payment": payment,
 				}).Error("Payment is not a bolt11 kind")
 			}
 
 			if bolt11PaymentKind.Preimage == nil {
-				logger.Logger.Errorf("No payment preimage for payment hash: %v", paymentHash)
+				logger.LNClient.Errorf("No payment preimage for payment hash: %v", paymentHash)
 				return nil, errors.New("payment preimage not found")
 			}
 			preimage = *bolt11PaymentKind.Preimage
@@ -489,7 +489,7 @@ func (ls *LDKService) SendPaymentSync(ctx context.Context, invoice string) (*lnc
 
 			failureReasonMessage := ls.getPaymentFailReason(&eventPaymentFailed)
 
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"payment_hash": paymentHash,
 				"reason":       failureReasonMessage,
 			}).Error("Received payment failed event")
@@ -498,13 +498,13 @@ func (ls *LDKService) SendPaymentSync(ctx context.Context, invoice string) (*lnc
 		}
 	}
 	if preimage == "" {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"paymentHash": paymentHash,
 		}).Warn("Timed out waiting for payment to be sent")
 		return nil, lnclient.NewTimeoutError()
 	}
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"duration": time.Since(paymentStart).Milliseconds(),
 		"fee":      fee,
 	}).Info("Successful payment")
@@ -515,6 +515,15 @@ func (ls *LDKService) SendPaymentSync(ctx context.Context, invoice string) (*lnc
 	}, nil
 }
 
+// LDK already splits payments across multiple paths when needed,
+// but the number of parts cannot be configured and AMP is not supported
+func (ls *LDKService) SendMultiPartPaymentSync(ctx context.Context, invoice string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	if options != nil && (options.AMP || options.MaxParts > 0) {
+		return nil, lnclient.NewMultiPartPaymentNotSupportedError()
+	}
+	return ls.SendPaymentSync(ctx, invoice)
+}
+
 func (ls *LDKService) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
 	paymentStart := time.Now()
 	customTlvs := []ldk_node.TlvEntry{}
@@ -535,7 +544,7 @@ func (ls *LDKService) SendKeysend(ctx context.Context, amount uint64, destinatio
 
 	paymentHash, err := ls.node.SpontaneousPayment().Send(amount, destination, customTlvs, &preimage)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Keysend failed")
+		logger.LNClient.WithError(err).Error("Keysend failed")
 		return nil, err
 	}
 	fee := uint64(0)
@@ -547,7 +556,7 @@ func (ls *LDKService) SendKeysend(ctx context.Context, amount uint64, destinatio
 		eventPaymentFailed, isEventPaymentFailedEvent := (*event).(ldk_node.EventPaymentFailed)
 
 		if isEventPaymentSuccessfulEvent && eventPaymentSuccessful.PaymentHash == paymentHash {
-			logger.Logger.Info("Got payment success event")
+			logger.LNClient.Info("Got payment success event")
 
 			paid = true
 
@@ -560,7 +569,7 @@ func (ls *LDKService) SendKeysend(ctx context.Context, amount uint64, destinatio
 
 			failureReasonMessage := ls.getPaymentFailReason(&eventPaymentFailed)
 
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"payment_hash": paymentHash,
 				"reason":       failureReasonMessage,
 			}).Error("Received payment failed event")
@@ -569,13 +578,13 @@ func (ls *LDKService) SendKeysend(ctx context.Context, amount uint64, destinatio
 		}
 	}
 	if !paid {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"payment_hash": paymentHash,
 		}).Warn("Timed out waiting for keysend to be sent")
 		return nil, lnclient.NewTimeoutError()
 	}
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"duration": time.Since(paymentStart).Milliseconds(),
 		"fee":      fee,
 	}).Info("Successful keysend payment")
@@ -645,14 +654,14 @@ func (ls *LDKService) MakeInvoice(ctx context.Context, amount int64, description
 		uint32(expiry))
 
 	if err != nil {
-		logger.Logger.WithError(err).Error("MakeInvoice failed")
+		logger.LNClient.WithError(err).Error("MakeInvoice failed")
 		return nil, err
 	}
 
 	var expiresAt *int64
 	paymentRequest, err := decodepay.Decodepay(invoice)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"bolt11": invoice,
 		}).Errorf("Failed to decode bolt11 invoice: %v", err)
 
@@ -680,18 +689,38 @@ func (ls *LDKService) MakeInvoice(ctx context.Context, amount int64, description
 	return transaction, nil
 }
 
+// MakeOffer creates an offer without an amount, the payer chooses how much to pay
+func (ls *LDKService) MakeOffer(ctx context.Context, description string) (*lnclient.Offer, error) {
+	offer, err := ls.node.Bolt12Payment().ReceiveVariableAmount(description)
+	if err != nil {
+		logger.LNClient.WithError(err).Error("MakeOffer failed")
+		return nil, err
+	}
+
+	offerId, err := bolt12OfferId(offer)
+	if err != nil {
+		logger.LNClient.WithField("offer", offer).WithError(err).Error("Failed to compute offer id")
+		return nil, err
+	}
+
+	return &lnclient.Offer{
+		Offer:   offer,
+		OfferId: offerId,
+	}, nil
+}
+
 func (ls *LDKService) LookupInvoice(ctx context.Context, paymentHash string) (transaction *lnclient.Transaction, err error) {
 
 	payment := ls.node.Payment(paymentHash)
 	if payment == nil {
-		logger.Logger.Errorf("Couldn't find payment by payment hash: %v", paymentHash)
+		logger.LNClient.Errorf("Couldn't find payment by payment hash: %v", paymentHash)
 		return nil, errors.New("Payment not found")
 	}
 
 	transaction, err = ls.ldkPaymentToTransaction(payment)
 
 	if err != nil {
-		logger.Logger.Errorf("Failed to map transaction: %v", err)
+		logger.LNClient.Errorf("Failed to map transaction: %v", err)
 		return nil, err
 	}
 
@@ -709,7 +738,7 @@ func (ls *LDKService) ListTransactions(ctx context.Context, from, until, limit,
 			transaction, err := ls.ldkPaymentToTransaction(&payment)
 
 			if err != nil {
-				logger.Logger.WithError(err).Error("Failed to map transaction")
+				logger.LNClient.WithError(err).Error("Failed to map transaction")
 				continue
 			}
 
@@ -745,7 +774,7 @@ func (ls *LDKService) ListTransactions(ctx context.Context, from, until, limit,
 		transactions = transactions[:limit]
 	}
 
-	// logger.Logger.WithField("transactions", transactions).Debug("Listed transactions")
+	// logger.LNClient.WithField("transactions", transactions).Debug("Listed transactions")
 
 	return transactions, nil
 }
@@ -770,7 +799,7 @@ func (ls *LDKService) ListChannels(ctx context.Context) ([]lnclient.Channel, err
 
 	channels := []lnclient.Channel{}
 
-	// logger.Logger.WithFields(logrus.Fields{
+	// logger.LNClient.WithFields(logrus.Fields{
 	// 	"channels": ldkChannels,
 	// }).Debug("Listed Channels")
 
@@ -818,6 +847,7 @@ func (ls *LDKService) ListChannels(ctx context.Context) ([]lnclient.Channel, err
 			Confirmations:                            ldkChannel.Confirmations,
 			ConfirmationsRequired:                    ldkChannel.ConfirmationsRequired,
 			ForwardingFeeBaseMsat:                    ldkChannel.Config.ForwardingFeeBaseMsat(),
+			ForwardingFeeProportionalMillionths:      ldkChannel.Config.ForwardingFeeProportionalMillionths(),
 			UnspendablePunishmentReserve:             unspendablePunishmentReserve,
 			CounterpartyUnspendablePunishmentReserve: ldkChannel.CounterpartyUnspendablePunishmentReserve,
 			Error:                                    channelError,
@@ -839,7 +869,7 @@ func (ls *LDKService) GetNodeConnectionInfo(ctx context.Context) (nodeConnection
 	}
 	port, err := strconv.Atoi(parts[1])
 	if err != nil {
-		logger.Logger.WithError(err).Error("ConnectPeer failed")
+		logger.LNClient.WithError(err).Error("ConnectPeer failed")
 		return nil, err
 	}*/
 
@@ -853,7 +883,7 @@ func (ls *LDKService) GetNodeConnectionInfo(ctx context.Context) (nodeConnection
 func (ls *LDKService) ConnectPeer(ctx context.Context, connectPeerRequest *lnclient.ConnectPeerRequest) error {
 	err := ls.node.Connect(connectPeerRequest.Pubkey, connectPeerRequest.Address+":"+strconv.Itoa(int(connectPeerRequest.Port)), true)
 	if err != nil {
-		logger.Logger.WithField("request", connectPeerRequest).WithError(err).Error("ConnectPeer failed")
+		logger.LNClient.WithField("request", connectPeerRequest).WithError(err).Error("ConnectPeer failed")
 		return err
 	}
 
@@ -878,15 +908,15 @@ func (ls *LDKService) OpenChannel(ctx context.Context, openChannelRequest *lncli
 	ldkEventSubscription := ls.ldkEventBroadcaster.Subscribe()
 	defer ls.ldkEventBroadcaster.CancelSubscription(ldkEventSubscription)
 
-	logger.Logger.WithField("peer_id", foundPeer.NodeId).Info("Opening channel")
+	logger.LNClient.WithField("peer_id", foundPeer.NodeId).Info("Opening channel")
 	userChannelId, err := ls.node.ConnectOpenChannel(foundPeer.NodeId, foundPeer.Address, uint64(openChannelRequest.Amount), nil, nil, openChannelRequest.Public)
 	if err != nil {
-		logger.Logger.WithError(err).Error("OpenChannel failed")
+		logger.LNClient.WithError(err).Error("OpenChannel failed")
 		return nil, err
 	}
 
 	// userChannelId allows to locally keep track of the channel (and is also used to close the channel)
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"peer_id":    foundPeer.NodeId,
 		"channel_id": userChannelId,
 	}).Info("Funded channel")
@@ -899,7 +929,7 @@ func (ls *LDKService) OpenChannel(ctx context.Context, openChannelRequest *lncli
 
 		if isChannelClosedEvent {
 			closureReason := ls.getChannelCloseReason(&channelClosedEvent)
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"event":  channelClosedEvent,
 				"reason": closureReason,
 			}).Info("Failed to open channel")
@@ -931,23 +961,26 @@ func (ls *LDKService) UpdateChannel(ctx context.Context, updateChannelRequest *l
 	}
 
 	if foundChannel == nil {
-		logger.Logger.WithField("request", updateChannelRequest).Error("failed to find channel to update")
+		logger.LNClient.WithField("request", updateChannelRequest).Error("failed to find channel to update")
 		return errors.New("channel not found")
 	}
 
 	existingConfig := foundChannel.Config
 	existingConfig.SetForwardingFeeBaseMsat(updateChannelRequest.ForwardingFeeBaseMsat)
+	if updateChannelRequest.ForwardingFeeProportionalMillionths != nil {
+		existingConfig.SetForwardingFeeProportionalMillionths(*updateChannelRequest.ForwardingFeeProportionalMillionths)
+	}
 
 	err := ls.node.UpdateChannelConfig(updateChannelRequest.ChannelId, updateChannelRequest.NodeId, existingConfig)
 	if err != nil {
-		logger.Logger.WithError(err).Error("UpdateChannelConfig failed")
+		logger.LNClient.WithError(err).Error("UpdateChannelConfig failed")
 		return err
 	}
 	return nil
 }
 
 func (ls *LDKService) CloseChannel(ctx context.Context, closeChannelRequest *lnclient.CloseChannelRequest) (*lnclient.CloseChannelResponse, error) {
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"request": closeChannelRequest,
 	}).Info("Closing Channel")
 
@@ -958,7 +991,7 @@ func (ls *LDKService) CloseChannel(ctx context.Context, closeChannelRequest *lnc
 		err = ls.node.CloseChannel(closeChannelRequest.ChannelId, closeChannelRequest.NodeId)
 	}
 	if err != nil {
-		logger.Logger.WithError(err).Error("CloseChannel failed")
+		logger.LNClient.WithError(err).Error("CloseChannel failed")
 		return nil, err
 	}
 	return &lnclient.CloseChannelResponse{}, nil
@@ -967,7 +1000,7 @@ func (ls *LDKService) CloseChannel(ctx context.Context, closeChannelRequest *lnc
 func (ls *LDKService) GetNewOnchainAddress(ctx context.Context) (string, error) {
 	address, err := ls.node.OnchainPayment().NewAddress()
 	if err != nil {
-		logger.Logger.WithError(err).Error("NewOnchainAddress failed")
+		logger.LNClient.WithError(err).Error("NewOnchainAddress failed")
 		return "", err
 	}
 	return address, nil
@@ -975,7 +1008,7 @@ func (ls *LDKService) GetNewOnchainAddress(ctx context.Context) (string, error)
 
 func (ls *LDKService) GetOnchainBalance(ctx context.Context) (*lnclient.OnchainBalanceResponse, error) {
 	balances := ls.node.ListBalances()
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"balances": balances,
 	}).Debug("Listed Balances")
 	return &lnclient.OnchainBalanceResponse{
@@ -985,13 +1018,19 @@ func (ls *LDKService) GetOnchainBalance(ctx context.Context) (*lnclient.OnchainB
 	}, nil
 }
 
-func (ls *LDKService) RedeemOnchainFunds(ctx context.Context, toAddress string) (string, error) {
-	spendableBalance := ls.node.ListBalances().SpendableOnchainBalanceSats
-	// TODO: estimate the transaction fee and subtract that from the spendable balance
-	// to avoid spending any of the reserved anchor channel balance
-	txId, err := ls.node.OnchainPayment().SendToAddress(toAddress, spendableBalance)
+func (ls *LDKService) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (string, error) {
+	if feeRate != nil {
+		// LDK picks the fee rate from its own fee estimator
+		return "", errors.New("custom fee rates are not supported")
+	}
+	if sendAll {
+		// TODO: estimate the transaction fee and subtract that from the spendable balance
+		// to avoid spending any of the reserved anchor channel balance
+		amount = ls.node.ListBalances().SpendableOnchainBalanceSats
+	}
+	txId, err := ls.node.OnchainPayment().SendToAddress(toAddress, amount)
 	if err != nil {
-		logger.Logger.WithError(err).Error("SendToAddress failed")
+		logger.LNClient.WithError(err).Error("SendToAddress failed")
 		return "", err
 	}
 	return txId, nil
@@ -1006,7 +1045,7 @@ func (ls *LDKService) ResetRouter(key string) error {
 func (ls *LDKService) SignMessage(ctx context.Context, message string) (string, error) {
 	sign, err := ls.node.SignMessage([]byte(message))
 	if err != nil {
-		logger.Logger.Errorf("SignMessage failed: %v", err)
+		logger.LNClient.Errorf("SignMessage failed: %v", err)
 		return "", err
 	}
 
@@ -1014,7 +1053,7 @@ func (ls *LDKService) SignMessage(ctx context.Context, message string) (string,
 }
 
 func (ls *LDKService) ldkPaymentToTransaction(payment *ldk_node.PaymentDetails) (*lnclient.Transaction, error) {
-	// logger.Logger.WithField("payment", payment).Debug("Mapping LDK payment to transaction")
+	// logger.LNClient.WithField("payment", payment).Debug("Mapping LDK payment to transaction")
 
 	transactionType := "incoming"
 	if payment.Direction == ldk_node.PaymentDirectionOutbound {
@@ -1037,7 +1076,7 @@ func (ls *LDKService) ldkPaymentToTransaction(payment *ldk_node.PaymentDetails)
 		bolt11Invoice = *bolt11PaymentKind.Bolt11Invoice
 		paymentRequest, err := decodepay.Decodepay(strings.ToLower(bolt11Invoice))
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"bolt11": bolt11Invoice,
 			}).Errorf("Failed to decode bolt11 invoice: %v", err)
 
@@ -1089,6 +1128,23 @@ func (ls *LDKService) ldkPaymentToTransaction(payment *ldk_node.PaymentDetails)
 		metadata["tlv_records"] = tlvRecords
 	}
 
+	var offerId string
+	bolt12OfferPaymentKind, isBolt12OfferPaymentKind := payment.Kind.(ldk_node.PaymentKindBolt12Offer)
+	if isBolt12OfferPaymentKind {
+		lastUpdate := int64(payment.LastUpdate)
+		createdAt = int64(payment.CreatedAt)
+		if payment.Status == ldk_node.PaymentStatusSucceeded {
+			settledAt = &lastUpdate
+		}
+		if bolt12OfferPaymentKind.Hash != nil {
+			paymentHash = *bolt12OfferPaymentKind.Hash
+		}
+		if bolt12OfferPaymentKind.Preimage != nil {
+			preimage = *bolt12OfferPaymentKind.Preimage
+		}
+		offerId = bolt12OfferPaymentKind.OfferId
+	}
+
 	var amount uint64 = 0
 	if payment.AmountMsat != nil {
 		amount = *payment.AmountMsat
@@ -1112,13 +1168,14 @@ func (ls *LDKService) ldkPaymentToTransaction(payment *ldk_node.PaymentDetails)
 		DescriptionHash: descriptionHash,
 		ExpiresAt:       expiresAt,
 		Metadata:        metadata,
+		OfferId:         offerId,
 	}, nil
 }
 
 func (ls *LDKService) SendPaymentProbes(ctx context.Context, invoice string) error {
 	err := ls.node.Bolt11Payment().SendProbes(invoice)
 	if err != nil {
-		logger.Logger.Errorf("Bolt11Payment.SendProbes failed: %v", err)
+		logger.LNClient.Errorf("Bolt11Payment.SendProbes failed: %v", err)
 		return err
 	}
 
@@ -1128,7 +1185,7 @@ func (ls *LDKService) SendPaymentProbes(ctx context.Context, invoice string) err
 func (ls *LDKService) SendSpontaneousPaymentProbes(ctx context.Context, amountMsat uint64, nodeId string) error {
 	err := ls.node.SpontaneousPayment().SendProbes(amountMsat, nodeId)
 	if err != nil {
-		logger.Logger.Errorf("SpontaneousPayment.SendProbes failed: %v", err)
+		logger.LNClient.Errorf("SpontaneousPayment.SendProbes failed: %v", err)
 		return err
 	}
 
@@ -1194,7 +1251,7 @@ func (ls *LDKService) GetLogOutput(ctx context.Context, maxLen int) ([]byte, err
 
 	allLogFiles, err := filepath.Glob(filepath.Join(logPath, "ldk_node_*.log"))
 	if err != nil {
-		logger.Logger.WithError(err).Error("GetLogOutput failed to list log files")
+		logger.LNClient.WithError(err).Error("GetLogOutput failed to list log files")
 		return nil, err
 	}
 
@@ -1208,7 +1265,7 @@ func (ls *LDKService) GetLogOutput(ctx context.Context, maxLen int) ([]byte, err
 
 	logData, err := utils.ReadFileTail(lastLogFileName, maxLen)
 	if err != nil {
-		logger.Logger.WithError(err).Error("GetLogOutput failed to read log file")
+		logger.LNClient.WithError(err).Error("GetLogOutput failed to read log file")
 		return nil, err
 	}
 
@@ -1216,7 +1273,7 @@ func (ls *LDKService) GetLogOutput(ctx context.Context, maxLen int) ([]byte, err
 }
 
 func (ls *LDKService) handleLdkEvent(event *ldk_node.Event) {
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"event": event,
 	}).Info("Received LDK event")
 
@@ -1227,7 +1284,7 @@ func (ls *LDKService) handleLdkEvent(event *ldk_node.Event) {
 			return c.ChannelId == eventType.ChannelId
 		})
 		if channelIndex == -1 {
-			logger.Logger.WithField("event", eventType).Error("Failed to find channel by ID")
+			logger.LNClient.WithField("event", eventType).Error("Failed to find channel by ID")
 			return
 		}
 		channel := channels[channelIndex]
@@ -1245,7 +1302,7 @@ func (ls *LDKService) handleLdkEvent(event *ldk_node.Event) {
 		ls.publishChannelsBackupEvent()
 
 		if eventType.CounterpartyNodeId == nil {
-			logger.Logger.WithField("event", eventType).Error("channel ready event has no counterparty node ID")
+			logger.LNClient.WithField("event", eventType).Error("channel ready event has no counterparty node ID")
 			return
 		}
 		// set a super-high forwarding fee of 100K sats by default to disable unwanted routing
@@ -1256,13 +1313,13 @@ func (ls *LDKService) handleLdkEvent(event *ldk_node.Event) {
 		})
 
 		if err != nil {
-			logger.Logger.WithField("event", eventType).Error("channel ready event has no counterparty node ID")
+			logger.LNClient.WithField("event", eventType).Error("channel ready event has no counterparty node ID")
 			return
 		}
 
 	case ldk_node.EventChannelClosed:
 		closureReason := ls.getChannelCloseReason(&eventType)
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"event":  event,
 			"reason": closureReason,
 		}).Info("Channel closed")
@@ -1277,18 +1334,18 @@ func (ls *LDKService) handleLdkEvent(event *ldk_node.Event) {
 		})
 	case ldk_node.EventPaymentReceived:
 		if eventType.PaymentId == nil {
-			logger.Logger.WithField("payment_hash", eventType.PaymentHash).Error("payment received event has no payment ID")
+			logger.LNClient.WithField("payment_hash", eventType.PaymentHash).Error("payment received event has no payment ID")
 			return
 		}
 		payment := ls.node.Payment(*eventType.PaymentId)
 		if payment == nil {
-			logger.Logger.WithField("payment_id", *eventType.PaymentId).Error("could not find LDK payment")
+			logger.LNClient.WithField("payment_id", *eventType.PaymentId).Error("could not find LDK payment")
 			return
 		}
 
 		transaction, err := ls.ldkPaymentToTransaction(payment)
 		if err != nil {
-			logger.Logger.WithField("payment_id", *eventType.PaymentId).Error("failed to convert LDK payment to transaction")
+			logger.LNClient.WithField("payment_id", *eventType.PaymentId).Error("failed to convert LDK payment to transaction")
 			return
 		}
 
@@ -1298,18 +1355,18 @@ func (ls *LDKService) handleLdkEvent(event *ldk_node.Event) {
 		})
 	case ldk_node.EventPaymentSuccessful:
 		if eventType.PaymentId == nil {
-			logger.Logger.WithField("payment_hash", eventType.PaymentHash).Error("payment received event has no payment ID")
+			logger.LNClient.WithField("payment_hash", eventType.PaymentHash).Error("payment received event has no payment ID")
 			return
 		}
 		payment := ls.node.Payment(*eventType.PaymentId)
 		if payment == nil {
-			logger.Logger.WithField("payment_id", *eventType.PaymentId).Error("could not find LDK payment")
+			logger.LNClient.WithField("payment_id", *eventType.PaymentId).Error("could not find LDK payment")
 			return
 		}
 
 		transaction, err := ls.ldkPaymentToTransaction(payment)
 		if err != nil {
-			logger.Logger.WithField("payment_id", *eventType.PaymentId).Error("failed to convert LDK payment to transaction")
+			logger.LNClient.WithField("payment_id", *eventType.PaymentId).Error("failed to convert LDK payment to transaction")
 			return
 		}
 
@@ -1319,18 +1376,18 @@ func (ls *LDKService) handleLdkEvent(event *ldk_node.Event) {
 		})
 	case ldk_node.EventPaymentFailed:
 		if eventType.PaymentId == nil {
-			logger.Logger.WithField("payment_hash", eventType.PaymentHash).Error("payment failed event has no payment ID")
+			logger.LNClient.WithField("payment_hash", eventType.PaymentHash).Error("payment failed event has no payment ID")
 			return
 		}
 		payment := ls.node.Payment(*eventType.PaymentId)
 		if payment == nil {
-			logger.Logger.WithField("payment_id", *eventType.PaymentId).Error("could not find LDK payment")
+			logger.LNClient.WithField("payment_id", *eventType.PaymentId).Error("could not find LDK payment")
 			return
 		}
 
 		transaction, err := ls.ldkPaymentToTransaction(payment)
 		if err != nil {
-			logger.Logger.WithField("payment_id", *eventType.PaymentId).Error("failed to convert LDK payment to transaction")
+			logger.LNClient.WithField("payment_id", *eventType.PaymentId).Error("failed to convert LDK payment to transaction")
 			return
 		}
 
@@ -1347,6 +1404,15 @@ func (ls *LDKService) handleLdkEvent(event *ldk_node.Event) {
 }
 
 func (ls *LDKService) publishChannelsBackupEvent() {
+	ls.eventPublisher.Publish(&events.Event{
+		Event: "nwc_backup_channels",
+		Properties: &events.ChannelBackupEvent{
+			Channels: ls.channelsBackupInfo(),
+		},
+	})
+}
+
+func (ls *LDKService) channelsBackupInfo() []events.ChannelBackupInfo {
 	ldkChannels := ls.node.ListChannels()
 	channels := make([]events.ChannelBackupInfo, 0, len(ldkChannels))
 	for _, ldkChannel := range ldkChannels {
@@ -1366,19 +1432,60 @@ func (ls *LDKService) publishChannelsBackupEvent() {
 			FundingTxVout: fundingTxVout,
 		})
 	}
+	return channels
+}
 
-	ls.eventPublisher.Publish(&events.Event{
-		Event: "nwc_backup_channels",
-		Properties: &events.ChannelBackupEvent{
-			Channels: channels,
-		},
+// ExportChannelBackup returns the channels with their peers and funding outputs.
+// LDK has no static channel backups, this is the data needed to ask the peers to
+// force close the channels after the node data is lost.
+func (ls *LDKService) ExportChannelBackup(ctx context.Context) (*lnclient.ChannelBackup, error) {
+	channels := ls.channelsBackupInfo()
+	data, err := json.Marshal(&events.ChannelBackupEvent{
+		Channels: channels,
 	})
+	if err != nil {
+		return nil, err
+	}
+	return &lnclient.ChannelBackup{
+		Data:         data,
+		ChannelCount: len(channels),
+	}, nil
+}
+
+func (ls *LDKService) VerifyChannelBackup(ctx context.Context, channelBackup *lnclient.ChannelBackup) error {
+	backup := events.ChannelBackupEvent{}
+	err := json.Unmarshal(channelBackup.Data, &backup)
+	if err != nil {
+		return fmt.Errorf("failed to decode channel backup: %w", err)
+	}
+	nodeId := ls.node.NodeId()
+	for _, channel := range backup.Channels {
+		if channel.NodeID != nodeId {
+			return fmt.Errorf("channel %s belongs to another node", channel.ChannelID)
+		}
+		if channel.PeerID == "" {
+			return fmt.Errorf("channel %s has no peer", channel.ChannelID)
+		}
+	}
+	return nil
+}
+
+func (ls *LDKService) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	return nil, errors.New("watchtowers are not supported by LDK")
+}
+
+func (ls *LDKService) AddWatchtower(ctx context.Context, pubkey string, address string) error {
+	return errors.New("watchtowers are not supported by LDK")
+}
+
+func (ls *LDKService) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	return errors.New("watchtowers are not supported by LDK")
 }
 
 func (ls *LDKService) GetBalances(ctx context.Context) (*lnclient.BalancesResponse, error) {
 	onchainBalance, err := ls.GetOnchainBalance(ctx)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to retrieve onchain balance")
+		logger.LNClient.WithError(err).Error("Failed to retrieve onchain balance")
 		return nil, err
 	}
 
@@ -1427,10 +1534,10 @@ func (ls *LDKService) GetStorageDir() (string, error) {
 }
 
 func deleteOldLDKLogs(ldkLogDir string) {
-	logger.Logger.WithField("ldkLogDir", ldkLogDir).Info("Deleting old LDK logs")
+	logger.LNClient.WithField("ldkLogDir", ldkLogDir).Info("Deleting old LDK logs")
 	files, err := os.ReadDir(ldkLogDir)
 	if err != nil {
-		logger.Logger.WithField("path", ldkLogDir).WithError(err).Error("Failed to list ldk log directory")
+		logger.LNClient.WithField("path", ldkLogDir).WithError(err).Error("Failed to list ldk log directory")
 		return
 	}
 
@@ -1440,17 +1547,17 @@ func deleteOldLDKLogs(ldkLogDir string) {
 			filePath := filepath.Join(ldkLogDir, file.Name())
 			fileInfo, err := file.Info()
 			if err != nil {
-				logger.Logger.WithField("filePath", filePath).WithError(err).Error("Failed to get file info")
+				logger.LNClient.WithField("filePath", filePath).WithError(err).Error("Failed to get file info")
 				continue
 			}
 			// delete files last modified over 3 days ago
 			if fileInfo.ModTime().Before(time.Now().AddDate(0, 0, -3)) {
 				err := os.Remove(filePath)
 				if err != nil {
-					logger.Logger.WithField("filePath", filePath).WithError(err).Error("Failed to get file info")
+					logger.LNClient.WithField("filePath", filePath).WithError(err).Error("Failed to get file info")
 					continue
 				}
-				logger.Logger.WithField("filePath", filePath).Info("Deleted old LDK log file")
+				logger.LNClient.WithField("filePath", filePath).Info("Deleted old LDK log file")
 			}
 		}
 	}
@@ -1471,7 +1578,7 @@ func (ls *LDKService) UpdateLastWalletSyncRequest() {
 }
 
 func (ls *LDKService) GetSupportedNIP47Methods() []string {
-	return []string{"pay_invoice", "pay_keysend", "get_balance", "get_info", "make_invoice", "lookup_invoice", "list_transactions", "multi_pay_invoice", "multi_pay_keysend", "sign_message"}
+	return []string{"pay_invoice", "pay_keysend", "get_balance", "get_info", "make_invoice", "lookup_invoice", "list_transactions", "multi_pay_invoice", "multi_pay_keysend", "sign_message", "make_offer"}
 }
 
 func (ls *LDKService) GetSupportedNIP47NotificationTypes() []string {
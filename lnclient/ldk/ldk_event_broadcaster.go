@@ -56,7 +56,7 @@ func (s *ldkEventBroadcastServer) CancelSubscription(channel chan *ldk_node.Even
 	func() {
 		defer func() {
 			if r := recover(); r != nil {
-				logger.Logger.WithField("r", r).Error("Failed to close subscription channel")
+				logger.LNClient.WithField("r", r).Error("Failed to close subscription channel")
 			}
 		}()
 		close(channel)
@@ -72,7 +72,7 @@ func (s *ldkEventBroadcastServer) serve(ctx context.Context) {
 			func() {
 				defer func() {
 					if r := recover(); r != nil {
-						logger.Logger.WithField("r", r).Error("Failed to close subscription channel")
+						logger.LNClient.WithField("r", r).Error("Failed to close subscription channel")
 					}
 				}()
 				close(listener)
@@ -101,7 +101,7 @@ func (s *ldkEventBroadcastServer) serve(ctx context.Context) {
 			}
 		case event := <-s.source:
 			// got a new LDK event - send it to all listeners
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"event":         event,
 				"listenerCount": len(s.listeners),
 			}).Debug("Sending LDK event to listeners")
@@ -110,7 +110,7 @@ func (s *ldkEventBroadcastServer) serve(ctx context.Context) {
 					// if we fail to send the event to the listener it was probably closed
 					defer func() {
 						if r := recover(); r != nil {
-							logger.Logger.WithField("r", r).Error("Failed to send event to listener")
+							logger.LNClient.WithField("r", r).Error("Failed to send event to listener")
 						}
 					}()
 
@@ -119,11 +119,11 @@ func (s *ldkEventBroadcastServer) serve(ctx context.Context) {
 					// worst case scenario: it times out because the listener is stuck processing an event
 					select {
 					case listener <- event:
-						logger.Logger.WithFields(logrus.Fields{
+						logger.LNClient.WithFields(logrus.Fields{
 							"event": event,
 						}).Debug("Sent LDK event to listener")
 					case <-time.After(5 * time.Second):
-						logger.Logger.WithFields(logrus.Fields{
+						logger.LNClient.WithFields(logrus.Fields{
 							"event": event,
 						}).Error("Timeout sending LDK event to listener")
 					}
@@ -0,0 +1,146 @@
+package ldk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// LDK identifies offers by the tagged hash of the merkle root of their TLV stream,
+// payments received for an offer only reference this id and not the offer itself
+func bolt12OfferId(offer string) (string, error) {
+	tlvStream, err := decodeBolt12(offer, "lno")
+	if err != nil {
+		return "", err
+	}
+	merkleRoot, err := bolt12MerkleRoot(tlvStream)
+	if err != nil {
+		return "", err
+	}
+	offerId := taggedHash("LDK Offer ID", merkleRoot)
+	return hex.EncodeToString(offerId), nil
+}
+
+// BOLT12 strings are bech32 without a checksum and can be split with "+"
+func decodeBolt12(encoded string, hrp string) ([]byte, error) {
+	encoded = strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(encoded, "+", " ")), ""))
+	if !strings.HasPrefix(encoded, hrp+"1") {
+		return nil, fmt.Errorf("invalid BOLT12 prefix, expected %s", hrp)
+	}
+	data := make([]byte, 0, len(encoded)-len(hrp)-1)
+	for _, char := range encoded[len(hrp)+1:] {
+		value := strings.IndexRune(bech32Charset, char)
+		if value == -1 {
+			return nil, fmt.Errorf("invalid bech32 character: %c", char)
+		}
+		data = append(data, byte(value))
+	}
+	return bech32.ConvertBits(data, 5, 8, false)
+}
+
+type tlvRecord struct {
+	typeBytes   []byte
+	recordBytes []byte
+}
+
+func readBigSize(data []byte) (value uint64, size int, err error) {
+	if len(data) == 0 {
+		return 0, 0, errors.New("unexpected end of TLV stream")
+	}
+	switch data[0] {
+	case 0xfd:
+		size = 3
+	case 0xfe:
+		size = 5
+	case 0xff:
+		size = 9
+	default:
+		return uint64(data[0]), 1, nil
+	}
+	if len(data) < size {
+		return 0, 0, errors.New("unexpected end of TLV stream")
+	}
+	switch size {
+	case 3:
+		value = uint64(binary.BigEndian.Uint16(data[1:3]))
+	case 5:
+		value = uint64(binary.BigEndian.Uint32(data[1:5]))
+	default:
+		value = binary.BigEndian.Uint64(data[1:9])
+	}
+	return value, size, nil
+}
+
+func parseTlvStream(data []byte) ([]tlvRecord, error) {
+	records := []tlvRecord{}
+	for offset := 0; offset < len(data); {
+		_, typeSize, err := readBigSize(data[offset:])
+		if err != nil {
+			return nil, err
+		}
+		length, lengthSize, err := readBigSize(data[offset+typeSize:])
+		if err != nil {
+			return nil, err
+		}
+		end := offset + typeSize + lengthSize + int(length)
+		if length > uint64(len(data)) || end > len(data) {
+			return nil, errors.New("TLV record exceeds the stream")
+		}
+		records = append(records, tlvRecord{
+			typeBytes:   data[offset : offset+typeSize],
+			recordBytes: data[offset:end],
+		})
+		offset = end
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty TLV stream")
+	}
+	return records, nil
+}
+
+func taggedHash(tag string, msg []byte) []byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	hash := sha256.New()
+	hash.Write(tagHash[:])
+	hash.Write(tagHash[:])
+	hash.Write(msg)
+	return hash.Sum(nil)
+}
+
+func branchHash(a []byte, b []byte) []byte {
+	if bytes.Compare(a, b) > 0 {
+		a, b = b, a
+	}
+	return taggedHash("LnBranch", append(append([]byte{}, a...), b...))
+}
+
+// bolt12MerkleRoot follows "Signature Calculation" of BOLT 12: every record has a leaf
+// and a nonce leaf and the tree is deepest on the lowest-order leaves.
+// Offers have no signature records, so all records are part of the tree.
+func bolt12MerkleRoot(tlvStream []byte) ([]byte, error) {
+	records, err := parseTlvStream(tlvStream)
+	if err != nil {
+		return nil, err
+	}
+
+	nonceTag := "LnNonce" + string(records[0].recordBytes)
+	leaves := make([][]byte, 0, 2*len(records))
+	for _, record := range records {
+		leaves = append(leaves, taggedHash("LnLeaf", record.recordBytes), taggedHash(nonceTag, record.typeBytes))
+	}
+
+	for step := 2; step/2 < len(leaves); step *= 2 {
+		for i := 0; i+step/2 < len(leaves); i += step {
+			leaves[i] = branchHash(leaves[i], leaves[i+step/2])
+		}
+	}
+	return leaves[0], nil
+}
@@ -25,6 +25,9 @@ import (
 
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/wtclientrpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type LNDService struct {
@@ -159,6 +162,15 @@ func (svc *LNDService) ListChannels(ctx context.Context) ([]lnclient.Channel, er
 		return nil, err
 	}
 
+	feeReport, err := svc.client.FeeReport(ctx, &lnrpc.FeeReportRequest{})
+	if err != nil {
+		return nil, err
+	}
+	channelFees := make(map[uint64]*lnrpc.ChannelFeeReport, len(feeReport.ChannelFees))
+	for _, channelFee := range feeReport.ChannelFees {
+		channelFees[channelFee.ChanId] = channelFee
+	}
+
 	channels := make([]lnclient.Channel, len(activeResp.Channels)+len(pendingResp.PendingOpenChannels))
 
 	for i, lndChannel := range activeResp.Channels {
@@ -171,6 +183,12 @@ func (svc *LNDService) ListChannels(ctx context.Context) ([]lnclient.Channel, er
 		channelOpeningBlockHeight := lndChannel.ChanId >> 40
 		confirmations := nodeInfo.BlockHeight - uint32(channelOpeningBlockHeight)
 
+		var forwardingFeeBaseMsat, forwardingFeeProportionalMillionths uint32
+		if channelFee, ok := channelFees[lndChannel.ChanId]; ok {
+			forwardingFeeBaseMsat = uint32(channelFee.BaseFeeMsat)
+			forwardingFeeProportionalMillionths = uint32(channelFee.FeePerMil)
+		}
+
 		channels[i] = lnclient.Channel{
 			InternalChannel:                          lndChannel,
 			LocalBalance:                             lndChannel.LocalBalance * 1000,
@@ -183,6 +201,8 @@ func (svc *LNDService) ListChannels(ctx context.Context) ([]lnclient.Channel, er
 			FundingTxId:                              channelPoint.GetFundingTxidStr(),
 			Confirmations:                            &confirmations,
 			ConfirmationsRequired:                    &confirmationsRequired,
+			ForwardingFeeBaseMsat:                    forwardingFeeBaseMsat,
+			ForwardingFeeProportionalMillionths:      forwardingFeeProportionalMillionths,
 			UnspendablePunishmentReserve:             lndChannel.LocalConstraints.ChanReserveSat,
 			CounterpartyUnspendablePunishmentReserve: lndChannel.RemoteConstraints.ChanReserveSat,
 		}
@@ -226,7 +246,7 @@ func (svc *LNDService) MakeInvoice(ctx context.Context, amount int64, descriptio
 		descriptionHashBytes, err = hex.DecodeString(descriptionHash)
 
 		if err != nil || len(descriptionHashBytes) != 32 {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"amount":          amount,
 				"description":     description,
 				"descriptionHash": descriptionHash,
@@ -279,7 +299,7 @@ func (svc *LNDService) LookupInvoice(ctx context.Context, paymentHash string) (t
 	paymentHashBytes, err := hex.DecodeString(paymentHash)
 
 	if err != nil || len(paymentHashBytes) != 32 {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"paymentHash": paymentHash,
 		}).Errorf("Invalid payment hash")
 		return nil, errors.New("Payment hash must be 32 bytes hex")
@@ -319,6 +339,74 @@ func (svc *LNDService) SendPaymentSync(ctx context.Context, payReq string) (*lnc
 	}, nil
 }
 
+func (svc *LNDService) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	sendPaymentRequest := &routerrpc.SendPaymentRequest{
+		PaymentRequest: payReq,
+		TimeoutSeconds: 50,
+	}
+	if options != nil {
+		sendPaymentRequest.MaxParts = options.MaxParts
+		sendPaymentRequest.Amp = options.AMP
+		sendPaymentRequest.FeeLimitMsat = int64(options.FeeLimitMsat)
+	}
+
+	paymentStream, err := svc.client.SendPaymentV2(ctx, sendPaymentRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	inFlight := false
+	for {
+		payment, err := paymentStream.Recv()
+		if err != nil {
+			// once LND dispatched the payment it can still succeed after the stream broke off
+			if inFlight || ctx.Err() != nil || isStreamTimeout(err) {
+				logger.LNClient.WithField("bolt11", payReq).WithError(err).Warn("Lost the multi-part payment stream while the payment is in flight")
+				return nil, lnclient.NewTimeoutError()
+			}
+			return nil, err
+		}
+
+		switch payment.Status {
+		case lnrpc.Payment_IN_FLIGHT:
+			inFlight = true
+		case lnrpc.Payment_FAILED:
+			return nil, fmt.Errorf("payment failed: %s", payment.FailureReason.String())
+		case lnrpc.Payment_SUCCEEDED:
+			parts := []lnclient.PaymentPart{}
+			for _, htlc := range payment.Htlcs {
+				if htlc.Status != lnrpc.HTLCAttempt_SUCCEEDED || htlc.Route == nil {
+					continue
+				}
+				parts = append(parts, lnclient.PaymentPart{
+					AmountMsat: uint64(htlc.Route.TotalAmtMsat - htlc.Route.TotalFeesMsat),
+					FeeMsat:    uint64(htlc.Route.TotalFeesMsat),
+				})
+			}
+
+			logger.LNClient.WithFields(logrus.Fields{
+				"payment_hash": payment.PaymentHash,
+				"parts":        len(parts),
+				"fee_msat":     payment.FeeMsat,
+			}).Info("Multi-part payment succeeded")
+
+			return &lnclient.PayInvoiceResponse{
+				Preimage: payment.PaymentPreimage,
+				Fee:      uint64(payment.FeeMsat),
+				Parts:    parts,
+			}, nil
+		}
+	}
+}
+
+func isStreamTimeout(err error) bool {
+	switch status.Code(err) {
+	case codes.DeadlineExceeded, codes.Canceled, codes.Unavailable:
+		return true
+	}
+	return false
+}
+
 func (svc *LNDService) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
 	destBytes, err := hex.DecodeString(destination)
 	if err != nil {
@@ -326,7 +414,7 @@ func (svc *LNDService) SendKeysend(ctx context.Context, amount uint64, destinati
 	}
 	preImageBytes, err := hex.DecodeString(preimage)
 	if err != nil || len(preImageBytes) != 32 {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"preimage": preimage,
 		}).WithError(err).Error("Invalid preimage")
 		return nil, err
@@ -357,7 +445,7 @@ func (svc *LNDService) SendKeysend(ctx context.Context, amount uint64, destinati
 
 	resp, err := svc.client.SendPaymentSync(ctx, sendPaymentRequest)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"amount":        amount,
 			"payeePubkey":   destination,
 			"paymentHash":   paymentHash,
@@ -368,7 +456,7 @@ func (svc *LNDService) SendKeysend(ctx context.Context, amount uint64, destinati
 		return nil, err
 	}
 	if resp.PaymentError != "" {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"amount":        amount,
 			"payeePubkey":   destination,
 			"paymentHash":   paymentHash,
@@ -380,7 +468,7 @@ func (svc *LNDService) SendKeysend(ctx context.Context, amount uint64, destinati
 	}
 	respPreimage := hex.EncodeToString(resp.PaymentPreimage)
 	if respPreimage != preimage {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"amount":        amount,
 			"payeePubkey":   destination,
 			"paymentHash":   paymentHash,
@@ -390,7 +478,7 @@ func (svc *LNDService) SendKeysend(ctx context.Context, amount uint64, destinati
 		}).Errorf("Preimage in keysend response does not match")
 		return nil, errors.New("preimage in keysend response does not match")
 	}
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"amount":        amount,
 		"payeePubkey":   destination,
 		"paymentHash":   paymentHash,
@@ -415,7 +503,7 @@ func NewLNDService(ctx context.Context, eventPublisher events.EventPublisher, ln
 		MacaroonHex: lndMacaroonHex,
 	})
 	if err != nil {
-		logger.Logger.Errorf("Failed to create new LND client %v", err)
+		logger.LNClient.Errorf("Failed to create new LND client %v", err)
 		return nil, err
 	}
 	info, err := lndClient.GetInfo(ctx, &lnrpc.GetInfoRequest{})
@@ -434,7 +522,7 @@ func NewLNDService(ctx context.Context, eventPublisher events.EventPublisher, ln
 				NoInflightUpdates: true,
 			})
 			if err != nil {
-				logger.Logger.WithError(err).Error("Error subscribing to payments")
+				logger.LNClient.WithError(err).Error("Error subscribing to payments")
 				continue
 			}
 			for {
@@ -444,13 +532,13 @@ func NewLNDService(ctx context.Context, eventPublisher events.EventPublisher, ln
 				default:
 					payment, err := paymentStream.Recv()
 					if err != nil {
-						logger.Logger.WithError(err).Error("Failed to receive payment")
+						logger.LNClient.WithError(err).Error("Failed to receive payment")
 						continue
 					}
 
 					switch payment.Status {
 					case lnrpc.Payment_FAILED:
-						logger.Logger.WithFields(logrus.Fields{
+						logger.LNClient.WithFields(logrus.Fields{
 							"payment": payment,
 						}).Info("Received payment failed notification")
 
@@ -466,7 +554,7 @@ func NewLNDService(ctx context.Context, eventPublisher events.EventPublisher, ln
 							},
 						})
 					case lnrpc.Payment_SUCCEEDED:
-						logger.Logger.WithFields(logrus.Fields{
+						logger.LNClient.WithFields(logrus.Fields{
 							"payment": payment,
 						}).Info("Received payment sent notification")
 
@@ -491,7 +579,7 @@ func NewLNDService(ctx context.Context, eventPublisher events.EventPublisher, ln
 		for {
 			invoiceStream, err := lndClient.SubscribeInvoices(lndCtx, &lnrpc.InvoiceSubscription{})
 			if err != nil {
-				logger.Logger.WithError(err).Error("Error subscribing to invoices")
+				logger.LNClient.WithError(err).Error("Error subscribing to invoices")
 				continue
 			}
 			for {
@@ -501,14 +589,14 @@ func NewLNDService(ctx context.Context, eventPublisher events.EventPublisher, ln
 				default:
 					invoice, err := invoiceStream.Recv()
 					if err != nil {
-						logger.Logger.WithError(err).Error("Failed to receive invoice")
+						logger.LNClient.WithError(err).Error("Failed to receive invoice")
 						continue
 					}
 					if invoice.State != lnrpc.Invoice_SETTLED {
 						continue
 					}
 
-					logger.Logger.WithFields(logrus.Fields{
+					logger.LNClient.WithFields(logrus.Fields{
 						"invoice": invoice,
 					}).Info("Received new invoice")
 
@@ -521,13 +609,13 @@ func NewLNDService(ctx context.Context, eventPublisher events.EventPublisher, ln
 		}
 	}()
 
-	logger.Logger.Infof("Connected to LND - alias %s", info.Alias)
+	logger.LNClient.Infof("Connected to LND - alias %s", info.Alias)
 
 	return lndService, nil
 }
 
 func (svc *LNDService) Shutdown() error {
-	logger.Logger.Info("cancelling LND context")
+	logger.LNClient.Info("cancelling LND context")
 	svc.cancel()
 	return nil
 }
@@ -570,7 +658,7 @@ func (svc *LNDService) OpenChannel(ctx context.Context, openChannelRequest *lncl
 		return nil, errors.New("node is not peered yet")
 	}
 
-	logger.Logger.WithField("peer_id", foundPeer.NodeId).Info("Opening channel")
+	logger.LNClient.WithField("peer_id", foundPeer.NodeId).Info("Opening channel")
 
 	nodePub, err := hex.DecodeString(openChannelRequest.Pubkey)
 	if err != nil {
@@ -601,7 +689,7 @@ func (svc *LNDService) OpenChannel(ctx context.Context, openChannelRequest *lncl
 }
 
 func (svc *LNDService) CloseChannel(ctx context.Context, closeChannelRequest *lnclient.CloseChannelRequest) (*lnclient.CloseChannelResponse, error) {
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"request": closeChannelRequest,
 	}).Info("Closing Channel")
 
@@ -649,7 +737,7 @@ func (svc *LNDService) CloseChannel(ctx context.Context, closeChannelRequest *ln
 			if err != nil {
 				return nil, err
 			}
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"closingTxid": txid.String(),
 			}).Info("Channel close pending")
 			// TODO: return the closing tx id or fire an event
@@ -663,7 +751,7 @@ func (svc *LNDService) GetNewOnchainAddress(ctx context.Context) (string, error)
 		Type: lnrpc.AddressType_WITNESS_PUBKEY_HASH,
 	})
 	if err != nil {
-		logger.Logger.WithError(err).Error("NewOnchainAddress failed")
+		logger.LNClient.WithError(err).Error("NewOnchainAddress failed")
 		return "", err
 	}
 	return resp.Address, nil
@@ -674,7 +762,7 @@ func (svc *LNDService) GetOnchainBalance(ctx context.Context) (*lnclient.Onchain
 	if err != nil {
 		return nil, err
 	}
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"balances": balances,
 	}).Debug("Listed Balances")
 	return &lnclient.OnchainBalanceResponse{
@@ -684,8 +772,24 @@ func (svc *LNDService) GetOnchainBalance(ctx context.Context) (*lnclient.Onchain
 	}, nil
 }
 
-func (svc *LNDService) RedeemOnchainFunds(ctx context.Context, toAddress string) (txId string, err error) {
-	return "", nil
+func (svc *LNDService) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (txId string, err error) {
+	sendCoinsRequest := &lnrpc.SendCoinsRequest{
+		Addr:    toAddress,
+		SendAll: sendAll,
+	}
+	if !sendAll {
+		sendCoinsRequest.Amount = int64(amount)
+	}
+	if feeRate != nil {
+		sendCoinsRequest.SatPerVbyte = *feeRate
+	}
+
+	resp, err := svc.client.SendCoins(ctx, sendCoinsRequest)
+	if err != nil {
+		logger.LNClient.WithError(err).Error("SendCoins failed")
+		return "", err
+	}
+	return resp.Txid, nil
 }
 
 func (svc *LNDService) SendPaymentProbes(ctx context.Context, invoice string) error {
@@ -723,10 +827,89 @@ func (svc *LNDService) SignMessage(ctx context.Context, message string) (string,
 	return resp.Signature, nil
 }
 
+func (svc *LNDService) MakeOffer(ctx context.Context, description string) (*lnclient.Offer, error) {
+	return nil, errors.New("not supported")
+}
+
+func (svc *LNDService) ExportChannelBackup(ctx context.Context) (*lnclient.ChannelBackup, error) {
+	resp, err := svc.client.ExportAllChannelBackups(ctx, &lnrpc.ChanBackupExportRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if resp.MultiChanBackup == nil {
+		return nil, errors.New("no channel backup returned by LND")
+	}
+
+	return &lnclient.ChannelBackup{
+		Data:         resp.MultiChanBackup.MultiChanBackup,
+		ChannelCount: len(resp.MultiChanBackup.ChanPoints),
+	}, nil
+}
+
+func (svc *LNDService) VerifyChannelBackup(ctx context.Context, channelBackup *lnclient.ChannelBackup) error {
+	// LND decrypts the backup with the node's keys, which also makes sure it belongs to this node
+	_, err := svc.client.VerifyChanBackup(ctx, &lnrpc.ChanBackupSnapshot{
+		MultiChanBackup: &lnrpc.MultiChanBackup{
+			MultiChanBackup: channelBackup.Data,
+		},
+	})
+	return err
+}
+
+func (svc *LNDService) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	resp, err := svc.client.ListTowers(ctx, &wtclientrpc.ListTowersRequest{IncludeSessions: true})
+	if err != nil {
+		return nil, err
+	}
+
+	watchtowers := make([]lnclient.Watchtower, 0, len(resp.Towers))
+	for _, tower := range resp.Towers {
+		watchtower := lnclient.Watchtower{
+			Pubkey:    hex.EncodeToString(tower.Pubkey),
+			Addresses: tower.Addresses,
+		}
+		// sessions are grouped by policy type (legacy, anchor, taproot)
+		for _, sessionInfo := range tower.SessionInfo {
+			watchtower.ActiveSessionCandidate = watchtower.ActiveSessionCandidate || sessionInfo.ActiveSessionCandidate
+			watchtower.NumSessions += sessionInfo.NumSessions
+			for _, session := range sessionInfo.Sessions {
+				watchtower.NumBackups += session.NumBackups
+				watchtower.NumPendingBackups += session.NumPendingBackups
+			}
+		}
+		watchtowers = append(watchtowers, watchtower)
+	}
+	return watchtowers, nil
+}
+
+func (svc *LNDService) AddWatchtower(ctx context.Context, pubkey string, address string) error {
+	pubkeyBytes, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return fmt.Errorf("invalid watchtower pubkey: %w", err)
+	}
+	_, err = svc.client.AddTower(ctx, &wtclientrpc.AddTowerRequest{
+		Pubkey:  pubkeyBytes,
+		Address: address,
+	})
+	return err
+}
+
+func (svc *LNDService) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	pubkeyBytes, err := hex.DecodeString(pubkey)
+	if err != nil {
+		return fmt.Errorf("invalid watchtower pubkey: %w", err)
+	}
+	// without an address the tower is not used for new sessions and backups anymore
+	_, err = svc.client.RemoveTower(ctx, &wtclientrpc.RemoveTowerRequest{
+		Pubkey: pubkeyBytes,
+	})
+	return err
+}
+
 func (svc *LNDService) GetBalances(ctx context.Context) (*lnclient.BalancesResponse, error) {
 	onchainBalance, err := svc.GetOnchainBalance(ctx)
 	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to retrieve onchain balance")
+		logger.LNClient.WithError(err).Error("Failed to retrieve onchain balance")
 		return nil, err
 	}
 
@@ -773,7 +956,7 @@ func lndPaymentToTransaction(payment *lnrpc.Payment) (*lnclient.Transaction, err
 	if payment.PaymentRequest != "" {
 		paymentRequest, err := decodepay.Decodepay(strings.ToLower(payment.PaymentRequest))
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.LNClient.WithFields(logrus.Fields{
 				"bolt11": payment.PaymentRequest,
 			}).Errorf("Failed to decode bolt11 invoice: %v", err)
 			return nil, err
@@ -868,7 +1051,7 @@ func (svc *LNDService) GetNetworkGraph(nodeIds []string) (lnclient.NetworkGraphR
 func (svc *LNDService) UpdateLastWalletSyncRequest() {}
 
 func (svc *LNDService) UpdateChannel(ctx context.Context, updateChannelRequest *lnclient.UpdateChannelRequest) error {
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"request": updateChannelRequest,
 	}).Info("Updating Channel")
 
@@ -896,12 +1079,17 @@ func (svc *LNDService) UpdateChannel(ctx context.Context, updateChannelRequest *
 		nodePolicy = channelEdge.Node2Policy
 	}
 
+	feeRatePpm := uint32(nodePolicy.FeeRateMilliMsat)
+	if updateChannelRequest.ForwardingFeeProportionalMillionths != nil {
+		feeRatePpm = *updateChannelRequest.ForwardingFeeProportionalMillionths
+	}
+
 	_, err = svc.client.UpdateChannel(ctx, &lnrpc.PolicyUpdateRequest{
 		Scope: &lnrpc.PolicyUpdateRequest_ChanPoint{
 			ChanPoint: channelPoint,
 		},
 		BaseFeeMsat:   int64(updateChannelRequest.ForwardingFeeBaseMsat),
-		FeeRatePpm:    uint32(nodePolicy.FeeRateMilliMsat),
+		FeeRatePpm:    feeRatePpm,
 		TimeLockDelta: nodePolicy.TimeLockDelta,
 		MaxHtlcMsat:   nodePolicy.MaxHtlcMsat,
 	})
@@ -7,11 +7,16 @@ import (
 	"encoding/hex"
 	"errors"
 
+	"github.com/getAlby/hub/logger"
 	"github.com/lightningnetwork/lnd/lnrpc"
 	"github.com/lightningnetwork/lnd/lnrpc/routerrpc"
+	"github.com/lightningnetwork/lnd/lnrpc/wtclientrpc"
 	"github.com/lightningnetwork/lnd/macaroons"
+	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 	"gopkg.in/macaroon.v2"
 )
 
@@ -32,6 +37,7 @@ type LNDoptions struct {
 type LNDWrapper struct {
 	client         lnrpc.LightningClient
 	routerClient   routerrpc.RouterClient
+	towerClient    wtclientrpc.WatchtowerClientClient
 	IdentityPubkey string
 }
 
@@ -75,6 +81,7 @@ func NewLNDclient(lndOptions LNDoptions) (result *LNDWrapper, err error) {
 		return nil, err
 	}
 	opts = append(opts, grpc.WithPerRPCCredentials(macCred))
+	opts = append(opts, grpc.WithChainUnaryInterceptor(logBodiesInterceptor))
 
 	conn, err := grpc.Dial(lndOptions.Address, opts...)
 	if err != nil {
@@ -84,9 +91,32 @@ func NewLNDclient(lndOptions LNDoptions) (result *LNDWrapper, err error) {
 	return &LNDWrapper{
 		client:       lnClient,
 		routerClient: routerrpc.NewRouterClient(conn),
+		towerClient:  wtclientrpc.NewWatchtowerClientClient(conn),
 	}, nil
 }
 
+// logBodiesInterceptor logs the requests to LND and its responses while the backend bodies debug toggle is enabled
+func logBodiesInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if !logger.IsDebugEnabled(logger.DebugBackendBodies) {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	fields := logrus.Fields{"method": method}
+	if message, ok := req.(proto.Message); ok {
+		body, _ := protojson.Marshal(message)
+		logger.LogBody(fields, body, "Backend request")
+	}
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	if err != nil {
+		logger.LNClient.WithFields(fields).WithError(err).Info("Backend request failed")
+		return err
+	}
+	if message, ok := reply.(proto.Message); ok {
+		body, _ := protojson.Marshal(message)
+		logger.LogBody(fields, body, "Backend response")
+	}
+	return nil
+}
+
 func (wrapper *LNDWrapper) ListChannels(ctx context.Context, req *lnrpc.ListChannelsRequest, options ...grpc.CallOption) (*lnrpc.ListChannelsResponse, error) {
 	return wrapper.client.ListChannels(ctx, req, options...)
 }
@@ -103,6 +133,10 @@ func (wrapper *LNDWrapper) SendPaymentSync(ctx context.Context, req *lnrpc.SendR
 	return wrapper.client.SendPaymentSync(ctx, req, options...)
 }
 
+func (wrapper *LNDWrapper) SendPaymentV2(ctx context.Context, req *routerrpc.SendPaymentRequest, options ...grpc.CallOption) (SubscribePaymentWrapper, error) {
+	return wrapper.routerClient.SendPaymentV2(ctx, req, options...)
+}
+
 func (wrapper *LNDWrapper) ChannelBalance(ctx context.Context, req *lnrpc.ChannelBalanceRequest, options ...grpc.CallOption) (*lnrpc.ChannelBalanceResponse, error) {
 	return wrapper.client.ChannelBalance(ctx, req, options...)
 }
@@ -181,10 +215,38 @@ func (wrapper *LNDWrapper) NewAddress(ctx context.Context, req *lnrpc.NewAddress
 	return wrapper.client.NewAddress(ctx, req, options...)
 }
 
+func (wrapper *LNDWrapper) SendCoins(ctx context.Context, req *lnrpc.SendCoinsRequest, options ...grpc.CallOption) (*lnrpc.SendCoinsResponse, error) {
+	return wrapper.client.SendCoins(ctx, req, options...)
+}
+
 func (wrapper *LNDWrapper) GetChanInfo(ctx context.Context, req *lnrpc.ChanInfoRequest, options ...grpc.CallOption) (*lnrpc.ChannelEdge, error) {
 	return wrapper.client.GetChanInfo(ctx, req, options...)
 }
 
+func (wrapper *LNDWrapper) FeeReport(ctx context.Context, req *lnrpc.FeeReportRequest, options ...grpc.CallOption) (*lnrpc.FeeReportResponse, error) {
+	return wrapper.client.FeeReport(ctx, req, options...)
+}
+
+func (wrapper *LNDWrapper) ExportAllChannelBackups(ctx context.Context, req *lnrpc.ChanBackupExportRequest, options ...grpc.CallOption) (*lnrpc.ChanBackupSnapshot, error) {
+	return wrapper.client.ExportAllChannelBackups(ctx, req, options...)
+}
+
+func (wrapper *LNDWrapper) VerifyChanBackup(ctx context.Context, req *lnrpc.ChanBackupSnapshot, options ...grpc.CallOption) (*lnrpc.VerifyChanBackupResponse, error) {
+	return wrapper.client.VerifyChanBackup(ctx, req, options...)
+}
+
+func (wrapper *LNDWrapper) ListTowers(ctx context.Context, req *wtclientrpc.ListTowersRequest, options ...grpc.CallOption) (*wtclientrpc.ListTowersResponse, error) {
+	return wrapper.towerClient.ListTowers(ctx, req, options...)
+}
+
+func (wrapper *LNDWrapper) AddTower(ctx context.Context, req *wtclientrpc.AddTowerRequest, options ...grpc.CallOption) (*wtclientrpc.AddTowerResponse, error) {
+	return wrapper.towerClient.AddTower(ctx, req, options...)
+}
+
+func (wrapper *LNDWrapper) RemoveTower(ctx context.Context, req *wtclientrpc.RemoveTowerRequest, options ...grpc.CallOption) (*wtclientrpc.RemoveTowerResponse, error) {
+	return wrapper.towerClient.RemoveTower(ctx, req, options...)
+}
+
 func (wrapper *LNDWrapper) UpdateChannel(ctx context.Context, req *lnrpc.PolicyUpdateRequest, options ...grpc.CallOption) (*lnrpc.PolicyUpdateResponse, error) {
 	return wrapper.client.UpdateChannelPolicy(ctx, req, options...)
 }
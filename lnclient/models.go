@@ -35,6 +35,30 @@ type Transaction struct {
 	ExpiresAt       *int64
 	SettledAt       *int64
 	Metadata        interface{}
+	// id of the BOLT12 offer an incoming payment was received for
+	OfferId string
+}
+
+// reusable BOLT12 offer, every payment to it has its own payment hash
+type Offer struct {
+	Offer   string
+	OfferId string
+}
+
+// node specific data to recover the funds of the channels if the node data is lost
+type ChannelBackup struct {
+	Data         []byte
+	ChannelCount int
+}
+
+// watchtower the node sends its channel states to, so that a breach is punished while the node is offline
+type Watchtower struct {
+	Pubkey                 string   `json:"pubkey"`
+	Addresses              []string `json:"addresses"`
+	ActiveSessionCandidate bool     `json:"activeSessionCandidate"`
+	NumSessions            uint32   `json:"numSessions"`
+	NumBackups             uint32   `json:"numBackups"`
+	NumPendingBackups      uint32   `json:"numPendingBackups"`
 }
 
 type NodeConnectionInfo struct {
@@ -45,11 +69,18 @@ type NodeConnectionInfo struct {
 
 type LNClient interface {
 	SendPaymentSync(ctx context.Context, payReq string) (*PayInvoiceResponse, error)
+	SendMultiPartPaymentSync(ctx context.Context, payReq string, options *MultiPartPaymentOptions) (*PayInvoiceResponse, error)
 	SendKeysend(ctx context.Context, amount uint64, destination string, customRecords []TLVRecord, preimage string) (*PayKeysendResponse, error)
 	GetBalance(ctx context.Context) (balance int64, err error)
 	GetPubkey() string
 	GetInfo(ctx context.Context) (info *NodeInfo, err error)
 	MakeInvoice(ctx context.Context, amount int64, description string, descriptionHash string, expiry int64) (transaction *Transaction, err error)
+	MakeOffer(ctx context.Context, description string) (offer *Offer, err error)
+	ExportChannelBackup(ctx context.Context) (*ChannelBackup, error)
+	VerifyChannelBackup(ctx context.Context, channelBackup *ChannelBackup) error
+	ListWatchtowers(ctx context.Context) ([]Watchtower, error)
+	AddWatchtower(ctx context.Context, pubkey string, address string) error
+	RemoveWatchtower(ctx context.Context, pubkey string) error
 	LookupInvoice(ctx context.Context, paymentHash string) (transaction *Transaction, err error)
 	ListTransactions(ctx context.Context, from, until, limit, offset uint64, unpaid bool, invoiceType string) (transactions []Transaction, err error)
 	Shutdown() error
@@ -65,7 +96,7 @@ type LNClient interface {
 	ResetRouter(key string) error
 	GetOnchainBalance(ctx context.Context) (*OnchainBalanceResponse, error)
 	GetBalances(ctx context.Context) (*BalancesResponse, error)
-	RedeemOnchainFunds(ctx context.Context, toAddress string) (txId string, err error)
+	RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (txId string, err error)
 	SendPaymentProbes(ctx context.Context, invoice string) error
 	SendSpontaneousPaymentProbes(ctx context.Context, amountMsat uint64, nodeId string) error
 	ListPeers(ctx context.Context) ([]PeerDetails, error)
@@ -91,6 +122,7 @@ type Channel struct {
 	Confirmations                            *uint32
 	ConfirmationsRequired                    *uint32
 	ForwardingFeeBaseMsat                    uint32
+	ForwardingFeeProportionalMillionths      uint32
 	UnspendablePunishmentReserve             uint64
 	CounterpartyUnspendablePunishmentReserve uint64
 	Error                                    *string
@@ -123,9 +155,10 @@ type CloseChannelRequest struct {
 }
 
 type UpdateChannelRequest struct {
-	ChannelId             string `json:"channelId"`
-	NodeId                string `json:"nodeId"`
-	ForwardingFeeBaseMsat uint32 `json:"forwardingFeeBaseMsat"`
+	ChannelId                           string  `json:"channelId"`
+	NodeId                              string  `json:"nodeId"`
+	ForwardingFeeBaseMsat               uint32  `json:"forwardingFeeBaseMsat"`
+	ForwardingFeeProportionalMillionths *uint32 `json:"forwardingFeeProportionalMillionths"`
 }
 
 type CloseChannelResponse struct {
@@ -155,6 +188,22 @@ type LightningBalanceResponse struct {
 type PayInvoiceResponse struct {
 	Preimage string `json:"preimage"`
 	Fee      uint64 `json:"fee"`
+	// only set by backends that report the individual parts of a multi-part payment
+	Parts []PaymentPart `json:"parts,omitempty"`
+}
+
+type MultiPartPaymentOptions struct {
+	// maximum number of parts the payment can be split into. 0 uses the backend default
+	MaxParts uint32
+	// send an atomic multi-path payment (AMP) instead of a regular MPP payment
+	AMP bool
+	// maximum total routing fee across all parts
+	FeeLimitMsat uint64
+}
+
+type PaymentPart struct {
+	AmountMsat uint64
+	FeeMsat    uint64
 }
 
 type PayKeysendResponse struct {
@@ -181,3 +230,14 @@ func NewTimeoutError() error {
 func (err *timeoutError) Error() string {
 	return "Timeout"
 }
+
+type multiPartPaymentNotSupportedError struct {
+}
+
+func NewMultiPartPaymentNotSupportedError() error {
+	return &multiPartPaymentNotSupportedError{}
+}
+
+func (err *multiPartPaymentNotSupportedError) Error() string {
+	return "Multi-part payment options are not supported by this node"
+}
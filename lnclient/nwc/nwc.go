@@ -0,0 +1,652 @@
+package nwc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/sirupsen/logrus"
+
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nip47/models"
+)
+
+// methods the hub can forward to the upstream wallet
+var forwardedMethods = []string{
+	models.PAY_INVOICE_METHOD,
+	models.GET_BALANCE_METHOD,
+	models.GET_INFO_METHOD,
+	models.MAKE_INVOICE_METHOD,
+	models.LOOKUP_INVOICE_METHOD,
+	models.LIST_TRANSACTIONS_METHOD,
+	models.PAY_KEYSEND_METHOD,
+	models.SIGN_MESSAGE_METHOD,
+}
+
+// UpstreamError is an error response of the upstream wallet
+type UpstreamError struct {
+	Code    string
+	Message string
+}
+
+func (err *UpstreamError) Error() string {
+	return fmt.Sprintf("upstream wallet returned %s: %s", err.Code, err.Message)
+}
+
+type getInfoResult struct {
+	Alias         string   `json:"alias"`
+	Color         string   `json:"color"`
+	Pubkey        string   `json:"pubkey"`
+	Network       string   `json:"network"`
+	BlockHeight   uint32   `json:"block_height"`
+	BlockHash     string   `json:"block_hash"`
+	Methods       []string `json:"methods"`
+	Notifications []string `json:"notifications"`
+}
+
+type getBalanceResult struct {
+	Balance int64 `json:"balance"`
+}
+
+type payResult struct {
+	Preimage string `json:"preimage"`
+	FeesPaid uint64 `json:"fees_paid"`
+}
+
+type listTransactionsResult struct {
+	Transactions []models.Transaction `json:"transactions"`
+}
+
+type signMessageResult struct {
+	Signature string `json:"signature"`
+}
+
+type response struct {
+	Error      *models.Error   `json:"error,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	ResultType string          `json:"result_type"`
+}
+
+type notification struct {
+	NotificationType string             `json:"notification_type"`
+	Notification     models.Transaction `json:"notification"`
+}
+
+// NWCService uses an upstream NWC wallet as the node, so the hub can hand out
+// connections with their own permissions and budgets for a single upstream connection
+type NWCService struct {
+	walletPubkey string
+	relayUrl     string
+	secretKey    string
+	pubkey       string
+	sharedSecret []byte
+	info         *getInfoResult
+	ctx          context.Context
+	cancel       context.CancelFunc
+	relayMtx     sync.Mutex
+	relay        *nostr.Relay
+}
+
+// NewNWCService connects to the wallet of a nostr+walletconnect:// connection string.
+// hubPubkey is the wallet pubkey of this hub, a hub cannot use one of its own connections.
+func NewNWCService(ctx context.Context, eventPublisher events.EventPublisher, connectionUri string, hubPubkey string) (result lnclient.LNClient, err error) {
+	walletPubkey, relayUrl, secretKey, err := parseConnectionUri(connectionUri)
+	if err != nil {
+		return nil, err
+	}
+	if walletPubkey == hubPubkey {
+		return nil, errors.New("the NWC connection belongs to this hub")
+	}
+
+	pubkey, err := nostr.GetPublicKey(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := nip04.ComputeSharedSecret(walletPubkey, secretKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nwcCtx, cancel := context.WithCancel(ctx)
+	nwcService := &NWCService{
+		walletPubkey: walletPubkey,
+		relayUrl:     relayUrl,
+		secretKey:    secretKey,
+		pubkey:       pubkey,
+		sharedSecret: sharedSecret,
+		ctx:          nwcCtx,
+		cancel:       cancel,
+	}
+
+	info := &getInfoResult{}
+	err = nwcService.request(ctx, models.GET_INFO_METHOD, struct{}{}, info, 30*time.Second)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	nwcService.info = info
+
+	go nwcService.subscribeNotifications(eventPublisher)
+
+	logger.LNClient.WithFields(logrus.Fields{
+		"wallet_pubkey": walletPubkey,
+		"relay":         relayUrl,
+		"methods":       info.Methods,
+	}).Info("Connected to upstream NWC wallet")
+
+	return nwcService, nil
+}
+
+func parseConnectionUri(connectionUri string) (walletPubkey string, relayUrl string, secretKey string, err error) {
+	parsedUri, err := url.Parse(strings.TrimSpace(connectionUri))
+	if err != nil || (parsedUri.Scheme != "nostr+walletconnect" && parsedUri.Scheme != "nostrwalletconnect") {
+		return "", "", "", errors.New("invalid NWC connection string")
+	}
+	walletPubkey = parsedUri.Host
+	if walletPubkey == "" {
+		// nostr+walletconnect:<pubkey>?...
+		walletPubkey = parsedUri.Opaque
+	}
+	relayUrl = parsedUri.Query().Get("relay")
+	secretKey = parsedUri.Query().Get("secret")
+	if !nostr.IsValid32ByteHex(walletPubkey) || !nostr.IsValid32ByteHex(secretKey) || relayUrl == "" {
+		return "", "", "", errors.New("NWC connection string needs a wallet pubkey, relay and secret")
+	}
+	return walletPubkey, relayUrl, secretKey, nil
+}
+
+func (svc *NWCService) getRelay() (*nostr.Relay, error) {
+	svc.relayMtx.Lock()
+	defer svc.relayMtx.Unlock()
+	if svc.relay != nil && svc.relay.IsConnected() {
+		return svc.relay, nil
+	}
+	relay, err := nostr.RelayConnect(svc.ctx, svc.relayUrl)
+	if err != nil {
+		return nil, err
+	}
+	svc.relay = relay
+	return relay, nil
+}
+
+// request sends a NIP-47 request to the upstream wallet and decodes the result of its response
+func (svc *NWCService) request(ctx context.Context, method string, params interface{}, result interface{}, timeout time.Duration) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"method": method,
+		"params": params,
+	})
+	if err != nil {
+		return err
+	}
+	content, err := nip04.Encrypt(string(payload), svc.sharedSecret)
+	if err != nil {
+		return err
+	}
+	event := nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{[]string{"p", svc.walletPubkey}},
+		Content:   content,
+	}
+	err = event.Sign(svc.secretKey)
+	if err != nil {
+		return err
+	}
+
+	relay, err := svc.getRelay()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// subscribe before publishing so the response cannot be missed
+	sub, err := relay.Subscribe(ctx, nostr.Filters{{
+		Kinds:   []int{models.RESPONSE_KIND},
+		Authors: []string{svc.walletPubkey},
+		Tags:    nostr.TagMap{"e": []string{event.ID}},
+	}})
+	if err != nil {
+		return err
+	}
+	defer sub.Unsub()
+
+	err = relay.Publish(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return lnclient.NewTimeoutError()
+	case responseEvent, ok := <-sub.Events:
+		if !ok {
+			return errors.New("relay closed the subscription")
+		}
+		decrypted, err := nip04.Decrypt(responseEvent.Content, svc.sharedSecret)
+		if err != nil {
+			return err
+		}
+		nip47Response := response{}
+		err = json.Unmarshal([]byte(decrypted), &nip47Response)
+		if err != nil {
+			return err
+		}
+		if nip47Response.Error != nil {
+			return &UpstreamError{
+				Code:    nip47Response.Error.Code,
+				Message: nip47Response.Error.Message,
+			}
+		}
+		if result == nil {
+			return nil
+		}
+		return json.Unmarshal(nip47Response.Result, result)
+	}
+}
+
+// subscribeNotifications forwards the payment notifications of the upstream wallet to the hub
+func (svc *NWCService) subscribeNotifications(eventPublisher events.EventPublisher) {
+	if !slices.Contains(svc.info.Notifications, "payment_received") && !slices.Contains(svc.info.Notifications, "payment_sent") {
+		return
+	}
+	for {
+		if svc.ctx.Err() != nil {
+			return
+		}
+		err := svc.receiveNotifications(eventPublisher)
+		if err != nil {
+			logger.LNClient.WithError(err).Error("Failed to subscribe to upstream NWC notifications")
+		}
+		select {
+		case <-svc.ctx.Done():
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
+
+func (svc *NWCService) receiveNotifications(eventPublisher events.EventPublisher) error {
+	relay, err := svc.getRelay()
+	if err != nil {
+		return err
+	}
+	since := nostr.Now()
+	sub, err := relay.Subscribe(svc.ctx, nostr.Filters{{
+		Kinds:   []int{models.NOTIFICATION_KIND},
+		Authors: []string{svc.walletPubkey},
+		Tags:    nostr.TagMap{"p": []string{svc.pubkey}},
+		Since:   &since,
+	}})
+	if err != nil {
+		return err
+	}
+	defer sub.Unsub()
+
+	for event := range sub.Events {
+		decrypted, err := nip04.Decrypt(event.Content, svc.sharedSecret)
+		if err != nil {
+			logger.LNClient.WithError(err).Error("Failed to decrypt upstream NWC notification")
+			continue
+		}
+		nip47Notification := notification{}
+		err = json.Unmarshal([]byte(decrypted), &nip47Notification)
+		if err != nil {
+			logger.LNClient.WithError(err).Error("Failed to decode upstream NWC notification")
+			continue
+		}
+
+		switch nip47Notification.NotificationType {
+		case "payment_received":
+			eventPublisher.Publish(&events.Event{
+				Event:      "nwc_payment_received",
+				Properties: toLNClientTransaction(&nip47Notification.Notification),
+			})
+		case "payment_sent":
+			eventPublisher.Publish(&events.Event{
+				Event:      "nwc_payment_sent",
+				Properties: toLNClientTransaction(&nip47Notification.Notification),
+			})
+		}
+	}
+	return errors.New("relay closed the notification subscription")
+}
+
+func toLNClientTransaction(transaction *models.Transaction) *lnclient.Transaction {
+	return &lnclient.Transaction{
+		Type:            transaction.Type,
+		Invoice:         transaction.Invoice,
+		Description:     transaction.Description,
+		DescriptionHash: transaction.DescriptionHash,
+		Preimage:        transaction.Preimage,
+		PaymentHash:     transaction.PaymentHash,
+		Amount:          transaction.Amount,
+		FeesPaid:        transaction.FeesPaid,
+		CreatedAt:       transaction.CreatedAt,
+		ExpiresAt:       transaction.ExpiresAt,
+		SettledAt:       transaction.SettledAt,
+		Metadata:        transaction.Metadata,
+	}
+}
+
+func (svc *NWCService) GetBalance(ctx context.Context) (balance int64, err error) {
+	result := &getBalanceResult{}
+	err = svc.request(ctx, models.GET_BALANCE_METHOD, struct{}{}, result, 30*time.Second)
+	if err != nil {
+		return 0, err
+	}
+	return result.Balance, nil
+}
+
+func (svc *NWCService) GetBalances(ctx context.Context) (*lnclient.BalancesResponse, error) {
+	balance, err := svc.GetBalance(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lnclient.BalancesResponse{
+		Onchain: lnclient.OnchainBalanceResponse{
+			Spendable: 0,
+			Total:     0,
+		},
+		Lightning: lnclient.LightningBalanceResponse{
+			TotalSpendable:       balance,
+			TotalReceivable:      0,
+			NextMaxSpendable:     balance,
+			NextMaxReceivable:    0,
+			NextMaxSpendableMPP:  balance,
+			NextMaxReceivableMPP: 0,
+		},
+	}, nil
+}
+
+func (svc *NWCService) ListTransactions(ctx context.Context, from, until, limit, offset uint64, unpaid bool, invoiceType string) (transactions []lnclient.Transaction, err error) {
+	params := map[string]interface{}{
+		"unpaid": unpaid,
+	}
+	if from != 0 {
+		params["from"] = from
+	}
+	if until != 0 {
+		params["until"] = until
+	}
+	if limit != 0 {
+		params["limit"] = limit
+	}
+	if offset != 0 {
+		params["offset"] = offset
+	}
+	if invoiceType != "" {
+		params["type"] = invoiceType
+	}
+
+	result := &listTransactionsResult{}
+	err = svc.request(ctx, models.LIST_TRANSACTIONS_METHOD, params, result, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions = []lnclient.Transaction{}
+	for _, transaction := range result.Transactions {
+		transactions = append(transactions, *toLNClientTransaction(&transaction))
+	}
+	return transactions, nil
+}
+
+func (svc *NWCService) GetInfo(ctx context.Context) (info *lnclient.NodeInfo, err error) {
+	result := &getInfoResult{}
+	err = svc.request(ctx, models.GET_INFO_METHOD, struct{}{}, result, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	network := result.Network
+	if network == "" {
+		network = "bitcoin"
+	}
+	return &lnclient.NodeInfo{
+		Alias:       result.Alias,
+		Color:       result.Color,
+		Pubkey:      result.Pubkey,
+		Network:     network,
+		BlockHeight: result.BlockHeight,
+		BlockHash:   result.BlockHash,
+	}, nil
+}
+
+func (svc *NWCService) ListChannels(ctx context.Context) ([]lnclient.Channel, error) {
+	channels := []lnclient.Channel{}
+	return channels, nil
+}
+
+func (svc *NWCService) MakeInvoice(ctx context.Context, amount int64, description string, descriptionHash string, expiry int64) (transaction *lnclient.Transaction, err error) {
+	params := map[string]interface{}{
+		"amount": amount,
+	}
+	if description != "" {
+		params["description"] = description
+	}
+	if descriptionHash != "" {
+		params["description_hash"] = descriptionHash
+	}
+	if expiry != 0 {
+		params["expiry"] = expiry
+	}
+
+	result := &models.Transaction{}
+	err = svc.request(ctx, models.MAKE_INVOICE_METHOD, params, result, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return toLNClientTransaction(result), nil
+}
+
+func (svc *NWCService) LookupInvoice(ctx context.Context, paymentHash string) (transaction *lnclient.Transaction, err error) {
+	result := &models.Transaction{}
+	err = svc.request(ctx, models.LOOKUP_INVOICE_METHOD, map[string]interface{}{
+		"payment_hash": paymentHash,
+	}, result, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return toLNClientTransaction(result), nil
+}
+
+func (svc *NWCService) SendPaymentSync(ctx context.Context, payReq string) (*lnclient.PayInvoiceResponse, error) {
+	result := &payResult{}
+	err := svc.request(ctx, models.PAY_INVOICE_METHOD, map[string]interface{}{
+		"invoice": payReq,
+	}, result, 90*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &lnclient.PayInvoiceResponse{
+		Preimage: result.Preimage,
+		Fee:      result.FeesPaid,
+	}, nil
+}
+
+func (svc *NWCService) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	return nil, lnclient.NewMultiPartPaymentNotSupportedError()
+}
+
+func (svc *NWCService) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
+	params := map[string]interface{}{
+		"amount": amount,
+		"pubkey": destination,
+	}
+	if preimage != "" {
+		params["preimage"] = preimage
+	}
+	if len(custom_records) > 0 {
+		params["tlv_records"] = custom_records
+	}
+
+	result := &payResult{}
+	err := svc.request(ctx, models.PAY_KEYSEND_METHOD, params, result, 90*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &lnclient.PayKeysendResponse{
+		Fee: result.FeesPaid,
+	}, nil
+}
+
+func (svc *NWCService) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (txId string, err error) {
+	return "", errors.New("not implemented")
+}
+
+func (svc *NWCService) ResetRouter(key string) error {
+	return nil
+}
+
+func (svc *NWCService) Shutdown() error {
+	svc.cancel()
+	svc.relayMtx.Lock()
+	defer svc.relayMtx.Unlock()
+	if svc.relay != nil {
+		svc.relay.Close()
+	}
+	return nil
+}
+
+func (svc *NWCService) GetNodeConnectionInfo(ctx context.Context) (nodeConnectionInfo *lnclient.NodeConnectionInfo, err error) {
+	return &lnclient.NodeConnectionInfo{
+		Pubkey: svc.info.Pubkey,
+	}, nil
+}
+
+func (svc *NWCService) ConnectPeer(ctx context.Context, connectPeerRequest *lnclient.ConnectPeerRequest) error {
+	return errors.New("not implemented")
+}
+
+func (svc *NWCService) OpenChannel(ctx context.Context, openChannelRequest *lnclient.OpenChannelRequest) (*lnclient.OpenChannelResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *NWCService) CloseChannel(ctx context.Context, closeChannelRequest *lnclient.CloseChannelRequest) (*lnclient.CloseChannelResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *NWCService) GetNewOnchainAddress(ctx context.Context) (string, error) {
+	return "", errors.New("not implemented")
+}
+
+func (svc *NWCService) GetOnchainBalance(ctx context.Context) (*lnclient.OnchainBalanceResponse, error) {
+	return &lnclient.OnchainBalanceResponse{}, nil
+}
+
+func (svc *NWCService) SignMessage(ctx context.Context, message string) (string, error) {
+	result := &signMessageResult{}
+	err := svc.request(ctx, models.SIGN_MESSAGE_METHOD, map[string]interface{}{
+		"message": message,
+	}, result, 30*time.Second)
+	if err != nil {
+		return "", err
+	}
+	return result.Signature, nil
+}
+
+func (svc *NWCService) MakeOffer(ctx context.Context, description string) (*lnclient.Offer, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *NWCService) ExportChannelBackup(ctx context.Context) (*lnclient.ChannelBackup, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *NWCService) VerifyChannelBackup(ctx context.Context, channelBackup *lnclient.ChannelBackup) error {
+	return errors.New("not implemented")
+}
+
+func (svc *NWCService) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *NWCService) AddWatchtower(ctx context.Context, pubkey string, address string) error {
+	return errors.New("not implemented")
+}
+
+func (svc *NWCService) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	return errors.New("not implemented")
+}
+
+func (svc *NWCService) SendPaymentProbes(ctx context.Context, invoice string) error {
+	return nil
+}
+
+func (svc *NWCService) SendSpontaneousPaymentProbes(ctx context.Context, amountMsat uint64, nodeId string) error {
+	return nil
+}
+
+func (svc *NWCService) ListPeers(ctx context.Context) ([]lnclient.PeerDetails, error) {
+	return nil, nil
+}
+
+func (svc *NWCService) GetLogOutput(ctx context.Context, maxLen int) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func (svc *NWCService) GetNodeStatus(ctx context.Context) (nodeStatus *lnclient.NodeStatus, err error) {
+	return nil, nil
+}
+
+func (svc *NWCService) GetStorageDir() (string, error) {
+	return "", nil
+}
+
+func (svc *NWCService) GetNetworkGraph(nodeIds []string) (lnclient.NetworkGraphResponse, error) {
+	return nil, nil
+}
+
+func (svc *NWCService) UpdateLastWalletSyncRequest() {}
+
+func (svc *NWCService) DisconnectPeer(ctx context.Context, peerId string) error {
+	return nil
+}
+
+func (svc *NWCService) UpdateChannel(ctx context.Context, updateChannelRequest *lnclient.UpdateChannelRequest) error {
+	return nil
+}
+
+// GetSupportedNIP47Methods returns the methods the upstream connection allows
+func (svc *NWCService) GetSupportedNIP47Methods() []string {
+	methods := []string{}
+	for _, method := range svc.info.Methods {
+		if slices.Contains(forwardedMethods, method) {
+			methods = append(methods, method)
+		}
+	}
+	// multi payments are split by the hub, so they only need pay_invoice or pay_keysend upstream
+	if slices.Contains(methods, models.PAY_INVOICE_METHOD) {
+		methods = append(methods, models.MULTI_PAY_INVOICE_METHOD)
+	}
+	if slices.Contains(methods, models.PAY_KEYSEND_METHOD) {
+		methods = append(methods, models.MULTI_PAY_KEYSEND_METHOD)
+	}
+	return methods
+}
+
+func (svc *NWCService) GetSupportedNIP47NotificationTypes() []string {
+	notificationTypes := []string{}
+	for _, notificationType := range svc.info.Notifications {
+		if notificationType == "payment_received" || notificationType == "payment_sent" {
+			notificationTypes = append(notificationTypes, notificationType)
+		}
+	}
+	return notificationTypes
+}
+
+func (svc *NWCService) GetPubkey() string {
+	return svc.info.Pubkey
+}
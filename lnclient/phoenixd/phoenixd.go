@@ -19,6 +19,9 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// logs the requests to the node while the backend bodies debug toggle is enabled
+var bodyLoggingTransport = logger.NewBodyLoggingTransport(http.DefaultTransport)
+
 type InvoiceResponse struct {
 	PaymentHash string `json:"paymentHash"`
 	Preimage    string `json:"preimage"`
@@ -95,7 +98,7 @@ func (svc *PhoenixService) GetBalance(ctx context.Context) (balance int64, err e
 		return 0, err
 	}
 	req.Header.Add("Authorization", "Basic "+svc.Authorization)
-	client := &http.Client{Timeout: 5 * time.Second}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: bodyLoggingTransport}
 	resp, err := client.Do(req)
 	if err != nil {
 		return 0, err
@@ -150,7 +153,7 @@ func (svc *PhoenixService) ListTransactions(ctx context.Context, from, until, li
 
 	incomingUrl := svc.Address + "/payments/incoming?" + incomingQuery.Encode()
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"url": incomingUrl,
 	}).Infof("Fetching incoming tranasctions: %s", incomingUrl)
 	incomingReq, err := http.NewRequest(http.MethodGet, incomingUrl, nil)
@@ -158,7 +161,7 @@ func (svc *PhoenixService) ListTransactions(ctx context.Context, from, until, li
 		return nil, err
 	}
 	incomingReq.Header.Add("Authorization", "Basic "+svc.Authorization)
-	client := &http.Client{Timeout: 5 * time.Second}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: bodyLoggingTransport}
 
 	incomingResp, err := client.Do(incomingReq)
 	if err != nil {
@@ -209,7 +212,7 @@ func (svc *PhoenixService) ListTransactions(ctx context.Context, from, until, li
 
 	outgoingUrl := svc.Address + "/payments/outgoing?" + outgoingQuery.Encode()
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"url": outgoingUrl,
 	}).Infof("Fetching outgoing tranasctions: %s", outgoingUrl)
 	outgoingReq, err := http.NewRequest(http.MethodGet, outgoingUrl, nil)
@@ -260,7 +263,7 @@ func (svc *PhoenixService) GetInfo(ctx context.Context) (info *lnclient.NodeInfo
 		return nil, err
 	}
 	req.Header.Add("Authorization", "Basic "+svc.Authorization)
-	client := &http.Client{Timeout: 5 * time.Second}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: bodyLoggingTransport}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -304,7 +307,7 @@ func (svc *PhoenixService) MakeInvoice(ctx context.Context, amount int64, descri
 
 	today := time.Now().UTC().Format("2006-02-01") // querying is too slow so we limit the invoices we query with the date - see list transactions
 	form.Add("externalId", today)                  // for some resone phoenixd requires an external id to query a list of invoices. thus we set this to nwc
-	logger.Logger.WithFields(logrus.Fields{
+	logger.LNClient.WithFields(logrus.Fields{
 		"externalId": today,
 		"amountSat":  amountSat,
 	}).Infof("Requesting phoenix invoice")
@@ -314,7 +317,7 @@ func (svc *PhoenixService) MakeInvoice(ctx context.Context, amount int64, descri
 	}
 	req.Header.Add("Authorization", "Basic "+svc.Authorization)
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	client := &http.Client{Timeout: 10 * time.Second}
+	client := &http.Client{Timeout: 10 * time.Second, Transport: bodyLoggingTransport}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -328,7 +331,7 @@ func (svc *PhoenixService) MakeInvoice(ctx context.Context, amount int64, descri
 
 	paymentRequest, err := decodepay.Decodepay(invoiceRes.Serialized)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"bolt11": invoiceRes.Serialized,
 		}).Errorf("Failed to decode bolt11 invoice: %v", err)
 
@@ -359,7 +362,7 @@ func (svc *PhoenixService) LookupInvoice(ctx context.Context, paymentHash string
 		return nil, err
 	}
 	req.Header.Add("Authorization", "Basic "+svc.Authorization)
-	client := &http.Client{Timeout: 5 * time.Second}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: bodyLoggingTransport}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -379,7 +382,7 @@ func (svc *PhoenixService) LookupInvoice(ctx context.Context, paymentHash string
 
 	paymentRequest, err := decodepay.Decodepay(invoiceRes.Invoice)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.LNClient.WithFields(logrus.Fields{
 			"bolt11": invoiceRes.Invoice,
 		}).Errorf("Failed to decode bolt11 invoice: %v", err)
 
@@ -413,7 +416,7 @@ func (svc *PhoenixService) SendPaymentSync(ctx context.Context, payReq string) (
 	}
 	req.Header.Add("Authorization", "Basic "+svc.Authorization)
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	client := &http.Client{Timeout: 90 * time.Second}
+	client := &http.Client{Timeout: 90 * time.Second, Transport: bodyLoggingTransport}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -431,11 +434,15 @@ func (svc *PhoenixService) SendPaymentSync(ctx context.Context, payReq string) (
 	}, nil
 }
 
+func (svc *PhoenixService) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	return nil, lnclient.NewMultiPartPaymentNotSupportedError()
+}
+
 func (svc *PhoenixService) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
 	return nil, errors.New("not implemented")
 }
 
-func (svc *PhoenixService) RedeemOnchainFunds(ctx context.Context, toAddress string) (txId string, err error) {
+func (svc *PhoenixService) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (txId string, err error) {
 	return "", errors.New("not implemented")
 }
 
@@ -453,7 +460,7 @@ func (svc *PhoenixService) GetNodeConnectionInfo(ctx context.Context) (nodeConne
 		return nil, err
 	}
 	req.Header.Add("Authorization", "Basic "+svc.Authorization)
-	client := &http.Client{Timeout: 5 * time.Second}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: bodyLoggingTransport}
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, err
@@ -492,6 +499,30 @@ func (svc *PhoenixService) SignMessage(ctx context.Context, message string) (str
 	return "", errors.New("not implemented")
 }
 
+func (svc *PhoenixService) MakeOffer(ctx context.Context, description string) (*lnclient.Offer, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *PhoenixService) ExportChannelBackup(ctx context.Context) (*lnclient.ChannelBackup, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *PhoenixService) VerifyChannelBackup(ctx context.Context, channelBackup *lnclient.ChannelBackup) error {
+	return errors.New("not implemented")
+}
+
+func (svc *PhoenixService) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (svc *PhoenixService) AddWatchtower(ctx context.Context, pubkey string, address string) error {
+	return errors.New("not implemented")
+}
+
+func (svc *PhoenixService) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	return errors.New("not implemented")
+}
+
 func (svc *PhoenixService) SendPaymentProbes(ctx context.Context, invoice string) error {
 	return nil
 }
@@ -0,0 +1,174 @@
+package lnurl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/transactions"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type lnurlService struct {
+	db                  *gorm.DB
+	cfg                 config.Config
+	transactionsService transactions.TransactionsService
+	// withdraw payments that are still being paid after the callback was answered
+	payments sync.WaitGroup
+}
+
+func NewLNURLService(db *gorm.DB, cfg config.Config, transactionsService transactions.TransactionsService) *lnurlService {
+	return &lnurlService{
+		db:                  db,
+		cfg:                 cfg,
+		transactionsService: transactionsService,
+	}
+}
+
+func (svc *lnurlService) GetPayRequest(username string) (*PayRequestResponse, error) {
+	_, err := svc.findRecipient(username)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PayRequestResponse{
+		Tag:            PAY_REQUEST_TAG,
+		Callback:       fmt.Sprintf("%s/api/lnurlp/%s/callback", strings.TrimSuffix(svc.cfg.GetEnv().BaseUrl, "/"), url.PathEscape(username)),
+		MinSendable:    MIN_SENDABLE_MSAT,
+		MaxSendable:    MAX_SENDABLE_MSAT,
+		Metadata:       svc.getMetadata(username),
+		CommentAllowed: COMMENT_ALLOWED,
+	}, nil
+}
+
+func (svc *lnurlService) HandlePayRequestCallback(ctx context.Context, username string, amountMsat uint64, comment string, lnClient lnclient.LNClient) (*PayRequestCallbackResponse, error) {
+	appId, err := svc.findRecipient(username)
+	if err != nil {
+		return nil, err
+	}
+
+	if amountMsat < MIN_SENDABLE_MSAT || amountMsat > MAX_SENDABLE_MSAT {
+		return nil, fmt.Errorf("amount must be between %d and %d millisats", MIN_SENDABLE_MSAT, MAX_SENDABLE_MSAT)
+	}
+	if len(comment) > COMMENT_ALLOWED {
+		return nil, fmt.Errorf("comment is too long. Limit: %d Received: %d", COMMENT_ALLOWED, len(comment))
+	}
+
+	// LUD-06: the invoice description hash must commit to the metadata
+	metadataHash := sha256.Sum256([]byte(svc.getMetadata(username)))
+
+	var metadata map[string]interface{}
+	if comment != "" {
+		metadata = map[string]interface{}{
+			"comment": comment,
+		}
+	}
+
+	logger.Logger.WithFields(logrus.Fields{
+		"username":    username,
+		"app_id":      appId,
+		"amount_msat": amountMsat,
+	}).Info("Creating invoice for lightning address payment")
+
+	transaction, err := svc.transactionsService.MakeInvoice(ctx, int64(amountMsat), "", hex.EncodeToString(metadataHash[:]), 0, metadata, lnClient, appId, nil)
+	if err != nil {
+		logger.Logger.WithFields(logrus.Fields{
+			"username": username,
+			"app_id":   appId,
+		}).WithError(err).Error("Failed to create invoice for lightning address payment")
+		return nil, err
+	}
+
+	return &PayRequestCallbackResponse{
+		PaymentRequest: transaction.PaymentRequest,
+		Routes:         []interface{}{},
+	}, nil
+}
+
+func (svc *lnurlService) GetLightningAddress(username string) string {
+	if username == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s@%s", username, svc.getDomain())
+}
+
+// returns the id of the app that should receive payments to the username,
+// or nil if the payment should be received by the main wallet
+func (svc *lnurlService) findRecipient(username string) (*uint, error) {
+	if username == "" {
+		return nil, errors.New("no username provided")
+	}
+
+	hubUsername := svc.cfg.GetEnv().LightningAddressUsername
+	if hubUsername != "" && strings.EqualFold(username, hubUsername) {
+		if !svc.cfg.IsFeatureEnabled(config.FeatureLNURL) {
+			return nil, fmt.Errorf("unknown lightning address: %s", username)
+		}
+		return nil, nil
+	}
+
+	app := db.App{}
+	result := svc.db.Limit(1).Find(&app, &db.App{
+		LightningAddressUsername: strings.ToLower(username),
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || !svc.cfg.IsFeatureEnabledForApp(config.FeatureLNURL, app.ID) {
+		return nil, fmt.Errorf("unknown lightning address: %s", username)
+	}
+	return &app.ID, nil
+}
+
+func (svc *lnurlService) getMetadata(username string) string {
+	lightningAddress := svc.GetLightningAddress(username)
+	metadata, _ := json.Marshal([][]string{
+		{"text/plain", fmt.Sprintf("Payment to %s", lightningAddress)},
+		{"text/identifier", lightningAddress},
+	})
+	return string(metadata)
+}
+
+func (svc *lnurlService) getDomain() string {
+	baseUrl, err := url.Parse(svc.cfg.GetEnv().BaseUrl)
+	if err != nil || baseUrl.Hostname() == "" {
+		return "localhost"
+	}
+	return baseUrl.Hostname()
+}
+
+// ValidateAppUsername checks the lightning address username of an app,
+// which must not shadow the lightning address of the hub
+func (svc *lnurlService) ValidateAppUsername(username string) error {
+	err := ValidateUsername(username)
+	if err != nil {
+		return err
+	}
+	hubUsername := svc.cfg.GetEnv().LightningAddressUsername
+	if hubUsername != "" && strings.EqualFold(username, hubUsername) {
+		return fmt.Errorf("lightning address username %s is used by the hub", username)
+	}
+	return nil
+}
+
+var usernameRegex = regexp.MustCompile(`^[a-z0-9\-_.]+$`)
+
+// LUD-16: usernames are limited to a-z0-9-_.
+func ValidateUsername(username string) error {
+	if !usernameRegex.MatchString(username) {
+		return fmt.Errorf("invalid lightning address username: %s", username)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package lnurl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/tests"
+	"github.com/getAlby/hub/transactions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPayRequest_App(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().BaseUrl = "https://hub.example.com"
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Model(app).Update("lightning_address_username", "alice").Error
+	assert.NoError(t, err)
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	payRequest, err := lnurlSvc.GetPayRequest("alice")
+	assert.NoError(t, err)
+	assert.Equal(t, PAY_REQUEST_TAG, payRequest.Tag)
+	assert.Equal(t, "https://hub.example.com/api/lnurlp/alice/callback", payRequest.Callback)
+	assert.Equal(t, `[["text/plain","Payment to alice@hub.example.com"],["text/identifier","alice@hub.example.com"]]`, payRequest.Metadata)
+}
+
+func TestGetPayRequest_UnknownUsername(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	payRequest, err := lnurlSvc.GetPayRequest("bob")
+	assert.Error(t, err)
+	assert.Nil(t, payRequest)
+}
+
+func TestHandlePayRequestCallback_App(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Model(app).Update("lightning_address_username", "alice").Error
+	assert.NoError(t, err)
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	response, err := lnurlSvc.HandlePayRequestCallback(ctx, "alice", 123000, "thanks!", svc.LNClient)
+	assert.NoError(t, err)
+	assert.Equal(t, tests.MockLNClientTransaction.Invoice, response.PaymentRequest)
+
+	transaction := transactions.Transaction{}
+	err = svc.DB.First(&transaction).Error
+	assert.NoError(t, err)
+	assert.Equal(t, app.ID, *transaction.AppId)
+	assert.Equal(t, `{"comment":"thanks!"}`, transaction.Metadata)
+}
+
+func TestHandlePayRequestCallback_HubUsername(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().LightningAddressUsername = "hub"
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	_, err = lnurlSvc.HandlePayRequestCallback(ctx, "hub", 123000, "", svc.LNClient)
+	assert.NoError(t, err)
+
+	transaction := transactions.Transaction{}
+	err = svc.DB.First(&transaction).Error
+	assert.NoError(t, err)
+	assert.Nil(t, transaction.AppId)
+}
+
+func TestHandlePayRequestCallback_AmountTooSmall(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().LightningAddressUsername = "hub"
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	response, err := lnurlSvc.HandlePayRequestCallback(ctx, "hub", 999, "", svc.LNClient)
+	assert.Error(t, err)
+	assert.Nil(t, response)
+}
+
+func TestValidateAppUsername(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().LightningAddressUsername = "hub"
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	assert.NoError(t, lnurlSvc.ValidateAppUsername("alice"))
+	assert.EqualError(t, lnurlSvc.ValidateAppUsername("hub"), "lightning address username hub is used by the hub")
+	assert.Error(t, lnurlSvc.ValidateAppUsername("Alice!"))
+}
+
+func TestGetPayRequest_FeatureEnabledForApp(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	err = svc.Cfg.SetFeatureEnabled(config.FeatureLNURL, false)
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Model(app).Update("lightning_address_username", "alice").Error
+	assert.NoError(t, err)
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	_, err = lnurlSvc.GetPayRequest("alice")
+	assert.EqualError(t, err, "unknown lightning address: alice")
+
+	err = svc.Cfg.SetAppFeatureEnabled(config.FeatureLNURL, app.ID, true)
+	assert.NoError(t, err)
+	_, err = lnurlSvc.GetPayRequest("alice")
+	assert.NoError(t, err)
+}
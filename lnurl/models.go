@@ -0,0 +1,68 @@
+package lnurl
+
+import (
+	"context"
+	"time"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/lnclient"
+)
+
+const (
+	PAY_REQUEST_TAG      = "payRequest"
+	WITHDRAW_REQUEST_TAG = "withdrawRequest"
+
+	STATUS_OK    = "OK"
+	STATUS_ERROR = "ERROR"
+
+	// LUD-06 amounts are in millisats
+	MIN_SENDABLE_MSAT = 1000
+	MAX_SENDABLE_MSAT = 100_000_000_000
+
+	// LUD-12
+	COMMENT_ALLOWED = 255
+)
+
+type LNURLService interface {
+	GetPayRequest(username string) (*PayRequestResponse, error)
+	HandlePayRequestCallback(ctx context.Context, username string, amountMsat uint64, comment string, lnClient lnclient.LNClient) (*PayRequestCallbackResponse, error)
+	GetLightningAddress(username string) string
+	ValidateAppUsername(username string) error
+	CreateWithdraw(appId uint, amountMsat uint64, description string, expiresAt *time.Time) (*db.LNURLWithdraw, error)
+	EncodeWithdrawLNURL(k1 string) (string, error)
+	GetWithdrawRequest(k1 string) (*WithdrawRequestResponse, error)
+	HandleWithdrawCallback(ctx context.Context, k1 string, payReq string, lnClient lnclient.LNClient) error
+}
+
+type PayRequestResponse struct {
+	Tag            string `json:"tag"`
+	Callback       string `json:"callback"`
+	MinSendable    uint64 `json:"minSendable"`
+	MaxSendable    uint64 `json:"maxSendable"`
+	Metadata       string `json:"metadata"`
+	CommentAllowed int    `json:"commentAllowed"`
+}
+
+type PayRequestCallbackResponse struct {
+	PaymentRequest string        `json:"pr"`
+	Routes         []interface{} `json:"routes"`
+}
+
+// LUD-03
+type WithdrawRequestResponse struct {
+	Tag                string `json:"tag"`
+	Callback           string `json:"callback"`
+	K1                 string `json:"k1"`
+	DefaultDescription string `json:"defaultDescription"`
+	MinWithdrawable    uint64 `json:"minWithdrawable"`
+	MaxWithdrawable    uint64 `json:"maxWithdrawable"`
+}
+
+type StatusResponse struct {
+	Status string `json:"status"`
+}
+
+type ErrorResponse struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
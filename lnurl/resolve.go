@@ -0,0 +1,199 @@
+package lnurl
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/getAlby/hub/logger"
+	decodepay "github.com/nbd-wtf/ln-decodepay"
+	"github.com/sirupsen/logrus"
+)
+
+// ResolvePayRequest fetches an invoice for the given amount from a bech32
+// encoded LNURL-pay link or a lightning address (LUD-06, LUD-16)
+func ResolvePayRequest(ctx context.Context, lnurlOrAddress string, amountMsat uint64, comment string) (string, error) {
+	payRequestUrl, err := decodePayRequestUrl(lnurlOrAddress)
+	if err != nil {
+		return "", err
+	}
+
+	payRequest := &PayRequestResponse{}
+	err = fetchJSON(ctx, payRequestUrl, payRequest)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch pay request: %v", err)
+	}
+	if payRequest.Tag != PAY_REQUEST_TAG {
+		return "", fmt.Errorf("unexpected lnurl tag: %s", payRequest.Tag)
+	}
+	if amountMsat < payRequest.MinSendable || amountMsat > payRequest.MaxSendable {
+		return "", fmt.Errorf("amount must be between %d and %d millisats", payRequest.MinSendable, payRequest.MaxSendable)
+	}
+
+	callbackUrl, err := url.Parse(payRequest.Callback)
+	if err != nil {
+		return "", fmt.Errorf("invalid pay request callback: %v", err)
+	}
+	query := callbackUrl.Query()
+	query.Set("amount", strconv.FormatUint(amountMsat, 10))
+	if comment != "" && payRequest.CommentAllowed > 0 {
+		if len(comment) > payRequest.CommentAllowed {
+			return "", fmt.Errorf("comment is too long. Limit: %d Received: %d", payRequest.CommentAllowed, len(comment))
+		}
+		query.Set("comment", comment)
+	}
+	callbackUrl.RawQuery = query.Encode()
+
+	callbackResponse := &PayRequestCallbackResponse{}
+	err = fetchJSON(ctx, callbackUrl.String(), callbackResponse)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch invoice: %v", err)
+	}
+
+	// never trust the service: the invoice must match what was requested
+	paymentRequest, err := decodepay.Decodepay(strings.ToLower(callbackResponse.PaymentRequest))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode invoice: %v", err)
+	}
+	if uint64(paymentRequest.MSatoshi) != amountMsat {
+		return "", fmt.Errorf("invoice amount %d does not match requested amount %d", paymentRequest.MSatoshi, amountMsat)
+	}
+	metadataHash := sha256.Sum256([]byte(payRequest.Metadata))
+	if paymentRequest.DescriptionHash != "" && paymentRequest.DescriptionHash != hex.EncodeToString(metadataHash[:]) {
+		return "", errors.New("invoice description hash does not match pay request metadata")
+	}
+
+	logger.Logger.WithFields(logrus.Fields{
+		"lnurl":       lnurlOrAddress,
+		"amount_msat": amountMsat,
+	}).Info("Resolved lnurl pay request")
+
+	return callbackResponse.PaymentRequest, nil
+}
+
+func decodePayRequestUrl(lnurlOrAddress string) (string, error) {
+	lnurlOrAddress = strings.TrimPrefix(strings.TrimSpace(lnurlOrAddress), "lightning:")
+
+	if username, domain, found := strings.Cut(lnurlOrAddress, "@"); found {
+		if username == "" || domain == "" {
+			return "", fmt.Errorf("invalid lightning address: %s", lnurlOrAddress)
+		}
+		return fmt.Sprintf("https://%s/.well-known/lnurlp/%s", domain, url.PathEscape(strings.ToLower(username))), nil
+	}
+
+	// LNURLs are longer than the 90 character limit of regular bech32 strings
+	hrp, data, err := bech32.DecodeNoLimit(strings.ToLower(lnurlOrAddress))
+	if err != nil {
+		return "", fmt.Errorf("invalid lnurl: %v", err)
+	}
+	if hrp != "lnurl" {
+		return "", fmt.Errorf("invalid lnurl prefix: %s", hrp)
+	}
+	decoded, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return "", fmt.Errorf("invalid lnurl: %v", err)
+	}
+	return string(decoded), nil
+}
+
+// the LNURLs are given by apps, so the client must not be able to reach the hub's own network
+var httpClient = newPublicHTTPClient()
+
+func newPublicHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// checked on connect rather than on lookup so the host cannot resolve to another address in between
+		Control: func(network, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("connecting to %s is not allowed", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return validateUrl(req.URL)
+		},
+	}
+}
+
+// carrier-grade NAT (RFC 6598), often used for internal networks such as Tailscale
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsMulticast() &&
+		!ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}
+
+// LUD-01: LNURLs must use https. Onion services cannot be reached as the hub does not connect through Tor
+func validateUrl(requestUrl *url.URL) error {
+	if strings.HasSuffix(requestUrl.Hostname(), ".onion") {
+		return fmt.Errorf("onion lnurls are not supported: %s", requestUrl.Redacted())
+	}
+	if requestUrl.Scheme != "https" {
+		return fmt.Errorf("lnurl must use https: %s", requestUrl.Redacted())
+	}
+	return nil
+}
+
+func fetchJSON(ctx context.Context, requestUrl string, v interface{}) error {
+	parsedUrl, err := url.Parse(requestUrl)
+	if err != nil {
+		return err
+	}
+	err = validateUrl(parsedUrl)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	rawBody := json.RawMessage{}
+	err = json.NewDecoder(res.Body).Decode(&rawBody)
+	if err != nil {
+		return fmt.Errorf("failed to decode response (status %d): %v", res.StatusCode, err)
+	}
+	errorResponse := ErrorResponse{}
+	err = json.Unmarshal(rawBody, &errorResponse)
+	if err == nil && errorResponse.Status == STATUS_ERROR {
+		return errors.New(errorResponse.Reason)
+	}
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+
+	return json.Unmarshal(rawBody, v)
+}
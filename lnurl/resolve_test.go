@@ -0,0 +1,108 @@
+package lnurl
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockLNURLServer(t *testing.T, invoice string) *httptest.Server {
+	mux := http.NewServeMux()
+	server := httptest.NewTLSServer(mux)
+	// the mock server listens on localhost, which the lnurl client refuses to connect to
+	defaultClient := httpClient
+	httpClient = server.Client()
+	t.Cleanup(func() {
+		httpClient = defaultClient
+	})
+	mux.HandleFunc("/lnurlp/alice", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(PayRequestResponse{
+			Tag:         PAY_REQUEST_TAG,
+			Callback:    server.URL + "/lnurlp/alice/callback",
+			MinSendable: 1000,
+			MaxSendable: 1000000,
+			Metadata:    `[["text/plain","alice"]]`,
+		})
+	})
+	mux.HandleFunc("/lnurlp/alice/callback", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(PayRequestCallbackResponse{
+			PaymentRequest: invoice,
+		})
+	})
+	return server
+}
+
+func encodeLNURL(t *testing.T, url string) string {
+	encoded, err := bech32.EncodeFromBase256("lnurl", []byte(url))
+	assert.NoError(t, err)
+	return strings.ToUpper(encoded)
+}
+
+func TestResolvePayRequest(t *testing.T) {
+	server := newMockLNURLServer(t, tests.MockInvoice)
+	defer server.Close()
+
+	invoice, err := ResolvePayRequest(context.TODO(), encodeLNURL(t, server.URL+"/lnurlp/alice"), 123000, "")
+	assert.NoError(t, err)
+	assert.Equal(t, tests.MockInvoice, invoice)
+}
+
+func TestResolvePayRequest_AmountMismatch(t *testing.T) {
+	server := newMockLNURLServer(t, tests.MockInvoice)
+	defer server.Close()
+
+	_, err := ResolvePayRequest(context.TODO(), encodeLNURL(t, server.URL+"/lnurlp/alice"), 100000, "")
+	assert.Error(t, err)
+	assert.Equal(t, "invoice amount 123000 does not match requested amount 100000", err.Error())
+}
+
+func TestResolvePayRequest_AmountOutOfRange(t *testing.T) {
+	server := newMockLNURLServer(t, tests.MockInvoice)
+	defer server.Close()
+
+	_, err := ResolvePayRequest(context.TODO(), encodeLNURL(t, server.URL+"/lnurlp/alice"), 2000000, "")
+	assert.Error(t, err)
+}
+
+func TestDecodePayRequestUrl_LightningAddress(t *testing.T) {
+	payRequestUrl, err := decodePayRequestUrl("Alice@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/.well-known/lnurlp/alice", payRequestUrl)
+
+	_, err = decodePayRequestUrl("@example.com")
+	assert.Error(t, err)
+}
+
+func TestFetchJSON_RequiresHttps(t *testing.T) {
+	err := fetchJSON(context.TODO(), "http://example.com/.well-known/lnurlp/alice", &PayRequestResponse{})
+	assert.EqualError(t, err, "lnurl must use https: http://example.com/.well-known/lnurlp/alice")
+
+	assert.EqualError(t, validateUrl(&url.URL{Scheme: "http", Host: "example.onion"}), "onion lnurls are not supported: http://example.onion")
+	assert.EqualError(t, validateUrl(&url.URL{Scheme: "https", Host: "example.onion"}), "onion lnurls are not supported: https://example.onion")
+}
+
+func TestFetchJSON_RejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	err := fetchJSON(context.TODO(), server.URL, &PayRequestResponse{})
+	assert.ErrorContains(t, err, "connecting to 127.0.0.1 is not allowed")
+
+	for _, ip := range []string{"10.0.0.1", "192.168.1.1", "169.254.169.254", "::1", "fe80::1", "0.0.0.0", "100.64.0.1", "100.127.255.254", "224.0.0.1", "239.255.255.250", "ff02::1"} {
+		assert.False(t, isPublicIP(net.ParseIP(ip)), ip)
+	}
+	for _, ip := range []string{"1.1.1.1", "100.63.255.255", "100.128.0.1"} {
+		assert.True(t, isPublicIP(net.ParseIP(ip)), ip)
+	}
+}
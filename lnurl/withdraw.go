@@ -0,0 +1,173 @@
+package lnurl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	decodepay "github.com/nbd-wtf/ln-decodepay"
+	"github.com/sirupsen/logrus"
+)
+
+func (svc *lnurlService) CreateWithdraw(appId uint, amountMsat uint64, description string, expiresAt *time.Time) (*db.LNURLWithdraw, error) {
+	if amountMsat < MIN_SENDABLE_MSAT {
+		return nil, fmt.Errorf("amount must be at least %d millisats", MIN_SENDABLE_MSAT)
+	}
+
+	var appPermission db.AppPermission
+	result := svc.db.Limit(1).Find(&appPermission, &db.AppPermission{
+		AppId: appId,
+		Scope: constants.PAY_INVOICE_SCOPE,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("app does not have pay_invoice scope")
+	}
+
+	k1Bytes := make([]byte, 32)
+	_, err := rand.Read(k1Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	withdraw := db.LNURLWithdraw{
+		AppId:       appId,
+		K1:          hex.EncodeToString(k1Bytes),
+		AmountMsat:  amountMsat,
+		Description: description,
+		ExpiresAt:   expiresAt,
+	}
+	err = svc.db.Create(&withdraw).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &withdraw, nil
+}
+
+// LUD-01: the link is the bech32 encoded URL of the withdraw request
+func (svc *lnurlService) EncodeWithdrawLNURL(k1 string) (string, error) {
+	encoded, err := bech32.EncodeFromBase256("lnurl", []byte(svc.getWithdrawRequestUrl(k1)))
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(encoded), nil
+}
+
+func (svc *lnurlService) GetWithdrawRequest(k1 string) (*WithdrawRequestResponse, error) {
+	withdraw, err := svc.findUnusedWithdraw(k1)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WithdrawRequestResponse{
+		Tag:                WITHDRAW_REQUEST_TAG,
+		Callback:           fmt.Sprintf("%s/api/lnurlw/callback", strings.TrimSuffix(svc.cfg.GetEnv().BaseUrl, "/")),
+		K1:                 withdraw.K1,
+		DefaultDescription: withdraw.Description,
+		MinWithdrawable:    withdraw.AmountMsat,
+		MaxWithdrawable:    withdraw.AmountMsat,
+	}, nil
+}
+
+func (svc *lnurlService) HandleWithdrawCallback(ctx context.Context, k1 string, payReq string, lnClient lnclient.LNClient) error {
+	withdraw, err := svc.findUnusedWithdraw(k1)
+	if err != nil {
+		return err
+	}
+
+	paymentRequest, err := decodepay.Decodepay(strings.ToLower(payReq))
+	if err != nil {
+		return fmt.Errorf("failed to decode invoice: %v", err)
+	}
+	if uint64(paymentRequest.MSatoshi) != withdraw.AmountMsat {
+		return fmt.Errorf("invoice amount must be %d millisats", withdraw.AmountMsat)
+	}
+
+	// claim the link before paying so concurrent callbacks cannot both pay it
+	now := time.Now()
+	result := svc.db.Model(&db.LNURLWithdraw{}).Where("id = ? AND used_at IS NULL", withdraw.ID).Update("used_at", &now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("withdraw link has already been used")
+	}
+
+	// LUD-03: the service replies once the request is valid and pays the invoice afterwards.
+	// The payment must not be cancelled when the wallet of the user disconnects, as the link is already claimed.
+	svc.payments.Add(1)
+	go func() {
+		defer svc.payments.Done()
+		svc.payWithdraw(context.WithoutCancel(ctx), withdraw, payReq, lnClient)
+	}()
+
+	return nil
+}
+
+func (svc *lnurlService) payWithdraw(ctx context.Context, withdraw *db.LNURLWithdraw, payReq string, lnClient lnclient.LNClient) {
+	logger.Logger.WithFields(logrus.Fields{
+		"app_id":      withdraw.AppId,
+		"amount_msat": withdraw.AmountMsat,
+	}).Info("Paying lnurl withdraw request")
+
+	transaction, err := svc.transactionsService.SendPaymentSync(ctx, payReq, lnClient, &withdraw.AppId, nil)
+	if err != nil {
+		logger.Logger.WithFields(logrus.Fields{
+			"app_id": withdraw.AppId,
+		}).WithError(err).Error("Failed to pay lnurl withdraw request")
+
+		// the payment may still succeed after a timeout, so the link stays claimed
+		if !errors.Is(err, lnclient.NewTimeoutError()) {
+			dbErr := svc.db.Model(&db.LNURLWithdraw{}).Where("id = ?", withdraw.ID).Update("used_at", nil).Error
+			if dbErr != nil {
+				logger.Logger.WithError(dbErr).Error("Failed to release lnurl withdraw link")
+			}
+		}
+		return
+	}
+
+	err = svc.db.Model(&db.LNURLWithdraw{}).Where("id = ?", withdraw.ID).Update("transaction_id", transaction.ID).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to link transaction to lnurl withdraw")
+	}
+}
+
+func (svc *lnurlService) findUnusedWithdraw(k1 string) (*db.LNURLWithdraw, error) {
+	if k1 == "" {
+		return nil, errors.New("no k1 provided")
+	}
+
+	withdraw := db.LNURLWithdraw{}
+	result := svc.db.Limit(1).Find(&withdraw, &db.LNURLWithdraw{
+		K1: k1,
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("unknown withdraw request")
+	}
+	if withdraw.UsedAt != nil {
+		return nil, errors.New("withdraw link has already been used")
+	}
+	if withdraw.ExpiresAt != nil && withdraw.ExpiresAt.Before(time.Now()) {
+		return nil, errors.New("withdraw link has expired")
+	}
+	return &withdraw, nil
+}
+
+func (svc *lnurlService) getWithdrawRequestUrl(k1 string) string {
+	return fmt.Sprintf("%s/api/lnurlw/%s", strings.TrimSuffix(svc.cfg.GetEnv().BaseUrl, "/"), k1)
+}
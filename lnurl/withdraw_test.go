@@ -0,0 +1,135 @@
+package lnurl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+	"github.com/getAlby/hub/transactions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleWithdrawCallback(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{AppId: app.ID, App: *app, Scope: constants.PAY_INVOICE_SCOPE}).Error
+	assert.NoError(t, err)
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	withdraw, err := lnurlSvc.CreateWithdraw(app.ID, 123000, "voucher", nil)
+	assert.NoError(t, err)
+
+	withdrawRequest, err := lnurlSvc.GetWithdrawRequest(withdraw.K1)
+	assert.NoError(t, err)
+	assert.Equal(t, WITHDRAW_REQUEST_TAG, withdrawRequest.Tag)
+	assert.Equal(t, uint64(123000), withdrawRequest.MaxWithdrawable)
+
+	err = lnurlSvc.HandleWithdrawCallback(ctx, withdraw.K1, tests.MockInvoice, svc.LNClient)
+	assert.NoError(t, err)
+	lnurlSvc.payments.Wait()
+
+	svc.DB.First(withdraw, withdraw.ID)
+	assert.NotNil(t, withdraw.UsedAt)
+	assert.NotNil(t, withdraw.TransactionId)
+
+	// links are single-use
+	err = lnurlSvc.HandleWithdrawCallback(ctx, withdraw.K1, tests.MockInvoice, svc.LNClient)
+	assert.Error(t, err)
+	_, err = lnurlSvc.GetWithdrawRequest(withdraw.K1)
+	assert.Error(t, err)
+}
+
+func TestHandleWithdrawCallback_PaysAfterCallbackCancelled(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{AppId: app.ID, App: *app, Scope: constants.PAY_INVOICE_SCOPE}).Error
+	assert.NoError(t, err)
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	withdraw, err := lnurlSvc.CreateWithdraw(app.ID, 123000, "", nil)
+	assert.NoError(t, err)
+
+	// the wallet disconnects right after the callback was answered
+	ctx, cancel := context.WithCancel(context.TODO())
+	err = lnurlSvc.HandleWithdrawCallback(ctx, withdraw.K1, tests.MockInvoice, svc.LNClient)
+	cancel()
+	assert.NoError(t, err)
+	lnurlSvc.payments.Wait()
+
+	svc.DB.First(withdraw, withdraw.ID)
+	assert.NotNil(t, withdraw.UsedAt)
+	assert.NotNil(t, withdraw.TransactionId)
+}
+
+func TestHandleWithdrawCallback_WrongAmount(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{AppId: app.ID, App: *app, Scope: constants.PAY_INVOICE_SCOPE}).Error
+	assert.NoError(t, err)
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	withdraw, err := lnurlSvc.CreateWithdraw(app.ID, 100000, "", nil)
+	assert.NoError(t, err)
+
+	err = lnurlSvc.HandleWithdrawCallback(ctx, withdraw.K1, tests.MockInvoice, svc.LNClient)
+	assert.Error(t, err)
+	assert.Equal(t, "invoice amount must be 100000 millisats", err.Error())
+}
+
+func TestHandleWithdrawCallback_PaymentFailedReleasesLink(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{AppId: app.ID, App: *app, Scope: constants.PAY_INVOICE_SCOPE}).Error
+	assert.NoError(t, err)
+
+	svc.LNClient.(*tests.MockLn).PayInvoiceErrors = append(svc.LNClient.(*tests.MockLn).PayInvoiceErrors, errors.New("Some error"))
+	svc.LNClient.(*tests.MockLn).PayInvoiceResponses = append(svc.LNClient.(*tests.MockLn).PayInvoiceResponses, nil)
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	withdraw, err := lnurlSvc.CreateWithdraw(app.ID, 123000, "", nil)
+	assert.NoError(t, err)
+
+	// the callback is answered before the payment fails
+	err = lnurlSvc.HandleWithdrawCallback(ctx, withdraw.K1, tests.MockInvoice, svc.LNClient)
+	assert.NoError(t, err)
+	lnurlSvc.payments.Wait()
+
+	svc.DB.First(withdraw, withdraw.ID)
+	assert.Nil(t, withdraw.UsedAt)
+}
+
+func TestCreateWithdraw_NoPayInvoiceScope(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	lnurlSvc := NewLNURLService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	withdraw, err := lnurlSvc.CreateWithdraw(app.ID, 123000, "", nil)
+	assert.Error(t, err)
+	assert.Nil(t, withdraw)
+}
@@ -0,0 +1,146 @@
+package lockout
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/logger"
+)
+
+const (
+	// failed attempts allowed before a source is locked out
+	freeAttempts = 5
+	// the lockout doubles with every further failed attempt
+	initialLockout = 30 * time.Second
+	maxLockout     = 24 * time.Hour
+)
+
+type lockoutService struct {
+	db             *gorm.DB
+	eventPublisher events.EventPublisher
+}
+
+// LockoutService throttles unlock password attempts per source
+type LockoutService interface {
+	// Check returns a lockedOutError if the source has to wait before trying again
+	Check(source string) error
+	RecordFailure(source string)
+	RecordSuccess(source string)
+}
+
+func NewLockoutService(db *gorm.DB, eventPublisher events.EventPublisher) *lockoutService {
+	return &lockoutService{
+		db:             db,
+		eventPublisher: eventPublisher,
+	}
+}
+
+type lockedOutError struct {
+	RetryAfter time.Duration
+}
+
+func NewLockedOutError(retryAfter time.Duration) error {
+	return &lockedOutError{
+		RetryAfter: retryAfter,
+	}
+}
+
+func (err *lockedOutError) Error() string {
+	return fmt.Sprintf("too many failed attempts, try again in %s", err.RetryAfter.Round(time.Second))
+}
+
+// RetryAfter returns how long the source is locked out for, if err is a lockout
+func RetryAfter(err error) (time.Duration, bool) {
+	var lockedOutErr *lockedOutError
+	if errors.As(err, &lockedOutErr) {
+		return lockedOutErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+func (svc *lockoutService) Check(source string) error {
+	var loginAttempt db.LoginAttempt
+	err := svc.db.Limit(1).Find(&loginAttempt, &db.LoginAttempt{Source: source}).Error
+	if err != nil {
+		logger.Logger.WithField("source", source).WithError(err).Error("Failed to load login attempts")
+		return err
+	}
+	if loginAttempt.LockedUntil != nil && time.Now().Before(*loginAttempt.LockedUntil) {
+		return NewLockedOutError(time.Until(*loginAttempt.LockedUntil))
+	}
+	return nil
+}
+
+func (svc *lockoutService) RecordFailure(source string) {
+	var loginAttempt db.LoginAttempt
+	err := svc.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "source"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failed_attempts": gorm.Expr("login_attempts.failed_attempts + 1"),
+				"updated_at":      time.Now(),
+			}),
+		}).Create(&db.LoginAttempt{Source: source, FailedAttempts: 1}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.First(&loginAttempt, &db.LoginAttempt{Source: source}).Error
+		if err != nil {
+			return err
+		}
+		lockout := lockoutDuration(loginAttempt.FailedAttempts)
+		if lockout == 0 {
+			return nil
+		}
+		lockedUntil := time.Now().Add(lockout)
+		loginAttempt.LockedUntil = &lockedUntil
+		return tx.Model(&loginAttempt).Update("locked_until", lockedUntil).Error
+	})
+	if err != nil {
+		logger.Logger.WithField("source", source).WithError(err).Error("Failed to record failed login attempt")
+		return
+	}
+
+	logger.Logger.WithFields(logrus.Fields{
+		"source":          source,
+		"failed_attempts": loginAttempt.FailedAttempts,
+		"locked_until":    loginAttempt.LockedUntil,
+	}).Warn("Failed unlock attempt")
+
+	if loginAttempt.LockedUntil != nil {
+		svc.eventPublisher.Publish(&events.Event{
+			Event: "nwc_login_locked_out",
+			Properties: map[string]interface{}{
+				"source":          source,
+				"failed_attempts": loginAttempt.FailedAttempts,
+				"locked_until":    loginAttempt.LockedUntil,
+			},
+		})
+	}
+}
+
+func (svc *lockoutService) RecordSuccess(source string) {
+	err := svc.db.Where(&db.LoginAttempt{Source: source}).Delete(&db.LoginAttempt{}).Error
+	if err != nil {
+		logger.Logger.WithField("source", source).WithError(err).Error("Failed to reset login attempts")
+	}
+}
+
+func lockoutDuration(failedAttempts int) time.Duration {
+	if failedAttempts <= freeAttempts {
+		return 0
+	}
+	lockout := initialLockout
+	for i := freeAttempts + 1; i < failedAttempts && lockout < maxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, maxLockout)
+}
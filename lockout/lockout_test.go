@@ -0,0 +1,76 @@
+package lockout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/tests"
+)
+
+type mockConsumer struct {
+	events []*events.Event
+}
+
+func (consumer *mockConsumer) ConsumeEvent(ctx context.Context, event *events.Event, globalProperties map[string]interface{}) {
+	consumer.events = append(consumer.events, event)
+}
+
+func TestLockoutDuration(t *testing.T) {
+	assert.Equal(t, time.Duration(0), lockoutDuration(5))
+	assert.Equal(t, 30*time.Second, lockoutDuration(6))
+	assert.Equal(t, 60*time.Second, lockoutDuration(7))
+	assert.Equal(t, 120*time.Second, lockoutDuration(8))
+	assert.Equal(t, 24*time.Hour, lockoutDuration(100))
+}
+
+func TestRecordFailure_LocksOut(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	consumer := &mockConsumer{}
+	svc.EventPublisher.RegisterSubscriber(consumer)
+
+	lockoutSvc := NewLockoutService(svc.DB, svc.EventPublisher)
+	for i := 0; i < freeAttempts; i++ {
+		assert.NoError(t, lockoutSvc.Check("1.2.3.4"))
+		lockoutSvc.RecordFailure("1.2.3.4")
+	}
+	assert.NoError(t, lockoutSvc.Check("1.2.3.4"))
+	assert.Empty(t, consumer.events)
+
+	lockoutSvc.RecordFailure("1.2.3.4")
+	err = lockoutSvc.Check("1.2.3.4")
+	retryAfter, lockedOut := RetryAfter(err)
+	assert.True(t, lockedOut)
+	assert.InDelta(t, initialLockout.Seconds(), retryAfter.Seconds(), 1)
+	assert.Len(t, consumer.events, 1)
+	assert.Equal(t, "nwc_login_locked_out", consumer.events[0].Event)
+
+	// other sources are not affected
+	assert.NoError(t, lockoutSvc.Check("5.6.7.8"))
+
+	// the lockout is persisted
+	err = NewLockoutService(svc.DB, svc.EventPublisher).Check("1.2.3.4")
+	_, lockedOut = RetryAfter(err)
+	assert.True(t, lockedOut)
+}
+
+func TestRecordSuccess_Resets(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	lockoutSvc := NewLockoutService(svc.DB, svc.EventPublisher)
+	lockoutSvc.RecordFailure("1.2.3.4")
+	lockoutSvc.RecordFailure("1.2.3.4")
+	lockoutSvc.RecordSuccess("1.2.3.4")
+
+	var count int64
+	svc.DB.Model(&db.LoginAttempt{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,129 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// debug toggles log details that are too verbose for the debug log level, for live troubleshooting.
+// Like all logs they are redacted.
+const (
+	// the events the hub sends to and receives from the relay
+	DebugNostrFrames = "nostr_frames"
+	// the requests to the node of the backend and its responses
+	DebugBackendBodies = "backend_bodies"
+)
+
+var DebugToggles = []string{DebugNostrFrames, DebugBackendBodies}
+
+var Components = []string{ComponentHTTP, ComponentNostr, ComponentLNClient, ComponentDB}
+
+// bodies are cut off after this size
+const maxLoggedBodySize = 16 * 1024
+
+var debugTogglesMu sync.RWMutex
+var debugToggles = map[string]bool{}
+
+// SetDebugEnabled turns a debug toggle on or off until the hub is restarted
+func SetDebugEnabled(toggle string, enabled bool) error {
+	if !slices.Contains(DebugToggles, toggle) {
+		return fmt.Errorf("unknown debug toggle %q", toggle)
+	}
+	debugTogglesMu.Lock()
+	defer debugTogglesMu.Unlock()
+	debugToggles[toggle] = enabled
+	return nil
+}
+
+func IsDebugEnabled(toggle string) bool {
+	debugTogglesMu.RLock()
+	defer debugTogglesMu.RUnlock()
+	return debugToggles[toggle]
+}
+
+// GetDebugToggles returns the state of every debug toggle
+func GetDebugToggles() map[string]bool {
+	toggles := map[string]bool{}
+	for _, toggle := range DebugToggles {
+		toggles[toggle] = IsDebugEnabled(toggle)
+	}
+	return toggles
+}
+
+// GetLevel returns the log level of the hub
+func GetLevel() string {
+	return fmt.Sprint(int(Logger.GetLevel()))
+}
+
+// GetComponentLevels returns the log level of every component
+func GetComponentLevels() map[string]string {
+	componentLevels := map[string]string{}
+	for _, component := range Components {
+		componentLevels[component] = fmt.Sprint(int(componentLogger(component).GetLevel()))
+	}
+	return componentLevels
+}
+
+// LogBody logs a request or response body while the backend bodies debug toggle is enabled
+func LogBody(fields logrus.Fields, body []byte, message string) {
+	if !IsDebugEnabled(DebugBackendBodies) {
+		return
+	}
+	if len(body) > maxLoggedBodySize {
+		body = append(body[:maxLoggedBodySize:maxLoggedBodySize], "..."...)
+	}
+	LNClient.WithFields(fields).WithField("body", Redact(string(body))).Info(message)
+}
+
+// NewBodyLoggingTransport returns a transport for the HTTP clients of backends, which logs
+// the bodies of their requests and responses while the backend bodies debug toggle is enabled
+func NewBodyLoggingTransport(transport http.RoundTripper) http.RoundTripper {
+	return &bodyLoggingTransport{transport: transport}
+}
+
+type bodyLoggingTransport struct {
+	transport http.RoundTripper
+}
+
+func (transport *bodyLoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !IsDebugEnabled(DebugBackendBodies) {
+		return transport.transport.RoundTrip(req)
+	}
+
+	fields := logrus.Fields{
+		"method": req.Method,
+		"url":    req.URL.Redacted(),
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		reqBody, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+		LogBody(fields, reqBody, "Backend request")
+	}
+
+	start := time.Now()
+	res, err := transport.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+	fields["status"] = res.StatusCode
+	fields["latency_ms"] = time.Since(start).Milliseconds()
+	LogBody(fields, resBody, "Backend response")
+	return res, nil
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLoggingTransport(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	Init("4")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"amountSat":21}`, string(body))
+		w.Write([]byte(`{"paymentHash":"abc","preimage":"0f1e2d"}`))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: NewBodyLoggingTransport(http.DefaultTransport)}
+
+	_, err := client.Post(server.URL+"/createinvoice", "application/json", strings.NewReader(`{"amountSat":21}`))
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String())
+
+	assert.NoError(t, SetDebugEnabled(DebugBackendBodies, true))
+	defer SetDebugEnabled(DebugBackendBodies, false)
+	res, err := client.Post(server.URL+"/createinvoice", "application/json", strings.NewReader(`{"amountSat":21}`))
+	assert.NoError(t, err)
+	// the response can still be read
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"paymentHash":"abc","preimage":"0f1e2d"}`, string(body))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 2)
+	var request, response map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &request))
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &response))
+	assert.Equal(t, "Backend request", request["msg"])
+	assert.Equal(t, `{"amountSat":21}`, request["body"])
+	assert.Equal(t, "Backend response", response["msg"])
+	assert.Equal(t, float64(200), response["status"])
+	assert.NotContains(t, response["body"], "0f1e2d")
+	assert.Contains(t, response["body"], `"paymentHash":"abc"`)
+}
+
+func TestDebugToggles(t *testing.T) {
+	assert.EqualError(t, SetDebugEnabled("sql", true), `unknown debug toggle "sql"`)
+	assert.NoError(t, SetDebugEnabled(DebugNostrFrames, true))
+	defer SetDebugEnabled(DebugNostrFrames, false)
+	assert.Equal(t, map[string]bool{DebugNostrFrames: true, DebugBackendBodies: false}, GetDebugToggles())
+}
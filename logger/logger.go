@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -14,27 +15,131 @@ const (
 	logFilename = "nwc.log"
 )
 
+const (
+	ComponentHTTP     = "http"
+	ComponentNostr    = "nostr"
+	ComponentLNClient = "lnclient"
+	ComponentDB       = "db"
+)
+
 var Logger *logrus.Logger
+
+// component loggers have their own log level and add a component field to every log
+var (
+	HTTP     *logrus.Logger
+	Nostr    *logrus.Logger
+	LNClient *logrus.Logger
+	DB       *logrus.Logger
+)
+
 var logFilePath string
+var logOutput io.Writer = os.Stdout
 
 func Init(logLevel string) {
-	Logger = logrus.New()
-	Logger.SetFormatter(&logrus.JSONFormatter{})
-	Logger.SetOutput(os.Stdout)
+	Logger = newLogger()
+	HTTP = newComponentLogger(ComponentHTTP)
+	Nostr = newComponentLogger(ComponentNostr)
+	LNClient = newComponentLogger(ComponentLNClient)
+	DB = newComponentLogger(ComponentDB)
+	SetLevel(logLevel)
+}
+
+func newLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.SetOutput(logOutput)
+	logger.AddHook(&redactHook{})
+	return logger
+}
+
+func newComponentLogger(component string) *logrus.Logger {
+	logger := newLogger()
+	logger.AddHook(&componentHook{component: component})
+	return logger
+}
+
+func allLoggers() []*logrus.Logger {
+	return []*logrus.Logger{Logger, HTTP, Nostr, LNClient, DB}
+}
+
+func componentLogger(component string) *logrus.Logger {
+	switch component {
+	case ComponentHTTP:
+		return HTTP
+	case ComponentNostr:
+		return Nostr
+	case ComponentLNClient:
+		return LNClient
+	case ComponentDB:
+		return DB
+	}
+	return nil
+}
+
+// SetLevel sets the log level of the hub and all components
+func SetLevel(logLevel string) {
+	for _, logger := range allLoggers() {
+		logger.SetLevel(parseLevel(logLevel))
+	}
+}
+
+// SetComponentLevels overrides the log level of individual components. Empty levels are ignored.
+func SetComponentLevels(componentLevels map[string]string) {
+	for component, logLevel := range componentLevels {
+		logger := componentLogger(component)
+		if logger == nil || logLevel == "" {
+			continue
+		}
+		logger.SetLevel(parseLevel(logLevel))
+	}
+}
+
+func parseLevel(logLevel string) logrus.Level {
 	logrusLogLevel, err := strconv.Atoi(logLevel)
 	if err != nil {
 		logrusLogLevel = int(logrus.InfoLevel)
 	}
-	Logger.SetLevel(logrus.Level(logrusLogLevel))
+	return logrus.Level(logrusLogLevel)
+}
+
+// SetOutput changes where logs are written, e.g. to keep stdout free for command output
+func SetOutput(w io.Writer) {
+	logOutput = w
+	if Logger != nil {
+		for _, logger := range allLoggers() {
+			logger.SetOutput(w)
+		}
+	}
+}
+
+func addHook(hook logrus.Hook) {
+	for _, logger := range allLoggers() {
+		logger.AddHook(hook)
+	}
 }
 
-func AddFileLogger(workdir string) error {
-	logFilePath = filepath.Join(workdir, logDir, logFilename)
+// FileLoggerOptions configures the rotating log file written in addition to stdout
+type FileLoggerOptions struct {
+	// defaults to <workdir>/log/nwc.log
+	Path       string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+	Compress   bool
+}
+
+func AddFileLogger(workdir string, options FileLoggerOptions) error {
+	logFilePath = options.Path
+	if logFilePath == "" {
+		logFilePath = filepath.Join(workdir, logDir, logFilename)
+	}
 	fileLoggerHook, err := lumberjackrus.NewHook(
 		&lumberjackrus.LogFile{
 			Filename:   logFilePath,
-			MaxAge:     3,
-			MaxBackups: 3,
+			MaxSize:    options.MaxSizeMB,
+			MaxAge:     options.MaxAgeDays,
+			MaxBackups: options.MaxBackups,
+			Compress:   options.Compress,
 		},
 		logrus.InfoLevel,
 		&logrus.JSONFormatter{},
@@ -43,10 +148,23 @@ func AddFileLogger(workdir string) error {
 	if err != nil {
 		return err
 	}
-	Logger.AddHook(fileLoggerHook)
+	addHook(fileLoggerHook)
 	return nil
 }
 
 func GetLogFilePath() string {
 	return logFilePath
 }
+
+type componentHook struct {
+	component string
+}
+
+func (hook *componentHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook *componentHook) Fire(entry *logrus.Entry) error {
+	entry.Data["component"] = hook.component
+	return nil
+}
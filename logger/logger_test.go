@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComponentLevels(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+
+	Init("4")
+	SetComponentLevels(map[string]string{
+		ComponentDB:   "5",
+		ComponentHTTP: "",
+	})
+
+	assert.Equal(t, logrus.InfoLevel, Logger.GetLevel())
+	assert.Equal(t, logrus.InfoLevel, HTTP.GetLevel())
+	assert.Equal(t, logrus.DebugLevel, DB.GetLevel())
+
+	DB.Debug("query")
+	HTTP.Debug("not logged")
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "query", entry["msg"])
+	assert.Equal(t, ComponentDB, entry["component"])
+}
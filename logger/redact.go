@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+
+	"github.com/sirupsen/logrus"
+)
+
+const redacted = "[redacted]"
+
+// log fields containing any of these are always redacted
+var sensitiveFieldNames = []string{
+	"secret",
+	"password",
+	"preimage",
+	"macaroon",
+	"token",
+	"authorization",
+	"mnemonic",
+	"privkey",
+	"seed",
+	"cookie",
+	"k1",
+}
+
+var (
+	// lightning invoices, the prefix is kept to tell them apart in logs
+	bolt11Regex = regexp.MustCompile(`(?i)\b(ln(?:bc|tbs|tb|bcrt|sb))[0-9a-z]{20,}`)
+	// the secret of nostr+walletconnect:// connection strings
+	connectionSecretRegex = regexp.MustCompile(`(?i)([?&]secret=)[0-9a-f]+`)
+	bearerRegex           = regexp.MustCompile(`(?i)(bearer\s+)[a-z0-9\-._~+/]+=*`)
+	// single-use LNURL-withdraw links
+	lnurlWithdrawRegex = regexp.MustCompile(`(?i)(/lnurlw/)[0-9a-f]{16,}`)
+	// key-value pairs such as "preimage":"..." or refresh_token=...
+	sensitiveValueRegex = regexp.MustCompile(`(?i)("?[a-z_]*(?:secret|password|preimage|macaroon|token|mnemonic|privkey|seed|k1)[a-z_]*"?\s*[:=]\s*"?)([^"\s,&}]+)`)
+)
+
+// redactHook masks secrets in the message and fields of every log entry
+// before it is written or sent anywhere
+type redactHook struct{}
+
+func (hook *redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook *redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = Redact(entry.Message)
+	for key, value := range entry.Data {
+		if isSensitiveField(key) {
+			entry.Data[key] = redacted
+			continue
+		}
+		entry.Data[key] = redactValue(value)
+	}
+	return nil
+}
+
+// Redact masks invoices, connection secrets, tokens and preimages in a string
+func Redact(s string) string {
+	s = bolt11Regex.ReplaceAllString(s, "${1}"+redacted)
+	s = connectionSecretRegex.ReplaceAllString(s, "${1}"+redacted)
+	s = bearerRegex.ReplaceAllString(s, "${1}"+redacted)
+	s = lnurlWithdrawRegex.ReplaceAllString(s, "${1}"+redacted)
+	s = sensitiveValueRegex.ReplaceAllString(s, "${1}"+redacted)
+	return s
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return Redact(v)
+	case error:
+		redactedError := Redact(v.Error())
+		if redactedError == v.Error() {
+			return v
+		}
+		return redactedError
+	}
+
+	switch reflect.Indirect(reflect.ValueOf(value)).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		// structs such as NIP-47 requests and responses can contain secrets in any field
+		marshalled, err := json.Marshal(value)
+		if err != nil {
+			return value
+		}
+		redactedJson := Redact(string(marshalled))
+		if redactedJson == string(marshalled) {
+			return value
+		}
+		return json.RawMessage(redactedJson)
+	}
+	return value
+}
@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testInvoice = "lnbc10n1pjdy76zpp5j8p6ar0sd4ewxgw3xq7mr0ls5xqfsxufuqxwqvnmxj0uqsffxfjqdqqcqzzsxqyz5vqsp5tvf8ush0w0k2skmrjpnhz3rlyz0tmkfrxgd9gvx9dn4z7pwq27hs9qyyssqawsk3xlrrha3ugcgmd5vfckh6mr8lq3xqsnxs2ydfwxkj5j7dfcqfqvtv0kxe5dm8dt6gns23wlhpttll6fe7rwpqnvl2yv0yht8adqppxvele"
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, "paying lnbc[redacted]", Redact("paying "+testInvoice))
+	assert.Equal(t,
+		"nostr+walletconnect://abc?relay=wss://relay.example.com&secret=[redacted]",
+		Redact("nostr+walletconnect://abc?relay=wss://relay.example.com&secret=0123456789abcdef"))
+	assert.Equal(t, "Authorization: Bearer [redacted]", Redact("Authorization: Bearer eyJhbGciOi.x-y_z"))
+	assert.Equal(t, `{"access_token":"[redacted]","refresh_token":"[redacted]","expires_in":3600}`,
+		Redact(`{"access_token":"abc","refresh_token":"def","expires_in":3600}`))
+	assert.Equal(t, "payment settled preimage=[redacted]", Redact("payment settled preimage=0102"))
+	assert.Equal(t, "/api/lnurlw/[redacted]", Redact("/api/lnurlw/00112233445566778899aabbccddeeff"))
+	// payment hashes are kept for debugging
+	paymentHash := "paymentHash=91c3ae8df06d72e321d1303db1bff0a18098371e3c0ce0327b349fc041293264"
+	assert.Equal(t, paymentHash, Redact(paymentHash))
+}
+
+func TestRedactHook(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stdout)
+	Init("4")
+
+	type payResponse struct {
+		Preimage string `json:"preimage"`
+		Fee      uint64 `json:"fee"`
+	}
+
+	LNClient.WithFields(logrus.Fields{
+		"bolt11":   testInvoice,
+		"preimage": "0102",
+		"response": &payResponse{Preimage: "0304", Fee: 5},
+		"amount":   1000,
+	}).WithError(errors.New("failed to pay "+testInvoice)).Infof("Paying invoice %s", testInvoice)
+
+	output := buf.String()
+	assert.NotContains(t, output, testInvoice[10:])
+	assert.NotContains(t, output, "0102")
+	assert.NotContains(t, output, "0304")
+
+	var entry map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "Paying invoice lnbc[redacted]", entry["msg"])
+	assert.Equal(t, "lnbc[redacted]", entry["bolt11"])
+	assert.Equal(t, "[redacted]", entry["preimage"])
+	assert.Equal(t, map[string]interface{}{"preimage": "[redacted]", "fee": float64(5)}, entry["response"])
+	assert.Equal(t, "failed to pay lnbc[redacted]", entry["error"])
+	assert.Equal(t, float64(1000), entry["amount"])
+}
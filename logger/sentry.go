@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/sirupsen/logrus"
+)
+
+const sentryFlushTimeout = 2 * time.Second
+
+// InitSentry reports error logs and panics to a Sentry-compatible DSN
+func InitSentry(dsn string, release string) error {
+	err := sentry.Init(sentry.ClientOptions{
+		Dsn:     dsn,
+		Release: release,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to init sentry: %w", err)
+	}
+	addHook(&sentryHook{})
+	return nil
+}
+
+func FlushSentry() {
+	sentry.Flush(sentryFlushTimeout)
+}
+
+// CapturePanic reports a panic with the given context and then re-panics.
+// It must be deferred directly, e.g. defer logger.CapturePanic(fields)
+func CapturePanic(fields logrus.Fields) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	Logger.WithFields(fields).WithField("stack", string(debug.Stack())).Errorf("Panic: %v", recovered)
+	FlushSentry()
+	panic(recovered)
+}
+
+type sentryHook struct{}
+
+func (hook *sentryHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+func (hook *sentryHook) Fire(entry *logrus.Entry) error {
+	event := sentry.NewEvent()
+	event.Level = sentryLevel(entry.Level)
+	event.Message = entry.Message
+	event.Timestamp = entry.Time
+
+	for key, value := range scrubFields(entry.Data) {
+		if err, ok := value.(error); ok && key == logrus.ErrorKey {
+			event.Exception = []sentry.Exception{{
+				Type:  entry.Message,
+				Value: err.Error(),
+			}}
+			continue
+		}
+		event.Extra[key] = value
+	}
+
+	sentry.CaptureEvent(event)
+
+	// the process exits after fatal and panic logs
+	if entry.Level <= logrus.FatalLevel {
+		FlushSentry()
+	}
+	return nil
+}
+
+func scrubFields(fields logrus.Fields) logrus.Fields {
+	scrubbed := logrus.Fields{}
+	for key, value := range fields {
+		if isSensitiveField(key) {
+			scrubbed[key] = "[scrubbed]"
+			continue
+		}
+		// errors are not serializable
+		if err, ok := value.(error); ok && key != logrus.ErrorKey {
+			value = err.Error()
+		}
+		scrubbed[key] = value
+	}
+	return scrubbed
+}
+
+func isSensitiveField(key string) bool {
+	lowerKey := strings.ToLower(key)
+	for _, name := range sensitiveFieldNames {
+		if strings.Contains(lowerKey, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func sentryLevel(level logrus.Level) sentry.Level {
+	switch level {
+	case logrus.PanicLevel, logrus.FatalLevel:
+		return sentry.LevelFatal
+	default:
+		return sentry.LevelError
+	}
+}
@@ -0,0 +1,29 @@
+package logger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScrubFields(t *testing.T) {
+	fields := scrubFields(logrus.Fields{
+		"appId":          1,
+		"pairingSecret":  "abc",
+		"unlockPassword": "hunter2",
+		"preimage":       "123preimage",
+		"Authorization":  "Bearer xyz",
+		"reason":         errors.New("some error"),
+		logrus.ErrorKey:  errors.New("the error"),
+	})
+
+	assert.Equal(t, 1, fields["appId"])
+	assert.Equal(t, "[scrubbed]", fields["pairingSecret"])
+	assert.Equal(t, "[scrubbed]", fields["unlockPassword"])
+	assert.Equal(t, "[scrubbed]", fields["preimage"])
+	assert.Equal(t, "[scrubbed]", fields["Authorization"])
+	assert.Equal(t, "some error", fields["reason"])
+	assert.EqualError(t, fields[logrus.ErrorKey].(error), "the error")
+}
@@ -10,7 +10,6 @@ import (
 	"github.com/getAlby/hub/logger"
 	"github.com/getAlby/hub/service"
 	"github.com/getAlby/hub/wails"
-	log "github.com/sirupsen/logrus"
 )
 
 //go:embed all:frontend/dist
@@ -20,9 +19,9 @@ var assets embed.FS
 var appIcon []byte
 
 func main() {
-	log.Info("NWC Starting in WAILS mode")
 	ctx, cancel := context.WithCancel(context.Background())
 	svc, _ := service.NewService(ctx)
+	logger.Logger.Info("NWC Starting in WAILS mode")
 
 	app := wails.NewApp(svc)
 	wails.LaunchWailsApp(app, assets, appIcon)
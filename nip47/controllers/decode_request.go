@@ -8,10 +8,11 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// decodeRequest decodes the params of the request and validates them, see validatedParams
 func decodeRequest(request *models.Request, methodParams interface{}) *models.Response {
 	err := json.Unmarshal(request.Params, methodParams)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"request": request,
 		}).WithError(err).Error("Failed to decode NIP-47 request")
 		return &models.Response{
@@ -21,5 +22,19 @@ func decodeRequest(request *models.Request, methodParams interface{}) *models.Re
 				Message: err.Error(),
 			}}
 	}
+	if params, ok := methodParams.(validatedParams); ok {
+		err = params.validate()
+		if err != nil {
+			logger.Nostr.WithFields(logrus.Fields{
+				"method": request.Method,
+			}).WithError(err).Info("Rejected invalid NIP-47 request params")
+			return &models.Response{
+				ResultType: request.Method,
+				Error: &models.Error{
+					Code:    models.ERROR_BAD_REQUEST,
+					Message: err.Error(),
+				}}
+		}
+	}
 	return nil
 }
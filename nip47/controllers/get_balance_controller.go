@@ -24,7 +24,7 @@ type getBalanceResponse struct {
 // TODO: remove checkPermission - can it be a middleware?
 func (controller *nip47Controller) HandleGetBalanceEvent(ctx context.Context, nip47Request *models.Request, requestEventId uint, app *db.App, publishResponse publishFunc) {
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"request_event_id": requestEventId,
 	}).Info("Getting balance")
 
@@ -35,7 +35,7 @@ func (controller *nip47Controller) HandleGetBalanceEvent(ctx context.Context, ni
 		balance_signed, err := controller.lnClient.GetBalance(ctx)
 		balance = uint64(balance_signed)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"request_event_id": requestEventId,
 			}).WithError(err).Error("Failed to fetch balance")
 			publishResponse(&models.Response{
@@ -46,8 +46,8 @@ func TestHandleGetBalanceEvent(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandleGetBalanceEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse)
 
 	assert.Equal(t, uint64(21000), publishedResponse.Result.(*getBalanceResponse).Balance)
@@ -80,8 +80,8 @@ func TestHandleGetBalanceEvent_IsolatedApp_NoTransactions(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandleGetBalanceEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse)
 
 	assert.Equal(t, uint64(0), publishedResponse.Result.(*getBalanceResponse).Balance)
@@ -127,8 +127,8 @@ func TestHandleGetBalanceEvent_IsolatedApp_Transactions(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandleGetBalanceEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse)
 
 	assert.Equal(t, uint64(1000), publishedResponse.Result.(*getBalanceResponse).Balance)
@@ -36,13 +36,13 @@ func (controller *nip47Controller) HandleGetInfoEvent(ctx context.Context, nip47
 	// basic permissions check
 	hasPermission, _, _ := controller.permissionsService.HasPermission(app, constants.GET_INFO_SCOPE)
 	if hasPermission {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"request_event_id": requestEventId,
 		}).Info("Getting info")
 
 		info, err := controller.lnClient.GetInfo(ctx)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"request_event_id": requestEventId,
 			}).Infof("Failed to fetch node info: %v", err)
 
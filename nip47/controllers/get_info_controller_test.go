@@ -54,8 +54,8 @@ func TestHandleGetInfoEvent_NoPermission(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandleGetInfoEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse)
 
 	assert.Nil(t, publishedResponse.Error)
@@ -102,8 +102,8 @@ func TestHandleGetInfoEvent_WithPermission(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandleGetInfoEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse)
 
 	assert.Nil(t, publishedResponse.Error)
@@ -158,8 +158,8 @@ func TestHandleGetInfoEvent_WithNotifications(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandleGetInfoEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse)
 
 	assert.Nil(t, publishedResponse.Error)
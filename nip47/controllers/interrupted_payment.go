@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/transactions"
+)
+
+// InterruptedPaymentResponse answers a pay_invoice or pay_keysend request, or an element of a
+// multi_pay request, whose handling was interrupted by a restart of the hub, from the payment it
+// made. The transaction is nil if the hub stopped before the payment was created. Returns nil
+// while the payment is still pending.
+func InterruptedPaymentResponse(method string, transaction *transactions.Transaction) *models.Response {
+	if transaction == nil {
+		return &models.Response{
+			ResultType: method,
+			Error: &models.Error{
+				Code:    models.ERROR_INTERNAL,
+				Message: "The hub was restarted before the payment was made",
+			},
+		}
+	}
+	switch transaction.State {
+	case constants.TRANSACTION_STATE_SETTLED:
+		preimage := ""
+		if transaction.Preimage != nil {
+			preimage = *transaction.Preimage
+		}
+		return &models.Response{
+			ResultType: method,
+			Result: payResponse{
+				Preimage: preimage,
+				FeesPaid: transaction.FeeMsat,
+			},
+		}
+	case constants.TRANSACTION_STATE_FAILED:
+		return &models.Response{
+			ResultType: method,
+			Error: &models.Error{
+				Code:    models.ERROR_INTERNAL,
+				Message: "The payment failed",
+			},
+		}
+	}
+	return nil
+}
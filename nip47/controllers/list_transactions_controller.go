@@ -2,9 +2,11 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/getAlby/hub/logger"
 	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/transactions"
 	"github.com/nbd-wtf/go-nostr"
 	"github.com/sirupsen/logrus"
 )
@@ -16,12 +18,21 @@ type listTransactionsParams struct {
 	Offset uint64 `json:"offset,omitempty"`
 	Unpaid bool   `json:"unpaid,omitempty"`
 	Type   string `json:"type,omitempty"`
+	// returned as next_cursor by the previous page, replaces the offset
+	Cursor string `json:"cursor,omitempty"`
 }
 
 type listTransactionsResponse struct {
 	Transactions []models.Transaction `json:"transactions"`
+	// only set if there are more transactions
+	NextCursor string `json:"next_cursor,omitempty"`
+	// the number of transactions matching the filters, only returned for the first page
+	TotalCount *int64 `json:"total_count,omitempty"`
 }
 
+// large responses are rejected by relays after encryption
+const maxListTransactionsPayloadSize = 32 * 1024
+
 func (controller *nip47Controller) HandleListTransactionsEvent(ctx context.Context, nip47Request *models.Request, requestEventId uint, appId uint, publishResponse publishFunc) {
 
 	listParams := &listTransactionsParams{}
@@ -31,7 +42,7 @@ func (controller *nip47Controller) HandleListTransactionsEvent(ctx context.Conte
 		return
 	}
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"params":           listParams,
 		"request_event_id": requestEventId,
 	}).Info("Fetching transactions")
@@ -47,9 +58,45 @@ func (controller *nip47Controller) HandleListTransactionsEvent(ctx context.Conte
 		transactionType = &listParams.Type
 	}
 
-	dbTransactions, err := controller.transactionsService.ListTransactions(ctx, listParams.From, listParams.Until, limit, listParams.Offset, listParams.Unpaid, transactionType, controller.lnClient, &appId)
+	var cursor *transactions.TransactionsCursor
+	if listParams.Cursor != "" {
+		var err error
+		cursor, err = transactions.DecodeTransactionsCursor(listParams.Cursor)
+		if err != nil {
+			publishResponse(&models.Response{
+				ResultType: nip47Request.Method,
+				Error: &models.Error{
+					Code:    models.ERROR_BAD_REQUEST,
+					Message: err.Error(),
+				},
+			}, nostr.Tags{})
+			return
+		}
+	}
+
+	nip47Transactions := []models.Transaction{}
+	payloadSize := 0
+	var nextCursor string
+	var lastTransaction *transactions.Transaction
+	// one extra transaction is fetched to know if there is a next page
+	err := controller.transactionsService.StreamTransactions(ctx, listParams.From, listParams.Until, limit+1, listParams.Offset, cursor, listParams.Unpaid, transactionType, controller.lnClient, &appId, func(dbTransaction *transactions.Transaction) bool {
+		nip47Transaction := models.ToNip47Transaction(dbTransaction)
+		encodedTransaction, err := json.Marshal(nip47Transaction)
+		if err != nil {
+			logger.Nostr.WithField("request_event_id", requestEventId).WithError(err).Error("Failed to encode transaction")
+			return false
+		}
+		if uint64(len(nip47Transactions)) == limit || (len(nip47Transactions) > 0 && payloadSize+len(encodedTransaction) > maxListTransactionsPayloadSize) {
+			nextCursor = transactions.NewTransactionsCursor(lastTransaction).Encode()
+			return false
+		}
+		nip47Transactions = append(nip47Transactions, *nip47Transaction)
+		payloadSize += len(encodedTransaction)
+		lastTransaction = dbTransaction
+		return true
+	})
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"params":           listParams,
 			"request_event_id": requestEventId,
 		}).WithError(err).Error("Failed to fetch transactions")
@@ -64,13 +111,18 @@ func (controller *nip47Controller) HandleListTransactionsEvent(ctx context.Conte
 		return
 	}
 
-	transactions := []models.Transaction{}
-	for _, dbTransaction := range dbTransactions {
-		transactions = append(transactions, *models.ToNip47Transaction(&dbTransaction))
+	var totalCount *int64
+	if cursor == nil {
+		count, err := controller.transactionsService.CountTransactions(listParams.From, listParams.Until, listParams.Unpaid, transactionType, &appId)
+		if err == nil {
+			totalCount = &count
+		}
 	}
 
 	responsePayload := &listTransactionsResponse{
-		Transactions: transactions,
+		Transactions: nip47Transactions,
+		NextCursor:   nextCursor,
+		TotalCount:   totalCount,
 	}
 
 	publishResponse(&models.Response{
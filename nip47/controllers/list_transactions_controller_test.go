@@ -3,6 +3,8 @@ package controllers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -75,8 +77,8 @@ func TestHandleListTransactionsEvent(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandleListTransactionsEvent(ctx, nip47Request, dbRequestEvent.ID, *dbRequestEvent.AppId, publishResponse)
 
 	assert.Nil(t, publishedResponse.Error)
@@ -94,4 +96,119 @@ func TestHandleListTransactionsEvent(t *testing.T) {
 	assert.Equal(t, tests.MockLNClientTransactions[0].SettledAt, transaction.SettledAt)
 }
 
-// TODO: add tests for pagination args
+func listTransactionsPage(t *testing.T, svc *tests.TestService, appId uint, params map[string]interface{}) *models.Response {
+	paramsBytes, err := json.Marshal(params)
+	assert.NoError(t, err)
+	nip47Request := &models.Request{
+		Method: models.LIST_TRANSACTIONS_METHOD,
+		Params: paramsBytes,
+	}
+
+	dbRequestEvent := &db.RequestEvent{
+		AppId:   &appId,
+		NostrId: nostr.GeneratePrivateKey(),
+	}
+	err = svc.DB.Create(&dbRequestEvent).Error
+	assert.NoError(t, err)
+
+	var publishedResponse *models.Response
+	publishResponse := func(response *models.Response, tags nostr.Tags) {
+		publishedResponse = response
+	}
+
+	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
+		HandleListTransactionsEvent(context.TODO(), nip47Request, dbRequestEvent.ID, appId, publishResponse)
+	return publishedResponse
+}
+
+func createSettledTransactions(t *testing.T, svc *tests.TestService, appId uint, count int, description string) {
+	createdAt := time.Now()
+	for i := 0; i < count; i++ {
+		// some transactions share the same creation time, those are ordered by id
+		if i%2 == 0 {
+			createdAt = createdAt.Add(-time.Minute)
+		}
+		err := svc.DB.Create(&db.Transaction{
+			Type:        constants.TRANSACTION_TYPE_INCOMING,
+			State:       constants.TRANSACTION_STATE_SETTLED,
+			PaymentHash: fmt.Sprintf("hash%d", i),
+			Description: description,
+			AmountMsat:  1000,
+			AppId:       &appId,
+			CreatedAt:   createdAt,
+		}).Error
+		assert.NoError(t, err)
+	}
+}
+
+func TestHandleListTransactionsEvent_Cursor(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	createSettledTransactions(t, svc, app.ID, 7, "")
+
+	paymentHashes := []string{}
+	params := map[string]interface{}{
+		"limit": 3,
+	}
+	for page := 0; ; page++ {
+		response := listTransactionsPage(t, svc, app.ID, params)
+		assert.Nil(t, response.Error)
+		result := response.Result.(*listTransactionsResponse)
+		if page == 0 {
+			assert.Equal(t, int64(7), *result.TotalCount)
+		} else {
+			assert.Nil(t, result.TotalCount)
+		}
+		for _, transaction := range result.Transactions {
+			paymentHashes = append(paymentHashes, transaction.PaymentHash)
+		}
+		if result.NextCursor == "" {
+			assert.Equal(t, 1, len(result.Transactions))
+			break
+		}
+		assert.Equal(t, 3, len(result.Transactions))
+		params["cursor"] = result.NextCursor
+	}
+
+	assert.Equal(t, []string{"hash1", "hash0", "hash3", "hash2", "hash5", "hash4", "hash6"}, paymentHashes)
+}
+
+func TestHandleListTransactionsEvent_InvalidCursor(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	response := listTransactionsPage(t, svc, app.ID, map[string]interface{}{
+		"cursor": "invalid",
+	})
+	assert.Nil(t, response.Result)
+	assert.Equal(t, models.ERROR_BAD_REQUEST, response.Error.Code)
+}
+
+func TestHandleListTransactionsEvent_PayloadSizeCapped(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	createSettledTransactions(t, svc, app.ID, 10, strings.Repeat("a", maxListTransactionsPayloadSize/4))
+
+	response := listTransactionsPage(t, svc, app.ID, map[string]interface{}{
+		"limit": 10,
+	})
+	assert.Nil(t, response.Error)
+	result := response.Result.(*listTransactionsResponse)
+	assert.Equal(t, 3, len(result.Transactions))
+	assert.NotEmpty(t, result.NextCursor)
+	assert.Equal(t, int64(10), *result.TotalCount)
+}
@@ -30,7 +30,7 @@ func (controller *nip47Controller) HandleLookupInvoiceEvent(ctx context.Context,
 		return
 	}
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"invoice":          lookupInvoiceParams.Invoice,
 		"payment_hash":     lookupInvoiceParams.PaymentHash,
 		"request_event_id": requestEventId,
@@ -41,7 +41,7 @@ func (controller *nip47Controller) HandleLookupInvoiceEvent(ctx context.Context,
 	if paymentHash == "" {
 		paymentRequest, err := decodepay.Decodepay(strings.ToLower(lookupInvoiceParams.Invoice))
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"request_event_id": requestEventId,
 				"invoice":          lookupInvoiceParams.Invoice,
 			}).WithError(err).Error("Failed to decode bolt11 invoice")
@@ -60,7 +60,7 @@ func (controller *nip47Controller) HandleLookupInvoiceEvent(ctx context.Context,
 
 	dbTransaction, err := controller.transactionsService.LookupTransaction(ctx, paymentHash, nil, controller.lnClient, &appId)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"request_event_id": requestEventId,
 			"invoice":          lookupInvoiceParams.Invoice,
 			"payment_hash":     paymentHash,
@@ -66,8 +66,8 @@ func TestHandleLookupInvoiceEvent(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandleLookupInvoiceEvent(ctx, nip47Request, dbRequestEvent.ID, *dbRequestEvent.AppId, publishResponse)
 
 	assert.Nil(t, publishedResponse.Error)
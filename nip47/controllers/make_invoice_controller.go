@@ -29,7 +29,7 @@ func (controller *nip47Controller) HandleMakeInvoiceEvent(ctx context.Context, n
 		return
 	}
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"request_event_id": requestEventId,
 		"amount":           makeInvoiceParams.Amount,
 		"description":      makeInvoiceParams.Description,
@@ -42,7 +42,7 @@ func (controller *nip47Controller) HandleMakeInvoiceEvent(ctx context.Context, n
 
 	transaction, err := controller.transactionsService.MakeInvoice(ctx, makeInvoiceParams.Amount, makeInvoiceParams.Description, makeInvoiceParams.DescriptionHash, expiry, makeInvoiceParams.Metadata, controller.lnClient, &appId, &requestEventId)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"request_event_id": requestEventId,
 			"amount":           makeInvoiceParams.Amount,
 			"description":      makeInvoiceParams.Description,
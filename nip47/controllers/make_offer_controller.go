@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+)
+
+type makeOfferParams struct {
+	Description string `json:"description"`
+}
+type makeOfferResponse struct {
+	Offer       string `json:"offer"`
+	OfferId     string `json:"offer_id"`
+	Description string `json:"description"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+func (controller *nip47Controller) HandleMakeOfferEvent(ctx context.Context, nip47Request *models.Request, requestEventId uint, appId uint, publishResponse publishFunc) {
+
+	makeOfferParams := &makeOfferParams{}
+	resp := decodeRequest(nip47Request, makeOfferParams)
+	if resp != nil {
+		publishResponse(resp, nostr.Tags{})
+		return
+	}
+
+	logger.Nostr.WithFields(logrus.Fields{
+		"request_event_id": requestEventId,
+		"description":      makeOfferParams.Description,
+	}).Info("Making offer")
+
+	offer, err := controller.transactionsService.MakeOffer(ctx, makeOfferParams.Description, controller.lnClient, &appId)
+	if err != nil {
+		logger.Nostr.WithFields(logrus.Fields{
+			"request_event_id": requestEventId,
+			"description":      makeOfferParams.Description,
+		}).Infof("Failed to make offer: %v", err)
+
+		publishResponse(&models.Response{
+			ResultType: nip47Request.Method,
+			Error: &models.Error{
+				Code:    models.ERROR_INTERNAL,
+				Message: err.Error(),
+			},
+		}, nostr.Tags{})
+		return
+	}
+
+	publishResponse(&models.Response{
+		ResultType: nip47Request.Method,
+		Result: &makeOfferResponse{
+			Offer:       offer.Offer,
+			OfferId:     offer.Bolt12OfferId,
+			Description: offer.Description,
+			CreatedAt:   offer.CreatedAt.Unix(),
+		},
+	}, nostr.Tags{})
+}
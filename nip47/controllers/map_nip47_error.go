@@ -18,6 +18,12 @@ func mapNip47Error(err error) *models.Error {
 	if errors.Is(err, transactions.NewQuotaExceededError()) {
 		code = models.ERROR_QUOTA_EXCEEDED
 	}
+	if errors.Is(err, transactions.NewDuplicatePaymentError()) {
+		code = models.ERROR_RESTRICTED
+	}
+	if errors.Is(err, transactions.NewPaymentNotConfirmedError()) {
+		code = models.ERROR_RESTRICTED
+	}
 
 	return &models.Error{
 		Code:    code,
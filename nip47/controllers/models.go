@@ -10,4 +10,5 @@ type publishFunc = func(*models.Response, nostr.Tags)
 type payResponse struct {
 	Preimage string `json:"preimage"`
 	FeesPaid uint64 `json:"fees_paid"`
+	Invoice  string `json:"invoice,omitempty"`
 }
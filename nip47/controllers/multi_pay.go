@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// the number of payments of a single multi_pay request that are sent at the same time
+const maxConcurrentMultiPayments = 10
+
+// multiPayment is an element of a multi_pay request that passed validation
+type multiPayment struct {
+	tags        nostr.Tags
+	paymentHash string
+	amountMsat  uint64
+	// send reserves the budget, sends the payment, records the result and publishes the response
+	send func(ctx context.Context, tags nostr.Tags)
+}
+
+// MultiPayElement is a payment of a multi_pay request, stored before the payments are sent so
+// that the payment can be found by its hash if the hub stops before it is answered
+type MultiPayElement struct {
+	DTag        string
+	PaymentHash string
+}
+
+// saveBatchFunc stores the payments of a multi_pay request
+type saveBatchFunc = func(elements []MultiPayElement) error
+
+// validateMultiPaySize rejects requests with more payments than NIP47_MULTI_PAY_MAX_PAYMENTS
+// before any element is handled. The error is a single response without a d tag.
+func (controller *nip47Controller) validateMultiPaySize(nip47Request *models.Request, count int) *models.Response {
+	maxPayments := controller.cfg.GetEnv().Nip47MultiPayMaxPayments
+	if maxPayments == 0 || count <= maxPayments {
+		return nil
+	}
+	return &models.Response{
+		ResultType: nip47Request.Method,
+		Error: &models.Error{
+			Code:    models.ERROR_BAD_REQUEST,
+			Message: fmt.Sprintf("A %s request can have at most %d payments, got %d", nip47Request.Method, maxPayments, count),
+		},
+	}
+}
+
+// sendMultiPayments sends the validated elements of a multi_pay request through a bounded pool of workers.
+// Budgets are reserved per payment in a database transaction by the transactions service,
+// so an element that exceeds the remaining budget fails without affecting the others.
+// Every element gets exactly one response, elements that were not sent yet when the context is cancelled get an error.
+// No payment is sent unless the batch was saved, saveBatch may be nil if the batch does not need to be stored.
+func (controller *nip47Controller) sendMultiPayments(ctx context.Context, nip47Request *models.Request, payments []multiPayment, publishResponse publishFunc, saveBatch saveBatchFunc) {
+	// none of the payments is made if together they exceed NIP47_MULTI_PAY_MAX_TOTAL_SAT
+	maxTotalSat := controller.cfg.GetEnv().Nip47MultiPayMaxTotalSat
+	if maxTotalSat > 0 {
+		remainingMsat := uint64(maxTotalSat) * 1000
+		exceeded := false
+		for _, payment := range payments {
+			if payment.amountMsat > remainingMsat {
+				exceeded = true
+				break
+			}
+			remainingMsat -= payment.amountMsat
+		}
+		if exceeded {
+			for _, payment := range payments {
+				publishResponse(&models.Response{
+					ResultType: nip47Request.Method,
+					Error: &models.Error{
+						Code:    models.ERROR_BAD_REQUEST,
+						Message: fmt.Sprintf("The payments of a %s request can be at most %d sats in total", nip47Request.Method, maxTotalSat),
+					},
+				}, payment.tags)
+			}
+			return
+		}
+	}
+
+	if saveBatch != nil && len(payments) > 0 {
+		elements := make([]MultiPayElement, 0, len(payments))
+		for _, payment := range payments {
+			elements = append(elements, MultiPayElement{
+				DTag:        payment.tags.GetFirst([]string{"d"}).Value(),
+				PaymentHash: payment.paymentHash,
+			})
+		}
+		err := saveBatch(elements)
+		if err != nil {
+			logger.Nostr.WithError(err).Error("Failed to save multi_pay batch")
+			for _, payment := range payments {
+				publishResponse(&models.Response{
+					ResultType: nip47Request.Method,
+					Error: &models.Error{
+						Code:    models.ERROR_INTERNAL,
+						Message: fmt.Sprintf("Failed to save request: %s", err.Error()),
+					},
+				}, payment.tags)
+			}
+			return
+		}
+	}
+
+	queue := make(chan multiPayment, len(payments))
+	for _, payment := range payments {
+		queue <- payment
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(len(payments), maxConcurrentMultiPayments); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for payment := range queue {
+				if ctx.Err() != nil {
+					publishResponse(&models.Response{
+						ResultType: nip47Request.Method,
+						Error: &models.Error{
+							Code:    models.ERROR_INTERNAL,
+							Message: "The request was cancelled before the payment was sent",
+						},
+					}, payment.tags)
+					continue
+				}
+				payment.send(ctx, payment.tags)
+			}
+		}()
+	}
+	wg.Wait()
+}
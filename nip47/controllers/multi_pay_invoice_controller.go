@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"sync"
 
 	"github.com/getAlby/hub/db"
 	"github.com/getAlby/hub/logger"
@@ -23,52 +22,71 @@ type multiPayInvoiceParams struct {
 	Invoices []multiPayInvoiceElement `json:"invoices"`
 }
 
-func (controller *nip47Controller) HandleMultiPayInvoiceEvent(ctx context.Context, nip47Request *models.Request, requestEventId uint, app *db.App, publishResponse publishFunc) {
+func (controller *nip47Controller) HandleMultiPayInvoiceEvent(ctx context.Context, nip47Request *models.Request, requestEventId uint, app *db.App, publishResponse publishFunc, saveBatch saveBatchFunc) {
 	multiPayParams := &multiPayInvoiceParams{}
 	resp := decodeRequest(nip47Request, multiPayParams)
 	if resp != nil {
 		publishResponse(resp, nostr.Tags{})
 		return
 	}
+	resp = controller.validateMultiPaySize(nip47Request, len(multiPayParams.Invoices))
+	if resp != nil {
+		publishResponse(resp, nostr.Tags{})
+		return
+	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(multiPayParams.Invoices))
+	payments := make([]multiPayment, 0, len(multiPayParams.Invoices))
 	for _, invoiceInfo := range multiPayParams.Invoices {
-		go func(invoiceInfo multiPayInvoiceElement) {
-			defer wg.Done()
-			bolt11 := invoiceInfo.Invoice
-			// Convert invoice to lowercase string
-			bolt11 = strings.ToLower(bolt11)
-			paymentRequest, err := decodepay.Decodepay(bolt11)
-			if err != nil {
-				logger.Logger.WithFields(logrus.Fields{
-					"request_event_id": requestEventId,
-					"appId":            app.ID,
-					"bolt11":           bolt11,
-				}).Errorf("Failed to decode bolt11 invoice: %v", err)
+		if err := invoiceInfo.validate(); err != nil {
+			publishResponse(&models.Response{
+				ResultType: nip47Request.Method,
+				Error: &models.Error{
+					Code:    models.ERROR_BAD_REQUEST,
+					Message: err.Error(),
+				},
+			}, nostr.Tags{[]string{"d", invoiceInfo.Id}})
+			continue
+		}
+
+		bolt11 := invoiceInfo.Invoice
+		// Convert invoice to lowercase string
+		bolt11 = strings.ToLower(bolt11)
+		paymentRequest, err := decodepay.Decodepay(bolt11)
+		if err != nil {
+			logger.Nostr.WithFields(logrus.Fields{
+				"request_event_id": requestEventId,
+				"appId":            app.ID,
+				"bolt11":           bolt11,
+			}).Errorf("Failed to decode bolt11 invoice: %v", err)
 
-				// TODO: Decide what to do if id is empty
-				dTag := []string{"d", invoiceInfo.Id}
-				publishResponse(&models.Response{
-					ResultType: nip47Request.Method,
-					Error: &models.Error{
-						Code:    models.ERROR_INTERNAL,
-						Message: fmt.Sprintf("Failed to decode bolt11 invoice: %s", err.Error()),
-					},
-				}, nostr.Tags{dTag})
-				return
-			}
+			// TODO: Decide what to do if id is empty
+			dTag := []string{"d", invoiceInfo.Id}
+			publishResponse(&models.Response{
+				ResultType: nip47Request.Method,
+				Error: &models.Error{
+					Code:    models.ERROR_INTERNAL,
+					Message: fmt.Sprintf("Failed to decode bolt11 invoice: %s", err.Error()),
+				},
+			}, nostr.Tags{dTag})
+			continue
+		}
 
-			invoiceDTagValue := invoiceInfo.Id
-			if invoiceDTagValue == "" {
-				invoiceDTagValue = paymentRequest.PaymentHash
-			}
-			dTag := []string{"d", invoiceDTagValue}
+		invoiceDTagValue := invoiceInfo.Id
+		if invoiceDTagValue == "" {
+			invoiceDTagValue = paymentRequest.PaymentHash
+		}
+		dTag := []string{"d", invoiceDTagValue}
 
-			controller.
-				pay(ctx, bolt11, &paymentRequest, nip47Request, requestEventId, app, publishResponse, nostr.Tags{dTag})
-		}(invoiceInfo)
+		payments = append(payments, multiPayment{
+			tags:        nostr.Tags{dTag},
+			paymentHash: paymentRequest.PaymentHash,
+			amountMsat:  uint64(paymentRequest.MSatoshi),
+			send: func(ctx context.Context, tags nostr.Tags) {
+				controller.
+					pay(ctx, bolt11, &paymentRequest, false, nip47Request, requestEventId, app, publishResponse, tags)
+			},
+		})
 	}
 
-	wg.Wait()
+	controller.sendMultiPayments(ctx, nip47Request, payments, publishResponse, saveBatch)
 }
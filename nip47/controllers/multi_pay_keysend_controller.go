@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"context"
-	"sync"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 
 	"github.com/getAlby/hub/db"
 	"github.com/getAlby/hub/nip47/models"
@@ -18,30 +20,83 @@ type multiPayKeysendElement struct {
 	Id string `json:"id"`
 }
 
-func (controller *nip47Controller) HandleMultiPayKeysendEvent(ctx context.Context, nip47Request *models.Request, requestEventId uint, app *db.App, publishResponse publishFunc) {
+func (controller *nip47Controller) HandleMultiPayKeysendEvent(ctx context.Context, nip47Request *models.Request, requestEventId uint, app *db.App, publishResponse publishFunc, saveBatch saveBatchFunc) {
 	multiPayParams := &multiPayKeysendParams{}
 	resp := decodeRequest(nip47Request, multiPayParams)
 	if resp != nil {
 		publishResponse(resp, nostr.Tags{})
 		return
 	}
+	resp = controller.validateMultiPaySize(nip47Request, len(multiPayParams.Keysends))
+	if resp != nil {
+		publishResponse(resp, nostr.Tags{})
+		return
+	}
 
-	var wg sync.WaitGroup
-	wg.Add(len(multiPayParams.Keysends))
+	payments := make([]multiPayment, 0, len(multiPayParams.Keysends))
 	for _, keysendInfo := range multiPayParams.Keysends {
-		go func(keysendInfo multiPayKeysendElement) {
-			defer wg.Done()
+		keysendDTagValue := keysendInfo.Id
+		if keysendDTagValue == "" {
+			keysendDTagValue = keysendInfo.Pubkey
+		}
+		dTag := []string{"d", keysendDTagValue}
+
+		if err := keysendInfo.validate(); err != nil {
+			publishResponse(&models.Response{
+				ResultType: nip47Request.Method,
+				Error: &models.Error{
+					Code:    models.ERROR_BAD_REQUEST,
+					Message: err.Error(),
+				},
+			}, nostr.Tags{dTag})
+			continue
+		}
 
-			keysendDTagValue := keysendInfo.Id
-			if keysendDTagValue == "" {
-				keysendDTagValue = keysendInfo.Pubkey
+		// the preimage is chosen here, so that the payment hash is known before the payment is sent
+		if keysendInfo.Preimage == "" {
+			preimage, err := makePreimageHex()
+			if err != nil {
+				publishResponse(&models.Response{
+					ResultType: nip47Request.Method,
+					Error: &models.Error{
+						Code:    models.ERROR_INTERNAL,
+						Message: err.Error(),
+					},
+				}, nostr.Tags{dTag})
+				continue
 			}
-			dTag := []string{"d", keysendDTagValue}
+			keysendInfo.Preimage = preimage
+		}
 
-			controller.
-				payKeysend(ctx, &keysendInfo.payKeysendParams, nip47Request, requestEventId, app, publishResponse, nostr.Tags{dTag})
-		}(keysendInfo)
+		payments = append(payments, multiPayment{
+			tags:        nostr.Tags{dTag},
+			paymentHash: paymentHashOf(keysendInfo.Preimage),
+			amountMsat:  keysendInfo.Amount,
+			send: func(ctx context.Context, tags nostr.Tags) {
+				controller.
+					payKeysend(ctx, &keysendInfo.payKeysendParams, nip47Request, requestEventId, app, publishResponse, tags)
+			},
+		})
 	}
 
-	wg.Wait()
+	controller.sendMultiPayments(ctx, nip47Request, payments, publishResponse, saveBatch)
+}
+
+func makePreimageHex() (string, error) {
+	bytes := make([]byte, 32)
+	_, err := rand.Read(bytes)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// paymentHashOf returns an empty hash for an invalid preimage, the payment fails in that case
+func paymentHashOf(preimage string) string {
+	preimageBytes, err := hex.DecodeString(preimage)
+	if err != nil {
+		return ""
+	}
+	paymentHash := sha256.Sum256(preimageBytes)
+	return hex.EncodeToString(paymentHash[:])
 }
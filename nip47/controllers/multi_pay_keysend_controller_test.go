@@ -23,7 +23,7 @@ const nip47MultiPayKeysendJson = `
 	"params": {
 		"keysends": [{
 				"amount": 123000,
-				"pubkey": "123pubkey",
+				"pubkey": "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c",
 				"tlv_records": [{
 					"type": 5482373484,
 					"value": "fajsn341414fq"
@@ -31,7 +31,7 @@ const nip47MultiPayKeysendJson = `
 			},
 			{
 				"amount": 123000,
-				"pubkey": "123pubkey",
+				"pubkey": "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c",
 				"tlv_records": [{
 					"type": 5482373484,
 					"value": "fajsn341414fq"
@@ -48,7 +48,7 @@ const nip47MultiPayKeysendOneOverflowingBudgetJson = `
 	"params": {
 		"keysends": [{
 				"amount": 123000,
-				"pubkey": "123pubkey",
+				"pubkey": "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c",
 				"id": "customId",
 				"tlv_records": [{
 					"type": 5482373484,
@@ -57,7 +57,7 @@ const nip47MultiPayKeysendOneOverflowingBudgetJson = `
 			},
 			{
 				"amount": 500000,
-				"pubkey": "500pubkey",
+				"pubkey": "02c16cca44562b590dd279c942200bdccfd4f990c3a69fad620c10ef2f8228eaff",
 				"tlv_records": [{
 					"type": 5482373484,
 					"value": "fajsn341414fq"
@@ -106,16 +106,16 @@ func TestHandleMultiPayKeysendEvent(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
-		HandleMultiPayKeysendEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
+		HandleMultiPayKeysendEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse, nil)
 
 	assert.Equal(t, 2, len(responses))
 	for i := 0; i < len(responses); i++ {
 		assert.Equal(t, 64, len(responses[i].Result.(payResponse).Preimage))
 		assert.Equal(t, uint64(1), responses[i].Result.(payResponse).FeesPaid)
 		assert.Nil(t, responses[i].Error)
-		assert.Equal(t, "123pubkey", dTags[i].GetFirst([]string{"d"}).Value())
+		assert.Equal(t, "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c", dTags[i].GetFirst([]string{"d"}).Value())
 	}
 }
 
@@ -158,9 +158,9 @@ func TestHandleMultiPayKeysendEvent_OneBudgetExceeded(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
-		HandleMultiPayKeysendEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
+		HandleMultiPayKeysendEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse, nil)
 
 	// we can't guarantee which request was processed first
 	// so swap them if they are back to front
@@ -177,3 +177,262 @@ func TestHandleMultiPayKeysendEvent_OneBudgetExceeded(t *testing.T) {
 	assert.Nil(t, responses[1].Result)
 	assert.Equal(t, models.ERROR_QUOTA_EXCEEDED, responses[1].Error.Code)
 }
+
+const nip47MultiPayKeysendOneMissingPubkeyJson = `
+{
+	"method": "multi_pay_keysend",
+	"params": {
+		"keysends": [{
+				"amount": 123000,
+				"id": "missingPubkey"
+			},
+			{
+				"amount": 123000,
+				"pubkey": "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c"
+			}
+		]
+	}
+}
+`
+
+func TestHandleMultiPayKeysendEvent_OneMissingPubkey(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	appPermission := &db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.PAY_INVOICE_SCOPE,
+	}
+	err = svc.DB.Create(appPermission).Error
+	assert.NoError(t, err)
+
+	nip47Request := &models.Request{}
+	err = json.Unmarshal([]byte(nip47MultiPayKeysendOneMissingPubkeyJson), nip47Request)
+	assert.NoError(t, err)
+
+	dbRequestEvent := &db.RequestEvent{}
+	err = svc.DB.Create(&dbRequestEvent).Error
+	assert.NoError(t, err)
+
+	responses := []*models.Response{}
+	dTags := []nostr.Tags{}
+
+	var mu sync.Mutex
+
+	publishResponse := func(response *models.Response, tags nostr.Tags) {
+		mu.Lock()
+		defer mu.Unlock()
+		responses = append(responses, response)
+		dTags = append(dTags, tags)
+	}
+
+	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
+		HandleMultiPayKeysendEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse, nil)
+
+	// invalid elements are answered before any payment is sent
+	assert.Equal(t, 2, len(responses))
+	assert.Equal(t, "missingPubkey", dTags[0].GetFirst([]string{"d"}).Value())
+	assert.Nil(t, responses[0].Result)
+	assert.Equal(t, models.ERROR_BAD_REQUEST, responses[0].Error.Code)
+
+	assert.Equal(t, "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c", dTags[1].GetFirst([]string{"d"}).Value())
+	assert.Nil(t, responses[1].Error)
+	assert.Equal(t, 64, len(responses[1].Result.(payResponse).Preimage))
+}
+
+func TestHandleMultiPayKeysendEvent_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	appPermission := &db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.PAY_INVOICE_SCOPE,
+	}
+	err = svc.DB.Create(appPermission).Error
+	assert.NoError(t, err)
+
+	nip47Request := &models.Request{}
+	err = json.Unmarshal([]byte(nip47MultiPayKeysendJson), nip47Request)
+	assert.NoError(t, err)
+
+	dbRequestEvent := &db.RequestEvent{}
+	err = svc.DB.Create(&dbRequestEvent).Error
+	assert.NoError(t, err)
+
+	responses := []*models.Response{}
+
+	var mu sync.Mutex
+
+	publishResponse := func(response *models.Response, tags nostr.Tags) {
+		mu.Lock()
+		defer mu.Unlock()
+		responses = append(responses, response)
+	}
+
+	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
+		HandleMultiPayKeysendEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse, nil)
+
+	assert.Equal(t, 2, len(responses))
+	for _, response := range responses {
+		assert.Nil(t, response.Result)
+		assert.Equal(t, models.ERROR_INTERNAL, response.Error.Code)
+	}
+
+	var transactionCount int64
+	svc.DB.Model(&db.Transaction{}).Count(&transactionCount)
+	assert.Zero(t, transactionCount)
+}
+
+func TestHandleMultiPayKeysendEvent_MorePaymentsThanWorkers(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	appPermission := &db.AppPermission{
+		AppId:        app.ID,
+		App:          *app,
+		Scope:        constants.PAY_INVOICE_SCOPE,
+		MaxAmountSat: 20,
+	}
+	err = svc.DB.Create(appPermission).Error
+	assert.NoError(t, err)
+
+	// each payment reserves 11 sats including the fee reserve
+	keysends := []multiPayKeysendElement{}
+	for i := 0; i < 3*maxConcurrentMultiPayments; i++ {
+		keysends = append(keysends, multiPayKeysendElement{
+			payKeysendParams: payKeysendParams{
+				Amount: 1000,
+				Pubkey: "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c",
+			},
+		})
+	}
+	params, err := json.Marshal(multiPayKeysendParams{Keysends: keysends})
+	assert.NoError(t, err)
+	nip47Request := &models.Request{
+		Method: models.MULTI_PAY_KEYSEND_METHOD,
+		Params: params,
+	}
+
+	dbRequestEvent := &db.RequestEvent{}
+	err = svc.DB.Create(&dbRequestEvent).Error
+	assert.NoError(t, err)
+
+	responses := []*models.Response{}
+
+	var mu sync.Mutex
+
+	publishResponse := func(response *models.Response, tags nostr.Tags) {
+		mu.Lock()
+		defer mu.Unlock()
+		responses = append(responses, response)
+	}
+
+	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
+		HandleMultiPayKeysendEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse, nil)
+
+	assert.Equal(t, 3*maxConcurrentMultiPayments, len(responses))
+	succeeded := 0
+	for _, response := range responses {
+		if response.Error == nil {
+			succeeded++
+		} else {
+			assert.Equal(t, models.ERROR_QUOTA_EXCEEDED, response.Error.Code)
+		}
+	}
+	assert.NotZero(t, succeeded)
+	assert.Less(t, succeeded, 3*maxConcurrentMultiPayments)
+
+	// the budget is never exceeded, however the payments are interleaved
+	var paidMsat uint64
+	svc.DB.Model(&db.Transaction{}).Where("state = ?", constants.TRANSACTION_STATE_SETTLED).Select("SUM(amount_msat + fee_msat)").Scan(&paidMsat)
+	assert.LessOrEqual(t, paidMsat, uint64(20_000))
+}
+
+func TestHandleMultiPayKeysendEvent_BatchLimits(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	appPermission := &db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.PAY_INVOICE_SCOPE,
+	}
+	err = svc.DB.Create(appPermission).Error
+	assert.NoError(t, err)
+
+	// the request of nip47MultiPayKeysendJson pays 2 x 123 sats
+	for _, limits := range []struct {
+		maxPayments int
+		maxTotalSat int
+		dTagged     bool
+	}{
+		{maxPayments: 1, dTagged: false},
+		{maxTotalSat: 245, dTagged: true},
+	} {
+		svc.Cfg.GetEnv().Nip47MultiPayMaxPayments = limits.maxPayments
+		svc.Cfg.GetEnv().Nip47MultiPayMaxTotalSat = limits.maxTotalSat
+
+		nip47Request := &models.Request{}
+		err = json.Unmarshal([]byte(nip47MultiPayKeysendJson), nip47Request)
+		assert.NoError(t, err)
+
+		responses := []*models.Response{}
+		dTags := []nostr.Tags{}
+		var mu sync.Mutex
+		publishResponse := func(response *models.Response, tags nostr.Tags) {
+			mu.Lock()
+			defer mu.Unlock()
+			responses = append(responses, response)
+			dTags = append(dTags, tags)
+		}
+
+		permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
+		transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+		NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
+			HandleMultiPayKeysendEvent(ctx, nip47Request, 0, app, publishResponse, nil)
+
+		if limits.dTagged {
+			assert.Equal(t, 2, len(responses))
+		} else {
+			assert.Equal(t, 1, len(responses))
+		}
+		for i, response := range responses {
+			assert.Equal(t, models.ERROR_BAD_REQUEST, response.Error.Code)
+			assert.Equal(t, limits.dTagged, dTags[i].GetFirst([]string{"d"}) != nil)
+		}
+	}
+
+	var transactionCount int64
+	svc.DB.Model(&db.Transaction{}).Count(&transactionCount)
+	assert.Zero(t, transactionCount)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/events"
 	"github.com/getAlby/hub/lnclient"
 	"github.com/getAlby/hub/nip47/permissions"
@@ -11,15 +12,17 @@ import (
 type nip47Controller struct {
 	lnClient            lnclient.LNClient
 	db                  *gorm.DB
+	cfg                 config.Config
 	eventPublisher      events.EventPublisher
 	permissionsService  permissions.PermissionsService
 	transactionsService transactions.TransactionsService
 }
 
-func NewNip47Controller(lnClient lnclient.LNClient, db *gorm.DB, eventPublisher events.EventPublisher, permissionsService permissions.PermissionsService, transactionsService transactions.TransactionsService) *nip47Controller {
+func NewNip47Controller(lnClient lnclient.LNClient, db *gorm.DB, cfg config.Config, eventPublisher events.EventPublisher, permissionsService permissions.PermissionsService, transactionsService transactions.TransactionsService) *nip47Controller {
 	return &nip47Controller{
 		lnClient:            lnClient,
 		db:                  db,
+		cfg:                 cfg,
 		eventPublisher:      eventPublisher,
 		permissionsService:  permissionsService,
 		transactionsService: transactionsService,
@@ -7,6 +7,7 @@ import (
 
 	"github.com/getAlby/hub/db"
 	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnurl"
 	"github.com/getAlby/hub/logger"
 	"github.com/getAlby/hub/nip47/models"
 	"github.com/nbd-wtf/go-nostr"
@@ -16,6 +17,12 @@ import (
 
 type payInvoiceParams struct {
 	Invoice string `json:"invoice"`
+	// alternatively a LNURL-pay link or lightning address can be paid
+	// by providing an amount in millisats
+	Lnurl   string `json:"lnurl"`
+	Address string `json:"address"`
+	Amount  uint64 `json:"amount"`
+	Comment string `json:"comment"`
 }
 
 func (controller *nip47Controller) HandlePayInvoiceEvent(ctx context.Context, nip47Request *models.Request, requestEventId uint, app *db.App, publishResponse publishFunc, tags nostr.Tags) {
@@ -27,11 +34,38 @@ func (controller *nip47Controller) HandlePayInvoiceEvent(ctx context.Context, ni
 	}
 
 	bolt11 := payParams.Invoice
+	resolved := false
+	if bolt11 == "" && (payParams.Lnurl != "" || payParams.Address != "") {
+		lnurlOrAddress := payParams.Lnurl
+		if lnurlOrAddress == "" {
+			lnurlOrAddress = payParams.Address
+		}
+		var err error
+		bolt11, err = lnurl.ResolvePayRequest(ctx, lnurlOrAddress, payParams.Amount, payParams.Comment)
+		if err != nil {
+			logger.Nostr.WithFields(logrus.Fields{
+				"request_event_id": requestEventId,
+				"app_id":           app.ID,
+				"lnurl":            lnurlOrAddress,
+			}).WithError(err).Error("Failed to resolve lnurl")
+
+			publishResponse(&models.Response{
+				ResultType: nip47Request.Method,
+				Error: &models.Error{
+					Code:    models.ERROR_INTERNAL,
+					Message: fmt.Sprintf("Failed to resolve lnurl: %s", err.Error()),
+				},
+			}, tags)
+			return
+		}
+		resolved = true
+	}
+
 	// Convert invoice to lowercase string
 	bolt11 = strings.ToLower(bolt11)
 	paymentRequest, err := decodepay.Decodepay(bolt11)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"request_event_id": requestEventId,
 			"app_id":           app.ID,
 			"bolt11":           bolt11,
@@ -47,11 +81,12 @@ func (controller *nip47Controller) HandlePayInvoiceEvent(ctx context.Context, ni
 		return
 	}
 
-	controller.pay(ctx, bolt11, &paymentRequest, nip47Request, requestEventId, app, publishResponse, tags)
+	controller.pay(ctx, bolt11, &paymentRequest, resolved, nip47Request, requestEventId, app, publishResponse, tags)
 }
 
-func (controller *nip47Controller) pay(ctx context.Context, bolt11 string, paymentRequest *decodepay.Bolt11, nip47Request *models.Request, requestEventId uint, app *db.App, publishResponse publishFunc, tags nostr.Tags) {
-	logger.Logger.WithFields(logrus.Fields{
+// includeInvoice returns the paid invoice so clients can verify an invoice the hub resolved for them
+func (controller *nip47Controller) pay(ctx context.Context, bolt11 string, paymentRequest *decodepay.Bolt11, includeInvoice bool, nip47Request *models.Request, requestEventId uint, app *db.App, publishResponse publishFunc, tags nostr.Tags) {
+	logger.Nostr.WithFields(logrus.Fields{
 		"request_event_id": requestEventId,
 		"app_id":           app.ID,
 		"bolt11":           bolt11,
@@ -59,7 +94,7 @@ func (controller *nip47Controller) pay(ctx context.Context, bolt11 string, payme
 
 	transaction, err := controller.transactionsService.SendPaymentSync(ctx, bolt11, controller.lnClient, &app.ID, &requestEventId)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"request_event_id": requestEventId,
 			"app_id":           app.ID,
 			"bolt11":           bolt11,
@@ -67,9 +102,10 @@ func (controller *nip47Controller) pay(ctx context.Context, bolt11 string, payme
 		controller.eventPublisher.Publish(&events.Event{
 			Event: "nwc_payment_failed",
 			Properties: map[string]interface{}{
-				"error":   err.Error(),
-				"invoice": bolt11,
-				"amount":  paymentRequest.MSatoshi / 1000,
+				"error":    err.Error(),
+				"invoice":  bolt11,
+				"amount":   paymentRequest.MSatoshi / 1000,
+				"app_name": app.Name,
 			},
 		})
 		publishResponse(&models.Response{
@@ -87,11 +123,16 @@ func (controller *nip47Controller) pay(ctx context.Context, bolt11 string, payme
 		},
 	})
 
+	result := payResponse{
+		Preimage: *transaction.Preimage,
+		FeesPaid: transaction.FeeMsat,
+	}
+	if includeInvoice {
+		result.Invoice = bolt11
+	}
+
 	publishResponse(&models.Response{
 		ResultType: nip47Request.Method,
-		Result: payResponse{
-			Preimage: *transaction.Preimage,
-			FeesPaid: transaction.FeeMsat,
-		},
+		Result:     result,
 	}, tags)
 }
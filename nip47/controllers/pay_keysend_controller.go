@@ -30,7 +30,7 @@ func (controller *nip47Controller) HandlePayKeysendEvent(ctx context.Context, ni
 }
 
 func (controller *nip47Controller) payKeysend(ctx context.Context, payKeysendParams *payKeysendParams, nip47Request *models.Request, requestEventId uint, app *db.App, publishResponse publishFunc, tags nostr.Tags) {
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"request_event_id": requestEventId,
 		"appId":            app.ID,
 		"senderPubkey":     payKeysendParams.Pubkey,
@@ -38,7 +38,7 @@ func (controller *nip47Controller) payKeysend(ctx context.Context, payKeysendPar
 
 	transaction, err := controller.transactionsService.SendKeysend(ctx, payKeysendParams.Amount, payKeysendParams.Pubkey, payKeysendParams.TLVRecords, payKeysendParams.Preimage, controller.lnClient, &app.ID, &requestEventId)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"request_event_id": requestEventId,
 			"appId":            app.ID,
 			"recipientPubkey":  payKeysendParams.Pubkey,
@@ -46,9 +46,10 @@ func (controller *nip47Controller) payKeysend(ctx context.Context, payKeysendPar
 		controller.eventPublisher.Publish(&events.Event{
 			Event: "nwc_payment_failed",
 			Properties: map[string]interface{}{
-				"error":   err.Error(),
-				"keysend": true,
-				"amount":  payKeysendParams.Amount / 1000,
+				"error":    err.Error(),
+				"keysend":  true,
+				"amount":   payKeysendParams.Amount / 1000,
+				"app_name": app.Name,
 			},
 		})
 		publishResponse(&models.Response{
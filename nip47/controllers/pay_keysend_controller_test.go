@@ -21,7 +21,7 @@ const nip47KeysendJson = `
 	"method": "pay_keysend",
 	"params": {
 		"amount": 123000,
-		"pubkey": "123pubkey",
+		"pubkey": "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c",
 		"tlv_records": [{
 			"type": 5482373484,
 			"value": "fajsn341414fq"
@@ -35,7 +35,7 @@ const nip47KeysendJsonWithPreimage = `
 	"method": "pay_keysend",
 	"params": {
 		"amount": 123000,
-		"pubkey": "123pubkey",
+		"pubkey": "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c",
 		"preimage": "018465013e2337234a7e5530a21c4a8cf70d84231f4a8ff0b1e2cce3cb2bd03b",
 		"tlv_records": [{
 			"type": 5482373484,
@@ -78,8 +78,8 @@ func TestHandlePayKeysendEvent(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandlePayKeysendEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse, nostr.Tags{})
 
 	assert.Nil(t, publishedResponse.Error)
@@ -119,8 +119,8 @@ func TestHandlePayKeysendEvent_WithPreimage(t *testing.T) {
 	}
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
-	NewNip47Controller(svc.LNClient, svc.DB, svc.EventPublisher, permissionsSvc, transactionsSvc).
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	NewNip47Controller(svc.LNClient, svc.DB, svc.Cfg, svc.EventPublisher, permissionsSvc, transactionsSvc).
 		HandlePayKeysendEvent(ctx, nip47Request, dbRequestEvent.ID, app, publishResponse, nostr.Tags{})
 
 	assert.Nil(t, publishedResponse.Error)
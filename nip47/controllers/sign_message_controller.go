@@ -26,13 +26,13 @@ func (controller *nip47Controller) HandleSignMessageEvent(ctx context.Context, n
 		return
 	}
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"request_event_id": requestEventId,
 	}).Info("Signing message")
 
 	signature, err := controller.lnClient.SignMessage(ctx, signParams.Message)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"request_event_id": requestEventId,
 		}).WithError(err).Error("Failed to sign message")
 		publishResponse(&models.Response{
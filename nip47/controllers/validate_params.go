@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/getAlby/hub/constants"
+)
+
+// limits of the request params, larger values cannot be valid or are not worth handling
+const (
+	// 21 million bitcoin
+	maxAmountMsat = 21_000_000 * 100_000_000 * 1000
+	// bolt11 invoices with many route hints can be long, but not this long
+	maxInvoiceLength = 8192
+	// the length of the description field of a bolt11 invoice
+	maxDescriptionLength = 639
+	maxCommentLength     = 2000
+	maxLnurlLength       = 2048
+	maxMessageLength     = 10000
+	maxTLVRecords        = 50
+)
+
+// validatedParams are params that decodeRequest checks before they are passed to the handler.
+// Elements of multi_pay requests are checked by the handler, so that each gets its own error.
+type validatedParams interface {
+	validate() error
+}
+
+func (params *payInvoiceParams) validate() error {
+	if params.Invoice == "" {
+		if params.Lnurl == "" && params.Address == "" {
+			return errors.New("invoice is required")
+		}
+		if params.Amount == 0 {
+			return errors.New("An amount is required to pay a lnurl or lightning address")
+		}
+		if err := validateAmount("amount", params.Amount); err != nil {
+			return err
+		}
+		if err := validateLength("lnurl", params.Lnurl, maxLnurlLength); err != nil {
+			return err
+		}
+		if err := validateLength("address", params.Address, maxLnurlLength); err != nil {
+			return err
+		}
+		return validateLength("comment", params.Comment, maxCommentLength)
+	}
+	return validateInvoice("invoice", params.Invoice)
+}
+
+func (params *payKeysendParams) validate() error {
+	if params.Pubkey == "" {
+		return errors.New("A pubkey is required to send a keysend payment")
+	}
+	if len(params.Pubkey) != 66 || !isHex(params.Pubkey) {
+		return fmt.Errorf("pubkey must be a hex encoded node public key, got %q", params.Pubkey)
+	}
+	if params.Amount == 0 {
+		return errors.New("amount is required")
+	}
+	if err := validateAmount("amount", params.Amount); err != nil {
+		return err
+	}
+	if params.Preimage != "" && (len(params.Preimage) != 64 || !isHex(params.Preimage)) {
+		return errors.New("preimage must be 32 hex encoded bytes")
+	}
+	if len(params.TLVRecords) > maxTLVRecords {
+		return fmt.Errorf("tlv_records: at most %d records are allowed", maxTLVRecords)
+	}
+	return nil
+}
+
+func (params *multiPayInvoiceParams) validate() error {
+	if len(params.Invoices) == 0 {
+		return errors.New("invoices is required")
+	}
+	return nil
+}
+
+func (params *multiPayKeysendParams) validate() error {
+	if len(params.Keysends) == 0 {
+		return errors.New("keysends is required")
+	}
+	return nil
+}
+
+// multi_pay_invoice only pays invoices, not lnurls or lightning addresses
+func (element *multiPayInvoiceElement) validate() error {
+	if element.Invoice == "" {
+		return errors.New("invoice is required")
+	}
+	return validateInvoice("invoice", element.Invoice)
+}
+
+func (params *makeInvoiceParams) validate() error {
+	if params.Amount < 0 {
+		return fmt.Errorf("amount cannot be negative, got %d", params.Amount)
+	}
+	if err := validateAmount("amount", uint64(params.Amount)); err != nil {
+		return err
+	}
+	if params.Expiry < 0 {
+		return fmt.Errorf("expiry cannot be negative, got %d", params.Expiry)
+	}
+	if err := validateLength("description", params.Description, maxDescriptionLength); err != nil {
+		return err
+	}
+	if params.DescriptionHash != "" && (len(params.DescriptionHash) != 64 || !isHex(params.DescriptionHash)) {
+		return errors.New("description_hash must be a hex encoded sha256 hash")
+	}
+	return nil
+}
+
+func (params *makeOfferParams) validate() error {
+	return validateLength("description", params.Description, maxDescriptionLength)
+}
+
+func (params *lookupInvoiceParams) validate() error {
+	if params.PaymentHash != "" {
+		if len(params.PaymentHash) != 64 || !isHex(params.PaymentHash) {
+			return errors.New("payment_hash must be a hex encoded sha256 hash")
+		}
+		return nil
+	}
+	if params.Invoice == "" {
+		return errors.New("invoice or payment_hash is required")
+	}
+	return validateInvoice("invoice", params.Invoice)
+}
+
+func (params *listTransactionsParams) validate() error {
+	if !slices.Contains([]string{"", constants.TRANSACTION_TYPE_INCOMING, constants.TRANSACTION_TYPE_OUTGOING}, params.Type) {
+		return fmt.Errorf("type must be incoming or outgoing, got %q", params.Type)
+	}
+	if params.From > 0 && params.Until > 0 && params.From > params.Until {
+		return errors.New("from cannot be after until")
+	}
+	return nil
+}
+
+func (params *signMessageParams) validate() error {
+	if params.Message == "" {
+		return errors.New("message is required")
+	}
+	return validateLength("message", params.Message, maxMessageLength)
+}
+
+func validateAmount(field string, amount uint64) error {
+	if amount > maxAmountMsat {
+		return fmt.Errorf("%s cannot be more than %d msat, got %d", field, uint64(maxAmountMsat), amount)
+	}
+	return nil
+}
+
+func validateLength(field string, value string, maxLength int) error {
+	if len(value) > maxLength {
+		return fmt.Errorf("%s cannot be longer than %d characters", field, maxLength)
+	}
+	return nil
+}
+
+// validateInvoice only checks the format, the invoice is decoded by the handler
+func validateInvoice(field string, invoice string) error {
+	if err := validateLength(field, invoice, maxInvoiceLength); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(strings.ToLower(invoice), "ln") {
+		return fmt.Errorf("%s must be a bolt11 invoice", field)
+	}
+	return nil
+}
+
+func isHex(value string) bool {
+	_, err := hex.DecodeString(value)
+	return err == nil
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeRequest_ValidatesParams(t *testing.T) {
+	pubkey := "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c"
+	for _, testCase := range []struct {
+		params interface{}
+		json   string
+		valid  bool
+	}{
+		{&payInvoiceParams{}, `{"invoice": "` + tests.MockInvoice + `"}`, true},
+		{&payInvoiceParams{}, `{"invoice": "` + strings.ToUpper(tests.MockInvoice) + `"}`, true},
+		{&payInvoiceParams{}, `{"invoice": "not an invoice"}`, false},
+		{&payInvoiceParams{}, `{}`, false},
+		{&payInvoiceParams{}, `{"address": "alice@example.com", "amount": 1000}`, true},
+		{&payInvoiceParams{}, `{"address": "alice@example.com", "amount": 2100000000000000001}`, false},
+		{&payInvoiceParams{}, `{"address": "alice@example.com", "amount": 1000, "comment": "` + strings.Repeat("a", maxCommentLength+1) + `"}`, false},
+		{&payKeysendParams{}, `{"pubkey": "` + pubkey + `", "amount": 1000}`, true},
+		{&payKeysendParams{}, `{"pubkey": "` + pubkey + `", "amount": 1000, "preimage": "` + strings.Repeat("ab", 32) + `"}`, true},
+		{&payKeysendParams{}, `{"pubkey": "` + pubkey + `", "amount": 1000, "preimage": "abc"}`, false},
+		{&payKeysendParams{}, `{"pubkey": "123pubkey", "amount": 1000}`, false},
+		{&payKeysendParams{}, `{"pubkey": "` + pubkey + `"}`, false},
+		{&multiPayInvoiceParams{}, `{"invoices": []}`, false},
+		{&multiPayKeysendParams{}, `{}`, false},
+		{&makeInvoiceParams{}, `{"amount": 1000, "description": "Hello, world"}`, true},
+		{&makeInvoiceParams{}, `{"amount": -1}`, false},
+		{&makeInvoiceParams{}, `{"amount": 1000, "expiry": -1}`, false},
+		{&makeInvoiceParams{}, `{"amount": 1000, "description": "` + strings.Repeat("a", maxDescriptionLength+1) + `"}`, false},
+		{&makeInvoiceParams{}, `{"amount": 1000, "description_hash": "not a hash"}`, false},
+		{&lookupInvoiceParams{}, `{"payment_hash": "` + tests.MockPaymentHash + `"}`, true},
+		{&lookupInvoiceParams{}, `{"payment_hash": "abc"}`, false},
+		{&lookupInvoiceParams{}, `{}`, false},
+		{&listTransactionsParams{}, `{"type": "incoming", "from": 1, "until": 2}`, true},
+		{&listTransactionsParams{}, `{"type": "unknown"}`, false},
+		{&listTransactionsParams{}, `{"from": 2, "until": 1}`, false},
+		{&signMessageParams{}, `{"message": "Hello"}`, true},
+		{&signMessageParams{}, `{}`, false},
+	} {
+		resp := decodeRequest(&models.Request{Method: "method", Params: []byte(testCase.json)}, testCase.params)
+		if testCase.valid {
+			assert.Nil(t, resp, testCase.json)
+			continue
+		}
+		if assert.NotNil(t, resp, testCase.json) {
+			assert.Equal(t, models.ERROR_BAD_REQUEST, resp.Error.Code)
+			assert.Equal(t, "method", resp.ResultType)
+		}
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/getAlby/hub/db"
@@ -16,14 +19,14 @@ import (
 	"github.com/getAlby/hub/nip47/permissions"
 	nostrmodels "github.com/getAlby/hub/nostr/models"
 	"github.com/nbd-wtf/go-nostr"
-	"github.com/nbd-wtf/go-nostr/nip04"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
 func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Relay, event *nostr.Event, lnClient lnclient.LNClient) {
+	timer := newRequestTimer(REQUEST_PHASE_STORE)
 	var nip47Response *models.Response
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"requestEventNostrId": event.ID,
 		"eventKind":           event.Kind,
 	}).Info("Processing Event")
@@ -31,40 +34,52 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 	// go-nostr already checks this, but just to be sure:
 	validEventSignature, err := event.CheckSignature()
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventNostrId": event.ID,
 			"eventKind":           event.Kind,
 		}).WithError(err).Error("invalid event signature")
 		return
 	}
 	if !validEventSignature {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventNostrId": event.ID,
 			"eventKind":           event.Kind,
 		}).Error("invalid event signature")
 		return
 	}
 
-	ss, err := nip04.ComputeSharedSecret(event.PubKey, svc.keys.GetNostrSecretKey())
+	walletSecretKey := svc.keys.GetNostrSecretKeyFor(walletPubkey(event))
+	version := requestVersion(event)
+	// apps requesting a version we do not support are answered with NIP-04, which every app understands
+	responseVersion := version
+	if !slices.Contains(models.SUPPORTED_VERSIONS, version) {
+		responseVersion = models.VERSION_0_0
+	}
+	cipher, err := newNip47Cipher(responseVersion, event.PubKey, walletSecretKey)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventNostrId": event.ID,
 			"eventKind":           event.Kind,
 		}).WithError(err).Error("Failed to compute shared secret")
 		return
 	}
 
+	// relays deliver events at least once
+	if svc.replayProcessedEvent(ctx, relay, event) {
+		return
+	}
+
 	// store request event
 	requestEvent := db.RequestEvent{AppId: nil, NostrId: event.ID, State: db.REQUEST_EVENT_STATE_HANDLER_EXECUTING}
-	err = svc.db.Create(&requestEvent).Error
+	err = svc.createRequestEvent(&requestEvent)
 	if err != nil {
 		if errors.Is(err, gorm.ErrDuplicatedKey) {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"requestEventNostrId": event.ID,
 			}).Warn("Event already processed")
 			return
 		}
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventNostrId": event.ID,
 			"eventKind":           event.Kind,
 		}).WithError(err).Error("Failed to save nostr event")
@@ -74,23 +89,20 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 				Message: fmt.Sprintf("Failed to save nostr event: %s", err.Error()),
 			},
 		}
-		resp, err := svc.CreateResponse(event, nip47Response, nostr.Tags{}, ss)
+		resp, err := svc.createResponse(event, nip47Response, nostr.Tags{}, cipher)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"requestEventNostrId": event.ID,
 				"eventKind":           event.Kind,
 			}).WithError(err).Error("Failed to process event")
 		}
-		svc.publishResponseEvent(ctx, relay, &requestEvent, resp, nil)
+		svc.queueResponse(ctx, relay, &requestEvent, resp, nil, nil)
 		return
 	}
 
-	app := db.App{}
-	err = svc.db.First(&app, &db.App{
-		NostrPubkey: event.PubKey,
-	}).Error
+	app, err := svc.permissionsService.GetAppByPubkey(event.PubKey)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"nostrPubkey": event.PubKey,
 		}).WithError(err).Error("Failed to find app for nostr pubkey")
 
@@ -100,19 +112,20 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 				Message: "The public key does not have a wallet connected.",
 			},
 		}
-		resp, err := svc.CreateResponse(event, nip47Response, nostr.Tags{}, ss)
+		resp, err := svc.createResponse(event, nip47Response, nostr.Tags{}, cipher)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"requestEventNostrId": event.ID,
 				"eventKind":           event.Kind,
 			}).WithError(err).Error("Failed to process event")
 		}
-		svc.publishResponseEvent(ctx, relay, &requestEvent, resp, &app)
+		svc.saveProcessedResponse(&requestEvent, resp)
+		svc.queueResponse(ctx, relay, &requestEvent, resp, &app, nil)
 
 		requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_ERROR
-		err = svc.db.Save(&requestEvent).Error
+		err = svc.saveRequestEvent(&requestEvent)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"nostrPubkey": event.PubKey,
 			}).WithError(err).Error("Failed to save state to nostr event")
 		}
@@ -122,7 +135,7 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 	requestEvent.AppId = &app.ID
 	err = svc.db.Save(&requestEvent).Error
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"nostrPubkey": event.PubKey,
 		}).WithError(err).Error("Failed to save app to nostr event")
 
@@ -132,19 +145,20 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 				Message: fmt.Sprintf("Failed to save app to nostr event: %s", err.Error()),
 			},
 		}
-		resp, err := svc.CreateResponse(event, nip47Response, nostr.Tags{}, ss)
+		resp, err := svc.createResponse(event, nip47Response, nostr.Tags{}, cipher)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"requestEventNostrId": event.ID,
 				"eventKind":           event.Kind,
 			}).WithError(err).Error("Failed to process event")
 		}
-		svc.publishResponseEvent(ctx, relay, &requestEvent, resp, &app)
+		svc.saveProcessedResponse(&requestEvent, resp)
+		svc.queueResponse(ctx, relay, &requestEvent, resp, &app, nil)
 
 		requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_ERROR
-		err = svc.db.Save(&requestEvent).Error
+		err = svc.saveRequestEvent(&requestEvent)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"nostrPubkey": event.PubKey,
 			}).WithError(err).Error("Failed to save state to nostr event")
 		}
@@ -152,64 +166,110 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 		return
 	}
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"requestEventNostrId": event.ID,
 		"eventKind":           event.Kind,
 		"appId":               app.ID,
 	}).Info("App found for nostr event")
+	timer.startPhase(REQUEST_PHASE_DECODE)
 
 	//to be extra safe, decrypt using the key found from the app
-	ss, err = nip04.ComputeSharedSecret(app.NostrPubkey, svc.keys.GetNostrSecretKey())
+	cipher, err = newNip47Cipher(responseVersion, app.NostrPubkey, walletSecretKey)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventNostrId": event.ID,
 			"eventKind":           event.Kind,
 		}).WithError(err).Error("Failed to process event")
 
 		requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_ERROR
-		err = svc.db.Save(&requestEvent).Error
+		err = svc.saveRequestEvent(&requestEvent)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"nostrPubkey": event.PubKey,
 			}).WithError(err).Error("Failed to save state to nostr event")
 		}
 
 		return
 	}
-	payload, err := nip04.Decrypt(event.Content, ss)
+
+	if responseVersion != version {
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventNostrId": event.ID,
+			"appId":               app.ID,
+			"version":             version,
+		}).Warn("Rejected request event with an unsupported version")
+
+		nip47Response = &models.Response{
+			Error: &models.Error{
+				Code:    models.ERROR_UNSUPPORTED_VERSION,
+				Message: fmt.Sprintf("Unsupported version: %s, supported versions: %s", version, strings.Join(models.SUPPORTED_VERSIONS, ", ")),
+			},
+		}
+		resp, err := svc.createResponse(event, nip47Response, nostr.Tags{}, cipher)
+		if err != nil {
+			logger.Nostr.WithFields(logrus.Fields{
+				"requestEventNostrId": event.ID,
+				"eventKind":           event.Kind,
+			}).WithError(err).Error("Failed to process event")
+		}
+		svc.saveProcessedResponse(&requestEvent, resp)
+		svc.queueResponse(ctx, relay, &requestEvent, resp, &app, nil)
+
+		requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_ERROR
+		err = svc.saveRequestEvent(&requestEvent)
+		if err != nil {
+			logger.Nostr.WithFields(logrus.Fields{
+				"nostrPubkey": event.PubKey,
+			}).WithError(err).Error("Failed to save state to nostr event")
+		}
+		return
+	}
+
+	err = checkEncryptedPayloadSize(event.Content)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		svc.rejectPayload(ctx, relay, event, &requestEvent, &app, cipher, err)
+		return
+	}
+
+	payload, err := cipher.Decrypt(event.Content)
+	if err != nil {
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventNostrId": event.ID,
 			"eventKind":           event.Kind,
 			"appId":               app.ID,
 		}).WithError(err).Error("Failed to decrypt content")
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventNostrId": event.ID,
 			"eventKind":           event.Kind,
 		}).WithError(err).Error("Failed to process event")
 
 		requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_ERROR
-		err = svc.db.Save(&requestEvent).Error
+		err = svc.saveRequestEvent(&requestEvent)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"nostrPubkey": event.PubKey,
 			}).WithError(err).Error("Failed to save state to nostr event")
 		}
 
 		return
 	}
+	err = checkPayloadLimits(payload)
+	if err != nil {
+		svc.rejectPayload(ctx, relay, event, &requestEvent, &app, cipher, err)
+		return
+	}
 	nip47Request := &models.Request{}
 	err = json.Unmarshal([]byte(payload), nip47Request)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventNostrId": event.ID,
 			"eventKind":           event.Kind,
 		}).WithError(err).Error("Failed to process event")
 
 		requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_ERROR
-		err = svc.db.Save(&requestEvent).Error
+		err = svc.saveRequestEvent(&requestEvent)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"nostrPubkey": event.PubKey,
 			}).WithError(err).Error("Failed to save state to nostr event")
 		}
@@ -217,48 +277,84 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 		return
 	}
 
+	timer.startPhase(REQUEST_PHASE_PERMISSIONS)
+
 	requestEvent.Method = nip47Request.Method
 	requestEvent.ContentData = payload
 	svc.db.Save(&requestEvent) // we ignore potential DB errors here as this only saves the method and content data
 
-	// TODO: replace with a channel
-	// TODO: update all previous occurences of svc.publishResponseEvent to also use the channel
+	// responses are published asynchronously and multi_pay requests publish several responses,
+	// so the state of the request event can be saved from different goroutines
+	var requestEventMtx sync.Mutex
+	saveRequestEventState := func(state string) {
+		requestEventMtx.Lock()
+		defer requestEventMtx.Unlock()
+		requestEvent.State = state
+		err := svc.saveRequestEvent(&requestEvent)
+		if err != nil {
+			logger.Nostr.WithFields(logrus.Fields{
+				"nostrPubkey": event.PubKey,
+			}).WithError(err).Error("Failed to save state to nostr event")
+		}
+	}
+
+	// set for payment requests before the payments are made, one per payment
+	var outboxIntents []*db.OutboxResponse
+
 	publishResponse := func(nip47Response *models.Response, tags nostr.Tags) {
-		resp, err := svc.CreateResponse(event, nip47Response, tags, ss)
+		timer.startPhase(REQUEST_PHASE_PUBLISH)
+		requestEventMtx.Lock()
+		setRequestEventResponse(&requestEvent, nip47Response)
+		requestEventMtx.Unlock()
+		resp, err := svc.createResponse(event, nip47Response, tags, cipher)
 		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"requestEventNostrId": event.ID,
 				"eventKind":           event.Kind,
 				"appId":               app.ID,
 			}).WithError(err).Error("Failed to create response")
-			requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_ERROR
+			saveRequestEventState(db.REQUEST_EVENT_STATE_HANDLER_ERROR)
+			return
+		}
+
+		// the response is stored before it is published, so that it is still published if the hub stops in between
+		requestEventMtx.Lock()
+		savedOutboxResponse, err := svc.saveOutboxResponse(&requestEvent, findOutboxIntent(outboxIntents, tags), resp)
+		requestEventMtx.Unlock()
+		outboxResponseId := uint(0)
+		if err != nil {
+			logger.Nostr.WithFields(logrus.Fields{
+				"requestEventNostrId": event.ID,
+				"appId":               app.ID,
+			}).WithError(err).Error("Failed to save response to the outbox")
 		} else {
-			err = svc.publishResponseEvent(ctx, relay, &requestEvent, resp, &app)
+			outboxResponseId = savedOutboxResponse.ID
+		}
+
+		svc.queueOutboxResponse(ctx, relay, &requestEvent, resp, &app, outboxResponseId, func(err error) {
+			timer.finish(nip47Request.Method, logrus.Fields{
+				"requestEventNostrId": event.ID,
+				"appId":               app.ID,
+			}, time.Duration(svc.cfg.GetEnv().Nip47SlowRequestMs)*time.Millisecond)
 			if err != nil {
-				logger.Logger.WithFields(logrus.Fields{
+				logger.Nostr.WithFields(logrus.Fields{
 					"requestEventNostrId": event.ID,
 					"eventKind":           event.Kind,
 					"appId":               app.ID,
 				}).WithError(err).Error("Failed to publish event")
-				requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_ERROR
-			} else {
-				requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_EXECUTED
-				logger.Logger.WithFields(logrus.Fields{
-					"requestEventNostrId": event.ID,
-					"eventKind":           event.Kind,
-					"appId":               app.ID,
-				}).Info("Published response")
+				saveRequestEventState(db.REQUEST_EVENT_STATE_HANDLER_ERROR)
+				return
 			}
-		}
-		err = svc.db.Save(&requestEvent).Error
-		if err != nil {
-			logger.Logger.WithFields(logrus.Fields{
-				"nostrPubkey": event.PubKey,
-			}).WithError(err).Error("Failed to save state to nostr event")
-		}
+			logger.Nostr.WithFields(logrus.Fields{
+				"requestEventNostrId": event.ID,
+				"eventKind":           event.Kind,
+				"appId":               app.ID,
+			}).Info("Published response")
+			saveRequestEventState(db.REQUEST_EVENT_STATE_HANDLER_EXECUTED)
+		})
 	}
 
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"requestEventNostrId": event.ID,
 		"eventKind":           event.Kind,
 		"appId":               app.ID,
@@ -266,6 +362,50 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 		"params":              nip47Request.Params,
 	}).Info("Handling NIP-47 request")
 
+	// the request event table only protects against events that were seen before
+	if err := svc.checkEventTimestamps(event, receivedAt(ctx)); err != nil {
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventNostrId": event.ID,
+			"appId":               app.ID,
+			"createdAt":           event.CreatedAt,
+		}).WithError(err).Warn("Rejected request event outside of the allowed time window")
+		publishResponse(&models.Response{
+			ResultType: nip47Request.Method,
+			Error: &models.Error{
+				Code:    models.ERROR_EXPIRED,
+				Message: err.Error(),
+			},
+		}, nostr.Tags{})
+		return
+	}
+
+	// the dispatcher already held the request back while the relay could not keep up
+	if !isHttpResponseRelay(relay) && !svc.responseQueue.hasCapacity() {
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventNostrId": event.ID,
+			"appId":               app.ID,
+		}).Warn("Rejected request event because the response queue is full")
+		publishResponse(&models.Response{
+			ResultType: nip47Request.Method,
+			Error: &models.Error{
+				Code:    models.ERROR_RATE_LIMITED,
+				Message: "Too many responses are waiting to be published, please try again later",
+			},
+		}, nostr.Tags{})
+		return
+	}
+
+	if err := svc.checkRateLimit(app.ID); err != nil {
+		publishResponse(&models.Response{
+			ResultType: nip47Request.Method,
+			Error: &models.Error{
+				Code:    models.ERROR_RATE_LIMITED,
+				Message: err.Error(),
+			},
+		}, nostr.Tags{})
+		return
+	}
+
 	if nip47Request.Method != models.GET_INFO_METHOD {
 		scope, err := permissions.RequestMethodToScope(nip47Request.Method)
 		if err != nil {
@@ -280,7 +420,7 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 		}
 		hasPermission, code, message := svc.permissionsService.HasPermission(&app, scope)
 		if !hasPermission {
-			logger.Logger.WithFields(logrus.Fields{
+			logger.Nostr.WithFields(logrus.Fields{
 				"request_event_id": requestEvent.ID,
 				"app_id":           app.ID,
 				"code":             code,
@@ -309,15 +449,47 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 		}
 	}
 
-	controller := controllers.NewNip47Controller(lnClient, svc.db, svc.eventPublisher, svc.permissionsService, svc.transactionsService)
+	// the payment is only made once the request is in the outbox, so that it is answered even if the hub stops during the payment
+	if slices.Contains(singlePaymentMethods, nip47Request.Method) {
+		outboxIntent, err := svc.createOutboxIntent(&requestEvent, event)
+		if err != nil {
+			logger.Nostr.WithFields(logrus.Fields{
+				"requestEventNostrId": event.ID,
+				"appId":               app.ID,
+			}).WithError(err).Error("Failed to save request to the outbox")
+			publishResponse(&models.Response{
+				ResultType: nip47Request.Method,
+				Error: &models.Error{
+					Code:    models.ERROR_INTERNAL,
+					Message: fmt.Sprintf("Failed to save request: %s", err.Error()),
+				},
+			}, nostr.Tags{})
+			return
+		}
+		outboxIntents = []*db.OutboxResponse{outboxIntent}
+	}
+	// multi_pay requests store their payments once the elements are decoded
+	saveBatch := func(elements []controllers.MultiPayElement) error {
+		batchIntents, err := svc.createOutboxBatch(&requestEvent, event, elements)
+		if err != nil {
+			return err
+		}
+		requestEventMtx.Lock()
+		outboxIntents = batchIntents
+		requestEventMtx.Unlock()
+		return nil
+	}
+
+	timer.startPhase(REQUEST_PHASE_BACKEND)
+	controller := controllers.NewNip47Controller(lnClient, svc.db, svc.cfg, svc.eventPublisher, svc.permissionsService, svc.transactionsService)
 
 	switch nip47Request.Method {
 	case models.MULTI_PAY_INVOICE_METHOD:
 		controller.
-			HandleMultiPayInvoiceEvent(ctx, nip47Request, requestEvent.ID, &app, publishResponse)
+			HandleMultiPayInvoiceEvent(ctx, nip47Request, requestEvent.ID, &app, publishResponse, saveBatch)
 	case models.MULTI_PAY_KEYSEND_METHOD:
 		controller.
-			HandleMultiPayKeysendEvent(ctx, nip47Request, requestEvent.ID, &app, publishResponse)
+			HandleMultiPayKeysendEvent(ctx, nip47Request, requestEvent.ID, &app, publishResponse, saveBatch)
 	case models.PAY_INVOICE_METHOD:
 		controller.
 			HandlePayInvoiceEvent(ctx, nip47Request, requestEvent.ID, &app, publishResponse, nostr.Tags{})
@@ -330,6 +502,9 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 	case models.MAKE_INVOICE_METHOD:
 		controller.
 			HandleMakeInvoiceEvent(ctx, nip47Request, requestEvent.ID, app.ID, publishResponse)
+	case models.MAKE_OFFER_METHOD:
+		controller.
+			HandleMakeOfferEvent(ctx, nip47Request, requestEvent.ID, app.ID, publishResponse)
 	case models.LOOKUP_INVOICE_METHOD:
 		controller.
 			HandleLookupInvoiceEvent(ctx, nip47Request, requestEvent.ID, app.ID, publishResponse)
@@ -353,27 +528,37 @@ func (svc *nip47Service) HandleEvent(ctx context.Context, relay nostrmodels.Rela
 	}
 }
 
+// CreateResponse creates a version 0.0 response encrypted with the NIP-04 shared secret
 func (svc *nip47Service) CreateResponse(initialEvent *nostr.Event, content interface{}, tags nostr.Tags, ss []byte) (result *nostr.Event, err error) {
+	return svc.createResponse(initialEvent, content, tags, &nip04Cipher{sharedSecret: ss})
+}
+
+func (svc *nip47Service) createResponse(initialEvent *nostr.Event, content interface{}, tags nostr.Tags, cipher nip47Cipher) (result *nostr.Event, err error) {
 	payloadBytes, err := json.Marshal(content)
 	if err != nil {
 		return nil, err
 	}
-	msg, err := nip04.Encrypt(string(payloadBytes), ss)
+	msg, err := cipher.Encrypt(string(payloadBytes))
 	if err != nil {
 		return nil, err
 	}
 
 	allTags := nostr.Tags{[]string{"p", initialEvent.PubKey}, []string{"e", initialEvent.ID}}
+	// version 0.0 responses stay untagged for apps that do not know about versions
+	if cipher.Version() != models.VERSION_0_0 {
+		allTags = append(allTags, []string{"v", cipher.Version()})
+	}
 	allTags = append(allTags, tags...)
 
+	// requests are answered by the key they were sent to,
+	// which can be the previous key for a while after a key rotation
 	resp := &nostr.Event{
-		PubKey:    svc.keys.GetNostrPublicKey(),
 		CreatedAt: nostr.Now(),
 		Kind:      models.RESPONSE_KIND,
 		Tags:      allTags,
 		Content:   msg,
 	}
-	err = resp.Sign(svc.keys.GetNostrSecretKey())
+	err = resp.Sign(svc.keys.GetNostrSecretKeyFor(walletPubkey(initialEvent)))
 	if err != nil {
 		return nil, err
 	}
@@ -385,10 +570,11 @@ func (svc *nip47Service) publishResponseEvent(ctx context.Context, relay nostrmo
 	if app != nil {
 		appId = &app.ID
 	}
+	// a response that is published again from the outbox was saved before
 	responseEvent := db.ResponseEvent{NostrId: resp.ID, RequestId: requestEvent.ID, State: "received"}
-	err := svc.db.Create(&responseEvent).Error
+	err := svc.db.Where(&db.ResponseEvent{NostrId: resp.ID}).FirstOrCreate(&responseEvent).Error
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventNostrId": requestEvent.NostrId,
 			"appId":               appId,
 			"replyEventId":        resp.ID,
@@ -396,20 +582,22 @@ func (svc *nip47Service) publishResponseEvent(ctx context.Context, relay nostrmo
 		return err
 	}
 
-	err = relay.Publish(ctx, *resp)
-	if err != nil {
+	publishStart := time.Now()
+	publishErr := relay.Publish(ctx, *resp)
+	relayPublishDuration.Observe(time.Since(publishStart).Seconds())
+	if publishErr != nil {
 		responseEvent.State = db.RESPONSE_EVENT_STATE_PUBLISH_FAILED
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventId":       requestEvent.ID,
 			"requestNostrEventId":  requestEvent.NostrId,
 			"appId":                appId,
 			"responseEventId":      responseEvent.ID,
 			"responseNostrEventId": resp.ID,
-		}).WithError(err).Error("Failed to publish reply")
+		}).WithError(publishErr).Error("Failed to publish reply")
 	} else {
 		responseEvent.State = db.RESPONSE_EVENT_STATE_PUBLISH_CONFIRMED
 		responseEvent.RepliedAt = time.Now()
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventId":       requestEvent.ID,
 			"requestNostrEventId":  requestEvent.NostrId,
 			"appId":                appId,
@@ -420,7 +608,7 @@ func (svc *nip47Service) publishResponseEvent(ctx context.Context, relay nostrmo
 
 	err = svc.db.Save(&responseEvent).Error
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"requestEventId":       requestEvent.ID,
 			"requestNostrEventId":  requestEvent.NostrId,
 			"appId":                appId,
@@ -430,5 +618,14 @@ func (svc *nip47Service) publishResponseEvent(ctx context.Context, relay nostrmo
 		return err
 	}
 
-	return nil
+	// the response stays in the outbox to be published again
+	return publishErr
+}
+
+func walletPubkey(event *nostr.Event) string {
+	pTag := event.Tags.GetFirst([]string{"p"})
+	if pTag == nil {
+		return ""
+	}
+	return pTag.Value()
 }
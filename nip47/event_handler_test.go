@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/getAlby/hub/constants"
 	"github.com/getAlby/hub/db"
@@ -114,6 +115,7 @@ func TestHandleResponse_WithPermission(t *testing.T) {
 	relay := tests.NewMockRelay()
 
 	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
 
 	assert.NotNil(t, relay.PublishedEvent)
 	assert.NotEmpty(t, relay.PublishedEvent.Content)
@@ -169,6 +171,7 @@ func TestHandleResponse_NoPermission(t *testing.T) {
 	relay := tests.NewMockRelay()
 
 	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
 
 	assert.NotNil(t, relay.PublishedEvent)
 	assert.NotEmpty(t, relay.PublishedEvent.Content)
@@ -187,6 +190,15 @@ func TestHandleResponse_NoPermission(t *testing.T) {
 	assert.Equal(t, models.GET_BALANCE_METHOD, unmarshalledResponse.ResultType)
 	assert.Equal(t, "RESTRICTED", unmarshalledResponse.Error.Code)
 	assert.Equal(t, "This app does not have the get_balance scope", unmarshalledResponse.Error.Message)
+
+	// the error is kept for the request inspector of the app
+	requestEvent := db.RequestEvent{}
+	err = svc.DB.First(&requestEvent, &db.RequestEvent{NostrId: reqEvent.ID}).Error
+	assert.NoError(t, err)
+	assert.Equal(t, models.GET_BALANCE_METHOD, requestEvent.Method)
+	assert.Equal(t, "RESTRICTED", requestEvent.ErrorCode)
+	assert.Equal(t, "This app does not have the get_balance scope", requestEvent.ErrorMessage)
+	assert.NotNil(t, requestEvent.RespondedAt)
 }
 
 func TestHandleResponse_NoApp(t *testing.T) {
@@ -229,6 +241,7 @@ func TestHandleResponse_NoApp(t *testing.T) {
 	relay := tests.NewMockRelay()
 
 	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
 
 	assert.NotNil(t, relay.PublishedEvent)
 	assert.NotEmpty(t, relay.PublishedEvent.Content)
@@ -300,6 +313,132 @@ func TestHandleResponse_IncorrectPubkey(t *testing.T) {
 	relay := tests.NewMockRelay()
 
 	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
 
 	assert.Nil(t, relay.PublishedEvent)
 }
+
+func TestHandleResponse_StaleEvent(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().Nip47RequestMaxAgeSecs = 600
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	reqPubkey, err := nostr.GetPublicKey(reqPrivateKey)
+	assert.NoError(t, err)
+
+	app, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	appPermission := &db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.GET_BALANCE_SCOPE,
+	}
+	err = svc.DB.Create(appPermission).Error
+	assert.NoError(t, err)
+
+	payloadBytes, err := json.Marshal(map[string]interface{}{
+		"method": models.GET_INFO_METHOD,
+	})
+	assert.NoError(t, err)
+
+	for _, createdAt := range []time.Time{time.Now().Add(-time.Hour), time.Now().Add(time.Hour)} {
+		msg, err := nip04.Encrypt(string(payloadBytes), ss)
+		assert.NoError(t, err)
+
+		reqEvent := &nostr.Event{
+			Kind:      models.REQUEST_KIND,
+			PubKey:    reqPubkey,
+			CreatedAt: nostr.Timestamp(createdAt.Unix()),
+			Tags:      nostr.Tags{},
+			Content:   msg,
+		}
+		err = reqEvent.Sign(reqPrivateKey)
+		assert.NoError(t, err)
+
+		relay := tests.NewMockRelay()
+		nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+		nip47svc.WaitForPublishedResponses()
+		assert.NotNil(t, relay.PublishedEvent)
+
+		responseSharedSecret, err := nip04.ComputeSharedSecret(svc.Keys.GetNostrPublicKey(), reqPrivateKey)
+		assert.NoError(t, err)
+		decrypted, err := nip04.Decrypt(relay.PublishedEvent.Content, responseSharedSecret)
+		assert.NoError(t, err)
+
+		unmarshalledResponse := models.Response{}
+		err = json.Unmarshal([]byte(decrypted), &unmarshalledResponse)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ERROR_EXPIRED, unmarshalledResponse.Error.Code)
+		assert.Nil(t, unmarshalledResponse.Result)
+
+		requestEvent := db.RequestEvent{}
+		err = svc.DB.First(&requestEvent, &db.RequestEvent{NostrId: reqEvent.ID}).Error
+		assert.NoError(t, err)
+		assert.Equal(t, db.REQUEST_EVENT_STATE_HANDLER_EXECUTED, requestEvent.State)
+	}
+}
+
+func TestHandleResponse_PreviousKeyAfterRotation(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	reqPubkey, err := nostr.GetPublicKey(reqPrivateKey)
+	assert.NoError(t, err)
+
+	// the app was connected before the rotation
+	app, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+	previousPubkey := svc.Keys.GetNostrPublicKey()
+
+	appPermission := &db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.GET_BALANCE_SCOPE,
+	}
+	err = svc.DB.Create(appPermission).Error
+	assert.NoError(t, err)
+
+	err = svc.Keys.Rotate()
+	assert.NoError(t, err)
+
+	payloadBytes, err := json.Marshal(map[string]interface{}{
+		"method": models.GET_INFO_METHOD,
+	})
+	assert.NoError(t, err)
+
+	msg, err := nip04.Encrypt(string(payloadBytes), ss)
+	assert.NoError(t, err)
+
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		PubKey:    reqPubkey,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{[]string{"p", previousPubkey}},
+		Content:   msg,
+	}
+	err = reqEvent.Sign(reqPrivateKey)
+	assert.NoError(t, err)
+
+	relay := tests.NewMockRelay()
+	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+
+	assert.NotNil(t, relay.PublishedEvent)
+	assert.Equal(t, previousPubkey, relay.PublishedEvent.PubKey)
+
+	decrypted, err := nip04.Decrypt(relay.PublishedEvent.Content, ss)
+	assert.NoError(t, err)
+
+	unmarshalledResponse := models.Response{}
+	err = json.Unmarshal([]byte(decrypted), &unmarshalledResponse)
+	assert.NoError(t, err)
+	assert.Nil(t, unmarshalledResponse.Error)
+	assert.Equal(t, models.GET_INFO_METHOD, unmarshalledResponse.ResultType)
+}
@@ -0,0 +1,50 @@
+package nip47
+
+import (
+	"context"
+	"sync"
+
+	"github.com/getAlby/hub/lnclient"
+	nostrmodels "github.com/getAlby/hub/nostr/models"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+// collectingRelay keeps the events that are published to it
+type collectingRelay struct {
+	mu     sync.Mutex
+	events []nostr.Event
+}
+
+func (relay *collectingRelay) Publish(ctx context.Context, event nostr.Event) error {
+	relay.mu.Lock()
+	defer relay.mu.Unlock()
+	relay.events = append(relay.events, event)
+	return nil
+}
+
+func (relay *collectingRelay) publishedEvents() []nostr.Event {
+	relay.mu.Lock()
+	defer relay.mu.Unlock()
+	return append([]nostr.Event{}, relay.events...)
+}
+
+// httpResponseRelay keeps the responses of a request that was sent over HTTP instead of a relay.
+// Its responses are returned to the client directly, they do not wait in the response queue behind the responses of the relay.
+type httpResponseRelay struct {
+	collectingRelay
+}
+
+func isHttpResponseRelay(relay nostrmodels.Relay) bool {
+	_, ok := relay.(*httpResponseRelay)
+	return ok
+}
+
+// HandleEventSync handles a request event like HandleEvent and returns its response events instead of publishing them.
+// No responses are returned for events that are not answered on a relay either (e.g. with an invalid signature).
+func (svc *nip47Service) HandleEventSync(ctx context.Context, event *nostr.Event, lnClient lnclient.LNClient) []nostr.Event {
+	relay := &httpResponseRelay{}
+	// a payment must not be cancelled halfway when the client disconnects
+	svc.HandleEvent(context.WithoutCancel(ctx), relay, event, lnClient)
+	// the responses are published to the relay before HandleEvent returns
+	return relay.publishedEvents()
+}
@@ -0,0 +1,143 @@
+package nip47
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleEventSync(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	app, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.GET_BALANCE_SCOPE,
+	}).Error
+	assert.NoError(t, err)
+
+	payloadBytes, err := json.Marshal(map[string]interface{}{
+		"method": models.GET_BALANCE_METHOD,
+	})
+	assert.NoError(t, err)
+	msg, err := nip04.Encrypt(string(payloadBytes), ss)
+	assert.NoError(t, err)
+
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{[]string{"p", svc.Keys.GetNostrPublicKey()}},
+		Content:   msg,
+	}
+	err = reqEvent.Sign(reqPrivateKey)
+	assert.NoError(t, err)
+
+	responses := nip47svc.HandleEventSync(context.TODO(), reqEvent, svc.LNClient)
+	assert.Equal(t, 1, len(responses))
+	assert.Equal(t, reqEvent.ID, responses[0].Tags.GetFirst([]string{"e"}).Value())
+
+	decrypted, err := nip04.Decrypt(responses[0].Content, ss)
+	assert.NoError(t, err)
+	response := models.Response{}
+	err = json.Unmarshal([]byte(decrypted), &response)
+	assert.NoError(t, err)
+	assert.Nil(t, response.Error)
+	assert.Equal(t, models.GET_BALANCE_METHOD, response.ResultType)
+
+	// a request is only handled once, and answered again with the same response
+	replayedResponses := nip47svc.HandleEventSync(context.TODO(), reqEvent, svc.LNClient)
+	assert.Equal(t, 1, len(replayedResponses))
+	assert.Equal(t, responses[0].ID, replayedResponses[0].ID)
+}
+
+func TestHandleEventSync_InvalidSignature(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{},
+		Content:   "invalid",
+	}
+	err = reqEvent.Sign(nostr.GeneratePrivateKey())
+	assert.NoError(t, err)
+	reqEvent.Content = "tampered"
+
+	responses := nip47svc.HandleEventSync(context.TODO(), reqEvent, svc.LNClient)
+	assert.Empty(t, responses)
+}
+
+func TestHandleEventSync_SlowRelay(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().Nip47ResponseQueueSize = 1
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	app, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.GET_BALANCE_SCOPE,
+	}).Error
+	assert.NoError(t, err)
+
+	// the responses of the relay are stuck behind a slow publish
+	slowRelay := &blockingRelay{release: make(chan struct{})}
+	defer close(slowRelay.release)
+	for _, nostrId := range []string{"slow1", "slow2"} {
+		requestEvent := &db.RequestEvent{NostrId: nostrId}
+		assert.NoError(t, svc.DB.Create(requestEvent).Error)
+		nip47svc.queueResponse(context.TODO(), slowRelay, requestEvent, &nostr.Event{ID: nostrId}, nil, nil)
+		if nostrId == "slow1" {
+			assert.Eventually(t, nip47svc.responseQueue.hasCapacity, time.Second, time.Millisecond)
+		}
+	}
+	assert.False(t, nip47svc.responseQueue.hasCapacity())
+
+	payloadBytes, err := json.Marshal(map[string]interface{}{
+		"method": models.GET_BALANCE_METHOD,
+	})
+	assert.NoError(t, err)
+	msg, err := nip04.Encrypt(string(payloadBytes), ss)
+	assert.NoError(t, err)
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{[]string{"p", svc.Keys.GetNostrPublicKey()}},
+		Content:   msg,
+	}
+	err = reqEvent.Sign(reqPrivateKey)
+	assert.NoError(t, err)
+
+	// the response of the HTTP request does not wait for the relay
+	responses := nip47svc.HandleEventSync(context.TODO(), reqEvent, svc.LNClient)
+	assert.Equal(t, 1, len(responses))
+	decrypted, err := nip04.Decrypt(responses[0].Content, ss)
+	assert.NoError(t, err)
+	response := models.Response{}
+	err = json.Unmarshal([]byte(decrypted), &response)
+	assert.NoError(t, err)
+	assert.Nil(t, response.Error)
+	assert.Equal(t, models.GET_BALANCE_METHOD, response.ResultType)
+}
@@ -0,0 +1,147 @@
+package nip47
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sirupsen/logrus"
+)
+
+// the phases of handling a request, in order
+const (
+	// saving the request event and finding its app
+	REQUEST_PHASE_STORE = "store"
+	// decrypting and parsing the request
+	REQUEST_PHASE_DECODE = "decode"
+	// checking the age of the event, the response queue and the permissions of the app
+	REQUEST_PHASE_PERMISSIONS = "permissions"
+	// handling the method, e.g. paying the invoice with the lightning backend
+	REQUEST_PHASE_BACKEND = "backend"
+	// waiting in the response queue, saving the response event and publishing it to the relay
+	REQUEST_PHASE_PUBLISH = "publish"
+)
+
+// from 5ms up to about 80s, payments can take a long time
+var latencyBuckets = prometheus.ExponentialBuckets(0.005, 2, 15)
+
+var (
+	requestPhaseDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "albyhub_nip47_request_phase_duration_seconds",
+		Help:    "Duration of the phases of handling NIP-47 requests",
+		Buckets: latencyBuckets,
+	}, []string{"method", "phase"})
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "albyhub_nip47_request_duration_seconds",
+		Help:    "Duration of handling NIP-47 requests, from receiving the request until the response is published",
+		Buckets: latencyBuckets,
+	}, []string{"method"})
+	slowRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "albyhub_nip47_slow_requests_total",
+		Help: "NIP-47 requests that took longer than NIP47_SLOW_REQUEST_MS",
+	}, []string{"method"})
+	relayPublishDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "albyhub_nostr_relay_publish_duration_seconds",
+		Help:    "Duration of publishing NIP-47 responses to the relay",
+		Buckets: latencyBuckets,
+	})
+)
+
+// the method is chosen by the app, every unknown method would create new time series
+var metricsMethods = []string{
+	models.PAY_INVOICE_METHOD,
+	models.GET_BALANCE_METHOD,
+	models.GET_INFO_METHOD,
+	models.MAKE_INVOICE_METHOD,
+	models.LOOKUP_INVOICE_METHOD,
+	models.LIST_TRANSACTIONS_METHOD,
+	models.PAY_KEYSEND_METHOD,
+	models.MULTI_PAY_INVOICE_METHOD,
+	models.MULTI_PAY_KEYSEND_METHOD,
+	models.SIGN_MESSAGE_METHOD,
+	models.MAKE_OFFER_METHOD,
+}
+
+type requestPhase struct {
+	name     string
+	duration time.Duration
+}
+
+// requestTimer measures the phases of a request. Requests with several responses
+// (multi_pay_invoice, multi_pay_keysend) are measured until the first response is published.
+type requestTimer struct {
+	mu         sync.Mutex
+	start      time.Time
+	phase      string
+	phaseStart time.Time
+	phases     []requestPhase
+	finished   bool
+}
+
+func newRequestTimer(phase string) *requestTimer {
+	now := time.Now()
+	return &requestTimer{
+		start:      now,
+		phase:      phase,
+		phaseStart: now,
+	}
+}
+
+// startPhase ends the current phase
+func (timer *requestTimer) startPhase(phase string) {
+	timer.mu.Lock()
+	defer timer.mu.Unlock()
+	if timer.finished || timer.phase == phase {
+		return
+	}
+	timer.endPhase()
+	timer.phase = phase
+}
+
+func (timer *requestTimer) endPhase() {
+	now := time.Now()
+	timer.phases = append(timer.phases, requestPhase{name: timer.phase, duration: now.Sub(timer.phaseStart)})
+	timer.phaseStart = now
+}
+
+// finish records the metrics of the request and logs it if it took longer than slowThreshold
+func (timer *requestTimer) finish(method string, fields logrus.Fields, slowThreshold time.Duration) {
+	timer.mu.Lock()
+	defer timer.mu.Unlock()
+	if timer.finished {
+		return
+	}
+	timer.finished = true
+	timer.endPhase()
+
+	methodLabel := method
+	if !slices.Contains(metricsMethods, method) {
+		methodLabel = "unknown"
+	}
+
+	total := time.Since(timer.start)
+	requestDuration.WithLabelValues(methodLabel).Observe(total.Seconds())
+	for _, phase := range timer.phases {
+		requestPhaseDuration.WithLabelValues(methodLabel, phase.name).Observe(phase.duration.Seconds())
+	}
+
+	if slowThreshold <= 0 || total < slowThreshold {
+		return
+	}
+	slowRequests.WithLabelValues(methodLabel).Inc()
+	logFields := logrus.Fields{
+		"method":     method,
+		"durationMs": total.Milliseconds(),
+	}
+	for key, value := range fields {
+		logFields[key] = value
+	}
+	for _, phase := range timer.phases {
+		logFields[phase.name+"Ms"] = phase.duration.Milliseconds()
+	}
+	logger.Nostr.WithFields(logFields).Warn("Slow NIP-47 request")
+}
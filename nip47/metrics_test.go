@@ -0,0 +1,39 @@
+package nip47
+
+import (
+	"testing"
+
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestTimer(t *testing.T) {
+	timer := newRequestTimer(REQUEST_PHASE_STORE)
+	timer.startPhase(REQUEST_PHASE_DECODE)
+	timer.startPhase(REQUEST_PHASE_BACKEND)
+	// a second response of a multi_pay request
+	timer.startPhase(REQUEST_PHASE_BACKEND)
+	timer.startPhase(REQUEST_PHASE_PUBLISH)
+
+	slowRequestsBefore := testutil.ToFloat64(slowRequests.WithLabelValues(models.MULTI_PAY_INVOICE_METHOD))
+	// every request is slow with a threshold of 1ns
+	timer.finish(models.MULTI_PAY_INVOICE_METHOD, nil, 1)
+	timer.finish(models.MULTI_PAY_INVOICE_METHOD, nil, 1)
+	timer.startPhase(REQUEST_PHASE_PUBLISH)
+
+	phaseNames := []string{}
+	for _, phase := range timer.phases {
+		phaseNames = append(phaseNames, phase.name)
+	}
+	assert.Equal(t, []string{REQUEST_PHASE_STORE, REQUEST_PHASE_DECODE, REQUEST_PHASE_BACKEND, REQUEST_PHASE_PUBLISH}, phaseNames)
+	assert.Equal(t, slowRequestsBefore+1, testutil.ToFloat64(slowRequests.WithLabelValues(models.MULTI_PAY_INVOICE_METHOD)))
+}
+
+func TestRequestTimer_UnknownMethod(t *testing.T) {
+	slowRequestsBefore := testutil.ToFloat64(slowRequests.WithLabelValues("unknown"))
+	timer := newRequestTimer(REQUEST_PHASE_STORE)
+	timer.finish("mine_bitcoin", nil, 1)
+
+	assert.Equal(t, slowRequestsBefore+1, testutil.ToFloat64(slowRequests.WithLabelValues("unknown")))
+}
@@ -10,6 +10,10 @@ const (
 	RESPONSE_KIND     = 23195
 	NOTIFICATION_KIND = 23196
 
+	// requests without a version tag are version 0.0
+	VERSION_0_0 = "0.0" // NIP-04 encryption
+	VERSION_1_0 = "1.0" // NIP-44 encryption
+
 	// request methods
 	PAY_INVOICE_METHOD       = "pay_invoice"
 	GET_BALANCE_METHOD       = "get_balance"
@@ -21,6 +25,7 @@ const (
 	MULTI_PAY_INVOICE_METHOD = "multi_pay_invoice"
 	MULTI_PAY_KEYSEND_METHOD = "multi_pay_keysend"
 	SIGN_MESSAGE_METHOD      = "sign_message"
+	MAKE_OFFER_METHOD        = "make_offer"
 
 	ERROR_INTERNAL             = "INTERNAL"
 	ERROR_NOT_IMPLEMENTED      = "NOT_IMPLEMENTED"
@@ -31,9 +36,14 @@ const (
 	ERROR_RESTRICTED           = "RESTRICTED"
 	ERROR_BAD_REQUEST          = "BAD_REQUEST"
 	ERROR_NOT_FOUND            = "NOT_FOUND"
+	ERROR_RATE_LIMITED         = "RATE_LIMITED"
+	ERROR_UNSUPPORTED_VERSION  = "UNSUPPORTED_VERSION"
 	OTHER                      = "OTHER"
 )
 
+// SUPPORTED_VERSIONS are advertised in the info event, the newest first
+var SUPPORTED_VERSIONS = []string{VERSION_1_0, VERSION_0_0}
+
 type Transaction struct {
 	Type            string      `json:"type"`
 	Invoice         string      `json:"invoice"`
@@ -27,7 +27,7 @@ func ToNip47Transaction(transaction *transactions.Transaction) *Transaction {
 	if transaction.Metadata != "" {
 		jsonErr := json.Unmarshal([]byte(transaction.Metadata), &metadata)
 		if jsonErr != nil {
-			logger.Logger.WithError(jsonErr).WithFields(logrus.Fields{
+			logger.Nostr.WithError(jsonErr).WithFields(logrus.Fields{
 				"id":       transaction.ID,
 				"metadata": transaction.Metadata,
 			}).Error("Failed to deserialize transaction metadata")
@@ -2,6 +2,8 @@ package nip47
 
 import (
 	"context"
+	"sync"
+	"time"
 
 	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/events"
@@ -23,33 +25,55 @@ type nip47Service struct {
 	keys                   keys.Keys
 	db                     *gorm.DB
 	eventPublisher         events.EventPublisher
+	responseQueue          *responseQueue
+	// guards relay, so that a response is either left in the outbox before RecoverResponses loads it
+	// or published to the relay that RecoverResponses was called for
+	relayMu sync.Mutex
+	// the relay of the current subscription
+	relay nostrmodels.Relay
+	// outbox responses of requests received before are not handled by this process anymore
+	startedAt time.Time
 }
 
 type Nip47Service interface {
 	events.EventSubscriber
-	StartNotifier(ctx context.Context, relay *nostr.Relay, lnClient lnclient.LNClient)
+	StartNotifier(ctx context.Context, relay nostrmodels.Relay, lnClient lnclient.LNClient)
 	HandleEvent(ctx context.Context, relay nostrmodels.Relay, event *nostr.Event, lnClient lnclient.LNClient)
+	HandleEventSync(ctx context.Context, event *nostr.Event, lnClient lnclient.LNClient) []nostr.Event
 	PublishNip47Info(ctx context.Context, relay nostrmodels.Relay, lnClient lnclient.LNClient) error
 	CreateResponse(initialEvent *nostr.Event, content interface{}, tags nostr.Tags, ss []byte) (result *nostr.Event, err error)
+	// RecoverResponses publishes the responses left in the outbox, e.g. after a restart
+	RecoverResponses(ctx context.Context, relay nostrmodels.Relay)
+	DeferWhileResponseQueueFull(ctx context.Context) bool
+	WaitForPublishedResponses()
 }
 
 func NewNip47Service(db *gorm.DB, cfg config.Config, keys keys.Keys, eventPublisher events.EventPublisher) *nip47Service {
-	return &nip47Service{
+	svc := &nip47Service{
 		nip47NotificationQueue: notifications.NewNip47NotificationQueue(),
 		cfg:                    cfg,
 		db:                     db,
 		permissionsService:     permissions.NewPermissionsService(db, eventPublisher),
-		transactionsService:    transactions.NewTransactionsService(db),
+		transactionsService:    transactions.NewTransactionsService(db, cfg, eventPublisher),
 		eventPublisher:         eventPublisher,
 		keys:                   keys,
+		responseQueue:          newResponseQueue(cfg.GetEnv().Nip47ResponseQueueSize),
+		startedAt:              time.Now(),
 	}
+	if cfg.GetEnv().LeaderElection {
+		svc.permissionsService = permissions.NewUncachedPermissionsService(db, eventPublisher)
+	}
+	go svc.publishQueuedResponses()
+	return svc
 }
 
 func (svc *nip47Service) ConsumeEvent(ctx context.Context, event *events.Event, globalProperties map[string]interface{}) {
+	svc.permissionsService.ConsumeEvent(ctx, event, globalProperties)
 	svc.nip47NotificationQueue.AddToQueue(event)
 }
 
-func (svc *nip47Service) StartNotifier(ctx context.Context, relay *nostr.Relay, lnClient lnclient.LNClient) {
+func (svc *nip47Service) StartNotifier(ctx context.Context, relay nostrmodels.Relay, lnClient lnclient.LNClient) {
+	// the notifications feature can be enabled for single apps, so it is checked for every app
 	nip47Notifier := notifications.NewNip47Notifier(relay, svc.db, svc.cfg, svc.keys, svc.permissionsService, svc.transactionsService, lnClient)
 	go func() {
 		for {
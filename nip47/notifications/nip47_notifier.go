@@ -51,14 +51,14 @@ func (notifier *Nip47Notifier) ConsumeEvent(ctx context.Context, event *events.E
 	case "nwc_payment_received":
 		lnClientTransaction, ok := event.Properties.(*lnclient.Transaction)
 		if !ok {
-			logger.Logger.WithField("event", event).Error("Failed to cast event")
+			logger.Nostr.WithField("event", event).Error("Failed to cast event")
 			return
 		}
 
 		transactionType := constants.TRANSACTION_TYPE_INCOMING
 		transaction, err := notifier.transactionsService.LookupTransaction(ctx, lnClientTransaction.PaymentHash, &transactionType, notifier.lnClient, nil)
 		if err != nil {
-			logger.Logger.
+			logger.Nostr.
 				WithField("paymentHash", lnClientTransaction.PaymentHash).
 				WithError(err).
 				Error("Failed to lookup transaction by payment hash")
@@ -76,14 +76,14 @@ func (notifier *Nip47Notifier) ConsumeEvent(ctx context.Context, event *events.E
 	case "nwc_payment_sent":
 		paymentSentEventProperties, ok := event.Properties.(*lnclient.Transaction)
 		if !ok {
-			logger.Logger.WithField("event", event).Error("Failed to cast event")
+			logger.Nostr.WithField("event", event).Error("Failed to cast event")
 			return
 		}
 
 		transactionType := constants.TRANSACTION_TYPE_OUTGOING
 		transaction, err := notifier.transactionsService.LookupTransaction(ctx, paymentSentEventProperties.PaymentHash, &transactionType, notifier.lnClient, nil)
 		if err != nil {
-			logger.Logger.
+			logger.Nostr.
 				WithField("paymentHash", paymentSentEventProperties.PaymentHash).
 				WithError(err).
 				Error("Failed to lookup invoice by payment hash")
@@ -112,22 +112,26 @@ func (notifier *Nip47Notifier) notifySubscribers(ctx context.Context, notificati
 		}
 
 		hasPermission, _, _ := notifier.permissionsSvc.HasPermission(&app, constants.NOTIFICATIONS_SCOPE)
-		if !hasPermission {
+		if !hasPermission || !notifier.cfg.IsFeatureEnabledForApp(config.FeatureNotifications, app.ID) {
 			continue
 		}
-		notifier.notifySubscriber(ctx, &app, notification, tags)
+		notifier.notifySubscriber(ctx, &app, notification, tags, notifier.keys.GetNostrSecretKey())
+		// apps that have not migrated yet still listen to the previous key
+		if previousPubkey := notifier.keys.GetPreviousNostrPublicKey(); previousPubkey != "" {
+			notifier.notifySubscriber(ctx, &app, notification, tags, notifier.keys.GetNostrSecretKeyFor(previousPubkey))
+		}
 	}
 }
 
-func (notifier *Nip47Notifier) notifySubscriber(ctx context.Context, app *db.App, notification *Notification, tags nostr.Tags) {
-	logger.Logger.WithFields(logrus.Fields{
+func (notifier *Nip47Notifier) notifySubscriber(ctx context.Context, app *db.App, notification *Notification, tags nostr.Tags, walletSecretKey string) {
+	logger.Nostr.WithFields(logrus.Fields{
 		"notification": notification,
 		"appId":        app.ID,
 	}).Info("Notifying subscriber")
 
-	ss, err := nip04.ComputeSharedSecret(app.NostrPubkey, notifier.keys.GetNostrSecretKey())
+	ss, err := nip04.ComputeSharedSecret(app.NostrPubkey, walletSecretKey)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"notification": notification,
 			"appId":        app.ID,
 		}).WithError(err).Error("Failed to compute shared secret")
@@ -136,7 +140,7 @@ func (notifier *Nip47Notifier) notifySubscriber(ctx context.Context, app *db.App
 
 	payloadBytes, err := json.Marshal(notification)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"notification": notification,
 			"appId":        app.ID,
 		}).WithError(err).Error("Failed to stringify notification")
@@ -144,7 +148,7 @@ func (notifier *Nip47Notifier) notifySubscriber(ctx context.Context, app *db.App
 	}
 	msg, err := nip04.Encrypt(string(payloadBytes), ss)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"notification": notification,
 			"appId":        app.ID,
 		}).WithError(err).Error("Failed to encrypt notification payload")
@@ -155,15 +159,14 @@ func (notifier *Nip47Notifier) notifySubscriber(ctx context.Context, app *db.App
 	allTags = append(allTags, tags...)
 
 	event := &nostr.Event{
-		PubKey:    notifier.keys.GetNostrPublicKey(),
 		CreatedAt: nostr.Now(),
 		Kind:      models.NOTIFICATION_KIND,
 		Tags:      allTags,
 		Content:   msg,
 	}
-	err = event.Sign(notifier.keys.GetNostrSecretKey())
+	err = event.Sign(walletSecretKey)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"notification": notification,
 			"appId":        app.ID,
 		}).WithError(err).Error("Failed to sign event")
@@ -172,13 +175,13 @@ func (notifier *Nip47Notifier) notifySubscriber(ctx context.Context, app *db.App
 
 	err = notifier.relay.Publish(ctx, *event)
 	if err != nil {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"notification": notification,
 			"appId":        app.ID,
 		}).WithError(err).Error("Failed to publish notification")
 		return
 	}
-	logger.Logger.WithFields(logrus.Fields{
+	logger.Nostr.WithFields(logrus.Fields{
 		"notification": notification,
 		"appId":        app.ID,
 	}).Info("Published notification event")
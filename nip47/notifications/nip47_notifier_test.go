@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/constants"
 	"github.com/getAlby/hub/db"
 	"github.com/getAlby/hub/events"
@@ -81,7 +82,7 @@ func TestSendNotification_PaymentReceived(t *testing.T) {
 	relay := tests.NewMockRelay()
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	notifier := NewNip47Notifier(relay, svc.DB, svc.Cfg, svc.Keys, permissionsSvc, transactionsSvc, svc.LNClient)
 	notifier.ConsumeEvent(ctx, receivedEvent)
@@ -161,7 +162,7 @@ func TestSendNotification_PaymentSent(t *testing.T) {
 	relay := tests.NewMockRelay()
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	notifier := NewNip47Notifier(relay, svc.DB, svc.Cfg, svc.Keys, permissionsSvc, transactionsSvc, svc.LNClient)
 	notifier.ConsumeEvent(ctx, receivedEvent)
@@ -221,10 +222,54 @@ func TestSendNotificationNoPermission(t *testing.T) {
 	relay := tests.NewMockRelay()
 
 	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
-	transactionsSvc := transactions.NewTransactionsService(svc.DB)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	notifier := NewNip47Notifier(relay, svc.DB, svc.Cfg, svc.Keys, permissionsSvc, transactionsSvc, svc.LNClient)
 	notifier.ConsumeEvent(ctx, receivedEvent)
 
 	assert.Nil(t, relay.PublishedEvent)
 }
+
+func TestSendNotificationFeatureDisabledForApp(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.NOTIFICATIONS_SCOPE,
+	}).Error
+	assert.NoError(t, err)
+	err = svc.Cfg.SetAppFeatureEnabled(config.FeatureNotifications, app.ID, false)
+	assert.NoError(t, err)
+
+	settledAt := time.Unix(*tests.MockLNClientTransaction.SettledAt, 0)
+	err = svc.DB.Create(&db.Transaction{
+		Type:           constants.TRANSACTION_TYPE_INCOMING,
+		PaymentRequest: tests.MockLNClientTransaction.Invoice,
+		PaymentHash:    tests.MockLNClientTransaction.PaymentHash,
+		Preimage:       &tests.MockLNClientTransaction.Preimage,
+		AmountMsat:     uint64(tests.MockLNClientTransaction.Amount),
+		SettledAt:      &settledAt,
+		AppId:          &app.ID,
+	}).Error
+	assert.NoError(t, err)
+
+	relay := tests.NewMockRelay()
+
+	permissionsSvc := permissions.NewPermissionsService(svc.DB, svc.EventPublisher)
+	transactionsSvc := transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+
+	notifier := NewNip47Notifier(relay, svc.DB, svc.Cfg, svc.Keys, permissionsSvc, transactionsSvc, svc.LNClient)
+	notifier.ConsumeEvent(ctx, &events.Event{
+		Event: "nwc_payment_received",
+		Properties: &lnclient.Transaction{
+			PaymentHash: tests.MockLNClientTransaction.PaymentHash,
+		},
+	})
+
+	assert.Nil(t, relay.PublishedEvent)
+}
@@ -0,0 +1,280 @@
+package nip47
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/leader"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nip47/controllers"
+	"github.com/getAlby/hub/nip47/models"
+	nostrmodels "github.com/getAlby/hub/nostr/models"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// requests that make a single payment get their outbox response before the request is handled
+var singlePaymentMethods = []string{models.PAY_INVOICE_METHOD, models.PAY_KEYSEND_METHOD}
+
+// payment requests can be answered from their payments after a restart
+var interruptibleMethods = []string{models.PAY_INVOICE_METHOD, models.PAY_KEYSEND_METHOD, models.MULTI_PAY_INVOICE_METHOD, models.MULTI_PAY_KEYSEND_METHOD}
+
+// createOutboxIntent stores the request before its payment is made. The payment is only
+// created after this is committed, so for every payment of the request an outbox response exists.
+func (svc *nip47Service) createOutboxIntent(requestEvent *db.RequestEvent, event *nostr.Event) (*db.OutboxResponse, error) {
+	request, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	outboxResponse := &db.OutboxResponse{RequestEventId: requestEvent.ID, Request: string(request), Replica: svc.cfg.GetEnv().ReplicaId}
+	err = svc.db.Create(outboxResponse).Error
+	if err != nil {
+		return nil, err
+	}
+	return outboxResponse, nil
+}
+
+// createOutboxBatch stores an outbox response for every payment of a multi_pay request,
+// before any of the payments is sent
+func (svc *nip47Service) createOutboxBatch(requestEvent *db.RequestEvent, event *nostr.Event, elements []controllers.MultiPayElement) ([]*db.OutboxResponse, error) {
+	request, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	outboxResponses := make([]*db.OutboxResponse, 0, len(elements))
+	for _, element := range elements {
+		outboxResponses = append(outboxResponses, &db.OutboxResponse{
+			RequestEventId: requestEvent.ID,
+			Request:        string(request),
+			DTag:           element.DTag,
+			PaymentHash:    element.PaymentHash,
+			Replica:        svc.cfg.GetEnv().ReplicaId,
+		})
+	}
+	err = svc.db.Omit("RequestEvent").Create(outboxResponses).Error
+	if err != nil {
+		return nil, err
+	}
+	return outboxResponses, nil
+}
+
+// findOutboxIntent returns the outbox response stored for the payment that the response with
+// these tags answers. Elements of a multi_pay request may share a d tag, so the first one
+// that was not answered yet is used.
+func findOutboxIntent(outboxIntents []*db.OutboxResponse, tags nostr.Tags) *db.OutboxResponse {
+	dTag := ""
+	if tag := tags.GetFirst([]string{"d"}); tag != nil {
+		dTag = tag.Value()
+	}
+	for _, outboxIntent := range outboxIntents {
+		if outboxIntent.Response == "" && outboxIntent.DTag == dTag {
+			return outboxIntent
+		}
+	}
+	return nil
+}
+
+// saveOutboxResponse stores the response together with the response fields of the request event
+// and adds it to the processed event of the request.
+// If outboxResponse is nil or already has a response, a new outbox response is created.
+func (svc *nip47Service) saveOutboxResponse(requestEvent *db.RequestEvent, outboxResponse *db.OutboxResponse, resp *nostr.Event) (*db.OutboxResponse, error) {
+	response, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	if outboxResponse == nil || outboxResponse.Response != "" {
+		outboxResponse = &db.OutboxResponse{RequestEventId: requestEvent.ID}
+	}
+	err = svc.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Save(requestEvent).Error
+		if err != nil {
+			return err
+		}
+		err = addProcessedResponse(tx, requestEvent, resp)
+		if err != nil {
+			return err
+		}
+		outboxResponse.Response = string(response)
+		return tx.Omit("RequestEvent").Save(outboxResponse).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outboxResponse, nil
+}
+
+// requeueToOutbox stores a response that was not in the outbox yet, after it failed to publish
+func (svc *nip47Service) requeueToOutbox(requestEvent *db.RequestEvent, resp *nostr.Event) (*db.OutboxResponse, error) {
+	response, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	outboxResponse := &db.OutboxResponse{RequestEventId: requestEvent.ID, Response: string(response)}
+	err = svc.db.Omit("RequestEvent").Create(outboxResponse).Error
+	if err != nil {
+		return nil, err
+	}
+	return outboxResponse, nil
+}
+
+func (svc *nip47Service) deleteOutboxResponse(outboxResponseId uint) {
+	err := svc.db.Delete(&db.OutboxResponse{}, outboxResponseId).Error
+	if err != nil {
+		logger.Nostr.WithField("outboxResponseId", outboxResponseId).WithError(err).Error("Failed to delete outbox response")
+	}
+}
+
+// RecoverResponses publishes the responses that were not published yet, e.g. because the hub
+// stopped or the relay failed, and answers the payment requests that were interrupted by a restart.
+// Queued responses of a disconnected relay are published to this relay instead.
+// A response that is still queued may be published twice, which relays ignore.
+func (svc *nip47Service) RecoverResponses(ctx context.Context, relay nostrmodels.Relay) {
+	svc.relayMu.Lock()
+	svc.relay = relay
+	outboxResponses := []db.OutboxResponse{}
+	err := svc.db.Preload("RequestEvent").Order("id").Find(&outboxResponses).Error
+	svc.relayMu.Unlock()
+	if err != nil {
+		logger.Nostr.WithError(err).Error("Failed to load outbox responses")
+		return
+	}
+
+	aliveReplicas := map[string]bool{}
+	for i := range outboxResponses {
+		outboxResponse := &outboxResponses[i]
+		requestEvent := &outboxResponse.RequestEvent
+		if outboxResponse.Response == "" {
+			interrupted, err := svc.isInterrupted(outboxResponse, aliveReplicas)
+			if err != nil {
+				logger.Nostr.WithFields(logrus.Fields{
+					"requestEventId": requestEvent.ID,
+				}).WithError(err).Error("Failed to check if request was interrupted")
+				continue
+			}
+			// still being handled
+			if !interrupted {
+				continue
+			}
+			err = svc.answerInterruptedRequest(outboxResponse)
+			if err != nil {
+				logger.Nostr.WithFields(logrus.Fields{
+					"requestEventId": requestEvent.ID,
+				}).WithError(err).Error("Failed to answer interrupted request")
+				continue
+			}
+			// the payment is still pending
+			if outboxResponse.Response == "" {
+				continue
+			}
+		}
+
+		resp := &nostr.Event{}
+		err = json.Unmarshal([]byte(outboxResponse.Response), resp)
+		if err != nil {
+			logger.Nostr.WithFields(logrus.Fields{
+				"requestEventId":   requestEvent.ID,
+				"outboxResponseId": outboxResponse.ID,
+			}).WithError(err).Error("Failed to decode outbox response")
+			continue
+		}
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventId":       requestEvent.ID,
+			"responseNostrEventId": resp.ID,
+		}).Info("Publishing unpublished response")
+		svc.queueOutboxResponse(ctx, relay, requestEvent, resp, nil, outboxResponse.ID, func(err error) {
+			state := db.REQUEST_EVENT_STATE_HANDLER_EXECUTED
+			if err != nil {
+				state = db.REQUEST_EVENT_STATE_HANDLER_ERROR
+			}
+			err = svc.saveRequestEventState(requestEvent.ID, state)
+			if err != nil {
+				logger.Nostr.WithFields(logrus.Fields{
+					"requestEventId": requestEvent.ID,
+				}).WithError(err).Error("Failed to save state to nostr event")
+			}
+		})
+	}
+}
+
+// isInterrupted returns whether the request of the outbox intent is no longer handled, because this replica
+// was restarted while handling it or because the replica that handled it stopped.
+// /api/nip47 requests are handled by every replica, not only by the leader.
+func (svc *nip47Service) isInterrupted(outboxResponse *db.OutboxResponse, aliveReplicas map[string]bool) (bool, error) {
+	if outboxResponse.Replica == "" || outboxResponse.Replica == svc.cfg.GetEnv().ReplicaId {
+		return outboxResponse.CreatedAt.Before(svc.startedAt), nil
+	}
+	alive, ok := aliveReplicas[outboxResponse.Replica]
+	if !ok {
+		var err error
+		alive, err = leader.IsReplicaAlive(svc.db, outboxResponse.Replica)
+		if err != nil {
+			return false, err
+		}
+		aliveReplicas[outboxResponse.Replica] = alive
+	}
+	return !alive, nil
+}
+
+// answerInterruptedRequest creates the response of a payment request from its payment,
+// unless the payment is still pending
+func (svc *nip47Service) answerInterruptedRequest(outboxResponse *db.OutboxResponse) error {
+	requestEvent := &outboxResponse.RequestEvent
+	if !slices.Contains(interruptibleMethods, requestEvent.Method) {
+		return errors.New("request method cannot be answered after a restart")
+	}
+	event := &nostr.Event{}
+	err := json.Unmarshal([]byte(outboxResponse.Request), event)
+	if err != nil {
+		return err
+	}
+
+	// the elements of a multi_pay request are found by their payment hash
+	query := svc.db.Where("request_event_id = ?", requestEvent.ID)
+	tags := nostr.Tags{}
+	if !slices.Contains(singlePaymentMethods, requestEvent.Method) {
+		query = query.Where("payment_hash = ?", outboxResponse.PaymentHash)
+		tags = nostr.Tags{[]string{"d", outboxResponse.DTag}}
+	}
+	transactions := []db.Transaction{}
+	err = query.Limit(1).Find(&transactions).Error
+	if err != nil {
+		return err
+	}
+	var transaction *db.Transaction
+	if len(transactions) > 0 {
+		transaction = &transactions[0]
+	}
+	nip47Response := controllers.InterruptedPaymentResponse(requestEvent.Method, transaction)
+	if nip47Response == nil {
+		return nil
+	}
+
+	// the request was handled, so its version is supported
+	cipher, err := newNip47Cipher(requestVersion(event), event.PubKey, svc.keys.GetNostrSecretKeyFor(walletPubkey(event)))
+	if err != nil {
+		return err
+	}
+	resp, err := svc.createResponse(event, nip47Response, tags, cipher)
+	if err != nil {
+		return err
+	}
+
+	setRequestEventResponse(requestEvent, nip47Response)
+	_, err = svc.saveOutboxResponse(requestEvent, outboxResponse, resp)
+	return err
+}
+
+// setRequestEventResponse sets the response fields of the request event, for the request inspector of the app
+func setRequestEventResponse(requestEvent *db.RequestEvent, nip47Response *models.Response) {
+	respondedAt := time.Now()
+	requestEvent.RespondedAt = &respondedAt
+	if nip47Response.Error != nil {
+		requestEvent.ErrorCode = nip47Response.Error.Code
+		requestEvent.ErrorMessage = nip47Response.Error.Message
+	}
+}
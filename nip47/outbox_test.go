@@ -0,0 +1,363 @@
+package nip47
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/leader"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingRelay struct{}
+
+func (relay *failingRelay) Publish(ctx context.Context, event nostr.Event) error {
+	return errors.New("relay disconnected")
+}
+
+// disconnectedRelay is a relay whose connection dropped
+type disconnectedRelay struct {
+	publishAttempts int
+}
+
+func (relay *disconnectedRelay) Publish(ctx context.Context, event nostr.Event) error {
+	relay.publishAttempts++
+	return errors.New("relay disconnected")
+}
+
+func (relay *disconnectedRelay) IsConnected() bool {
+	return false
+}
+
+func createSignedRequest(t *testing.T, privateKey string, ss []byte, method string) *nostr.Event {
+	payloadBytes, err := json.Marshal(map[string]interface{}{
+		"method": method,
+	})
+	assert.NoError(t, err)
+	msg, err := nip04.Encrypt(string(payloadBytes), ss)
+	assert.NoError(t, err)
+	pubkey, err := nostr.GetPublicKey(privateKey)
+	assert.NoError(t, err)
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		PubKey:    pubkey,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{},
+		Content:   msg,
+	}
+	assert.NoError(t, reqEvent.Sign(privateKey))
+	return reqEvent
+}
+
+func TestHandleEvent_UnpublishedResponseIsRecovered(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	_, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	reqEvent := createSignedRequest(t, reqPrivateKey, ss, models.GET_INFO_METHOD)
+	nip47svc.HandleEvent(context.TODO(), &failingRelay{}, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+
+	outboxResponses := []db.OutboxResponse{}
+	assert.NoError(t, svc.DB.Find(&outboxResponses).Error)
+	assert.Equal(t, 1, len(outboxResponses))
+
+	relay := tests.NewMockRelay()
+	nip47svc.RecoverResponses(context.TODO(), relay)
+	nip47svc.WaitForPublishedResponses()
+
+	assert.NotNil(t, relay.PublishedEvent)
+	assert.Equal(t, reqEvent.ID, relay.PublishedEvent.Tags.GetFirst([]string{"e"}).Value())
+	assert.NoError(t, svc.DB.Find(&outboxResponses).Error)
+	assert.Empty(t, outboxResponses)
+
+	requestEvent := db.RequestEvent{}
+	assert.NoError(t, svc.DB.Where("nostr_id = ?", reqEvent.ID).First(&requestEvent).Error)
+	assert.Equal(t, db.REQUEST_EVENT_STATE_HANDLER_EXECUTED, requestEvent.State)
+}
+
+func TestRecoverResponses_InterruptedPayments(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	app, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	// the hub stopped while handling the requests
+	createInterruptedRequest := func(transactionState string) *nostr.Event {
+		reqEvent := createSignedRequest(t, reqPrivateKey, ss, models.PAY_KEYSEND_METHOD)
+		requestEvent := &db.RequestEvent{AppId: &app.ID, NostrId: reqEvent.ID, Method: models.PAY_KEYSEND_METHOD, State: db.REQUEST_EVENT_STATE_HANDLER_EXECUTING}
+		assert.NoError(t, svc.DB.Create(requestEvent).Error)
+		request, err := json.Marshal(reqEvent)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.DB.Create(&db.OutboxResponse{RequestEventId: requestEvent.ID, Request: string(request)}).Error)
+		if transactionState != "" {
+			preimage := tests.MockLNClientTransaction.Preimage
+			assert.NoError(t, svc.DB.Create(&db.Transaction{AppId: &app.ID, RequestEventId: &requestEvent.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: transactionState, PaymentHash: reqEvent.ID, Preimage: &preimage, FeeMsat: 1000}).Error)
+		}
+		return reqEvent
+	}
+	settled := createInterruptedRequest(constants.TRANSACTION_STATE_SETTLED)
+	inFlight := createInterruptedRequest(constants.TRANSACTION_STATE_IN_FLIGHT)
+	notPaid := createInterruptedRequest("")
+
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+	relay := &collectingRelay{}
+	nip47svc.RecoverResponses(context.TODO(), relay)
+	nip47svc.WaitForPublishedResponses()
+
+	publishedEvents := relay.publishedEvents()
+	assert.Equal(t, 2, len(publishedEvents))
+	responses := map[string]*models.Response{}
+	for _, publishedEvent := range publishedEvents {
+		decrypted, err := nip04.Decrypt(publishedEvent.Content, ss)
+		assert.NoError(t, err)
+		response := &models.Response{}
+		assert.NoError(t, json.Unmarshal([]byte(decrypted), response))
+		responses[publishedEvent.Tags.GetFirst([]string{"e"}).Value()] = response
+	}
+
+	assert.Nil(t, responses[settled.ID].Error)
+	assert.Equal(t, tests.MockLNClientTransaction.Preimage, responses[settled.ID].Result.(map[string]interface{})["preimage"])
+	assert.Equal(t, models.ERROR_INTERNAL, responses[notPaid.ID].Error.Code)
+	assert.NotContains(t, responses, inFlight.ID)
+
+	// the pending payment is answered once it is resolved
+	outboxResponses := []db.OutboxResponse{}
+	assert.NoError(t, svc.DB.Preload("RequestEvent").Find(&outboxResponses).Error)
+	assert.Equal(t, 1, len(outboxResponses))
+	assert.Equal(t, inFlight.ID, outboxResponses[0].RequestEvent.NostrId)
+	assert.Empty(t, outboxResponses[0].Response)
+}
+
+func TestRecoverResponses_OtherReplicas(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().ReplicaId = "replica-1"
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	app, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	// requests that another replica received on /api/nip47
+	createRequest := func(replica string) *nostr.Event {
+		reqEvent := createSignedRequest(t, reqPrivateKey, ss, models.PAY_KEYSEND_METHOD)
+		requestEvent := &db.RequestEvent{AppId: &app.ID, NostrId: reqEvent.ID, Method: models.PAY_KEYSEND_METHOD, State: db.REQUEST_EVENT_STATE_HANDLER_EXECUTING}
+		assert.NoError(t, svc.DB.Create(requestEvent).Error)
+		request, err := json.Marshal(reqEvent)
+		assert.NoError(t, err)
+		assert.NoError(t, svc.DB.Create(&db.OutboxResponse{RequestEventId: requestEvent.ID, Request: string(request), Replica: replica}).Error)
+		return reqEvent
+	}
+	stillHandled := createRequest("replica-2")
+	interrupted := createRequest("replica-3")
+	assert.NoError(t, svc.DB.Create(&db.LeaderLease{Name: leader.ReplicaLeaseName("replica-2"), Holder: "replica-2", ExpiresAt: time.Now().Add(time.Minute)}).Error)
+	assert.NoError(t, svc.DB.Create(&db.LeaderLease{Name: leader.ReplicaLeaseName("replica-3"), Holder: "replica-3", ExpiresAt: time.Now().Add(-time.Minute)}).Error)
+
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+	relay := &collectingRelay{}
+	nip47svc.RecoverResponses(context.TODO(), relay)
+	nip47svc.WaitForPublishedResponses()
+
+	publishedEvents := relay.publishedEvents()
+	assert.Equal(t, 1, len(publishedEvents))
+	assert.Equal(t, interrupted.ID, publishedEvents[0].Tags.GetFirst([]string{"e"}).Value())
+
+	outboxResponses := []db.OutboxResponse{}
+	assert.NoError(t, svc.DB.Preload("RequestEvent").Find(&outboxResponses).Error)
+	assert.Equal(t, 1, len(outboxResponses))
+	assert.Equal(t, stillHandled.ID, outboxResponses[0].RequestEvent.NostrId)
+	assert.Empty(t, outboxResponses[0].Response)
+}
+
+func TestRecoverResponses_InterruptedMultiPay(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	app, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	// the hub stopped while sending the payments of the batch
+	reqEvent := createSignedRequest(t, reqPrivateKey, ss, models.MULTI_PAY_KEYSEND_METHOD)
+	requestEvent := &db.RequestEvent{AppId: &app.ID, NostrId: reqEvent.ID, Method: models.MULTI_PAY_KEYSEND_METHOD, State: db.REQUEST_EVENT_STATE_HANDLER_EXECUTING}
+	assert.NoError(t, svc.DB.Create(requestEvent).Error)
+	request, err := json.Marshal(reqEvent)
+	assert.NoError(t, err)
+	preimage := tests.MockLNClientTransaction.Preimage
+	for _, element := range []struct {
+		dTag             string
+		transactionState string
+	}{
+		{"settled", constants.TRANSACTION_STATE_SETTLED},
+		{"failed", constants.TRANSACTION_STATE_FAILED},
+		{"in_flight", constants.TRANSACTION_STATE_IN_FLIGHT},
+		{"not_paid", ""},
+	} {
+		paymentHash := element.dTag + "_hash"
+		assert.NoError(t, svc.DB.Create(&db.OutboxResponse{RequestEventId: requestEvent.ID, Request: string(request), DTag: element.dTag, PaymentHash: paymentHash}).Error)
+		if element.transactionState != "" {
+			assert.NoError(t, svc.DB.Create(&db.Transaction{AppId: &app.ID, RequestEventId: &requestEvent.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: element.transactionState, PaymentHash: paymentHash, Preimage: &preimage}).Error)
+		}
+	}
+
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+	relay := &collectingRelay{}
+	nip47svc.RecoverResponses(context.TODO(), relay)
+	nip47svc.WaitForPublishedResponses()
+
+	responses := map[string]*models.Response{}
+	for _, publishedEvent := range relay.publishedEvents() {
+		assert.Equal(t, reqEvent.ID, publishedEvent.Tags.GetFirst([]string{"e"}).Value())
+		decrypted, err := nip04.Decrypt(publishedEvent.Content, ss)
+		assert.NoError(t, err)
+		response := &models.Response{}
+		assert.NoError(t, json.Unmarshal([]byte(decrypted), response))
+		responses[publishedEvent.Tags.GetFirst([]string{"d"}).Value()] = response
+	}
+	assert.Equal(t, 3, len(responses))
+	assert.Nil(t, responses["settled"].Error)
+	assert.Equal(t, preimage, responses["settled"].Result.(map[string]interface{})["preimage"])
+	assert.Equal(t, models.ERROR_INTERNAL, responses["failed"].Error.Code)
+	assert.Equal(t, models.ERROR_INTERNAL, responses["not_paid"].Error.Code)
+	assert.NotContains(t, responses, "in_flight")
+
+	outboxResponses := []db.OutboxResponse{}
+	assert.NoError(t, svc.DB.Find(&outboxResponses).Error)
+	assert.Equal(t, 1, len(outboxResponses))
+	assert.Equal(t, "in_flight", outboxResponses[0].DTag)
+}
+
+func TestHandleEvent_MultiPayBatchIsStored(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	app, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.DB.Create(&db.AppPermission{AppId: app.ID, App: *app, Scope: constants.PAY_INVOICE_SCOPE}).Error)
+
+	payloadBytes, err := json.Marshal(map[string]interface{}{
+		"method": models.MULTI_PAY_KEYSEND_METHOD,
+		"params": map[string]interface{}{
+			"keysends": []map[string]interface{}{
+				{"id": "first", "amount": 1000, "pubkey": "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c"},
+				{"id": "second", "amount": 1000, "pubkey": "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c"},
+			},
+		},
+	})
+	assert.NoError(t, err)
+	msg, err := nip04.Encrypt(string(payloadBytes), ss)
+	assert.NoError(t, err)
+	pubkey, err := nostr.GetPublicKey(reqPrivateKey)
+	assert.NoError(t, err)
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		PubKey:    pubkey,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{},
+		Content:   msg,
+	}
+	assert.NoError(t, reqEvent.Sign(reqPrivateKey))
+
+	// the responses stay in the outbox because they cannot be published
+	nip47svc.HandleEvent(context.TODO(), &failingRelay{}, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+
+	outboxResponses := []db.OutboxResponse{}
+	assert.NoError(t, svc.DB.Order("d_tag").Find(&outboxResponses).Error)
+	assert.Equal(t, 2, len(outboxResponses))
+	for i, dTag := range []string{"first", "second"} {
+		assert.Equal(t, dTag, outboxResponses[i].DTag)
+		assert.NotEmpty(t, outboxResponses[i].Response)
+		transaction := db.Transaction{}
+		assert.NoError(t, svc.DB.Where("payment_hash = ?", outboxResponses[i].PaymentHash).First(&transaction).Error)
+		assert.Equal(t, constants.TRANSACTION_STATE_SETTLED, transaction.State)
+	}
+}
+
+func TestHandleEvent_UnpublishedErrorResponseIsRequeued(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	// the pubkey has no app, so the request is answered with an error before it is handled
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	ss, err := nip04.ComputeSharedSecret(svc.Keys.GetNostrPublicKey(), reqPrivateKey)
+	assert.NoError(t, err)
+	reqEvent := createSignedRequest(t, reqPrivateKey, ss, models.GET_INFO_METHOD)
+	nip47svc.HandleEvent(context.TODO(), &failingRelay{}, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+
+	outboxResponses := []db.OutboxResponse{}
+	assert.NoError(t, svc.DB.Find(&outboxResponses).Error)
+	assert.Equal(t, 1, len(outboxResponses))
+	assert.NotEmpty(t, outboxResponses[0].Response)
+
+	relay := tests.NewMockRelay()
+	nip47svc.RecoverResponses(context.TODO(), relay)
+	nip47svc.WaitForPublishedResponses()
+
+	assert.NotNil(t, relay.PublishedEvent)
+	assert.Equal(t, reqEvent.ID, relay.PublishedEvent.Tags.GetFirst([]string{"e"}).Value())
+	assert.NoError(t, svc.DB.Find(&outboxResponses).Error)
+	assert.Empty(t, outboxResponses)
+}
+
+func TestHandleEvent_ResponsesOfDisconnectedRelayArePublishedAfterReconnect(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	_, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	oldRelay := &disconnectedRelay{}
+	nip47svc.RecoverResponses(context.TODO(), oldRelay)
+	firstReqEvent := createSignedRequest(t, reqPrivateKey, ss, models.GET_INFO_METHOD)
+	nip47svc.HandleEvent(context.TODO(), oldRelay, firstReqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+
+	// the relay is known to be disconnected, so nothing is sent to it
+	assert.Equal(t, 0, oldRelay.publishAttempts)
+	outboxResponses := []db.OutboxResponse{}
+	assert.NoError(t, svc.DB.Find(&outboxResponses).Error)
+	assert.Equal(t, 1, len(outboxResponses))
+
+	relay := &collectingRelay{}
+	nip47svc.RecoverResponses(context.TODO(), relay)
+	// a response of the old relay that is queued after the reconnect is published to the new relay
+	secondReqEvent := createSignedRequest(t, reqPrivateKey, ss, models.GET_BALANCE_METHOD)
+	nip47svc.HandleEvent(context.TODO(), oldRelay, secondReqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+
+	assert.Equal(t, 0, oldRelay.publishAttempts)
+	publishedEvents := relay.publishedEvents()
+	assert.Equal(t, 2, len(publishedEvents))
+	assert.Equal(t, firstReqEvent.ID, publishedEvents[0].Tags.GetFirst([]string{"e"}).Value())
+	assert.Equal(t, secondReqEvent.ID, publishedEvents[1].Tags.GetFirst([]string{"e"}).Value())
+	assert.NoError(t, svc.DB.Find(&outboxResponses).Error)
+	assert.Empty(t, outboxResponses)
+}
@@ -0,0 +1,122 @@
+package nip47
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nip47/models"
+	nostrmodels "github.com/getAlby/hub/nostr/models"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+)
+
+// limits of request events, checked before the content is decrypted and decoded.
+// The largest valid requests are multi_pay requests, which are also limited by NIP47_MULTI_PAY_MAX_PAYMENTS.
+const (
+	// a few hundred bolt11 invoices
+	maxEncryptedPayloadSize = 256 * 1024
+	// the deepest valid request is a multi_pay_keysend with TLV records, at depth 6
+	maxPayloadDepth       = 16
+	maxPayloadArrayLength = 1000
+)
+
+// checkEncryptedPayloadSize rejects events that are too large to be worth decrypting
+func checkEncryptedPayloadSize(content string) error {
+	if len(content) > maxEncryptedPayloadSize {
+		return fmt.Errorf("request payload is too large: %d bytes, at most %d bytes are allowed", len(content), maxEncryptedPayloadSize)
+	}
+	return nil
+}
+
+type jsonContainer struct {
+	array  bool
+	length int
+	// objects alternate between keys and values
+	expectKey bool
+}
+
+// checkPayloadLimits scans the decrypted payload for nesting and arrays that are too deep or too long,
+// before it is decoded into the request params
+func checkPayloadLimits(payload string) error {
+	decoder := json.NewDecoder(strings.NewReader(payload))
+	containers := []*jsonContainer{}
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			// the payload is rejected when it is decoded
+			return nil
+		}
+
+		delim, isDelim := token.(json.Delim)
+		if isDelim && (delim == '}' || delim == ']') {
+			containers = containers[:len(containers)-1]
+			continue
+		}
+
+		if len(containers) > 0 {
+			parent := containers[len(containers)-1]
+			if parent.array {
+				parent.length++
+				if parent.length > maxPayloadArrayLength {
+					return fmt.Errorf("request payload has an array with more than %d elements", maxPayloadArrayLength)
+				}
+			} else {
+				isKey := parent.expectKey
+				parent.expectKey = !isKey
+				if isKey {
+					continue
+				}
+			}
+		}
+
+		if isDelim {
+			containers = append(containers, &jsonContainer{array: delim == '[', expectKey: delim == '{'})
+			if len(containers) > maxPayloadDepth {
+				return fmt.Errorf("request payload is nested deeper than %d levels", maxPayloadDepth)
+			}
+		}
+	}
+}
+
+// rejectPayload answers a request whose payload exceeds the limits with a BAD_REQUEST error
+func (svc *nip47Service) rejectPayload(ctx context.Context, relay nostrmodels.Relay, event *nostr.Event, requestEvent *db.RequestEvent, app *db.App, cipher nip47Cipher, limitErr error) {
+	logger.Nostr.WithFields(logrus.Fields{
+		"requestEventNostrId": event.ID,
+		"appId":               app.ID,
+		"contentLength":       len(event.Content),
+	}).WithError(limitErr).Warn("Rejected request event that exceeds the payload limits")
+
+	nip47Response := &models.Response{
+		Error: &models.Error{
+			Code:    models.ERROR_BAD_REQUEST,
+			Message: limitErr.Error(),
+		},
+	}
+	resp, err := svc.createResponse(event, nip47Response, nostr.Tags{}, cipher)
+	if err != nil {
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventNostrId": event.ID,
+			"eventKind":           event.Kind,
+		}).WithError(err).Error("Failed to process event")
+	}
+	setRequestEventResponse(requestEvent, nip47Response)
+	svc.saveProcessedResponse(requestEvent, resp)
+	svc.queueResponse(ctx, relay, requestEvent, resp, app, nil)
+
+	requestEvent.State = db.REQUEST_EVENT_STATE_HANDLER_ERROR
+	err = svc.saveRequestEvent(requestEvent)
+	if err != nil {
+		logger.Nostr.WithFields(logrus.Fields{
+			"nostrPubkey": event.PubKey,
+		}).WithError(err).Error("Failed to save state to nostr event")
+	}
+}
@@ -0,0 +1,77 @@
+package nip47
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPayloadLimits(t *testing.T) {
+	keysends := make([]string, maxPayloadArrayLength)
+	for i := range keysends {
+		keysends[i] = `{"amount":1000,"pubkey":"03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c","tlv_records":[{"type":5482373484,"value":"00"}]}`
+	}
+	multiPay := `{"method":"multi_pay_keysend","params":{"keysends":[` + strings.Join(keysends, ",") + `]}}`
+	assert.NoError(t, checkPayloadLimits(multiPay))
+	assert.NoError(t, checkPayloadLimits(`{"method":"get_info"}`))
+	// invalid JSON is rejected when it is decoded
+	assert.NoError(t, checkPayloadLimits(`{"method":`))
+
+	tooLong := `{"method":"multi_pay_keysend","params":{"keysends":[` + strings.Join(append(keysends, keysends[0]), ",") + `]}}`
+	assert.EqualError(t, checkPayloadLimits(tooLong), "request payload has an array with more than 1000 elements")
+
+	deepest := strings.Repeat(`{"a":`, maxPayloadDepth) + "1" + strings.Repeat("}", maxPayloadDepth)
+	assert.NoError(t, checkPayloadLimits(deepest))
+	tooDeep := strings.Repeat("[", maxPayloadDepth+1) + strings.Repeat("]", maxPayloadDepth+1)
+	assert.EqualError(t, checkPayloadLimits(tooDeep), "request payload is nested deeper than 16 levels")
+}
+
+func TestHandleEvent_PayloadTooLarge(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	_, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	payload := `{"method":"multi_pay_invoice","params":{"invoices":[` + strings.Repeat(`{"invoice":"`+tests.MockInvoice+`"},`, 1000) + `{}]}}`
+	msg, err := nip04.Encrypt(payload, ss)
+	assert.NoError(t, err)
+	pubkey, err := nostr.GetPublicKey(reqPrivateKey)
+	assert.NoError(t, err)
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		PubKey:    pubkey,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{},
+		Content:   msg,
+	}
+	assert.NoError(t, reqEvent.Sign(reqPrivateKey))
+
+	relay := tests.NewMockRelay()
+	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+
+	assert.NotNil(t, relay.PublishedEvent)
+	decrypted, err := nip04.Decrypt(relay.PublishedEvent.Content, ss)
+	assert.NoError(t, err)
+	response := models.Response{}
+	assert.NoError(t, json.Unmarshal([]byte(decrypted), &response))
+	assert.Equal(t, models.ERROR_BAD_REQUEST, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "request payload is too large")
+
+	requestEvent := db.RequestEvent{}
+	assert.NoError(t, svc.DB.Where("nostr_id = ?", reqEvent.ID).First(&requestEvent).Error)
+	assert.Equal(t, db.REQUEST_EVENT_STATE_HANDLER_ERROR, requestEvent.State)
+	assert.Empty(t, requestEvent.ContentData)
+}
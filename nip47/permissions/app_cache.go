@@ -0,0 +1,93 @@
+package permissions
+
+import (
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/db"
+	"gorm.io/gorm"
+)
+
+// entries are dropped on app changes, this only covers writes that do not publish an event
+const appCacheTTL = time.Minute
+
+type cachedApp struct {
+	app         db.App
+	permissions []db.AppPermission
+	expiresAt   time.Time
+}
+
+// appCache keeps apps and their permissions in memory so that
+// authorizing a NIP-47 request does not need to query the database
+type appCache struct {
+	db       *gorm.DB
+	ttl      time.Duration
+	mu       sync.RWMutex
+	byPubkey map[string]*cachedApp
+	byId     map[uint]*cachedApp
+	// incremented on every invalidation so that lookups started before it are not stored
+	generation uint64
+}
+
+func newAppCache(db *gorm.DB, ttl time.Duration) *appCache {
+	return &appCache{
+		db:       db,
+		ttl:      ttl,
+		byPubkey: map[string]*cachedApp{},
+		byId:     map[uint]*cachedApp{},
+	}
+}
+
+func (cache *appCache) getByPubkey(pubkey string) (*cachedApp, error) {
+	cache.mu.RLock()
+	entry, ok := cache.byPubkey[pubkey]
+	cache.mu.RUnlock()
+	if ok && entry.expiresAt.After(time.Now()) {
+		return entry, nil
+	}
+	return cache.load(&db.App{NostrPubkey: pubkey})
+}
+
+func (cache *appCache) getById(appId uint) (*cachedApp, error) {
+	cache.mu.RLock()
+	entry, ok := cache.byId[appId]
+	cache.mu.RUnlock()
+	if ok && entry.expiresAt.After(time.Now()) {
+		return entry, nil
+	}
+	return cache.load(appId)
+}
+
+func (cache *appCache) load(conds ...interface{}) (*cachedApp, error) {
+	cache.mu.RLock()
+	generation := cache.generation
+	cache.mu.RUnlock()
+
+	entry := &cachedApp{
+		expiresAt: time.Now().Add(cache.ttl),
+	}
+	err := cache.db.First(&entry.app, conds...).Error
+	if err != nil {
+		return nil, err
+	}
+	err = cache.db.Where("app_id = ?", entry.app.ID).Find(&entry.permissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.generation == generation {
+		cache.byPubkey[entry.app.NostrPubkey] = entry
+		cache.byId[entry.app.ID] = entry
+	}
+	return entry, nil
+}
+
+func (cache *appCache) invalidate() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.byPubkey = map[string]*cachedApp{}
+	cache.byId = map[uint]*cachedApp{}
+	cache.generation++
+}
@@ -1,6 +1,7 @@
 package permissions
 
 import (
+	"context"
 	"fmt"
 	"slices"
 	"time"
@@ -19,10 +20,13 @@ import (
 type permissionsService struct {
 	db             *gorm.DB
 	eventPublisher events.EventPublisher
+	appCache       *appCache
 }
 
 // TODO: does this need to be a service?
 type PermissionsService interface {
+	events.EventSubscriber
+	GetAppByPubkey(pubkey string) (db.App, error)
 	HasPermission(app *db.App, requestMethod string) (result bool, code string, message string)
 	GetPermittedMethods(app *db.App, lnClient lnclient.LNClient) []string
 	PermitsNotifications(app *db.App) bool
@@ -32,23 +36,56 @@ func NewPermissionsService(db *gorm.DB, eventPublisher events.EventPublisher) *p
 	return &permissionsService{
 		db:             db,
 		eventPublisher: eventPublisher,
+		appCache:       newAppCache(db, appCacheTTL),
 	}
 }
 
-func (svc *permissionsService) HasPermission(app *db.App, scope string) (result bool, code string, message string) {
+// NewUncachedPermissionsService loads the app of every request from the database, for replicas that share it.
+// Apps that are changed by another replica do not publish an event that would drop them from the cache.
+func NewUncachedPermissionsService(db *gorm.DB, eventPublisher events.EventPublisher) *permissionsService {
+	return &permissionsService{
+		db:             db,
+		eventPublisher: eventPublisher,
+		appCache:       newAppCache(db, 0),
+	}
+}
+
+func (svc *permissionsService) ConsumeEvent(ctx context.Context, event *events.Event, globalProperties map[string]interface{}) {
+	switch event.Event {
+	case "app_created", "app_updated", "app_deleted", "app_secret_regenerated":
+		svc.appCache.invalidate()
+	}
+}
+
+func (svc *permissionsService) GetAppByPubkey(pubkey string) (db.App, error) {
+	entry, err := svc.appCache.getByPubkey(pubkey)
+	if err != nil {
+		return db.App{}, err
+	}
+	return entry.app, nil
+}
+
+func (svc *permissionsService) getAppPermissions(app *db.App) []db.AppPermission {
+	entry, err := svc.appCache.getById(app.ID)
+	if err != nil {
+		logger.Nostr.WithField("appId", app.ID).WithError(err).Error("Failed to load app permissions")
+		return nil
+	}
+	return entry.permissions
+}
 
-	appPermission := db.AppPermission{}
-	findPermissionResult := svc.db.Find(&appPermission, &db.AppPermission{
-		AppId: app.ID,
-		Scope: scope,
+func (svc *permissionsService) HasPermission(app *db.App, scope string) (result bool, code string, message string) {
+	appPermissions := svc.getAppPermissions(app)
+	appPermissionIndex := slices.IndexFunc(appPermissions, func(appPermission db.AppPermission) bool {
+		return appPermission.Scope == scope
 	})
-	if findPermissionResult.RowsAffected == 0 {
+	if appPermissionIndex == -1 {
 		// No permission for this request method
 		return false, models.ERROR_RESTRICTED, fmt.Sprintf("This app does not have the %s scope", scope)
 	}
-	expiresAt := appPermission.ExpiresAt
+	expiresAt := appPermissions[appPermissionIndex].ExpiresAt
 	if expiresAt != nil && expiresAt.Before(time.Now()) {
-		logger.Logger.WithFields(logrus.Fields{
+		logger.Nostr.WithFields(logrus.Fields{
 			"scope":     scope,
 			"expiresAt": expiresAt.Unix(),
 			"appId":     app.ID,
@@ -62,8 +99,7 @@ func (svc *permissionsService) HasPermission(app *db.App, scope string) (result
 }
 
 func (svc *permissionsService) GetPermittedMethods(app *db.App, lnClient lnclient.LNClient) []string {
-	appPermissions := []db.AppPermission{}
-	svc.db.Where("app_id = ?", app.ID).Find(&appPermissions)
+	appPermissions := svc.getAppPermissions(app)
 	scopes := make([]string, 0, len(appPermissions))
 	for _, appPermission := range appPermissions {
 		scopes = append(scopes, appPermission.Scope)
@@ -81,16 +117,9 @@ func (svc *permissionsService) GetPermittedMethods(app *db.App, lnClient lnclien
 }
 
 func (svc *permissionsService) PermitsNotifications(app *db.App) bool {
-	notificationPermission := db.AppPermission{}
-	err := svc.db.First(&notificationPermission, &db.AppPermission{
-		AppId: app.ID,
-		Scope: constants.NOTIFICATIONS_SCOPE,
-	}).Error
-	if err != nil {
-		return false
-	}
-
-	return true
+	return slices.ContainsFunc(svc.getAppPermissions(app), func(appPermission db.AppPermission) bool {
+		return appPermission.Scope == constants.NOTIFICATIONS_SCOPE
+	})
 }
 
 func scopesToRequestMethods(scopes []string) []string {
@@ -112,7 +141,7 @@ func scopeToRequestMethods(scope string) []string {
 	case constants.GET_INFO_SCOPE:
 		return []string{models.GET_INFO_METHOD}
 	case constants.MAKE_INVOICE_SCOPE:
-		return []string{models.MAKE_INVOICE_METHOD}
+		return []string{models.MAKE_INVOICE_METHOD, models.MAKE_OFFER_METHOD}
 	case constants.LOOKUP_INVOICE_SCOPE:
 		return []string{models.LOOKUP_INVOICE_METHOD}
 	case constants.LIST_TRANSACTIONS_SCOPE:
@@ -146,7 +175,7 @@ func RequestMethodToScope(requestMethod string) (string, error) {
 		return constants.GET_BALANCE_SCOPE, nil
 	case models.GET_INFO_METHOD:
 		return constants.GET_INFO_SCOPE, nil
-	case models.MAKE_INVOICE_METHOD:
+	case models.MAKE_INVOICE_METHOD, models.MAKE_OFFER_METHOD:
 		return constants.MAKE_INVOICE_SCOPE, nil
 	case models.LOOKUP_INVOICE_METHOD:
 		return constants.LOOKUP_INVOICE_SCOPE, nil
@@ -155,7 +184,7 @@ func RequestMethodToScope(requestMethod string) (string, error) {
 	case models.SIGN_MESSAGE_METHOD:
 		return constants.SIGN_MESSAGE_SCOPE, nil
 	}
-	logger.Logger.WithField("request_method", requestMethod).Error("Unsupported request method")
+	logger.Nostr.WithField("request_method", requestMethod).Error("Unsupported request method")
 	return "", fmt.Errorf("unsupported request method: %s", requestMethod)
 }
 
@@ -1,14 +1,18 @@
 package permissions
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/getAlby/hub/constants"
 	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
 	"github.com/getAlby/hub/nip47/models"
 	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 )
 
 func TestHasPermission_NoPermission(t *testing.T) {
@@ -110,3 +114,87 @@ func TestHasPermission_OK(t *testing.T) {
 	assert.Empty(t, code)
 	assert.Empty(t, message)
 }
+
+func TestHasPermission_CachedUntilAppUpdated(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	appPermission := &db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.PAY_INVOICE_SCOPE,
+	}
+	err = svc.DB.Create(appPermission).Error
+	assert.NoError(t, err)
+
+	permissionsSvc := NewPermissionsService(svc.DB, svc.EventPublisher)
+	result, _, _ := permissionsSvc.HasPermission(app, constants.PAY_INVOICE_SCOPE)
+	assert.True(t, result)
+
+	err = svc.DB.Delete(appPermission).Error
+	assert.NoError(t, err)
+
+	// served from the cache
+	result, _, _ = permissionsSvc.HasPermission(app, constants.PAY_INVOICE_SCOPE)
+	assert.True(t, result)
+
+	permissionsSvc.ConsumeEvent(context.TODO(), &events.Event{Event: "app_updated"}, map[string]interface{}{})
+
+	result, code, _ := permissionsSvc.HasPermission(app, constants.PAY_INVOICE_SCOPE)
+	assert.False(t, result)
+	assert.Equal(t, models.ERROR_RESTRICTED, code)
+}
+
+func TestGetAppByPubkey_Deleted(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	permissionsSvc := NewPermissionsService(svc.DB, svc.EventPublisher)
+	cachedApp, err := permissionsSvc.GetAppByPubkey(app.NostrPubkey)
+	assert.NoError(t, err)
+	assert.Equal(t, app.ID, cachedApp.ID)
+
+	err = svc.DB.Delete(app).Error
+	assert.NoError(t, err)
+	permissionsSvc.ConsumeEvent(context.TODO(), &events.Event{Event: "app_deleted"}, map[string]interface{}{})
+
+	_, err = permissionsSvc.GetAppByPubkey(app.NostrPubkey)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestGetAppByPubkey_SecretRegenerated(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	oldPubkey := app.NostrPubkey
+
+	permissionsSvc := NewPermissionsService(svc.DB, svc.EventPublisher)
+	_, err = permissionsSvc.GetAppByPubkey(oldPubkey)
+	assert.NoError(t, err)
+
+	pairingSecretKey, err := db.NewDBService(svc.DB, svc.EventPublisher).RegenerateAppSecret(app)
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldPubkey, app.NostrPubkey)
+	permissionsSvc.ConsumeEvent(context.TODO(), &events.Event{Event: "app_secret_regenerated"}, map[string]interface{}{})
+
+	// the old secret can no longer be used
+	_, err = permissionsSvc.GetAppByPubkey(oldPubkey)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	pairingPublicKey, err := nostr.GetPublicKey(pairingSecretKey)
+	assert.NoError(t, err)
+	cachedApp, err := permissionsSvc.GetAppByPubkey(pairingPublicKey)
+	assert.NoError(t, err)
+	assert.Equal(t, app.ID, cachedApp.ID)
+}
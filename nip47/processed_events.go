@@ -0,0 +1,145 @@
+package nip47
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+	nostrmodels "github.com/getAlby/hub/nostr/models"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// createRequestEvent stores a new request event together with its processed event.
+// Returns gorm.ErrDuplicatedKey if the request was received before.
+func (svc *nip47Service) createRequestEvent(requestEvent *db.RequestEvent) error {
+	return svc.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(requestEvent).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&db.ProcessedEvent{
+			NostrId:        requestEvent.NostrId,
+			RequestEventId: requestEvent.ID,
+			State:          requestEvent.State,
+		}).Error
+	})
+}
+
+// saveRequestEvent saves the request event and the state of its processed event
+func (svc *nip47Service) saveRequestEvent(requestEvent *db.RequestEvent) error {
+	return svc.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Save(requestEvent).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&db.ProcessedEvent{}).Where("request_event_id = ?", requestEvent.ID).Update("state", requestEvent.State).Error
+	})
+}
+
+// saveRequestEventState saves the state of a request event that is not loaded
+func (svc *nip47Service) saveRequestEventState(requestEventId uint, state string) error {
+	return svc.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&db.RequestEvent{}).Where("id = ?", requestEventId).Update("state", state).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&db.ProcessedEvent{}).Where("request_event_id = ?", requestEventId).Update("state", state).Error
+	})
+}
+
+// addProcessedResponse adds the response to the processed event of the request
+func addProcessedResponse(tx *gorm.DB, requestEvent *db.RequestEvent, resp *nostr.Event) error {
+	processedEvents := []db.ProcessedEvent{}
+	err := tx.Where("request_event_id = ?", requestEvent.ID).Limit(1).Find(&processedEvents).Error
+	if err != nil {
+		return err
+	}
+	// the request event could not be stored
+	if len(processedEvents) == 0 {
+		return nil
+	}
+	processedEvent := &processedEvents[0]
+
+	responses, err := decodeProcessedResponses(processedEvent)
+	if err != nil {
+		return err
+	}
+	responsesBytes, err := json.Marshal(append(responses, *resp))
+	if err != nil {
+		return err
+	}
+	return tx.Model(processedEvent).Update("responses", string(responsesBytes)).Error
+}
+
+// saveProcessedResponse adds the response of a request that is answered without the outbox
+func (svc *nip47Service) saveProcessedResponse(requestEvent *db.RequestEvent, resp *nostr.Event) {
+	if resp == nil {
+		return
+	}
+	err := addProcessedResponse(svc.db, requestEvent, resp)
+	if err != nil {
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventNostrId": requestEvent.NostrId,
+		}).WithError(err).Error("Failed to save response to processed event")
+	}
+}
+
+// replayProcessedEvent publishes the stored responses again if the request was received before,
+// e.g. because a relay delivered it twice. Returns false for new requests.
+func (svc *nip47Service) replayProcessedEvent(ctx context.Context, relay nostrmodels.Relay, event *nostr.Event) bool {
+	processedEvents := []db.ProcessedEvent{}
+	err := svc.db.Where("nostr_id = ?", event.ID).Limit(1).Find(&processedEvents).Error
+	if err != nil {
+		// storing the request event rejects it as well if it was received before
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventNostrId": event.ID,
+		}).WithError(err).Error("Failed to load processed event")
+		return false
+	}
+	if len(processedEvents) == 0 {
+		return false
+	}
+	processedEvent := &processedEvents[0]
+
+	responses, err := decodeProcessedResponses(processedEvent)
+	if err != nil {
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventNostrId": event.ID,
+		}).WithError(err).Error("Failed to decode responses of processed event")
+		return true
+	}
+	if len(responses) == 0 {
+		// still being handled, or not answered at all, e.g. because it could not be decrypted
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventNostrId": event.ID,
+			"state":               processedEvent.State,
+		}).Warn("Event already processed")
+		return true
+	}
+
+	logger.Nostr.WithFields(logrus.Fields{
+		"requestEventNostrId": event.ID,
+		"state":               processedEvent.State,
+		"responses":           len(responses),
+	}).Info("Event already processed, publishing its responses again")
+	requestEvent := &db.RequestEvent{ID: processedEvent.RequestEventId, NostrId: processedEvent.NostrId}
+	for i := range responses {
+		svc.queueResponse(ctx, relay, requestEvent, &responses[i], nil, nil)
+	}
+	return true
+}
+
+func decodeProcessedResponses(processedEvent *db.ProcessedEvent) ([]nostr.Event, error) {
+	responses := []nostr.Event{}
+	if processedEvent.Responses == "" {
+		return responses, nil
+	}
+	err := json.Unmarshal([]byte(processedEvent.Responses), &responses)
+	if err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
@@ -0,0 +1,77 @@
+package nip47
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleEvent_DeliveredTwice(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	_, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+	reqEvent := createSignedRequest(t, reqPrivateKey, ss, models.GET_INFO_METHOD)
+
+	relay := tests.NewMockRelay()
+	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+	assert.NotNil(t, relay.PublishedEvent)
+	response := relay.PublishedEvent
+
+	processedEvent := db.ProcessedEvent{}
+	assert.NoError(t, svc.DB.Where("nostr_id = ?", reqEvent.ID).First(&processedEvent).Error)
+	assert.Equal(t, db.REQUEST_EVENT_STATE_HANDLER_EXECUTED, processedEvent.State)
+	assert.Contains(t, processedEvent.Responses, response.ID)
+
+	relay = tests.NewMockRelay()
+	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+	assert.NotNil(t, relay.PublishedEvent)
+	assert.Equal(t, response.ID, relay.PublishedEvent.ID)
+
+	var requestEventCount, responseEventCount int64
+	assert.NoError(t, svc.DB.Model(&db.RequestEvent{}).Count(&requestEventCount).Error)
+	assert.NoError(t, svc.DB.Model(&db.ResponseEvent{}).Count(&responseEventCount).Error)
+	assert.Equal(t, int64(1), requestEventCount)
+	assert.Equal(t, int64(1), responseEventCount)
+}
+
+func TestHandleEvent_UnknownAppDeliveredTwice(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	// no app is connected for the key
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	ss, err := nip04.ComputeSharedSecret(svc.Keys.GetNostrPublicKey(), reqPrivateKey)
+	assert.NoError(t, err)
+	reqEvent := createSignedRequest(t, reqPrivateKey, ss, models.GET_INFO_METHOD)
+
+	relay := tests.NewMockRelay()
+	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+	assert.NotNil(t, relay.PublishedEvent)
+	response := relay.PublishedEvent
+
+	relay = tests.NewMockRelay()
+	nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+	nip47svc.WaitForPublishedResponses()
+	assert.NotNil(t, relay.PublishedEvent)
+	assert.Equal(t, response.ID, relay.PublishedEvent.ID)
+
+	processedEvent := db.ProcessedEvent{}
+	assert.NoError(t, svc.DB.Where("nostr_id = ?", reqEvent.ID).First(&processedEvent).Error)
+	assert.Equal(t, db.REQUEST_EVENT_STATE_HANDLER_ERROR, processedEvent.State)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/lnclient"
 	"github.com/getAlby/hub/nip47/models"
 	nostrmodels "github.com/getAlby/hub/nostr/models"
@@ -13,17 +14,38 @@ import (
 
 func (svc *nip47Service) PublishNip47Info(ctx context.Context, relay nostrmodels.Relay, lnClient lnclient.LNClient) error {
 	capabilities := lnClient.GetSupportedNIP47Methods()
-	if len(lnClient.GetSupportedNIP47NotificationTypes()) > 0 {
+	var notificationTypes []string
+	if svc.cfg.IsFeatureEnabled(config.FeatureNotifications) {
+		notificationTypes = lnClient.GetSupportedNIP47NotificationTypes()
+	}
+	if len(notificationTypes) > 0 {
 		capabilities = append(capabilities, "notifications")
 	}
 
+	tags := nostr.Tags{
+		[]string{"notifications", strings.Join(notificationTypes, " ")},
+		[]string{"v", strings.Join(models.SUPPORTED_VERSIONS, " ")},
+	}
+	err := svc.publishInfoEvent(ctx, relay, capabilities, tags, svc.keys.GetNostrSecretKey())
+	if err != nil {
+		return err
+	}
+
+	// clients still connected to the previous key can migrate to the new one
+	if previousPubkey := svc.keys.GetPreviousNostrPublicKey(); previousPubkey != "" {
+		migrationTags := append(tags, []string{"new_pubkey", svc.keys.GetNostrPublicKey()})
+		return svc.publishInfoEvent(ctx, relay, capabilities, migrationTags, svc.keys.GetNostrSecretKeyFor(previousPubkey))
+	}
+	return nil
+}
+
+func (svc *nip47Service) publishInfoEvent(ctx context.Context, relay nostrmodels.Relay, capabilities []string, tags nostr.Tags, secretKey string) error {
 	ev := &nostr.Event{}
 	ev.Kind = models.INFO_EVENT_KIND
 	ev.Content = strings.Join(capabilities, " ")
 	ev.CreatedAt = nostr.Now()
-	ev.PubKey = svc.keys.GetNostrPublicKey()
-	ev.Tags = nostr.Tags{[]string{"notifications", strings.Join(lnClient.GetSupportedNIP47NotificationTypes(), " ")}}
-	err := ev.Sign(svc.keys.GetNostrSecretKey())
+	ev.Tags = tags
+	err := ev.Sign(secretKey)
 	if err != nil {
 		return err
 	}
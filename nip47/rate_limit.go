@@ -0,0 +1,61 @@
+package nip47
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+)
+
+const rateLimitWindow = time.Minute
+
+// checkRateLimit counts the request of the app in the current window of NIP47_RATE_LIMIT_PER_MIN.
+// The counters are kept in the database, so the limit holds for all replicas that share it.
+func (svc *nip47Service) checkRateLimit(appId uint) error {
+	limit := svc.cfg.GetEnv().Nip47RateLimitPerMin
+	if limit <= 0 {
+		return nil
+	}
+
+	windowStart := time.Now().Truncate(rateLimitWindow)
+	var counter db.RequestCounter
+	err := svc.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "app_id"}, {Name: "window_start"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"requests": gorm.Expr("request_counters.requests + 1"),
+			}),
+		}).Create(&db.RequestCounter{AppId: appId, WindowStart: windowStart, Requests: 1}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.First(&counter, "app_id = ? AND window_start = ?", appId, windowStart).Error
+		if err != nil {
+			return err
+		}
+		if counter.Requests == 1 {
+			// the first request of a window removes the previous windows of the app
+			return tx.Where("app_id = ? AND window_start < ?", appId, windowStart).Delete(&db.RequestCounter{}).Error
+		}
+		return nil
+	})
+	if err != nil {
+		// requests are not rejected because the counter is unavailable
+		logger.Nostr.WithField("appId", appId).WithError(err).Error("Failed to count request for the rate limit")
+		return nil
+	}
+
+	if counter.Requests > limit {
+		logger.Nostr.WithFields(logrus.Fields{
+			"appId":    appId,
+			"requests": counter.Requests,
+		}).Warn("App exceeded the rate limit")
+		return fmt.Errorf("too many requests: at most %d per minute", limit)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package nip47
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+)
+
+func TestHandleEvent_RateLimitIsSharedByReplicas(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().Nip47RateLimitPerMin = 2
+	// two services on the same database, like two replicas of the hub
+	replicas := []*nip47Service{
+		NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher),
+		NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher),
+	}
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	app, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	handle := func(nip47svc *nip47Service) *models.Response {
+		relay := tests.NewMockRelay()
+		nip47svc.HandleEvent(context.TODO(), relay, createSignedRequest(t, reqPrivateKey, ss, models.GET_INFO_METHOD), svc.LNClient)
+		nip47svc.WaitForPublishedResponses()
+		assert.NotNil(t, relay.PublishedEvent)
+		decrypted, err := nip04.Decrypt(relay.PublishedEvent.Content, ss)
+		assert.NoError(t, err)
+		response := &models.Response{}
+		assert.NoError(t, json.Unmarshal([]byte(decrypted), response))
+		return response
+	}
+
+	assert.Nil(t, handle(replicas[0]).Error)
+	assert.Nil(t, handle(replicas[1]).Error)
+
+	response := handle(replicas[0])
+	assert.NotNil(t, response.Error)
+	assert.Equal(t, models.ERROR_RATE_LIMITED, response.Error.Code)
+	assert.Equal(t, "too many requests: at most 2 per minute", response.Error.Message)
+
+	var counter db.RequestCounter
+	assert.NoError(t, svc.DB.First(&counter, "app_id = ?", app.ID).Error)
+	assert.Equal(t, 3, counter.Requests)
+}
@@ -0,0 +1,201 @@
+package nip47
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+	nostrmodels "github.com/getAlby/hub/nostr/models"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+)
+
+var errRelayDisconnected = errors.New("relay disconnected")
+
+const (
+	defaultResponseQueueSize = 100
+	// how long the dispatcher holds back a new request while the response queue is full
+	responseQueueDeferTimeout = 10 * time.Second
+)
+
+type queuedResponse struct {
+	ctx          context.Context
+	relay        nostrmodels.Relay
+	requestEvent *db.RequestEvent
+	resp         *nostr.Event
+	app          *db.App
+	// removed from the outbox once the response was published, 0 if the response is not in the outbox
+	outboxResponseId uint
+	// called by the publisher once the response was published or failed to publish.
+	// A queued response without an event only calls onPublished, once the responses queued before it are published
+	onPublished func(err error)
+}
+
+// responseQueue decouples request handlers from the relay.
+// Responses are published one at a time in the order they were queued. When the relay is slow the queue fills up,
+// which the dispatcher uses as a signal to hold back new requests instead of handling them
+// and blocking on the websocket.
+type responseQueue struct {
+	responses chan *queuedResponse
+	// receives a token whenever a response leaves the queue, to wake up a deferred request
+	space   chan struct{}
+	pending sync.WaitGroup
+}
+
+func newResponseQueue(size int) *responseQueue {
+	if size <= 0 {
+		size = defaultResponseQueueSize
+	}
+	return &responseQueue{
+		responses: make(chan *queuedResponse, size),
+		space:     make(chan struct{}, 1),
+	}
+}
+
+// push blocks while the queue is full
+func (queue *responseQueue) push(response *queuedResponse) {
+	queue.pending.Add(1)
+	queue.responses <- response
+}
+
+func (queue *responseQueue) hasCapacity() bool {
+	return len(queue.responses) < cap(queue.responses)
+}
+
+// waitForCapacity returns false if the queue is still full after the timeout
+func (queue *responseQueue) waitForCapacity(ctx context.Context, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for !queue.hasCapacity() {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			return false
+		case <-queue.space:
+		}
+	}
+	return true
+}
+
+func (svc *nip47Service) publishQueuedResponses() {
+	for response := range svc.responseQueue.responses {
+		select {
+		case svc.responseQueue.space <- struct{}{}:
+		default:
+		}
+
+		var err error
+		if response.resp != nil {
+			err = svc.publishQueuedResponse(response)
+		}
+		if response.onPublished != nil {
+			response.onPublished(err)
+		}
+		svc.responseQueue.pending.Done()
+	}
+}
+
+// publishQueuedResponse publishes the response, or keeps it in the outbox if it failed to publish.
+// The remaining responses of a relay that disconnected are not sent to it, they are published
+// to the relay the hub reconnected to, or after the hub reconnects.
+func (svc *nip47Service) publishQueuedResponse(response *queuedResponse) error {
+	relay := response.relay
+	for {
+		err := errRelayDisconnected
+		if !isDisconnected(relay) {
+			err = svc.publishResponseEvent(response.ctx, relay, response.requestEvent, response.resp, response.app)
+		}
+		if err == nil {
+			if response.outboxResponseId != 0 {
+				svc.deleteOutboxResponse(response.outboxResponseId)
+			}
+			return nil
+		}
+		relay = svc.keepInOutbox(response, relay)
+		if relay == nil {
+			return err
+		}
+	}
+}
+
+// keepInOutbox stores the response in the outbox, unless the hub reconnected since the failed relay
+// disconnected. In that case the relay of the new subscription is returned to publish the response to.
+func (svc *nip47Service) keepInOutbox(response *queuedResponse, failedRelay nostrmodels.Relay) nostrmodels.Relay {
+	svc.relayMu.Lock()
+	defer svc.relayMu.Unlock()
+	if svc.relay != nil && svc.relay != failedRelay && isDisconnected(failedRelay) && !isDisconnected(svc.relay) {
+		return svc.relay
+	}
+	if response.outboxResponseId != 0 {
+		return nil
+	}
+	// the request event could not be saved
+	if response.requestEvent == nil || response.requestEvent.ID == 0 {
+		return nil
+	}
+
+	outboxResponse, err := svc.requeueToOutbox(response.requestEvent, response.resp)
+	if err != nil {
+		logger.Nostr.WithFields(logrus.Fields{
+			"requestEventId":       response.requestEvent.ID,
+			"responseNostrEventId": response.resp.ID,
+		}).WithError(err).Error("Failed to requeue response to the outbox")
+		return nil
+	}
+	response.outboxResponseId = outboxResponse.ID
+	logger.Nostr.WithFields(logrus.Fields{
+		"requestEventId":       response.requestEvent.ID,
+		"responseNostrEventId": response.resp.ID,
+		"outboxResponseId":     outboxResponse.ID,
+	}).Warn("Requeued unpublished response to the outbox")
+	return nil
+}
+
+// isDisconnected returns false for relays that do not know their connection state
+func isDisconnected(relay nostrmodels.Relay) bool {
+	connection, ok := relay.(interface{ IsConnected() bool })
+	return ok && !connection.IsConnected()
+}
+
+func (svc *nip47Service) queueResponse(ctx context.Context, relay nostrmodels.Relay, requestEvent *db.RequestEvent, resp *nostr.Event, app *db.App, onPublished func(err error)) {
+	svc.queueOutboxResponse(ctx, relay, requestEvent, resp, app, 0, onPublished)
+}
+
+func (svc *nip47Service) queueOutboxResponse(ctx context.Context, relay nostrmodels.Relay, requestEvent *db.RequestEvent, resp *nostr.Event, app *db.App, outboxResponseId uint, onPublished func(err error)) {
+	response := &queuedResponse{
+		ctx:              ctx,
+		relay:            relay,
+		requestEvent:     requestEvent,
+		resp:             resp,
+		app:              app,
+		outboxResponseId: outboxResponseId,
+		onPublished:      onPublished,
+	}
+	// responses to requests sent over HTTP are returned to the client right away
+	if isHttpResponseRelay(relay) {
+		var err error
+		if resp != nil {
+			err = svc.publishQueuedResponse(response)
+		}
+		if onPublished != nil {
+			onPublished(err)
+		}
+		return
+	}
+	svc.responseQueue.push(response)
+}
+
+// DeferWhileResponseQueueFull holds back a new request while the relay is not keeping up with the responses.
+// Requests that are handled while the queue is still full are rejected with a RATE_LIMITED error.
+func (svc *nip47Service) DeferWhileResponseQueueFull(ctx context.Context) bool {
+	return svc.responseQueue.waitForCapacity(ctx, responseQueueDeferTimeout)
+}
+
+// WaitForPublishedResponses blocks until all queued responses have been published
+func (svc *nip47Service) WaitForPublishedResponses() {
+	svc.responseQueue.pending.Wait()
+}
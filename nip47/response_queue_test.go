@@ -0,0 +1,103 @@
+package nip47
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingRelay holds every publish until it is released
+type blockingRelay struct {
+	release chan struct{}
+}
+
+func (relay *blockingRelay) Publish(ctx context.Context, event nostr.Event) error {
+	<-relay.release
+	return nil
+}
+
+func TestHandleResponse_ResponseQueueFull(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().Nip47ResponseQueueSize = 1
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	reqPubkey, err := nostr.GetPublicKey(reqPrivateKey)
+	assert.NoError(t, err)
+	_, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	// one response is being published and another one is waiting
+	slowRelay := &blockingRelay{release: make(chan struct{})}
+	for _, nostrId := range []string{"slow1", "slow2"} {
+		requestEvent := &db.RequestEvent{NostrId: nostrId}
+		err = svc.DB.Create(requestEvent).Error
+		assert.NoError(t, err)
+		nip47svc.queueResponse(context.TODO(), slowRelay, requestEvent, &nostr.Event{ID: nostrId}, nil, nil)
+		if nostrId == "slow1" {
+			// wait for the publisher to pick it up
+			assert.Eventually(t, nip47svc.responseQueue.hasCapacity, time.Second, time.Millisecond)
+		}
+	}
+	assert.False(t, nip47svc.responseQueue.hasCapacity())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.False(t, nip47svc.DeferWhileResponseQueueFull(ctx))
+
+	payloadBytes, err := json.Marshal(map[string]interface{}{
+		"method": models.GET_INFO_METHOD,
+	})
+	assert.NoError(t, err)
+	msg, err := nip04.Encrypt(string(payloadBytes), ss)
+	assert.NoError(t, err)
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		PubKey:    reqPubkey,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{},
+		Content:   msg,
+	}
+	err = reqEvent.Sign(reqPrivateKey)
+	assert.NoError(t, err)
+
+	relay := tests.NewMockRelay()
+	handled := make(chan struct{})
+	go func() {
+		nip47svc.HandleEvent(context.TODO(), relay, reqEvent, svc.LNClient)
+		close(handled)
+	}()
+
+	// wait until the request was parsed, the rejection is queued right after
+	assert.Eventually(t, func() bool {
+		requestEvent := db.RequestEvent{}
+		return svc.DB.First(&requestEvent, &db.RequestEvent{NostrId: reqEvent.ID}).Error == nil && requestEvent.Method != ""
+	}, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	close(slowRelay.release)
+	<-handled
+	nip47svc.WaitForPublishedResponses()
+
+	assert.NotNil(t, relay.PublishedEvent)
+	decrypted, err := nip04.Decrypt(relay.PublishedEvent.Content, ss)
+	assert.NoError(t, err)
+	unmarshalledResponse := models.Response{}
+	err = json.Unmarshal([]byte(decrypted), &unmarshalledResponse)
+	assert.NoError(t, err)
+	assert.Nil(t, unmarshalledResponse.Result)
+	assert.Equal(t, models.GET_INFO_METHOD, unmarshalledResponse.ResultType)
+	assert.Equal(t, models.ERROR_RATE_LIMITED, unmarshalledResponse.Error.Code)
+
+	assert.True(t, nip47svc.DeferWhileResponseQueueFull(context.Background()))
+}
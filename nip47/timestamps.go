@@ -0,0 +1,58 @@
+package nip47
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+)
+
+type receivedAtKey struct{}
+
+// WithReceivedAt sets the time the request event was received from the relay. The timestamps of
+// the event are checked against this time, so that requests that waited in the request queue
+// are not rejected because of the backlog of the hub.
+func WithReceivedAt(ctx context.Context, receivedAt time.Time) context.Context {
+	return context.WithValue(ctx, receivedAtKey{}, receivedAt)
+}
+
+func receivedAt(ctx context.Context) time.Time {
+	receivedAt, ok := ctx.Value(receivedAtKey{}).(time.Time)
+	if !ok {
+		return time.Now()
+	}
+	return receivedAt
+}
+
+// checkEventTimestamps rejects events older than NIP47_REQUEST_MAX_AGE_SECS and events with an
+// expiration tag that passed, so that stale or pre-signed requests cannot trigger payments long
+// after they were created. The clocks of the app and the hub may differ by NIP47_CLOCK_SKEW_SECS
+// in either direction.
+func (svc *nip47Service) checkEventTimestamps(event *nostr.Event, receivedAt time.Time) error {
+	clockSkew := time.Duration(svc.cfg.GetEnv().Nip47ClockSkewSecs) * time.Second
+	createdAt := event.CreatedAt.Time()
+	if createdAt.Sub(receivedAt) > clockSkew {
+		return errors.New("request event is dated in the future")
+	}
+
+	maxAgeSecs := svc.cfg.GetEnv().Nip47RequestMaxAgeSecs
+	if maxAgeSecs > 0 && receivedAt.Sub(createdAt) > time.Duration(maxAgeSecs)*time.Second+clockSkew {
+		return fmt.Errorf("request event is older than %d seconds", maxAgeSecs)
+	}
+
+	expirationTag := event.Tags.GetFirst([]string{"expiration"})
+	if expirationTag == nil {
+		return nil
+	}
+	expiration, err := strconv.ParseInt(expirationTag.Value(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expiration tag: %q", expirationTag.Value())
+	}
+	if receivedAt.Sub(time.Unix(expiration, 0)) > clockSkew {
+		return errors.New("request event has expired")
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package nip47
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckEventTimestamps(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().Nip47RequestMaxAgeSecs = 600
+	svc.Cfg.GetEnv().Nip47ClockSkewSecs = 120
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	receivedAt := time.Now()
+	createEvent := func(createdAt time.Time, tags nostr.Tags) *nostr.Event {
+		return &nostr.Event{CreatedAt: nostr.Timestamp(createdAt.Unix()), Tags: tags}
+	}
+	expirationTag := func(expiration time.Time) nostr.Tags {
+		return nostr.Tags{[]string{"expiration", strconv.FormatInt(expiration.Unix(), 10)}}
+	}
+
+	// within the max age and the clock skew
+	assert.NoError(t, nip47svc.checkEventTimestamps(createEvent(receivedAt.Add(-11*time.Minute), nostr.Tags{}), receivedAt))
+	assert.NoError(t, nip47svc.checkEventTimestamps(createEvent(receivedAt.Add(time.Minute), nostr.Tags{}), receivedAt))
+	assert.NoError(t, nip47svc.checkEventTimestamps(createEvent(receivedAt, expirationTag(receivedAt.Add(-time.Minute))), receivedAt))
+
+	assert.Error(t, nip47svc.checkEventTimestamps(createEvent(receivedAt.Add(-13*time.Minute), nostr.Tags{}), receivedAt))
+	assert.Error(t, nip47svc.checkEventTimestamps(createEvent(receivedAt.Add(3*time.Minute), nostr.Tags{}), receivedAt))
+	assert.Error(t, nip47svc.checkEventTimestamps(createEvent(receivedAt, expirationTag(receivedAt.Add(-3*time.Minute))), receivedAt))
+	assert.Error(t, nip47svc.checkEventTimestamps(createEvent(receivedAt, nostr.Tags{[]string{"expiration", "soon"}}), receivedAt))
+
+	// the expiration tag is checked even if the max age is disabled
+	svc.Cfg.GetEnv().Nip47RequestMaxAgeSecs = 0
+	assert.NoError(t, nip47svc.checkEventTimestamps(createEvent(receivedAt.Add(-time.Hour), nostr.Tags{}), receivedAt))
+	assert.Error(t, nip47svc.checkEventTimestamps(createEvent(receivedAt.Add(-time.Hour), expirationTag(receivedAt.Add(-time.Hour))), receivedAt))
+}
+
+func TestReceivedAt(t *testing.T) {
+	receivedAtTime := time.Now().Add(-time.Minute)
+	assert.Equal(t, receivedAtTime, receivedAt(WithReceivedAt(context.TODO(), receivedAtTime)))
+	assert.WithinDuration(t, time.Now(), receivedAt(context.TODO()), time.Second)
+}
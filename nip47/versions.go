@@ -0,0 +1,88 @@
+package nip47
+
+import (
+	"crypto/rand"
+	"fmt"
+	"slices"
+
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/nbd-wtf/go-nostr/nip44"
+)
+
+// nip47Cipher encrypts the content of the events exchanged with an app,
+// the encryption depends on the protocol version of the request
+type nip47Cipher interface {
+	Version() string
+	Encrypt(plaintext string) (string, error)
+	Decrypt(ciphertext string) (string, error)
+}
+
+// requestVersion returns the version requested by the "v" tag of the event
+func requestVersion(event *nostr.Event) string {
+	vTag := event.Tags.GetFirst([]string{"v"})
+	if vTag == nil || vTag.Value() == "" {
+		return models.VERSION_0_0
+	}
+	return vTag.Value()
+}
+
+func newNip47Cipher(version string, appPubkey string, walletSecretKey string) (nip47Cipher, error) {
+	if !slices.Contains(models.SUPPORTED_VERSIONS, version) {
+		return nil, fmt.Errorf("unsupported NIP-47 version: %s", version)
+	}
+
+	switch version {
+	case models.VERSION_1_0:
+		conversationKey, err := nip44.GenerateConversationKey(appPubkey, walletSecretKey)
+		if err != nil {
+			return nil, err
+		}
+		return &nip44Cipher{conversationKey: conversationKey}, nil
+	default:
+		ss, err := nip04.ComputeSharedSecret(appPubkey, walletSecretKey)
+		if err != nil {
+			return nil, err
+		}
+		return &nip04Cipher{sharedSecret: ss}, nil
+	}
+}
+
+type nip04Cipher struct {
+	sharedSecret []byte
+}
+
+func (cipher *nip04Cipher) Version() string {
+	return models.VERSION_0_0
+}
+
+func (cipher *nip04Cipher) Encrypt(plaintext string) (string, error) {
+	return nip04.Encrypt(plaintext, cipher.sharedSecret)
+}
+
+func (cipher *nip04Cipher) Decrypt(ciphertext string) (string, error) {
+	return nip04.Decrypt(ciphertext, cipher.sharedSecret)
+}
+
+type nip44Cipher struct {
+	conversationKey []byte
+}
+
+func (cipher *nip44Cipher) Version() string {
+	return models.VERSION_1_0
+}
+
+func (cipher *nip44Cipher) Encrypt(plaintext string) (string, error) {
+	// go-nostr does not generate the salt itself when none is passed
+	salt := make([]byte, 32)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	return nip44.Encrypt(plaintext, cipher.conversationKey, nip44.WithCustomSalt(salt))
+}
+
+func (cipher *nip44Cipher) Decrypt(ciphertext string) (string, error) {
+	return nip44.Decrypt(ciphertext, cipher.conversationKey)
+}
@@ -0,0 +1,100 @@
+package nip47
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+	"github.com/nbd-wtf/go-nostr/nip44"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleEvent_Version1(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	app, _, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.GET_BALANCE_SCOPE,
+	}).Error
+	assert.NoError(t, err)
+
+	conversationKey, err := nip44.GenerateConversationKey(svc.Keys.GetNostrPublicKey(), reqPrivateKey)
+	assert.NoError(t, err)
+	payloadBytes, err := json.Marshal(map[string]interface{}{
+		"method": models.GET_BALANCE_METHOD,
+	})
+	assert.NoError(t, err)
+	msg, err := (&nip44Cipher{conversationKey: conversationKey}).Encrypt(string(payloadBytes))
+	assert.NoError(t, err)
+
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{[]string{"p", svc.Keys.GetNostrPublicKey()}, []string{"v", models.VERSION_1_0}},
+		Content:   msg,
+	}
+	err = reqEvent.Sign(reqPrivateKey)
+	assert.NoError(t, err)
+
+	responses := nip47svc.HandleEventSync(context.TODO(), reqEvent, svc.LNClient)
+	assert.Equal(t, 1, len(responses))
+	assert.Equal(t, models.VERSION_1_0, responses[0].Tags.GetFirst([]string{"v"}).Value())
+
+	decrypted, err := nip44.Decrypt(responses[0].Content, conversationKey)
+	assert.NoError(t, err)
+	response := models.Response{}
+	err = json.Unmarshal([]byte(decrypted), &response)
+	assert.NoError(t, err)
+	assert.Nil(t, response.Error)
+	assert.Equal(t, models.GET_BALANCE_METHOD, response.ResultType)
+}
+
+func TestHandleEvent_UnsupportedVersion(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	nip47svc := NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher)
+
+	reqPrivateKey := nostr.GeneratePrivateKey()
+	_, ss, err := tests.CreateAppWithPrivateKey(svc, reqPrivateKey)
+	assert.NoError(t, err)
+
+	reqEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{[]string{"p", svc.Keys.GetNostrPublicKey()}, []string{"v", "9.0"}},
+		Content:   "unknown encryption",
+	}
+	err = reqEvent.Sign(reqPrivateKey)
+	assert.NoError(t, err)
+
+	responses := nip47svc.HandleEventSync(context.TODO(), reqEvent, svc.LNClient)
+	assert.Equal(t, 1, len(responses))
+	assert.Nil(t, responses[0].Tags.GetFirst([]string{"v"}))
+
+	// the error is encrypted with NIP-04 so that every app can read it
+	decrypted, err := nip04.Decrypt(responses[0].Content, ss)
+	assert.NoError(t, err)
+	response := models.Response{}
+	err = json.Unmarshal([]byte(decrypted), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ERROR_UNSUPPORTED_VERSION, response.Error.Code)
+}
+
+func TestRequestVersion(t *testing.T) {
+	assert.Equal(t, models.VERSION_0_0, requestVersion(&nostr.Event{}))
+	assert.Equal(t, models.VERSION_1_0, requestVersion(&nostr.Event{Tags: nostr.Tags{[]string{"v", "1.0"}}}))
+}
@@ -0,0 +1,294 @@
+// Package relay is a minimal nostr relay for the all-in-one mode, in which the hub serves its own relay.
+// It only accepts the event kinds of NIP-47 and keeps events in memory.
+package relay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+)
+
+// requests larger than this are rejected by the hub anyway, see NIP-47 payload limits
+const maxMessageSize = 512 * 1024
+
+// events are kept for apps that subscribe after the hub published the response.
+// Requests older than NIP47_REQUEST_MAX_AGE_SECS are not handled by the hub, so there is no need to keep them longer.
+const (
+	eventRetention = 10 * time.Minute
+	maxEvents      = 10000
+)
+
+var acceptedKinds = []int{models.INFO_EVENT_KIND, models.REQUEST_KIND, models.RESPONSE_KIND, models.NOTIFICATION_KIND}
+
+type storedEvent struct {
+	event      *nostr.Event
+	receivedAt time.Time
+}
+
+type Relay struct {
+	mu     sync.Mutex
+	events []storedEvent
+	// replaceable events, e.g. the NIP-47 info event, are kept until they are replaced
+	replaceableEvents map[string]*nostr.Event
+	conns             map[*relayConn]struct{}
+	now               func() time.Time
+}
+
+type relayConn struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	// guarded by the mutex of the relay
+	subscriptions map[string]nostr.Filters
+}
+
+func NewRelay() *Relay {
+	return &Relay{
+		replaceableEvents: map[string]*nostr.Event{},
+		conns:             map[*relayConn]struct{}{},
+		now:               time.Now,
+	}
+}
+
+// ServeHTTP accepts websocket connections of clients and answers NIP-11 requests
+func (relay *Relay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		if r.Header.Get("Accept") == "application/nostr+json" {
+			w.Header().Set("Content-Type", "application/nostr+json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"name":           "Alby Hub",
+				"description":    "The relay of this hub, for its connected apps only",
+				"supported_nips": []int{1, 11, 47},
+			})
+			return
+		}
+		http.Error(w, "This is a nostr relay, connect to it with a websocket", http.StatusBadRequest)
+		return
+	}
+
+	netConn, _, _, err := ws.UpgradeHTTP(r, w)
+	if err != nil {
+		logger.Nostr.WithError(err).Error("Failed to upgrade relay connection")
+		return
+	}
+	conn := &relayConn{conn: netConn, subscriptions: map[string]nostr.Filters{}}
+	relay.mu.Lock()
+	relay.conns[conn] = struct{}{}
+	relay.mu.Unlock()
+
+	defer func() {
+		relay.mu.Lock()
+		delete(relay.conns, conn)
+		relay.mu.Unlock()
+		netConn.Close()
+	}()
+
+	for {
+		message, err := conn.read()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				logger.Nostr.WithError(err).Debug("Closing relay connection")
+			}
+			return
+		}
+		relay.handleMessage(conn, message)
+	}
+}
+
+// CloseConnections closes the connections of all clients
+func (relay *Relay) CloseConnections() {
+	relay.mu.Lock()
+	defer relay.mu.Unlock()
+	for conn := range relay.conns {
+		conn.conn.Close()
+		delete(relay.conns, conn)
+	}
+}
+
+func (relay *Relay) handleMessage(conn *relayConn, message []byte) {
+	switch envelope := nostr.ParseMessage(message).(type) {
+	case *nostr.EventEnvelope:
+		event := envelope.Event
+		if !slices.Contains(acceptedKinds, event.Kind) {
+			conn.write(&nostr.OKEnvelope{EventID: event.ID, OK: false, Reason: "blocked: this relay only accepts NIP-47 events"})
+			return
+		}
+		if ok, err := event.CheckSignature(); !ok || err != nil {
+			conn.write(&nostr.OKEnvelope{EventID: event.ID, OK: false, Reason: "invalid: bad signature"})
+			return
+		}
+		relay.publish(&event)
+		conn.write(&nostr.OKEnvelope{EventID: event.ID, OK: true})
+	case *nostr.ReqEnvelope:
+		relay.mu.Lock()
+		conn.subscriptions[envelope.SubscriptionID] = envelope.Filters
+		stored := relay.storedEvents(envelope.Filters)
+		relay.mu.Unlock()
+		for _, event := range stored {
+			conn.write(&nostr.EventEnvelope{SubscriptionID: &envelope.SubscriptionID, Event: *event})
+		}
+		eose := nostr.EOSEEnvelope(envelope.SubscriptionID)
+		conn.write(&eose)
+	case *nostr.CloseEnvelope:
+		relay.mu.Lock()
+		delete(conn.subscriptions, string(*envelope))
+		relay.mu.Unlock()
+	case nil:
+		notice := nostr.NoticeEnvelope("error: failed to parse message")
+		conn.write(&notice)
+	}
+}
+
+// storedEvents returns the stored events that match the filters, oldest first
+func (relay *Relay) storedEvents(filters nostr.Filters) []*nostr.Event {
+	relay.pruneEvents()
+	limit := 0
+	for _, filter := range filters {
+		if filter.Limit <= 0 {
+			limit = 0
+			break
+		}
+		limit = max(limit, filter.Limit)
+	}
+
+	events := []*nostr.Event{}
+	for _, event := range relay.replaceableEvents {
+		if filters.Match(event) {
+			events = append(events, event)
+		}
+	}
+	for _, stored := range relay.events {
+		if filters.Match(stored.event) {
+			events = append(events, stored.event)
+		}
+	}
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events
+}
+
+// publish stores the event and sends it to the matching subscriptions
+func (relay *Relay) publish(event *nostr.Event) {
+	type delivery struct {
+		conn           *relayConn
+		subscriptionId string
+	}
+	deliveries := []delivery{}
+	relay.mu.Lock()
+	if isReplaceableKind(event.Kind) {
+		key := fmt.Sprintf("%s:%d", event.PubKey, event.Kind)
+		if previous, ok := relay.replaceableEvents[key]; ok && previous.CreatedAt > event.CreatedAt {
+			relay.mu.Unlock()
+			return
+		}
+		relay.replaceableEvents[key] = event
+	} else {
+		relay.pruneEvents()
+		relay.events = append(relay.events, storedEvent{event: event, receivedAt: relay.now()})
+	}
+	for conn := range relay.conns {
+		for subscriptionId, filters := range conn.subscriptions {
+			if filters.Match(event) {
+				deliveries = append(deliveries, delivery{conn: conn, subscriptionId: subscriptionId})
+			}
+		}
+	}
+	relay.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		delivery.conn.write(&nostr.EventEnvelope{SubscriptionID: &delivery.subscriptionId, Event: *event})
+	}
+}
+
+// see NIP-01
+func isReplaceableKind(kind int) bool {
+	return kind == 0 || kind == 3 || (kind >= 10000 && kind < 20000)
+}
+
+// pruneEvents removes the events that are older than the retention, and the oldest ones above maxEvents
+func (relay *Relay) pruneEvents() {
+	now := relay.now()
+	expired := 0
+	for expired < len(relay.events) && (now.Sub(relay.events[expired].receivedAt) > eventRetention || len(relay.events)-expired >= maxEvents) {
+		expired++
+	}
+	if expired > 0 {
+		relay.events = slices.Delete(relay.events, 0, expired)
+	}
+}
+
+// read returns the next text or binary message of the client
+func (conn *relayConn) read() ([]byte, error) {
+	reader := wsutil.Reader{
+		Source:       conn.conn,
+		State:        ws.StateServerSide,
+		CheckUTF8:    true,
+		MaxFrameSize: maxMessageSize,
+	}
+	// pings are answered while the message is read
+	controlHandler := wsutil.ControlFrameHandler(lockedWriter{conn}, ws.StateServerSide)
+	reader.OnIntermediate = controlHandler
+	for {
+		header, err := reader.NextFrame()
+		if err != nil {
+			return nil, err
+		}
+		if header.OpCode.IsControl() {
+			err = controlHandler(header, &reader)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		message, err := io.ReadAll(io.LimitReader(&reader, maxMessageSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(message) > maxMessageSize {
+			return nil, wsutil.ErrFrameTooLarge
+		}
+		return message, nil
+	}
+}
+
+func (conn *relayConn) write(envelope nostr.Envelope) {
+	message, err := envelope.MarshalJSON()
+	if err != nil {
+		logger.Nostr.WithError(err).Error("Failed to encode relay message")
+		return
+	}
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	err = wsutil.WriteServerMessage(conn.conn, ws.OpText, message)
+	if err != nil {
+		// the client receives no more messages once its connection is closed
+		logger.Nostr.WithFields(logrus.Fields{
+			"remoteAddr": conn.conn.RemoteAddr().String(),
+		}).WithError(err).Debug("Failed to write to relay connection")
+	}
+}
+
+// lockedWriter writes the answers to control frames without interleaving them with other messages
+type lockedWriter struct {
+	conn *relayConn
+}
+
+func (writer lockedWriter) Write(p []byte) (int, error) {
+	writer.conn.writeMu.Lock()
+	defer writer.conn.writeMu.Unlock()
+	return writer.conn.conn.Write(p)
+}
@@ -0,0 +1,52 @@
+package relay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedEvent(t *testing.T, privateKey string, kind int, createdAt nostr.Timestamp) *nostr.Event {
+	event := &nostr.Event{Kind: kind, CreatedAt: createdAt, Tags: nostr.Tags{}}
+	assert.NoError(t, event.Sign(privateKey))
+	return event
+}
+
+func TestPublish_ReplaceableEventIsReplaced(t *testing.T) {
+	relay := NewRelay()
+	privateKey := nostr.GeneratePrivateKey()
+
+	first := signedEvent(t, privateKey, models.INFO_EVENT_KIND, 100)
+	second := signedEvent(t, privateKey, models.INFO_EVENT_KIND, 200)
+	relay.publish(first)
+	relay.publish(second)
+	// an older info event does not replace the newer one
+	relay.publish(signedEvent(t, privateKey, models.INFO_EVENT_KIND, 150))
+
+	events := relay.storedEvents(nostr.Filters{{Kinds: []int{models.INFO_EVENT_KIND}}})
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, second.ID, events[0].ID)
+}
+
+func TestStoredEvents_ExpiredEventsArePruned(t *testing.T) {
+	relay := NewRelay()
+	now := time.Now()
+	relay.now = func() time.Time { return now }
+	privateKey := nostr.GeneratePrivateKey()
+
+	expired := signedEvent(t, privateKey, models.REQUEST_KIND, nostr.Now())
+	relay.publish(expired)
+	now = now.Add(eventRetention + time.Second)
+	recent := signedEvent(t, privateKey, models.REQUEST_KIND, nostr.Now())
+	relay.publish(recent)
+
+	events := relay.storedEvents(nostr.Filters{{Kinds: []int{models.REQUEST_KIND}}})
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, recent.ID, events[0].ID)
+
+	limited := relay.storedEvents(nostr.Filters{{Kinds: []int{models.REQUEST_KIND}, Limit: 1}})
+	assert.Equal(t, 1, len(limited))
+}
@@ -0,0 +1,104 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportMailer emails the HTML report with the PDF attached
+type reportMailer struct {
+	smtpAddr     string
+	smtpUsername string
+	smtpPassword string
+	from         string
+	to           string
+	// smtp.SendMail, replaced in tests
+	sendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func newReportMailer(smtpHost string, smtpPort int, smtpUsername string, smtpPassword string, from string, to string) *reportMailer {
+	return &reportMailer{
+		smtpAddr:     net.JoinHostPort(smtpHost, strconv.Itoa(smtpPort)),
+		smtpUsername: smtpUsername,
+		smtpPassword: smtpPassword,
+		from:         from,
+		to:           to,
+		sendMail:     smtp.SendMail,
+	}
+}
+
+func (mailer *reportMailer) send(subject string, html []byte, pdf []byte, pdfFileName string) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	htmlPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+	writeBase64(htmlPart, html)
+
+	pdfPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"application/pdf"},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", pdfFileName)},
+	})
+	if err != nil {
+		return err
+	}
+	writeBase64(pdfPart, pdf)
+
+	err = writer.Close()
+	if err != nil {
+		return err
+	}
+
+	headers := []string{
+		"From: " + mailer.from,
+		"To: " + mailer.to,
+		// the brand name in the subject can contain any characters
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + writer.Boundary(),
+	}
+	message := strings.Join(headers, "\r\n") + "\r\n\r\n" + body.String()
+
+	var auth smtp.Auth
+	if mailer.smtpUsername != "" {
+		host, _, _ := net.SplitHostPort(mailer.smtpAddr)
+		auth = smtp.PlainAuth("", mailer.smtpUsername, mailer.smtpPassword, host)
+	}
+	fromAddress, err := mail.ParseAddress(mailer.from)
+	if err != nil {
+		return err
+	}
+	toAddress, err := mail.ParseAddress(mailer.to)
+	if err != nil {
+		return err
+	}
+	return mailer.sendMail(mailer.smtpAddr, auth, fromAddress.Address, []string{toAddress.Address}, []byte(message))
+}
+
+// writeBase64 writes the data in lines of 76 characters, as required for emails
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
@@ -0,0 +1,112 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// page layout of the PDF in points, A4 with a font size of 10
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 10
+	pdfLineHeight   = 14
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	// a line of Helvetica at the font size fits about this many characters
+	pdfMaxLineLength = 95
+)
+
+// writePDF renders the lines as plain text in a PDF document. The report only contains
+// text, so this avoids a dependency on a PDF library.
+func writePDF(lines []string) []byte {
+	wrappedLines := []string{}
+	for _, line := range lines {
+		wrappedLines = append(wrappedLines, wrapLine(line, pdfMaxLineLength)...)
+	}
+	pages := [][]string{}
+	for len(wrappedLines) > 0 {
+		pageLength := min(len(wrappedLines), pdfLinesPerPage)
+		pages = append(pages, wrappedLines[:pageLength])
+		wrappedLines = wrappedLines[pageLength:]
+	}
+	if len(pages) == 0 {
+		pages = append(pages, []string{})
+	}
+
+	// objects 1 and 2 are the catalog and page tree, 3 is the font,
+	// followed by a page and its content stream for every page
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	pageRefs := []string{}
+	for _, page := range pages {
+		var content strings.Builder
+		fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", escapePDFText(line))
+		}
+		content.WriteString("ET")
+
+		pageId := len(objects) + 1
+		pageRefs = append(pageRefs, fmt.Sprintf("%d 0 R", pageId))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pdfPageWidth, pdfPageHeight, pageId+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(pages))
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := []int{}
+	for i, object := range objects {
+		offsets = append(offsets, pdf.Len())
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xrefOffset := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xrefOffset)
+	return pdf.Bytes()
+}
+
+// escapePDFText escapes a string for a PDF string literal. Characters that are
+// not part of Latin-1 cannot be shown with the standard font and are replaced.
+func escapePDFText(text string) string {
+	var escaped strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			escaped.WriteByte('\\')
+			escaped.WriteRune(r)
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			escaped.WriteByte('?')
+		case r > 0x7f:
+			fmt.Fprintf(&escaped, "\\%03o", r)
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
+
+// wrapLine splits a line at spaces, and long words at the maximum length
+func wrapLine(line string, maxLength int) []string {
+	lines := []string{}
+	for len([]rune(line)) > maxLength {
+		runes := []rune(line)
+		breakAt := strings.LastIndex(string(runes[:maxLength]), " ")
+		if breakAt <= 0 {
+			breakAt = len(string(runes[:maxLength]))
+		}
+		lines = append(lines, line[:breakAt])
+		line = strings.TrimLeft(line[breakAt:], " ")
+	}
+	return append(lines, line)
+}
@@ -0,0 +1,141 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"sats":    formatSats,
+	"fiat":    formatFiat,
+	"appName": appName,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; color: #171717; }
+table { width: 100%; border-collapse: collapse; margin-bottom: 2rem; }
+th, td { text-align: left; padding: 0.5rem; border-bottom: 1px solid #e5e5e5; }
+td.amount, th.amount { text-align: right; }
+code { word-break: break-all; }
+.muted { color: #737373; font-size: 0.875rem; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><td>Sent</td><td class="amount">{{sats .Report.TotalSentSat}}{{fiat .Report .Report.TotalSentSat}}</td><td class="amount">{{.Report.SentCount}} payments</td></tr>
+<tr><td>Received</td><td class="amount">{{sats .Report.TotalReceivedSat}}{{fiat .Report .Report.TotalReceivedSat}}</td><td class="amount">{{.Report.ReceivedCount}} payments</td></tr>
+<tr><td>Fees</td><td class="amount">{{sats .Report.TotalFeesSat}}{{fiat .Report .Report.TotalFeesSat}}</td><td></td></tr>
+</table>
+{{if .Report.Apps}}
+<h2>Apps</h2>
+<table>
+<tr><th>App</th><th class="amount">Sent</th><th class="amount">Received</th><th class="amount">Fees</th></tr>
+{{range .Report.Apps}}<tr><td>{{appName .}}</td><td class="amount">{{sats .SentSat}}</td><td class="amount">{{sats .ReceivedSat}}</td><td class="amount">{{sats .FeesSat}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Report.Tags}}
+<h2>Tags</h2>
+<table>
+<tr><th>Tag</th><th class="amount">Sent</th><th class="amount">Received</th><th class="amount">Payments</th></tr>
+{{range .Report.Tags}}<tr><td>{{.Tag}}</td><td class="amount">{{sats .SentSat}}</td><td class="amount">{{sats .ReceivedSat}}</td><td class="amount">{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Report.TopDestinations}}
+<h2>Top destinations</h2>
+<table>
+<tr><th>Node</th><th class="amount">Sent</th><th class="amount">Payments</th></tr>
+{{range .Report.TopDestinations}}<tr><td><code>{{.Destination}}</code></td><td class="amount">{{sats .AmountSat}}</td><td class="amount">{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}
+<p class="muted">Generated on {{.Report.GeneratedAt.Format "2006-01-02 15:04 MST"}} by {{.BrandName}}.{{if .Report.Currency}} Fiat values use the bitcoin price at that time.{{end}}</p>
+</body>
+</html>
+`))
+
+type reportPage struct {
+	Title     string
+	BrandName string
+	Report    *MonthlyReport
+}
+
+func (svc *reportsService) RenderHTML(report *MonthlyReport) ([]byte, error) {
+	var html bytes.Buffer
+	err := reportTemplate.Execute(&html, &reportPage{
+		Title:     svc.reportTitle(report),
+		BrandName: svc.cfg.GetEnv().GetBranding().Name,
+		Report:    report,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return html.Bytes(), nil
+}
+
+func (svc *reportsService) RenderPDF(report *MonthlyReport) ([]byte, error) {
+	lines := []string{
+		svc.reportTitle(report),
+		"",
+		fmt.Sprintf("Sent: %s%s in %d payments", formatSats(report.TotalSentSat), formatFiat(report, report.TotalSentSat), report.SentCount),
+		fmt.Sprintf("Received: %s%s in %d payments", formatSats(report.TotalReceivedSat), formatFiat(report, report.TotalReceivedSat), report.ReceivedCount),
+		fmt.Sprintf("Fees: %s%s", formatSats(report.TotalFeesSat), formatFiat(report, report.TotalFeesSat)),
+	}
+	if len(report.Apps) > 0 {
+		lines = append(lines, "", "Apps")
+		for _, appReport := range report.Apps {
+			lines = append(lines, fmt.Sprintf("  %s: sent %s, received %s, fees %s", appName(appReport), formatSats(appReport.SentSat), formatSats(appReport.ReceivedSat), formatSats(appReport.FeesSat)))
+		}
+	}
+	if len(report.Tags) > 0 {
+		lines = append(lines, "", "Tags")
+		for _, tagReport := range report.Tags {
+			lines = append(lines, fmt.Sprintf("  %s: sent %s, received %s in %d payments", tagReport.Tag, formatSats(tagReport.SentSat), formatSats(tagReport.ReceivedSat), tagReport.Count))
+		}
+	}
+	if len(report.TopDestinations) > 0 {
+		lines = append(lines, "", "Top destinations")
+		for _, destination := range report.TopDestinations {
+			lines = append(lines, fmt.Sprintf("  %s: %s in %d payments", destination.Destination, formatSats(destination.AmountSat), destination.Count))
+		}
+	}
+	lines = append(lines, "", fmt.Sprintf("Generated on %s by %s.", report.GeneratedAt.Format("2006-01-02 15:04 MST"), svc.cfg.GetEnv().GetBranding().Name))
+	if report.Currency != "" {
+		lines = append(lines, "Fiat values use the bitcoin price at that time.")
+	}
+	return writePDF(lines), nil
+}
+
+func (svc *reportsService) reportTitle(report *MonthlyReport) string {
+	return fmt.Sprintf("%s report for %s %d", svc.cfg.GetEnv().GetBranding().Name, report.Month, report.Year)
+}
+
+func appName(appReport AppReport) string {
+	if appReport.AppId == nil {
+		return "Other payments"
+	}
+	if appReport.AppName == "" {
+		return "Deleted app"
+	}
+	return appReport.AppName
+}
+
+func formatSats(amount int64) string {
+	if amount == 1 {
+		return "1 sat"
+	}
+	return fmt.Sprintf("%d sats", amount)
+}
+
+// formatFiat returns e.g. " (12.34 USD)", or an empty string if the report has no fiat rate
+func formatFiat(report *MonthlyReport, amountSat int64) string {
+	if report.Currency == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (%.2f %s)", report.FiatValue(amountSat), strings.ToUpper(report.Currency))
+}
@@ -0,0 +1,365 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	decodepay "github.com/nbd-wtf/ln-decodepay"
+	"gorm.io/gorm"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+)
+
+const (
+	ratesApiUrl          = "https://getalby.com/api/rates"
+	topDestinationsLimit = 5
+	reportMailerInterval = 1 * time.Hour
+	reportMailerTimeout  = 1 * time.Minute
+)
+
+// config key of the month of the last emailed report, e.g. 2024-07
+const monthlyReportSentKey = "MonthlyReportSent"
+
+type MonthlyReport struct {
+	Year             int                 `json:"year"`
+	Month            time.Month          `json:"month"`
+	TotalSentSat     int64               `json:"totalSentSat"`
+	TotalReceivedSat int64               `json:"totalReceivedSat"`
+	TotalFeesSat     int64               `json:"totalFeesSat"`
+	SentCount        int                 `json:"sentCount"`
+	ReceivedCount    int                 `json:"receivedCount"`
+	Apps             []AppReport         `json:"apps"`
+	TopDestinations  []DestinationReport `json:"topDestinations"`
+	// a payment with several tags is counted for each of them
+	Tags []TagReport `json:"tags"`
+	// empty if no currency is configured or the rate could not be fetched
+	Currency string `json:"currency"`
+	// price of one bitcoin when the report was generated, not at the time of the payments
+	BtcRate     float64   `json:"btcRate"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+type AppReport struct {
+	// nil for payments that were not made by an app, e.g. from the wallet page
+	AppId       *uint  `json:"appId"`
+	AppName     string `json:"appName"`
+	SentSat     int64  `json:"sentSat"`
+	ReceivedSat int64  `json:"receivedSat"`
+	FeesSat     int64  `json:"feesSat"`
+	Count       int    `json:"count"`
+}
+
+type TagReport struct {
+	Tag         string `json:"tag"`
+	SentSat     int64  `json:"sentSat"`
+	ReceivedSat int64  `json:"receivedSat"`
+	Count       int    `json:"count"`
+}
+
+type DestinationReport struct {
+	// pubkey of the receiving node
+	Destination string `json:"destination"`
+	AmountSat   int64  `json:"amountSat"`
+	Count       int    `json:"count"`
+}
+
+type ReportsService interface {
+	// GetMonthlyReport sums the settled payments of the month, in the time zone of the server
+	GetMonthlyReport(ctx context.Context, year int, month time.Month) (*MonthlyReport, error)
+	RenderHTML(report *MonthlyReport) ([]byte, error)
+	RenderPDF(report *MonthlyReport) ([]byte, error)
+	// StartReportMailer emails the report of the previous month once a month, if REPORT_EMAIL is set
+	StartReportMailer(ctx context.Context)
+}
+
+type reportsService struct {
+	db          *gorm.DB
+	cfg         config.Config
+	ratesApiUrl string
+	httpClient  *http.Client
+	mailer      *reportMailer
+}
+
+type fiatRate struct {
+	Code      string  `json:"code"`
+	RateFloat float64 `json:"rate_float"`
+}
+
+func NewReportsService(db *gorm.DB, cfg config.Config) *reportsService {
+	svc := &reportsService{
+		db:          db,
+		cfg:         cfg,
+		ratesApiUrl: ratesApiUrl,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+	if cfg.GetEnv().ReportEmail != "" {
+		env := cfg.GetEnv()
+		svc.mailer = newReportMailer(env.SmtpHost, env.SmtpPort, env.SmtpUsername, env.SmtpPassword, env.SmtpFrom, env.ReportEmail)
+	}
+	return svc
+}
+
+func (svc *reportsService) GetMonthlyReport(ctx context.Context, year int, month time.Month) (*MonthlyReport, error) {
+	if month < time.January || month > time.December {
+		return nil, fmt.Errorf("invalid month %d", month)
+	}
+	from := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 1, 0)
+
+	// self payments only move funds between apps of the hub
+	settledInMonth := func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("state = ? AND self_payment = ? AND settled_at >= ? AND settled_at < ?", constants.TRANSACTION_STATE_SETTLED, false, from, to)
+	}
+	transactions := []db.Transaction{}
+	err := svc.db.
+		Preload("App").
+		Scopes(settledInMonth).
+		Order("settled_at asc").
+		Find(&transactions).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to load transactions for monthly report")
+		return nil, err
+	}
+
+	transactionTags := []db.TransactionTag{}
+	err = svc.db.
+		Where("transaction_id IN (?)", svc.db.Model(&db.Transaction{}).Select("id").Scopes(settledInMonth)).
+		Find(&transactionTags).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to load transaction tags for monthly report")
+		return nil, err
+	}
+	tagsByTransaction := map[uint][]string{}
+	for _, transactionTag := range transactionTags {
+		tagsByTransaction[transactionTag.TransactionId] = append(tagsByTransaction[transactionTag.TransactionId], transactionTag.Tag)
+	}
+
+	report := &MonthlyReport{
+		Year:            year,
+		Month:           month,
+		Apps:            []AppReport{},
+		TopDestinations: []DestinationReport{},
+		Tags:            []TagReport{},
+		GeneratedAt:     time.Now(),
+	}
+	appReports := map[uint]*AppReport{}
+	var noAppReport *AppReport
+	destinations := map[string]*DestinationReport{}
+	tagReports := map[string]*TagReport{}
+
+	for _, transaction := range transactions {
+		var appReport *AppReport
+		if transaction.AppId == nil {
+			if noAppReport == nil {
+				noAppReport = &AppReport{}
+			}
+			appReport = noAppReport
+		} else {
+			appReport = appReports[*transaction.AppId]
+			if appReport == nil {
+				appReport = &AppReport{AppId: transaction.AppId}
+				if transaction.App != nil {
+					appReport.AppName = transaction.App.Name
+				}
+				appReports[*transaction.AppId] = appReport
+			}
+		}
+
+		amountSat := int64(transaction.AmountMsat / 1000)
+		appReport.Count++
+		for _, tag := range tagsByTransaction[transaction.ID] {
+			if tagReports[tag] == nil {
+				tagReports[tag] = &TagReport{Tag: tag}
+			}
+			tagReports[tag].Count++
+			if transaction.Type == constants.TRANSACTION_TYPE_INCOMING {
+				tagReports[tag].ReceivedSat += amountSat
+			} else {
+				tagReports[tag].SentSat += amountSat
+			}
+		}
+		if transaction.Type == constants.TRANSACTION_TYPE_INCOMING {
+			report.TotalReceivedSat += amountSat
+			report.ReceivedCount++
+			appReport.ReceivedSat += amountSat
+			continue
+		}
+
+		feesSat := int64(transaction.FeeMsat / 1000)
+		report.TotalSentSat += amountSat
+		report.TotalFeesSat += feesSat
+		report.SentCount++
+		appReport.SentSat += amountSat
+		appReport.FeesSat += feesSat
+
+		destination := getDestination(&transaction)
+		if destination == "" {
+			continue
+		}
+		if destinations[destination] == nil {
+			destinations[destination] = &DestinationReport{Destination: destination}
+		}
+		destinations[destination].AmountSat += amountSat
+		destinations[destination].Count++
+	}
+
+	for _, appReport := range appReports {
+		report.Apps = append(report.Apps, *appReport)
+	}
+	sort.Slice(report.Apps, func(i, j int) bool {
+		return report.Apps[i].SentSat+report.Apps[i].ReceivedSat > report.Apps[j].SentSat+report.Apps[j].ReceivedSat
+	})
+	// payments without an app are listed last
+	if noAppReport != nil {
+		report.Apps = append(report.Apps, *noAppReport)
+	}
+
+	for _, destination := range destinations {
+		report.TopDestinations = append(report.TopDestinations, *destination)
+	}
+	sort.Slice(report.TopDestinations, func(i, j int) bool {
+		return report.TopDestinations[i].AmountSat > report.TopDestinations[j].AmountSat
+	})
+	report.TopDestinations = report.TopDestinations[:min(len(report.TopDestinations), topDestinationsLimit)]
+
+	for _, tagReport := range tagReports {
+		report.Tags = append(report.Tags, *tagReport)
+	}
+	sort.Slice(report.Tags, func(i, j int) bool {
+		return report.Tags[i].Tag < report.Tags[j].Tag
+	})
+
+	if currency := svc.cfg.GetEnv().ReportCurrency; currency != "" {
+		rate, err := svc.fetchRate(ctx, strings.ToLower(currency))
+		if err != nil {
+			// the report is still useful in sats
+			logger.Logger.WithError(err).WithField("currency", currency).Error("Failed to fetch fiat rate")
+		} else {
+			report.Currency = strings.ToUpper(currency)
+			report.BtcRate = rate.RateFloat
+		}
+	}
+
+	return report, nil
+}
+
+// FiatValue returns the value of the amount in the currency of the report
+func (report *MonthlyReport) FiatValue(amountSat int64) float64 {
+	return float64(amountSat) * report.BtcRate / 100_000_000
+}
+
+// getDestination returns the node a payment was sent to, or an empty string if it is unknown
+func getDestination(transaction *db.Transaction) string {
+	if transaction.Metadata != "" {
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(transaction.Metadata), &metadata); err == nil {
+			if destination, ok := metadata["destination"].(string); ok && destination != "" {
+				return destination
+			}
+		}
+	}
+	if transaction.PaymentRequest != "" {
+		paymentRequest, err := decodepay.Decodepay(strings.ToLower(transaction.PaymentRequest))
+		if err == nil {
+			return paymentRequest.Payee
+		}
+	}
+	return ""
+}
+
+// fetchRate returns the price of one bitcoin in the currency
+func (svc *reportsService) fetchRate(ctx context.Context, currency string) (*fiatRate, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s.json", svc.ratesApiUrl, currency), nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := svc.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	rate := &fiatRate{}
+	err = json.NewDecoder(res.Body).Decode(rate)
+	if err != nil {
+		return nil, err
+	}
+	if rate.RateFloat <= 0 {
+		return nil, fmt.Errorf("invalid rate %v", rate.RateFloat)
+	}
+	return rate, nil
+}
+
+func (svc *reportsService) StartReportMailer(ctx context.Context) {
+	if svc.mailer == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(reportMailerInterval)
+		defer ticker.Stop()
+		for {
+			svc.checkMonthlyReport(ctx)
+			select {
+			case <-ctx.Done():
+				logger.Logger.Info("Stopping report mailer")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// checkMonthlyReport emails the report of the previous month if it was not sent yet
+func (svc *reportsService) checkMonthlyReport(ctx context.Context) {
+	now := time.Now()
+	previousMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local).AddDate(0, -1, 0)
+	monthKey := previousMonth.Format("2006-01")
+	sentMonth, _ := svc.cfg.Get(monthlyReportSentKey, "")
+	if sentMonth == monthKey {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reportMailerTimeout)
+	defer cancel()
+	err := svc.sendMonthlyReport(ctx, previousMonth.Year(), previousMonth.Month())
+	if err != nil {
+		logger.Logger.WithError(err).WithField("month", monthKey).Error("Failed to send monthly report")
+		return
+	}
+	svc.cfg.SetUpdate(monthlyReportSentKey, monthKey, "")
+	logger.Logger.WithField("month", monthKey).Info("Sent monthly report")
+}
+
+func (svc *reportsService) sendMonthlyReport(ctx context.Context, year int, month time.Month) error {
+	report, err := svc.GetMonthlyReport(ctx, year, month)
+	if err != nil {
+		return err
+	}
+	html, err := svc.RenderHTML(report)
+	if err != nil {
+		return err
+	}
+	pdf, err := svc.RenderPDF(report)
+	if err != nil {
+		return err
+	}
+	return svc.mailer.send(svc.reportTitle(report), html, pdf, report.FileName("pdf"))
+}
+
+// FileName returns e.g. report-2024-07.pdf
+func (report *MonthlyReport) FileName(extension string) string {
+	return fmt.Sprintf("report-%d-%02d.%s", report.Year, report.Month, extension)
+}
@@ -0,0 +1,173 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+const mockDestination = "02e89ca9e8da72b33d896bae51d20e7e6675aa971f7557500b6591b15429e717f1"
+
+func TestGetMonthlyReport(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	settledAt := time.Date(2024, time.July, 10, 12, 0, 0, 0, time.Local)
+	otherMonth := time.Date(2024, time.August, 1, 0, 0, 0, 0, time.Local)
+	svc.DB.Create(&[]db.Transaction{
+		{AppId: &app.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, AmountMsat: 1_000_000, FeeMsat: 3000, SettledAt: &settledAt, Metadata: `{"destination":"` + mockDestination + `"}`},
+		{AppId: &app.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, AmountMsat: 2_000_000, FeeMsat: 1000, SettledAt: &settledAt, Metadata: `{"destination":"` + mockDestination + `"}`},
+		{Type: constants.TRANSACTION_TYPE_INCOMING, State: constants.TRANSACTION_STATE_SETTLED, AmountMsat: 5_000_000, SettledAt: &settledAt},
+		// not included
+		{AppId: &app.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_FAILED, AmountMsat: 7_000_000, SettledAt: &settledAt},
+		{AppId: &app.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, AmountMsat: 9_000_000, SettledAt: &settledAt, SelfPayment: true},
+		{AppId: &app.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, AmountMsat: 11_000_000, SettledAt: &otherMonth},
+	})
+
+	transactions := []db.Transaction{}
+	svc.DB.Order("id").Find(&transactions)
+	svc.DB.Create(&[]db.TransactionTag{
+		{TransactionId: transactions[0].ID, Tag: "podcasting"},
+		{TransactionId: transactions[2].ID, Tag: "podcasting"},
+		{TransactionId: transactions[2].ID, Tag: "zaps"},
+		// not included
+		{TransactionId: transactions[5].ID, Tag: "podcasting"},
+	})
+
+	reportsService := NewReportsService(svc.DB, svc.Cfg)
+	report, err := reportsService.GetMonthlyReport(context.TODO(), 2024, time.July)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(3000), report.TotalSentSat)
+	assert.Equal(t, int64(5000), report.TotalReceivedSat)
+	assert.Equal(t, int64(4), report.TotalFeesSat)
+	assert.Equal(t, 2, report.SentCount)
+	assert.Equal(t, 1, report.ReceivedCount)
+	assert.Equal(t, "", report.Currency)
+
+	assert.Equal(t, 2, len(report.Apps))
+	assert.Equal(t, app.ID, *report.Apps[0].AppId)
+	assert.Equal(t, "test", report.Apps[0].AppName)
+	assert.Equal(t, int64(3000), report.Apps[0].SentSat)
+	assert.Equal(t, int64(4), report.Apps[0].FeesSat)
+	assert.Nil(t, report.Apps[1].AppId)
+	assert.Equal(t, int64(5000), report.Apps[1].ReceivedSat)
+
+	assert.Equal(t, []DestinationReport{{Destination: mockDestination, AmountSat: 3000, Count: 2}}, report.TopDestinations)
+	assert.Equal(t, []TagReport{
+		{Tag: "podcasting", SentSat: 1000, ReceivedSat: 5000, Count: 2},
+		{Tag: "zaps", ReceivedSat: 5000, Count: 1},
+	}, report.Tags)
+
+	_, err = reportsService.GetMonthlyReport(context.TODO(), 2024, 13)
+	assert.Error(t, err)
+}
+
+func TestGetMonthlyReport_FiatValue(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	rates := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/eur.json", r.URL.Path)
+		w.Write([]byte(`{"code":"EUR","rate_float":50000}`))
+	}))
+	defer rates.Close()
+
+	svc.Cfg.GetEnv().ReportCurrency = "EUR"
+	reportsService := NewReportsService(svc.DB, svc.Cfg)
+	reportsService.ratesApiUrl = rates.URL
+	report, err := reportsService.GetMonthlyReport(context.TODO(), 2024, time.July)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "EUR", report.Currency)
+	assert.Equal(t, 10.5, report.FiatValue(21_000))
+	assert.Equal(t, " (10.50 EUR)", formatFiat(report, 21_000))
+}
+
+func TestRenderReport(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	reportsService := NewReportsService(svc.DB, svc.Cfg)
+	report := &MonthlyReport{
+		Year:            2024,
+		Month:           time.July,
+		TotalSentSat:    3000,
+		SentCount:       2,
+		Apps:            []AppReport{{AppId: new(uint), AppName: "<Damus>", SentSat: 3000}},
+		TopDestinations: []DestinationReport{{Destination: mockDestination, AmountSat: 3000, Count: 2}},
+		Tags:            []TagReport{{Tag: "podcasting", SentSat: 3000, Count: 2}},
+		GeneratedAt:     time.Now(),
+	}
+
+	html, err := reportsService.RenderHTML(report)
+	assert.NoError(t, err)
+	assert.Contains(t, string(html), "<title>Alby Hub report for July 2024</title>")
+	assert.Contains(t, string(html), "&lt;Damus&gt;")
+	assert.Contains(t, string(html), mockDestination)
+	assert.Contains(t, string(html), "<td>podcasting</td>")
+
+	pdf, err := reportsService.RenderPDF(report)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Contains(t, string(pdf), "(Alby Hub report for July 2024) '")
+	assert.Contains(t, string(pdf), "(  <Damus>: sent 3000 sats, received 0 sats, fees 0 sats) '")
+	assert.Contains(t, string(pdf), "(  podcasting: sent 3000 sats, received 0 sats in 2 payments) '")
+}
+
+func TestWritePDF_Pages(t *testing.T) {
+	lines := make([]string, pdfLinesPerPage*2+1)
+	pdf := string(writePDF(lines))
+	assert.Contains(t, pdf, "/Count 3")
+	assert.Equal(t, 3, strings.Count(pdf, "/Type /Page "))
+}
+
+func TestEscapePDFText(t *testing.T) {
+	assert.Equal(t, `\(a\\b\) caf\351 ?`, escapePDFText(`(a\b) café ⚡`))
+}
+
+func TestWrapLine(t *testing.T) {
+	assert.Equal(t, []string{"short"}, wrapLine("short", 10))
+	assert.Equal(t, []string{"a long", "line"}, wrapLine("a long line", 8))
+	assert.Equal(t, []string{"abcde", "fgh"}, wrapLine("abcdefgh", 5))
+}
+
+func TestReportMailer(t *testing.T) {
+	messages := []string{}
+	mailer := newReportMailer("smtp.example.com", 587, "", "", "Alby Hub <hub@example.com>", "me@example.com")
+	mailer.sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "smtp.example.com:587", addr)
+		assert.Equal(t, "hub@example.com", from)
+		assert.Equal(t, []string{"me@example.com"}, to)
+		messages = append(messages, string(msg))
+		return nil
+	}
+
+	err := mailer.send("Alby Hub report for July 2024", []byte("<h1>Report</h1>"), []byte("%PDF-1.4"), "report-2024-07.pdf")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, len(messages))
+	assert.Contains(t, messages[0], "Subject: Alby Hub report for July 2024\r\n")
+	assert.Contains(t, messages[0], "Content-Type: multipart/mixed; boundary=")
+	assert.Contains(t, messages[0], `Content-Disposition: attachment; filename="report-2024-07.pdf"`)
+	// base64 of the HTML and the PDF
+	assert.Contains(t, messages[0], "PGgxPlJlcG9ydDwvaDE+")
+	assert.Contains(t, messages[0], "JVBERi0xLjQ=")
+}
@@ -0,0 +1,190 @@
+// Package secrets reads config values from HashiCorp Vault and AWS Secrets Manager.
+// A config value refers to a secret with one of the prefixes:
+//
+//	vault:<path>#<field>         e.g. vault:secret/data/albyhub#cookie_secret (KV version 1 or 2)
+//	aws-sm:<secret id>[#<field>] e.g. aws-sm:albyhub/lnd#macaroon, the field of a JSON secret or the whole secret string
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/getAlby/hub/utils"
+)
+
+const (
+	vaultPrefix = "vault:"
+	awsPrefix   = "aws-sm:"
+)
+
+type Options struct {
+	VaultAddr  string
+	VaultToken string
+
+	AWSRegion          string
+	AWSAccessKeyId     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	// overrides the regional endpoint, e.g. for LocalStack
+	AWSEndpointUrl string
+}
+
+type Resolver struct {
+	options    Options
+	httpClient *http.Client
+}
+
+func NewResolver(options Options) *Resolver {
+	return &Resolver{
+		options:    options,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// IsReference returns true if the value refers to a secret instead of being the secret itself
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, vaultPrefix) || strings.HasPrefix(value, awsPrefix)
+}
+
+// Resolve returns the secret the reference refers to
+func (resolver *Resolver) Resolve(ctx context.Context, reference string) (string, error) {
+	switch {
+	case strings.HasPrefix(reference, vaultPrefix):
+		path, field, _ := strings.Cut(strings.TrimPrefix(reference, vaultPrefix), "#")
+		if path == "" || field == "" {
+			return "", fmt.Errorf("invalid Vault reference %q, expected vault:<path>#<field>", reference)
+		}
+		return resolver.readVault(ctx, path, field)
+	case strings.HasPrefix(reference, awsPrefix):
+		secretId, field, _ := strings.Cut(strings.TrimPrefix(reference, awsPrefix), "#")
+		if secretId == "" {
+			return "", fmt.Errorf("invalid AWS Secrets Manager reference %q, expected aws-sm:<secret id>[#<field>]", reference)
+		}
+		return resolver.readAWS(ctx, secretId, field)
+	default:
+		return "", fmt.Errorf("unsupported secret reference %q", reference)
+	}
+}
+
+// see https://developer.hashicorp.com/vault/api-docs/secret/kv/kv-v2#read-secret-version
+func (resolver *Resolver) readVault(ctx context.Context, path string, field string) (string, error) {
+	if resolver.options.VaultAddr == "" || resolver.options.VaultToken == "" {
+		return "", errors.New("VAULT_ADDR and VAULT_TOKEN are required for Vault secrets")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(resolver.options.VaultAddr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", resolver.options.VaultToken)
+
+	body, err := resolver.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Vault secret %s: %w", path, err)
+	}
+	response := struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}{}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode Vault secret %s: %w", path, err)
+	}
+	data := response.Data
+	// KV version 2 nests the fields of the secret in its metadata
+	if nested, ok := data["data"]; ok {
+		if _, isMetadata := data["metadata"]; isMetadata {
+			data = map[string]json.RawMessage{}
+			err = json.Unmarshal(nested, &data)
+			if err != nil {
+				return "", fmt.Errorf("failed to decode Vault secret %s: %w", path, err)
+			}
+		}
+	}
+	return stringField(data, field, "Vault secret "+path)
+}
+
+// see https://docs.aws.amazon.com/secretsmanager/latest/apireference/API_GetSecretValue.html
+func (resolver *Resolver) readAWS(ctx context.Context, secretId string, field string) (string, error) {
+	options := resolver.options
+	if options.AWSRegion == "" || options.AWSAccessKeyId == "" || options.AWSSecretAccessKey == "" {
+		return "", errors.New("AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for AWS Secrets Manager secrets")
+	}
+	endpoint := options.AWSEndpointUrl
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + options.AWSRegion + ".amazonaws.com"
+	}
+	payload, err := json.Marshal(map[string]string{"SecretId": secretId})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if options.AWSSessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", options.AWSSessionToken)
+	}
+	utils.SignAWSRequest(req, "secretsmanager", utils.SHA256Hex(payload), options.AWSRegion, options.AWSAccessKeyId, options.AWSSecretAccessKey, time.Now())
+
+	body, err := resolver.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to read AWS secret %s: %w", secretId, err)
+	}
+	response := struct {
+		SecretString *string `json:"SecretString"`
+	}{}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode AWS secret %s: %w", secretId, err)
+	}
+	if response.SecretString == nil {
+		return "", fmt.Errorf("AWS secret %s has no secret string, binary secrets are not supported", secretId)
+	}
+	if field == "" {
+		return *response.SecretString, nil
+	}
+
+	data := map[string]json.RawMessage{}
+	err = json.Unmarshal([]byte(*response.SecretString), &data)
+	if err != nil {
+		return "", fmt.Errorf("AWS secret %s is not a JSON object, so it has no field %s", secretId, field)
+	}
+	return stringField(data, field, "AWS secret "+secretId)
+}
+
+func (resolver *Resolver) do(req *http.Request) ([]byte, error) {
+	res, err := resolver.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("request failed with status %d", res.StatusCode)
+	}
+	return body, nil
+}
+
+func stringField(data map[string]json.RawMessage, field string, name string) (string, error) {
+	raw, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("%s has no field %s", name, field)
+	}
+	var value string
+	err := json.Unmarshal(raw, &value)
+	if err != nil {
+		return "", fmt.Errorf("field %s of %s is not a string", field, name)
+	}
+	return value, nil
+}
@@ -0,0 +1,97 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve_Vault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/albyhub":
+			w.Write([]byte(`{"data":{"data":{"cookie_secret":"from-kv2"},"metadata":{"version":3}}}`))
+		case "/v1/kv/albyhub":
+			w.Write([]byte(`{"data":{"cookie_secret":"from-kv1","port":8080}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	resolver := NewResolver(Options{VaultAddr: server.URL, VaultToken: "vault-token"})
+	value, err := resolver.Resolve(context.Background(), "vault:secret/data/albyhub#cookie_secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-kv2", value)
+	value, err = resolver.Resolve(context.Background(), "vault:kv/albyhub#cookie_secret")
+	assert.NoError(t, err)
+	assert.Equal(t, "from-kv1", value)
+
+	_, err = resolver.Resolve(context.Background(), "vault:kv/albyhub#unlock_password")
+	assert.EqualError(t, err, "Vault secret kv/albyhub has no field unlock_password")
+	_, err = resolver.Resolve(context.Background(), "vault:kv/albyhub#port")
+	assert.EqualError(t, err, "field port of Vault secret kv/albyhub is not a string")
+	_, err = resolver.Resolve(context.Background(), "vault:kv/missing#cookie_secret")
+	assert.EqualError(t, err, "failed to read Vault secret kv/missing: request failed with status 404")
+	_, err = resolver.Resolve(context.Background(), "vault:kv/albyhub")
+	assert.ErrorContains(t, err, "expected vault:<path>#<field>")
+
+	_, err = NewResolver(Options{}).Resolve(context.Background(), "vault:kv/albyhub#cookie_secret")
+	assert.EqualError(t, err, "VAULT_ADDR and VAULT_TOKEN are required for Vault secrets")
+}
+
+func TestResolve_AWS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session-token", r.Header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access-key-id/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request")
+
+		request := map[string]string{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		switch request["SecretId"] {
+		case "albyhub/lnd":
+			w.Write([]byte(`{"Name":"albyhub/lnd","SecretString":"{\"macaroon\":\"0201036c6e64\"}"}`))
+		case "albyhub/cookie":
+			w.Write([]byte(`{"Name":"albyhub/cookie","SecretString":"plain-secret"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	resolver := NewResolver(Options{
+		AWSRegion:          "eu-west-1",
+		AWSAccessKeyId:     "access-key-id",
+		AWSSecretAccessKey: "secret-access-key",
+		AWSSessionToken:    "session-token",
+		AWSEndpointUrl:     server.URL,
+	})
+	value, err := resolver.Resolve(context.Background(), "aws-sm:albyhub/lnd#macaroon")
+	assert.NoError(t, err)
+	assert.Equal(t, "0201036c6e64", value)
+	value, err = resolver.Resolve(context.Background(), "aws-sm:albyhub/cookie")
+	assert.NoError(t, err)
+	assert.Equal(t, "plain-secret", value)
+
+	_, err = resolver.Resolve(context.Background(), "aws-sm:albyhub/cookie#secret")
+	assert.EqualError(t, err, "AWS secret albyhub/cookie is not a JSON object, so it has no field secret")
+	_, err = resolver.Resolve(context.Background(), "aws-sm:albyhub/missing")
+	assert.EqualError(t, err, "failed to read AWS secret albyhub/missing: request failed with status 400")
+}
+
+func TestIsReference(t *testing.T) {
+	assert.True(t, IsReference("vault:secret/data/albyhub#cookie_secret"))
+	assert.True(t, IsReference("aws-sm:albyhub/lnd"))
+	assert.False(t, IsReference("postgres://hub:secret@db:5432/hub"))
+	assert.False(t, IsReference(""))
+}
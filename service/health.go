@@ -0,0 +1,30 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// the relay loop backs off for at most 60 seconds between reconnects,
+// so a longer outage means it is stuck
+const relayReconnectGracePeriod = 3 * time.Minute
+
+// CheckHealth returns an error if the relay consumer is wedged.
+// A hub that has not been started yet is considered healthy.
+func (svc *service) CheckHealth() error {
+	svc.relayMtx.Lock()
+	defer svc.relayMtx.Unlock()
+
+	if svc.relay != nil {
+		if !svc.relay.IsConnected() {
+			return errors.New("relay connection lost")
+		}
+		return nil
+	}
+
+	if !svc.relayDownSince.IsZero() && time.Since(svc.relayDownSince) > relayReconnectGracePeriod {
+		return fmt.Errorf("not connected to the relay since %s", svc.relayDownSince.Format(time.RFC3339))
+	}
+	return nil
+}
@@ -1,9 +1,16 @@
 package keys
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/logger"
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
 )
 
 type Keys interface {
@@ -12,11 +19,25 @@ type Keys interface {
 	GetNostrPublicKey() string
 	// Wallet Service Nostr secret key
 	GetNostrSecretKey() string
+	// Wallet Service Nostr pubkey before the last rotation, empty once its grace period is over
+	GetPreviousNostrPublicKey() string
+	// Secret key of the current or previous Wallet Service Nostr pubkey
+	GetNostrSecretKeyFor(nostrPublicKey string) string
+	// Rotate replaces the identity key. The previous key keeps working until the grace period is over.
+	Rotate() error
 }
 
+const defaultRotationGracePeriod = 30 * 24 * time.Hour
+
 type keys struct {
-	nostrSecretKey string
-	nostrPublicKey string
+	mu                     sync.RWMutex
+	store                  secretStore
+	cfg                    config.Config
+	nostrSecretKey         string
+	nostrPublicKey         string
+	previousNostrSecretKey string
+	previousNostrPublicKey string
+	previousKeyExpiresAt   time.Time
 }
 
 func NewKeys() *keys {
@@ -24,23 +45,187 @@ func NewKeys() *keys {
 }
 
 func (keys *keys) Init(cfg config.Config, encryptionKey string) error {
-	nostrSecretKey, _ := cfg.Get("NostrSecretKey", encryptionKey)
+	if encryptionKey == "" {
+		return errors.New("the nostr secret key cannot be stored unencrypted")
+	}
+
+	keyStore := cfg.GetEnv().KeyStore
+	if keyStore == "" {
+		keyStore = config.DBKeyStoreType
+	}
+	store, err := newSecretStore(cfg, keyStore, encryptionKey)
+	if err != nil {
+		return err
+	}
+
+	err = moveKeys(cfg, keyStore, store, encryptionKey)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to move nostr keys to the configured key store")
+		return err
+	}
+
+	// if the key cannot be read the hub must not silently get a new identity
+	nostrSecretKey, err := store.Get(nostrSecretKeyName)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to read nostr secret key")
+		return err
+	}
 
 	if nostrSecretKey == "" {
 		nostrSecretKey = nostr.GeneratePrivateKey()
-		cfg.SetUpdate("NostrSecretKey", nostrSecretKey, encryptionKey)
+		err = store.Set(nostrSecretKeyName, nostrSecretKey)
+		if err != nil {
+			return err
+		}
 	}
 	nostrPublicKey, err := nostr.GetPublicKey(nostrSecretKey)
 	if err != nil {
 		logger.Logger.WithError(err).Error("Error converting nostr privkey to pubkey")
 		return err
 	}
+
+	keys.mu.Lock()
+	defer keys.mu.Unlock()
+	keys.store = store
+	keys.cfg = cfg
 	keys.nostrSecretKey = nostrSecretKey
 	keys.nostrPublicKey = nostrPublicKey
+	return keys.loadPreviousKey()
+}
+
+func (keys *keys) loadPreviousKey() error {
+	keys.previousNostrSecretKey = ""
+	keys.previousNostrPublicKey = ""
+
+	previousNostrSecretKey, err := keys.store.Get(previousNostrSecretKeyName)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to read previous nostr secret key")
+		return err
+	}
+	if previousNostrSecretKey == "" {
+		return nil
+	}
+
+	rotatedAt, _ := keys.cfg.Get(nostrKeyRotatedAtName, "")
+	rotatedAtUnix, _ := strconv.ParseInt(rotatedAt, 10, 64)
+	keys.previousKeyExpiresAt = time.Unix(rotatedAtUnix, 0).Add(keys.gracePeriod())
+	if time.Now().After(keys.previousKeyExpiresAt) {
+		logger.Logger.Info("Grace period of the previous nostr key is over, removing it")
+		return keys.store.Set(previousNostrSecretKeyName, "")
+	}
+
+	previousNostrPublicKey, err := nostr.GetPublicKey(previousNostrSecretKey)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Error converting previous nostr privkey to pubkey")
+		return err
+	}
+	keys.previousNostrSecretKey = previousNostrSecretKey
+	keys.previousNostrPublicKey = previousNostrPublicKey
+	return nil
+}
+
+func (keys *keys) gracePeriod() time.Duration {
+	if graceDays := keys.cfg.GetEnv().KeyRotationGraceDays; graceDays > 0 {
+		return time.Duration(graceDays) * 24 * time.Hour
+	}
+	return defaultRotationGracePeriod
+}
+
+func (keys *keys) Rotate() error {
+	keys.mu.Lock()
+	defer keys.mu.Unlock()
+	if keys.store == nil {
+		return errors.New("keys not initialized")
+	}
+
+	nostrSecretKey := nostr.GeneratePrivateKey()
+	nostrPublicKey, err := nostr.GetPublicKey(nostrSecretKey)
+	if err != nil {
+		return err
+	}
+
+	// the current key is kept first so that a failed rotation never loses it
+	err = keys.store.Set(previousNostrSecretKeyName, keys.nostrSecretKey)
+	if err != nil {
+		return err
+	}
+	keys.cfg.SetUpdate(nostrKeyRotatedAtName, strconv.FormatInt(time.Now().Unix(), 10), "")
+	err = keys.store.Set(nostrSecretKeyName, nostrSecretKey)
+	if err != nil {
+		return err
+	}
+
+	logger.Logger.WithFields(logrus.Fields{
+		"previous_pubkey": keys.nostrPublicKey,
+		"pubkey":          nostrPublicKey,
+	}).Info("Rotated nostr identity key")
+
+	keys.previousNostrSecretKey = keys.nostrSecretKey
+	keys.previousNostrPublicKey = keys.nostrPublicKey
+	keys.previousKeyExpiresAt = time.Now().Add(keys.gracePeriod())
+	keys.nostrSecretKey = nostrSecretKey
+	keys.nostrPublicKey = nostrPublicKey
+	return nil
+}
+
+// moveKeys moves the nostr keys from the key store they were stored in before to the configured one,
+// so that changing KEY_STORE never gives the hub a new identity. The hub does not start if the
+// keys cannot be read from the previous key store or if both key stores hold a different key.
+func moveKeys(cfg config.Config, keyStore string, store secretStore, encryptionKey string) error {
+	storedIn, err := cfg.Get(nostrKeyStoreName, "")
+	if err != nil {
+		return err
+	}
+	if storedIn == "" {
+		storedIn = config.DBKeyStoreType
+	}
+	if storedIn == keyStore {
+		return nil
+	}
+
+	previousStore, err := newSecretStore(cfg, storedIn, encryptionKey)
+	if err != nil {
+		return err
+	}
+	names := []string{nostrSecretKeyName, previousNostrSecretKeyName}
+	for _, name := range names {
+		value, err := previousStore.Get(name)
+		if err != nil {
+			return err
+		}
+		if value == "" {
+			continue
+		}
+		existing, err := store.Get(name)
+		if err != nil {
+			return err
+		}
+		if existing != "" && existing != value {
+			return fmt.Errorf("different %s stored in the %s and %s key stores", name, storedIn, keyStore)
+		}
+		err = store.Set(name, value)
+		if err != nil {
+			return err
+		}
+	}
+	// only removed once every key is in the new key store
+	for _, name := range names {
+		err = previousStore.Set(name, "")
+		if err != nil {
+			return err
+		}
+	}
+	cfg.SetUpdate(nostrKeyStoreName, keyStore, "")
+	logger.Logger.WithFields(logrus.Fields{
+		"from": storedIn,
+		"to":   keyStore,
+	}).Info("Moved nostr keys to the configured key store")
 	return nil
 }
 
 func (keys *keys) GetNostrPublicKey() string {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
 	if keys.nostrPublicKey == "" {
 		logger.Logger.Fatal("keys not initialized")
 	}
@@ -48,8 +233,35 @@ func (keys *keys) GetNostrPublicKey() string {
 }
 
 func (keys *keys) GetNostrSecretKey() string {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
 	if keys.nostrSecretKey == "" {
 		logger.Logger.Fatal("keys not initialized")
 	}
 	return keys.nostrSecretKey
 }
+
+func (keys *keys) GetPreviousNostrPublicKey() string {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+	if !keys.hasPreviousKey() {
+		return ""
+	}
+	return keys.previousNostrPublicKey
+}
+
+func (keys *keys) GetNostrSecretKeyFor(nostrPublicKey string) string {
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+	if keys.hasPreviousKey() && nostrPublicKey == keys.previousNostrPublicKey {
+		return keys.previousNostrSecretKey
+	}
+	if keys.nostrSecretKey == "" {
+		logger.Logger.Fatal("keys not initialized")
+	}
+	return keys.nostrSecretKey
+}
+
+func (keys *keys) hasPreviousKey() bool {
+	return keys.previousNostrPublicKey != "" && time.Now().Before(keys.previousKeyExpiresAt)
+}
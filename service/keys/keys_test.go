@@ -0,0 +1,202 @@
+package keys_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/stretchr/testify/assert"
+	"github.com/zalando/go-keyring"
+
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/service/keys"
+	"github.com/getAlby/hub/tests"
+)
+
+func TestInit_StoresSecretKeyEncrypted(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	var userConfig db.UserConfig
+	err = svc.DB.Where(&db.UserConfig{Key: "NostrSecretKey"}).First(&userConfig).Error
+	assert.NoError(t, err)
+	assert.True(t, userConfig.Encrypted)
+	assert.NotEqual(t, svc.Keys.GetNostrSecretKey(), userConfig.Value)
+
+	// the same identity is loaded again with the unlock password
+	reloaded := keys.NewKeys()
+	err = reloaded.Init(svc.Cfg, tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, svc.Keys.GetNostrPublicKey(), reloaded.GetNostrPublicKey())
+}
+
+func TestInit_WrongPassword(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	var userConfig db.UserConfig
+	err = svc.DB.Where(&db.UserConfig{Key: "NostrSecretKey"}).First(&userConfig).Error
+	assert.NoError(t, err)
+
+	err = keys.NewKeys().Init(svc.Cfg, "wrong-password")
+	assert.Error(t, err)
+
+	// the identity must not be replaced
+	var after db.UserConfig
+	err = svc.DB.Where(&db.UserConfig{Key: "NostrSecretKey"}).First(&after).Error
+	assert.NoError(t, err)
+	assert.Equal(t, userConfig.Value, after.Value)
+}
+
+func TestInit_NoPassword(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	err = keys.NewKeys().Init(svc.Cfg, "")
+	assert.Error(t, err)
+}
+
+func TestInit_KeychainMovesKeyFromDB(t *testing.T) {
+	keyring.MockInit()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	svc.Cfg.GetEnv().KeyStore = config.KeychainKeyStoreType
+	keychainKeys := keys.NewKeys()
+	err = keychainKeys.Init(svc.Cfg, tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, svc.Keys.GetNostrPublicKey(), keychainKeys.GetNostrPublicKey())
+
+	// the key is no longer in the database
+	value, err := svc.Cfg.Get("NostrSecretKey", tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Empty(t, value)
+
+	reloaded := keys.NewKeys()
+	err = reloaded.Init(svc.Cfg, tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, svc.Keys.GetNostrPublicKey(), reloaded.GetNostrPublicKey())
+}
+
+func TestInit_DBMovesKeysFromKeychain(t *testing.T) {
+	keyring.MockInit()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	err = svc.Keys.Rotate()
+	assert.NoError(t, err)
+	previousPubkey := svc.Keys.GetPreviousNostrPublicKey()
+
+	svc.Cfg.GetEnv().KeyStore = config.KeychainKeyStoreType
+	keychainKeys := keys.NewKeys()
+	err = keychainKeys.Init(svc.Cfg, tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, previousPubkey, keychainKeys.GetPreviousNostrPublicKey())
+
+	// switching back keeps the identity and the previous key
+	svc.Cfg.GetEnv().KeyStore = config.DBKeyStoreType
+	dbKeys := keys.NewKeys()
+	err = dbKeys.Init(svc.Cfg, tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, svc.Keys.GetNostrPublicKey(), dbKeys.GetNostrPublicKey())
+	assert.Equal(t, previousPubkey, dbKeys.GetPreviousNostrPublicKey())
+
+	value, err := keyring.Get("Alby Hub NostrSecretKey", svc.Cfg.GetEnv().Workdir)
+	assert.NoError(t, err)
+	assert.Empty(t, value)
+}
+
+func TestInit_KeychainUnavailable(t *testing.T) {
+	keyring.MockInit()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	svc.Cfg.GetEnv().KeyStore = config.KeychainKeyStoreType
+	err = keys.NewKeys().Init(svc.Cfg, tests.UnlockPassword)
+	assert.NoError(t, err)
+
+	// the hub must not get a new identity if the keys cannot be moved back
+	keyring.MockInitWithError(errors.New("keychain locked"))
+	svc.Cfg.GetEnv().KeyStore = config.DBKeyStoreType
+	err = keys.NewKeys().Init(svc.Cfg, tests.UnlockPassword)
+	assert.Error(t, err)
+
+	value, err := svc.Cfg.Get("NostrSecretKey", tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Empty(t, value)
+}
+
+func TestInit_DifferentKeysInBothKeyStores(t *testing.T) {
+	keyring.MockInit()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	err = keyring.Set("Alby Hub NostrSecretKey", svc.Cfg.GetEnv().Workdir, nostr.GeneratePrivateKey())
+	assert.NoError(t, err)
+
+	svc.Cfg.GetEnv().KeyStore = config.KeychainKeyStoreType
+	err = keys.NewKeys().Init(svc.Cfg, tests.UnlockPassword)
+	assert.Error(t, err)
+
+	// the key in the database is kept
+	value, err := svc.Cfg.Get("NostrSecretKey", tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, svc.Keys.GetNostrSecretKey(), value)
+}
+
+func TestRotate_KeepsPreviousKey(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	previousPubkey := svc.Keys.GetNostrPublicKey()
+	previousSecretKey := svc.Keys.GetNostrSecretKey()
+	assert.Empty(t, svc.Keys.GetPreviousNostrPublicKey())
+
+	err = svc.Keys.Rotate()
+	assert.NoError(t, err)
+	assert.NotEqual(t, previousPubkey, svc.Keys.GetNostrPublicKey())
+	assert.Equal(t, previousPubkey, svc.Keys.GetPreviousNostrPublicKey())
+	assert.Equal(t, previousSecretKey, svc.Keys.GetNostrSecretKeyFor(previousPubkey))
+	assert.Equal(t, svc.Keys.GetNostrSecretKey(), svc.Keys.GetNostrSecretKeyFor(svc.Keys.GetNostrPublicKey()))
+
+	// both keys are loaded again on the next start
+	reloaded := keys.NewKeys()
+	err = reloaded.Init(svc.Cfg, tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, svc.Keys.GetNostrPublicKey(), reloaded.GetNostrPublicKey())
+	assert.Equal(t, previousPubkey, reloaded.GetPreviousNostrPublicKey())
+}
+
+func TestRotate_PreviousKeyExpires(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	previousPubkey := svc.Keys.GetNostrPublicKey()
+	err = svc.Keys.Rotate()
+	assert.NoError(t, err)
+
+	svc.Cfg.SetUpdate("NostrKeyRotatedAt", strconv.FormatInt(time.Now().Add(-31*24*time.Hour).Unix(), 10), "")
+
+	reloaded := keys.NewKeys()
+	err = reloaded.Init(svc.Cfg, tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Empty(t, reloaded.GetPreviousNostrPublicKey())
+	assert.Equal(t, reloaded.GetNostrSecretKey(), reloaded.GetNostrSecretKeyFor(previousPubkey))
+
+	// the previous key is removed for good
+	value, err := svc.Cfg.Get("PreviousNostrSecretKey", tests.UnlockPassword)
+	assert.NoError(t, err)
+	assert.Empty(t, value)
+}
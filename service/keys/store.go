@@ -0,0 +1,84 @@
+package keys
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+
+	"github.com/getAlby/hub/config"
+)
+
+const (
+	nostrSecretKeyName         = "NostrSecretKey"
+	previousNostrSecretKeyName = "PreviousNostrSecretKey"
+	nostrKeyRotatedAtName      = "NostrKeyRotatedAt"
+)
+
+// the key store that holds the keys, to move them when KEY_STORE is changed
+const nostrKeyStoreName = "NostrKeyStore"
+
+// secretStore persists the nostr identity keys of the hub
+type secretStore interface {
+	// Get returns an empty string if the secret is not stored
+	Get(name string) (string, error)
+	Set(name string, value string) error
+}
+
+func newSecretStore(cfg config.Config, keyStore string, encryptionKey string) (secretStore, error) {
+	switch keyStore {
+	case config.DBKeyStoreType:
+		return &dbSecretStore{cfg: cfg, encryptionKey: encryptionKey}, nil
+	case config.KeychainKeyStoreType:
+		return &keychainSecretStore{
+			// the workdir tells apart multiple hubs run by the same OS user
+			user: cfg.GetEnv().Workdir,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown key store: %s", keyStore)
+	}
+}
+
+// dbSecretStore stores secrets in the database, encrypted with the unlock password
+type dbSecretStore struct {
+	cfg           config.Config
+	encryptionKey string
+}
+
+func (store *dbSecretStore) Get(name string) (string, error) {
+	return store.cfg.Get(name, store.encryptionKey)
+}
+
+func (store *dbSecretStore) Set(name string, value string) error {
+	store.cfg.SetUpdate(name, value, store.encryptionKey)
+	return nil
+}
+
+// keychainSecretStore stores secrets in the OS keychain
+// (macOS Keychain, Windows Credential Manager or the Secret Service on Linux)
+type keychainSecretStore struct {
+	user string
+}
+
+func (store *keychainSecretStore) service(name string) string {
+	return "Alby Hub " + name
+}
+
+func (store *keychainSecretStore) Get(name string) (string, error) {
+	value, err := keyring.Get(store.service(name), store.user)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s from the keychain: %w", name, err)
+	}
+	return value, nil
+}
+
+func (store *keychainSecretStore) Set(name string, value string) error {
+	err := keyring.Set(store.service(name), store.user, value)
+	if err != nil {
+		return fmt.Errorf("failed to write %s to the keychain: %w", name, err)
+	}
+	return nil
+}
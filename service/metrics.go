@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/getAlby/hub/logger"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// startMetricsServer serves the Prometheus metrics on a separate address,
+// so that they can be scraped without access to the API
+func startMetricsServer(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	server := &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}
+
+	go func() {
+		<-ctx.Done()
+		err := server.Shutdown(context.Background())
+		if err != nil {
+			logger.Logger.WithError(err).Error("Failed to shut down metrics server")
+		}
+	}()
+
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Logger.WithError(err).Error("Metrics server failed")
+		}
+	}()
+}
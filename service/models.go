@@ -1,26 +1,50 @@
 package service
 
 import (
+	"context"
+
+	"github.com/getAlby/hub/activity"
 	"github.com/getAlby/hub/alby"
+	"github.com/getAlby/hub/backups"
 	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/events"
 	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/nostr/relay"
+	"github.com/getAlby/hub/reports"
 	"github.com/getAlby/hub/service/keys"
+	"github.com/getAlby/hub/swaps"
+	"github.com/getAlby/hub/tags"
 	"github.com/getAlby/hub/transactions"
+	"github.com/nbd-wtf/go-nostr"
 	"gorm.io/gorm"
 )
 
 type Service interface {
 	StartApp(encryptionKey string) error
+	StartAppWithLNClient(encryptionKey string, lnClient lnclient.LNClient) error
 	StopApp()
+	HandOverPassword() string
+	StandBy(encryptionKey string) error
+	TakeOver(encryptionKey string) error
 	Shutdown()
+	ReloadConfig() error
+	CheckHealth() error
+	RotateKeys(ctx context.Context) error
+	HandleNip47Request(ctx context.Context, event *nostr.Event) ([]nostr.Event, error)
 
 	// TODO: remove getters (currently used by http / wails services)
 	GetAlbyOAuthSvc() alby.AlbyOAuthService
 	GetEventPublisher() events.EventPublisher
 	GetLNClient() lnclient.LNClient
 	GetTransactionsService() transactions.TransactionsService
+	GetSwapsService() swaps.SwapsService
+	GetChannelBackupService() backups.ChannelBackupService
+	GetActivityService() activity.ActivityService
+	GetReportsService() reports.ReportsService
+	GetTagsService() tags.TagsService
 	GetDB() *gorm.DB
 	GetConfig() config.Config
 	GetKeys() keys.Keys
+	// nil unless the hub runs in all-in-one mode
+	GetEmbeddedRelay() *relay.Relay
 }
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+
+	"github.com/getAlby/hub/logger"
+)
+
+// frameLoggingRelay logs the events the hub publishes while the nostr frames debug toggle is enabled
+type frameLoggingRelay struct {
+	relay *nostr.Relay
+}
+
+func (relay *frameLoggingRelay) Publish(ctx context.Context, event nostr.Event) error {
+	logNostrFrame("Publishing event", relay.relay.URL, &event)
+	return relay.relay.Publish(ctx, event)
+}
+
+func logNostrFrame(message string, relayUrl string, event *nostr.Event) {
+	if !logger.IsDebugEnabled(logger.DebugNostrFrames) {
+		return
+	}
+	logger.Nostr.WithFields(logrus.Fields{
+		"relay_url": relayUrl,
+		"event":     event.String(),
+	}).Info(message)
+}
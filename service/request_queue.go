@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/nip47"
+	nostrmodels "github.com/getAlby/hub/nostr/models"
+)
+
+const (
+	defaultNip47Workers         = 10
+	defaultNip47QueueSize       = 1000
+	defaultNip47QueueSizePerApp = 100
+)
+
+type queuedRequest struct {
+	// the context of the subscription the event was received on
+	ctx   context.Context
+	relay nostrmodels.Relay
+	event *nostr.Event
+	// when the event was received from the relay
+	receivedAt time.Time
+	// set for requests that were sent over HTTP, receives their responses once they were handled
+	responses chan []nostr.Event
+}
+
+// requestQueue holds incoming NIP-47 requests until a worker is free.
+// Requests are grouped by the pubkey of the app that sent them and the apps take turns,
+// so that one busy app cannot hold up the requests of the others.
+type requestQueue struct {
+	mu        sync.Mutex
+	requests  map[string][]*queuedRequest
+	apps      []string
+	size      int
+	maxSize   int
+	maxPerApp int
+	// holds one token per queued request
+	pending chan struct{}
+}
+
+func newRequestQueue(maxSize int, maxPerApp int) *requestQueue {
+	return &requestQueue{
+		requests:  map[string][]*queuedRequest{},
+		maxSize:   maxSize,
+		maxPerApp: maxPerApp,
+		pending:   make(chan struct{}, maxSize),
+	}
+}
+
+// push returns false if the request was dropped because the queue or the queue of the app is full
+func (queue *requestQueue) push(request *queuedRequest) bool {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	appPubkey := request.event.PubKey
+	if queue.size >= queue.maxSize || len(queue.requests[appPubkey]) >= queue.maxPerApp {
+		return false
+	}
+	if len(queue.requests[appPubkey]) == 0 {
+		queue.apps = append(queue.apps, appPubkey)
+	}
+	queue.requests[appPubkey] = append(queue.requests[appPubkey], request)
+	queue.size++
+	queue.pending <- struct{}{}
+	return true
+}
+
+// pop blocks until a request is queued or the context is done
+func (queue *requestQueue) pop(ctx context.Context) (*queuedRequest, bool) {
+	select {
+	case <-ctx.Done():
+		return nil, false
+	case <-queue.pending:
+	}
+
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	appPubkey := queue.apps[0]
+	queue.apps = queue.apps[1:]
+	requests := queue.requests[appPubkey]
+	request := requests[0]
+	requests[0] = nil
+	if len(requests) == 1 {
+		delete(queue.requests, appPubkey)
+	} else {
+		queue.requests[appPubkey] = requests[1:]
+		queue.apps = append(queue.apps, appPubkey)
+	}
+	queue.size--
+	return request, true
+}
+
+func (svc *service) nip47QueueSizes() (int, int) {
+	queueSize := svc.cfg.GetEnv().Nip47QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultNip47QueueSize
+	}
+	queueSizePerApp := svc.cfg.GetEnv().Nip47QueueSizePerApp
+	if queueSizePerApp <= 0 {
+		queueSizePerApp = defaultNip47QueueSizePerApp
+	}
+	return queueSize, queueSizePerApp
+}
+
+func (svc *service) startRequestWorkers(ctx context.Context, queue *requestQueue) {
+	workers := svc.cfg.GetEnv().Nip47Workers
+	if workers <= 0 {
+		workers = defaultNip47Workers
+	}
+	for i := 0; i < workers; i++ {
+		go svc.handleQueuedRequests(ctx, queue)
+	}
+}
+
+func (svc *service) handleQueuedRequests(ctx context.Context, queue *requestQueue) {
+	for {
+		request, ok := queue.pop(ctx)
+		if !ok {
+			return
+		}
+		svc.handleQueuedRequest(ctx, request)
+	}
+}
+
+func (svc *service) handleQueuedRequest(ctx context.Context, request *queuedRequest) {
+	if request.responses != nil {
+		// the caller stops waiting for the responses if the request is not handled
+		defer close(request.responses)
+	} else if !svc.nip47Service.DeferWhileResponseQueueFull(ctx) {
+		logger.Logger.WithFields(logrus.Fields{
+			"requestEventNostrId": request.event.ID,
+			"appPubkey":           request.event.PubKey,
+		}).Warn("Relay is not keeping up with responses")
+	}
+	if !svc.startRequestHandler(request.ctx) {
+		// stored requests are received again by the next subscription
+		logger.Logger.WithField("requestEventNostrId", request.event.ID).Info("Shutting down, ignoring event")
+		return
+	}
+	defer svc.requestHandlersWg.Done()
+	defer logger.CapturePanic(logrus.Fields{
+		"requestEventNostrId": request.event.ID,
+		"appPubkey":           request.event.PubKey,
+	})
+	if request.responses != nil {
+		request.responses <- svc.nip47Service.HandleEventSync(nip47.WithReceivedAt(request.ctx, request.receivedAt), request.event, svc.lnClient)
+		return
+	}
+	svc.nip47Service.HandleEvent(nip47.WithReceivedAt(ctx, request.receivedAt), request.relay, request.event, svc.lnClient)
+}
+
+// HandleNip47Request handles a request event that was sent over HTTP instead of the relay and returns its responses.
+// The request waits in the same queue as the requests of the relay.
+func (svc *service) HandleNip47Request(ctx context.Context, event *nostr.Event) ([]nostr.Event, error) {
+	request := &queuedRequest{
+		ctx:        ctx,
+		event:      event,
+		receivedAt: time.Now(),
+		responses:  make(chan []nostr.Event, 1),
+	}
+	if !svc.requestQueue.push(request) {
+		logger.Logger.WithFields(logrus.Fields{
+			"requestEventNostrId": event.ID,
+			"appPubkey":           event.PubKey,
+		}).Warn("Request queue is full, rejecting HTTP request")
+		return nil, errors.New("too many requests are waiting to be handled")
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case responses, ok := <-request.responses:
+		if !ok {
+			return nil, errors.New("the app is shutting down")
+		}
+		return responses, nil
+	}
+}
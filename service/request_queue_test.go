@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/getAlby/hub/logger"
+)
+
+func newQueuedRequest(appPubkey string, id string) *queuedRequest {
+	return &queuedRequest{
+		ctx:   context.TODO(),
+		event: &nostr.Event{ID: id, PubKey: appPubkey},
+	}
+}
+
+func TestRequestQueue_AppsTakeTurns(t *testing.T) {
+	queue := newRequestQueue(10, 10)
+
+	assert.True(t, queue.push(newQueuedRequest("app1", "1")))
+	assert.True(t, queue.push(newQueuedRequest("app1", "2")))
+	assert.True(t, queue.push(newQueuedRequest("app1", "3")))
+	assert.True(t, queue.push(newQueuedRequest("app2", "4")))
+	assert.True(t, queue.push(newQueuedRequest("app3", "5")))
+	assert.True(t, queue.push(newQueuedRequest("app2", "6")))
+
+	ids := []string{}
+	for i := 0; i < 6; i++ {
+		request, ok := queue.pop(context.TODO())
+		assert.True(t, ok)
+		ids = append(ids, request.event.ID)
+	}
+	assert.Equal(t, []string{"1", "4", "5", "2", "6", "3"}, ids)
+}
+
+func TestRequestQueue_Bounded(t *testing.T) {
+	queue := newRequestQueue(3, 2)
+
+	assert.True(t, queue.push(newQueuedRequest("app1", "1")))
+	assert.True(t, queue.push(newQueuedRequest("app1", "2")))
+	// the queue of the app is full
+	assert.False(t, queue.push(newQueuedRequest("app1", "3")))
+	assert.True(t, queue.push(newQueuedRequest("app2", "4")))
+	// the whole queue is full
+	assert.False(t, queue.push(newQueuedRequest("app3", "5")))
+
+	_, ok := queue.pop(context.TODO())
+	assert.True(t, ok)
+	assert.True(t, queue.push(newQueuedRequest("app3", "5")))
+}
+
+func TestRequestQueue_PopWaitsForRequest(t *testing.T) {
+	queue := newRequestQueue(10, 10)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		queue.push(newQueuedRequest("app1", "1"))
+	}()
+	request, ok := queue.pop(context.TODO())
+	assert.True(t, ok)
+	assert.Equal(t, "1", request.event.ID)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, ok = queue.pop(ctx)
+	assert.False(t, ok)
+}
+
+func TestHandleNip47Request_Queued(t *testing.T) {
+	logger.Init(strconv.Itoa(int(logrus.DebugLevel)))
+	svc := &service{requestQueue: newRequestQueue(10, 1)}
+
+	// the request waits for a worker until the client gives up
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	_, err := svc.HandleNip47Request(ctx, &nostr.Event{ID: "1", PubKey: "app1"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the queue of the app is still full
+	_, err = svc.HandleNip47Request(context.TODO(), &nostr.Event{ID: "2", PubKey: "app1"})
+	assert.Error(t, err)
+
+	request, ok := svc.requestQueue.pop(context.TODO())
+	assert.True(t, ok)
+	assert.Equal(t, "1", request.event.ID)
+	assert.NotNil(t, request.responses)
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/getAlby/hub/logger"
+)
+
+// RotateKeys replaces the nostr identity key of the hub. Connected apps keep working
+// through the previous key until the grace period is over, and are pointed to the
+// new key by the info event published for the previous key.
+func (svc *service) RotateKeys(ctx context.Context) error {
+	if svc.lnClient == nil {
+		return errors.New("LNClient not started")
+	}
+
+	err := svc.keys.Rotate()
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to rotate nostr keys")
+		return err
+	}
+
+	svc.relayMtx.Lock()
+	relay := svc.relay
+	sub := svc.subscription
+	svc.relayMtx.Unlock()
+
+	// otherwise the new key is picked up when the relay loop reconnects
+	if relay == nil || sub == nil {
+		return nil
+	}
+
+	sub.Sub(ctx, svc.createFilters())
+	err = svc.nip47Service.PublishNip47Info(ctx, &frameLoggingRelay{relay: relay}, svc.lnClient)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Could not publish NIP47 info")
+	}
+	return nil
+}
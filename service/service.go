@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"os"
@@ -11,23 +12,30 @@ import (
 
 	"github.com/adrg/xdg"
 	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 
-	"github.com/joho/godotenv"
-	"github.com/kelseyhightower/envconfig"
-
+	"github.com/getAlby/hub/activity"
 	"github.com/getAlby/hub/alby"
+	"github.com/getAlby/hub/alerts"
+	"github.com/getAlby/hub/backups"
 	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/leader"
 	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/reports"
 	"github.com/getAlby/hub/service/keys"
+	"github.com/getAlby/hub/swaps"
+	"github.com/getAlby/hub/tags"
 	"github.com/getAlby/hub/transactions"
 	"github.com/getAlby/hub/version"
 
 	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/dumps"
 	"github.com/getAlby/hub/lnclient"
 	"github.com/getAlby/hub/nip47"
 	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/nostr/relay"
 )
 
 type service struct {
@@ -36,50 +44,114 @@ type service struct {
 	db                  *gorm.DB
 	lnClient            lnclient.LNClient
 	transactionsService transactions.TransactionsService
+	swapsService        swaps.SwapsService
+	channelBackupSvc    backups.ChannelBackupService
+	activitySvc         activity.ActivityService
+	reportsSvc          reports.ReportsService
+	tagsSvc             tags.TagsService
+	dumpsSvc            dumps.DumpsService
 	albyOAuthSvc        alby.AlbyOAuthService
+	alertsService       alerts.AlertsService
 	eventPublisher      events.EventPublisher
 	ctx                 context.Context
 	wg                  *sync.WaitGroup
 	nip47Service        nip47.Nip47Service
 	appCancelFn         context.CancelFunc
 	keys                keys.Keys
+	// kept so that the app can be handed over to a new process on upgrade
+	encryptionKey string
+	// NIP-47 requests run with their own context so they can publish
+	// their responses while the app is shutting down
+	requestHandlersCtx    context.Context
+	cancelRequestHandlers context.CancelFunc
+	requestHandlersWg     sync.WaitGroup
+	requestHandlersMtx    sync.Mutex
+	requestQueue          *requestQueue
+	// relay connection state, used for health checks
+	relayMtx       sync.Mutex
+	relay          *nostr.Relay
+	relayDownSince time.Time
+	// the subscription is updated with new filters when the identity key is rotated
+	subscription *nostr.Subscription
+	// served by the HTTP server in all-in-one mode, nil otherwise
+	embeddedRelay *relay.Relay
+	// elects the replica that subscribes to the relay when replicas share the database, nil otherwise
+	leaderElection leader.LeaderElection
+	// set while the app is handed over from the process being upgraded
+	standbyMtx  sync.Mutex
+	standby     *standby
+	handingOver bool
+}
+
+// LoadAppConfig reads the config from flags, environment variables and the config file
+// and fills in the default workdir and database location
+func LoadAppConfig() (*config.AppConfig, error) {
+	appConfig, err := config.LoadAppConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if appConfig.Workdir == "" {
+		appConfig.Workdir = filepath.Join(xdg.DataHome, "/albyhub")
+	}
+
+	if appConfig.LeaderElection && appConfig.ReplicaId == "" {
+		hostname, _ := os.Hostname()
+		appConfig.ReplicaId = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+
+	// If DATABASE_URI is a URI, a Postgres connection string or a path, leave it unchanged.
+	// If it only contains a filename, prepend the workdir.
+	if !strings.HasPrefix(appConfig.DatabaseUri, "file:") && !db.IsPostgresUri(appConfig.DatabaseUri) {
+		databasePath, _ := filepath.Split(appConfig.DatabaseUri)
+		if databasePath == "" {
+			appConfig.DatabaseUri = filepath.Join(appConfig.Workdir, appConfig.DatabaseUri)
+		}
+	}
+
+	return appConfig, nil
 }
 
 func NewService(ctx context.Context) (*service, error) {
-	// Load config from environment variables / .GetEnv() file
-	godotenv.Load(".env")
-	appConfig := &config.AppConfig{}
-	err := envconfig.Process("", appConfig)
+	appConfig, err := LoadAppConfig()
 	if err != nil {
 		return nil, err
 	}
 
 	logger.Init(appConfig.LogLevel)
+	logger.SetComponentLevels(appConfig.ComponentLogLevels())
 	logger.Logger.Info("AlbyHub " + version.Tag)
+	summaryFields := logrus.Fields{}
+	for key, value := range appConfig.Summary() {
+		summaryFields[key] = value
+	}
+	logger.Logger.WithFields(summaryFields).Info("Effective config")
 
-	if appConfig.Workdir == "" {
-		appConfig.Workdir = filepath.Join(xdg.DataHome, "/albyhub")
-		logger.Logger.WithField("workdir", appConfig.Workdir).Info("No workdir specified, using default")
+	if appConfig.SentryDSN != "" {
+		err = logger.InitSentry(appConfig.SentryDSN, version.Tag)
+		if err != nil {
+			return nil, err
+		}
+		logger.Logger.Info("Error reporting enabled")
 	}
+	logger.Logger.WithField("workdir", appConfig.Workdir).Info("Using workdir")
+
 	// make sure workdir exists
 	os.MkdirAll(appConfig.Workdir, os.ModePerm)
 
-	err = logger.AddFileLogger(appConfig.Workdir)
+	err = logger.AddFileLogger(appConfig.Workdir, logger.FileLoggerOptions{
+		Path:       appConfig.LogFilePath,
+		MaxSizeMB:  appConfig.LogFileMaxSizeMB,
+		MaxAgeDays: appConfig.LogFileMaxAgeDays,
+		MaxBackups: appConfig.LogFileMaxBackups,
+		Compress:   appConfig.LogFileCompress,
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	finishRestoreNode(appConfig.Workdir)
 
-	// If DATABASE_URI is a URI or a path, leave it unchanged.
-	// If it only contains a filename, prepend the workdir.
-	if !strings.HasPrefix(appConfig.DatabaseUri, "file:") {
-		databasePath, _ := filepath.Split(appConfig.DatabaseUri)
-		if databasePath == "" {
-			appConfig.DatabaseUri = filepath.Join(appConfig.Workdir, appConfig.DatabaseUri)
-		}
-	}
-
 	gormDB, err := db.NewDB(appConfig.DatabaseUri)
 	if err != nil {
 		return nil, err
@@ -95,6 +167,7 @@ func NewService(ctx context.Context) (*service, error) {
 	}
 
 	keys := keys.NewKeys()
+	transactionsService := transactions.NewTransactionsService(gormDB, cfg, eventPublisher)
 
 	var wg sync.WaitGroup
 	svc := &service{
@@ -103,11 +176,26 @@ func NewService(ctx context.Context) (*service, error) {
 		wg:                  &wg,
 		eventPublisher:      eventPublisher,
 		albyOAuthSvc:        alby.NewAlbyOAuthService(gormDB, cfg, keys, eventPublisher),
+		alertsService:       alerts.NewAlertsService(cfg),
 		nip47Service:        nip47.NewNip47Service(gormDB, cfg, keys, eventPublisher),
-		transactionsService: transactions.NewTransactionsService(gormDB),
+		transactionsService: transactionsService,
+		swapsService:        swaps.NewSwapsService(gormDB, cfg, transactionsService),
+		channelBackupSvc:    backups.NewChannelBackupService(cfg),
+		activitySvc:         activity.NewActivityService(gormDB),
+		reportsSvc:          reports.NewReportsService(gormDB, cfg),
+		tagsSvc:             tags.NewTagsService(gormDB),
+		dumpsSvc:            dumps.NewDumpsService(gormDB, cfg, transactionsService),
 		db:                  gormDB,
 		keys:                keys,
 	}
+	if appConfig.AllInOne {
+		svc.embeddedRelay = relay.NewRelay()
+		logger.Logger.WithField("relay_url", appConfig.Relay).Info("Serving the embedded relay")
+	}
+	if appConfig.LeaderElection {
+		svc.leaderElection = leader.NewLeaderElection(gormDB, leader.HubLeaseName, appConfig.ReplicaId, time.Duration(appConfig.LeaderLeaseSecs)*time.Second)
+		logger.Logger.WithField("replica_id", appConfig.ReplicaId).Info("Leader election enabled")
+	}
 
 	// Note: order is important here: transactions service will update transactions
 	// from payment events, which will then be consumed by the NIP-47 service to send notifications
@@ -115,6 +203,9 @@ func NewService(ctx context.Context) (*service, error) {
 	eventPublisher.RegisterSubscriber(svc.transactionsService)
 	eventPublisher.RegisterSubscriber(svc.nip47Service)
 	eventPublisher.RegisterSubscriber(svc.albyOAuthSvc)
+	eventPublisher.RegisterSubscriber(svc.alertsService)
+	eventPublisher.RegisterSubscriber(svc.activitySvc)
+	eventPublisher.RegisterSubscriber(svc.tagsSvc)
 
 	eventPublisher.Publish(&events.Event{
 		Event: "nwc_started",
@@ -127,16 +218,29 @@ func NewService(ctx context.Context) (*service, error) {
 		startProfiler(ctx, appConfig.GoProfilerAddr)
 	}
 
+	if appConfig.MetricsAddr != "" {
+		startMetricsServer(ctx, appConfig.MetricsAddr)
+	}
+
 	if appConfig.DdProfilerEnabled {
 		startDataDogProfiler(ctx)
 	}
 
+	if appConfig.HasExternalSecrets() && appConfig.SecretsRefreshMins > 0 {
+		svc.startSecretsRefresh(ctx, time.Duration(appConfig.SecretsRefreshMins)*time.Minute)
+	}
+
 	return svc, nil
 }
 
-func (svc *service) createFilters(identityPubkey string) nostr.Filters {
+// requests to the previous identity key are still handled during the grace period after a rotation
+func (svc *service) createFilters() nostr.Filters {
+	identityPubkeys := []string{svc.keys.GetNostrPublicKey()}
+	if previousPubkey := svc.keys.GetPreviousNostrPublicKey(); previousPubkey != "" {
+		identityPubkeys = append(identityPubkeys, previousPubkey)
+	}
 	filter := nostr.Filter{
-		Tags:  nostr.TagMap{"p": []string{identityPubkey}},
+		Tags:  nostr.TagMap{"p": identityPubkeys},
 		Kinds: []int{models.REQUEST_KIND},
 	}
 	return []nostr.Filter{filter}
@@ -147,16 +251,46 @@ func (svc *service) noticeHandler(notice string) {
 }
 
 func (svc *service) StartSubscription(ctx context.Context, sub *nostr.Subscription) error {
-	svc.nip47Service.StartNotifier(ctx, sub.Relay, svc.lnClient)
+	relay := &frameLoggingRelay{relay: sub.Relay}
+	svc.nip47Service.StartNotifier(ctx, relay, svc.lnClient)
+	svc.nip47Service.RecoverResponses(ctx, relay)
 
 	go func() {
 		// block till EOS is received
 		<-sub.EndOfStoredEvents
 		logger.Logger.Info("Received EOS")
 
+		queue := func(event *nostr.Event, receivedAt time.Time) {
+			queued := svc.requestQueue.push(&queuedRequest{
+				ctx:        ctx,
+				relay:      relay,
+				event:      event,
+				receivedAt: receivedAt,
+			})
+			if !queued {
+				// the event was not stored, so it is received again after reconnecting to the relay
+				logger.Logger.WithFields(logrus.Fields{
+					"requestEventNostrId": event.ID,
+					"appPubkey":           event.PubKey,
+				}).Warn("Request queue is full, dropping event")
+			}
+		}
+
+		// requests received before the app was handed over to this process,
+		// some of them can be received by both subscriptions
+		handedOver := map[string]bool{}
+		for _, standbyEvent := range svc.takeStandbyEvents() {
+			handedOver[standbyEvent.event.ID] = true
+			queue(standbyEvent.event, standbyEvent.receivedAt)
+		}
+
 		// loop through incoming events
 		for event := range sub.Events {
-			go svc.nip47Service.HandleEvent(ctx, sub.Relay, event, svc.lnClient)
+			logNostrFrame("Received event", sub.Relay.URL, event)
+			if handedOver[event.ID] {
+				continue
+			}
+			queue(event, time.Now())
 		}
 		logger.Logger.Info("Relay subscription events channel ended")
 	}()
@@ -217,6 +351,51 @@ func (svc *service) Shutdown() {
 	})
 	// wait for any remaining events
 	time.Sleep(1 * time.Second)
+
+	// the relay and LN client are stopped at this point
+	if svc.embeddedRelay != nil {
+		svc.embeddedRelay.CloseConnections()
+	}
+	err := db.Stop(svc.db)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to close database")
+	}
+	logger.FlushSentry()
+}
+
+// ReloadConfig re-reads the config and applies the settings that can be changed without a restart
+func (svc *service) ReloadConfig() error {
+	appConfig, err := LoadAppConfig()
+	if err != nil {
+		return err
+	}
+	err = appConfig.Validate()
+	if err != nil {
+		return err
+	}
+	svc.cfg.Reload(appConfig)
+	svc.alertsService.Reload()
+	return nil
+}
+
+// startSecretsRefresh periodically reloads the config, so rotated secrets in files and secret managers are picked up
+func (svc *service) startSecretsRefresh(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := svc.ReloadConfig()
+				if err != nil {
+					// the current secrets are kept
+					logger.Logger.WithError(err).Error("Failed to refresh secrets")
+				}
+			}
+		}
+	}()
 }
 
 func (svc *service) GetDB() *gorm.DB {
@@ -247,6 +426,30 @@ func (svc *service) GetTransactionsService() transactions.TransactionsService {
 	return svc.transactionsService
 }
 
+func (svc *service) GetSwapsService() swaps.SwapsService {
+	return svc.swapsService
+}
+
+func (svc *service) GetChannelBackupService() backups.ChannelBackupService {
+	return svc.channelBackupSvc
+}
+
+func (svc *service) GetActivityService() activity.ActivityService {
+	return svc.activitySvc
+}
+
+func (svc *service) GetReportsService() reports.ReportsService {
+	return svc.reportsSvc
+}
+
+func (svc *service) GetTagsService() tags.TagsService {
+	return svc.tagsSvc
+}
+
 func (svc *service) GetKeys() keys.Keys {
 	return svc.keys
 }
+
+func (svc *service) GetEmbeddedRelay() *relay.Relay {
+	return svc.embeddedRelay
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/sirupsen/logrus"
+
+	"github.com/getAlby/hub/logger"
+)
+
+// standby is the relay subscription of a process that takes over from a process being upgraded.
+// NIP-47 requests are ephemeral events that relays do not store, so the new process subscribes
+// before the old one stops and keeps the requests until its own app is started.
+type standby struct {
+	relay   *nostr.Relay
+	mu      sync.Mutex
+	events  []standbyEvent
+	dropped int
+	// closed once the standby subscription stopped receiving events
+	done chan struct{}
+}
+
+type standbyEvent struct {
+	event      *nostr.Event
+	receivedAt time.Time
+}
+
+// StandBy subscribes to the relay on behalf of the app that is still running in the process being upgraded.
+// The app cannot be started until TakeOver is called.
+func (svc *service) StandBy(encryptionKey string) error {
+	if !svc.cfg.CheckUnlockPassword(encryptionKey) {
+		return errors.New("invalid password")
+	}
+	svc.standbyMtx.Lock()
+	svc.handingOver = true
+	svc.standbyMtx.Unlock()
+
+	if svc.embeddedRelay != nil {
+		// the apps are connected to the embedded relay of the old process and reconnect to this one
+		logger.Logger.Info("Not subscribing before the handover, the embedded relay is handed over with the process")
+		return nil
+	}
+
+	err := svc.keys.Init(svc.cfg, encryptionKey)
+	if err != nil {
+		return err
+	}
+	relay, err := nostr.RelayConnect(svc.ctx, svc.cfg.GetRelayUrl(), nostr.WithNoticeHandler(svc.noticeHandler))
+	if err != nil {
+		return err
+	}
+	sub, err := relay.Subscribe(svc.ctx, svc.createFilters())
+	if err != nil {
+		closeRelay(relay)
+		return err
+	}
+	select {
+	case <-sub.EndOfStoredEvents:
+	case <-sub.Context.Done():
+		closeRelay(relay)
+		return errors.New("relay closed the subscription")
+	}
+
+	s := &standby{
+		relay: relay,
+		done:  make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		for event := range sub.Events {
+			logNostrFrame("Received event", relay.URL, event)
+			s.mu.Lock()
+			if len(s.events) < defaultNip47QueueSize {
+				s.events = append(s.events, standbyEvent{event: event, receivedAt: time.Now()})
+			} else {
+				s.dropped++
+			}
+			s.mu.Unlock()
+		}
+	}()
+
+	svc.standbyMtx.Lock()
+	svc.standby = s
+	svc.standbyMtx.Unlock()
+	logger.Logger.Info("Subscribed to the relay before the handover")
+	return nil
+}
+
+// TakeOver starts the app once the process being upgraded has stopped it.
+// The requests received by the standby subscription are handled first.
+func (svc *service) TakeOver(encryptionKey string) error {
+	svc.standbyMtx.Lock()
+	svc.handingOver = false
+	svc.standbyMtx.Unlock()
+	return svc.StartApp(encryptionKey)
+}
+
+func (svc *service) isHandingOver() bool {
+	svc.standbyMtx.Lock()
+	defer svc.standbyMtx.Unlock()
+	return svc.handingOver
+}
+
+// takeStandbyEvents closes the standby subscription and returns the requests it received,
+// once the subscription of the app is receiving them instead
+func (svc *service) takeStandbyEvents() []standbyEvent {
+	svc.standbyMtx.Lock()
+	s := svc.standby
+	svc.standby = nil
+	svc.standbyMtx.Unlock()
+	if s == nil {
+		return nil
+	}
+
+	closeRelay(s.relay)
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	logger.Logger.WithFields(logrus.Fields{
+		"events":  len(s.events),
+		"dropped": s.dropped,
+	}).Info("Handing standby requests over to the app")
+	return s.events
+}
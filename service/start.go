@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"path"
 	"time"
 
@@ -12,20 +13,21 @@ import (
 
 	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/leader"
 	"github.com/getAlby/hub/lnclient"
 	"github.com/getAlby/hub/lnclient/breez"
+	"github.com/getAlby/hub/lnclient/btcpay"
 	"github.com/getAlby/hub/lnclient/cashu"
 	"github.com/getAlby/hub/lnclient/greenlight"
 	"github.com/getAlby/hub/lnclient/ldk"
 	"github.com/getAlby/hub/lnclient/lnd"
+	"github.com/getAlby/hub/lnclient/nwc"
 	"github.com/getAlby/hub/lnclient/phoenixd"
 	"github.com/getAlby/hub/logger"
 )
 
 func (svc *service) startNostr(ctx context.Context, encryptionKey string) error {
 
-	relayUrl := svc.cfg.GetRelayUrl()
-
 	err := svc.keys.Init(svc.cfg, encryptionKey)
 	if err != nil {
 		logger.Logger.WithError(err).Fatal("Failed to init nostr keys")
@@ -44,110 +46,177 @@ func (svc *service) startNostr(ctx context.Context, encryptionKey string) error
 		svc.wg.Add(1)
 		// ensure the relay is properly disconnected before exiting
 		defer svc.wg.Done()
-		//Start infinite loop which will be only broken by canceling ctx (SIGINT)
-		var relay *nostr.Relay
-		waitToReconnectSeconds := 0
+		if svc.leaderElection == nil {
+			svc.runRelay(ctx)
+			return
+		}
+		// every replica handles /api/nip47 requests, the leader checks the lease before answering them as interrupted
+		svc.wg.Add(1)
+		go func() {
+			defer svc.wg.Done()
+			leader.RunReplicaLease(ctx, svc.db, svc.cfg.GetEnv().ReplicaId, time.Duration(svc.cfg.GetEnv().LeaderLeaseSecs)*time.Second)
+		}()
+		// only the leader subscribes to the relay, so that every request is handled by one replica
+		svc.leaderElection.Run(ctx, func(leaderCtx context.Context) {
+			svc.startBackgroundTasks(leaderCtx)
+			svc.runRelay(leaderCtx)
+		})
+	}()
+	return nil
+}
+
+// runRelay connects and subscribes to the relay until ctx is done
+func (svc *service) runRelay(ctx context.Context) {
+	//Start infinite loop which will be only broken by canceling ctx (SIGINT)
+	var relay *nostr.Relay
+	var err error
+	waitToReconnectSeconds := 0
 
-		for i := 0; ; i++ {
+	for i := 0; ; i++ {
 
-			// wait for a delay if any before retrying
-			if waitToReconnectSeconds > 0 {
-				contextCancelled := false
+		// wait for a delay if any before retrying
+		if waitToReconnectSeconds > 0 {
+			contextCancelled := false
 
-				select {
-				case <-ctx.Done(): //context cancelled
-					logger.Logger.Info("service context cancelled while waiting for retry")
-					contextCancelled = true
-				case <-time.After(time.Duration(waitToReconnectSeconds) * time.Second): //timeout
-				}
-				if contextCancelled {
-					break
-				}
+			select {
+			case <-ctx.Done(): //context cancelled
+				logger.Logger.Info("service context cancelled while waiting for retry")
+				contextCancelled = true
+			case <-time.After(time.Duration(waitToReconnectSeconds) * time.Second): //timeout
 			}
+			if contextCancelled {
+				break
+			}
+		}
 
-			closeRelay(relay)
+		closeRelay(relay)
+		svc.setRelay(nil)
 
-			//connect to the relay
-			logger.Logger.WithFields(logrus.Fields{
-				"relay_url": relayUrl,
-				"iteration": i,
-			}).Info("Connecting to the relay")
+		// read on every reconnect so that a reloaded relay url is picked up
+		relayUrl := svc.cfg.GetRelayUrl()
+		if svc.embeddedRelay != nil {
+			relayUrl = svc.cfg.GetEnv().LocalEmbeddedRelayUrl()
+		}
 
-			relay, err = nostr.RelayConnect(ctx, relayUrl, nostr.WithNoticeHandler(svc.noticeHandler))
-			if err != nil {
-				// exponential backoff from 2 - 60 seconds
-				waitToReconnectSeconds = max(waitToReconnectSeconds, 1)
-				waitToReconnectSeconds *= 2
-				waitToReconnectSeconds = min(waitToReconnectSeconds, 60)
-				logger.Logger.WithFields(logrus.Fields{
-					"iteration":     i,
-					"retry_seconds": waitToReconnectSeconds,
-				}).WithError(err).Error("Failed to connect to relay")
-				continue
-			}
+		//connect to the relay
+		logger.Logger.WithFields(logrus.Fields{
+			"relay_url": relayUrl,
+			"iteration": i,
+		}).Info("Connecting to the relay")
+
+		relay, err = nostr.RelayConnect(ctx, relayUrl, nostr.WithNoticeHandler(svc.noticeHandler))
+		if err != nil {
+			// exponential backoff from 2 - 60 seconds
+			waitToReconnectSeconds = max(waitToReconnectSeconds, 1)
+			waitToReconnectSeconds *= 2
+			waitToReconnectSeconds = min(waitToReconnectSeconds, 60)
+			logger.Logger.WithFields(logrus.Fields{
+				"iteration":     i,
+				"retry_seconds": waitToReconnectSeconds,
+			}).WithError(err).Error("Failed to connect to relay")
+			continue
+		}
 
-			waitToReconnectSeconds = 0
+		waitToReconnectSeconds = 0
+		svc.setRelay(relay)
 
-			//publish event with NIP-47 info
-			err = svc.nip47Service.PublishNip47Info(ctx, relay, svc.lnClient)
-			if err != nil {
-				logger.Logger.WithError(err).Error("Could not publish NIP47 info")
-			}
+		//publish event with NIP-47 info
+		err = svc.nip47Service.PublishNip47Info(ctx, &frameLoggingRelay{relay: relay}, svc.lnClient)
+		if err != nil {
+			logger.Logger.WithError(err).Error("Could not publish NIP47 info")
+		}
 
-			logger.Logger.Info("Subscribing to events")
-			sub, err := relay.Subscribe(ctx, svc.createFilters(svc.keys.GetNostrPublicKey()))
-			if err != nil {
-				logger.Logger.WithError(err).Error("Failed to subscribe to events")
-				continue
-			}
-			err = svc.StartSubscription(sub.Context, sub)
-			if err != nil {
-				//err being non-nil means that we have an error on the websocket error channel. In this case we just try to reconnect.
-				logger.Logger.WithError(err).Error("Got an error from the relay while listening to subscription.")
-				continue
-			}
-			//err being nil means that the context was canceled and we should exit the program.
-			break
+		logger.Logger.Info("Subscribing to events")
+		sub, err := relay.Subscribe(ctx, svc.createFilters())
+		if err != nil {
+			logger.Logger.WithError(err).Error("Failed to subscribe to events")
+			continue
 		}
-		closeRelay(relay)
-		logger.Logger.Info("Relay subroutine ended")
-	}()
-	return nil
+		svc.setSubscription(sub)
+		err = svc.StartSubscription(sub.Context, sub)
+		if err != nil {
+			//err being non-nil means that we have an error on the websocket error channel. In this case we just try to reconnect.
+			logger.Logger.WithError(err).Error("Got an error from the relay while listening to subscription.")
+			continue
+		}
+		//err being nil means that the context was canceled and we should exit the program.
+		break
+	}
+	// in-flight requests still need the relay to publish their responses
+	svc.drainRequestHandlers()
+	closeRelay(relay)
+	svc.clearRelay()
+	logger.Logger.Info("Relay subroutine ended")
 }
 
 func (svc *service) StartApp(encryptionKey string) error {
+	return svc.startApp(encryptionKey, nil)
+}
+
+// StartAppWithLNClient starts the app with the given LN client instead of the configured LN backend,
+// e.g. a mock client for the loadtest command
+func (svc *service) StartAppWithLNClient(encryptionKey string, lnClient lnclient.LNClient) error {
+	return svc.startApp(encryptionKey, lnClient)
+}
+
+func (svc *service) startApp(encryptionKey string, lnClient lnclient.LNClient) error {
 	if svc.lnClient != nil {
 		return errors.New("app already started")
 	}
+	if svc.isHandingOver() {
+		return errors.New("the app is being handed over from the process being upgraded")
+	}
 	if !svc.cfg.CheckUnlockPassword(encryptionKey) {
 		logger.Logger.Errorf("Invalid password")
 		return errors.New("invalid password")
 	}
 
 	ctx, cancelFn := context.WithCancel(svc.ctx)
+	svc.requestHandlersCtx, svc.cancelRequestHandlers = context.WithCancel(context.WithoutCancel(ctx))
+	svc.requestQueue = newRequestQueue(svc.nip47QueueSizes())
+	svc.startRequestWorkers(svc.requestHandlersCtx, svc.requestQueue)
 
-	err := svc.launchLNBackend(ctx, encryptionKey)
+	err := svc.launchLNBackend(ctx, encryptionKey, lnClient)
 	if err != nil {
 		logger.Logger.Errorf("Failed to launch LN backend: %v", err)
 		svc.eventPublisher.Publish(&events.Event{
 			Event: "nwc_node_start_failed",
 		})
 		cancelFn()
+		svc.cancelRequestHandlers()
 		return err
 	}
 
+	if svc.leaderElection == nil {
+		svc.startBackgroundTasks(ctx)
+	}
+
 	err = svc.startNostr(ctx, encryptionKey)
 	if err != nil {
 		cancelFn()
+		svc.cancelRequestHandlers()
 		return err
 	}
 
 	svc.appCancelFn = cancelFn
+	svc.encryptionKey = encryptionKey
 
 	return nil
 }
 
-func (svc *service) launchLNBackend(ctx context.Context, encryptionKey string) error {
+// startBackgroundTasks starts the tasks that run on one replica only, until ctx is done
+func (svc *service) startBackgroundTasks(ctx context.Context) {
+	svc.transactionsService.StartPaymentSweeper(ctx)
+	svc.transactionsService.StartBudgetMonitor(ctx)
+	svc.swapsService.StartSwapMonitor(ctx, svc.lnClient)
+	svc.channelBackupSvc.StartChannelBackupMonitor(ctx, svc.lnClient)
+	svc.reportsSvc.StartReportMailer(ctx)
+	svc.dumpsSvc.StartDumps(ctx)
+	svc.alertsService.StartCommands(ctx, svc.lnClient)
+	svc.alertsService.StartAlertRules(ctx)
+}
+
+func (svc *service) launchLNBackend(ctx context.Context, encryptionKey string, lnClient lnclient.LNClient) error {
 	if svc.lnClient != nil {
 		logger.Logger.Error("LNClient already started")
 		return errors.New("LNClient already started")
@@ -157,12 +226,51 @@ func (svc *service) launchLNBackend(ctx context.Context, encryptionKey string) e
 		// ensure the LNClient is stopped properly before exiting
 		svc.wg.Add(1)
 		<-ctx.Done()
+		svc.drainRequestHandlers()
 		svc.stopLNClient()
 	}()
 
+	lnBackend := "custom"
+	if lnClient == nil {
+		var err error
+		lnBackend, lnClient, err = svc.newLNClient(ctx, encryptionKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	svc.lnClient = lnClient
+	info, err := lnClient.GetInfo(ctx)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to fetch node info")
+	}
+	if info != nil {
+		svc.eventPublisher.SetGlobalProperty("node_id", info.Pubkey)
+		svc.eventPublisher.SetGlobalProperty("network", info.Network)
+	}
+
+	svc.eventPublisher.Publish(&events.Event{
+		Event: "nwc_node_started",
+		Properties: map[string]interface{}{
+			"node_type": lnBackend,
+		},
+	})
+
+	return nil
+}
+
+func (svc *service) newLNClient(ctx context.Context, encryptionKey string) (string, lnclient.LNClient, error) {
 	lnBackend, _ := svc.cfg.Get("LNBackendType", "")
 	if lnBackend == "" {
-		return errors.New("no LNBackendType specified")
+		return "", nil, errors.New("no LNBackendType specified")
+	}
+
+	if !svc.cfg.IsBackendEnabled(lnBackend) {
+		return "", nil, fmt.Errorf("the %s backend is experimental, enable the %s feature flag to use it", lnBackend, config.BackendFeature(lnBackend))
+	}
+
+	if svc.leaderElection != nil && !config.SupportsReplicas(lnBackend) {
+		return "", nil, fmt.Errorf("the %s backend cannot be shared by replicas, LEADER_ELECTION needs a node that runs outside of the hub", lnBackend)
 	}
 
 	logger.Logger.Infof("Launching LN Backend: %s", lnBackend)
@@ -202,32 +310,63 @@ func (svc *service) launchLNBackend(ctx context.Context, encryptionKey string) e
 		cashuWorkdir := path.Join(svc.cfg.GetEnv().Workdir, "cashu")
 
 		lnClient, err = cashu.NewCashuService(cashuWorkdir, cashuMintUrl)
+	case config.BTCPayBackendType:
+		BTCPayUrl, _ := svc.cfg.Get("BTCPayUrl", encryptionKey)
+		BTCPayApiKey, _ := svc.cfg.Get("BTCPayApiKey", encryptionKey)
+		BTCPayStoreId, _ := svc.cfg.Get("BTCPayStoreId", encryptionKey)
+
+		lnClient, err = btcpay.NewBTCPayService(BTCPayUrl, BTCPayApiKey, BTCPayStoreId)
+	case config.NWCBackendType:
+		NWCConnectionUri, _ := svc.cfg.Get("NWCConnectionUri", encryptionKey)
+
+		lnClient, err = nwc.NewNWCService(ctx, svc.eventPublisher, NWCConnectionUri, svc.keys.GetNostrPublicKey())
 	default:
 		logger.Logger.Fatalf("Unsupported LNBackendType: %v", lnBackend)
 	}
 	if err != nil {
 		logger.Logger.WithError(err).Error("Failed to launch LN backend")
-		return err
+		return "", nil, err
 	}
+	return lnBackend, lnClient, nil
+}
 
-	svc.lnClient = lnClient
-	info, err := lnClient.GetInfo(ctx)
-	if err != nil {
-		logger.Logger.WithError(err).Error("Failed to fetch node info")
+func (svc *service) setRelay(relay *nostr.Relay) {
+	svc.relayMtx.Lock()
+	wasDown := !svc.relayDownSince.IsZero()
+	svc.relay = relay
+	svc.subscription = nil
+	if relay != nil {
+		svc.relayDownSince = time.Time{}
+	} else if svc.relayDownSince.IsZero() {
+		svc.relayDownSince = time.Now()
 	}
-	if info != nil {
-		svc.eventPublisher.SetGlobalProperty("node_id", info.Pubkey)
-		svc.eventPublisher.SetGlobalProperty("network", info.Network)
+	svc.relayMtx.Unlock()
+
+	// for the alert rules, published outside of the lock as subscribers can check the health
+	if relay != nil && wasDown {
+		svc.eventPublisher.Publish(&events.Event{
+			Event: "nwc_relay_connected",
+		})
+	} else if relay == nil && !wasDown {
+		svc.eventPublisher.Publish(&events.Event{
+			Event: "nwc_relay_disconnected",
+		})
 	}
+}
 
-	svc.eventPublisher.Publish(&events.Event{
-		Event: "nwc_node_started",
-		Properties: map[string]interface{}{
-			"node_type": lnBackend,
-		},
-	})
+// clearRelay marks the relay loop as stopped so it is no longer part of health checks
+func (svc *service) clearRelay() {
+	svc.relayMtx.Lock()
+	defer svc.relayMtx.Unlock()
+	svc.relay = nil
+	svc.subscription = nil
+	svc.relayDownSince = time.Time{}
+}
 
-	return nil
+func (svc *service) setSubscription(sub *nostr.Subscription) {
+	svc.relayMtx.Lock()
+	defer svc.relayMtx.Unlock()
+	svc.subscription = sub
 }
 
 func closeRelay(relay *nostr.Relay) {
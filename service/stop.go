@@ -1,21 +1,69 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/getAlby/hub/events"
 	"github.com/getAlby/hub/logger"
 )
 
+// how long in-flight NIP-47 requests get to finish when the app is stopped
+const requestDrainTimeout = 60 * time.Second
+
 func (svc *service) StopApp() {
 	if svc.appCancelFn != nil {
 		logger.Logger.Info("Stopping app...")
 		svc.appCancelFn()
 		svc.wg.Wait()
+		svc.cancelRequestHandlers()
+		svc.encryptionKey = ""
 		logger.Logger.Info("app stopped")
 	}
 }
 
+// HandOverPassword returns the unlock password a new process needs to take over the app,
+// or an empty password if the app was not started
+func (svc *service) HandOverPassword() string {
+	return svc.encryptionKey
+}
+
+// startRequestHandler registers a new in-flight request,
+// unless the subscription context is done and the app is shutting down
+func (svc *service) startRequestHandler(ctx context.Context) bool {
+	svc.requestHandlersMtx.Lock()
+	defer svc.requestHandlersMtx.Unlock()
+	if ctx.Err() != nil {
+		return false
+	}
+	svc.requestHandlersWg.Add(1)
+	return true
+}
+
+// drainRequestHandlers waits for in-flight requests (e.g. multi_pay batches) to finish and their responses to be published,
+// cancelling them if they take longer than requestDrainTimeout
+func (svc *service) drainRequestHandlers() {
+	// no new handlers can be registered once the lock is released
+	svc.requestHandlersMtx.Lock()
+	svc.requestHandlersMtx.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		svc.requestHandlersWg.Wait()
+		svc.nip47Service.WaitForPublishedResponses()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		logger.Logger.Info("In-flight requests finished")
+	case <-time.After(requestDrainTimeout):
+		logger.Logger.Warn("Timed out waiting for in-flight requests, cancelling them")
+		svc.cancelRequestHandlers()
+	}
+}
+
 func (svc *service) stopLNClient() {
 	defer svc.wg.Done()
 	if svc.lnClient == nil {
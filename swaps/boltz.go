@@ -0,0 +1,243 @@
+package swaps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Boltz swap statuses, see https://docs.boltz.exchange/api/lifecycle
+const (
+	boltzStatusSwapExpired             = "swap.expired"
+	boltzStatusTransactionMempool      = "transaction.mempool"
+	boltzStatusTransactionConfirmed    = "transaction.confirmed"
+	boltzStatusTransactionClaimed      = "transaction.claimed"
+	boltzStatusTransactionClaimPending = "transaction.claim.pending"
+	boltzStatusTransactionFailed       = "transaction.failed"
+	boltzStatusTransactionLockupFailed = "transaction.lockupFailed"
+	boltzStatusTransactionRefunded     = "transaction.refunded"
+	boltzStatusInvoiceSettled          = "invoice.settled"
+	boltzStatusInvoicePaid             = "invoice.paid"
+	boltzStatusInvoiceFailedToPay      = "invoice.failedToPay"
+	boltzStatusInvoiceExpired          = "invoice.expired"
+)
+
+const (
+	boltzCurrency            = "BTC"
+	boltzRequestTimeout      = 30 * time.Second
+	boltzTaprootLeafVersion  = 192
+	boltzMaxResponseBodySize = 1 << 20
+)
+
+type boltzClient struct {
+	apiUrl     string
+	httpClient *http.Client
+}
+
+type boltzLeaf struct {
+	Version uint8  `json:"version"`
+	Output  string `json:"output"`
+}
+
+type boltzSwapTree struct {
+	ClaimLeaf  boltzLeaf `json:"claimLeaf"`
+	RefundLeaf boltzLeaf `json:"refundLeaf"`
+}
+
+type boltzPairLimits struct {
+	Minimal uint64 `json:"minimal"`
+	Maximal uint64 `json:"maximal"`
+}
+
+type boltzPairFees struct {
+	Percentage float64         `json:"percentage"`
+	MinerFees  json.RawMessage `json:"minerFees"`
+}
+
+type boltzPair struct {
+	Hash   string          `json:"hash"`
+	Limits boltzPairLimits `json:"limits"`
+	Fees   boltzPairFees   `json:"fees"`
+}
+
+type boltzCreateSubmarineSwapRequest struct {
+	From            string `json:"from"`
+	To              string `json:"to"`
+	Invoice         string `json:"invoice"`
+	RefundPublicKey string `json:"refundPublicKey"`
+	PairHash        string `json:"pairHash,omitempty"`
+}
+
+type boltzCreateSubmarineSwapResponse struct {
+	Id                 string        `json:"id"`
+	Address            string        `json:"address"`
+	SwapTree           boltzSwapTree `json:"swapTree"`
+	ClaimPublicKey     string        `json:"claimPublicKey"`
+	TimeoutBlockHeight uint32        `json:"timeoutBlockHeight"`
+	ExpectedAmount     uint64        `json:"expectedAmount"`
+}
+
+type boltzCreateReverseSwapRequest struct {
+	From           string `json:"from"`
+	To             string `json:"to"`
+	InvoiceAmount  uint64 `json:"invoiceAmount"`
+	PreimageHash   string `json:"preimageHash"`
+	ClaimPublicKey string `json:"claimPublicKey"`
+	PairHash       string `json:"pairHash,omitempty"`
+}
+
+type boltzCreateReverseSwapResponse struct {
+	Id                 string        `json:"id"`
+	Invoice            string        `json:"invoice"`
+	SwapTree           boltzSwapTree `json:"swapTree"`
+	LockupAddress      string        `json:"lockupAddress"`
+	RefundPublicKey    string        `json:"refundPublicKey"`
+	TimeoutBlockHeight uint32        `json:"timeoutBlockHeight"`
+	OnchainAmount      uint64        `json:"onchainAmount"`
+}
+
+type boltzTransaction struct {
+	Id  string `json:"id"`
+	Hex string `json:"hex"`
+}
+
+type boltzSwapStatus struct {
+	Status      string            `json:"status"`
+	Transaction *boltzTransaction `json:"transaction"`
+}
+
+type boltzErrorResponse struct {
+	Error string `json:"error"`
+}
+
+func newBoltzClient(apiUrl string) *boltzClient {
+	return &boltzClient{
+		apiUrl: apiUrl,
+		httpClient: &http.Client{
+			Timeout: boltzRequestTimeout,
+		},
+	}
+}
+
+func (boltz *boltzClient) getSubmarinePair(ctx context.Context) (*boltzPair, error) {
+	pairs := map[string]map[string]boltzPair{}
+	err := boltz.call(ctx, http.MethodGet, "/swap/submarine", nil, &pairs)
+	if err != nil {
+		return nil, err
+	}
+	return findBoltzPair(pairs)
+}
+
+func (boltz *boltzClient) getReversePair(ctx context.Context) (*boltzPair, error) {
+	pairs := map[string]map[string]boltzPair{}
+	err := boltz.call(ctx, http.MethodGet, "/swap/reverse", nil, &pairs)
+	if err != nil {
+		return nil, err
+	}
+	return findBoltzPair(pairs)
+}
+
+func (boltz *boltzClient) createSubmarineSwap(ctx context.Context, request *boltzCreateSubmarineSwapRequest) (*boltzCreateSubmarineSwapResponse, error) {
+	response := &boltzCreateSubmarineSwapResponse{}
+	err := boltz.call(ctx, http.MethodPost, "/swap/submarine", request, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (boltz *boltzClient) createReverseSwap(ctx context.Context, request *boltzCreateReverseSwapRequest) (*boltzCreateReverseSwapResponse, error) {
+	response := &boltzCreateReverseSwapResponse{}
+	err := boltz.call(ctx, http.MethodPost, "/swap/reverse", request, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (boltz *boltzClient) getSwapStatus(ctx context.Context, id string) (*boltzSwapStatus, error) {
+	response := &boltzSwapStatus{}
+	err := boltz.call(ctx, http.MethodGet, "/swap/"+id, nil, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (boltz *boltzClient) getTransaction(ctx context.Context, txId string) (*boltzTransaction, error) {
+	response := &boltzTransaction{}
+	err := boltz.call(ctx, http.MethodGet, "/chain/"+boltzCurrency+"/transaction/"+txId, nil, response)
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (boltz *boltzClient) getFeeRate(ctx context.Context) (float64, error) {
+	response := struct {
+		Fee float64 `json:"fee"`
+	}{}
+	err := boltz.call(ctx, http.MethodGet, "/chain/"+boltzCurrency+"/fee", nil, &response)
+	if err != nil {
+		return 0, err
+	}
+	return response.Fee, nil
+}
+
+func (boltz *boltzClient) broadcastTransaction(ctx context.Context, txHex string) (string, error) {
+	response := boltzTransaction{}
+	err := boltz.call(ctx, http.MethodPost, "/chain/"+boltzCurrency+"/transaction", map[string]string{"hex": txHex}, &response)
+	if err != nil {
+		return "", err
+	}
+	return response.Id, nil
+}
+
+func (boltz *boltzClient) call(ctx context.Context, method string, path string, payload interface{}, result interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(payloadBytes)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, boltz.apiUrl+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := boltz.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call Boltz %s %s: %w", method, path, err)
+	}
+	defer res.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(res.Body, boltzMaxResponseBodySize))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= 300 {
+		errorResponse := boltzErrorResponse{}
+		if json.Unmarshal(responseBody, &errorResponse) == nil && errorResponse.Error != "" {
+			return fmt.Errorf("Boltz %s %s failed: %s", method, path, errorResponse.Error)
+		}
+		return fmt.Errorf("Boltz %s %s failed with status %d", method, path, res.StatusCode)
+	}
+	return json.Unmarshal(responseBody, result)
+}
+
+func findBoltzPair(pairs map[string]map[string]boltzPair) (*boltzPair, error) {
+	pair, ok := pairs[boltzCurrency][boltzCurrency]
+	if !ok {
+		return nil, fmt.Errorf("Boltz does not offer %s/%s swaps", boltzCurrency, boltzCurrency)
+	}
+	return &pair, nil
+}
@@ -0,0 +1,219 @@
+package swaps
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// outputs below this value are not relayed by most nodes
+const dustLimitSat = 546
+
+// swapScript is the taproot output that locks the funds of a swap.
+// Boltz uses the MuSig2 aggregate of both keys as internal key, funds are only
+// spent through the script path here so that no cooperation of Boltz is needed.
+type swapScript struct {
+	internalKey *btcec.PublicKey
+	tree        *txscript.IndexedTapScriptTree
+	claimLeaf   txscript.TapLeaf
+	refundLeaf  txscript.TapLeaf
+	pkScript    []byte
+}
+
+func newSwapScript(swapTree *boltzSwapTree, boltzPublicKey *btcec.PublicKey, ourPublicKey *btcec.PublicKey) (*swapScript, error) {
+	claimLeaf, err := parseTapLeaf(swapTree.ClaimLeaf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid claim leaf: %w", err)
+	}
+	refundLeaf, err := parseTapLeaf(swapTree.RefundLeaf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid refund leaf: %w", err)
+	}
+
+	// Boltz puts its own key first and does not sort the keys
+	aggregateKey, _, _, err := musig2.AggregateKeys([]*btcec.PublicKey{boltzPublicKey, ourPublicKey}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := txscript.AssembleTaprootScriptTree(claimLeaf, refundLeaf)
+	rootHash := tree.RootNode.TapHash()
+	outputKey := txscript.ComputeTaprootOutputKey(aggregateKey.PreTweakedKey, rootHash[:])
+	pkScript, err := txscript.PayToTaprootScript(outputKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &swapScript{
+		internalKey: aggregateKey.PreTweakedKey,
+		tree:        tree,
+		claimLeaf:   claimLeaf,
+		refundLeaf:  refundLeaf,
+		pkScript:    pkScript,
+	}, nil
+}
+
+func parseTapLeaf(leaf boltzLeaf) (txscript.TapLeaf, error) {
+	if leaf.Version != boltzTaprootLeafVersion {
+		return txscript.TapLeaf{}, fmt.Errorf("unsupported leaf version: %d", leaf.Version)
+	}
+	script, err := hex.DecodeString(leaf.Output)
+	if err != nil {
+		return txscript.TapLeaf{}, err
+	}
+	return txscript.NewBaseTapLeaf(script), nil
+}
+
+func (script *swapScript) address(params *chaincfg.Params) (string, error) {
+	// the pk script is OP_1 followed by the 32 byte output key
+	address, err := btcutil.NewAddressTaproot(script.pkScript[2:], params)
+	if err != nil {
+		return "", err
+	}
+	return address.EncodeAddress(), nil
+}
+
+// verifyClaimLeaf makes sure that the funds locked by Boltz can be claimed with our key and preimage
+func (script *swapScript) verifyClaimLeaf(ourPublicKey *btcec.PublicKey, preimage []byte) error {
+	if !bytes.Contains(script.claimLeaf.Script, schnorr.SerializePubKey(ourPublicKey)) {
+		return errors.New("claim leaf does not contain our public key")
+	}
+	// the leaf checks the preimage with OP_HASH160
+	if !bytes.Contains(script.claimLeaf.Script, btcutil.Hash160(preimage)) {
+		return errors.New("claim leaf does not contain the preimage hash")
+	}
+	return nil
+}
+
+// verifyRefundLeaf makes sure that the funds we lock can be refunded with our key after the timeout
+func (script *swapScript) verifyRefundLeaf(ourPublicKey *btcec.PublicKey) error {
+	if !bytes.Contains(script.refundLeaf.Script, schnorr.SerializePubKey(ourPublicKey)) {
+		return errors.New("refund leaf does not contain our public key")
+	}
+	return nil
+}
+
+func (script *swapScript) findOutput(tx *wire.MsgTx) (uint32, int64, error) {
+	for i, txOut := range tx.TxOut {
+		if bytes.Equal(txOut.PkScript, script.pkScript) {
+			return uint32(i), txOut.Value, nil
+		}
+	}
+	return 0, 0, errors.New("lockup transaction does not pay to the swap address")
+}
+
+func (script *swapScript) claimTransaction(lockupTx *wire.MsgTx, outputScript []byte, privateKey *btcec.PrivateKey, preimage []byte, feeRate float64) (*wire.MsgTx, error) {
+	return script.spendTransaction(lockupTx, outputScript, script.claimLeaf, privateKey, [][]byte{preimage}, 0, feeRate)
+}
+
+func (script *swapScript) refundTransaction(lockupTx *wire.MsgTx, outputScript []byte, privateKey *btcec.PrivateKey, timeoutBlockHeight uint32, feeRate float64) (*wire.MsgTx, error) {
+	return script.spendTransaction(lockupTx, outputScript, script.refundLeaf, privateKey, nil, timeoutBlockHeight, feeRate)
+}
+
+func (script *swapScript) spendTransaction(lockupTx *wire.MsgTx, outputScript []byte, leaf txscript.TapLeaf, privateKey *btcec.PrivateKey, witnessData [][]byte, lockTime uint32, feeRate float64) (*wire.MsgTx, error) {
+	outputIndex, amount, err := script.findOutput(lockupTx)
+	if err != nil {
+		return nil, err
+	}
+
+	controlBlock, err := script.controlBlock(leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	lockupTxHash := lockupTx.TxHash()
+	tx := wire.NewMsgTx(2)
+	tx.LockTime = lockTime
+	txIn := wire.NewTxIn(wire.NewOutPoint(&lockupTxHash, outputIndex), nil, nil)
+	if lockTime > 0 {
+		// the lock time is only enforced if the input is not final
+		txIn.Sequence = wire.MaxTxInSequenceNum - 2
+	}
+	tx.AddTxIn(txIn)
+	tx.AddTxOut(wire.NewTxOut(amount, outputScript))
+
+	sign := func() error {
+		prevOutputFetcher := txscript.NewCannedPrevOutputFetcher(script.pkScript, amount)
+		sigHashes := txscript.NewTxSigHashes(tx, prevOutputFetcher)
+		signature, err := txscript.RawTxInTapscriptSignature(tx, sigHashes, 0, amount, script.pkScript, leaf, txscript.SigHashDefault, privateKey)
+		if err != nil {
+			return err
+		}
+		witness := wire.TxWitness{signature}
+		witness = append(witness, witnessData...)
+		tx.TxIn[0].Witness = append(witness, leaf.Script, controlBlock)
+		return nil
+	}
+
+	// sign once to know the size of the transaction, then again with the fee deducted
+	err = sign()
+	if err != nil {
+		return nil, err
+	}
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+	virtualSize := float64(weight+3) / 4
+	fee := int64(math.Ceil(virtualSize * feeRate))
+	if amount-fee < dustLimitSat {
+		return nil, fmt.Errorf("swap amount of %d sats does not cover the transaction fee of %d sats", amount, fee)
+	}
+	tx.TxOut[0].Value = amount - fee
+	err = sign()
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (script *swapScript) controlBlock(leaf txscript.TapLeaf) ([]byte, error) {
+	leafIndex, ok := script.tree.LeafProofIndex[leaf.TapHash()]
+	if !ok {
+		return nil, errors.New("leaf is not part of the swap tree")
+	}
+	controlBlock := script.tree.LeafMerkleProofs[leafIndex].ToControlBlock(script.internalKey)
+	return controlBlock.ToBytes()
+}
+
+func parseTransaction(txHex string) (*wire.MsgTx, error) {
+	txBytes, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, err
+	}
+	tx := &wire.MsgTx{}
+	err = tx.Deserialize(bytes.NewReader(txBytes))
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func serializeTransaction(tx *wire.MsgTx) (string, error) {
+	var buffer bytes.Buffer
+	err := tx.Serialize(&buffer)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buffer.Bytes()), nil
+}
+
+func networkParams(network string) *chaincfg.Params {
+	switch network {
+	case "testnet":
+		return &chaincfg.TestNet3Params
+	case "signet":
+		return &chaincfg.SigNetParams
+	case "regtest":
+		return &chaincfg.RegressionNetParams
+	default:
+		return &chaincfg.MainNetParams
+	}
+}
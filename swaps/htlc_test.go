@@ -0,0 +1,155 @@
+package swaps
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+const testTimeoutBlockHeight = 850_000
+
+// testSwapTree builds a swap tree like the ones returned by Boltz
+func testSwapTree(t *testing.T, claimKey *btcec.PublicKey, refundKey *btcec.PublicKey, preimage []byte) *boltzSwapTree {
+	claimScript, err := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_SIZE).
+		AddInt64(32).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(preimage)).
+		AddOp(txscript.OP_EQUALVERIFY).
+		AddData(schnorr.SerializePubKey(claimKey)).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	assert.NoError(t, err)
+
+	refundScript, err := txscript.NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(refundKey)).
+		AddOp(txscript.OP_CHECKSIGVERIFY).
+		AddInt64(testTimeoutBlockHeight).
+		AddOp(txscript.OP_CHECKLOCKTIMEVERIFY).
+		Script()
+	assert.NoError(t, err)
+
+	return &boltzSwapTree{
+		ClaimLeaf:  boltzLeaf{Version: boltzTaprootLeafVersion, Output: hex.EncodeToString(claimScript)},
+		RefundLeaf: boltzLeaf{Version: boltzTaprootLeafVersion, Output: hex.EncodeToString(refundScript)},
+	}
+}
+
+func testLockupTx(script *swapScript, amount int64) *wire.MsgTx {
+	lockupTx := wire.NewMsgTx(2)
+	lockupTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	lockupTx.AddTxOut(wire.NewTxOut(10_000, []byte{txscript.OP_TRUE}))
+	lockupTx.AddTxOut(wire.NewTxOut(amount, script.pkScript))
+	return lockupTx
+}
+
+func testOutputScript(t *testing.T) []byte {
+	key, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	address, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(key.PubKey()), &chaincfg.RegressionNetParams)
+	assert.NoError(t, err)
+	outputScript, err := txscript.PayToAddrScript(address)
+	assert.NoError(t, err)
+	return outputScript
+}
+
+func assertValidSpend(t *testing.T, script *swapScript, spendTx *wire.MsgTx, amount int64) {
+	prevOutputFetcher := txscript.NewCannedPrevOutputFetcher(script.pkScript, amount)
+	engine, err := txscript.NewEngine(script.pkScript, spendTx, 0, txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(spendTx, prevOutputFetcher), amount, prevOutputFetcher)
+	assert.NoError(t, err)
+	assert.NoError(t, engine.Execute())
+}
+
+func TestClaimTransaction(t *testing.T) {
+	boltzKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	claimKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	preimage := []byte("01234567890123456789012345678901")
+
+	script, err := newSwapScript(testSwapTree(t, claimKey.PubKey(), boltzKey.PubKey(), preimage), boltzKey.PubKey(), claimKey.PubKey())
+	assert.NoError(t, err)
+	assert.NoError(t, script.verifyClaimLeaf(claimKey.PubKey(), preimage))
+
+	lockupTx := testLockupTx(script, 100_000)
+	outputScript := testOutputScript(t)
+	claimTx, err := script.claimTransaction(lockupTx, outputScript, claimKey, preimage, 2)
+	assert.NoError(t, err)
+
+	assert.Equal(t, lockupTx.TxHash(), claimTx.TxIn[0].PreviousOutPoint.Hash)
+	assert.Equal(t, uint32(1), claimTx.TxIn[0].PreviousOutPoint.Index)
+	assert.Equal(t, outputScript, claimTx.TxOut[0].PkScript)
+	// a script path claim is around 150 vbytes
+	fee := 100_000 - claimTx.TxOut[0].Value
+	assert.Greater(t, fee, int64(250))
+	assert.Less(t, fee, int64(350))
+	assertValidSpend(t, script, claimTx, 100_000)
+}
+
+func TestRefundTransaction(t *testing.T) {
+	boltzKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	refundKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	preimage := []byte("01234567890123456789012345678901")
+
+	script, err := newSwapScript(testSwapTree(t, boltzKey.PubKey(), refundKey.PubKey(), preimage), boltzKey.PubKey(), refundKey.PubKey())
+	assert.NoError(t, err)
+	assert.NoError(t, script.verifyRefundLeaf(refundKey.PubKey()))
+
+	lockupTx := testLockupTx(script, 100_000)
+	refundTx, err := script.refundTransaction(lockupTx, testOutputScript(t), refundKey, testTimeoutBlockHeight, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(testTimeoutBlockHeight), refundTx.LockTime)
+	assertValidSpend(t, script, refundTx, 100_000)
+}
+
+func TestSpendTransaction_FeeExceedsAmount(t *testing.T) {
+	boltzKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	claimKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	preimage := []byte("01234567890123456789012345678901")
+
+	script, err := newSwapScript(testSwapTree(t, claimKey.PubKey(), boltzKey.PubKey(), preimage), boltzKey.PubKey(), claimKey.PubKey())
+	assert.NoError(t, err)
+
+	_, err = script.claimTransaction(testLockupTx(script, 1_000), testOutputScript(t), claimKey, preimage, 10)
+	assert.Error(t, err)
+}
+
+func TestVerifySwapScript(t *testing.T) {
+	boltzKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	claimKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	otherKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	preimage := []byte("01234567890123456789012345678901")
+
+	swapTree := testSwapTree(t, claimKey.PubKey(), boltzKey.PubKey(), preimage)
+	script, err := newSwapScript(swapTree, boltzKey.PubKey(), claimKey.PubKey())
+	assert.NoError(t, err)
+	address, err := script.address(&chaincfg.RegressionNetParams)
+	assert.NoError(t, err)
+	assert.Contains(t, address, "bcrt1p")
+
+	svc := &swapsService{params: &chaincfg.RegressionNetParams}
+	_, err = svc.verifySwapScript(swapTree, hex.EncodeToString(boltzKey.PubKey().SerializeCompressed()), claimKey, address)
+	assert.NoError(t, err)
+	// the address commits to other keys
+	_, err = svc.verifySwapScript(swapTree, hex.EncodeToString(otherKey.PubKey().SerializeCompressed()), claimKey, address)
+	assert.Error(t, err)
+
+	assert.Error(t, script.verifyClaimLeaf(otherKey.PubKey(), preimage))
+	assert.Error(t, script.verifyClaimLeaf(claimKey.PubKey(), []byte("other preimage")))
+}
@@ -0,0 +1,629 @@
+package swaps
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/getAlby/hub/config"
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/transactions"
+	decodepay "github.com/nbd-wtf/ln-decodepay"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	swapMonitorInterval = 30 * time.Second
+	// Boltz accepts claims and refunds with a fee rate of at least 1 sat/vB
+	minFeeRate = 1.0
+
+	autoSwapThresholdKey   = "AutoSwapBalanceThresholdSat"
+	autoSwapAmountKey      = "AutoSwapAmountSat"
+	autoSwapDestinationKey = "AutoSwapDestination"
+)
+
+type AutoSwapConfig struct {
+	// lightning balance above which a swap out is started, 0 disables the auto swap
+	BalanceThresholdSat uint64 `json:"balanceThreshold"`
+	AmountSat           uint64 `json:"amount"`
+	// empty to swap to the on-chain wallet of the node
+	DestinationAddress string `json:"destinationAddress"`
+}
+
+type SwapsService interface {
+	// StartSwapMonitor claims and refunds pending swaps and starts auto swaps until the context is cancelled
+	StartSwapMonitor(ctx context.Context, lnClient lnclient.LNClient)
+	SwapIn(ctx context.Context, amountSat uint64, lnClient lnclient.LNClient) (*db.Swap, error)
+	SwapOut(ctx context.Context, amountSat uint64, destinationAddress string, lnClient lnclient.LNClient) (*db.Swap, error)
+	ListSwaps() ([]db.Swap, error)
+	GetAutoSwapConfig() (*AutoSwapConfig, error)
+	SetAutoSwapConfig(autoSwapConfig *AutoSwapConfig) error
+}
+
+type swapsService struct {
+	db                  *gorm.DB
+	cfg                 config.Config
+	transactionsService transactions.TransactionsService
+	boltz               *boltzClient
+	params              *chaincfg.Params
+}
+
+type swapLimitsError struct {
+	minimal uint64
+	maximal uint64
+}
+
+func NewSwapLimitsError(minimal uint64, maximal uint64) error {
+	return &swapLimitsError{
+		minimal: minimal,
+		maximal: maximal,
+	}
+}
+
+func (err *swapLimitsError) Error() string {
+	return fmt.Sprintf("swap amount must be between %d and %d sats", err.minimal, err.maximal)
+}
+
+func NewSwapsService(db *gorm.DB, cfg config.Config, transactionsService transactions.TransactionsService) *swapsService {
+	return &swapsService{
+		db:                  db,
+		cfg:                 cfg,
+		transactionsService: transactionsService,
+		boltz:               newBoltzClient(cfg.GetEnv().BoltzApi),
+		params:              networkParams(cfg.GetEnv().LDKNetwork),
+	}
+}
+
+func (svc *swapsService) StartSwapMonitor(ctx context.Context, lnClient lnclient.LNClient) {
+	go func() {
+		ticker := time.NewTicker(swapMonitorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Logger.Info("Stopped swap monitor")
+				return
+			case <-ticker.C:
+				svc.processPendingSwaps(ctx, lnClient)
+				svc.checkAutoSwap(ctx, lnClient)
+			}
+		}
+	}()
+}
+
+// SwapIn moves funds from the on-chain wallet of the node to its lightning balance.
+// Boltz pays an invoice of the node once it has received the on-chain funds.
+func (svc *swapsService) SwapIn(ctx context.Context, amountSat uint64, lnClient lnclient.LNClient) (*db.Swap, error) {
+	pair, err := svc.boltz.getSubmarinePair(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if amountSat < pair.Limits.Minimal || amountSat > pair.Limits.Maximal {
+		return nil, NewSwapLimitsError(pair.Limits.Minimal, pair.Limits.Maximal)
+	}
+
+	invoice, err := svc.transactionsService.MakeInvoice(ctx, int64(amountSat*1000), "Boltz swap in", "", lnclient.DEFAULT_INVOICE_EXPIRY, nil, lnClient, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	refundKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := svc.boltz.createSubmarineSwap(ctx, &boltzCreateSubmarineSwapRequest{
+		From:            boltzCurrency,
+		To:              boltzCurrency,
+		Invoice:         invoice.PaymentRequest,
+		RefundPublicKey: hex.EncodeToString(refundKey.PubKey().SerializeCompressed()),
+		PairHash:        pair.Hash,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	script, err := svc.verifySwapScript(&response.SwapTree, response.ClaimPublicKey, refundKey, response.Address)
+	if err != nil {
+		return nil, err
+	}
+	err = script.verifyRefundLeaf(refundKey.PubKey())
+	if err != nil {
+		return nil, err
+	}
+
+	swap, err := svc.createSwap(&db.Swap{
+		SwapId:             response.Id,
+		Type:               constants.SWAP_TYPE_IN,
+		AmountSat:          amountSat,
+		OnchainAmountSat:   response.ExpectedAmount,
+		Invoice:            invoice.PaymentRequest,
+		PaymentHash:        invoice.PaymentHash,
+		PrivateKey:         hex.EncodeToString(refundKey.Serialize()),
+		BoltzPublicKey:     response.ClaimPublicKey,
+		LockupAddress:      response.Address,
+		TimeoutBlockHeight: response.TimeoutBlockHeight,
+	}, &response.SwapTree)
+	if err != nil {
+		return nil, err
+	}
+
+	txId, err := lnClient.RedeemOnchainFunds(ctx, response.Address, response.ExpectedAmount, nil, false)
+	if err != nil {
+		logger.Logger.WithError(err).WithField("swapId", swap.SwapId).Error("Failed to send on-chain funds to the swap address")
+		svc.updateSwap(swap, map[string]interface{}{"state": constants.SWAP_STATE_FAILED})
+		return nil, err
+	}
+	svc.updateSwap(swap, map[string]interface{}{"lockup_tx_id": txId})
+
+	logger.Logger.WithFields(logrus.Fields{
+		"swapId":     swap.SwapId,
+		"amount":     amountSat,
+		"lockupTxId": txId,
+	}).Info("Started swap in")
+
+	return swap, nil
+}
+
+// SwapOut moves funds from the lightning balance of the node to an on-chain address.
+// Boltz locks the on-chain funds once the invoice is paid and the payment only
+// settles after the funds are claimed, which reveals the preimage to Boltz.
+func (svc *swapsService) SwapOut(ctx context.Context, amountSat uint64, destinationAddress string, lnClient lnclient.LNClient) (*db.Swap, error) {
+	pair, err := svc.boltz.getReversePair(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if amountSat < pair.Limits.Minimal || amountSat > pair.Limits.Maximal {
+		return nil, NewSwapLimitsError(pair.Limits.Minimal, pair.Limits.Maximal)
+	}
+
+	if destinationAddress == "" {
+		destinationAddress, err = lnClient.GetNewOnchainAddress(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = btcutil.DecodeAddress(destinationAddress, svc.params)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination address: %w", err)
+	}
+
+	preimage := make([]byte, 32)
+	_, err = rand.Read(preimage)
+	if err != nil {
+		return nil, err
+	}
+	preimageHash := sha256.Sum256(preimage)
+
+	claimKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := svc.boltz.createReverseSwap(ctx, &boltzCreateReverseSwapRequest{
+		From:           boltzCurrency,
+		To:             boltzCurrency,
+		InvoiceAmount:  amountSat,
+		PreimageHash:   hex.EncodeToString(preimageHash[:]),
+		ClaimPublicKey: hex.EncodeToString(claimKey.PubKey().SerializeCompressed()),
+		PairHash:       pair.Hash,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	paymentRequest, err := decodepay.Decodepay(response.Invoice)
+	if err != nil {
+		return nil, err
+	}
+	if paymentRequest.PaymentHash != hex.EncodeToString(preimageHash[:]) || uint64(paymentRequest.MSatoshi) != amountSat*1000 {
+		return nil, errors.New("swap invoice does not match the requested swap")
+	}
+
+	script, err := svc.verifySwapScript(&response.SwapTree, response.RefundPublicKey, claimKey, response.LockupAddress)
+	if err != nil {
+		return nil, err
+	}
+	err = script.verifyClaimLeaf(claimKey.PubKey(), preimage)
+	if err != nil {
+		return nil, err
+	}
+
+	swap, err := svc.createSwap(&db.Swap{
+		SwapId:             response.Id,
+		Type:               constants.SWAP_TYPE_OUT,
+		AmountSat:          amountSat,
+		OnchainAmountSat:   response.OnchainAmount,
+		Invoice:            response.Invoice,
+		PaymentHash:        paymentRequest.PaymentHash,
+		Preimage:           hex.EncodeToString(preimage),
+		PrivateKey:         hex.EncodeToString(claimKey.Serialize()),
+		BoltzPublicKey:     response.RefundPublicKey,
+		LockupAddress:      response.LockupAddress,
+		DestinationAddress: destinationAddress,
+		TimeoutBlockHeight: response.TimeoutBlockHeight,
+	}, &response.SwapTree)
+	if err != nil {
+		return nil, err
+	}
+
+	// the payment is held by Boltz until the funds are claimed by the swap monitor
+	go func() {
+		_, err := svc.transactionsService.SendPaymentSync(context.WithoutCancel(ctx), response.Invoice, lnClient, nil, nil)
+		if err != nil {
+			logger.Logger.WithError(err).WithField("swapId", swap.SwapId).Error("Failed to pay swap out invoice")
+		}
+	}()
+
+	logger.Logger.WithFields(logrus.Fields{
+		"swapId": swap.SwapId,
+		"amount": amountSat,
+	}).Info("Started swap out")
+
+	return swap, nil
+}
+
+func (svc *swapsService) ListSwaps() ([]db.Swap, error) {
+	swaps := []db.Swap{}
+	err := svc.db.Order("created_at desc").Find(&swaps).Error
+	if err != nil {
+		return nil, err
+	}
+	return swaps, nil
+}
+
+func (svc *swapsService) GetAutoSwapConfig() (*AutoSwapConfig, error) {
+	autoSwapConfig := &AutoSwapConfig{}
+	for key, value := range map[string]*uint64{
+		autoSwapThresholdKey: &autoSwapConfig.BalanceThresholdSat,
+		autoSwapAmountKey:    &autoSwapConfig.AmountSat,
+	} {
+		valueString, err := svc.cfg.Get(key, "")
+		if err != nil {
+			return nil, err
+		}
+		if valueString == "" {
+			continue
+		}
+		*value, err = strconv.ParseUint(valueString, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	destinationAddress, err := svc.cfg.Get(autoSwapDestinationKey, "")
+	if err != nil {
+		return nil, err
+	}
+	autoSwapConfig.DestinationAddress = destinationAddress
+	return autoSwapConfig, nil
+}
+
+func (svc *swapsService) SetAutoSwapConfig(autoSwapConfig *AutoSwapConfig) error {
+	if autoSwapConfig.BalanceThresholdSat > 0 && autoSwapConfig.AmountSat == 0 {
+		return errors.New("no swap amount provided")
+	}
+	if autoSwapConfig.DestinationAddress != "" {
+		_, err := btcutil.DecodeAddress(autoSwapConfig.DestinationAddress, svc.params)
+		if err != nil {
+			return fmt.Errorf("invalid destination address: %w", err)
+		}
+	}
+	svc.cfg.SetUpdate(autoSwapThresholdKey, strconv.FormatUint(autoSwapConfig.BalanceThresholdSat, 10), "")
+	svc.cfg.SetUpdate(autoSwapAmountKey, strconv.FormatUint(autoSwapConfig.AmountSat, 10), "")
+	svc.cfg.SetUpdate(autoSwapDestinationKey, autoSwapConfig.DestinationAddress, "")
+	return nil
+}
+
+// verifySwapScript checks that the address returned by Boltz commits to the swap tree and our key
+func (svc *swapsService) verifySwapScript(swapTree *boltzSwapTree, boltzPublicKeyHex string, ourKey *btcec.PrivateKey, address string) (*swapScript, error) {
+	boltzPublicKeyBytes, err := hex.DecodeString(boltzPublicKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	boltzPublicKey, err := btcec.ParsePubKey(boltzPublicKeyBytes)
+	if err != nil {
+		return nil, err
+	}
+	script, err := newSwapScript(swapTree, boltzPublicKey, ourKey.PubKey())
+	if err != nil {
+		return nil, err
+	}
+	expectedAddress, err := script.address(svc.params)
+	if err != nil {
+		return nil, err
+	}
+	if expectedAddress != address {
+		return nil, errors.New("swap address does not match the swap tree")
+	}
+	return script, nil
+}
+
+func (svc *swapsService) createSwap(swap *db.Swap, swapTree *boltzSwapTree) (*db.Swap, error) {
+	swapTreeJson, err := json.Marshal(swapTree)
+	if err != nil {
+		return nil, err
+	}
+	swap.SwapTree = string(swapTreeJson)
+	swap.State = constants.SWAP_STATE_PENDING
+	err = svc.db.Create(swap).Error
+	if err != nil {
+		return nil, err
+	}
+	return swap, nil
+}
+
+func (svc *swapsService) updateSwap(swap *db.Swap, updates map[string]interface{}) {
+	err := svc.db.Model(swap).Updates(updates).Error
+	if err != nil {
+		logger.Logger.WithError(err).WithField("swapId", swap.SwapId).Error("Failed to update swap")
+	}
+}
+
+func (svc *swapsService) processPendingSwaps(ctx context.Context, lnClient lnclient.LNClient) {
+	swaps := []db.Swap{}
+	err := svc.db.Where("state = ?", constants.SWAP_STATE_PENDING).Find(&swaps).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list pending swaps")
+		return
+	}
+
+	for _, swap := range swaps {
+		err := svc.processSwap(ctx, &swap, lnClient)
+		if err != nil {
+			logger.Logger.WithError(err).WithFields(logrus.Fields{
+				"swapId": swap.SwapId,
+				"type":   swap.Type,
+			}).Error("Failed to process swap")
+		}
+	}
+}
+
+func (svc *swapsService) processSwap(ctx context.Context, swap *db.Swap, lnClient lnclient.LNClient) error {
+	status, err := svc.boltz.getSwapStatus(ctx, swap.SwapId)
+	if err != nil {
+		return err
+	}
+	if status.Status != swap.BoltzStatus {
+		svc.updateSwap(swap, map[string]interface{}{"boltz_status": status.Status})
+	}
+
+	if swap.Type == constants.SWAP_TYPE_OUT {
+		switch status.Status {
+		case boltzStatusTransactionMempool, boltzStatusTransactionConfirmed:
+			if swap.ClaimTxId != "" || status.Transaction == nil {
+				return nil
+			}
+			return svc.claimSwapOut(ctx, swap, status.Transaction)
+		case boltzStatusInvoiceSettled:
+			svc.updateSwap(swap, map[string]interface{}{"state": constants.SWAP_STATE_SUCCESS})
+			logger.Logger.WithField("swapId", swap.SwapId).Info("Swap out succeeded")
+		case boltzStatusSwapExpired, boltzStatusTransactionFailed, boltzStatusTransactionRefunded, boltzStatusInvoiceExpired:
+			svc.updateSwap(swap, map[string]interface{}{"state": constants.SWAP_STATE_FAILED})
+			logger.Logger.WithFields(logrus.Fields{
+				"swapId": swap.SwapId,
+				"status": status.Status,
+			}).Warn("Swap out failed")
+		}
+		return nil
+	}
+
+	switch status.Status {
+	case boltzStatusInvoicePaid, boltzStatusTransactionClaimPending, boltzStatusTransactionClaimed:
+		svc.updateSwap(swap, map[string]interface{}{"state": constants.SWAP_STATE_SUCCESS})
+		logger.Logger.WithField("swapId", swap.SwapId).Info("Swap in succeeded")
+	case boltzStatusInvoiceFailedToPay, boltzStatusTransactionLockupFailed, boltzStatusSwapExpired:
+		if swap.LockupTxId == "" {
+			svc.updateSwap(swap, map[string]interface{}{"state": constants.SWAP_STATE_FAILED})
+			return nil
+		}
+		return svc.refundSwapIn(ctx, swap, lnClient)
+	}
+	return nil
+}
+
+func (svc *swapsService) claimSwapOut(ctx context.Context, swap *db.Swap, lockupTransaction *boltzTransaction) error {
+	if lockupTransaction.Hex == "" {
+		var err error
+		lockupTransaction, err = svc.boltz.getTransaction(ctx, lockupTransaction.Id)
+		if err != nil {
+			return err
+		}
+	}
+	lockupTx, err := parseTransaction(lockupTransaction.Hex)
+	if err != nil {
+		return err
+	}
+	script, privateKey, err := svc.loadSwapScript(swap)
+	if err != nil {
+		return err
+	}
+	preimage, err := hex.DecodeString(swap.Preimage)
+	if err != nil {
+		return err
+	}
+	outputScript, err := svc.addressScript(swap.DestinationAddress)
+	if err != nil {
+		return err
+	}
+	feeRate, err := svc.getFeeRate(ctx)
+	if err != nil {
+		return err
+	}
+
+	claimTx, err := script.claimTransaction(lockupTx, outputScript, privateKey, preimage, feeRate)
+	if err != nil {
+		return err
+	}
+	claimTxId, err := svc.broadcast(ctx, claimTx)
+	if err != nil {
+		return err
+	}
+	svc.updateSwap(swap, map[string]interface{}{
+		"lockup_tx_id": lockupTx.TxHash().String(),
+		"claim_tx_id":  claimTxId,
+	})
+
+	logger.Logger.WithFields(logrus.Fields{
+		"swapId":    swap.SwapId,
+		"claimTxId": claimTxId,
+	}).Info("Claimed swap out funds")
+	return nil
+}
+
+// refundSwapIn sends the funds back to the on-chain wallet of the node once the swap timed out
+func (svc *swapsService) refundSwapIn(ctx context.Context, swap *db.Swap, lnClient lnclient.LNClient) error {
+	nodeInfo, err := lnClient.GetInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if nodeInfo.BlockHeight < swap.TimeoutBlockHeight {
+		// the refund path can only be used after the timeout
+		return nil
+	}
+
+	lockupTransaction, err := svc.boltz.getTransaction(ctx, swap.LockupTxId)
+	if err != nil {
+		return err
+	}
+	lockupTx, err := parseTransaction(lockupTransaction.Hex)
+	if err != nil {
+		return err
+	}
+	script, privateKey, err := svc.loadSwapScript(swap)
+	if err != nil {
+		return err
+	}
+	refundAddress, err := lnClient.GetNewOnchainAddress(ctx)
+	if err != nil {
+		return err
+	}
+	outputScript, err := svc.addressScript(refundAddress)
+	if err != nil {
+		return err
+	}
+	feeRate, err := svc.getFeeRate(ctx)
+	if err != nil {
+		return err
+	}
+
+	refundTx, err := script.refundTransaction(lockupTx, outputScript, privateKey, swap.TimeoutBlockHeight, feeRate)
+	if err != nil {
+		return err
+	}
+	refundTxId, err := svc.broadcast(ctx, refundTx)
+	if err != nil {
+		return err
+	}
+	svc.updateSwap(swap, map[string]interface{}{
+		"state":               constants.SWAP_STATE_REFUNDED,
+		"destination_address": refundAddress,
+		"refund_tx_id":        refundTxId,
+	})
+
+	logger.Logger.WithFields(logrus.Fields{
+		"swapId":     swap.SwapId,
+		"refundTxId": refundTxId,
+	}).Info("Refunded swap in")
+	return nil
+}
+
+func (svc *swapsService) checkAutoSwap(ctx context.Context, lnClient lnclient.LNClient) {
+	autoSwapConfig, err := svc.GetAutoSwapConfig()
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to load auto swap config")
+		return
+	}
+	if autoSwapConfig.BalanceThresholdSat == 0 {
+		return
+	}
+
+	var pendingSwapsCount int64
+	err = svc.db.Model(&db.Swap{}).Where("type = ? AND state = ?", constants.SWAP_TYPE_OUT, constants.SWAP_STATE_PENDING).Count(&pendingSwapsCount).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to count pending swaps")
+		return
+	}
+	if pendingSwapsCount > 0 {
+		return
+	}
+
+	balances, err := lnClient.GetBalances(ctx)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to get balances for auto swap")
+		return
+	}
+	if uint64(balances.Lightning.TotalSpendable/1000) <= autoSwapConfig.BalanceThresholdSat {
+		return
+	}
+
+	logger.Logger.WithFields(logrus.Fields{
+		"balance":   balances.Lightning.TotalSpendable / 1000,
+		"threshold": autoSwapConfig.BalanceThresholdSat,
+		"amount":    autoSwapConfig.AmountSat,
+	}).Info("Lightning balance exceeds the auto swap threshold")
+
+	swap, err := svc.SwapOut(ctx, autoSwapConfig.AmountSat, autoSwapConfig.DestinationAddress, lnClient)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to start auto swap out")
+		return
+	}
+	svc.updateSwap(swap, map[string]interface{}{"auto_swap": true})
+}
+
+func (svc *swapsService) loadSwapScript(swap *db.Swap) (*swapScript, *btcec.PrivateKey, error) {
+	swapTree := &boltzSwapTree{}
+	err := json.Unmarshal([]byte(swap.SwapTree), swapTree)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKeyBytes, err := hex.DecodeString(swap.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey, _ := btcec.PrivKeyFromBytes(privateKeyBytes)
+	script, err := svc.verifySwapScript(swapTree, swap.BoltzPublicKey, privateKey, swap.LockupAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+	return script, privateKey, nil
+}
+
+func (svc *swapsService) addressScript(address string) ([]byte, error) {
+	decodedAddress, err := btcutil.DecodeAddress(address, svc.params)
+	if err != nil {
+		return nil, err
+	}
+	return txscript.PayToAddrScript(decodedAddress)
+}
+
+func (svc *swapsService) getFeeRate(ctx context.Context) (float64, error) {
+	feeRate, err := svc.boltz.getFeeRate(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return math.Max(feeRate, minFeeRate), nil
+}
+
+func (svc *swapsService) broadcast(ctx context.Context, tx *wire.MsgTx) (string, error) {
+	txHex, err := serializeTransaction(tx)
+	if err != nil {
+		return "", err
+	}
+	return svc.boltz.broadcastTransaction(ctx, txHex)
+}
@@ -0,0 +1,159 @@
+package swaps
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/tests"
+	"github.com/getAlby/hub/transactions"
+	"github.com/stretchr/testify/assert"
+)
+
+const testPairs = `{"BTC":{"BTC":{"hash":"pair-hash","limits":{"minimal":25000,"maximal":25000000}}}}`
+
+// newFakeBoltz answers swap requests like Boltz, with swap trees for the keys in the requests
+func newFakeBoltz(t *testing.T, boltzKey *btcec.PrivateKey, swapStatus string) *httptest.Server {
+	preimage := []byte("01234567890123456789012345678901")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /swap/submarine", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testPairs))
+	})
+	mux.HandleFunc("GET /swap/reverse", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testPairs))
+	})
+	mux.HandleFunc("POST /swap/submarine", func(w http.ResponseWriter, r *http.Request) {
+		request := boltzCreateSubmarineSwapRequest{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, tests.MockInvoice, request.Invoice)
+		assert.Equal(t, "pair-hash", request.PairHash)
+
+		refundKeyBytes, err := hex.DecodeString(request.RefundPublicKey)
+		assert.NoError(t, err)
+		refundKey, err := btcec.ParsePubKey(refundKeyBytes)
+		assert.NoError(t, err)
+		swapTree := testSwapTree(t, boltzKey.PubKey(), refundKey, preimage)
+		script, err := newSwapScript(swapTree, boltzKey.PubKey(), refundKey)
+		assert.NoError(t, err)
+		address, err := script.address(&chaincfg.MainNetParams)
+		assert.NoError(t, err)
+
+		json.NewEncoder(w).Encode(&boltzCreateSubmarineSwapResponse{
+			Id:                 "swap-in",
+			Address:            address,
+			SwapTree:           *swapTree,
+			ClaimPublicKey:     hex.EncodeToString(boltzKey.PubKey().SerializeCompressed()),
+			TimeoutBlockHeight: testTimeoutBlockHeight,
+			ExpectedAmount:     100_500,
+		})
+	})
+	mux.HandleFunc("POST /swap/reverse", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&boltzCreateReverseSwapResponse{
+			Id:      "swap-out",
+			Invoice: tests.MockInvoice,
+		})
+	})
+	mux.HandleFunc("GET /swap/{id}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&boltzSwapStatus{Status: swapStatus})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSwapIn(t *testing.T) {
+	ctx := context.TODO()
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	boltzKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	server := newFakeBoltz(t, boltzKey, boltzStatusInvoicePaid)
+
+	swapsService := NewSwapsService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	swapsService.boltz = newBoltzClient(server.URL)
+
+	swap, err := swapsService.SwapIn(ctx, 100_000, svc.LNClient)
+	assert.NoError(t, err)
+	assert.Equal(t, "swap-in", swap.SwapId)
+	assert.Equal(t, constants.SWAP_TYPE_IN, swap.Type)
+	assert.Equal(t, constants.SWAP_STATE_PENDING, swap.State)
+	assert.Equal(t, uint64(100_500), swap.OnchainAmountSat)
+	assert.Equal(t, tests.MockPaymentHash, swap.PaymentHash)
+
+	swapsService.processPendingSwaps(ctx, svc.LNClient)
+
+	swaps, err := swapsService.ListSwaps()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(swaps))
+	assert.Equal(t, constants.SWAP_STATE_SUCCESS, swaps[0].State)
+	assert.Equal(t, boltzStatusInvoicePaid, swaps[0].BoltzStatus)
+}
+
+func TestSwapIn_AmountOutsideLimits(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	boltzKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	server := newFakeBoltz(t, boltzKey, "")
+
+	swapsService := NewSwapsService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	swapsService.boltz = newBoltzClient(server.URL)
+
+	_, err = swapsService.SwapIn(context.TODO(), 1_000, svc.LNClient)
+	assert.Equal(t, NewSwapLimitsError(25_000, 25_000_000), err)
+}
+
+func TestSwapOut_InvoiceMismatch(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	boltzKey, err := btcec.NewPrivateKey()
+	assert.NoError(t, err)
+	server := newFakeBoltz(t, boltzKey, "")
+
+	swapsService := NewSwapsService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	swapsService.boltz = newBoltzClient(server.URL)
+
+	// the invoice of Boltz is not for the preimage hash of the swap
+	_, err = swapsService.SwapOut(context.TODO(), 100_000, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", svc.LNClient)
+	assert.EqualError(t, err, "swap invoice does not match the requested swap")
+
+	swaps, err := swapsService.ListSwaps()
+	assert.NoError(t, err)
+	assert.Empty(t, swaps)
+}
+
+func TestAutoSwapConfig(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	swapsService := NewSwapsService(svc.DB, svc.Cfg, transactions.NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+
+	autoSwapConfig, err := swapsService.GetAutoSwapConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, &AutoSwapConfig{}, autoSwapConfig)
+
+	err = swapsService.SetAutoSwapConfig(&AutoSwapConfig{BalanceThresholdSat: 1_000_000})
+	assert.EqualError(t, err, "no swap amount provided")
+	err = swapsService.SetAutoSwapConfig(&AutoSwapConfig{BalanceThresholdSat: 1_000_000, AmountSat: 500_000, DestinationAddress: "invalid"})
+	assert.Error(t, err)
+
+	expected := &AutoSwapConfig{BalanceThresholdSat: 1_000_000, AmountSat: 500_000, DestinationAddress: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"}
+	err = swapsService.SetAutoSwapConfig(expected)
+	assert.NoError(t, err)
+	autoSwapConfig, err = swapsService.GetAutoSwapConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, autoSwapConfig)
+}
@@ -0,0 +1,306 @@
+package tags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/transactions"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxTagLength          = 32
+	maxTagsPerTransaction = 10
+)
+
+type TagsService interface {
+	events.EventSubscriber
+	// ListTags returns the tags of all transactions, in alphabetical order
+	ListTags() ([]string, error)
+	// GetTags returns the tags of each of the transactions
+	GetTags(transactionIds []uint) (map[uint][]string, error)
+	// SetTags replaces the tags of a settled transaction
+	SetTags(transactionId uint, tags []string) error
+	// ListTaggedTransactions returns the settled transactions with the tag, newest first
+	ListTaggedTransactions(tag string, limit uint64, offset uint64) ([]db.Transaction, error)
+	ListTagRules() ([]db.TagRule, error)
+	// CreateTagRule saves the rule and tags the existing transactions that match it
+	CreateTagRule(rule *db.TagRule) error
+	// DeleteTagRule deletes the rule and the tags it added
+	DeleteTagRule(id uint) error
+}
+
+type tagsService struct {
+	db *gorm.DB
+}
+
+func NewTagsService(db *gorm.DB) *tagsService {
+	return &tagsService{
+		db: db,
+	}
+}
+
+// ConsumeEvent applies the tag rules to settled payments. It must be registered
+// after the transactions service, which settles the transactions of the events.
+func (svc *tagsService) ConsumeEvent(ctx context.Context, event *events.Event, globalProperties map[string]interface{}) {
+	var transactionType string
+	switch event.Event {
+	case "nwc_payment_received":
+		transactionType = constants.TRANSACTION_TYPE_INCOMING
+	case "nwc_payment_sent":
+		transactionType = constants.TRANSACTION_TYPE_OUTGOING
+	default:
+		return
+	}
+	lnClientTransaction, ok := event.Properties.(*lnclient.Transaction)
+	if !ok {
+		logger.Logger.WithField("event", event).Error("Failed to cast event")
+		return
+	}
+
+	var transaction db.Transaction
+	result := svc.db.
+		Where("type = ? AND payment_hash = ? AND state = ?", transactionType, lnClientTransaction.PaymentHash, constants.TRANSACTION_STATE_SETTLED).
+		Order("created_at desc").
+		Limit(1).
+		Find(&transaction)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return
+	}
+
+	rules := []db.TagRule{}
+	err := svc.db.Find(&rules).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to load tag rules")
+		return
+	}
+	for _, rule := range rules {
+		err := svc.applyTagRule(&rule, &transaction.ID)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"rule_id":        rule.ID,
+				"transaction_id": transaction.ID,
+			}).WithError(err).Error("Failed to apply tag rule")
+		}
+	}
+}
+
+func (svc *tagsService) ListTags() ([]string, error) {
+	tags := []string{}
+	err := svc.db.Model(&db.TransactionTag{}).Distinct("tag").Order("tag").Pluck("tag", &tags).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list tags")
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (svc *tagsService) GetTags(transactionIds []uint) (map[uint][]string, error) {
+	transactionTags := map[uint][]string{}
+	if len(transactionIds) == 0 {
+		return transactionTags, nil
+	}
+	tags := []db.TransactionTag{}
+	err := svc.db.Where("transaction_id IN ?", transactionIds).Order("tag").Find(&tags).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to load transaction tags")
+		return nil, err
+	}
+	for _, tag := range tags {
+		transactionTags[tag.TransactionId] = append(transactionTags[tag.TransactionId], tag.Tag)
+	}
+	return transactionTags, nil
+}
+
+func (svc *tagsService) SetTags(transactionId uint, tags []string) error {
+	normalizedTags := []string{}
+	for _, tag := range tags {
+		normalizedTag, err := normalizeTag(tag)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(normalizedTags, normalizedTag) {
+			normalizedTags = append(normalizedTags, normalizedTag)
+		}
+	}
+	if len(normalizedTags) > maxTagsPerTransaction {
+		return fmt.Errorf("a transaction can have at most %d tags", maxTagsPerTransaction)
+	}
+
+	return svc.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Limit(1).Find(&db.Transaction{}, "id = ? AND state = ?", transactionId, constants.TRANSACTION_STATE_SETTLED)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return transactions.NewNotFoundError()
+		}
+
+		// existing tags are kept, so that tags added by a rule are still removed with the rule
+		deleteQuery := tx.Where("transaction_id = ?", transactionId)
+		if len(normalizedTags) > 0 {
+			deleteQuery = deleteQuery.Where("tag NOT IN ?", normalizedTags)
+		}
+		err := deleteQuery.Delete(&db.TransactionTag{}).Error
+		if err != nil {
+			return err
+		}
+		for _, tag := range normalizedTags {
+			err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&db.TransactionTag{
+				TransactionId: transactionId,
+				Tag:           tag,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (svc *tagsService) ListTaggedTransactions(tag string, limit uint64, offset uint64) ([]db.Transaction, error) {
+	tx := svc.db.
+		Where("state = ? AND id IN (?)", constants.TRANSACTION_STATE_SETTLED, svc.db.Model(&db.TransactionTag{}).Select("transaction_id").Where("tag = ?", tag)).
+		Order("created_at desc")
+	if limit > 0 {
+		tx = tx.Limit(int(limit))
+	}
+	if offset > 0 {
+		tx = tx.Offset(int(offset))
+	}
+
+	taggedTransactions := []db.Transaction{}
+	err := tx.Find(&taggedTransactions).Error
+	if err != nil {
+		logger.Logger.WithError(err).WithField("tag", tag).Error("Failed to list tagged transactions")
+		return nil, err
+	}
+	return taggedTransactions, nil
+}
+
+func (svc *tagsService) ListTagRules() ([]db.TagRule, error) {
+	rules := []db.TagRule{}
+	err := svc.db.Preload("App").Order("tag").Order("id").Find(&rules).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list tag rules")
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (svc *tagsService) CreateTagRule(rule *db.TagRule) error {
+	tag, err := normalizeTag(rule.Tag)
+	if err != nil {
+		return err
+	}
+	rule.Tag = tag
+	rule.DescriptionContains = strings.TrimSpace(rule.DescriptionContains)
+	if rule.Type != "" && rule.Type != constants.TRANSACTION_TYPE_INCOMING && rule.Type != constants.TRANSACTION_TYPE_OUTGOING {
+		return fmt.Errorf("invalid transaction type: %s", rule.Type)
+	}
+	if rule.Keysend && rule.Type == constants.TRANSACTION_TYPE_INCOMING {
+		return errors.New("keysend rules only match outgoing payments")
+	}
+	if rule.AppId == nil && rule.Type == "" && !rule.Keysend && rule.DescriptionContains == "" {
+		return errors.New("a tag rule needs at least one condition")
+	}
+	if rule.AppId != nil {
+		result := svc.db.Limit(1).Find(&db.App{}, *rule.AppId)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("app %d not found", *rule.AppId)
+		}
+	}
+
+	err = svc.db.Create(rule).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to create tag rule")
+		return err
+	}
+	return svc.applyTagRule(rule, nil)
+}
+
+func (svc *tagsService) DeleteTagRule(id uint) error {
+	result := svc.db.Delete(&db.TagRule{}, id)
+	if result.Error != nil {
+		logger.Logger.WithError(result.Error).WithField("id", id).Error("Failed to delete tag rule")
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("tag rule %d not found", id)
+	}
+	return nil
+}
+
+// applyTagRule tags the settled transactions that match the rule,
+// or only the transaction with the id if it is given
+func (svc *tagsService) applyTagRule(rule *db.TagRule, transactionId *uint) error {
+	tx := svc.db.Model(&db.Transaction{}).Where("state = ?", constants.TRANSACTION_STATE_SETTLED)
+	if transactionId != nil {
+		tx = tx.Where("id = ?", *transactionId)
+	}
+	if rule.AppId != nil {
+		tx = tx.Where("app_id = ?", *rule.AppId)
+	}
+	if rule.Type != "" {
+		tx = tx.Where("type = ?", rule.Type)
+	}
+	if rule.Keysend {
+		// keysend payments are the only outgoing payments without an invoice
+		tx = tx.Where("type = ? AND payment_request = ''", constants.TRANSACTION_TYPE_OUTGOING)
+	}
+	if rule.DescriptionContains != "" {
+		if db.IsPostgres(svc.db) {
+			tx = tx.Where("strpos(lower(description), ?) > 0", strings.ToLower(rule.DescriptionContains))
+		} else {
+			tx = tx.Where("instr(lower(description), ?) > 0", strings.ToLower(rule.DescriptionContains))
+		}
+	}
+
+	ids := []uint{}
+	err := tx.Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	tags := []db.TransactionTag{}
+	for _, id := range ids {
+		tags = append(tags, db.TransactionTag{
+			TransactionId: id,
+			Tag:           rule.Tag,
+			TagRuleId:     &rule.ID,
+		})
+	}
+	// transactions that already have the tag keep it as it is
+	return svc.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(tags, 100).Error
+}
+
+// normalizeTag lowercases the tag and checks that it is a single short word
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", errors.New("tag cannot be empty")
+	}
+	if len([]rune(tag)) > maxTagLength {
+		return "", fmt.Errorf("tag cannot be longer than %d characters", maxTagLength)
+	}
+	if strings.ContainsAny(tag, " \t\n,") {
+		return "", fmt.Errorf("tag cannot contain spaces or commas: %s", tag)
+	}
+	return tag, nil
+}
@@ -0,0 +1,124 @@
+package tags
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/tests"
+	"github.com/getAlby/hub/transactions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateTagRule_TagsExistingTransactions(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	keysend := db.Transaction{AppId: &app.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "keysend"}
+	invoice := db.Transaction{AppId: &app.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "invoice", PaymentRequest: tests.MockInvoice}
+	failed := db.Transaction{AppId: &app.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_FAILED, PaymentHash: "failed"}
+	otherApp := db.Transaction{Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "other"}
+	for _, transaction := range []*db.Transaction{&keysend, &invoice, &failed, &otherApp} {
+		assert.NoError(t, svc.DB.Create(transaction).Error)
+	}
+
+	tagsService := NewTagsService(svc.DB)
+	rule := &db.TagRule{Tag: " Podcasting ", AppId: &app.ID, Keysend: true}
+	err = tagsService.CreateTagRule(rule)
+	assert.NoError(t, err)
+	assert.Equal(t, "podcasting", rule.Tag)
+
+	transactionTags, err := tagsService.GetTags([]uint{keysend.ID, invoice.ID, failed.ID, otherApp.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, map[uint][]string{keysend.ID: {"podcasting"}}, transactionTags)
+
+	taggedTransactions, err := tagsService.ListTaggedTransactions("podcasting", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, taggedTransactions, 1)
+	assert.Equal(t, keysend.ID, taggedTransactions[0].ID)
+
+	// tags added by the rule are removed with it
+	err = tagsService.DeleteTagRule(rule.ID)
+	assert.NoError(t, err)
+	tags, err := tagsService.ListTags()
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func TestCreateTagRule_Invalid(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	tagsService := NewTagsService(svc.DB)
+	assert.Error(t, tagsService.CreateTagRule(&db.TagRule{Tag: "podcasting"}))
+	assert.Error(t, tagsService.CreateTagRule(&db.TagRule{Tag: "", Type: constants.TRANSACTION_TYPE_OUTGOING}))
+	assert.Error(t, tagsService.CreateTagRule(&db.TagRule{Tag: "podcasting", Type: constants.TRANSACTION_TYPE_INCOMING, Keysend: true}))
+	assert.Error(t, tagsService.CreateTagRule(&db.TagRule{Tag: "podcasting", Type: "unknown"}))
+	missingAppId := uint(1000)
+	assert.Error(t, tagsService.CreateTagRule(&db.TagRule{Tag: "podcasting", AppId: &missingAppId}))
+}
+
+func TestSetTags(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	transaction := db.Transaction{Type: constants.TRANSACTION_TYPE_INCOMING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "incoming", Description: "Zap from bob"}
+	assert.NoError(t, svc.DB.Create(&transaction).Error)
+
+	tagsService := NewTagsService(svc.DB)
+	rule := &db.TagRule{Tag: "zaps", DescriptionContains: "zap"}
+	assert.NoError(t, tagsService.CreateTagRule(rule))
+
+	err = tagsService.SetTags(transaction.ID, []string{"zaps", "Income", "income"})
+	assert.NoError(t, err)
+	transactionTags, err := tagsService.GetTags([]uint{transaction.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"income", "zaps"}, transactionTags[transaction.ID])
+
+	// the tag added by the rule is kept, the manual tag stays after the rule is deleted
+	assert.NoError(t, tagsService.DeleteTagRule(rule.ID))
+	transactionTags, err = tagsService.GetTags([]uint{transaction.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"income"}, transactionTags[transaction.ID])
+
+	err = tagsService.SetTags(transaction.ID, []string{})
+	assert.NoError(t, err)
+	tags, err := tagsService.ListTags()
+	assert.NoError(t, err)
+	assert.Empty(t, tags)
+
+	assert.Error(t, tagsService.SetTags(transaction.ID, []string{"two words"}))
+	assert.ErrorIs(t, tagsService.SetTags(transaction.ID+1, []string{"income"}), transactions.NewNotFoundError())
+}
+
+func TestConsumeEvent_AppliesTagRules(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	tagsService := NewTagsService(svc.DB)
+	assert.NoError(t, tagsService.CreateTagRule(&db.TagRule{Tag: "income", Type: constants.TRANSACTION_TYPE_INCOMING}))
+
+	transaction := db.Transaction{Type: constants.TRANSACTION_TYPE_INCOMING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: tests.MockPaymentHash}
+	assert.NoError(t, svc.DB.Create(&transaction).Error)
+
+	tagsService.ConsumeEvent(context.TODO(), &events.Event{
+		Event: "nwc_payment_received",
+		Properties: &lnclient.Transaction{
+			PaymentHash: tests.MockPaymentHash,
+		},
+	}, map[string]interface{}{})
+
+	transactionTags, err := tagsService.GetTags([]uint{transaction.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"income"}, transactionTags[transaction.ID])
+}
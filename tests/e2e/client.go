@@ -0,0 +1,160 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
+	"github.com/nbd-wtf/go-nostr/nip04"
+)
+
+// Client is a connected app that sends NIP-47 requests through the relay
+type Client struct {
+	App          *db.App
+	privateKey   string
+	walletPubkey string
+	ss           []byte
+	relayURL     string
+}
+
+// Response is a decrypted NIP-47 response, the result is decoded by the test
+type Response struct {
+	ResultType string          `json:"result_type"`
+	Error      *models.Error   `json:"error,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	// the d tag of the responses of multi_pay requests
+	DTag string `json:"-"`
+}
+
+// NewClient creates an app with the scopes
+func (harness *Harness) NewClient(scopes ...string) (*Client, error) {
+	privateKey := nostr.GeneratePrivateKey()
+	app, ss, err := tests.CreateAppWithPrivateKey(harness.Svc, privateKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		err = harness.Svc.DB.Create(&db.AppPermission{AppId: app.ID, App: *app, Scope: scope}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &Client{
+		App:          app,
+		privateKey:   privateKey,
+		walletPubkey: harness.Svc.Keys.GetNostrPublicKey(),
+		ss:           ss,
+		relayURL:     harness.Relay.URL(),
+	}, nil
+}
+
+// Request sends the request and waits for its response
+func (client *Client) Request(ctx context.Context, method string, params interface{}) (*Response, error) {
+	requestEvent, err := client.SendRequest(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := client.WaitForResponses(ctx, requestEvent, 1)
+	if err != nil {
+		return nil, err
+	}
+	return responses[0], nil
+}
+
+// SendRequest publishes the request without waiting for the response
+func (client *Client) SendRequest(ctx context.Context, method string, params interface{}) (*nostr.Event, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"method": method,
+		"params": params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	content, err := nip04.Encrypt(string(payload), client.ss)
+	if err != nil {
+		return nil, err
+	}
+	pubkey, err := nostr.GetPublicKey(client.privateKey)
+	if err != nil {
+		return nil, err
+	}
+	requestEvent := &nostr.Event{
+		Kind:      models.REQUEST_KIND,
+		PubKey:    pubkey,
+		CreatedAt: nostr.Now(),
+		Tags:      nostr.Tags{[]string{"p", client.walletPubkey}},
+		Content:   content,
+	}
+	err = requestEvent.Sign(client.privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	relay, err := nostr.RelayConnect(ctx, client.relayURL)
+	if err != nil {
+		return nil, err
+	}
+	defer relay.Close()
+	err = relay.Publish(ctx, *requestEvent)
+	if err != nil {
+		return nil, err
+	}
+	return requestEvent, nil
+}
+
+// WaitForResponses waits until the relay has count responses to the request.
+// It connects to the relay again, so responses published while the client was disconnected are received too.
+func (client *Client) WaitForResponses(ctx context.Context, requestEvent *nostr.Event, count int) ([]*Response, error) {
+	relay, err := nostr.RelayConnect(ctx, client.relayURL)
+	if err != nil {
+		return nil, err
+	}
+	defer relay.Close()
+	sub, err := relay.Subscribe(ctx, nostr.Filters{{
+		Kinds:   []int{models.RESPONSE_KIND},
+		Authors: []string{client.walletPubkey},
+		Tags:    nostr.TagMap{"e": []string{requestEvent.ID}},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsub()
+
+	responses := []*Response{}
+	for len(responses) < count {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case event, ok := <-sub.Events:
+			if !ok {
+				return nil, errors.New("relay connection closed")
+			}
+			response, err := client.decryptResponse(event)
+			if err != nil {
+				return nil, err
+			}
+			responses = append(responses, response)
+		}
+	}
+	return responses, nil
+}
+
+func (client *Client) decryptResponse(event *nostr.Event) (*Response, error) {
+	payload, err := nip04.Decrypt(event.Content, client.ss)
+	if err != nil {
+		return nil, err
+	}
+	response := &Response{}
+	err = json.Unmarshal([]byte(payload), response)
+	if err != nil {
+		return nil, err
+	}
+	if dTag := event.Tags.GetFirst([]string{"d"}); dTag != nil {
+		response.DTag = dTag.Value()
+	}
+	return response, nil
+}
@@ -0,0 +1,147 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const keysendPubkey = "03cbd788f5b22bd56e2714bff756372d2293504c064e03250ed16a4dd80ad70e2c"
+
+func newTestHarness(t *testing.T) *Harness {
+	harness, err := NewHarness()
+	require.NoError(t, err)
+	t.Cleanup(harness.Close)
+	return harness
+}
+
+func TestE2E_GetInfo(t *testing.T) {
+	harness := newTestHarness(t)
+	client, err := harness.NewClient(constants.GET_INFO_SCOPE)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	response, err := client.Request(ctx, models.GET_INFO_METHOD, struct{}{})
+	require.NoError(t, err)
+
+	assert.Nil(t, response.Error)
+	assert.Equal(t, models.GET_INFO_METHOD, response.ResultType)
+	result := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(response.Result, &result))
+	assert.Equal(t, tests.MockNodeInfo.Alias, result["alias"])
+}
+
+func TestE2E_PermissionDenied(t *testing.T) {
+	harness := newTestHarness(t)
+	client, err := harness.NewClient(constants.GET_INFO_SCOPE)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	response, err := client.Request(ctx, models.PAY_INVOICE_METHOD, map[string]interface{}{"invoice": tests.MockInvoice})
+	require.NoError(t, err)
+
+	require.NotNil(t, response.Error)
+	assert.Equal(t, models.ERROR_RESTRICTED, response.Error.Code)
+	assert.Equal(t, 0, harness.LNClient.Payments())
+}
+
+func TestE2E_PayInvoice(t *testing.T) {
+	harness := newTestHarness(t)
+	client, err := harness.NewClient(constants.PAY_INVOICE_SCOPE)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	response, err := client.Request(ctx, models.PAY_INVOICE_METHOD, map[string]interface{}{"invoice": tests.MockInvoice})
+	require.NoError(t, err)
+
+	assert.Nil(t, response.Error)
+	result := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal(response.Result, &result))
+	assert.Equal(t, "123preimage", result["preimage"])
+}
+
+func TestE2E_PayInvoice_PaymentFails(t *testing.T) {
+	harness := newTestHarness(t)
+	client, err := harness.NewClient(constants.PAY_INVOICE_SCOPE)
+	require.NoError(t, err)
+	harness.LNClient.FailPayments(errors.New("no route"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	response, err := client.Request(ctx, models.PAY_INVOICE_METHOD, map[string]interface{}{"invoice": tests.MockInvoice})
+	require.NoError(t, err)
+
+	require.NotNil(t, response.Error)
+	assert.Equal(t, models.ERROR_INTERNAL, response.Error.Code)
+	assert.Contains(t, response.Error.Message, "no route")
+}
+
+func TestE2E_MultiPayKeysend_OneFails(t *testing.T) {
+	harness := newTestHarness(t)
+	client, err := harness.NewClient(constants.PAY_INVOICE_SCOPE)
+	require.NoError(t, err)
+	harness.LNClient.FailPayments(errors.New("no route"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	requestEvent, err := client.SendRequest(ctx, models.MULTI_PAY_KEYSEND_METHOD, map[string]interface{}{
+		"keysends": []map[string]interface{}{
+			{"id": "first", "amount": 1000, "pubkey": keysendPubkey},
+			{"id": "second", "amount": 1000, "pubkey": keysendPubkey},
+			{"id": "third", "amount": 1000, "pubkey": keysendPubkey},
+		},
+	})
+	require.NoError(t, err)
+	responses, err := client.WaitForResponses(ctx, requestEvent, 3)
+	require.NoError(t, err)
+
+	// the payments are made concurrently, so any of them may be the failed one
+	dTags := []string{}
+	failed := 0
+	for _, response := range responses {
+		dTags = append(dTags, response.DTag)
+		if response.Error != nil {
+			failed++
+		}
+	}
+	assert.ElementsMatch(t, []string{"first", "second", "third"}, dTags)
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, 3, harness.LNClient.Payments())
+}
+
+func TestE2E_RelayDisconnectsDuringPayment(t *testing.T) {
+	harness := newTestHarness(t)
+	client, err := harness.NewClient(constants.PAY_INVOICE_SCOPE)
+	require.NoError(t, err)
+	harness.LNClient.SetLatency(500 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	requestEvent, err := client.SendRequest(ctx, models.PAY_KEYSEND_METHOD, map[string]interface{}{
+		"amount": 1000,
+		"pubkey": keysendPubkey,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return harness.LNClient.Payments() == 1 }, 5*time.Second, 10*time.Millisecond)
+	harness.Relay.DropConnections()
+	require.Eventually(t, func() bool { return !harness.HubConnected() }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, harness.ConnectHub())
+
+	// the response is published once the payment completes, to the new connection
+	responses, err := client.WaitForResponses(ctx, requestEvent, 1)
+	require.NoError(t, err)
+	assert.Nil(t, responses[0].Error)
+	assert.Equal(t, models.PAY_KEYSEND_METHOD, responses[0].ResultType)
+}
@@ -0,0 +1,103 @@
+// Package e2e runs NIP-47 requests end-to-end: a client publishes requests to an in-process
+// relay, the hub handles them with a mock lightning backend and publishes its responses to the relay.
+package e2e
+
+import (
+	"context"
+	"sync"
+
+	"github.com/getAlby/hub/nip47"
+	"github.com/getAlby/hub/nip47/models"
+	"github.com/getAlby/hub/tests"
+	"github.com/nbd-wtf/go-nostr"
+)
+
+type Harness struct {
+	Svc          *tests.TestService
+	LNClient     *MockLNClient
+	Relay        *Relay
+	Nip47Service nip47.Nip47Service
+	ctx          context.Context
+	cancel       context.CancelFunc
+	mu           sync.Mutex
+	// the connection of the hub to the relay
+	hubRelay *nostr.Relay
+}
+
+// NewHarness starts the relay and connects the hub to it. Close must be called when the test is done.
+func NewHarness() (*Harness, error) {
+	svc, err := tests.CreateTestService()
+	if err != nil {
+		return nil, err
+	}
+	lnClient, err := NewMockLNClient()
+	if err != nil {
+		tests.RemoveTestService()
+		return nil, err
+	}
+	svc.LNClient = lnClient
+
+	ctx, cancel := context.WithCancel(context.Background())
+	harness := &Harness{
+		Svc:          svc,
+		LNClient:     lnClient,
+		Relay:        NewRelay(),
+		Nip47Service: nip47.NewNip47Service(svc.DB, svc.Cfg, svc.Keys, svc.EventPublisher),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+	err = harness.ConnectHub()
+	if err != nil {
+		harness.Close()
+		return nil, err
+	}
+	return harness, nil
+}
+
+// ConnectHub connects the hub to the relay, like the hub does after the relay went away
+func (harness *Harness) ConnectHub() error {
+	relay, err := nostr.RelayConnect(harness.ctx, harness.Relay.URL())
+	if err != nil {
+		return err
+	}
+	sub, err := relay.Subscribe(harness.ctx, nostr.Filters{{
+		Kinds: []int{models.REQUEST_KIND},
+		Tags:  nostr.TagMap{"p": []string{harness.Svc.Keys.GetNostrPublicKey()}},
+	}})
+	if err != nil {
+		relay.Close()
+		return err
+	}
+
+	harness.mu.Lock()
+	harness.hubRelay = relay
+	harness.mu.Unlock()
+
+	harness.Nip47Service.RecoverResponses(harness.ctx, relay)
+	go func() {
+		for event := range sub.Events {
+			go harness.Nip47Service.HandleEvent(harness.ctx, relay, event, harness.LNClient)
+		}
+	}()
+	return nil
+}
+
+// HubConnected returns whether the hub is still connected to the relay
+func (harness *Harness) HubConnected() bool {
+	harness.mu.Lock()
+	defer harness.mu.Unlock()
+	return harness.hubRelay != nil && harness.hubRelay.IsConnected()
+}
+
+// Close waits for the responses that are still being published and stops the relay
+func (harness *Harness) Close() {
+	harness.Nip47Service.WaitForPublishedResponses()
+	harness.cancel()
+	harness.mu.Lock()
+	if harness.hubRelay != nil {
+		harness.hubRelay.Close()
+	}
+	harness.mu.Unlock()
+	harness.Relay.Close()
+	tests.RemoveTestService()
+}
@@ -0,0 +1,94 @@
+package e2e
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/tests"
+)
+
+// MockLNClient is a tests.MockLn whose payments can be made slow or fail
+type MockLNClient struct {
+	*tests.MockLn
+	mu      sync.Mutex
+	latency time.Duration
+	// consumed in order by the payments, a nil error lets the payment succeed
+	paymentErrors []error
+	payments      int
+}
+
+func NewMockLNClient() (*MockLNClient, error) {
+	mockLn, err := tests.NewMockLn()
+	if err != nil {
+		return nil, err
+	}
+	return &MockLNClient{MockLn: mockLn}, nil
+}
+
+// SetLatency makes every payment take this long
+func (ln *MockLNClient) SetLatency(latency time.Duration) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	ln.latency = latency
+}
+
+// FailPayments makes the next payments return these errors, in order
+func (ln *MockLNClient) FailPayments(errs ...error) {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	ln.paymentErrors = append(ln.paymentErrors, errs...)
+}
+
+// Payments returns the number of payments that were attempted
+func (ln *MockLNClient) Payments() int {
+	ln.mu.Lock()
+	defer ln.mu.Unlock()
+	return ln.payments
+}
+
+func (ln *MockLNClient) startPayment(ctx context.Context) error {
+	ln.mu.Lock()
+	ln.payments++
+	latency := ln.latency
+	var err error
+	if len(ln.paymentErrors) > 0 {
+		err = ln.paymentErrors[0]
+		ln.paymentErrors = ln.paymentErrors[1:]
+	}
+	ln.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(latency):
+		}
+	}
+	return err
+}
+
+func (ln *MockLNClient) SendPaymentSync(ctx context.Context, payReq string) (*lnclient.PayInvoiceResponse, error) {
+	err := ln.startPayment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ln.MockLn.SendPaymentSync(ctx, payReq)
+}
+
+func (ln *MockLNClient) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	err := ln.startPayment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ln.MockLn.SendMultiPartPaymentSync(ctx, payReq, options)
+}
+
+func (ln *MockLNClient) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
+	err := ln.startPayment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return ln.MockLn.SendKeysend(ctx, amount, destination, custom_records, preimage)
+}
@@ -0,0 +1,36 @@
+package e2e
+
+import (
+	"net/http/httptest"
+	"strings"
+
+	"github.com/getAlby/hub/nostr/relay"
+)
+
+// Relay is the relay of the all-in-one mode, served on a local port
+type Relay struct {
+	*relay.Relay
+	server *httptest.Server
+}
+
+func NewRelay() *Relay {
+	embeddedRelay := relay.NewRelay()
+	return &Relay{
+		Relay:  embeddedRelay,
+		server: httptest.NewServer(embeddedRelay),
+	}
+}
+
+func (relay *Relay) URL() string {
+	return "ws" + strings.TrimPrefix(relay.server.URL, "http")
+}
+
+// DropConnections closes the connections of all clients, as if the relay went away
+func (relay *Relay) DropConnections() {
+	relay.CloseConnections()
+}
+
+func (relay *Relay) Close() {
+	relay.CloseConnections()
+	relay.server.Close()
+}
@@ -2,6 +2,8 @@ package tests
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/getAlby/hub/lnclient"
@@ -14,6 +16,11 @@ const MockPaymentHash500 = "be8ad5d0b82071d538dcd160e3a3af444bd890de68388a4d771b
 const MockInvoice = "lntb1230n1pjypux0pp5xgxzcks5jtx06k784f9dndjh664wc08ucrganpqn52d0ftrh9n8sdqyw3jscqzpgxqyz5vqsp5rkx7cq252p3frx8ytjpzc55rkgyx2mfkzzraa272dqvr2j6leurs9qyyssqhutxa24r5hqxstchz5fxlslawprqjnarjujp5sm3xj7ex73s32sn54fthv2aqlhp76qmvrlvxppx9skd3r5ut5xutgrup8zuc6ay73gqmra29m"
 const MockPaymentHash = "320c2c5a1492ccfd5bc7aa4ad9b657d6aaec3cfcc0d1d98413a29af4ac772ccf" // for the above invoice
 
+const MockOffer = "lno1qgsqvgnwgcg35z6ee2h3yczraddm72xrfua9uve2rlrm9deu7xyfzrcgqyqs5pr5v4ehg93pqfnwgkvdr57yzh6h92zg3qctvrm7w38djg67kzcm4yj5rtjr2rwd"
+const MockOfferId = "3b3b9a0aa0c3ffd00fa1e39e60b2ebbb0f0d73184262470fac0bb2209a7f3c0d"
+
+const MockChannelBackup = "mock channel backup"
+
 var MockNodeInfo = lnclient.NodeInfo{
 	Alias:       "bob",
 	Color:       "#3399FF",
@@ -23,6 +30,17 @@ var MockNodeInfo = lnclient.NodeInfo{
 	BlockHash:   "123blockhash",
 }
 
+var MockBalances = lnclient.BalancesResponse{
+	Onchain: lnclient.OnchainBalanceResponse{
+		Spendable: 1000,
+		Total:     1000,
+	},
+	Lightning: lnclient.LightningBalanceResponse{
+		TotalSpendable:  21000,
+		TotalReceivable: 100000,
+	},
+}
+
 var MockTime = time.Unix(1693876963, 0)
 var MockTimeUnix = MockTime.Unix()
 
@@ -60,6 +78,11 @@ type MockLn struct {
 	PayInvoiceResponses []*lnclient.PayInvoiceResponse
 	PayInvoiceErrors    []error
 	Pubkey              string
+	// options passed to the last multi-part payment
+	MultiPartPaymentOptions *lnclient.MultiPartPaymentOptions
+	Channels                []lnclient.Channel
+	// payments can be sent concurrently
+	mu sync.Mutex
 }
 
 func NewMockLn() (*MockLn, error) {
@@ -67,6 +90,8 @@ func NewMockLn() (*MockLn, error) {
 }
 
 func (mln *MockLn) SendPaymentSync(ctx context.Context, payReq string) (*lnclient.PayInvoiceResponse, error) {
+	mln.mu.Lock()
+	defer mln.mu.Unlock()
 	if len(mln.PayInvoiceResponses) > 0 {
 		response := mln.PayInvoiceResponses[0]
 		err := mln.PayInvoiceErrors[0]
@@ -80,6 +105,13 @@ func (mln *MockLn) SendPaymentSync(ctx context.Context, payReq string) (*lnclien
 	}, nil
 }
 
+func (mln *MockLn) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions) (*lnclient.PayInvoiceResponse, error) {
+	mln.mu.Lock()
+	mln.MultiPartPaymentOptions = options
+	mln.mu.Unlock()
+	return mln.SendPaymentSync(ctx, payReq)
+}
+
 func (mln *MockLn) SendKeysend(ctx context.Context, amount uint64, destination string, custom_records []lnclient.TLVRecord, preimage string) (*lnclient.PayKeysendResponse, error) {
 	return &lnclient.PayKeysendResponse{
 		Fee: 1,
@@ -110,7 +142,7 @@ func (mln *MockLn) Shutdown() error {
 }
 
 func (mln *MockLn) ListChannels(ctx context.Context) (channels []lnclient.Channel, err error) {
-	return []lnclient.Channel{}, nil
+	return append([]lnclient.Channel{}, mln.Channels...), nil
 }
 func (mln *MockLn) GetNodeConnectionInfo(ctx context.Context) (nodeConnectionInfo *lnclient.NodeConnectionInfo, err error) {
 	return nil, nil
@@ -128,12 +160,12 @@ func (mln *MockLn) GetNewOnchainAddress(ctx context.Context) (string, error) {
 	return "", nil
 }
 func (mln *MockLn) GetBalances(ctx context.Context) (*lnclient.BalancesResponse, error) {
-	return nil, nil
+	return &MockBalances, nil
 }
 func (mln *MockLn) GetOnchainBalance(ctx context.Context) (*lnclient.OnchainBalanceResponse, error) {
 	return nil, nil
 }
-func (mln *MockLn) RedeemOnchainFunds(ctx context.Context, toAddress string) (txId string, err error) {
+func (mln *MockLn) RedeemOnchainFunds(ctx context.Context, toAddress string, amount uint64, feeRate *uint64, sendAll bool) (txId string, err error) {
 	return "", nil
 }
 func (mln *MockLn) ResetRouter(key string) error {
@@ -154,6 +186,33 @@ func (mln *MockLn) GetLogOutput(ctx context.Context, maxLen int) ([]byte, error)
 func (mln *MockLn) SignMessage(ctx context.Context, message string) (string, error) {
 	return "", nil
 }
+func (mln *MockLn) MakeOffer(ctx context.Context, description string) (*lnclient.Offer, error) {
+	return &lnclient.Offer{
+		Offer:   MockOffer,
+		OfferId: MockOfferId,
+	}, nil
+}
+func (mln *MockLn) ExportChannelBackup(ctx context.Context) (*lnclient.ChannelBackup, error) {
+	return &lnclient.ChannelBackup{
+		Data:         []byte(MockChannelBackup),
+		ChannelCount: len(mln.Channels),
+	}, nil
+}
+func (mln *MockLn) VerifyChannelBackup(ctx context.Context, channelBackup *lnclient.ChannelBackup) error {
+	if string(channelBackup.Data) != MockChannelBackup {
+		return errors.New("invalid channel backup")
+	}
+	return nil
+}
+func (mln *MockLn) ListWatchtowers(ctx context.Context) ([]lnclient.Watchtower, error) {
+	return []lnclient.Watchtower{}, nil
+}
+func (mln *MockLn) AddWatchtower(ctx context.Context, pubkey string, address string) error {
+	return nil
+}
+func (mln *MockLn) RemoveWatchtower(ctx context.Context, pubkey string) error {
+	return nil
+}
 func (mln *MockLn) GetStorageDir() (string, error) {
 	return "", nil
 }
@@ -175,7 +234,7 @@ func (mln *MockLn) UpdateChannel(ctx context.Context, updateChannelRequest *lncl
 }
 
 func (mln *MockLn) GetSupportedNIP47Methods() []string {
-	return []string{"pay_invoice", "pay_keysend", "get_balance", "get_info", "make_invoice", "lookup_invoice", "list_transactions", "multi_pay_invoice", "multi_pay_keysend", "sign_message"}
+	return []string{"pay_invoice", "pay_keysend", "get_balance", "get_info", "make_invoice", "lookup_invoice", "list_transactions", "multi_pay_invoice", "multi_pay_keysend", "sign_message", "make_offer"}
 }
 func (mln *MockLn) GetSupportedNIP47NotificationTypes() []string {
 	return []string{"payment_received", "payment_sent"}
@@ -11,13 +11,23 @@ import (
 	"github.com/getAlby/hub/logger"
 	"github.com/getAlby/hub/service/keys"
 	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 const testDB = "test.db"
 
+// set TEST_DATABASE_URI to run the tests against a Postgres database, which is emptied after every test
+const testDatabaseUriEnv = "TEST_DATABASE_URI"
+
+const UnlockPassword = "123"
+
 func CreateTestService() (svc *TestService, err error) {
-	gormDb, err := db.NewDB(testDB)
+	databaseUri := testDB
+	if uri := os.Getenv(testDatabaseUriEnv); uri != "" {
+		databaseUri = uri
+	}
+	gormDb, err := db.NewDB(databaseUri)
 	if err != nil {
 		return nil, err
 	}
@@ -39,7 +49,10 @@ func CreateTestService() (svc *TestService, err error) {
 	)
 
 	keys := keys.NewKeys()
-	keys.Init(cfg, "")
+	err = keys.Init(cfg, UnlockPassword)
+	if err != nil {
+		return nil, err
+	}
 
 	eventPublisher := events.NewEventPublisher()
 
@@ -61,5 +74,22 @@ type TestService struct {
 }
 
 func RemoveTestService() {
+	if uri := os.Getenv(testDatabaseUriEnv); db.IsPostgresUri(uri) {
+		resetPostgres(uri)
+		return
+	}
 	os.Remove(testDB)
 }
+
+func resetPostgres(uri string) {
+	gormDb, err := gorm.Open(postgres.Open(uri), &gorm.Config{})
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to connect to the test database")
+		return
+	}
+	defer db.Stop(gormDb)
+	err = gormDb.Exec("DROP SCHEMA public CASCADE; CREATE SCHEMA public;").Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to reset the test database")
+	}
+}
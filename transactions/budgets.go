@@ -0,0 +1,76 @@
+package transactions
+
+import (
+	"context"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/db/queries"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/logger"
+	"github.com/sirupsen/logrus"
+)
+
+const budgetMonitorInterval = 1 * time.Hour
+
+// StartBudgetMonitor warns once per budget period when the budget of an app
+// is projected to run out before it renews
+func (svc *transactionsService) StartBudgetMonitor(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(budgetMonitorInterval)
+		defer ticker.Stop()
+		for {
+			svc.checkBudgetForecasts()
+			select {
+			case <-ctx.Done():
+				logger.Logger.Info("Stopped budget monitor")
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (svc *transactionsService) checkBudgetForecasts() {
+	var appPermissions []db.AppPermission
+	err := svc.db.
+		Preload("App").
+		Where("scope = ? AND max_amount_sat > 0 AND budget_renewal != ?", constants.PAY_INVOICE_SCOPE, constants.BUDGET_RENEWAL_NEVER).
+		Find(&appPermissions).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list app budgets")
+		return
+	}
+
+	for _, appPermission := range appPermissions {
+		forecast := queries.GetBudgetForecast(svc.db, &appPermission)
+		if forecast == nil || forecast.RunsOutAt == nil {
+			continue
+		}
+		if appPermission.BudgetWarningSentAt != nil && appPermission.BudgetWarningSentAt.After(forecast.RenewedAt) {
+			continue
+		}
+
+		err := svc.db.Model(&appPermission).Update("budget_warning_sent_at", time.Now()).Error
+		if err != nil {
+			logger.Logger.WithError(err).WithField("app_id", appPermission.AppId).Error("Failed to update budget warning")
+			continue
+		}
+		logger.Logger.WithFields(logrus.Fields{
+			"app_id":      appPermission.AppId,
+			"runs_out_at": forecast.RunsOutAt,
+			"renews_at":   forecast.RenewsAt,
+		}).Info("Budget is projected to run out before it renews")
+		svc.eventPublisher.Publish(&events.Event{
+			Event: "nwc_budget_warning",
+			Properties: map[string]interface{}{
+				"app_id":      appPermission.AppId,
+				"app_name":    appPermission.App.Name,
+				"budget":      appPermission.MaxAmountSat,
+				"runs_out_at": *forecast.RunsOutAt,
+				"renews_at":   forecast.RenewsAt,
+			},
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package transactions
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/db/queries"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockEventConsumer struct {
+	events []*events.Event
+}
+
+func (consumer *mockEventConsumer) ConsumeEvent(ctx context.Context, event *events.Event, globalProperties map[string]interface{}) {
+	consumer.events = append(consumer.events, event)
+}
+
+func TestCheckBudgetForecasts(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	consumer := &mockEventConsumer{}
+	svc.EventPublisher.RegisterSubscriber(consumer)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	appPermission := &db.AppPermission{
+		AppId:         app.ID,
+		App:           *app,
+		Scope:         constants.PAY_INVOICE_SCOPE,
+		MaxAmountSat:  100,
+		BudgetRenewal: constants.BUDGET_RENEWAL_MONTHLY,
+	}
+	assert.NoError(t, svc.DB.Create(appPermission).Error)
+	// the budget is used up, which projects it to run out now
+	svc.DB.Create(&db.Transaction{
+		AppId:      &app.ID,
+		Type:       constants.TRANSACTION_TYPE_OUTGOING,
+		State:      constants.TRANSACTION_STATE_SETTLED,
+		AmountMsat: 100_000,
+	})
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	transactionsService.checkBudgetForecasts()
+
+	assert.Len(t, consumer.events, 1)
+	assert.Equal(t, "nwc_budget_warning", consumer.events[0].Event)
+	properties := consumer.events[0].Properties.(map[string]interface{})
+	assert.Equal(t, app.Name, properties["app_name"])
+	assert.Equal(t, 100, properties["budget"])
+	assert.IsType(t, time.Time{}, properties["renews_at"])
+
+	// only warned once per budget period
+	transactionsService.checkBudgetForecasts()
+	assert.Len(t, consumer.events, 1)
+
+	svc.DB.First(appPermission, appPermission.ID)
+	assert.NotNil(t, appPermission.BudgetWarningSentAt)
+}
+
+func TestSendKeysend_App_ConcurrentPaymentsDoNotExceedBudget(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	appPermission := &db.AppPermission{
+		AppId:        app.ID,
+		App:          *app,
+		Scope:        constants.PAY_INVOICE_SCOPE,
+		MaxAmountSat: 10100, // 10 payments of 1000 sats + the fee reserve (10 sats) of the last one
+	}
+	err = svc.DB.Create(appPermission).Error
+	assert.NoError(t, err)
+
+	// every service has its own write batcher, so the payments are not only serialized by one batcher
+	transactionsServices := []*transactionsService{}
+	for i := 0; i < 4; i++ {
+		transactionsServices = append(transactionsServices, NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher))
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 100)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = transactionsServices[i%len(transactionsServices)].SendKeysend(ctx, uint64(1_000_000), "fake destination", []lnclient.TLVRecord{}, "", svc.LNClient, &app.ID, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	settled := 0
+	for _, err := range errs {
+		if err == nil {
+			settled++
+			continue
+		}
+		assert.ErrorIs(t, err, NewQuotaExceededError())
+	}
+	assert.Equal(t, 10, settled)
+	assert.LessOrEqual(t, queries.GetBudgetUsageSat(svc.DB, appPermission), uint64(appPermission.MaxAmountSat))
+}
+
+func TestReserveTransaction_BudgetUsedConcurrently(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	err = svc.DB.Create(&db.AppPermission{
+		AppId:        app.ID,
+		App:          *app,
+		Scope:        constants.PAY_INVOICE_SCOPE,
+		MaxAmountSat: 1010,
+	}).Error
+	assert.NoError(t, err)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	err = svc.DB.Transaction(func(tx *gorm.DB) error {
+		// both payments are validated against the same usage before either is reserved
+		var appPermissions []*db.AppPermission
+		var dbTransactions []*db.Transaction
+		for i := 0; i < 2; i++ {
+			appPermission, err := transactionsService.validateCanPay(tx, &app.ID, 1_000_000)
+			assert.NoError(t, err)
+			appPermissions = append(appPermissions, appPermission)
+		}
+		for i := 0; i < 2; i++ {
+			dbTransaction := &db.Transaction{
+				AppId:          &app.ID,
+				Type:           constants.TRANSACTION_TYPE_OUTGOING,
+				State:          constants.TRANSACTION_STATE_CREATED,
+				AmountMsat:     1_000_000,
+				FeeReserveMsat: transactionsService.calculateFeeReserveMsat(1_000_000),
+			}
+			assert.NoError(t, tx.Create(dbTransaction).Error)
+			dbTransactions = append(dbTransactions, dbTransaction)
+		}
+
+		assert.NoError(t, transactionsService.reserveTransaction(tx, dbTransactions[0], appPermissions[0]))
+		assert.Equal(t, constants.TRANSACTION_STATE_RESERVED, dbTransactions[0].State)
+		assert.ErrorIs(t, transactionsService.reserveTransaction(tx, dbTransactions[1], appPermissions[1]), NewQuotaExceededError())
+		assert.Equal(t, constants.TRANSACTION_STATE_CREATED, dbTransactions[1].State)
+		return nil
+	})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,193 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/logger"
+	"github.com/getAlby/hub/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultPaymentConfirmationTimeout = 2 * time.Minute
+
+// the encrypted secret of the authenticator app that approves payments, so that
+// a stolen session alone cannot approve them
+const PaymentConfirmationTotpSecretKey = "PaymentConfirmationTotpSecret"
+
+// the payment is rejected after this many wrong codes, so that codes cannot be guessed
+const maxConfirmationCodeAttempts = 3
+
+type PendingPaymentConfirmation struct {
+	TransactionId uint
+	AppId         uint
+	AmountMsat    uint64
+	PaymentHash   string
+	Description   string
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	result        chan bool
+	// wrong authenticator codes sent to approve the payment
+	failedAttempts int
+}
+
+type paymentNotConfirmedError struct {
+}
+
+func NewPaymentNotConfirmedError() error {
+	return &paymentNotConfirmedError{}
+}
+
+func (err *paymentNotConfirmedError) Error() string {
+	return "The payment was not confirmed by the wallet owner"
+}
+
+type invalidConfirmationCodeError struct {
+}
+
+func NewInvalidConfirmationCodeError() error {
+	return &invalidConfirmationCodeError{}
+}
+
+func (err *invalidConfirmationCodeError) Error() string {
+	return "Invalid authenticator code"
+}
+
+type paymentConfirmations struct {
+	mu      sync.Mutex
+	pending map[uint]*PendingPaymentConfirmation
+}
+
+func (svc *transactionsService) requiresConfirmation(appId *uint, amountMsat uint64) bool {
+	// payments from the UI are made by the owner themselves
+	if appId == nil {
+		return false
+	}
+	thresholdSat := svc.cfg.GetEnv().ConfirmPaymentsAboveSat
+	return thresholdSat > 0 && amountMsat >= uint64(thresholdSat)*1000
+}
+
+func (svc *transactionsService) paymentConfirmationTimeout() time.Duration {
+	if timeoutSecs := svc.cfg.GetEnv().ConfirmationTimeoutSecs; timeoutSecs > 0 {
+		return time.Duration(timeoutSecs) * time.Second
+	}
+	return defaultPaymentConfirmationTimeout
+}
+
+// awaitConfirmation holds an app payment above the confirmation threshold
+// until the owner approves it from an unlocked session with a code of their authenticator app.
+// The amount stays reserved in the meantime.
+func (svc *transactionsService) awaitConfirmation(ctx context.Context, dbTransaction *db.Transaction) error {
+	if !svc.requiresConfirmation(dbTransaction.AppId, dbTransaction.AmountMsat) {
+		return nil
+	}
+
+	timeout := svc.paymentConfirmationTimeout()
+
+	confirmation := &PendingPaymentConfirmation{
+		TransactionId: dbTransaction.ID,
+		AppId:         *dbTransaction.AppId,
+		AmountMsat:    dbTransaction.AmountMsat,
+		PaymentHash:   dbTransaction.PaymentHash,
+		Description:   dbTransaction.Description,
+		CreatedAt:     time.Now(),
+		ExpiresAt:     time.Now().Add(timeout),
+		// buffered so that confirming never blocks on a payment that already gave up
+		result: make(chan bool, 1),
+	}
+
+	svc.confirmations.mu.Lock()
+	svc.confirmations.pending[confirmation.TransactionId] = confirmation
+	svc.confirmations.mu.Unlock()
+	defer func() {
+		svc.confirmations.mu.Lock()
+		delete(svc.confirmations.pending, confirmation.TransactionId)
+		svc.confirmations.mu.Unlock()
+	}()
+
+	logger.Logger.WithFields(logrus.Fields{
+		"transaction_id": confirmation.TransactionId,
+		"app_id":         confirmation.AppId,
+		"amount":         confirmation.AmountMsat / 1000,
+	}).Info("Waiting for payment to be confirmed")
+
+	svc.eventPublisher.Publish(&events.Event{
+		Event: "nwc_payment_confirmation_required",
+		Properties: map[string]interface{}{
+			"transaction_id": confirmation.TransactionId,
+			"app_id":         confirmation.AppId,
+			"amount":         confirmation.AmountMsat / 1000,
+		},
+	})
+
+	select {
+	case approved := <-confirmation.result:
+		if approved {
+			return nil
+		}
+		logger.Logger.WithField("transaction_id", confirmation.TransactionId).Info("Payment was rejected")
+	case <-time.After(timeout):
+		logger.Logger.WithField("transaction_id", confirmation.TransactionId).Info("Timed out waiting for payment confirmation")
+	case <-ctx.Done():
+		logger.Logger.WithField("transaction_id", confirmation.TransactionId).Info("Stopped waiting for payment confirmation")
+	}
+	return NewPaymentNotConfirmedError()
+}
+
+func (svc *transactionsService) ListPendingPaymentConfirmations() []PendingPaymentConfirmation {
+	svc.confirmations.mu.Lock()
+	defer svc.confirmations.mu.Unlock()
+
+	confirmations := []PendingPaymentConfirmation{}
+	for _, confirmation := range svc.confirmations.pending {
+		confirmations = append(confirmations, *confirmation)
+	}
+	sort.Slice(confirmations, func(i, j int) bool {
+		return confirmations[i].CreatedAt.Before(confirmations[j].CreatedAt)
+	})
+	return confirmations
+}
+
+// ConfirmPayment answers a payment that is waiting for confirmation. Approving it requires a code
+// of the authenticator app, whose secret is decrypted with the unlock password of the running app.
+func (svc *transactionsService) ConfirmPayment(transactionId uint, approved bool, totpCode string, encryptionKey string) error {
+	svc.confirmations.mu.Lock()
+	defer svc.confirmations.mu.Unlock()
+
+	confirmation, ok := svc.confirmations.pending[transactionId]
+	if !ok {
+		return NewNotFoundError()
+	}
+
+	if approved {
+		totpSecret, err := svc.cfg.Get(PaymentConfirmationTotpSecretKey, encryptionKey)
+		if err != nil {
+			return err
+		}
+		if totpSecret == "" {
+			return errors.New("an authenticator app has to be set up to approve payments")
+		}
+		if !utils.ValidateTotpCode(totpSecret, totpCode, time.Now()) {
+			confirmation.failedAttempts++
+			logger.Logger.WithFields(logrus.Fields{
+				"transaction_id":  transactionId,
+				"failed_attempts": confirmation.failedAttempts,
+			}).Warn("Invalid code to approve payment")
+			if confirmation.failedAttempts >= maxConfirmationCodeAttempts {
+				delete(svc.confirmations.pending, transactionId)
+				confirmation.result <- false
+			}
+			return NewInvalidConfirmationCodeError()
+		}
+	}
+
+	// a payment can only be answered once
+	delete(svc.confirmations.pending, transactionId)
+	confirmation.result <- approved
+	return nil
+}
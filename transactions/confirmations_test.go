@@ -0,0 +1,169 @@
+package transactions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+	"github.com/getAlby/hub/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupConfirmationTest(t *testing.T) (*tests.TestService, *db.App, *db.RequestEvent) {
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	appPermission := &db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.PAY_INVOICE_SCOPE,
+	}
+	err = svc.DB.Create(appPermission).Error
+	assert.NoError(t, err)
+
+	dbRequestEvent := &db.RequestEvent{}
+	err = svc.DB.Create(&dbRequestEvent).Error
+	assert.NoError(t, err)
+
+	svc.Cfg.GetEnv().ConfirmPaymentsAboveSat = 100
+	svc.Cfg.SetUpdate(PaymentConfirmationTotpSecretKey, confirmationTotpSecret, tests.UnlockPassword)
+	return svc, app, dbRequestEvent
+}
+
+const confirmationTotpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func currentConfirmationCode(t *testing.T) string {
+	code, err := utils.GenerateTotpCode(confirmationTotpSecret, time.Now())
+	assert.NoError(t, err)
+	return code
+}
+
+// waits for the first payment waiting for confirmation
+func awaitPendingConfirmation(t *testing.T, transactionsService *transactionsService) *PendingPaymentConfirmation {
+	for i := 0; i < 100; i++ {
+		confirmations := transactionsService.ListPendingPaymentConfirmations()
+		if len(confirmations) > 0 {
+			assert.Equal(t, uint64(123000), confirmations[0].AmountMsat)
+			return &confirmations[0]
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("no payment is waiting for confirmation")
+	return nil
+}
+
+// answers the first payment waiting for confirmation
+func answerConfirmation(t *testing.T, transactionsService *transactionsService, approved bool) {
+	confirmation := awaitPendingConfirmation(t, transactionsService)
+	if confirmation == nil {
+		return
+	}
+	assert.NoError(t, transactionsService.ConfirmPayment(confirmation.TransactionId, approved, currentConfirmationCode(t), tests.UnlockPassword))
+}
+
+func TestSendPaymentSync_App_Confirmed(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, app, dbRequestEvent := setupConfirmationTest(t)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	go answerConfirmation(t, transactionsService, true)
+	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, &app.ID, &dbRequestEvent.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, constants.TRANSACTION_STATE_SETTLED, transaction.State)
+	assert.Empty(t, transactionsService.ListPendingPaymentConfirmations())
+}
+
+func TestSendPaymentSync_App_Rejected(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, app, dbRequestEvent := setupConfirmationTest(t)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	go answerConfirmation(t, transactionsService, false)
+	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, &app.ID, &dbRequestEvent.ID)
+
+	assert.ErrorIs(t, err, NewPaymentNotConfirmedError())
+	assert.Nil(t, transaction)
+
+	// the reserved amount is released
+	dbTransaction := db.Transaction{}
+	svc.DB.Find(&dbTransaction, &db.Transaction{AppId: &app.ID})
+	assert.Equal(t, constants.TRANSACTION_STATE_FAILED, dbTransaction.State)
+	assert.Zero(t, dbTransaction.FeeReserveMsat)
+}
+
+func TestSendPaymentSync_App_ConfirmationTimedOut(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, app, dbRequestEvent := setupConfirmationTest(t)
+	svc.Cfg.GetEnv().ConfirmationTimeoutSecs = 1
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, &app.ID, &dbRequestEvent.ID)
+
+	assert.ErrorIs(t, err, NewPaymentNotConfirmedError())
+	assert.Nil(t, transaction)
+	assert.Empty(t, transactionsService.ListPendingPaymentConfirmations())
+}
+
+func TestSendPaymentSync_App_BelowConfirmationThreshold(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, app, dbRequestEvent := setupConfirmationTest(t)
+	svc.Cfg.GetEnv().ConfirmPaymentsAboveSat = 1000
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, &app.ID, &dbRequestEvent.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, constants.TRANSACTION_STATE_SETTLED, transaction.State)
+}
+
+func TestConfirmPayment_NotFound(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	err = transactionsService.ConfirmPayment(1, true, "", tests.UnlockPassword)
+	assert.ErrorIs(t, err, NewNotFoundError())
+}
+
+func TestSendPaymentSync_App_InvalidConfirmationCode(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, app, dbRequestEvent := setupConfirmationTest(t)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	go func() {
+		confirmation := awaitPendingConfirmation(t, transactionsService)
+		if confirmation == nil {
+			return
+		}
+		// a session without the authenticator cannot approve the payment
+		for i := 0; i < maxConfirmationCodeAttempts; i++ {
+			err := transactionsService.ConfirmPayment(confirmation.TransactionId, true, "000000", tests.UnlockPassword)
+			assert.ErrorIs(t, err, NewInvalidConfirmationCodeError())
+		}
+		// the payment was rejected after too many wrong codes
+		err := transactionsService.ConfirmPayment(confirmation.TransactionId, true, currentConfirmationCode(t), tests.UnlockPassword)
+		assert.ErrorIs(t, err, NewNotFoundError())
+	}()
+	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, &app.ID, &dbRequestEvent.ID)
+
+	assert.ErrorIs(t, err, NewPaymentNotConfirmedError())
+	assert.Nil(t, transaction)
+}
@@ -0,0 +1,88 @@
+package transactions
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TransactionsCursor points at the last transaction of a page of transactions.
+// Transactions are ordered by creation date (newest first) and then by id,
+// so that the next page can be found through the created_at index instead of skipping rows with an offset.
+type TransactionsCursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+func NewTransactionsCursor(transaction *Transaction) *TransactionsCursor {
+	return &TransactionsCursor{
+		CreatedAt: transaction.CreatedAt,
+		ID:        transaction.ID,
+	}
+}
+
+// Encode returns the cursor in the opaque form that is passed to clients
+func (cursor *TransactionsCursor) Encode() string {
+	return encodeCursor(cursor.CreatedAt, cursor.ID)
+}
+
+func DecodeTransactionsCursor(encoded string) (*TransactionsCursor, error) {
+	createdAt, id, err := decodeCursor(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &TransactionsCursor{
+		CreatedAt: createdAt,
+		ID:        id,
+	}, nil
+}
+
+// ExportCursor points at the last exported transaction. Exports are ordered by
+// settlement date (oldest first) and then by id, so a transaction that settles after
+// an export comes after the cursor of that export and is picked up by the next one.
+type ExportCursor struct {
+	SettledAt time.Time
+	ID        uint
+}
+
+func NewExportCursor(transaction *Transaction) *ExportCursor {
+	return &ExportCursor{
+		SettledAt: *transaction.SettledAt,
+		ID:        transaction.ID,
+	}
+}
+
+func (cursor *ExportCursor) Encode() string {
+	return encodeCursor(cursor.SettledAt, cursor.ID)
+}
+
+func DecodeExportCursor(encoded string) (*ExportCursor, error) {
+	settledAt, id, err := decodeCursor(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return &ExportCursor{
+		SettledAt: settledAt,
+		ID:        id,
+	}, nil
+}
+
+func encodeCursor(timestamp time.Time, id uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", timestamp.UnixNano(), id)))
+}
+
+func decodeCursor(encoded string) (time.Time, uint, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid cursor")
+	}
+	var nanos int64
+	var id uint
+	_, err = fmt.Sscanf(string(decoded), "%d:%d", &nanos, &id)
+	if err != nil {
+		return time.Time{}, 0, errors.New("invalid cursor")
+	}
+	// stored timestamps are in local time
+	return time.Unix(0, nanos), id, nil
+}
@@ -0,0 +1,54 @@
+package transactions
+
+import (
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+)
+
+// transactions settled within this time are left for the next export. The settlement
+// timestamp is set before the transaction is committed, so a transaction that is still
+// being written could otherwise end up before the cursor of an export that missed it.
+const exportSettleDelay = 5 * time.Second
+
+func (svc *transactionsService) ExportTransactions(since, until, limit uint64, cursor *ExportCursor, handle func(transaction *Transaction) bool) error {
+	tx := svc.db.Model(&db.Transaction{}).
+		Where("state = ? AND settled_at <= ?", constants.TRANSACTION_STATE_SETTLED, time.Now().Add(-exportSettleDelay)).
+		Order("settled_at").
+		Order("id")
+
+	if since > 0 {
+		tx = tx.Where("settled_at >= ?", time.Unix(int64(since), 0))
+	}
+	if until > 0 {
+		tx = tx.Where("settled_at <= ?", time.Unix(int64(until), 0))
+	}
+	if cursor != nil {
+		tx = tx.Where("(settled_at > ? OR (settled_at = ? AND id > ?))", cursor.SettledAt, cursor.SettledAt, cursor.ID)
+	}
+	if limit > 0 {
+		tx = tx.Limit(int(limit))
+	}
+
+	rows, err := tx.Rows()
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to export DB transactions")
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transaction Transaction
+		err = svc.db.ScanRows(rows, &transaction)
+		if err != nil {
+			logger.Logger.WithError(err).Error("Failed to scan DB transaction")
+			return err
+		}
+		if !handle(&transaction) {
+			break
+		}
+	}
+	return rows.Err()
+}
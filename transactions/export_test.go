@@ -0,0 +1,61 @@
+package transactions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportTransactions(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	firstSettledAt := time.Date(2024, time.July, 1, 12, 0, 0, 0, time.Local)
+	secondSettledAt := time.Date(2024, time.July, 2, 12, 0, 0, 0, time.Local)
+	justNow := time.Now()
+	// created in a different order than they settled
+	second := &db.Transaction{Type: constants.TRANSACTION_TYPE_INCOMING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "second", SettledAt: &secondSettledAt}
+	first := &db.Transaction{Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "first", SettledAt: &firstSettledAt}
+	sameTime := &db.Transaction{Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "same-time", SettledAt: &secondSettledAt}
+	pending := &db.Transaction{Type: constants.TRANSACTION_TYPE_INCOMING, State: constants.TRANSACTION_STATE_PENDING, PaymentHash: "pending"}
+	settling := &db.Transaction{Type: constants.TRANSACTION_TYPE_INCOMING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "settling", SettledAt: &justNow}
+	for _, transaction := range []*db.Transaction{second, first, sameTime, pending, settling} {
+		assert.NoError(t, svc.DB.Create(transaction).Error)
+	}
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	export := func(since, until, limit uint64, cursor *ExportCursor) ([]string, *ExportCursor) {
+		paymentHashes := []string{}
+		var lastCursor *ExportCursor
+		err := transactionsService.ExportTransactions(since, until, limit, cursor, func(transaction *Transaction) bool {
+			paymentHashes = append(paymentHashes, transaction.PaymentHash)
+			lastCursor = NewExportCursor(transaction)
+			return true
+		})
+		assert.NoError(t, err)
+		return paymentHashes, lastCursor
+	}
+
+	paymentHashes, _ := export(0, 0, 0, nil)
+	assert.Equal(t, []string{"first", "second", "same-time"}, paymentHashes)
+
+	paymentHashes, cursor := export(0, 0, 2, nil)
+	assert.Equal(t, []string{"first", "second"}, paymentHashes)
+	decodedCursor, err := DecodeExportCursor(cursor.Encode())
+	assert.NoError(t, err)
+	paymentHashes, _ = export(0, 0, 2, decodedCursor)
+	assert.Equal(t, []string{"same-time"}, paymentHashes)
+
+	paymentHashes, _ = export(uint64(secondSettledAt.Unix()), 0, 0, nil)
+	assert.Equal(t, []string{"second", "same-time"}, paymentHashes)
+	paymentHashes, _ = export(0, uint64(firstSettledAt.Unix()), 0, nil)
+	assert.Equal(t, []string{"first"}, paymentHashes)
+
+	_, err = DecodeExportCursor("invalid")
+	assert.Error(t, err)
+}
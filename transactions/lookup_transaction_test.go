@@ -27,7 +27,7 @@ func TestLookupTransaction_IncomingPayment(t *testing.T) {
 		AmountMsat:     123000,
 	})
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	incomingTransaction, err := transactionsService.LookupTransaction(ctx, tests.MockLNClientTransaction.PaymentHash, nil, svc.LNClient, nil)
 	assert.NoError(t, err)
@@ -46,7 +46,7 @@ func TestLookupTransaction_OutgoingPayment(t *testing.T) {
 
 	mockPreimage := tests.MockLNClientTransaction.Preimage
 	svc.DB.Create(&db.Transaction{
-		State:          constants.TRANSACTION_STATE_PENDING,
+		State:          constants.TRANSACTION_STATE_IN_FLIGHT,
 		Type:           constants.TRANSACTION_TYPE_OUTGOING,
 		PaymentRequest: tests.MockLNClientTransaction.Invoice,
 		PaymentHash:    tests.MockLNClientTransaction.PaymentHash,
@@ -54,12 +54,12 @@ func TestLookupTransaction_OutgoingPayment(t *testing.T) {
 		AmountMsat:     123000,
 	})
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	outgoingTransaction, err := transactionsService.LookupTransaction(ctx, tests.MockLNClientTransaction.PaymentHash, nil, svc.LNClient, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, uint64(123000), outgoingTransaction.AmountMsat)
-	assert.Equal(t, constants.TRANSACTION_STATE_PENDING, outgoingTransaction.State)
+	assert.Equal(t, constants.TRANSACTION_STATE_IN_FLIGHT, outgoingTransaction.State)
 	assert.Equal(t, tests.MockLNClientTransaction.Preimage, *outgoingTransaction.Preimage)
 	assert.Zero(t, outgoingTransaction.FeeReserveMsat)
 }
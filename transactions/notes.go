@@ -0,0 +1,82 @@
+package transactions
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+)
+
+const maxNoteLength = 1000
+
+// custom records of keysend payments, see https://github.com/satoshisstream/satoshis.stream/blob/main/TLV_registry.md
+const (
+	boostagramTlvType     = 7629169
+	keysendMessageTlvType = 34349334
+)
+
+func (svc *transactionsService) SetTransactionNote(transactionId uint, note string) error {
+	note = strings.TrimSpace(note)
+	if len([]rune(note)) > maxNoteLength {
+		return fmt.Errorf("note cannot be longer than %d characters", maxNoteLength)
+	}
+	result := svc.db.Model(&db.Transaction{}).Where("id = ?", transactionId).Update("note", note)
+	if result.Error != nil {
+		logger.Logger.WithError(result.Error).WithField("id", transactionId).Error("Failed to update transaction note")
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return NewNotFoundError()
+	}
+	return nil
+}
+
+type boostagram struct {
+	Message    string `json:"message"`
+	SenderName string `json:"sender_name"`
+}
+
+// GetMemo returns the message that was sent along with the payment: the boostagram or
+// message of a keysend payment, or else the description of the invoice
+func GetMemo(transaction *Transaction) string {
+	if transaction.Metadata != "" {
+		var metadata struct {
+			TLVRecords []lnclient.TLVRecord `json:"tlv_records"`
+		}
+		if err := json.Unmarshal([]byte(transaction.Metadata), &metadata); err == nil {
+			if memo := getKeysendMemo(metadata.TLVRecords); memo != "" {
+				return memo
+			}
+		}
+	}
+	return transaction.Description
+}
+
+func getKeysendMemo(tlvRecords []lnclient.TLVRecord) string {
+	for _, tlvRecord := range tlvRecords {
+		value, err := hex.DecodeString(tlvRecord.Value)
+		if err != nil {
+			continue
+		}
+		switch tlvRecord.Type {
+		case boostagramTlvType:
+			var boost boostagram
+			if err := json.Unmarshal(value, &boost); err != nil || strings.TrimSpace(boost.Message) == "" {
+				continue
+			}
+			if boost.SenderName != "" {
+				return boost.SenderName + ": " + boost.Message
+			}
+			return boost.Message
+		case keysendMessageTlvType:
+			if message := strings.TrimSpace(string(value)); message != "" {
+				return message
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,57 @@
+package transactions
+
+import (
+	"testing"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMemo(t *testing.T) {
+	assert.Equal(t, "Coffee", GetMemo(&db.Transaction{Description: "Coffee"}))
+	// {"message": "Great episode", "sender_name": "alice", "action": "boost"}
+	assert.Equal(t, "alice: Great episode", GetMemo(&db.Transaction{
+		Metadata: `{"tlv_records":[{"type":7629169,"value":"7b226d657373616765223a2022477265617420657069736f6465222c202273656e6465725f6e616d65223a2022616c696365222c2022616374696f6e223a2022626f6f7374227d"}]}`,
+	}))
+	assert.Equal(t, "Hello", GetMemo(&db.Transaction{
+		Metadata: `{"tlv_records":[{"type":34349334,"value":"48656c6c6f"}]}`,
+	}))
+	// a boost without a message falls back to the description
+	assert.Equal(t, "Coffee", GetMemo(&db.Transaction{
+		Description: "Coffee",
+		Metadata:    `{"tlv_records":[{"type":7629169,"value":"7b7d"}]}`,
+	}))
+	assert.Equal(t, "", GetMemo(&db.Transaction{Metadata: `{"tlv_records":[{"type":7629169,"value":"not hex"}]}`}))
+}
+
+func TestSetTransactionNote(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	transaction := &db.Transaction{
+		Type:        constants.TRANSACTION_TYPE_INCOMING,
+		State:       constants.TRANSACTION_STATE_SETTLED,
+		PaymentHash: tests.MockPaymentHash,
+		Description: "Invoice",
+	}
+	assert.NoError(t, svc.DB.Create(transaction).Error)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	err = transactionsService.SetTransactionNote(transaction.ID, "  Paid back for lunch  ")
+	assert.NoError(t, err)
+
+	svc.DB.First(transaction, transaction.ID)
+	assert.Equal(t, "Paid back for lunch", transaction.Note)
+	assert.Equal(t, "Invoice", transaction.Description)
+
+	err = transactionsService.SetTransactionNote(transaction.ID, "")
+	assert.NoError(t, err)
+	svc.DB.First(transaction, transaction.ID)
+	assert.Equal(t, "", transaction.Note)
+
+	assert.Error(t, transactionsService.SetTransactionNote(transaction.ID, string(make([]rune, maxNoteLength+1))))
+	assert.ErrorIs(t, transactionsService.SetTransactionNote(transaction.ID+1, "note"), NewNotFoundError())
+}
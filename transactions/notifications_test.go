@@ -28,7 +28,7 @@ func TestNotifications_ReceivedKnownPayment(t *testing.T) {
 		AmountMsat:     123000,
 	})
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	transactionsService.ConsumeEvent(ctx, &events.Event{
 		Event:      "nwc_payment_received",
@@ -54,7 +54,7 @@ func TestNotifications_ReceivedUnknownPayment(t *testing.T) {
 	svc, err := tests.CreateTestService()
 	assert.NoError(t, err)
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	transactionsService.ConsumeEvent(ctx, &events.Event{
 		Event:      "nwc_payment_received",
@@ -82,7 +82,7 @@ func TestNotifications_SentKnownPayment(t *testing.T) {
 	assert.NoError(t, err)
 
 	svc.DB.Create(&db.Transaction{
-		State:          constants.TRANSACTION_STATE_PENDING,
+		State:          constants.TRANSACTION_STATE_IN_FLIGHT,
 		Type:           constants.TRANSACTION_TYPE_OUTGOING,
 		PaymentRequest: tests.MockLNClientTransaction.Invoice,
 		PaymentHash:    tests.MockLNClientTransaction.PaymentHash,
@@ -90,7 +90,7 @@ func TestNotifications_SentKnownPayment(t *testing.T) {
 		FeeReserveMsat: uint64(10000),
 	})
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	transactionsService.ConsumeEvent(ctx, &events.Event{
 		Event:      "nwc_payment_sent",
@@ -117,7 +117,7 @@ func TestNotifications_SentUnknownPayment(t *testing.T) {
 	svc, err := tests.CreateTestService()
 	assert.NoError(t, err)
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	transactions := []db.Transaction{}
 	result := svc.DB.Find(&transactions)
@@ -149,7 +149,7 @@ func TestNotifications_FailedKnownPayment(t *testing.T) {
 	assert.NoError(t, err)
 
 	svc.DB.Create(&db.Transaction{
-		State:          constants.TRANSACTION_STATE_PENDING,
+		State:          constants.TRANSACTION_STATE_IN_FLIGHT,
 		Type:           constants.TRANSACTION_TYPE_OUTGOING,
 		PaymentRequest: tests.MockLNClientTransaction.Invoice,
 		PaymentHash:    tests.MockLNClientTransaction.PaymentHash,
@@ -157,7 +157,7 @@ func TestNotifications_FailedKnownPayment(t *testing.T) {
 		FeeReserveMsat: uint64(10000),
 	})
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 
 	transactionsService.ConsumeEvent(ctx, &events.Event{
 		Event: "nwc_payment_failed_async",
@@ -0,0 +1,48 @@
+package transactions
+
+import (
+	"context"
+
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// MakeOffer creates a reusable BOLT12 offer. Payments received for the offer
+// are stored as incoming transactions of the app that created it.
+func (svc *transactionsService) MakeOffer(ctx context.Context, description string, lnClient lnclient.LNClient, appId *uint) (*db.Offer, error) {
+	lnClientOffer, err := lnClient.MakeOffer(ctx, description)
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to create offer")
+		return nil, err
+	}
+
+	offer := db.Offer{
+		AppId:         appId,
+		Offer:         lnClientOffer.Offer,
+		Bolt12OfferId: lnClientOffer.OfferId,
+		Description:   description,
+	}
+	err = svc.db.Create(&offer).Error
+	if err != nil {
+		logger.Logger.WithFields(logrus.Fields{
+			"offer_id": lnClientOffer.OfferId,
+		}).WithError(err).Error("Failed to save offer")
+		return nil, err
+	}
+	return &offer, nil
+}
+
+func (svc *transactionsService) ListOffers(appId *uint) ([]db.Offer, error) {
+	offers := []db.Offer{}
+	tx := svc.db.Order("created_at desc")
+	if appId != nil {
+		tx = tx.Where("app_id = ?", *appId)
+	}
+	err := tx.Find(&offers).Error
+	if err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
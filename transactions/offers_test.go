@@ -0,0 +1,75 @@
+package transactions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeOffer_App(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	offer, err := transactionsService.MakeOffer(ctx, "Donations", svc.LNClient, &app.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, tests.MockOffer, offer.Offer)
+	assert.Equal(t, tests.MockOfferId, offer.Bolt12OfferId)
+	assert.Equal(t, app.ID, *offer.AppId)
+
+	offers, err := transactionsService.ListOffers(&app.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(offers))
+
+	otherAppId := app.ID + 1
+	offers, err = transactionsService.ListOffers(&otherAppId)
+	assert.NoError(t, err)
+	assert.Empty(t, offers)
+}
+
+func TestNotifications_ReceivedOfferPayment(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	offer, err := transactionsService.MakeOffer(ctx, "Donations", svc.LNClient, &app.ID)
+	assert.NoError(t, err)
+
+	transactionsService.ConsumeEvent(ctx, &events.Event{
+		Event: "nwc_payment_received",
+		Properties: &lnclient.Transaction{
+			Type:        constants.TRANSACTION_TYPE_INCOMING,
+			PaymentHash: tests.MockPaymentHash,
+			Preimage:    "preimage",
+			Amount:      21000,
+			OfferId:     tests.MockOfferId,
+		},
+	}, map[string]interface{}{})
+
+	transaction := db.Transaction{}
+	err = svc.DB.First(&transaction, &db.Transaction{PaymentHash: tests.MockPaymentHash}).Error
+	assert.NoError(t, err)
+	assert.Equal(t, constants.TRANSACTION_STATE_SETTLED, transaction.State)
+	assert.Equal(t, uint64(21000), transaction.AmountMsat)
+	assert.Equal(t, offer.ID, *transaction.OfferId)
+	assert.Equal(t, app.ID, *transaction.AppId)
+	assert.Equal(t, "Donations", transaction.Description)
+}
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/events"
 	"github.com/getAlby/hub/lnclient"
 	"github.com/getAlby/hub/tests"
 	"github.com/stretchr/testify/assert"
@@ -18,7 +20,7 @@ func TestSendPaymentSync_NoApp(t *testing.T) {
 	svc, err := tests.CreateTestService()
 	assert.NoError(t, err)
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, nil, nil)
 
 	assert.NoError(t, err)
@@ -38,7 +40,7 @@ func TestSendPaymentSync_FailedRemovesFeeReserve(t *testing.T) {
 	svc.LNClient.(*tests.MockLn).PayInvoiceErrors = append(svc.LNClient.(*tests.MockLn).PayInvoiceErrors, errors.New("Some error"))
 	svc.LNClient.(*tests.MockLn).PayInvoiceResponses = append(svc.LNClient.(*tests.MockLn).PayInvoiceResponses, nil)
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, nil, nil)
 
 	assert.Error(t, err)
@@ -65,9 +67,9 @@ func TestSendPaymentSync_PendingHasFeeReserve(t *testing.T) {
 	svc.LNClient.(*tests.MockLn).PayInvoiceErrors = append(svc.LNClient.(*tests.MockLn).PayInvoiceErrors, lnclient.NewTimeoutError())
 	svc.LNClient.(*tests.MockLn).PayInvoiceResponses = append(svc.LNClient.(*tests.MockLn).PayInvoiceResponses, nil)
 
-	transactionsService := NewTransactionsService(svc.DB)
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
 	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, nil, nil)
-
+	// timeout will leave the payment as timed out, keeping the fee reserve
 	assert.Error(t, err)
 	assert.Nil(t, transaction)
 
@@ -76,7 +78,114 @@ func TestSendPaymentSync_PendingHasFeeReserve(t *testing.T) {
 	assert.Nil(t, err)
 
 	assert.Equal(t, uint64(123000), transaction.AmountMsat)
-	assert.Equal(t, constants.TRANSACTION_STATE_PENDING, transaction.State)
+	assert.Equal(t, constants.TRANSACTION_STATE_TIMED_OUT, transaction.State)
 	assert.Equal(t, uint64(10000), transaction.FeeReserveMsat)
 	assert.Nil(t, transaction.Preimage)
 }
+
+func TestSendMultiPartPaymentSync_StoresParts(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	svc.LNClient.(*tests.MockLn).PayInvoiceErrors = append(svc.LNClient.(*tests.MockLn).PayInvoiceErrors, nil)
+	svc.LNClient.(*tests.MockLn).PayInvoiceResponses = append(svc.LNClient.(*tests.MockLn).PayInvoiceResponses, &lnclient.PayInvoiceResponse{
+		Preimage: "123preimage",
+		Fee:      3000,
+		Parts: []lnclient.PaymentPart{
+			{AmountMsat: 100000, FeeMsat: 1000},
+			{AmountMsat: 23000, FeeMsat: 2000},
+		},
+	})
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	transaction, err := transactionsService.SendMultiPartPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, &lnclient.MultiPartPaymentOptions{MaxParts: 4}, svc.LNClient, nil, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(123000), transaction.AmountMsat)
+	assert.Equal(t, constants.TRANSACTION_STATE_SETTLED, transaction.State)
+
+	options := svc.LNClient.(*tests.MockLn).MultiPartPaymentOptions
+	assert.Equal(t, uint32(4), options.MaxParts)
+	// the fee reserve is the fee limit for all parts
+	assert.Equal(t, uint64(10000), options.FeeLimitMsat)
+
+	var transactionParts []db.TransactionPart
+	err = svc.DB.Where("transaction_id = ?", transaction.ID).Order("id").Find(&transactionParts).Error
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(transactionParts))
+	assert.Equal(t, uint64(100000), transactionParts[0].AmountMsat)
+	assert.Equal(t, uint64(2000), transactionParts[1].FeeMsat)
+
+	updatedTransaction := db.Transaction{}
+	svc.DB.First(&updatedTransaction, transaction.ID)
+	assert.Equal(t, uint64(3000), updatedTransaction.FeeMsat)
+}
+
+func TestSendPaymentSync_DuplicatePaymentFromOtherApp(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{AppId: app.ID, App: *app, Scope: constants.PAY_INVOICE_SCOPE}).Error
+	assert.NoError(t, err)
+
+	consumer := &duplicatePaymentConsumer{}
+	svc.EventPublisher.RegisterSubscriber(consumer)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	_, err = transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, nil, nil)
+	assert.NoError(t, err)
+
+	// duplicates are only reported by default
+	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, &app.ID, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.TRANSACTION_STATE_SETTLED, transaction.State)
+	assert.Equal(t, []interface{}{false}, consumer.blocked)
+}
+
+func TestSendPaymentSync_DuplicatePaymentFromOtherAppBlocked(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+	svc.Cfg.GetEnv().BlockDuplicatePayments = true
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{AppId: app.ID, App: *app, Scope: constants.PAY_INVOICE_SCOPE}).Error
+	assert.NoError(t, err)
+
+	consumer := &duplicatePaymentConsumer{}
+	svc.EventPublisher.RegisterSubscriber(consumer)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	_, err = transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, nil, nil)
+	assert.NoError(t, err)
+
+	transaction, err := transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, &app.ID, nil)
+	assert.ErrorIs(t, err, NewDuplicatePaymentError())
+	assert.Nil(t, transaction)
+	assert.Equal(t, []interface{}{true}, consumer.blocked)
+
+	// the same app can still retry its own payment
+	_, err = transactionsService.SendPaymentSync(ctx, tests.MockLNClientTransaction.Invoice, svc.LNClient, nil, nil)
+	assert.NoError(t, err)
+}
+
+type duplicatePaymentConsumer struct {
+	blocked []interface{}
+}
+
+func (consumer *duplicatePaymentConsumer) ConsumeEvent(ctx context.Context, event *events.Event, globalProperties map[string]interface{}) {
+	if event.Event == "nwc_duplicate_payment_detected" {
+		consumer.blocked = append(consumer.blocked, event.Properties.(map[string]interface{})["blocked"])
+	}
+}
@@ -0,0 +1,87 @@
+package transactions
+
+import (
+	"strings"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+)
+
+func (svc *transactionsService) SearchTransactions(query string, limit uint64, offset uint64) ([]Transaction, error) {
+	transactions := []Transaction{}
+	if db.IsPostgres(svc.db) {
+		return svc.searchTransactionsPostgres(query, limit, offset)
+	}
+	matchQuery := toMatchQuery(query)
+	if matchQuery == "" {
+		return transactions, nil
+	}
+
+	tx := svc.db.
+		Where("state = ? AND id IN (SELECT rowid FROM transactions_search WHERE transactions_search MATCH ?)", constants.TRANSACTION_STATE_SETTLED, matchQuery).
+		Order("created_at desc")
+	if limit > 0 {
+		tx = tx.Limit(int(limit))
+	}
+	if offset > 0 {
+		tx = tx.Offset(int(offset))
+	}
+
+	err := tx.Find(&transactions).Error
+	if err != nil {
+		logger.Logger.WithError(err).WithField("query", query).Error("Failed to search transactions")
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// the same columns as the full-text index of sqlite, the destination of keysend payments is stored in the metadata
+const postgresSearchDocument = `to_tsvector('simple', concat_ws(' ', description, note,
+	substring(metadata from '"destination":\s*"([^"]*)"'),
+	(SELECT name FROM apps WHERE apps.id = transactions.app_id)))`
+
+// searchTransactionsPostgres matches every word of the query as a prefix, like the sqlite full-text search.
+// Postgres has no index of the transactions, so the search scans the settled transactions.
+func (svc *transactionsService) searchTransactionsPostgres(query string, limit uint64, offset uint64) ([]Transaction, error) {
+	transactions := []Transaction{}
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return transactions, nil
+	}
+
+	tx := svc.db.Where("state = ?", constants.TRANSACTION_STATE_SETTLED)
+	for _, word := range words {
+		// quoting the word keeps characters of the tsquery syntax from being interpreted
+		tx = tx.Where(postgresSearchDocument+" @@ to_tsquery('simple', quote_literal(?) || ':*')", word)
+	}
+	tx = tx.Order("created_at desc")
+	if limit > 0 {
+		tx = tx.Limit(int(limit))
+	}
+	if offset > 0 {
+		tx = tx.Offset(int(offset))
+	}
+
+	err := tx.Find(&transactions).Error
+	if err != nil {
+		logger.Logger.WithError(err).WithField("query", query).Error("Failed to search transactions")
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// toMatchQuery turns the words of the query into a full-text query that matches all
+// words as prefixes, so that "coff sho" finds "Coffee shop". Quoting every word keeps
+// characters of the FTS5 query syntax from being interpreted.
+func toMatchQuery(query string) string {
+	terms := []string{}
+	for _, word := range strings.Fields(query) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word == "" {
+			continue
+		}
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
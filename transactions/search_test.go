@@ -0,0 +1,59 @@
+package transactions
+
+import (
+	"testing"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTransactions(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+
+	coffee := &db.Transaction{Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "coffee", Description: "Café Central coffee shop"}
+	keysend := &db.Transaction{AppId: &app.ID, Type: constants.TRANSACTION_TYPE_OUTGOING, State: constants.TRANSACTION_STATE_SETTLED, PaymentHash: "keysend", Metadata: `{"destination":"02abcdef"}`}
+	unpaid := &db.Transaction{Type: constants.TRANSACTION_TYPE_INCOMING, State: constants.TRANSACTION_STATE_PENDING, PaymentHash: "unpaid", Description: "coffee"}
+	for _, transaction := range []*db.Transaction{coffee, keysend, unpaid} {
+		assert.NoError(t, svc.DB.Create(transaction).Error)
+	}
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	search := func(query string) []string {
+		transactions, err := transactionsService.SearchTransactions(query, 0, 0)
+		assert.NoError(t, err)
+		paymentHashes := []string{}
+		for _, transaction := range transactions {
+			paymentHashes = append(paymentHashes, transaction.PaymentHash)
+		}
+		return paymentHashes
+	}
+
+	assert.Equal(t, []string{"coffee"}, search("coffee"))
+	assert.Equal(t, []string{"coffee"}, search("cafe sho"))
+	assert.Equal(t, []string{}, search("coffee bakery"))
+	assert.Equal(t, []string{"keysend"}, search("02abc"))
+	assert.Equal(t, []string{"keysend"}, search(app.Name))
+	assert.Equal(t, []string{}, search(`" - OR`))
+	assert.Equal(t, []string{}, search(""))
+
+	// the index follows changes of the note and the app name
+	assert.NoError(t, transactionsService.SetTransactionNote(keysend.ID, "Lunch with Bob"))
+	assert.Equal(t, []string{"keysend"}, search("bob"))
+	assert.NoError(t, svc.DB.Model(app).Update("name", "Fountain").Error)
+	assert.Equal(t, []string{"keysend"}, search("fountain"))
+	assert.NoError(t, svc.DB.Delete(keysend).Error)
+	assert.Equal(t, []string{}, search("fountain"))
+}
+
+func TestToMatchQuery(t *testing.T) {
+	assert.Equal(t, `"coffee"* "shop"*`, toMatchQuery(" coffee  shop "))
+	assert.Equal(t, `"OR"*`, toMatchQuery(`"" OR`))
+	assert.Equal(t, "", toMatchQuery(""))
+}
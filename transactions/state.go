@@ -0,0 +1,179 @@
+package transactions
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/logger"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// outgoing payments go through:
+// created -> reserved -> in_flight -> settled / failed / timed_out
+// a timed out payment may still settle or fail later.
+// incoming payments go from pending to settled.
+var allowedStateTransitions = map[string]map[string][]string{
+	constants.TRANSACTION_TYPE_OUTGOING: {
+		constants.TRANSACTION_STATE_CREATED:   {constants.TRANSACTION_STATE_RESERVED, constants.TRANSACTION_STATE_FAILED},
+		constants.TRANSACTION_STATE_RESERVED:  {constants.TRANSACTION_STATE_IN_FLIGHT, constants.TRANSACTION_STATE_FAILED},
+		constants.TRANSACTION_STATE_IN_FLIGHT: {constants.TRANSACTION_STATE_SETTLED, constants.TRANSACTION_STATE_FAILED, constants.TRANSACTION_STATE_TIMED_OUT},
+		constants.TRANSACTION_STATE_TIMED_OUT: {constants.TRANSACTION_STATE_SETTLED, constants.TRANSACTION_STATE_FAILED},
+	},
+	constants.TRANSACTION_TYPE_INCOMING: {
+		constants.TRANSACTION_STATE_PENDING: {constants.TRANSACTION_STATE_SETTLED},
+	},
+}
+
+// payments in flight for longer than this are marked as timed out by the sweeper
+const inFlightPaymentTimeout = 5 * time.Minute
+
+type invalidStateTransitionError struct {
+	from string
+	to   string
+}
+
+func NewInvalidStateTransitionError(from, to string) error {
+	return &invalidStateTransitionError{
+		from: from,
+		to:   to,
+	}
+}
+
+func (err *invalidStateTransitionError) Error() string {
+	return fmt.Sprintf("Invalid transaction state transition from %s to %s", err.from, err.to)
+}
+
+func validateStateTransition(transactionType, from, to string) error {
+	if !slices.Contains(allowedStateTransitions[transactionType][from], to) {
+		return NewInvalidStateTransitionError(from, to)
+	}
+	return nil
+}
+
+// transitionState moves the transaction to a new state and applies the updates in the same query.
+// The update only applies if the state was not changed concurrently, so e.g. a payment
+// settled by an event and by the payment call at the same time is only settled once.
+func transitionState(tx *gorm.DB, transaction *db.Transaction, to string, updates map[string]interface{}) error {
+	if transaction.State == to {
+		return nil
+	}
+
+	err := validateStateTransition(transaction.Type, transaction.State, to)
+	if err != nil {
+		return err
+	}
+
+	if updates == nil {
+		updates = map[string]interface{}{}
+	}
+	updates["State"] = to
+
+	result := tx.Model(&db.Transaction{}).Where("id = ? AND state = ?", transaction.ID, transaction.State).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		var current db.Transaction
+		err = tx.First(&current, transaction.ID).Error
+		if err != nil {
+			return err
+		}
+		if current.State == to {
+			*transaction = current
+			return nil
+		}
+		return NewInvalidStateTransitionError(current.State, to)
+	}
+
+	return tx.First(transaction, transaction.ID).Error
+}
+
+func (svc *transactionsService) StartPaymentSweeper(ctx context.Context) {
+	go func() {
+		svc.sweepUnsentPayments(svc.interruptedPaymentsBefore())
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				logger.Logger.Info("Stopped payment sweeper")
+				return
+			case <-ticker.C:
+				svc.sweepUnsentPayments(time.Now().Add(-svc.paymentConfirmationTimeout() - inFlightPaymentTimeout))
+				svc.sweepInFlightPayments()
+			}
+		}
+	}()
+}
+
+// interruptedPaymentsBefore returns the time before which unsent payments were interrupted by a restart.
+// Their confirmations were only kept in memory, so nobody can confirm them anymore.
+// Other replicas may still be waiting for confirmations, so with replicas only
+// the payments whose confirmation timed out are interrupted for sure.
+func (svc *transactionsService) interruptedPaymentsBefore() time.Time {
+	if svc.cfg.GetEnv().LeaderElection {
+		return time.Now().Add(-svc.paymentConfirmationTimeout() - inFlightPaymentTimeout)
+	}
+	return svc.startedAt
+}
+
+// these payments were never sent (e.g. the hub was restarted before paying),
+// so they are failed to release their reserved amount.
+// Payments waiting for confirmation are reserved for up to the confirmation timeout.
+func (svc *transactionsService) sweepUnsentPayments(updatedBefore time.Time) {
+	var transactions []db.Transaction
+	err := svc.db.Where("type = ? AND state IN ? AND updated_at < ?", constants.TRANSACTION_TYPE_OUTGOING, []string{constants.TRANSACTION_STATE_CREATED, constants.TRANSACTION_STATE_RESERVED}, updatedBefore).Find(&transactions).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list unsent payments")
+		return
+	}
+
+	for _, transaction := range transactions {
+		err := transitionState(svc.db, &transaction, constants.TRANSACTION_STATE_FAILED, map[string]interface{}{
+			"FeeReserveMsat": 0,
+		})
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"id":           transaction.ID,
+				"payment_hash": transaction.PaymentHash,
+			}).WithError(err).Error("Failed to mark payment as failed")
+			continue
+		}
+		logger.Logger.WithFields(logrus.Fields{
+			"id":           transaction.ID,
+			"payment_hash": transaction.PaymentHash,
+		}).Warn("Marked unsent payment as failed")
+	}
+}
+
+// the result of these payments is unknown (e.g. the hub was restarted while paying).
+// They keep their fee reserve until the node reports the payment as settled or failed.
+func (svc *transactionsService) sweepInFlightPayments() {
+	var transactions []db.Transaction
+	err := svc.db.Where("type = ? AND state = ? AND updated_at < ?", constants.TRANSACTION_TYPE_OUTGOING, constants.TRANSACTION_STATE_IN_FLIGHT, time.Now().Add(-inFlightPaymentTimeout)).Find(&transactions).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list in flight payments")
+		return
+	}
+
+	for _, transaction := range transactions {
+		err := transitionState(svc.db, &transaction, constants.TRANSACTION_STATE_TIMED_OUT, nil)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"id":           transaction.ID,
+				"payment_hash": transaction.PaymentHash,
+			}).WithError(err).Error("Failed to mark payment as timed out")
+			continue
+		}
+		logger.Logger.WithFields(logrus.Fields{
+			"id":           transaction.ID,
+			"payment_hash": transaction.PaymentHash,
+		}).Warn("Marked stuck in flight payment as timed out")
+	}
+}
@@ -0,0 +1,175 @@
+package transactions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransitionState_RejectsIllegalTransition(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	transaction := &db.Transaction{
+		State:       constants.TRANSACTION_STATE_SETTLED,
+		Type:        constants.TRANSACTION_TYPE_OUTGOING,
+		PaymentHash: tests.MockLNClientTransaction.PaymentHash,
+		AmountMsat:  123000,
+	}
+	svc.DB.Create(transaction)
+
+	err = transitionState(svc.DB, transaction, constants.TRANSACTION_STATE_FAILED, nil)
+	assert.Error(t, err)
+	assert.True(t, errors.As(err, new(*invalidStateTransitionError)))
+
+	svc.DB.First(transaction, transaction.ID)
+	assert.Equal(t, constants.TRANSACTION_STATE_SETTLED, transaction.State)
+}
+
+func TestTransitionState_StaleState(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	transaction := &db.Transaction{
+		State:       constants.TRANSACTION_STATE_IN_FLIGHT,
+		Type:        constants.TRANSACTION_TYPE_OUTGOING,
+		PaymentHash: tests.MockLNClientTransaction.PaymentHash,
+		AmountMsat:  123000,
+	}
+	svc.DB.Create(transaction)
+
+	// another process already failed the payment
+	staleTransaction := *transaction
+	err = transitionState(svc.DB, transaction, constants.TRANSACTION_STATE_FAILED, nil)
+	assert.NoError(t, err)
+
+	err = transitionState(svc.DB, &staleTransaction, constants.TRANSACTION_STATE_SETTLED, nil)
+	assert.Error(t, err)
+
+	// the same transition from a stale copy succeeds without changes
+	staleTransaction = db.Transaction{ID: transaction.ID, Type: transaction.Type, State: constants.TRANSACTION_STATE_IN_FLIGHT}
+	err = transitionState(svc.DB, &staleTransaction, constants.TRANSACTION_STATE_FAILED, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, constants.TRANSACTION_STATE_FAILED, staleTransaction.State)
+}
+
+func TestSweepInFlightPayments(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	stuckTransaction := &db.Transaction{
+		State:          constants.TRANSACTION_STATE_IN_FLIGHT,
+		Type:           constants.TRANSACTION_TYPE_OUTGOING,
+		PaymentHash:    "stuck",
+		AmountMsat:     123000,
+		FeeReserveMsat: 10000,
+	}
+	svc.DB.Create(stuckTransaction)
+	svc.DB.Model(stuckTransaction).UpdateColumn("updated_at", time.Now().Add(-2*inFlightPaymentTimeout))
+
+	recentTransaction := &db.Transaction{
+		State:       constants.TRANSACTION_STATE_IN_FLIGHT,
+		Type:        constants.TRANSACTION_TYPE_OUTGOING,
+		PaymentHash: "recent",
+		AmountMsat:  123000,
+	}
+	svc.DB.Create(recentTransaction)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	transactionsService.sweepInFlightPayments()
+
+	svc.DB.First(stuckTransaction, stuckTransaction.ID)
+	assert.Equal(t, constants.TRANSACTION_STATE_TIMED_OUT, stuckTransaction.State)
+	assert.Equal(t, uint64(10000), stuckTransaction.FeeReserveMsat)
+
+	svc.DB.First(recentTransaction, recentTransaction.ID)
+	assert.Equal(t, constants.TRANSACTION_STATE_IN_FLIGHT, recentTransaction.State)
+}
+
+func TestSweepUnsentPayments(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	staleUpdatedAt := time.Now().Add(-transactionsService.paymentConfirmationTimeout() - 2*inFlightPaymentTimeout)
+
+	createdTransaction := &db.Transaction{
+		State:          constants.TRANSACTION_STATE_CREATED,
+		Type:           constants.TRANSACTION_TYPE_OUTGOING,
+		PaymentHash:    "created",
+		AmountMsat:     123000,
+		FeeReserveMsat: 10000,
+	}
+	svc.DB.Create(createdTransaction)
+	svc.DB.Model(createdTransaction).UpdateColumn("updated_at", staleUpdatedAt)
+
+	reservedTransaction := &db.Transaction{
+		State:          constants.TRANSACTION_STATE_RESERVED,
+		Type:           constants.TRANSACTION_TYPE_OUTGOING,
+		PaymentHash:    "reserved",
+		AmountMsat:     123000,
+		FeeReserveMsat: 10000,
+	}
+	svc.DB.Create(reservedTransaction)
+	svc.DB.Model(reservedTransaction).UpdateColumn("updated_at", staleUpdatedAt)
+
+	// may still be waiting for confirmation
+	recentTransaction := &db.Transaction{
+		State:          constants.TRANSACTION_STATE_RESERVED,
+		Type:           constants.TRANSACTION_TYPE_OUTGOING,
+		PaymentHash:    "recent",
+		AmountMsat:     123000,
+		FeeReserveMsat: 10000,
+	}
+	svc.DB.Create(recentTransaction)
+
+	transactionsService.sweepUnsentPayments(time.Now().Add(-transactionsService.paymentConfirmationTimeout() - inFlightPaymentTimeout))
+
+	svc.DB.First(createdTransaction, createdTransaction.ID)
+	assert.Equal(t, constants.TRANSACTION_STATE_FAILED, createdTransaction.State)
+	assert.Equal(t, uint64(0), createdTransaction.FeeReserveMsat)
+
+	svc.DB.First(reservedTransaction, reservedTransaction.ID)
+	assert.Equal(t, constants.TRANSACTION_STATE_FAILED, reservedTransaction.State)
+	assert.Equal(t, uint64(0), reservedTransaction.FeeReserveMsat)
+
+	svc.DB.First(recentTransaction, recentTransaction.ID)
+	assert.Equal(t, constants.TRANSACTION_STATE_RESERVED, recentTransaction.State)
+	assert.Equal(t, uint64(10000), recentTransaction.FeeReserveMsat)
+}
+
+func TestInterruptedPaymentsBefore(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	// payments reserved before the restart are failed right away
+	reservedTransaction := &db.Transaction{
+		State:          constants.TRANSACTION_STATE_RESERVED,
+		Type:           constants.TRANSACTION_TYPE_OUTGOING,
+		PaymentHash:    "reserved",
+		AmountMsat:     123000,
+		FeeReserveMsat: 10000,
+	}
+	svc.DB.Create(reservedTransaction)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+	transactionsService.sweepUnsentPayments(transactionsService.interruptedPaymentsBefore())
+
+	svc.DB.First(reservedTransaction, reservedTransaction.ID)
+	assert.Equal(t, constants.TRANSACTION_STATE_FAILED, reservedTransaction.State)
+	assert.Equal(t, uint64(0), reservedTransaction.FeeReserveMsat)
+
+	// other replicas may still be waiting for their confirmations
+	svc.Cfg.GetEnv().LeaderElection = true
+	assert.True(t, transactionsService.interruptedPaymentsBefore().Before(time.Now().Add(-transactionsService.paymentConfirmationTimeout())))
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/getAlby/hub/config"
 	"github.com/getAlby/hub/constants"
 	"github.com/getAlby/hub/db"
 	"github.com/getAlby/hub/db/queries"
@@ -22,19 +23,41 @@ import (
 	decodepay "github.com/nbd-wtf/ln-decodepay"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type transactionsService struct {
-	db *gorm.DB
+	db             *gorm.DB
+	cfg            config.Config
+	eventPublisher events.EventPublisher
+	confirmations  paymentConfirmations
+	writeBatcher   *writeBatcher
+	startedAt      time.Time
 }
 
 type TransactionsService interface {
 	events.EventSubscriber
+	StartPaymentSweeper(ctx context.Context)
+	StartBudgetMonitor(ctx context.Context)
 	MakeInvoice(ctx context.Context, amount int64, description string, descriptionHash string, expiry int64, metadata interface{}, lnClient lnclient.LNClient, appId *uint, requestEventId *uint) (*Transaction, error)
+	MakeOffer(ctx context.Context, description string, lnClient lnclient.LNClient, appId *uint) (*db.Offer, error)
+	ListOffers(appId *uint) ([]db.Offer, error)
 	LookupTransaction(ctx context.Context, paymentHash string, transactionType *string, lnClient lnclient.LNClient, appId *uint) (*Transaction, error)
 	ListTransactions(ctx context.Context, from, until, limit, offset uint64, unpaid bool, transactionType *string, lnClient lnclient.LNClient, appId *uint) (transactions []Transaction, err error)
+	StreamTransactions(ctx context.Context, from, until, limit, offset uint64, cursor *TransactionsCursor, unpaid bool, transactionType *string, lnClient lnclient.LNClient, appId *uint, handle func(transaction *Transaction) bool) error
+	CountTransactions(from, until uint64, unpaid bool, transactionType *string, appId *uint) (int64, error)
 	SendPaymentSync(ctx context.Context, payReq string, lnClient lnclient.LNClient, appId *uint, requestEventId *uint) (*Transaction, error)
+	SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions, lnClient lnclient.LNClient, appId *uint, requestEventId *uint) (*Transaction, error)
 	SendKeysend(ctx context.Context, amount uint64, destination string, customRecords []lnclient.TLVRecord, preimage string, lnClient lnclient.LNClient, appId *uint, requestEventId *uint) (*Transaction, error)
+	ListPendingPaymentConfirmations() []PendingPaymentConfirmation
+	ConfirmPayment(transactionId uint, approved bool, totpCode string, encryptionKey string) error
+	// SearchTransactions returns the settled transactions whose description, note, keysend destination
+	// or app name contain all words of the query, newest first
+	SearchTransactions(query string, limit uint64, offset uint64) ([]Transaction, error)
+	// ExportTransactions passes the settled transactions after the cursor to handle, oldest settled first
+	ExportTransactions(since, until, limit uint64, cursor *ExportCursor, handle func(transaction *Transaction) bool) error
+	// SetTransactionNote replaces the private note of the transaction, an empty note removes it
+	SetTransactionNote(transactionId uint, note string) error
 }
 
 type Transaction = db.Transaction
@@ -72,9 +95,31 @@ func (err *quotaExceededError) Error() string {
 	return "Your wallet has exceeded its spending quota"
 }
 
-func NewTransactionsService(db *gorm.DB) *transactionsService {
+// outgoing payments to the same payment hash from different apps
+// within this window are reported as duplicates
+const duplicatePaymentWindow = 24 * time.Hour
+
+type duplicatePaymentError struct {
+}
+
+func NewDuplicatePaymentError() error {
+	return &duplicatePaymentError{}
+}
+
+func (err *duplicatePaymentError) Error() string {
+	return "This invoice is already being paid or was already paid by another app"
+}
+
+func NewTransactionsService(db *gorm.DB, cfg config.Config, eventPublisher events.EventPublisher) *transactionsService {
 	return &transactionsService{
-		db: db,
+		db:             db,
+		cfg:            cfg,
+		eventPublisher: eventPublisher,
+		confirmations: paymentConfirmations{
+			pending: map[uint]*PendingPaymentConfirmation{},
+		},
+		writeBatcher: newWriteBatcher(db),
+		startedAt:    time.Now(),
 	}
 }
 
@@ -132,6 +177,19 @@ func (svc *transactionsService) MakeInvoice(ctx context.Context, amount int64, d
 }
 
 func (svc *transactionsService) SendPaymentSync(ctx context.Context, payReq string, lnClient lnclient.LNClient, appId *uint, requestEventId *uint) (*Transaction, error) {
+	return svc.sendPaymentSync(ctx, payReq, nil, lnClient, appId, requestEventId)
+}
+
+// the individual parts reported by the node are stored as transaction parts,
+// the transaction itself holds the total amount and fee of the payment
+func (svc *transactionsService) SendMultiPartPaymentSync(ctx context.Context, payReq string, options *lnclient.MultiPartPaymentOptions, lnClient lnclient.LNClient, appId *uint, requestEventId *uint) (*Transaction, error) {
+	if options == nil {
+		options = &lnclient.MultiPartPaymentOptions{}
+	}
+	return svc.sendPaymentSync(ctx, payReq, options, lnClient, appId, requestEventId)
+}
+
+func (svc *transactionsService) sendPaymentSync(ctx context.Context, payReq string, multiPartOptions *lnclient.MultiPartPaymentOptions, lnClient lnclient.LNClient, appId *uint, requestEventId *uint) (*Transaction, error) {
 	payReq = strings.ToLower(payReq)
 	paymentRequest, err := decodepay.Decodepay(payReq)
 	if err != nil {
@@ -145,13 +203,24 @@ func (svc *transactionsService) SendPaymentSync(ctx context.Context, payReq stri
 	selfPayment := paymentRequest.Payee != "" && paymentRequest.Payee == lnClient.GetPubkey()
 
 	var dbTransaction db.Transaction
+	var duplicateTransaction *db.Transaction
+	duplicateBlocked := false
 
-	err = svc.db.Transaction(func(tx *gorm.DB) error {
-		err := svc.validateCanPay(tx, appId, uint64(paymentRequest.MSatoshi))
+	err = svc.writeBatcher.do(func(tx *gorm.DB) error {
+		appPermission, err := svc.validateCanPay(tx, appId, uint64(paymentRequest.MSatoshi))
 		if err != nil {
 			return err
 		}
 
+		duplicateTransaction, err = svc.findDuplicatePayment(tx, appId, paymentRequest.PaymentHash)
+		if err != nil {
+			return err
+		}
+		duplicateBlocked = duplicateTransaction != nil && svc.cfg.GetEnv().BlockDuplicatePayments
+		if duplicateBlocked {
+			return NewDuplicatePaymentError()
+		}
+
 		var expiresAt *time.Time
 		if paymentRequest.Expiry > 0 {
 			expiresAtValue := time.Now().Add(time.Duration(paymentRequest.Expiry) * time.Second)
@@ -161,7 +230,7 @@ func (svc *transactionsService) SendPaymentSync(ctx context.Context, payReq stri
 			AppId:           appId,
 			RequestEventId:  requestEventId,
 			Type:            constants.TRANSACTION_TYPE_OUTGOING,
-			State:           constants.TRANSACTION_STATE_PENDING,
+			State:           constants.TRANSACTION_STATE_CREATED,
 			FeeReserveMsat:  svc.calculateFeeReserveMsat(uint64(paymentRequest.MSatoshi)),
 			AmountMsat:      uint64(paymentRequest.MSatoshi),
 			PaymentRequest:  payReq,
@@ -173,9 +242,19 @@ func (svc *transactionsService) SendPaymentSync(ctx context.Context, payReq stri
 			// Metadata:       metadata,
 		}
 		err = tx.Create(&dbTransaction).Error
-		return err
+		if err != nil {
+			return err
+		}
+		return svc.reserveTransaction(tx, &dbTransaction, appPermission)
 	})
 
+	if duplicateTransaction != nil {
+		svc.publishDuplicatePayment(duplicateTransaction, appId, uint64(paymentRequest.MSatoshi), duplicateBlocked)
+	}
+	if errors.Is(err, NewQuotaExceededError()) {
+		svc.publishBudgetExceeded(appId, uint64(paymentRequest.MSatoshi))
+	}
+
 	if err != nil {
 		logger.Logger.WithFields(logrus.Fields{
 			"bolt11": payReq,
@@ -183,9 +262,43 @@ func (svc *transactionsService) SendPaymentSync(ctx context.Context, payReq stri
 		return nil, err
 	}
 
+	err = svc.awaitConfirmation(ctx, &dbTransaction)
+	if err != nil {
+		dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_FAILED, map[string]interface{}{
+			"FeeReserveMsat": 0,
+		})
+		if dbErr != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"bolt11": payReq,
+			}).WithError(dbErr).Error("Failed to update DB transaction")
+		}
+		return nil, err
+	}
+
+	err = svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_IN_FLIGHT, nil)
+	if err != nil {
+		logger.Logger.WithFields(logrus.Fields{
+			"bolt11": payReq,
+		}).WithError(err).Error("Failed to mark payment as in flight")
+		dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_FAILED, map[string]interface{}{
+			"FeeReserveMsat": 0,
+		})
+		if dbErr != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"bolt11": payReq,
+			}).WithError(dbErr).Error("Failed to update DB transaction")
+		}
+		return nil, err
+	}
+
 	var response *lnclient.PayInvoiceResponse
 	if selfPayment {
 		response, err = svc.interceptSelfPayment(paymentRequest.PaymentHash)
+	} else if multiPartOptions != nil {
+		// the fee reserve caps the fees of all parts combined
+		options := *multiPartOptions
+		options.FeeLimitMsat = dbTransaction.FeeReserveMsat
+		response, err = lnClient.SendMultiPartPaymentSync(ctx, payReq, &options)
 	} else {
 		response, err = lnClient.SendPaymentSync(ctx, payReq)
 	}
@@ -198,17 +311,22 @@ func (svc *transactionsService) SendPaymentSync(ctx context.Context, payReq stri
 		if errors.Is(err, lnclient.NewTimeoutError()) {
 			logger.Logger.WithFields(logrus.Fields{
 				"bolt11": payReq,
-			}).WithError(err).Error("Timed out waiting for payment to be sent. It may still succeed. Skipping update of transaction status")
+			}).WithError(err).Error("Timed out waiting for payment to be sent. It may still succeed. Keeping fee reserve until the payment is settled or failed")
 			// we cannot update the payment to failed as it still might succeed.
 			// we'll need to check the status of it later
+			dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_TIMED_OUT, nil)
+			if dbErr != nil {
+				logger.Logger.WithFields(logrus.Fields{
+					"bolt11": payReq,
+				}).WithError(dbErr).Error("Failed to update DB transaction")
+			}
 			return nil, err
 		}
 
 		// As the LNClient did not return a timeout error, we assume the payment definitely failed
-		dbErr := svc.db.Model(&dbTransaction).Updates(map[string]interface{}{
-			"State":          constants.TRANSACTION_STATE_FAILED,
+		dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_FAILED, map[string]interface{}{
 			"FeeReserveMsat": 0,
-		}).Error
+		})
 		if dbErr != nil {
 			logger.Logger.WithFields(logrus.Fields{
 				"bolt11": payReq,
@@ -220,19 +338,37 @@ func (svc *transactionsService) SendPaymentSync(ctx context.Context, payReq stri
 
 	// the payment definitely succeeded
 	now := time.Now()
-	dbErr := svc.db.Model(&dbTransaction).Updates(map[string]interface{}{
-		"State":          constants.TRANSACTION_STATE_SETTLED,
+	dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_SETTLED, map[string]interface{}{
 		"Preimage":       &response.Preimage,
 		"FeeMsat":        response.Fee,
 		"FeeReserveMsat": 0,
 		"SettledAt":      &now,
-	}).Error
+	})
 	if dbErr != nil {
 		logger.Logger.WithFields(logrus.Fields{
 			"bolt11": payReq,
 		}).WithError(dbErr).Error("Failed to update DB transaction")
 	}
 
+	if len(response.Parts) > 0 {
+		transactionParts := make([]db.TransactionPart, 0, len(response.Parts))
+		for _, part := range response.Parts {
+			transactionParts = append(transactionParts, db.TransactionPart{
+				TransactionId: dbTransaction.ID,
+				AmountMsat:    part.AmountMsat,
+				FeeMsat:       part.FeeMsat,
+			})
+		}
+		dbErr = svc.writeBatcher.do(func(tx *gorm.DB) error {
+			return tx.Create(&transactionParts).Error
+		})
+		if dbErr != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"bolt11": payReq,
+			}).WithError(dbErr).Error("Failed to save transaction parts")
+		}
+	}
+
 	// TODO: check the fields are updated here
 	return &dbTransaction, nil
 }
@@ -273,8 +409,8 @@ func (svc *transactionsService) SendKeysend(ctx context.Context, amount uint64,
 
 	var dbTransaction db.Transaction
 
-	err = svc.db.Transaction(func(tx *gorm.DB) error {
-		err := svc.validateCanPay(tx, appId, amount)
+	err = svc.writeBatcher.do(func(tx *gorm.DB) error {
+		appPermission, err := svc.validateCanPay(tx, appId, amount)
 		if err != nil {
 			return err
 		}
@@ -283,7 +419,7 @@ func (svc *transactionsService) SendKeysend(ctx context.Context, amount uint64,
 			AppId:          appId,
 			RequestEventId: requestEventId,
 			Type:           constants.TRANSACTION_TYPE_OUTGOING,
-			State:          constants.TRANSACTION_STATE_PENDING,
+			State:          constants.TRANSACTION_STATE_CREATED,
 			FeeReserveMsat: svc.calculateFeeReserveMsat(uint64(amount)),
 			AmountMsat:     amount,
 			Metadata:       string(metadataBytes),
@@ -291,10 +427,17 @@ func (svc *transactionsService) SendKeysend(ctx context.Context, amount uint64,
 			Preimage:       &preimage,
 		}
 		err = tx.Create(&dbTransaction).Error
+		if err != nil {
+			return err
+		}
 
-		return err
+		return svc.reserveTransaction(tx, &dbTransaction, appPermission)
 	})
 
+	if errors.Is(err, NewQuotaExceededError()) {
+		svc.publishBudgetExceeded(appId, amount)
+	}
+
 	if err != nil {
 		logger.Logger.WithFields(logrus.Fields{
 			"destination": destination,
@@ -303,6 +446,38 @@ func (svc *transactionsService) SendKeysend(ctx context.Context, amount uint64,
 		return nil, err
 	}
 
+	err = svc.awaitConfirmation(ctx, &dbTransaction)
+	if err != nil {
+		dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_FAILED, map[string]interface{}{
+			"FeeReserveMsat": 0,
+		})
+		if dbErr != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"destination": destination,
+				"amount":      amount,
+			}).WithError(dbErr).Error("Failed to update DB transaction")
+		}
+		return nil, err
+	}
+
+	err = svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_IN_FLIGHT, nil)
+	if err != nil {
+		logger.Logger.WithFields(logrus.Fields{
+			"destination": destination,
+			"amount":      amount,
+		}).WithError(err).Error("Failed to mark payment as in flight")
+		dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_FAILED, map[string]interface{}{
+			"FeeReserveMsat": 0,
+		})
+		if dbErr != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"destination": destination,
+				"amount":      amount,
+			}).WithError(dbErr).Error("Failed to update DB transaction")
+		}
+		return nil, err
+	}
+
 	payKeysendResponse, err := lnClient.SendKeysend(ctx, amount, destination, customRecords, preimage)
 
 	if err != nil {
@@ -320,10 +495,7 @@ func (svc *transactionsService) SendKeysend(ctx context.Context, amount uint64,
 
 			// we cannot update the payment to failed as it still might succeed.
 			// we'll need to check the status of it later
-			// but we have the payment hash now, so save it on the transaction
-			dbErr := svc.db.Model(&dbTransaction).Updates(&db.Transaction{
-				PaymentHash: paymentHash,
-			}).Error
+			dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_TIMED_OUT, nil)
 			if dbErr != nil {
 				logger.Logger.WithFields(logrus.Fields{
 					"destination": destination,
@@ -334,10 +506,9 @@ func (svc *transactionsService) SendKeysend(ctx context.Context, amount uint64,
 		}
 
 		// As the LNClient did not return a timeout error, we assume the payment definitely failed
-		dbErr := svc.db.Model(&dbTransaction).Updates(&db.Transaction{
-			PaymentHash: paymentHash,
-			State:       constants.TRANSACTION_STATE_FAILED,
-		}).Error
+		dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_FAILED, map[string]interface{}{
+			"FeeReserveMsat": 0,
+		})
 		if dbErr != nil {
 			logger.Logger.WithFields(logrus.Fields{
 				"destination": destination,
@@ -350,12 +521,11 @@ func (svc *transactionsService) SendKeysend(ctx context.Context, amount uint64,
 
 	// the payment definitely succeeded
 	now := time.Now()
-	dbErr := svc.db.Model(&dbTransaction).Updates(map[string]interface{}{
-		"State":          constants.TRANSACTION_STATE_SETTLED,
+	dbErr := svc.batchedTransitionState(&dbTransaction, constants.TRANSACTION_STATE_SETTLED, map[string]interface{}{
 		"FeeMsat":        &payKeysendResponse.Fee,
 		"FeeReserveMsat": 0,
 		"SettledAt":      &now,
-	}).Error
+	})
 	if dbErr != nil {
 		logger.Logger.WithFields(logrus.Fields{
 			"destination": destination,
@@ -378,12 +548,12 @@ func (svc *transactionsService) LookupTransaction(ctx context.Context, paymentHa
 			ID: *appId,
 		})
 		if app.Isolated {
-			tx = tx.Where("app_id == ?", *appId)
+			tx = tx.Where("app_id = ?", *appId)
 		}
 	}
 
 	if transactionType != nil {
-		tx = tx.Where("type == ?", *transactionType)
+		tx = tx.Where("type = ?", *transactionType)
 	}
 
 	// order settled first, otherwise by created date, as there can be multiple outgoing payments
@@ -417,16 +587,85 @@ func (svc *transactionsService) ListTransactions(ctx context.Context, from, unti
 	svc.checkUnsettledTransactions(ctx, lnClient)
 
 	// TODO: add other filtering and pagination
-	tx := svc.db
+	tx := svc.filterTransactions(from, until, unpaid, transactionType, appId)
 
 	tx = tx.Order("created_at desc")
 
+	if limit > 0 {
+		tx = tx.Limit(int(limit))
+	}
+	if offset > 0 {
+		tx = tx.Offset(int(offset))
+	}
+
+	result := tx.Find(&transactions)
+	if result.Error != nil {
+		logger.Logger.WithError(result.Error).Error("Failed to list DB transactions")
+		return nil, result.Error
+	}
+
+	return transactions, nil
+}
+
+// StreamTransactions passes the transactions after the cursor one by one to handle, until handle returns false.
+// Unlike ListTransactions the transactions are not all loaded into memory at once.
+func (svc *transactionsService) StreamTransactions(ctx context.Context, from, until, limit, offset uint64, cursor *TransactionsCursor, unpaid bool, transactionType *string, lnClient lnclient.LNClient, appId *uint, handle func(transaction *Transaction) bool) error {
+	svc.checkUnsettledTransactions(ctx, lnClient)
+
+	tx := svc.filterTransactions(from, until, unpaid, transactionType, appId)
+
+	// the id keeps the order stable for transactions created at the same time
+	tx = tx.Order("created_at desc").Order("id desc")
+
+	if cursor != nil {
+		tx = tx.Where("(created_at < ? OR (created_at = ? AND id < ?))", cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+	} else if offset > 0 {
+		tx = tx.Offset(int(offset))
+	}
+	if limit > 0 {
+		tx = tx.Limit(int(limit))
+	}
+
+	rows, err := tx.Model(&db.Transaction{}).Rows()
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to list DB transactions")
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transaction Transaction
+		err = svc.db.ScanRows(rows, &transaction)
+		if err != nil {
+			logger.Logger.WithError(err).Error("Failed to scan DB transaction")
+			return err
+		}
+		if !handle(&transaction) {
+			break
+		}
+	}
+	return rows.Err()
+}
+
+func (svc *transactionsService) CountTransactions(from, until uint64, unpaid bool, transactionType *string, appId *uint) (int64, error) {
+	var count int64
+	err := svc.filterTransactions(from, until, unpaid, transactionType, appId).Model(&db.Transaction{}).Count(&count).Error
+	if err != nil {
+		logger.Logger.WithError(err).Error("Failed to count DB transactions")
+		return 0, err
+	}
+	return count, nil
+}
+
+func (svc *transactionsService) filterTransactions(from, until uint64, unpaid bool, transactionType *string, appId *uint) *gorm.DB {
+	tx := svc.db
+
 	if !unpaid {
-		tx = tx.Where("state == ?", constants.TRANSACTION_STATE_SETTLED)
+		tx = tx.Where("state = ?", constants.TRANSACTION_STATE_SETTLED)
 	}
 
 	if transactionType != nil {
-		tx = tx.Where("type == ?", *transactionType)
+		tx = tx.Where("type = ?", *transactionType)
 	}
 
 	if from > 0 {
@@ -436,33 +675,17 @@ func (svc *transactionsService) ListTransactions(ctx context.Context, from, unti
 		tx = tx.Where("created_at <= ?", time.Unix(int64(until), 0))
 	}
 
-	if limit > 0 {
-		tx = tx.Limit(int(limit))
-	}
-	if offset > 0 {
-		tx = tx.Offset(int(limit))
-	}
-
 	if appId != nil {
 		var app db.App
 		svc.db.Find(&app, &db.App{
 			ID: *appId,
 		})
 		if app.Isolated {
-			tx = tx.Where("app_id == ?", *appId)
+			tx = tx.Where("app_id = ?", *appId)
 		}
 	}
 
-	if limit != 0 {
-		tx = tx.Limit(int(limit))
-	}
-	result := tx.Find(&transactions)
-	if result.Error != nil {
-		logger.Logger.WithError(result.Error).Error("Failed to list DB transactions")
-		return nil, result.Error
-	}
-
-	return transactions, nil
+	return tx
 }
 
 func (svc *transactionsService) checkUnsettledTransactions(ctx context.Context, lnClient lnclient.LNClient) {
@@ -474,7 +697,7 @@ func (svc *transactionsService) checkUnsettledTransactions(ctx context.Context,
 
 	// check pending payments less than a day old
 	transactions := []Transaction{}
-	result := svc.db.Where("state == ? AND created_at > ?", constants.TRANSACTION_STATE_PENDING, time.Now().Add(-24*time.Hour)).Find(&transactions)
+	result := svc.db.Where("type = ? AND state = ? AND created_at > ?", constants.TRANSACTION_TYPE_INCOMING, constants.TRANSACTION_STATE_PENDING, time.Now().Add(-24*time.Hour)).Find(&transactions)
 	if result.Error != nil {
 		logger.Logger.WithError(result.Error).Error("Failed to list DB transactions")
 		return
@@ -499,13 +722,12 @@ func (svc *transactionsService) checkUnsettledTransaction(ctx context.Context, t
 	if lnClientTransaction.SettledAt != nil {
 		// the payment definitely succeeded
 		now := time.Now()
-		dbErr := svc.db.Model(transaction).Updates(map[string]interface{}{
-			"State":          constants.TRANSACTION_STATE_SETTLED,
+		dbErr := transitionState(svc.db, transaction, constants.TRANSACTION_STATE_SETTLED, map[string]interface{}{
 			"Preimage":       &lnClientTransaction.Preimage,
 			"FeeMsat":        lnClientTransaction.FeesPaid,
 			"FeeReserveMsat": 0,
 			"SettledAt":      &now,
-		}).Error
+		})
 		if dbErr != nil {
 			logger.Logger.WithFields(logrus.Fields{
 				"bolt11": transaction.PaymentRequest,
@@ -532,7 +754,7 @@ func (svc *transactionsService) ConsumeEvent(ctx context.Context, event *events.
 			})
 
 			if result.RowsAffected == 0 {
-				// Note: brand new payments cannot be associated with an app
+				// Note: brand new payments cannot be associated with an app, unless they were received for an offer of the app
 				var metadata string
 				if lnClientTransaction.Metadata != nil {
 					metadataBytes, err := json.Marshal(lnClientTransaction.Metadata)
@@ -549,6 +771,7 @@ func (svc *transactionsService) ConsumeEvent(ctx context.Context, event *events.
 				}
 				dbTransaction = db.Transaction{
 					Type:            constants.TRANSACTION_TYPE_INCOMING,
+					State:           constants.TRANSACTION_STATE_PENDING,
 					AmountMsat:      uint64(lnClientTransaction.Amount),
 					PaymentRequest:  lnClientTransaction.Invoice,
 					PaymentHash:     lnClientTransaction.PaymentHash,
@@ -557,6 +780,20 @@ func (svc *transactionsService) ConsumeEvent(ctx context.Context, event *events.
 					ExpiresAt:       expiresAt,
 					Metadata:        metadata,
 				}
+				if lnClientTransaction.OfferId != "" {
+					var offer db.Offer
+					offerResult := tx.Limit(1).Find(&offer, &db.Offer{Bolt12OfferId: lnClientTransaction.OfferId})
+					if offerResult.Error != nil {
+						return offerResult.Error
+					}
+					if offerResult.RowsAffected > 0 {
+						dbTransaction.OfferId = &offer.ID
+						dbTransaction.AppId = offer.AppId
+						if dbTransaction.Description == "" {
+							dbTransaction.Description = offer.Description
+						}
+					}
+				}
 				err := tx.Create(&dbTransaction).Error
 				if err != nil {
 					logger.Logger.WithFields(logrus.Fields{
@@ -568,12 +805,11 @@ func (svc *transactionsService) ConsumeEvent(ctx context.Context, event *events.
 
 			settledAt := time.Now()
 
-			err := tx.Model(&dbTransaction).Updates(map[string]interface{}{
+			err := transitionState(tx, &dbTransaction, constants.TRANSACTION_STATE_SETTLED, map[string]interface{}{
 				"FeeMsat":   lnClientTransaction.FeesPaid,
 				"Preimage":  &lnClientTransaction.Preimage,
-				"State":     constants.TRANSACTION_STATE_SETTLED,
 				"SettledAt": &settledAt,
-			}).Error
+			})
 			if err != nil {
 				logger.Logger.WithFields(logrus.Fields{
 					"payment_hash": lnClientTransaction.PaymentHash,
@@ -600,13 +836,18 @@ func (svc *transactionsService) ConsumeEvent(ctx context.Context, event *events.
 
 		var dbTransaction db.Transaction
 		err := svc.db.Transaction(func(tx *gorm.DB) error {
-			result := tx.Find(&dbTransaction, &db.Transaction{
-				Type:        constants.TRANSACTION_TYPE_OUTGOING,
-				PaymentHash: lnClientTransaction.PaymentHash,
-			})
+			// ignore earlier failed attempts to pay the same invoice
+			result := tx.
+				Where("state IN ?", []string{constants.TRANSACTION_STATE_IN_FLIGHT, constants.TRANSACTION_STATE_TIMED_OUT, constants.TRANSACTION_STATE_SETTLED}).
+				Order("created_at desc").
+				Limit(1).
+				Find(&dbTransaction, &db.Transaction{
+					Type:        constants.TRANSACTION_TYPE_OUTGOING,
+					PaymentHash: lnClientTransaction.PaymentHash,
+				})
 
 			if result.RowsAffected == 0 {
-				// Note: brand new payments cannot be associated with an app
+				// Note: brand new payments cannot be associated with an app, unless they were received for an offer of the app
 				var metadata string
 				if lnClientTransaction.Metadata != nil {
 					metadataBytes, err := json.Marshal(lnClientTransaction.Metadata)
@@ -621,8 +862,10 @@ func (svc *transactionsService) ConsumeEvent(ctx context.Context, event *events.
 					expiresAtValue := time.Unix(*lnClientTransaction.ExpiresAt, 0)
 					expiresAt = &expiresAtValue
 				}
+				// payments made outside of the hub were in flight until now
 				dbTransaction = db.Transaction{
 					Type:            constants.TRANSACTION_TYPE_OUTGOING,
+					State:           constants.TRANSACTION_STATE_IN_FLIGHT,
 					AmountMsat:      uint64(lnClientTransaction.Amount),
 					PaymentRequest:  lnClientTransaction.Invoice,
 					PaymentHash:     lnClientTransaction.PaymentHash,
@@ -631,6 +874,20 @@ func (svc *transactionsService) ConsumeEvent(ctx context.Context, event *events.
 					ExpiresAt:       expiresAt,
 					Metadata:        metadata,
 				}
+				if lnClientTransaction.OfferId != "" {
+					var offer db.Offer
+					offerResult := tx.Limit(1).Find(&offer, &db.Offer{Bolt12OfferId: lnClientTransaction.OfferId})
+					if offerResult.Error != nil {
+						return offerResult.Error
+					}
+					if offerResult.RowsAffected > 0 {
+						dbTransaction.OfferId = &offer.ID
+						dbTransaction.AppId = offer.AppId
+						if dbTransaction.Description == "" {
+							dbTransaction.Description = offer.Description
+						}
+					}
+				}
 				err := tx.Create(&dbTransaction).Error
 				if err != nil {
 					logger.Logger.WithFields(logrus.Fields{
@@ -641,14 +898,12 @@ func (svc *transactionsService) ConsumeEvent(ctx context.Context, event *events.
 			}
 
 			settledAt := time.Now()
-			err := tx.Model(&dbTransaction).Updates(map[string]interface{}{
+			return transitionState(tx, &dbTransaction, constants.TRANSACTION_STATE_SETTLED, map[string]interface{}{
 				"FeeMsat":        lnClientTransaction.FeesPaid,
 				"FeeReserveMsat": 0,
 				"Preimage":       &lnClientTransaction.Preimage,
-				"State":          constants.TRANSACTION_STATE_SETTLED,
 				"SettledAt":      &settledAt,
-			}).Error
-			return err
+			})
 		})
 
 		if err != nil {
@@ -669,22 +924,24 @@ func (svc *transactionsService) ConsumeEvent(ctx context.Context, event *events.
 		lnClientTransaction := paymentFailedAsyncProperties.Transaction
 
 		var dbTransaction db.Transaction
-		result := svc.db.Find(&dbTransaction, &db.Transaction{
-			Type:        constants.TRANSACTION_TYPE_OUTGOING,
-			PaymentHash: lnClientTransaction.PaymentHash,
-		})
+		result := svc.db.
+			Where("state IN ?", []string{constants.TRANSACTION_STATE_IN_FLIGHT, constants.TRANSACTION_STATE_TIMED_OUT}).
+			Order("created_at desc").
+			Limit(1).
+			Find(&dbTransaction, &db.Transaction{
+				Type:        constants.TRANSACTION_TYPE_OUTGOING,
+				PaymentHash: lnClientTransaction.PaymentHash,
+			})
 
-		// Note: this will happen for keysend payments since our transaction entry will not have a payment
-		// hash at this point
+		// Note: this will also happen if the payment was already marked as failed
 		if result.RowsAffected == 0 {
 			logger.Logger.WithField("event", event).Error("Failed to find outgoing transaction by payment hash")
 			return
 		}
 
-		err := svc.db.Model(&dbTransaction).Updates(map[string]interface{}{
-			"State":          constants.TRANSACTION_STATE_FAILED,
+		err := transitionState(svc.db, &dbTransaction, constants.TRANSACTION_STATE_FAILED, map[string]interface{}{
 			"FeeReserveMsat": 0,
-		}).Error
+		})
 		if err != nil {
 			logger.Logger.WithFields(logrus.Fields{
 				"payment_hash": lnClientTransaction.PaymentHash,
@@ -716,11 +973,10 @@ func (svc *transactionsService) interceptSelfPayment(paymentHash string) (*lncli
 
 	// update the incoming transaction
 	now := time.Now()
-	err := svc.db.Model(&incomingTransaction).Updates(map[string]interface{}{
-		"State":       constants.TRANSACTION_STATE_SETTLED,
+	err := transitionState(svc.db, &incomingTransaction, constants.TRANSACTION_STATE_SETTLED, map[string]interface{}{
 		"SettledAt":   &now,
 		"SelfPayment": true,
-	}).Error
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -733,7 +989,8 @@ func (svc *transactionsService) interceptSelfPayment(paymentHash string) (*lncli
 	}, nil
 }
 
-func (svc *transactionsService) validateCanPay(tx *gorm.DB, appId *uint, amount uint64) error {
+// validateCanPay returns the pay_invoice permission of the app, if the payment is made by an app
+func (svc *transactionsService) validateCanPay(tx *gorm.DB, appId *uint, amount uint64) (*db.AppPermission, error) {
 	amountWithFeeReserve := amount + svc.calculateFeeReserveMsat(amount)
 
 	// ensure balance for isolated apps
@@ -743,31 +1000,119 @@ func (svc *transactionsService) validateCanPay(tx *gorm.DB, appId *uint, amount
 			ID: *appId,
 		})
 		var appPermission db.AppPermission
-		result := tx.Find(&appPermission, &db.AppPermission{
+		query := tx
+		if db.IsPostgres(tx) {
+			// replicas that share the database reserve payments of the same app one after another,
+			// sqlite only has one writer at a time anyway
+			query = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		result := query.Find(&appPermission, &db.AppPermission{
 			AppId: *appId,
 			Scope: constants.PAY_INVOICE_SCOPE,
 		})
+		if result.Error != nil {
+			return nil, result.Error
+		}
 		if result.RowsAffected == 0 {
-			return errors.New("app does not have pay_invoice scope")
+			return nil, errors.New("app does not have pay_invoice scope")
 		}
+		appPermission.App = app
 
 		if app.Isolated {
 			balance := queries.GetIsolatedBalance(tx, appPermission.AppId)
 
 			if amountWithFeeReserve > balance {
-				return NewInsufficientBalanceError()
+				return nil, NewInsufficientBalanceError()
 			}
 		}
 
 		if appPermission.MaxAmountSat > 0 {
 			budgetUsageSat := queries.GetBudgetUsageSat(tx, &appPermission)
 			if int(amountWithFeeReserve/1000) > appPermission.MaxAmountSat-int(budgetUsageSat) {
-				return NewQuotaExceededError()
+				return nil, NewQuotaExceededError()
 			}
 		}
+		return &appPermission, nil
 	}
 
-	return nil
+	return nil, nil
+}
+
+// reserveTransaction moves the created payment to reserved. For apps with a budget or an
+// isolated balance, the budget and balance are checked again by the update itself, so
+// payments of the same app that all passed validateCanPay cannot overspend together, even
+// if they were validated against the same usage.
+func (svc *transactionsService) reserveTransaction(tx *gorm.DB, transaction *db.Transaction, appPermission *db.AppPermission) error {
+	if appPermission == nil || (appPermission.MaxAmountSat == 0 && !appPermission.App.Isolated) {
+		return transitionState(tx, transaction, constants.TRANSACTION_STATE_RESERVED, nil)
+	}
+
+	amountWithFeeReserve := transaction.AmountMsat + transaction.FeeReserveMsat
+	query := tx.Model(&db.Transaction{}).Where("id = ? AND state = ?", transaction.ID, constants.TRANSACTION_STATE_CREATED)
+	if appPermission.App.Isolated {
+		query = query.Where("? <= (?)", amountWithFeeReserve, queries.IsolatedBalanceMsatQuery(tx, appPermission.AppId))
+	}
+	if appPermission.MaxAmountSat > 0 {
+		// rounded down to sats like in validateCanPay
+		query = query.Where("? <= ? - (?) / 1000", amountWithFeeReserve/1000, appPermission.MaxAmountSat, queries.BudgetUsageMsatQuery(tx, appPermission))
+	}
+	result := query.Update("state", constants.TRANSACTION_STATE_RESERVED)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// tell the app which limit the payment exceeds
+		_, err := svc.validateCanPay(tx, transaction.AppId, transaction.AmountMsat)
+		if err != nil {
+			return err
+		}
+		return NewQuotaExceededError()
+	}
+
+	return tx.First(transaction, transaction.ID).Error
+}
+
+// shared invoices can easily be paid by more than one app by accident
+func (svc *transactionsService) findDuplicatePayment(tx *gorm.DB, appId *uint, paymentHash string) (*db.Transaction, error) {
+	var existingTransaction db.Transaction
+	query := tx.
+		Where("type = ? AND payment_hash = ? AND state IN ? AND created_at > ?",
+			constants.TRANSACTION_TYPE_OUTGOING,
+			paymentHash,
+			constants.OUTGOING_TRANSACTION_RESERVED_STATES,
+			time.Now().Add(-duplicatePaymentWindow))
+	// payments of the main wallet have no app
+	if appId == nil {
+		query = query.Where("app_id IS NOT NULL")
+	} else {
+		query = query.Where("(app_id IS NULL OR app_id <> ?)", *appId)
+	}
+	result := query.Limit(1).Find(&existingTransaction)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &existingTransaction, nil
+}
+
+func (svc *transactionsService) publishDuplicatePayment(existingTransaction *db.Transaction, appId *uint, amountMsat uint64, blocked bool) {
+	logger.Logger.WithFields(logrus.Fields{
+		"payment_hash":    existingTransaction.PaymentHash,
+		"app_id":          appId,
+		"existing_app_id": existingTransaction.AppId,
+		"blocked":         blocked,
+	}).Warn("Detected duplicate payment of the same invoice by different apps")
+
+	svc.eventPublisher.Publish(&events.Event{
+		Event: "nwc_duplicate_payment_detected",
+		Properties: map[string]interface{}{
+			"payment_hash": existingTransaction.PaymentHash,
+			"amount":       amountMsat / 1000,
+			"blocked":      blocked,
+		},
+	})
 }
 
 // max of 1% or 10000 millisats (10 sats)
@@ -784,3 +1129,22 @@ func makePreimageHex() ([]byte, error) {
 	}
 	return bytes, nil
 }
+
+// published outside of the batched write, subscribers are free to query the database
+func (svc *transactionsService) publishBudgetExceeded(appId *uint, amountMsat uint64) {
+	var app db.App
+	err := svc.db.Find(&app, *appId).Error
+	if err != nil {
+		logger.Logger.WithError(err).WithField("app_id", *appId).Error("Failed to find app")
+		return
+	}
+
+	svc.eventPublisher.Publish(&events.Event{
+		Event: "nwc_budget_exceeded",
+		Properties: map[string]interface{}{
+			"app_id":   app.ID,
+			"app_name": app.Name,
+			"amount":   amountMsat / 1000,
+		},
+	})
+}
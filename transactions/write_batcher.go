@@ -0,0 +1,101 @@
+package transactions
+
+import (
+	"sync"
+	"time"
+
+	"github.com/getAlby/hub/db"
+	"gorm.io/gorm"
+)
+
+const (
+	maxBatchedWrites = 100
+	// how long to wait for more writes before committing a batch
+	batchWindow = 2 * time.Millisecond
+)
+
+type batchedWrite struct {
+	fn   func(tx *gorm.DB) error
+	done chan error
+}
+
+// writeBatcher coalesces concurrent writes into a single database transaction.
+// SQLite allows only one writer at a time, so during a burst of payments (e.g. a large multi_pay request)
+// committing once per batch instead of once per write avoids most of the lock contention.
+// Each write runs in its own savepoint so that a failed write does not roll back the rest of its batch.
+type writeBatcher struct {
+	db       *gorm.DB
+	mu       sync.Mutex
+	queue    []*batchedWrite
+	flushing bool
+}
+
+func newWriteBatcher(db *gorm.DB) *writeBatcher {
+	return &writeBatcher{
+		db: db,
+	}
+}
+
+// do blocks until fn has been committed as part of a batch
+func (batcher *writeBatcher) do(fn func(tx *gorm.DB) error) error {
+	write := &batchedWrite{
+		fn:   fn,
+		done: make(chan error, 1),
+	}
+
+	batcher.mu.Lock()
+	batcher.queue = append(batcher.queue, write)
+	if !batcher.flushing {
+		batcher.flushing = true
+		go batcher.flush()
+	}
+	batcher.mu.Unlock()
+
+	return <-write.done
+}
+
+// flush keeps committing batches until there are no more queued writes.
+// Writes that are queued while a batch is being committed go into the next one.
+// Without the batch window the first write of a burst would always be committed on its own.
+func (batcher *writeBatcher) flush() {
+	for {
+		time.Sleep(batchWindow)
+
+		batcher.mu.Lock()
+		if len(batcher.queue) == 0 {
+			batcher.flushing = false
+			batcher.mu.Unlock()
+			return
+		}
+		batchSize := min(len(batcher.queue), maxBatchedWrites)
+		writes := batcher.queue[:batchSize:batchSize]
+		batcher.queue = append([]*batchedWrite{}, batcher.queue[batchSize:]...)
+		batcher.mu.Unlock()
+
+		batcher.commit(writes)
+	}
+}
+
+func (batcher *writeBatcher) commit(writes []*batchedWrite) {
+	results := make([]error, len(writes))
+	err := batcher.db.Transaction(func(tx *gorm.DB) error {
+		for i, write := range writes {
+			results[i] = tx.Transaction(write.fn)
+		}
+		return nil
+	})
+
+	for i, write := range writes {
+		if err != nil {
+			// nothing in the batch was saved
+			results[i] = err
+		}
+		write.done <- results[i]
+	}
+}
+
+func (svc *transactionsService) batchedTransitionState(transaction *db.Transaction, to string, updates map[string]interface{}) error {
+	return svc.writeBatcher.do(func(tx *gorm.DB) error {
+		return transitionState(tx, transaction, to, updates)
+	})
+}
@@ -0,0 +1,133 @@
+package transactions
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/getAlby/hub/constants"
+	"github.com/getAlby/hub/db"
+	"github.com/getAlby/hub/lnclient"
+	"github.com/getAlby/hub/tests"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestWriteBatcher_FailedWriteIsRolledBack(t *testing.T) {
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	batcher := newWriteBatcher(svc.DB)
+
+	var wg sync.WaitGroup
+	results := make([]error, 20)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = batcher.do(func(tx *gorm.DB) error {
+				err := tx.Create(&db.App{Name: "test", NostrPubkey: strconv.Itoa(i)}).Error
+				if err != nil {
+					return err
+				}
+				if i%2 == 1 {
+					return errors.New("failed")
+				}
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		if i%2 == 1 {
+			assert.Error(t, result)
+		} else {
+			assert.NoError(t, result)
+		}
+	}
+
+	var count int64
+	svc.DB.Model(&db.App{}).Count(&count)
+	assert.Equal(t, int64(10), count)
+}
+
+func TestSendKeysend_Burst(t *testing.T) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	assert.NoError(t, err)
+
+	app, _, err := tests.CreateApp(svc)
+	assert.NoError(t, err)
+	err = svc.DB.Create(&db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.PAY_INVOICE_SCOPE,
+	}).Error
+	assert.NoError(t, err)
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			transaction, err := transactionsService.SendKeysend(ctx, uint64(1000), "fake destination", []lnclient.TLVRecord{}, "", svc.LNClient, &app.ID, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, constants.TRANSACTION_STATE_SETTLED, transaction.State)
+		}()
+	}
+	wg.Wait()
+
+	var count int64
+	svc.DB.Model(&db.Transaction{}).Where("app_id = ? AND state = ?", app.ID, constants.TRANSACTION_STATE_SETTLED).Count(&count)
+	assert.Equal(t, int64(200), count)
+}
+
+func BenchmarkSendKeysend_Burst(b *testing.B) {
+	ctx := context.TODO()
+
+	defer tests.RemoveTestService()
+	svc, err := tests.CreateTestService()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	app, _, err := tests.CreateApp(svc)
+	if err != nil {
+		b.Fatal(err)
+	}
+	err = svc.DB.Create(&db.AppPermission{
+		AppId: app.ID,
+		App:   *app,
+		Scope: constants.PAY_INVOICE_SCOPE,
+	}).Error
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	transactionsService := NewTransactionsService(svc.DB, svc.Cfg, svc.EventPublisher)
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		// the size of the largest multi_pay_keysend batches
+		var wg sync.WaitGroup
+		for i := 0; i < 1000; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := transactionsService.SendKeysend(ctx, uint64(1000), "fake destination", []lnclient.TLVRecord{}, "", svc.LNClient, &app.ID, nil)
+				if err != nil {
+					b.Error(err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// time based one-time passwords (RFC 6238) as used by authenticator apps:
+// 6 digits, a 30 second period and HMAC-SHA1
+const (
+	totpDigits = 6
+	totpPeriod = 30
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTotpSecret returns a new base32 encoded secret to add to an authenticator app
+func GenerateTotpSecret() (string, error) {
+	secret := make([]byte, 20)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TotpUri returns the otpauth uri of the secret, which authenticator apps scan as a QR code
+func TotpUri(secret string, issuer string, account string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+func totpCode(key []byte, counter uint64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// GenerateTotpCode returns the code that an authenticator app shows for the secret at the given time
+func GenerateTotpCode(secret string, now time.Time) (string, error) {
+	key, err := decodeTotpSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return totpCode(key, uint64(now.Unix()/totpPeriod)), nil
+}
+
+func decodeTotpSecret(secret string) ([]byte, error) {
+	return totpEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+}
+
+// ValidateTotpCode checks the code of the secret at the given time.
+// The codes of the previous and next period are accepted too, for clocks that are slightly off.
+func ValidateTotpCode(secret string, code string, now time.Time) bool {
+	key, err := decodeTotpSecret(secret)
+	if err != nil || len(code) != totpDigits {
+		return false
+	}
+	counter := uint64(now.Unix() / totpPeriod)
+	valid := false
+	for _, step := range []uint64{counter - 1, counter, counter + 1} {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			valid = true
+		}
+	}
+	return valid
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// the SHA1 test vectors of RFC 6238, truncated to 6 digits
+func TestValidateTotpCode(t *testing.T) {
+	secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	assert.True(t, ValidateTotpCode(secret, "287082", time.Unix(59, 0)))
+	assert.True(t, ValidateTotpCode(secret, "081804", time.Unix(1111111109, 0)))
+	assert.True(t, ValidateTotpCode(secret, "005924", time.Unix(1234567890, 0)))
+
+	// the previous and next period are accepted
+	assert.True(t, ValidateTotpCode(secret, "005924", time.Unix(1234567890+totpPeriod, 0)))
+	assert.True(t, ValidateTotpCode(secret, "005924", time.Unix(1234567890-totpPeriod, 0)))
+	assert.False(t, ValidateTotpCode(secret, "005924", time.Unix(1234567890+3*totpPeriod, 0)))
+
+	assert.False(t, ValidateTotpCode(secret, "005925", time.Unix(1234567890, 0)))
+	assert.False(t, ValidateTotpCode(secret, "", time.Unix(1234567890, 0)))
+	assert.False(t, ValidateTotpCode("not base32!", "005924", time.Unix(1234567890, 0)))
+}
+
+func TestGenerateTotpSecret(t *testing.T) {
+	secret, err := GenerateTotpSecret()
+	assert.NoError(t, err)
+	assert.Len(t, secret, 32)
+	assert.NotEqual(t, "", TotpUri(secret, "Alby Hub", "payments"))
+
+	code, err := GenerateTotpCode(secret, time.Now())
+	assert.NoError(t, err)
+	assert.True(t, ValidateTotpCode(secret, code, time.Now()))
+}
@@ -3,6 +3,7 @@ package wails
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -49,6 +50,266 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 		return WailsRequestRouterResponse{Body: nil, Error: ""}
 	}
 
+	featureRegex := regexp.MustCompile(
+		`/api/features/([a-z_]+)`,
+	)
+
+	featureMatch := featureRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(featureMatch) > 1 && method == "PATCH":
+		feature := featureMatch[1]
+		updateFeatureRequest := &api.UpdateFeatureRequest{}
+		err := json.Unmarshal([]byte(body), updateFeatureRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		err = app.api.UpdateFeature(feature, updateFeatureRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to update feature")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	}
+
+	paymentConfirmationRegex := regexp.MustCompile(
+		`/api/payment-confirmations/([0-9]+)`,
+	)
+
+	paymentConfirmationMatch := paymentConfirmationRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(paymentConfirmationMatch) > 1 && method == "POST":
+		transactionId, err := strconv.ParseUint(paymentConfirmationMatch[1], 10, 64)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		confirmPaymentRequest := &api.ConfirmPaymentRequest{}
+		err = json.Unmarshal([]byte(body), confirmPaymentRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		err = app.api.ConfirmPayment(uint(transactionId), confirmPaymentRequest)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	}
+
+	transactionTagsRegex := regexp.MustCompile(
+		`/api/transactions/([0-9]+)/tags$`,
+	)
+
+	transactionTagsMatch := transactionTagsRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(transactionTagsMatch) > 1 && method == "PUT":
+		transactionId, err := strconv.ParseUint(transactionTagsMatch[1], 10, 64)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		setTransactionTagsRequest := &api.SetTransactionTagsRequest{}
+		err = json.Unmarshal([]byte(body), setTransactionTagsRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		err = app.api.SetTransactionTags(uint(transactionId), setTransactionTagsRequest)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	}
+
+	transactionNoteRegex := regexp.MustCompile(
+		`/api/transactions/([0-9]+)/note$`,
+	)
+
+	transactionNoteMatch := transactionNoteRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(transactionNoteMatch) > 1 && method == "PUT":
+		transactionId, err := strconv.ParseUint(transactionNoteMatch[1], 10, 64)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		setTransactionNoteRequest := &api.SetTransactionNoteRequest{}
+		err = json.Unmarshal([]byte(body), setTransactionNoteRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		err = app.api.SetTransactionNote(uint(transactionId), setTransactionNoteRequest)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	}
+
+	tagRuleRegex := regexp.MustCompile(
+		`/api/tag-rules/([0-9]+)$`,
+	)
+
+	tagRuleMatch := tagRuleRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(tagRuleMatch) > 1 && method == "DELETE":
+		id, err := strconv.ParseUint(tagRuleMatch[1], 10, 64)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		err = app.api.DeleteTagRule(uint(id))
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	}
+
+	appLNURLWithdrawRegex := regexp.MustCompile(
+		`/api/apps/([0-9a-f]+)/lnurl-withdraws`,
+	)
+
+	appLNURLWithdrawMatch := appLNURLWithdrawRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(appLNURLWithdrawMatch) > 1 && method == "POST":
+		pubkey := appLNURLWithdrawMatch[1]
+
+		dbApp := db.App{}
+		findResult := app.db.Where("nostr_pubkey = ?", pubkey).First(&dbApp)
+
+		if findResult.RowsAffected == 0 {
+			return WailsRequestRouterResponse{Body: nil, Error: "App does not exist"}
+		}
+
+		createLNURLWithdrawRequest := &api.CreateLNURLWithdrawRequest{}
+		err := json.Unmarshal([]byte(body), createLNURLWithdrawRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		createLNURLWithdrawResponse, err := app.api.CreateLNURLWithdraw(&dbApp, createLNURLWithdrawRequest)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: createLNURLWithdrawResponse, Error: ""}
+	}
+
+	appRequestsRegex := regexp.MustCompile(
+		`/api/apps/([0-9a-f]+)/requests`,
+	)
+
+	appRequestsMatch := appRequestsRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(appRequestsMatch) > 1 && method == "GET":
+		pubkey := appRequestsMatch[1]
+
+		dbApp := db.App{}
+		findResult := app.db.Where("nostr_pubkey = ?", pubkey).First(&dbApp)
+
+		if findResult.RowsAffected == 0 {
+			return WailsRequestRouterResponse{Body: nil, Error: "App does not exist"}
+		}
+
+		limit := uint64(20)
+		offset := uint64(0)
+
+		paramRegex := regexp.MustCompile(`[?&](limit|offset)=([^&]+)`)
+		paramMatches := paramRegex.FindAllStringSubmatch(route, -1)
+		for _, match := range paramMatches {
+			switch match[1] {
+			case "limit":
+				if parsedLimit, err := strconv.ParseUint(match[2], 10, 64); err == nil {
+					limit = parsedLimit
+				}
+			case "offset":
+				if parsedOffset, err := strconv.ParseUint(match[2], 10, 64); err == nil {
+					offset = parsedOffset
+				}
+			}
+		}
+
+		appRequests, err := app.api.ListAppRequests(&dbApp, limit, offset)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: appRequests, Error: ""}
+	}
+
+	nip47UsageRegex := regexp.MustCompile(
+		`^/api/nip47/usage(\?days=([0-9]+))?$`,
+	)
+
+	nip47UsageMatch := nip47UsageRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(nip47UsageMatch) > 0 && method == "GET":
+		days := uint64(0)
+		if nip47UsageMatch[2] != "" {
+			parsedDays, err := strconv.ParseUint(nip47UsageMatch[2], 10, 64)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			days = parsedDays
+		}
+		usage, err := app.api.GetNip47Usage(days)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: usage, Error: ""}
+	}
+
+	appRegenerateSecretRegex := regexp.MustCompile(
+		`/api/apps/([0-9a-f]+)/regenerate-secret`,
+	)
+
+	appRegenerateSecretMatch := appRegenerateSecretRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(appRegenerateSecretMatch) > 1 && method == "POST":
+		pubkey := appRegenerateSecretMatch[1]
+
+		dbApp := db.App{}
+		findResult := app.db.Where("nostr_pubkey = ?", pubkey).First(&dbApp)
+
+		if findResult.RowsAffected == 0 {
+			return WailsRequestRouterResponse{Body: nil, Error: "App does not exist"}
+		}
+
+		regenerateAppSecretResponse, err := app.api.RegenerateAppSecret(&dbApp)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: regenerateAppSecretResponse, Error: ""}
+	}
+
 	appRegex := regexp.MustCompile(
 		`/api/apps/([0-9a-f]+)`,
 	)
@@ -154,6 +415,77 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 		}
 	}
 
+	watchtowerRegex := regexp.MustCompile(
+		`/api/watchtowers/([^/]+)`,
+	)
+
+	watchtowerMatch := watchtowerRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(watchtowerMatch) == 2:
+		pubkey := watchtowerMatch[1]
+		switch method {
+		case "DELETE":
+			err := app.api.RemoveWatchtower(ctx, pubkey)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: nil, Error: ""}
+		}
+	}
+
+	reportRegex := regexp.MustCompile(
+		`/api/reports/(\d+)/(\d+)(/export\?format=(html|pdf))?$`,
+	)
+
+	reportMatch := reportRegex.FindStringSubmatch(route)
+
+	switch {
+	case len(reportMatch) == 5:
+		// the pattern only matches numbers
+		year, _ := strconv.Atoi(reportMatch[1])
+		month, _ := strconv.Atoi(reportMatch[2])
+		format := reportMatch[4]
+		if format == "" {
+			report, err := app.api.GetMonthlyReport(ctx, year, month)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: report, Error: ""}
+		}
+
+		saveFilePath, err := runtime.SaveFileDialog(ctx, runtime.SaveDialogOptions{
+			Title:           "Save Report",
+			DefaultFilename: fmt.Sprintf("report-%d-%02d.%s", year, month, format),
+		})
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to open save file dialog")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		if saveFilePath == "" {
+			return WailsRequestRouterResponse{Body: nil, Error: "no file selected"}
+		}
+
+		report, err := app.api.ExportMonthlyReport(ctx, year, month, format)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		err = os.WriteFile(saveFilePath, report, 0600)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to write report file")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	}
+
 	networkGraphRegex := regexp.MustCompile(
 		`/api/node/network-graph\?nodeIds=(.+)`,
 	)
@@ -202,6 +534,40 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 		return WailsRequestRouterResponse{Body: paymentInfo, Error: ""}
 	}
 
+	listActivitiesRegex := regexp.MustCompile(
+		`/api/activities`,
+	)
+
+	switch {
+	case listActivitiesRegex.MatchString(route):
+		cursor := uint64(0)
+		limit := uint64(0)
+		types := []string{}
+
+		paramRegex := regexp.MustCompile(`[?&](cursor|limit|types)=([^&]+)`)
+		paramMatches := paramRegex.FindAllStringSubmatch(route, -1)
+		for _, match := range paramMatches {
+			switch match[1] {
+			case "cursor":
+				if parsedCursor, err := strconv.ParseUint(match[2], 10, 64); err == nil {
+					cursor = parsedCursor
+				}
+			case "limit":
+				if parsedLimit, err := strconv.ParseUint(match[2], 10, 64); err == nil {
+					limit = parsedLimit
+				}
+			case "types":
+				types = strings.Split(match[2], ",")
+			}
+		}
+
+		activities, err := app.api.ListActivities(uint(cursor), limit, types)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: activities, Error: ""}
+	}
+
 	listTransactionsRegex := regexp.MustCompile(
 		`/api/transactions`,
 	)
@@ -210,9 +576,11 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 	case listTransactionsRegex.MatchString(route):
 		limit := uint64(20)
 		offset := uint64(0)
+		tag := ""
+		query := ""
 
-		// Extract limit and offset parameters
-		paramRegex := regexp.MustCompile(`[?&](limit|offset)=([^&]+)`)
+		// Extract limit, offset, tag and search query parameters
+		paramRegex := regexp.MustCompile(`[?&](limit|offset|tag|q)=([^&]+)`)
 		paramMatches := paramRegex.FindAllStringSubmatch(route, -1)
 		for _, match := range paramMatches {
 			switch match[1] {
@@ -224,10 +592,18 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 				if parsedOffset, err := strconv.ParseUint(match[2], 10, 64); err == nil {
 					offset = parsedOffset
 				}
+			case "tag":
+				if parsedTag, err := url.QueryUnescape(match[2]); err == nil {
+					tag = parsedTag
+				}
+			case "q":
+				if parsedQuery, err := url.QueryUnescape(match[2]); err == nil {
+					query = parsedQuery
+				}
 			}
 		}
 
-		transactions, err := app.api.ListTransactions(ctx, limit, offset)
+		transactions, err := app.api.ListTransactions(ctx, limit, offset, tag, query)
 		if err != nil {
 			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
 		}
@@ -242,7 +618,19 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 	switch {
 	case len(invoiceMatch) > 1:
 		invoice := invoiceMatch[1]
-		paymentResponse, err := app.api.SendPayment(ctx, invoice)
+		sendPaymentRequest := &api.SendPaymentRequest{}
+		if body != "" {
+			err := json.Unmarshal([]byte(body), sendPaymentRequest)
+			if err != nil {
+				logger.Logger.WithFields(logrus.Fields{
+					"route":  route,
+					"method": method,
+					"body":   body,
+				}).WithError(err).Error("Failed to decode request to wails router")
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+		}
+		paymentResponse, err := app.api.SendPayment(ctx, invoice, sendPaymentRequest)
 		if err != nil {
 			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
 		}
@@ -393,6 +781,34 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 		}
 		res := WailsRequestRouterResponse{Body: *balancesResponse, Error: ""}
 		return res
+	case "/api/notifications":
+		notifications, err := app.api.ListNotifications()
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: *notifications, Error: ""}
+	case "/api/notifications/read":
+		markNotificationsReadRequest := &api.MarkNotificationsReadRequest{}
+		err := json.Unmarshal([]byte(body), markNotificationsReadRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		err = app.api.MarkNotificationsRead(markNotificationsReadRequest)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	case "/api/wallet/summary":
+		summary, err := app.api.GetWalletSummary(ctx)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: *summary, Error: ""}
 	case "/api/invoices":
 		makeInvoiceRequest := &api.MakeInvoiceRequest{}
 		err := json.Unmarshal([]byte(body), makeInvoiceRequest)
@@ -410,6 +826,125 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 		}
 		res := WailsRequestRouterResponse{Body: invoice, Error: ""}
 		return res
+	case "/api/offers":
+		switch method {
+		case "GET":
+			offers, err := app.api.ListOffers(ctx)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: offers, Error: ""}
+		case "POST":
+			createOfferRequest := &api.CreateOfferRequest{}
+			err := json.Unmarshal([]byte(body), createOfferRequest)
+			if err != nil {
+				logger.Logger.WithFields(logrus.Fields{
+					"route":  route,
+					"method": method,
+					"body":   body,
+				}).WithError(err).Error("Failed to decode request to wails router")
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			offer, err := app.api.CreateOffer(ctx, createOfferRequest)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: offer, Error: ""}
+		}
+	case "/api/transaction-tags":
+		tags, err := app.api.ListTransactionTags()
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: tags, Error: ""}
+	case "/api/tag-rules":
+		switch method {
+		case "GET":
+			rules, err := app.api.ListTagRules()
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: rules, Error: ""}
+		case "POST":
+			createTagRuleRequest := &api.CreateTagRuleRequest{}
+			err := json.Unmarshal([]byte(body), createTagRuleRequest)
+			if err != nil {
+				logger.Logger.WithFields(logrus.Fields{
+					"route":  route,
+					"method": method,
+					"body":   body,
+				}).WithError(err).Error("Failed to decode request to wails router")
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			rule, err := app.api.CreateTagRule(createTagRuleRequest)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: rule, Error: ""}
+		}
+	case "/api/swaps":
+		swaps, err := app.api.ListSwaps(ctx)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: swaps, Error: ""}
+	case "/api/swaps/in":
+		swapInRequest := &api.SwapInRequest{}
+		err := json.Unmarshal([]byte(body), swapInRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		swap, err := app.api.SwapIn(ctx, swapInRequest)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: swap, Error: ""}
+	case "/api/swaps/out":
+		swapOutRequest := &api.SwapOutRequest{}
+		err := json.Unmarshal([]byte(body), swapOutRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		swap, err := app.api.SwapOut(ctx, swapOutRequest)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: swap, Error: ""}
+	case "/api/swaps/auto":
+		switch method {
+		case "GET":
+			autoSwapConfig, err := app.api.GetAutoSwapConfig()
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: autoSwapConfig, Error: ""}
+		case "PATCH":
+			autoSwapConfig := &api.AutoSwapConfig{}
+			err := json.Unmarshal([]byte(body), autoSwapConfig)
+			if err != nil {
+				logger.Logger.WithFields(logrus.Fields{
+					"route":  route,
+					"method": method,
+					"body":   body,
+				}).WithError(err).Error("Failed to decode request to wails router")
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			err = app.api.UpdateAutoSwapConfig(autoSwapConfig)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: nil, Error: ""}
+		}
 	case "/api/wallet/sync":
 		app.api.SyncWallet()
 		return WailsRequestRouterResponse{Body: nil, Error: ""}
@@ -437,7 +972,7 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
 		}
 
-		redeemOnchainFundsResponse, err := app.api.RedeemOnchainFunds(ctx, redeemOnchainFundsRequest.ToAddress)
+		redeemOnchainFundsResponse, err := app.api.RedeemOnchainFunds(ctx, redeemOnchainFundsRequest.ToAddress, redeemOnchainFundsRequest.Amount, redeemOnchainFundsRequest.FeeRate, redeemOnchainFundsRequest.SendAll)
 		if err != nil {
 			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
 		}
@@ -489,6 +1024,31 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 			}
 			return WailsRequestRouterResponse{Body: nil, Error: ""}
 		}
+	case "/api/watchtowers":
+		switch method {
+		case "GET":
+			watchtowers, err := app.api.ListWatchtowers(ctx)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: watchtowers, Error: ""}
+		case "POST":
+			addWatchtowerRequest := &api.AddWatchtowerRequest{}
+			err := json.Unmarshal([]byte(body), addWatchtowerRequest)
+			if err != nil {
+				logger.Logger.WithFields(logrus.Fields{
+					"route":  route,
+					"method": method,
+					"body":   body,
+				}).WithError(err).Error("Failed to decode request to wails router")
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			err = app.api.AddWatchtower(ctx, addWatchtowerRequest)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: nil, Error: ""}
+		}
 	case "/api/node/connection-info":
 		nodeConnectionInfo, err := app.api.GetNodeConnectionInfo(ctx)
 		if err != nil {
@@ -501,6 +1061,10 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
 		}
 		return WailsRequestRouterResponse{Body: *nodeStatus, Error: ""}
+	case "/api/payment-confirmations":
+		return WailsRequestRouterResponse{Body: app.api.ListPaymentConfirmations(), Error: ""}
+	case "/api/features":
+		return WailsRequestRouterResponse{Body: *app.api.ListFeatures(), Error: ""}
 	case "/api/info":
 		infoResponse, err := app.api.GetInfo(ctx)
 		if err != nil {
@@ -547,6 +1111,27 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 		infoResponse := app.api.GetEncryptedMnemonic()
 		res := WailsRequestRouterResponse{Body: *infoResponse, Error: ""}
 		return res
+	case "/api/log-settings":
+		switch method {
+		case "GET":
+			return WailsRequestRouterResponse{Body: app.api.GetLogSettings(), Error: ""}
+		case "PATCH":
+			updateLogSettingsRequest := &api.UpdateLogSettingsRequest{}
+			err := json.Unmarshal([]byte(body), updateLogSettingsRequest)
+			if err != nil {
+				logger.Logger.WithFields(logrus.Fields{
+					"route":  route,
+					"method": method,
+					"body":   body,
+				}).WithError(err).Error("Failed to decode request to wails router")
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			logSettings, err := app.api.UpdateLogSettings(updateLogSettingsRequest)
+			if err != nil {
+				return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+			}
+			return WailsRequestRouterResponse{Body: logSettings, Error: ""}
+		}
 	case "/api/backup-reminder":
 		backupReminderRequest := &api.BackupReminderRequest{}
 		err := json.Unmarshal([]byte(body), backupReminderRequest)
@@ -591,6 +1176,48 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
 		}
 		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	case "/api/keys":
+		return WailsRequestRouterResponse{Body: *app.api.GetNostrKeys(), Error: ""}
+	case "/api/payment-confirmations/authenticator":
+		setupRequest := &api.SetupPaymentConfirmationAuthenticatorRequest{}
+		err := json.Unmarshal([]byte(body), setupRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		setupResponse, err := app.api.SetupPaymentConfirmationAuthenticator(setupRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+			}).WithError(err).Error("Failed to set up authenticator")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: *setupResponse, Error: ""}
+	case "/api/keys/rotate":
+		rotateNostrKeysRequest := &api.RotateNostrKeysRequest{}
+		err := json.Unmarshal([]byte(body), rotateNostrKeysRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		nostrKeys, err := app.api.RotateNostrKeys(ctx, rotateNostrKeysRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+			}).WithError(err).Error("Failed to rotate keys")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: *nostrKeys, Error: ""}
 	case "/api/start":
 		startRequest := &api.StartRequest{}
 		err := json.Unmarshal([]byte(body), startRequest)
@@ -725,6 +1352,65 @@ func (app *WailsApp) WailsRequestRouter(route string, method string, body string
 			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
 		}
 		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	case "/api/channel-backup":
+		status, err := app.api.GetChannelBackupStatus(ctx)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: status, Error: ""}
+	case "/api/channel-backup/export":
+		saveFilePath, err := runtime.SaveFileDialog(ctx, runtime.SaveDialogOptions{
+			Title:           "Save Channel Backup File",
+			DefaultFilename: "channel-backup.json",
+		})
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to open save file dialog")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		if saveFilePath == "" {
+			return WailsRequestRouterResponse{Body: nil, Error: "no file selected"}
+		}
+
+		backupFile, err := app.api.ExportChannelBackup(ctx)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to export channel backup")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+
+		err = os.WriteFile(saveFilePath, backupFile, 0600)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to write channel backup file")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: nil, Error: ""}
+	case "/api/channel-backup/verify":
+		verifyChannelBackupRequest := &api.VerifyChannelBackupRequest{}
+		err := json.Unmarshal([]byte(body), verifyChannelBackupRequest)
+		if err != nil {
+			logger.Logger.WithFields(logrus.Fields{
+				"route":  route,
+				"method": method,
+				"body":   body,
+			}).WithError(err).Error("Failed to decode request to wails router")
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		verifyChannelBackupResponse, err := app.api.VerifyChannelBackup(ctx, verifyChannelBackupRequest)
+		if err != nil {
+			return WailsRequestRouterResponse{Body: nil, Error: err.Error()}
+		}
+		return WailsRequestRouterResponse{Body: verifyChannelBackupResponse, Error: ""}
 	case "/api/restore":
 		restoreRequest := &api.BasicRestoreWailsRequest{}
 		err := json.Unmarshal([]byte(body), restoreRequest)